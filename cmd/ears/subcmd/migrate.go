@@ -0,0 +1,167 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/app"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/fx/fragmentstorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/routestorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/tenantstorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/migrate"
+	"github.com/xmidt-org/ears/pkg/cli"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copies routes, fragments and tenant configs between storers",
+	Long: `Copies all routes, fragments and tenant configs from one storage type to another and verifies the copy,
+e.g. ears migrate --from dynamodb --to postgres. Both sides use the ears.storage settings, settings under
+ears.migrate.from and ears.migrate.to override them for one side, e.g. ears.migrate.to.route.tableName.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runMigration(viper.GetViper())
+		if err != nil {
+			log.Fatal().Str("op", "migrate").Msg(err.Error())
+		}
+	},
+}
+
+// migrationConfig reads the storage settings of one side of a migration, e.g. ears.storage.route.tableName is
+// read from ears.migrate.to.route.tableName if set and the storage type of all storers from ears.migrate.to.type
+type migrationConfig struct {
+	config.Config
+	side string
+}
+
+func (c *migrationConfig) override(key string) string {
+	if !strings.HasPrefix(key, "ears.storage.") {
+		return ""
+	}
+	return "ears.migrate." + c.side + "." + strings.TrimPrefix(key, "ears.storage.")
+}
+
+func (c *migrationConfig) GetString(key string) string {
+	if o := c.override(key); o != "" {
+		if val := c.Config.GetString(o); val != "" {
+			return val
+		}
+		if strings.HasSuffix(key, ".type") {
+			if val := c.Config.GetString("ears.migrate." + c.side + ".type"); val != "" {
+				return val
+			}
+		}
+	}
+	return c.Config.GetString(key)
+}
+
+func (c *migrationConfig) GetInt(key string) int {
+	if o := c.override(key); o != "" {
+		if val := c.Config.GetInt(o); val != 0 {
+			return val
+		}
+	}
+	return c.Config.GetInt(key)
+}
+
+func (c *migrationConfig) GetBool(key string) bool {
+	if o := c.override(key); o != "" && c.Config.GetBool(o) {
+		return true
+	}
+	return c.Config.GetBool(key)
+}
+
+func migrationStorers(cfg config.Config, side string) (migrate.Storers, error) {
+	storers := migrate.Storers{}
+	sideConfig := &migrationConfig{cfg, side}
+	logger, err := app.ProvideLogger(cfg)
+	if err != nil {
+		return storers, err
+	}
+	routeOut, err := routestorerfx.ProvideRouteStorer(routestorerfx.StorageIn{Config: sideConfig, Logger: logger})
+	if err != nil {
+		return storers, err
+	}
+	fragmentOut, err := fragmentstorerfx.ProvideFragmentStorer(fragmentstorerfx.StorageIn{Config: sideConfig, Logger: logger})
+	if err != nil {
+		return storers, err
+	}
+	tenantOut, err := tenantstorerfx.ProvideTenantStorer(tenantstorerfx.StorageIn{Config: sideConfig, Logger: logger})
+	if err != nil {
+		return storers, err
+	}
+	storers.Routes = routeOut.RouteStorer
+	storers.Fragments = fragmentOut.FragmentStorer
+	storers.Tenants = tenantOut.TenantStorer
+	return storers, nil
+}
+
+func runMigration(cfg config.Config) error {
+	if cfg.GetString("ears.migrate.from.type") == "" || cfg.GetString("ears.migrate.to.type") == "" {
+		return errors.New("both --from and --to are required")
+	}
+	from, err := migrationStorers(cfg, "from")
+	if err != nil {
+		return err
+	}
+	to, err := migrationStorers(cfg, "to")
+	if err != nil {
+		return err
+	}
+	dryRun := cfg.GetBool("ears.migrate.dryRun")
+	result, err := migrate.Migrate(context.Background(), from, to, dryRun)
+	if err != nil {
+		return err
+	}
+	buf, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(buf))
+	if len(result.Mismatches) > 0 && !dryRun {
+		return errors.New("verification failed, target differs from source")
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	cli.ViperAddArguments(
+		migrateCmd,
+		[]cli.Argument{
+			cli.Argument{
+				Name: "from", Shorthand: "", Type: cli.ArgTypeString,
+				Default: "", LookupKey: "ears.migrate.from.type",
+				Description: "storage type to copy from, e.g. dynamodb",
+			},
+			cli.Argument{
+				Name: "to", Shorthand: "", Type: cli.ArgTypeString,
+				Default: "", LookupKey: "ears.migrate.to.type",
+				Description: "storage type to copy to, e.g. postgres",
+			},
+			cli.Argument{
+				Name: "dryRun", Shorthand: "", Type: cli.ArgTypeBool,
+				Default: false, LookupKey: "ears.migrate.dryRun",
+				Description: "only report the items that would be copied or changed",
+			},
+		},
+	)
+}
@@ -20,8 +20,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/xmidt-org/ears/internal/pkg/app"
-	"github.com/xmidt-org/ears/internal/pkg/appsecret"
 	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/fx/auditstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/checkpointmanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/fragmentstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/jwtmanagerfx"
@@ -29,6 +29,8 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/fx/pluginmanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/quotamanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/routestorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/secretvaultfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/snapshotfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/syncerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/tenantstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
@@ -58,7 +60,9 @@ var runCmd = &cobra.Command{
 		//zerolog only logs at debug level when using Printf. If
 		//we don't use a separate zerolog logger, the error
 		//message is suppressed if logLevel is above debug
-		initLogger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+		//The log level is set globally by the app logger, so the init logger logs
+		//without a level to not be filtered by it either
+		initLogger := fxPrinter{zerolog.New(os.Stdout).With().Timestamp().Logger()}
 		zerolog.LevelFieldName = "log.level"
 
 		earsApp := fx.New(
@@ -71,14 +75,18 @@ var runCmd = &cobra.Command{
 			nodestatemanagerfx.Module,
 			checkpointmanagerfx.Module,
 			jwtmanagerfx.Module,
+			snapshotfx.Module,
+			auditstorerfx.Module,
+			secretvaultfx.Module,
 			fx.Provide(
 				AppConfig,
-				appsecret.NewConfigVault,
 				app.ProvideLogger,
 				tablemgr.NewRoutingTableManager,
 				app.NewAPIManager,
 				app.NewMiddleware,
 				app.NewMux,
+				AppConfigReader,
+				app.NewConfigReloader,
 			),
 			fx.Logger(&initLogger),
 			fx.Invoke(syncerfx.SetupDeltaSyncer),
@@ -86,9 +94,14 @@ var runCmd = &cobra.Command{
 			fx.Invoke(quotamanagerfx.SetupQuotaManager),
 			fx.Invoke(app.SetupOpenTelemetry),
 			fx.Invoke(app.SetupAPIServer),
-			fx.Invoke(app.SetupPprof),
+			fx.Invoke(app.SetupAdminServer),
 			fx.Invoke(app.SetupNodeStateManager),
 			fx.Invoke(app.SetupCheckpointManager),
+			fx.Invoke(snapshotfx.SetupSnapshotManager),
+			fx.Invoke(secretvaultfx.SetupSecretVault),
+			fx.Invoke(app.SetupConfigReload),
+			fx.Invoke(app.SetupQuotaAlerts),
+			fx.Invoke(app.SetupSystemEvents),
 		)
 		earsApp.Run()
 	},
@@ -98,6 +111,20 @@ func AppConfig() config.Config {
 	return viper.GetViper()
 }
 
+// AppConfigReader reads ears.yaml again into the app config
+func AppConfigReader() app.ConfigReader {
+	return cli.ViperReloadConfig
+}
+
+// fxPrinter logs uberfx initialization messages regardless of the log level
+type fxPrinter struct {
+	logger zerolog.Logger
+}
+
+func (p fxPrinter) Printf(format string, args ...interface{}) {
+	p.logger.Log().Msgf(format, args...)
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 	cli.ViperAddArguments(
@@ -0,0 +1,139 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/xmidt-org/ears/internal/pkg/app"
+	"github.com/xmidt-org/ears/internal/pkg/fx/fragmentstorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/routestorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/snapshotfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/tenantstorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
+	"go.uber.org/fx"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Manages snapshots of routes, fragments and tenant configs",
+	Long:  `Takes, lists and restores snapshots of routes, fragments and tenant configs stored at ears.snapshot.url`,
+}
+
+var snapshotTakeCmd = &cobra.Command{
+	Use:   "take",
+	Short: "Takes a snapshot",
+	Long:  `Takes a snapshot of all routes, fragments and tenant configs in the configured storers`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withSnapshotManager(func(m *snapshot.SnapshotManager) error {
+			url, err := m.Save(context.Background(), time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Println(url)
+			return nil
+		})
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists snapshots",
+	Long:  `Lists the urls of all snapshots, newest first`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withSnapshotManager(func(m *snapshot.SnapshotManager) error {
+			urls, err := m.List(context.Background())
+			if err != nil {
+				return err
+			}
+			for _, url := range urls {
+				fmt.Println(url)
+			}
+			return nil
+		})
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore [url]",
+	Short: "Restores a snapshot",
+	Long: `Restores a snapshot, by default the latest one, into the configured storers. Running instances pick up
+restored routes with their periodic sync, use the restore endpoint of the API to start them right away.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		url := "latest"
+		if len(args) > 0 {
+			url = args[0]
+		}
+		withSnapshotManager(func(m *snapshot.SnapshotManager) error {
+			ctx := context.Background()
+			s, err := m.Load(ctx, url)
+			if err != nil {
+				return err
+			}
+			result := m.Restore(ctx, s, nil)
+			buf, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(buf))
+			if len(result.Errors) > 0 {
+				return errors.New("snapshot partially restored")
+			}
+			return nil
+		})
+	},
+}
+
+// withSnapshotManager creates the configured storers and runs f with a snapshot manager using them
+func withSnapshotManager(f func(m *snapshot.SnapshotManager) error) {
+	var err error
+	snapshotApp := fx.New(
+		routestorerfx.Module,
+		fragmentstorerfx.Module,
+		tenantstorerfx.Module,
+		snapshotfx.Module,
+		fx.Provide(
+			AppConfig,
+			app.ProvideLogger,
+		),
+		fx.NopLogger,
+		fx.Invoke(func(m *snapshot.SnapshotManager) {
+			if m == nil {
+				err = errors.New("snapshots not configured, set ears.snapshot.url")
+				return
+			}
+			err = f(m)
+		}),
+	)
+	if snapshotApp.Err() != nil {
+		err = snapshotApp.Err()
+	}
+	if err != nil {
+		log.Fatal().Str("op", "snapshot").Msg(err.Error())
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotTakeCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+}
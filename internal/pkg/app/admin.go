@@ -0,0 +1,130 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/jwt"
+	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
+	"github.com/xmidt-org/ears/pkg/logs"
+	"go.uber.org/fx"
+)
+
+// MemoryReport combines the memory statistics of the go runtime with the size of the routing table
+type MemoryReport struct {
+	HeapAllocBytes uint64                `json:"heapAllocBytes"`
+	HeapInuseBytes uint64                `json:"heapInuseBytes"`
+	HeapObjects    uint64                `json:"heapObjects"`
+	SysBytes       uint64                `json:"sysBytes"`
+	NumGC          uint32                `json:"numGC"`
+	Goroutines     int                   `json:"goroutines"`
+	RoutingTable   *tablemgr.TableReport `json:"routingTable"`
+}
+
+// NewAdminMux returns the handler of the admin endpoints, callers must present the token of an admin client
+func NewAdminMux(routingTableMgr tablemgr.RoutingTableManager, jwtManager jwt.JWTConsumer, logger *zerolog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		// debug level 2 prints the stack of every goroutine like an unrecovered panic does
+		runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	mux.HandleFunc("/debug/memory", func(w http.ResponseWriter, r *http.Request) {
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+		report := MemoryReport{
+			HeapAllocBytes: memStats.HeapAlloc,
+			HeapInuseBytes: memStats.HeapInuse,
+			HeapObjects:    memStats.HeapObjects,
+			SysBytes:       memStats.Sys,
+			NumGC:          memStats.NumGC,
+			Goroutines:     runtime.NumGoroutine(),
+			RoutingTable:   routingTableMgr.GetTableReport(r.Context()),
+		}
+		resp := ItemResponse(report)
+		resp.Respond(r.Context(), w, doYaml(r))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logs.SubLoggerCtx(r.Context(), logger)
+		// without a tenant only admin clients are authorized
+		_, sub, authErr := jwtManager.VerifyToken(ctx, getBearerToken(r), r.URL.Path, r.Method, nil)
+		if authErr != nil {
+			logger.Error().Str("op", "adminMux").Str("path", r.URL.Path).Str("error", authErr.Error()).Msg("authorization error")
+			resp := ErrorResponse(convertToApiError(ctx, authErr))
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+		logger.Info().Str("op", "adminMux").Str("path", r.URL.Path).Str("sub", sub).Msg("admin request")
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetupAdminServer serves the admin endpoints for diagnosing performance issues on ears.admin.port, the admin server
+// is disabled unless the port is set
+func SetupAdminServer(lifecycle fx.Lifecycle, config config.Config, logger *zerolog.Logger, routingTableMgr tablemgr.RoutingTableManager, jwtManager jwt.JWTConsumer) error {
+	port := config.GetInt("ears.admin.port")
+	if port <= 0 {
+		logger.Info().Int("port", port).Msg("Admin Server disabled")
+		return nil
+	}
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: NewAdminMux(routingTableMgr, jwtManager, logger),
+	}
+	lifecycle.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				// bind the port before serving so that startup fails if the port is taken
+				listener, err := net.Listen("tcp", server.Addr)
+				if err != nil {
+					logger.Error().Str("op", "SetupAdminServer.OnStart").Int("port", port).Str("error", err.Error()).Msg("cannot bind admin port")
+					return err
+				}
+				go func() {
+					err := server.Serve(listener)
+					if err != nil && err != http.ErrServerClosed {
+						logger.Error().Str("op", "SetupAdminServer").Int("port", port).Str("error", err.Error()).Msg("Admin Server failed")
+					}
+				}()
+				logger.Info().Int("port", port).Msg("Admin Server Started")
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				err := server.Shutdown(ctx)
+				if err != nil {
+					logger.Error().Str("op", "SetupAdminServer.OnStop").Msg(err.Error())
+				} else {
+					logger.Info().Msg("Admin Server Stopped")
+				}
+				return nil
+			},
+		},
+	)
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/jwt"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.uber.org/fx/fxtest"
+)
+
+type rejectingJWTConsumer struct{}
+
+func (c *rejectingJWTConsumer) VerifyToken(ctx context.Context, token string, api string, method string, tid *tenant.Id) ([]string, string, error) {
+	return nil, "", &jwt.UnauthorizedError{Msg: jwt.MissingToken}
+}
+
+func TestAdminMux(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	defer func() {
+		r := httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+		runtime.apiManager.muxRouter.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+	jwtMgr, _ := jwt.NewJWTConsumer("", nil, false, "", "", nil, nil, nil)
+	adminMux := NewAdminMux(runtime.routingTableManager, jwtMgr, &log.Logger)
+	w = httptest.NewRecorder()
+	adminMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/memory", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item MemoryReport `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	report := data.Item
+	if report.HeapAllocBytes == 0 || report.Goroutines == 0 || report.RoutingTable == nil {
+		t.Fatalf("unexpected memory report: %s", w.Body.String())
+	}
+	if report.RoutingTable.LiveRoutes != 1 || len(report.RoutingTable.Routes) != 1 || report.RoutingTable.Routes[0].RouteId != "r100" || report.RoutingTable.Routes[0].ConfigBytes == 0 {
+		t.Fatalf("unexpected routing table report: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	adminMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/goroutines", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Fatalf("unexpected goroutine dump %d: %s", w.Code, w.Body.String())
+	}
+	// callers without the token of an admin client are turned away
+	adminMux = NewAdminMux(runtime.routingTableManager, &rejectingJWTConsumer{}, &log.Logger)
+	w = httptest.NewRecorder()
+	adminMux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAdminServerPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("cannot bind port: %s", err.Error())
+	}
+	defer listener.Close()
+	v := viper.New()
+	v.Set("ears.admin.port", listener.Addr().(*net.TCPAddr).Port)
+	lifecycle := fxtest.NewLifecycle(t)
+	err = SetupAdminServer(lifecycle, v, &log.Logger, nil, &rejectingJWTConsumer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if lifecycle.Start(context.Background()) == nil {
+		lifecycle.RequireStop()
+		t.Fatalf("admin server started on a port already in use")
+	}
+}
@@ -22,38 +22,45 @@ import (
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/internal/pkg/config"
-	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
-	"github.com/xmidt-org/ears/pkg/app"
 	"github.com/xmidt-org/ears/pkg/checkpoint"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/sharder"
+	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/export/metric"
+	"go.opentelemetry.io/otel/sdk/export/metric/aggregation"
+	"go.opentelemetry.io/otel/sdk/metric/aggregator/histogram"
 	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
 	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
 	"go.opentelemetry.io/otel/sdk/metric/selector/simple"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.uber.org/fx"
 	"net/http"
-	_ "net/http/pprof"
-	"os"
+	"sync"
 	"time"
 )
 
+const (
+	DefaultPrometheusPort = 9090
+	DefaultPrometheusPath = "/metrics"
+)
+
+// default histogram buckets in milliseconds, suitable for event processing latencies
+var defaultHistogramBoundaries = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
 func NewMux(a *APIManager, middleware []func(next http.Handler) http.Handler) (http.Handler, error) {
 	for _, m := range middleware {
 		a.muxRouter.Use(m)
 	}
-	return a.muxRouter, nil
+	return a.corsMiddleware(a.muxRouter), nil
 }
 
 func SetupCheckpointManager(lifecycle fx.Lifecycle, checkpointManager checkpoint.CheckpointManager, config config.Config, logger *zerolog.Logger) error {
@@ -85,72 +92,249 @@ func SetupNodeStateManager(lifecycle fx.Lifecycle, nodeStateManager sharder.Node
 	return nil
 }
 
-func SetupOpenTelemetry(lifecycle fx.Lifecycle, config config.Config, logger *zerolog.Logger) error {
+// NewPrometheusExporter creates a pull based metrics pipeline, the exporter serves the scrape endpoint
+func NewPrometheusExporter(res *resource.Resource) (*prometheus.Exporter, error) {
+	ctrl := controller.New(
+		processor.New(
+			simple.NewWithHistogramDistribution(
+				histogram.WithExplicitBoundaries(defaultHistogramBoundaries),
+			),
+			sdkmetric.CumulativeExportKindSelector(),
+			processor.WithMemory(true),
+		),
+		controller.WithResource(res),
+	)
+	return prometheus.New(prometheus.Config{DefaultHistogramBoundaries: defaultHistogramBoundaries}, ctrl)
+}
+
+// scrapedMetricsPusher pushes the metrics of the prometheus pipeline to the otel collector or stdout exporter. The
+// controller of the pipeline cannot be started for pushing itself because a started controller refuses the
+// collections triggered by scrapes.
+type scrapedMetricsPusher struct {
+	ctrl     *controller.Controller
+	exporter sdkmetric.Exporter
+	interval time.Duration
+	logger   *zerolog.Logger
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func newScrapedMetricsPusher(ctrl *controller.Controller, exporter sdkmetric.Exporter, interval time.Duration, logger *zerolog.Logger) *scrapedMetricsPusher {
+	return &scrapedMetricsPusher{
+		ctrl:     ctrl,
+		exporter: exporter,
+		interval: interval,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func (p *scrapedMetricsPusher) Start(ctx context.Context) error {
+	go func() {
+		defer close(p.doneCh)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopCh:
+				return
+			case <-ticker.C:
+				p.push(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *scrapedMetricsPusher) Stop(ctx context.Context) error {
+	close(p.stopCh)
+	<-p.doneCh
+	p.push(ctx)
+	return nil
+}
+
+func (p *scrapedMetricsPusher) push(ctx context.Context) {
+	err := p.ctrl.Collect(ctx)
+	if err == nil {
+		err = p.exporter.Export(ctx, p.ctrl.Resource(), controllerCheckpointSet{p.ctrl})
+	}
+	if err != nil {
+		p.logger.Error().Str("op", "scrapedMetricsPusher.push").Str("error", err.Error()).Msg("fail to push metrics")
+	}
+}
+
+// controllerCheckpointSet exposes the latest checkpoint of a controller to an exporter. Scrapes collect in between
+// pushes, so only cumulative values are complete and they are handed out regardless of the export kind the exporter
+// asks for.
+type controllerCheckpointSet struct {
+	ctrl *controller.Controller
+}
+
+func (c controllerCheckpointSet) ForEach(_ sdkmetric.ExportKindSelector, f func(sdkmetric.Record) error) error {
+	return c.ctrl.ForEach(sdkmetric.CumulativeExportKindSelector(), f)
+}
+
+// the controller locks the checkpoint in ForEach
+func (c controllerCheckpointSet) Lock()    {}
+func (c controllerCheckpointSet) Unlock()  {}
+func (c controllerCheckpointSet) RLock()   {}
+func (c controllerCheckpointSet) RUnlock() {}
+
+// reloadableMetricExporter forwards to an otlp metric exporter that can be replaced while the metrics controller
+// keeps running
+type reloadableMetricExporter struct {
+	sync.RWMutex
+	exporter *otlpmetric.Exporter
+}
+
+func (e *reloadableMetricExporter) Export(ctx context.Context, res *resource.Resource, checkpointSet sdkmetric.CheckpointSet) error {
+	e.RLock()
+	defer e.RUnlock()
+	return e.exporter.Export(ctx, res, checkpointSet)
+}
+
+func (e *reloadableMetricExporter) ExportKindFor(descriptor *metric.Descriptor, aggregatorKind aggregation.Kind) sdkmetric.ExportKind {
+	e.RLock()
+	defer e.RUnlock()
+	return e.exporter.ExportKindFor(descriptor, aggregatorKind)
+}
+
+// swap replaces the exporter and returns the previous one
+func (e *reloadableMetricExporter) swap(exporter *otlpmetric.Exporter) *otlpmetric.Exporter {
+	e.Lock()
+	defer e.Unlock()
+	old := e.exporter
+	e.exporter = exporter
+	return old
+}
+
+// telemetryExporters holds the otel collector exporters so that they can be replaced when the collector config changes
+type telemetryExporters struct {
+	sync.Mutex
+	ctx            context.Context
+	otlp           otlpConfig
+	resource       *resource.Resource
+	modes          string
+	traceProvider  *sdktrace.TracerProvider
+	sampler        *traceSampler
+	metricExporter *reloadableMetricExporter
+	logger         *zerolog.Logger
+}
+
+func telemetryExporterModes(config config.Config) string {
+	return fmt.Sprintf("otel-collector=%t,stdout=%t,prometheus=%t",
+		config.GetBool("ears.opentelemetry.otel-collector.active"),
+		config.GetBool("ears.opentelemetry.stdout.active"),
+		config.GetBool("ears.opentelemetry.prometheus.active"))
+}
+
+// ReloadConfig switches the otel collector exporters to a new collector config, changing the exporter itself, the
+// resource attributes or the push interval requires a restart
+func (e *telemetryExporters) ReloadConfig(config config.Config) error {
+	if modes := telemetryExporterModes(config); modes != e.modes {
+		e.logger.Warn().Str("op", "telemetryExporters.ReloadConfig").Str("from", e.modes).Str("to", modes).
+			Msg("changing the telemetry exporter requires a restart")
+	}
+	e.Lock()
+	defer e.Unlock()
+	otlp, err := newOtlpConfig(config)
+	if err != nil {
+		return err
+	}
+	if e.metricExporter == nil || otlp.equal(e.otlp) {
+		return nil
+	}
+	traceProvider, err := newOtelCollectorTraceProvider(e.ctx, otlp, e.resource, e.sampler)
+	if err != nil {
+		return err
+	}
+	metricExporter, err := newOtelCollectorMetricExporter(e.ctx, otlp)
+	if err != nil {
+		traceProvider.Shutdown(e.ctx)
+		return err
+	}
+	otel.SetTracerProvider(traceProvider)
+	oldTraceProvider := e.traceProvider
+	e.traceProvider = traceProvider
+	oldMetricExporter := e.metricExporter.swap(metricExporter)
+	// spans and metrics buffered for the old collector are flushed on shutdown
+	err = oldTraceProvider.Shutdown(e.ctx)
+	if err != nil {
+		e.logger.Error().Str("error", err.Error()).Msg("fail to stop old traceProvider")
+	}
+	err = oldMetricExporter.Shutdown(e.ctx)
+	if err != nil {
+		e.logger.Error().Str("error", err.Error()).Msg("fail to stop old metric exporter")
+	}
+	e.logger.Info().Str("telemetryexporter", "otel").Str("from", e.otlp.Protocol+"://"+e.otlp.Endpoint).
+		Str("to", otlp.Protocol+"://"+otlp.Endpoint).Msg("collector changed")
+	e.otlp = otlp
+	return nil
+}
+
+func SetupOpenTelemetry(lifecycle fx.Lifecycle, config config.Config, logger *zerolog.Logger, reloader *ConfigReloader, tenantStorer tenant.TenantStorer) error {
 
-	var traceProvider *sdktrace.TracerProvider
-	var metricsPusher *controller.Controller
+	var metricsPusher interface {
+		Start(ctx context.Context) error
+		Stop(ctx context.Context) error
+	}
+	var metricsServer *http.Server
 	ctx := context.Background() // long lived context
 
+	otlp, err := newOtlpConfig(config)
+	if err != nil {
+		return err
+	}
+	res, err := telemetryResource(config)
+	if err != nil {
+		return err
+	}
+	pushInterval := telemetryPushInterval(config)
+	// the otel collector config can be changed by reloading the config
+	exporters := &telemetryExporters{
+		ctx:      ctx,
+		otlp:     otlp,
+		resource: res,
+		modes:    telemetryExporterModes(config),
+		sampler:  newTraceSampler(config, tenantStorer, logger),
+		logger:   logger,
+	}
+	reloader.Register("opentelemetry", exporters)
+	reloader.Register("traceSampling", exporters.sampler)
+
 	lifecycle.Append(
 		fx.Hook{
 			OnStart: func(context.Context) error {
+				// W3C trace context is propagated from receivers to senders even without a trace exporter so that
+				// EARS does not break end-to-end traces
+				otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
+				// metrics are pushed to the otel collector or stdout exporter, scraped by prometheus or both
+				var pushExporter sdkmetric.Exporter
 				if config.GetBool("ears.opentelemetry.otel-collector.active") {
+					exporters.Lock()
+					defer exporters.Unlock()
 					// setup tracing
-					// grpc does not allow a uri path which makes it hard to set this up behind a proxy or load balancer
-					traceExporter, err := otlptracegrpc.New(
-						ctx,
-						otlptracegrpc.WithEndpoint(config.GetString("ears.opentelemetry.otel-collector.endpoint")),
-						otlptracegrpc.WithInsecure(),
-					)
+					var err error
+					exporters.traceProvider, err = newOtelCollectorTraceProvider(ctx, exporters.otlp, exporters.resource, exporters.sampler)
 					if err != nil {
 						return err
 					}
-					var hostname, _ = os.Hostname()
-
-					traceProvider = sdktrace.NewTracerProvider(
-						sdktrace.WithBatcher(traceExporter),
-						sdktrace.WithResource(
-							resource.NewSchemaless(
-								semconv.ServiceNameKey.String(rtsemconv.EARSServiceName),
-								semconv.ServiceVersionKey.String(app.Version),
-								semconv.NetHostNameKey.String(hostname),
-							),
-						),
-					)
 					// setup metrics
-					metricExporter, err := otlpmetric.New(
-						ctx,
-						otlpmetricgrpc.NewClient(
-							otlpmetricgrpc.WithEndpoint(config.GetString("ears.opentelemetry.otel-collector.endpoint")),
-							otlpmetricgrpc.WithInsecure(),
-						),
-						otlpmetric.WithMetricExportKindSelector(sdkmetric.DeltaExportKindSelector()),
-					)
-					if err != nil {
-						return err
-					}
-					metricsPusher = controller.New(
-						processor.New(
-							simple.NewWithExactDistribution(),
-							metricExporter,
-						),
-						controller.WithExporter(metricExporter),
-						controller.WithCollectPeriod(5*time.Second),
-					)
-					err = metricsPusher.Start(ctx)
+					otlpExporter, err := newOtelCollectorMetricExporter(ctx, exporters.otlp)
 					if err != nil {
 						return err
 					}
+					exporters.metricExporter = &reloadableMetricExporter{exporter: otlpExporter}
+					pushExporter = exporters.metricExporter
 					// global settings
-					otel.SetTracerProvider(traceProvider)
-					global.SetMeterProvider(metricsPusher.MeterProvider())
-					propagator := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{})
-					otel.SetTextMapPropagator(propagator)
+					otel.SetTracerProvider(exporters.traceProvider)
 					logger.Info().Str("telemetryexporter", "otel").
-						Str("endpoint", config.GetString("ears.opentelemetry.otel-collector.endpoint")).
-						Str("urlPath", config.GetString("ears.opentelemetry.otel-collector.urlPath")).
-						Str("protocol", config.GetString("ears.opentelemetry.otel-collector.protocol")).
+						Str("endpoint", exporters.otlp.Endpoint).
+						Str("urlPath", exporters.otlp.UrlPath).
+						Str("protocol", exporters.otlp.Protocol).
+						Bool("tls", exporters.otlp.TLS).
 						Msg("started")
 				} else if config.GetBool("ears.opentelemetry.stdout.active") {
 					// setup tracing
@@ -158,46 +342,100 @@ func SetupOpenTelemetry(lifecycle fx.Lifecycle, config config.Config, logger *ze
 					if err != nil {
 						return err
 					}
-					traceProvider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter))
+					exporters.traceProvider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithSampler(exporters.sampler), sdktrace.WithResource(exporters.resource))
 
 					// setup metrics
 					metricExporter, err := stdoutmetric.New(stdoutmetric.WithPrettyPrint())
 					if err != nil {
 						return err
 					}
-
-					metricsPusher = controller.New(
+					pushExporter = metricExporter
+					// global settings
+					otel.SetTracerProvider(exporters.traceProvider)
+					logger.Info().Str("telemetryexporter", "stdout").Msg("started")
+				}
+				if config.GetBool("ears.opentelemetry.prometheus.active") {
+					// prometheus scrapes the endpoint, the scraped pipeline is pushed as well if a push exporter is active
+					metricExporter, err := NewPrometheusExporter(exporters.resource)
+					if err != nil {
+						return err
+					}
+					port := config.GetInt("ears.opentelemetry.prometheus.port")
+					if port <= 0 {
+						port = DefaultPrometheusPort
+					}
+					path := config.GetString("ears.opentelemetry.prometheus.path")
+					if path == "" {
+						path = DefaultPrometheusPath
+					}
+					mux := http.NewServeMux()
+					mux.Handle(path, metricExporter)
+					metricsServer = &http.Server{
+						Addr:    fmt.Sprintf(":%d", port),
+						Handler: mux,
+					}
+					go func() {
+						err := metricsServer.ListenAndServe()
+						if err != nil && err != http.ErrServerClosed {
+							logger.Error().Str("op", "SetupOpenTelemetry").Int("port", port).Str("error", err.Error()).Msg("prometheus endpoint failed")
+						}
+					}()
+					if pushExporter != nil {
+						metricsPusher = newScrapedMetricsPusher(metricExporter.Controller(), pushExporter, pushInterval, logger)
+					}
+					// global settings
+					global.SetMeterProvider(metricExporter.MeterProvider())
+					logger.Info().Str("telemetryexporter", "prometheus").Int("port", port).Str("path", path).Msg("started")
+				} else if pushExporter != nil {
+					ctrl := controller.New(
 						processor.New(
 							simple.NewWithExactDistribution(),
-							metricExporter,
+							pushExporter,
 						),
-						controller.WithExporter(metricExporter),
-						controller.WithCollectPeriod(5*time.Second),
+						controller.WithExporter(pushExporter),
+						controller.WithCollectPeriod(pushInterval),
+						controller.WithResource(exporters.resource),
 					)
-					err = metricsPusher.Start(ctx)
+					metricsPusher = ctrl
+					// global settings
+					global.SetMeterProvider(ctrl.MeterProvider())
+				}
+				if metricsPusher != nil {
+					err := metricsPusher.Start(ctx)
 					if err != nil {
 						return err
 					}
-					// global settings
-					otel.SetTracerProvider(traceProvider)
-					global.SetMeterProvider(metricsPusher.MeterProvider())
-					propagator := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, propagation.TraceContext{})
-					otel.SetTextMapPropagator(propagator)
-					logger.Info().Str("telemetryexporter", "stdout").Msg("started")
+				}
+				if exporters.traceProvider != nil {
+					// the trace sampling of tenants is only needed when traces are exported
+					exporters.sampler.start(ctx)
 				}
 				return nil
 			},
 			OnStop: func(ctx context.Context) error {
-				if config.GetBool("ears.opentelemetry.otel-collector.active") || config.GetBool("ears.opentelemetry.stdout.active") {
-					err := traceProvider.Shutdown(ctx)
+				exporters.sampler.stop()
+				if metricsServer != nil {
+					err := metricsServer.Shutdown(ctx)
+					if err != nil {
+						logger.Error().Str("error", err.Error()).Msg("fail to stop prometheus endpoint")
+					}
+					logger.Info().Msg("prometheus exporter stopped")
+				}
+				// the exporter flags may have changed since start when the config was reloaded
+				if exporters.traceProvider != nil {
+					exporters.Lock()
+					defer exporters.Unlock()
+					err := exporters.traceProvider.Shutdown(ctx)
 					if err != nil {
 						logger.Error().Str("error", err.Error()).Msg("fail to stop traceProvider")
 					}
-					err = metricsPusher.Stop(ctx)
+					logger.Info().Msg("otel exporter stopped")
+				}
+				if metricsPusher != nil {
+					err := metricsPusher.Stop(ctx)
 					if err != nil {
 						logger.Error().Str("error", err.Error()).Msg("fail to stop metricsPusher")
 					}
-					logger.Info().Msg("otel exporter stopped")
 				}
 				return nil
 			},
@@ -242,25 +480,3 @@ func SetupAPIServer(lifecycle fx.Lifecycle, config config.Config, logger *zerolo
 	)
 	return nil
 }
-
-func SetupPprof(lifecycle fx.Lifecycle, logger *zerolog.Logger, config config.Config) error {
-	port := config.GetInt("ears.pprof.port")
-	if port <= 0 {
-		logger.Info().Int("port", port).Msg("Pprof disabled")
-		return nil
-	}
-	lifecycle.Append(
-		fx.Hook{
-			OnStart: func(ctx context.Context) error {
-				go http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
-				logger.Info().Msg("Pprof Server Started")
-				return nil
-			},
-			OnStop: func(ctx context.Context) error {
-				logger.Info().Msg("Pprof Server Stopped")
-				return nil
-			},
-		},
-	)
-	return nil
-}
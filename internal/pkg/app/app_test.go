@@ -15,6 +15,7 @@
 package app_test
 
 import (
+	"context"
 	"errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -22,17 +23,26 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/app"
 	"github.com/xmidt-org/ears/internal/pkg/appsecret"
 	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/fx/auditstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/fragmentstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/jwtmanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/pluginmanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/quotamanagerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/routestorerfx"
+	"github.com/xmidt-org/ears/internal/pkg/fx/snapshotfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/syncerfx"
 	"github.com/xmidt-org/ears/internal/pkg/fx/tenantstorerfx"
 	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
 	testLog "github.com/xmidt-org/ears/test/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
 	"go.uber.org/fx"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -89,6 +99,22 @@ func GetTestLogger() *zerolog.Logger {
 	return &applogger
 }
 
+func TestPrometheusExporter(t *testing.T) {
+	exporter, err := app.NewPrometheusExporter(resource.Empty())
+	if err != nil {
+		t.Fatalf("cannot create prometheus exporter: %s", err.Error())
+	}
+	meter := exporter.MeterProvider().Meter("ears")
+	counter := metric.Must(meter).NewInt64Counter("ears.test.events")
+	counter.Add(context.Background(), 3, attribute.String("ears.app.id", "myapp"), attribute.String("ears.route.id", "r100"))
+	w := httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	if !strings.Contains(string(body), `ears_test_events{ears_app_id="myapp",ears_route_id="r100"`) {
+		t.Fatalf("metric missing in scrape output: %s", string(body))
+	}
+}
+
 func TestAppRunSuccess(t *testing.T) {
 
 	logListener := testLog.NewLogListener()
@@ -102,6 +128,8 @@ func TestAppRunSuccess(t *testing.T) {
 		tenantstorerfx.Module,
 		quotamanagerfx.Module,
 		jwtmanagerfx.Module,
+		snapshotfx.Module,
+		auditstorerfx.Module,
 		fx.Provide(
 			AppConfig,
 			appsecret.NewConfigVault,
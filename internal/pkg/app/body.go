@@ -0,0 +1,80 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/xmidt-org/ears/internal/pkg/config"
+)
+
+const (
+	DEFAULT_MAX_BODY_BYTES = 1024 * 1024
+
+	// endpoints with individually configurable body size limits, configured as ears.api.maxBodyBytes.<endpoint>
+	BODY_ENDPOINT_EVENT    = "event"
+	BODY_ENDPOINT_SIMULATE = "simulate"
+	BODY_ENDPOINT_ROUTE    = "route"
+	BODY_ENDPOINT_FRAGMENT = "fragment"
+	BODY_ENDPOINT_TENANT   = "tenant"
+	BODY_ENDPOINT_GRAPHQL  = "graphql"
+)
+
+var bodyEndpoints = []string{BODY_ENDPOINT_EVENT, BODY_ENDPOINT_SIMULATE, BODY_ENDPOINT_ROUTE, BODY_ENDPOINT_FRAGMENT, BODY_ENDPOINT_TENANT, BODY_ENDPOINT_GRAPHQL}
+
+// parseMaxBodyBytes reads the body size limit of each endpoint, ears.api.maxBodyBytes.default overrides
+// the built-in default for all endpoints without a limit of their own
+func parseMaxBodyBytes(config config.Config) map[string]int64 {
+	defaultLimit := int64(DEFAULT_MAX_BODY_BYTES)
+	if config != nil && config.GetInt("ears.api.maxBodyBytes.default") > 0 {
+		defaultLimit = int64(config.GetInt("ears.api.maxBodyBytes.default"))
+	}
+	limits := make(map[string]int64)
+	for _, endpoint := range bodyEndpoints {
+		limits[endpoint] = defaultLimit
+		if config != nil && config.GetInt("ears.api.maxBodyBytes."+endpoint) > 0 {
+			limits[endpoint] = int64(config.GetInt("ears.api.maxBodyBytes." + endpoint))
+		}
+	}
+	return limits
+}
+
+// readBody reads the request body up to the size limit of the endpoint, larger bodies are rejected without
+// being read in full, either upfront based on their content length or as soon as the limit is exceeded
+func (a *APIManager) readBody(r *http.Request, endpoint string) ([]byte, ApiError) {
+	limit, ok := a.maxBodyBytes[endpoint]
+	if !ok {
+		limit = DEFAULT_MAX_BODY_BYTES
+	}
+	tooLarge := &RequestEntityTooLargeError{endpoint + " request body exceeds " + strconv.FormatInt(limit, 10) + " bytes"}
+	if r.ContentLength > limit {
+		return nil, tooLarge
+	}
+	var buf bytes.Buffer
+	if r.ContentLength > 0 {
+		buf.Grow(int(r.ContentLength))
+	}
+	n, err := io.Copy(&buf, io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, &BadRequestError{"error reading request body", err}
+	}
+	if n > limit {
+		return nil, tooLarge
+	}
+	return buf.Bytes(), nil
+}
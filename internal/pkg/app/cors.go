@@ -0,0 +1,144 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/xmidt-org/ears/internal/pkg/config"
+)
+
+const (
+	DEFAULT_CORS_ALLOWED_METHODS = "GET,POST,PUT,DELETE,OPTIONS"
+	DEFAULT_CORS_ALLOWED_HEADERS = "Authorization,Content-Type,Content-Encoding,Accept-Encoding,If-Match,If-None-Match"
+	DEFAULT_CORS_EXPOSED_HEADERS = "ETag"
+)
+
+// corsConfig holds the CORS settings of the API, CORS is disabled unless at least one origin is allowed
+type corsConfig struct {
+	allowedOrigins   []string
+	allowedMethods   string
+	allowedHeaders   string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAgeSecs       int
+}
+
+// splitList splits a comma separated config value into its trimmed non-empty elements
+func splitList(list string) []string {
+	result := make([]string, 0)
+	for _, e := range strings.Split(list, ",") {
+		e = strings.TrimSpace(e)
+		if e != "" {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// parseCorsConfig reads the comma separated lists ears.api.cors.allowedOrigins, allowedMethods, allowedHeaders
+// and exposedHeaders as well as ears.api.cors.allowCredentials and ears.api.cors.maxAgeSecs, the wildcard origin
+// cannot be combined with credentials
+func parseCorsConfig(config config.Config) (*corsConfig, error) {
+	cc := &corsConfig{
+		allowedOrigins: []string{},
+		allowedMethods: DEFAULT_CORS_ALLOWED_METHODS,
+		allowedHeaders: DEFAULT_CORS_ALLOWED_HEADERS,
+		exposedHeaders: DEFAULT_CORS_EXPOSED_HEADERS,
+	}
+	if config == nil {
+		return cc, nil
+	}
+	cc.allowedOrigins = splitList(config.GetString("ears.api.cors.allowedOrigins"))
+	if methods := splitList(config.GetString("ears.api.cors.allowedMethods")); len(methods) > 0 {
+		cc.allowedMethods = strings.Join(methods, ",")
+	}
+	if headers := splitList(config.GetString("ears.api.cors.allowedHeaders")); len(headers) > 0 {
+		cc.allowedHeaders = strings.Join(headers, ",")
+	}
+	if headers := splitList(config.GetString("ears.api.cors.exposedHeaders")); len(headers) > 0 {
+		cc.exposedHeaders = strings.Join(headers, ",")
+	}
+	cc.allowCredentials = config.GetBool("ears.api.cors.allowCredentials")
+	cc.maxAgeSecs = config.GetInt("ears.api.cors.maxAgeSecs")
+	if cc.allowCredentials {
+		for _, o := range cc.allowedOrigins {
+			if o == "*" {
+				return nil, errors.New("cors allowed origin * cannot be combined with allowCredentials")
+			}
+		}
+	}
+	return cc, nil
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header for an origin or blank if the
+// origin is not allowed, only explicitly listed origins are echoed back
+func (cc *corsConfig) allowOrigin(origin string) string {
+	for _, o := range cc.allowedOrigins {
+		if o == "*" {
+			return "*"
+		}
+		if strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsMiddleware adds CORS headers to responses for allowed origins and answers preflight requests, it wraps
+// the router rather than being a router middleware because preflight OPTIONS requests match no route
+func (a *APIManager) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || a.cors == nil || len(a.cors.allowedOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		allowed := a.cors.allowOrigin(origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if allowed == "" {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Set("Access-Control-Allow-Origin", allowed)
+		if a.cors.allowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if a.cors.exposedHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", a.cors.exposedHeaders)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", a.cors.allowedMethods)
+		h.Set("Access-Control-Allow-Headers", a.cors.allowedHeaders)
+		if a.cors.maxAgeSecs > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(a.cors.maxAgeSecs))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCorsMiddleware(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("ears.api.cors.allowedOrigins", "https://ui.example.com, https://admin.example.com")
+	cfg.Set("ears.api.cors.maxAgeSecs", 600)
+	cc, err := parseCorsConfig(cfg)
+	if err != nil {
+		t.Fatalf("cannot parse cors config: %s", err.Error())
+	}
+	api := &APIManager{cors: cc}
+	handler := api.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	testCases := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string
+		status        int
+		allowOrigin   string
+		allowMethods  string
+	}{
+		{"noOrigin", http.MethodGet, "", "", http.StatusOK, "", ""},
+		{"allowed", http.MethodGet, "https://ui.example.com", "", http.StatusOK, "https://ui.example.com", ""},
+		{"notAllowed", http.MethodGet, "https://evil.example.com", "", http.StatusOK, "", ""},
+		{"preflight", http.MethodOptions, "https://admin.example.com", http.MethodPut, http.StatusNoContent, "https://admin.example.com", DEFAULT_CORS_ALLOWED_METHODS},
+		{"preflightNotAllowed", http.MethodOptions, "https://evil.example.com", http.MethodPut, http.StatusForbidden, "", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "/ears/v1/routes", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				r.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status %d", w.Code)
+			}
+			if w.Header().Get("Access-Control-Allow-Origin") != tc.allowOrigin || w.Header().Get("Access-Control-Allow-Methods") != tc.allowMethods {
+				t.Fatalf("unexpected cors headers %v", w.Header())
+			}
+			if tc.allowMethods != "" && w.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Fatalf("unexpected max age %v", w.Header())
+			}
+		})
+	}
+	// the wildcard origin is not echoed back and cannot be combined with credentials
+	cfg.Set("ears.api.cors.allowedOrigins", "*")
+	api.cors, err = parseCorsConfig(cfg)
+	if err != nil {
+		t.Fatalf("cannot parse cors config: %s", err.Error())
+	}
+	r := httptest.NewRequest(http.MethodGet, "/ears/v1/routes", nil)
+	r.Header.Set("Origin", "https://any.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("unexpected cors headers %v", w.Header())
+	}
+	cfg.Set("ears.api.cors.allowCredentials", true)
+	_, err = parseCorsConfig(cfg)
+	if err == nil {
+		t.Fatalf("expected error for wildcard origin with credentials")
+	}
+	// cors is disabled without allowed origins
+	api.cors, _ = parseCorsConfig(viper.New())
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("unexpected cors headers %v", w.Header())
+	}
+}
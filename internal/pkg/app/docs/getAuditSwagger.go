@@ -0,0 +1,55 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/audit admin getAuditRecords
+// Gets list of route, fragment and tenant config changes, most recent first.
+// responses:
+//   200: AuditRecordsResponse
+//   400: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters getAuditRecords
+type auditParamWrapper struct {
+	// Optional org ID filter
+	// in: query
+	OrgId string `json:"orgId"`
+	// Optional app ID filter
+	// in: query
+	AppId string `json:"appId"`
+	// Optional item type filter, one of route, fragment or tenant
+	// in: query
+	Type string `json:"type"`
+	// Optional item ID filter
+	// in: query
+	Id string `json:"id"`
+	// Optional jwt subject filter
+	// in: query
+	Subject string `json:"subject"`
+	// Optional start time in unix milliseconds
+	// in: query
+	Since int64 `json:"since"`
+	// Optional end time in unix milliseconds
+	// in: query
+	Until int64 `json:"until"`
+	// Optional maximum number of records
+	// in: query
+	Limit int `json:"limit"`
+}
+
+type AuditRecordsResponse struct {
+	Status responseStatus           `json:"status"`
+	Items  []map[string]interface{} `json:"items"`
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/events/{eventId}/status routes getEventStatus
+// Gets the processing status of an event submitted asynchronously to the instance serving the request.
+// responses:
+//   200: EventStatusResponse
+//   404: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters getEventStatus
+type eventIdParamWrapper struct {
+	// Event ID
+	// in: path
+	// required: true
+	EventId string `json:"eventId"`
+}
+
+type EventStatusResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
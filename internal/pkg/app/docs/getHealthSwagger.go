@@ -0,0 +1,37 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /health health health
+// Reports route storer, tenant storer and sync backend connectivity, route registration errors and the run state of all receivers on this instance. Returns status 503 if a backend cannot be reached.
+// responses:
+//   200: HealthResponse
+//   503: HealthResponse
+
+// swagger:route GET /ready health ready
+// Readiness probe, returns status 503 if a storer or the sync backend cannot be reached.
+// responses:
+//   200: SuccessResponse
+//   503: ErrorResponse
+
+// swagger:route GET /live health live
+// Liveness probe, returns status 200 as long as the process is responsive.
+// responses:
+//   200: SuccessResponse
+
+type HealthResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/errors routes getRouteErrors
+// Gets the most recent filter, sender and parse errors of a route running on the instance serving the request, most recent first.
+// responses:
+//   200: RouteErrorsResponse
+//   404: ErrorResponse
+//   500: ErrorResponse
+
+type RouteErrorsResponse struct {
+	Status responseStatus           `json:"status"`
+	Items  []map[string]interface{} `json:"items"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/stats routes getRouteStats
+// Gets runtime statistics of a route running on the instance serving the request, aggregated over a rolling window of 60 seconds.
+// responses:
+//   200: RouteStatsResponse
+//   404: ErrorResponse
+//   500: ErrorResponse
+
+type RouteStatsResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/tail routes getRouteTail
+// Streams copies of a sample of the events flowing through a route running on the instance serving the request as server sent events.
+// produces:
+// - text/event-stream
+// responses:
+//   200: description: stream of server sent events
+//   400: ErrorResponse
+//   404: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters getRouteTail
+type routeTailParamWrapper struct {
+	// Optional fraction of events to stream, greater than 0 and at most 1, defaults to 1
+	// in: query
+	Sample float64 `json:"sample"`
+	// Optional path to mask in streamed events, may be repeated
+	// in: query
+	Redact []string `json:"redact"`
+	// Optional number of seconds after which the stream ends, at most 600, defaults to 60
+	// in: query
+	Timeout int `json:"timeout"`
+	// Optional number of events after which the stream ends
+	// in: query
+	MaxEvents int `json:"maxEvents"`
+}
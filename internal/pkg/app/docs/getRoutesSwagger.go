@@ -37,3 +37,11 @@ type RoutesResponse struct {
 type RouteConfig struct {
 	route.Config
 }
+
+// swagger:parameters getRoutes getAllRoutes
+type labelParamWrapper struct {
+	// Optional label selector, e.g. team=xfi, team!=xfi or team. Multiple selectors must all match.
+	// in: query
+	// required: false
+	Label []string `json:"label"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/config/usage tenants getTenantUsage
+// Gets recent event throughput of a tenant and its routes compared against the tenant event quota.
+// responses:
+//   200: TenantUsageResponse
+//   404: TenantErrorResponse
+//   500: TenantErrorResponse
+
+type TenantUsageResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
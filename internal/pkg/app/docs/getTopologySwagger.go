@@ -0,0 +1,36 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/orgs/{orgId}/applications/{appId}/topology routes getTopology
+// Gets the receiver, filter and sender instances of a tenant and the routes sharing them, most shared first.
+// responses:
+//   200: TopologyResponse
+//   500: ErrorResponse
+
+// swagger:parameters getTopology
+type topologyParamWrapper struct {
+	// Optional route ID, only plugins of this route are returned
+	// in: query
+	RouteId string `json:"routeId"`
+	// Optional, if true only plugins shared by more than one route are returned
+	// in: query
+	Shared bool `json:"shared"`
+}
+
+type TopologyResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
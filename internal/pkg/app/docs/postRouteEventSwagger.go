@@ -18,4 +18,18 @@ package docs
 // Injects a test event into an existing route.
 // responses:
 //   200: SuccessResponse
+//   202: EventAcceptedResponse
+//   429: ErrorResponse
 //   500: ErrorResponse
+
+// swagger:parameters postRouteEvent
+type routeEventParamWrapper struct {
+	// Optional, if true the event is accepted without waiting for it to be processed and its outcome can be polled via the event status API
+	// in: query
+	Async bool `json:"async"`
+}
+
+type EventAcceptedResponse struct {
+	Status responseStatus    `json:"status"`
+	Item   map[string]string `json:"item"`
+}
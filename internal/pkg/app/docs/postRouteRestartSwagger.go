@@ -0,0 +1,27 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route POST /v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/restart routes postRouteRestart
+// Tears down and re-instantiates receiver, filters and sender of a route running on the instance serving the request.
+// responses:
+//   200: RouteRestartResponse
+//   404: ErrorResponse
+//   500: ErrorResponse
+
+type RouteRestartResponse struct {
+	Status responseStatus    `json:"status"`
+	Item   map[string]string `json:"item"`
+}
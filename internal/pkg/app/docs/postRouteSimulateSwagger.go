@@ -0,0 +1,34 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route POST /v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/simulate routes postRouteSimulate
+// Runs a sample payload through the filter chain of an existing route and returns the resulting events along with a trace of each filter, nothing is sent.
+// responses:
+//   200: SimulationResponse
+//   500: ErrorResponse
+
+// swagger:parameters postRouteSimulate
+type simulateParamWrapper struct {
+	// Sample event payload.
+	// in: body
+	// required: true
+	Body interface{}
+}
+
+type SimulationResponse struct {
+	Status responseStatus         `json:"status"`
+	Item   map[string]interface{} `json:"item"`
+}
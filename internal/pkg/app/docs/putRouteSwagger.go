@@ -56,7 +56,15 @@ type routeParamWrapper struct {
 	Body RouteConfig
 }
 
-// swagger:parameters putRoute getRoute deleteRoute postRouteEvent
+// swagger:parameters putRoute postRoute
+type dryRunParamWrapper struct {
+	// If true the route is only validated including fragment resolution but neither stored nor started.
+	// in: query
+	// required: false
+	DryRun bool `json:"dryRun"`
+}
+
+// swagger:parameters putRoute getRoute deleteRoute postRouteEvent postRouteSimulate getRouteStats getRouteTail postRouteRestart
 type routeIdParamWrapper struct {
 	// Route ID
 	// in: path
@@ -0,0 +1,35 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/search admin search
+// Searches route IDs, route names, plugin configs and fragments of all tenants, for example for a queue URL or topic.
+// responses:
+//   200: SearchResponse
+//   400: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters search
+type searchParamWrapper struct {
+	// Case insensitive search text
+	// in: query
+	// required: true
+	Q string `json:"q"`
+}
+
+type SearchResponse struct {
+	Status responseStatus           `json:"status"`
+	Items  []map[string]interface{} `json:"items"`
+}
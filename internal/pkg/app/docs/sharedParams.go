@@ -14,7 +14,7 @@
 
 package docs
 
-// swagger:parameters putRoute postRoute getRoute deleteRoute putTenant getTenant deleteTenant postRouteEvent
+// swagger:parameters putRoute postRoute getRoute deleteRoute putTenant getTenant deleteTenant postRouteEvent postRouteSimulate getRouteStats getRouteTail postRouteRestart getEventStatus getTopology getTenantUsage
 type appIdParamWrapper struct {
 	// App ID
 	// in: path
@@ -22,10 +22,17 @@ type appIdParamWrapper struct {
 	AppId string `json:"appId"`
 }
 
-// swagger:parameters putRoute postRoute getRoute deleteRoute putTenant getTenant deleteTenant postRouteEvent
+// swagger:parameters putRoute postRoute getRoute deleteRoute putTenant getTenant deleteTenant postRouteEvent postRouteSimulate getRouteStats getRouteTail postRouteRestart getEventStatus getTopology getTenantUsage
 type orgIdParamWrapper struct {
 	// Org ID
 	// in: path
 	// required: true
 	OrgId string `json:"orgId"`
 }
+
+// swagger:parameters putRoute deleteRoute putTenant deleteTenant
+type ifMatchParamWrapper struct {
+	// Optional entity tag as returned in the ETag header, the request fails with status 412 if the item has been modified in the meantime
+	// in: header
+	IfMatch string `json:"If-Match"`
+}
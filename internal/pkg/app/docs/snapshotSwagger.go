@@ -0,0 +1,45 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/snapshots admin getSnapshots
+// Gets the urls of all snapshots, newest first.
+// responses:
+//   200: description: snapshot urls
+//   412: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:route POST /v1/snapshots admin takeSnapshot
+// Takes a snapshot of all routes, fragments and tenant configs.
+// responses:
+//   200: description: snapshot url
+//   412: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:route POST /v1/snapshots/restore admin restoreSnapshot
+// Restores a snapshot, items not in the snapshot are kept.
+// responses:
+//   200: description: restored item counts and errors
+//   400: ErrorResponse
+//   404: ErrorResponse
+//   412: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters restoreSnapshot
+type restoreSnapshotParamWrapper struct {
+	// Optional url of the snapshot to restore, the latest snapshot by default
+	// in: query
+	Url string `json:"url"`
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+// swagger:route GET /v1/watch admin watch
+// Streams route, fragment and tenant config changes made through the instance serving the request as server sent events.
+// produces:
+// - text/event-stream
+// responses:
+//   200: description: stream of server sent events
+//   400: ErrorResponse
+//   500: ErrorResponse
+
+// swagger:parameters watch
+type watchParamWrapper struct {
+	// Optional org ID of the changes to stream
+	// in: query
+	OrgId string `json:"orgId"`
+	// Optional app ID of the changes to stream
+	// in: query
+	AppId string `json:"appId"`
+	// Optional item type of the changes to stream, one of route, fragment or tenant
+	// in: query
+	Type string `json:"type"`
+	// Optional item ID of the changes to stream
+	// in: query
+	Id string `json:"id"`
+	// Optional number of seconds after which the stream ends, at most 86400, streams until the client disconnects by default
+	// in: query
+	Timeout int `json:"timeout"`
+}
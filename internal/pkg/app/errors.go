@@ -29,6 +29,20 @@ func (e *InvalidOptionError) Error() string {
 	return fmt.Sprintf("InvalidOptionError (Option=%s)", e.Option)
 }
 
+// ConfigReloadError is returned when a component cannot apply a reloaded configuration
+type ConfigReloadError struct {
+	Component string
+	Err       error
+}
+
+func (e *ConfigReloadError) Error() string {
+	return errs.String("ConfigReloadError", map[string]interface{}{"component": e.Component}, e.Err)
+}
+
+func (e *ConfigReloadError) Unwrap() error {
+	return e.Err
+}
+
 // Rest API errors
 type ApiError interface {
 	error
@@ -85,3 +99,121 @@ func (e *InternalServerError) Error() string {
 func (e *InternalServerError) StatusCode() int {
 	return http.StatusInternalServerError
 }
+
+type PreconditionFailedError struct {
+	message string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return errs.String("PreconditionFailedError", map[string]interface{}{"message": e.message}, nil)
+}
+
+func (e *PreconditionFailedError) StatusCode() int {
+	return http.StatusPreconditionFailed
+}
+
+type ServiceUnavailableError struct {
+	message string
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	return errs.String("ServiceUnavailableError", map[string]interface{}{"message": e.message}, nil)
+}
+
+func (e *ServiceUnavailableError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+// MisdirectedRequestError is returned when a request must be sent to another node, such as requests for a live
+// route that runs on another node because of route sharding
+type MisdirectedRequestError struct {
+	message string
+	err     error
+}
+
+func (e *MisdirectedRequestError) Error() string {
+	return errs.String("MisdirectedRequestError", map[string]interface{}{"message": e.message}, e.err)
+}
+
+func (e *MisdirectedRequestError) StatusCode() int {
+	return http.StatusMisdirectedRequest
+}
+
+type ConflictError struct {
+	message string
+	err     error
+}
+
+func (e *ConflictError) Error() string {
+	return errs.String("ConflictError", map[string]interface{}{"message": e.message}, e.err)
+}
+
+func (e *ConflictError) StatusCode() int {
+	return http.StatusConflict
+}
+
+type MethodNotAllowedError struct {
+	message string
+	err     error
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return errs.String("MethodNotAllowedError", map[string]interface{}{"message": e.message}, e.err)
+}
+
+func (e *MethodNotAllowedError) StatusCode() int {
+	return http.StatusMethodNotAllowed
+}
+
+type RequestEntityTooLargeError struct {
+	message string
+}
+
+func (e *RequestEntityTooLargeError) Error() string {
+	return errs.String("RequestEntityTooLargeError", map[string]interface{}{"message": e.message}, nil)
+}
+
+func (e *RequestEntityTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+type ForbiddenError struct {
+	message string
+	err     error
+}
+
+func (e *ForbiddenError) Error() string {
+	return errs.String("ForbiddenError", map[string]interface{}{"message": e.message}, e.err)
+}
+
+func (e *ForbiddenError) StatusCode() int {
+	return http.StatusForbidden
+}
+
+type TooManyRequestsError struct {
+	message    string
+	retryAfter int
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return errs.String("TooManyRequestsError", map[string]interface{}{"message": e.message, "retryAfter": e.retryAfter}, nil)
+}
+
+func (e *TooManyRequestsError) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+// RetryAfter returns the number of seconds the client should wait before retrying
+func (e *TooManyRequestsError) RetryAfter() int {
+	return e.retryAfter
+}
+
+// QuotaAlertError is returned when the webhook of a quota alert does not accept the alert
+type QuotaAlertError struct {
+	Url        string
+	StatusCode int
+}
+
+func (e *QuotaAlertError) Error() string {
+	return errs.String("QuotaAlertError", map[string]interface{}{"url": e.Url, "statusCode": e.StatusCode}, nil)
+}
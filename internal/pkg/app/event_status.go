@@ -0,0 +1,176 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	EVENT_STATUS_TTL_SECS    = 600
+	EVENT_STATUS_MAX_ENTRIES = 100000
+
+	// eventStatusSweepInterval is how often expired statuses are dropped
+	eventStatusSweepInterval = time.Second
+
+	EventStatusPending = "pending"
+	EventStatusAcked   = "acked"
+	EventStatusNacked  = "nacked"
+)
+
+// EventStatus is the processing outcome of an event submitted asynchronously
+type EventStatus struct {
+	EventId   string `json:"eventId"`
+	RouteId   string `json:"routeId"`
+	TraceId   string `json:"tx.traceId,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Submitted int64  `json:"submitted"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// EventStatusCache keeps the status of asynchronously submitted events for a limited time. Statuses live in the
+// memory of the instance the event was submitted to, so they are only known to that instance. Expired statuses are
+// dropped in the background and the oldest statuses make room once the cache holds maxEntries statuses.
+type EventStatusCache struct {
+	cache      map[string]*list.Element
+	order      *list.List // statuses in the order they were added, oldest first
+	ttlSecs    int
+	maxEntries int
+	done       chan struct{}
+	stopOnce   sync.Once
+	sync.Mutex
+}
+
+type eventStatusEntry struct {
+	key    string
+	status *EventStatus
+}
+
+func NewEventStatusCache(ttlSecs int, maxEntries int) *EventStatusCache {
+	if maxEntries <= 0 {
+		maxEntries = EVENT_STATUS_MAX_ENTRIES
+	}
+	c := &EventStatusCache{
+		cache:      make(map[string]*list.Element),
+		order:      list.New(),
+		ttlSecs:    ttlSecs,
+		maxEntries: maxEntries,
+		done:       make(chan struct{}),
+	}
+	go c.sweep()
+	return c
+}
+
+func eventStatusKey(tid tenant.Id, eventId string) string {
+	return tid.Key() + "/" + eventId
+}
+
+// sweep drops expired statuses until the cache is stopped
+func (c *EventStatusCache) sweep() {
+	ticker := time.NewTicker(eventStatusSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.purge()
+		}
+	}
+}
+
+// purge drops expired statuses, which are at the front because statuses are added in the order they are submitted
+func (c *EventStatusCache) purge() {
+	c.Lock()
+	defer c.Unlock()
+	expired := time.Now().Add(-time.Duration(c.ttlSecs)*time.Second).UnixNano() / 1e6
+	for e := c.order.Front(); e != nil && e.Value.(*eventStatusEntry).status.Submitted < expired; e = c.order.Front() {
+		c.remove(e)
+	}
+}
+
+func (c *EventStatusCache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.cache, e.Value.(*eventStatusEntry).key)
+}
+
+// Stop ends the background sweep
+func (c *EventStatusCache) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// Len returns the number of statuses in the cache
+func (c *EventStatusCache) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.order.Len()
+}
+
+// Add adds the status of a newly submitted event, dropping the oldest status if the cache is full
+func (c *EventStatusCache) Add(tid tenant.Id, status EventStatus) {
+	c.Lock()
+	defer c.Unlock()
+	key := eventStatusKey(tid, status.EventId)
+	if e, ok := c.cache[key]; ok {
+		c.remove(e)
+	}
+	for c.order.Len() >= c.maxEntries {
+		c.remove(c.order.Front())
+	}
+	c.cache[key] = c.order.PushBack(&eventStatusEntry{key: key, status: &status})
+}
+
+// Update modifies the status of an event if it is still known
+func (c *EventStatusCache) Update(tid tenant.Id, eventId string, fn func(status *EventStatus)) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.cache[eventStatusKey(tid, eventId)]
+	if ok {
+		fn(e.Value.(*eventStatusEntry).status)
+	}
+}
+
+// Remove removes the status of an event
+func (c *EventStatusCache) Remove(tid tenant.Id, eventId string) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.cache[eventStatusKey(tid, eventId)]
+	if ok {
+		c.remove(e)
+	}
+}
+
+// Get returns a copy of the status of an event or nil if the event is unknown or its status has expired
+func (c *EventStatusCache) Get(tid tenant.Id, eventId string) *EventStatus {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.cache[eventStatusKey(tid, eventId)]
+	if !ok {
+		return nil
+	}
+	status := e.Value.(*eventStatusEntry).status
+	if time.Now().UnixNano()/1e6-status.Submitted > int64(c.ttlSecs)*1000 {
+		return nil
+	}
+	cpy := *status
+	return &cpy
+}
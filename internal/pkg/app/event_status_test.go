@@ -0,0 +1,60 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestEventStatusCacheMaxEntries(t *testing.T) {
+	c := NewEventStatusCache(EVENT_STATUS_TTL_SECS, 3)
+	defer c.Stop()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	now := time.Now().UnixNano() / 1e6
+	for i := 0; i < 5; i++ {
+		c.Add(tid, EventStatus{EventId: "e" + strconv.Itoa(i), Status: EventStatusPending, Submitted: now})
+	}
+	if c.Len() != 3 {
+		t.Fatalf("unexpected number of statuses %d", c.Len())
+	}
+	if c.Get(tid, "e1") != nil {
+		t.Fatalf("oldest status not dropped")
+	}
+	if c.Get(tid, "e4") == nil {
+		t.Fatalf("newest status dropped")
+	}
+	c.Remove(tid, "e3")
+	c.Add(tid, EventStatus{EventId: "e5", Status: EventStatusPending, Submitted: now})
+	if c.Len() != 3 || c.Get(tid, "e2") == nil {
+		t.Fatalf("status dropped although there was room")
+	}
+}
+
+func TestEventStatusCacheSweep(t *testing.T) {
+	c := NewEventStatusCache(1, 0)
+	defer c.Stop()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	now := time.Now().UnixNano() / 1e6
+	c.Add(tid, EventStatus{EventId: "expired", Status: EventStatusAcked, Submitted: now - 5000})
+	c.Add(tid, EventStatus{EventId: "fresh", Status: EventStatusPending, Submitted: now + 60000})
+	time.Sleep(2 * eventStatusSweepInterval)
+	if c.Len() != 1 || c.Get(tid, "fresh") == nil {
+		t.Fatalf("expired status not swept, %d statuses left", c.Len())
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/graphql"
+	"github.com/xmidt-org/ears/internal/pkg/plugin"
+	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// toGeneric converts a value into the maps, slices and scalars the graphql object types resolve their fields from
+func toGeneric(v interface{}) (interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(buf, &generic)
+	return generic, err
+}
+
+// stringArg returns a string argument or blank if the argument is missing
+func stringArg(p gql.ResolveParams, name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+// stringListArg returns a list of strings argument, a single string is coerced into a list of one by graphql
+func stringListArg(p gql.ResolveParams, name string) []string {
+	list, _ := p.Args[name].([]interface{})
+	result := make([]string, 0, len(list))
+	for _, e := range list {
+		if s, ok := e.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// tenantOf returns the tenant of a generic value, found under the given key
+func tenantOf(source interface{}, key string) tenant.Id {
+	var tid tenant.Id
+	if m, ok := source.(map[string]interface{}); ok {
+		if t, ok := m[key].(map[string]interface{}); ok {
+			tid.OrgId, _ = t["orgId"].(string)
+			tid.AppId, _ = t["appId"].(string)
+		}
+	}
+	return tid
+}
+
+func tenantMatches(tid tenant.Id, orgId string, appId string) bool {
+	return (orgId == "" || tid.OrgId == orgId) && (appId == "" || tid.AppId == appId)
+}
+
+// newGraphqlSchema builds the read only schema of the graphql endpoint, object types are derived from the types
+// served by the REST API.
+//
+// The resolvers do not check tenant access, they rely on authenticateMiddleware restricting /ears/graphql to admin
+// clients by verifying the token with a nil tenant. Opening the endpoint to tenant clients requires filtering every
+// resolver by the tenants of the caller first.
+func (a *APIManager) newGraphqlSchema() (gql.Schema, error) {
+	types := graphql.NewTypes()
+	tenantArgs := gql.FieldConfigArgument{
+		"orgId": &gql.ArgumentConfig{Type: gql.String},
+		"appId": &gql.ArgumentConfig{Type: gql.String},
+	}
+	withTenantArgs := func(args gql.FieldConfigArgument) gql.FieldConfigArgument {
+		for name, arg := range tenantArgs {
+			args[name] = arg
+		}
+		return args
+	}
+	routeType := types.Object("Route", route.Config{}, gql.Fields{
+		"stats": &gql.Field{
+			Type:        types.Output(reflect.TypeOf(route.StatsSnapshot{})),
+			Description: "statistics of the route if it runs on this instance",
+			Resolve:     a.resolveRouteStats,
+		},
+	})
+	fragmentType := types.Object("Fragment", route.PluginConfig{}, gql.Fields{
+		"tenant": &gql.Field{Type: types.Output(reflect.TypeOf(tenant.Id{}))},
+	})
+	tenantType := types.Object("Tenant", tenant.Config{}, gql.Fields{
+		"usage": &gql.Field{
+			Type:        types.Output(reflect.TypeOf(tablemgr.TenantUsage{})),
+			Description: "usage of the tenant on this instance",
+			Resolve:     a.resolveTenantUsage,
+		},
+	})
+	pluginStatusField := func(name string, sample interface{}, getStatus func(ctx context.Context) (interface{}, error)) *gql.Field {
+		return &gql.Field{
+			Type:    gql.NewList(types.Object(name, sample, gql.Fields{"id": &gql.Field{Type: gql.String}})),
+			Args:    withTenantArgs(gql.FieldConfigArgument{}),
+			Resolve: a.resolvePluginStatus(getStatus),
+		}
+	}
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"routes": &gql.Field{
+				Type: gql.NewList(routeType),
+				Args: withTenantArgs(gql.FieldConfigArgument{
+					"id":     &gql.ArgumentConfig{Type: gql.String},
+					"status": &gql.ArgumentConfig{Type: gql.String, Description: "running or stopped"},
+					"label":  &gql.ArgumentConfig{Type: gql.NewList(gql.String), Description: "label selectors"},
+				}),
+				Resolve: a.resolveRoutes,
+			},
+			"fragments": &gql.Field{
+				Type:    gql.NewList(fragmentType),
+				Args:    withTenantArgs(gql.FieldConfigArgument{"id": &gql.ArgumentConfig{Type: gql.String}}),
+				Resolve: a.resolveFragments,
+			},
+			"tenants": &gql.Field{
+				Type:    gql.NewList(tenantType),
+				Args:    withTenantArgs(gql.FieldConfigArgument{}),
+				Resolve: a.resolveTenants,
+			},
+			"senders": pluginStatusField("Sender", plugin.SenderStatus{}, func(ctx context.Context) (interface{}, error) {
+				return a.routingTableMgr.GetAllSendersStatus(ctx)
+			}),
+			"receivers": pluginStatusField("Receiver", plugin.ReceiverStatus{}, func(ctx context.Context) (interface{}, error) {
+				return a.routingTableMgr.GetAllReceiversStatus(ctx)
+			}),
+			"filters": pluginStatusField("Filter", plugin.FilterStatus{}, func(ctx context.Context) (interface{}, error) {
+				return a.routingTableMgr.GetAllFiltersStatus(ctx)
+			}),
+		},
+	})
+	return gql.NewSchema(gql.SchemaConfig{Query: query})
+}
+
+// resolveRoutes lists routes filtered by tenant, id, status and label selectors
+func (a *APIManager) resolveRoutes(p gql.ResolveParams) (interface{}, error) {
+	orgId, appId := stringArg(p, "orgId"), stringArg(p, "appId")
+	id, status := stringArg(p, "id"), stringArg(p, "status")
+	ls, err := route.ParseLabelSelector(stringListArg(p, "label"))
+	if err != nil {
+		return nil, err
+	}
+	var routes []route.Config
+	if orgId != "" && appId != "" {
+		routes, err = a.routingTableMgr.GetAllTenantRoutes(p.Context, tenant.Id{OrgId: orgId, AppId: appId})
+	} else {
+		routes, err = a.routingTableMgr.GetAllRoutes(p.Context)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0)
+	for _, rc := range route.FilterByLabels(routes, ls) {
+		if !tenantMatches(rc.TenantId, orgId, appId) || (id != "" && rc.Id != id) || (status != "" && rc.Status != status) {
+			continue
+		}
+		generic, err := toGeneric(rc)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, generic)
+	}
+	return result, nil
+}
+
+// resolveRouteStats looks up the stats of a route, null if the route does not run on this instance
+func (a *APIManager) resolveRouteStats(p gql.ResolveParams) (interface{}, error) {
+	routeId, _ := p.Source.(map[string]interface{})["id"].(string)
+	snapshot, err := a.routingTableMgr.GetRouteStats(p.Context, tenantOf(p.Source, "tenant"), routeId)
+	if err != nil {
+		return nil, nil
+	}
+	return toGeneric(snapshot)
+}
+
+// resolveFragments lists fragments filtered by tenant and id, each fragment carries its tenant
+func (a *APIManager) resolveFragments(p gql.ResolveParams) (interface{}, error) {
+	orgId, appId, id := stringArg(p, "orgId"), stringArg(p, "appId"), stringArg(p, "id")
+	var tenants []tenant.Id
+	if orgId != "" && appId != "" {
+		tenants = []tenant.Id{{OrgId: orgId, AppId: appId}}
+	} else {
+		configs, err := a.tenantStorer.GetAllConfigs(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range configs {
+			if tenantMatches(config.Tenant, orgId, appId) {
+				tenants = append(tenants, config.Tenant)
+			}
+		}
+	}
+	result := make([]interface{}, 0)
+	for _, tid := range tenants {
+		fragments, err := a.routingTableMgr.GetAllTenantFragments(p.Context, tid)
+		if err != nil {
+			return nil, err
+		}
+		for _, fragment := range fragments {
+			if id != "" && fragment.FragmentName != id {
+				continue
+			}
+			generic, err := toGeneric(fragment)
+			if err != nil {
+				return nil, err
+			}
+			generic.(map[string]interface{})["tenant"] = map[string]interface{}{"orgId": tid.OrgId, "appId": tid.AppId}
+			result = append(result, generic)
+		}
+	}
+	return result, nil
+}
+
+// resolveTenants lists tenant configs filtered by tenant
+func (a *APIManager) resolveTenants(p gql.ResolveParams) (interface{}, error) {
+	orgId, appId := stringArg(p, "orgId"), stringArg(p, "appId")
+	configs, err := a.tenantStorer.GetAllConfigs(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0)
+	for _, config := range configs {
+		if !tenantMatches(config.Tenant, orgId, appId) {
+			continue
+		}
+		generic, err := toGeneric(config)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, generic)
+	}
+	return result, nil
+}
+
+// resolveTenantUsage looks up the usage of a tenant on this instance
+func (a *APIManager) resolveTenantUsage(p gql.ResolveParams) (interface{}, error) {
+	usage, err := a.routingTableMgr.GetTenantUsage(p.Context, tenantOf(p.Source, "tenant"))
+	if err != nil {
+		return nil, err
+	}
+	return toGeneric(usage)
+}
+
+// resolvePluginStatus lists plugin instances filtered by tenant, each instance carries its id
+func (a *APIManager) resolvePluginStatus(getStatus func(ctx context.Context) (interface{}, error)) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		orgId, appId := stringArg(p, "orgId"), stringArg(p, "appId")
+		statuses, err := getStatus(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		generic, err := toGeneric(statuses)
+		if err != nil {
+			return nil, err
+		}
+		byId, _ := generic.(map[string]interface{})
+		ids := make([]string, 0, len(byId))
+		for id := range byId {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		result := make([]interface{}, 0)
+		for _, id := range ids {
+			status, ok := byId[id].(map[string]interface{})
+			if !ok || !tenantMatches(tenantOf(status, "Tid"), orgId, appId) {
+				continue
+			}
+			status["id"] = id
+			result = append(result, status)
+		}
+		return result, nil
+	}
+}
+
+// parseGraphqlRequest reads the query from the query parameters of GET requests and from the body of POST requests,
+// either as json or, with content type application/graphql, as plain query
+func (a *APIManager) parseGraphqlRequest(r *http.Request) (*graphqlRequest, ApiError) {
+	req := &graphqlRequest{}
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		if params.Get("variables") != "" {
+			err := json.Unmarshal([]byte(params.Get("variables")), &req.Variables)
+			if err != nil {
+				return nil, &BadRequestError{"bad graphql variables", err}
+			}
+		}
+		return req, nil
+	}
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_GRAPHQL)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+		req.Query = string(body)
+		return req, nil
+	}
+	err := json.Unmarshal(body, req)
+	if err != nil {
+		return nil, &BadRequestError{"bad graphql request", err}
+	}
+	return req, nil
+}
+
+func respondGraphql(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (a *APIManager) graphqlHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, apiErr := a.parseGraphqlRequest(r)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "graphqlHandler").Str("error", apiErr.Error()).Msg("bad graphql request")
+		respondGraphql(w, apiErr.StatusCode(), map[string]interface{}{"errors": []graphqlError{{Message: apiErr.Error()}}})
+		return
+	}
+	result := gql.Do(gql.Params{
+		Schema:         a.graphqlSchema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        ctx,
+	})
+	for _, err := range result.Errors {
+		log.Ctx(ctx).Error().Str("op", "graphqlHandler").Str("error", err.Message).Msg("graphql error")
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("graphqlErrorCount", len(result.Errors)))
+	// queries failing to parse or validate are not executed and yield no data
+	if result.Data == nil && result.HasErrors() {
+		respondGraphql(w, http.StatusBadRequest, result)
+		return
+	}
+	respondGraphql(w, http.StatusOK, result)
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// gzipResponseWriter compresses the response body, compression starts with the first write so that
+// responses without body such as 304 Not Modified are passed through unchanged
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	noBody      bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.noBody = status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified
+	if !w.noBody {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+// Flush writes pending compressed data to the client, needed for streaming responses such as route tails
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
+
+// acceptsGzip reports whether the client accepts gzip encoded responses
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc = strings.TrimSpace(enc)
+		if idx := strings.Index(enc, ";"); idx >= 0 {
+			if strings.ReplaceAll(enc[idx+1:], " ", "") == "q=0" {
+				continue
+			}
+			enc = strings.TrimSpace(enc[:idx])
+		}
+		if strings.EqualFold(enc, "gzip") {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipMiddleware decompresses request bodies sent with Content-Encoding gzip and compresses responses
+// for clients sending Accept-Encoding gzip
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), "gzip") {
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil && err != io.EOF {
+				log.Ctx(ctx).Error().Str("op", "gzipMiddleware").Str("error", err.Error()).Msg("bad gzip request body")
+				resp := ErrorResponse(&BadRequestError{"bad gzip request body", err})
+				resp.Respond(ctx, w, doYaml(r))
+				return
+			}
+			if gr != nil {
+				defer gr.Close()
+				r.Body = gr
+			} else {
+				r.Body = http.NoBody
+			}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		}
+		if !acceptsGzip(r) || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer func() {
+			err := gw.close()
+			if err != nil {
+				log.Ctx(ctx).Error().Str("op", "gzipMiddleware").Str("error", err.Error()).Msg("cannot complete gzip response")
+			}
+		}()
+		next.ServeHTTP(gw, r)
+	})
+}
@@ -19,26 +19,38 @@ import (
 	"embed"
 	"encoding/json"
 	"errors"
+	"fmt"
 	yaml "github.com/goccy/go-yaml"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	gql "github.com/graphql-go/graphql"
 	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
 	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db"
 	"github.com/xmidt-org/ears/internal/pkg/jwt"
 	"github.com/xmidt-org/ears/internal/pkg/plugin"
 	"github.com/xmidt-org/ears/internal/pkg/quota"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
 	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
 	"github.com/xmidt-org/ears/pkg/app"
 	"github.com/xmidt-org/ears/pkg/cli"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/hasher"
 	logs2 "github.com/xmidt-org/ears/pkg/logs"
+	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/trace"
-	"io/ioutil"
+	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -51,6 +63,11 @@ var WebsiteFS embed.FS
 
 const (
 	TENANT_CACHE_TTL_SECS = 30
+
+	DEFAULT_TAIL_TIMEOUT_SECS = 60
+	MAX_TAIL_TIMEOUT_SECS     = 600
+	TAIL_BUFFER_SIZE          = 100
+	TAIL_REDACTED_VALUE       = "***"
 )
 
 var (
@@ -64,14 +81,23 @@ type APIManager struct {
 	tenantStorer               tenant.TenantStorer
 	quotaManager               *quota.QuotaManager
 	jwtManager                 jwt.JWTConsumer
+	auditStorer                audit.AuditStorer
 	tenantCache                *TenantCache
+	eventStatusCache           *EventStatusCache
 	addRouteSuccessRecorder    metric.BoundFloat64Counter
 	addRouteFailureRecorder    metric.BoundFloat64Counter
 	removeRouteSuccessRecorder metric.BoundFloat64Counter
 	removeRouteFailureRecorder metric.BoundFloat64Counter
-	globalWebhookOrg           string
-	globalWebhookApp           string
-	globalWebhookRouteId       string
+	webhookMappings            []webhookMapping
+	webhookLock                sync.RWMutex
+	maxBodyBytes               map[string]int64
+	cors                       *corsConfig
+	trustedProxies             []*net.IPNet
+	watchHub                   *WatchHub
+	snapshotManager            *snapshot.SnapshotManager
+	configReloader             *ConfigReloader
+	graphqlSchema              gql.Schema
+	tenantConfigLock           sync.Mutex // held from the If-Match check of a tenant config until it is written
 	sync.RWMutex
 }
 
@@ -130,7 +156,7 @@ func (c *TenantCache) GetTenant(tenantId string) *tenant.Config {
 	return &item.Config
 }
 
-func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.TenantStorer, quotaManager *quota.QuotaManager, jwtManager jwt.JWTConsumer, config config.Config) (*APIManager, error) {
+func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.TenantStorer, quotaManager *quota.QuotaManager, jwtManager jwt.JWTConsumer, snapshotManager *snapshot.SnapshotManager, auditStorer audit.AuditStorer, config config.Config) (*APIManager, error) {
 	api := &APIManager{
 		muxRouter:       mux.NewRouter(),
 		routingTableMgr: routingMgr,
@@ -138,12 +164,53 @@ func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.
 		quotaManager:    quotaManager,
 		jwtManager:      jwtManager,
 		tenantCache:     NewTenantCache(TENANT_CACHE_TTL_SECS),
+		maxBodyBytes:    parseMaxBodyBytes(config),
+		watchHub:        NewWatchHub(),
+		snapshotManager: snapshotManager,
+		auditStorer:     auditStorer,
+	}
+
+	// changes synced from other instances or made outside the api are streamed to watchers as well
+	api.routingTableMgr.RegisterLocalSyncer(syncer.ITEM_TYPE_ROUTE, api.watchHub.localSyncer(audit.ItemTypeRoute))
+	api.routingTableMgr.RegisterLocalSyncer(syncer.ITEM_TYPE_TENANT, api.watchHub.localSyncer(audit.ItemTypeTenant))
+	if watcher, ok := tenantStorer.(syncer.ItemWatcher); ok {
+		watcher.RegisterLocalSyncer(syncer.ITEM_TYPE_TENANT, api.watchHub.localSyncer(audit.ItemTypeTenant))
+	}
+
+	var err error
+	api.cors, err = parseCorsConfig(config)
+	if err != nil {
+		return nil, err
 	}
+	api.trustedProxies, err = parseTrustedProxies(config)
+	if err != nil {
+		return nil, err
+	}
+	api.graphqlSchema, err = api.newGraphqlSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	eventStatusTtlSecs := EVENT_STATUS_TTL_SECS
+	eventStatusMaxEntries := EVENT_STATUS_MAX_ENTRIES
 
+	auditMaxRecords := audit.DefaultMaxRecords
 	if config != nil {
-		api.globalWebhookApp = config.GetString("ears.api.webhook.app")
-		api.globalWebhookOrg = config.GetString("ears.api.webhook.org")
-		api.globalWebhookRouteId = config.GetString("ears.api.webhook.routeId")
+		api.webhookMappings, err = webhookMappingsFromConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		if config.GetInt("ears.audit.maxRecords") > 0 {
+			auditMaxRecords = config.GetInt("ears.audit.maxRecords")
+		}
+		if config.GetInt("ears.api.eventStatusTtlSecs") > 0 {
+			eventStatusTtlSecs = config.GetInt("ears.api.eventStatusTtlSecs")
+		}
+		eventStatusMaxEntries = config.GetInt("ears.api.eventStatusMaxEntries")
+	}
+	api.eventStatusCache = NewEventStatusCache(eventStatusTtlSecs, eventStatusMaxEntries)
+	if api.auditStorer == nil {
+		api.auditStorer = audit.NewInMemoryAuditStorer(auditMaxRecords)
 	}
 
 	api.muxRouter.PathPrefix("/ears/openapi").Handler(
@@ -151,13 +218,23 @@ func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.
 	)
 
 	api.muxRouter.HandleFunc("/ears/version", api.versionHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/health", api.healthHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/ready", api.readyHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/live", api.liveHandler).Methods(http.MethodGet)
 
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}", api.addRouteHandler).Methods(http.MethodPut)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/event", api.sendEventHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/simulate", api.simulateRouteHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/stats", api.getRouteStatsHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/errors", api.getRouteErrorsHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/tail", api.tailRouteHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}/restart", api.restartRouteHandler).Methods(http.MethodPost)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes", api.addRouteHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/events/{eventId}/status", api.getEventStatusHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}", api.removeRouteHandler).Methods(http.MethodDelete)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes/{routeId}", api.getRouteHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/routes", api.getAllTenantRoutesHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/topology", api.getTopologyHandler).Methods(http.MethodGet)
 
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/senders", api.getAllSendersHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/receivers", api.getAllReceiversHandler).Methods(http.MethodGet)
@@ -167,11 +244,13 @@ func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/fragments", api.addFragmentHandler).Methods(http.MethodPost)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/fragments/{fragmentId}", api.removeFragmentHandler).Methods(http.MethodDelete)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/fragments/{fragmentId}", api.getFragmentHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/fragments/{fragmentId}/usage", api.getFragmentUsageHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/fragments", api.getAllTenantFragmentsHandler).Methods(http.MethodGet)
 
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/config", api.getTenantConfigHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/config", api.setTenantConfigHandler).Methods(http.MethodPut)
 	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/config", api.deleteTenantConfigHandler).Methods(http.MethodDelete)
+	api.muxRouter.HandleFunc("/ears/v1/orgs/{orgId}/applications/{appId}/config/usage", api.getTenantUsageHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/routes", api.getAllRoutesHandler).Methods(http.MethodGet)
 
 	api.muxRouter.HandleFunc("/ears/v1/tenants", api.getAllTenantConfigsHandler).Methods(http.MethodGet)
@@ -179,10 +258,20 @@ func NewAPIManager(routingMgr tablemgr.RoutingTableManager, tenantStorer tenant.
 	api.muxRouter.HandleFunc("/ears/v1/receivers", api.getAllReceiversHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/filters", api.getAllFiltersHandler).Methods(http.MethodGet)
 	api.muxRouter.HandleFunc("/ears/v1/fragments", api.getAllFragmentsHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/audit", api.getAuditRecordsHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/search", api.searchHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/watch", api.watchHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/snapshots", api.getSnapshotsHandler).Methods(http.MethodGet)
+	api.muxRouter.HandleFunc("/ears/v1/snapshots", api.takeSnapshotHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/v1/snapshots/restore", api.restoreSnapshotHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/v1/config/reload", api.reloadConfigHandler).Methods(http.MethodPost)
+	api.muxRouter.HandleFunc("/ears/graphql", api.graphqlHandler).Methods(http.MethodGet, http.MethodPost)
 
 	// for backward compatibility during transition period
 	api.muxRouter.HandleFunc("/eel/v1/events", api.webhookHandler).Methods(http.MethodPost)
 	api.muxRouter.HandleFunc("/ears/v1/events", api.webhookHandler).Methods(http.MethodPost)
+	api.muxRouter.PathPrefix("/eel/v1/events/").HandlerFunc(api.webhookHandler).Methods(http.MethodPost)
+	api.muxRouter.PathPrefix("/ears/v1/events/").HandlerFunc(api.webhookHandler).Methods(http.MethodPost)
 	// metrics
 	// where should meters live (api manager, uberfx, global variables,...)?
 	meter := global.Meter(rtsemconv.EARSMeterName)
@@ -224,6 +313,12 @@ func doYaml(r *http.Request) bool {
 	return strings.Contains(ct, "yaml")
 }
 
+// doDryRun returns true if a request should only be validated but not executed
+func doDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	return dryRun
+}
+
 type versions struct {
 	Version string
 	Config  string
@@ -239,6 +334,184 @@ func (a *APIManager) versionHandler(w http.ResponseWriter, r *http.Request) {
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+// parseTrustedProxies parses the comma separated ip addresses and cidr ranges of ears.api.trustedProxies
+func parseTrustedProxies(config config.Config) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+	if config == nil {
+		return proxies, nil
+	}
+	for _, proxy := range splitList(config.GetString("ears.api.trustedProxies")) {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.New("bad trusted proxy " + proxy + ": " + err.Error())
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+func (a *APIManager) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range a.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// getSourceIp returns the ip address of the caller. X-Forwarded-For is only followed through trusted proxies,
+// starting at the proxy that connected to us, because any other hop could have made up the addresses before it.
+func (a *APIManager) getSourceIp(req *http.Request) string {
+	addr, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		addr = req.RemoteAddr
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0 && a.isTrustedProxy(addr); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr = hop
+	}
+	return addr
+}
+
+// recordAudit adds an audit record for a successful config change and notifies watchers, failures to write the record
+// are logged but do not fail the request
+func (a *APIManager) recordAudit(r *http.Request, tid tenant.Id, itemType string, itemId string, action string, before interface{}, after interface{}) {
+	ctx := r.Context()
+	subject, _ := ctx.Value(subjectCtxKey{}).(string)
+	record := audit.Record{
+		Id:       uuid.New().String(),
+		Time:     time.Now().UnixNano() / int64(time.Millisecond),
+		Tenant:   tid,
+		ItemType: itemType,
+		ItemId:   itemId,
+		Action:   action,
+		Subject:  subject,
+		SourceIp: a.getSourceIp(r),
+		Before:   before,
+		After:    after,
+	}
+	err := a.auditStorer.AddRecord(ctx, record)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "recordAudit").Str("itemType", itemType).Str("itemId", itemId).Msg(err.Error())
+	}
+	a.watchHub.Publish(record)
+}
+
+type healthReport struct {
+	Healthy      bool                     `json:"healthy"`
+	TenantStorer tablemgr.ComponentHealth `json:"tenantStorer"`
+	*tablemgr.Health
+}
+
+// getHealth probes all backends, the instance is considered healthy if storers and sync backend can be reached
+func (a *APIManager) getHealth(ctx context.Context) *healthReport {
+	report := &healthReport{
+		Health: a.routingTableMgr.GetHealth(ctx),
+	}
+	probeId := tenant.Id{OrgId: "_health", AppId: "_health"}
+	_, err := a.tenantStorer.GetConfig(ctx, probeId)
+	var notFoundErr *tenant.TenantNotFoundError
+	if err != nil && !errors.As(err, &notFoundErr) {
+		report.TenantStorer = tablemgr.ComponentHealth{Healthy: false, Error: err.Error()}
+	} else {
+		report.TenantStorer = tablemgr.ComponentHealth{Healthy: true}
+	}
+	report.Healthy = report.TenantStorer.Healthy && report.RouteStorer.Healthy && report.Syncer.Healthy
+	return report
+}
+
+func (a *APIManager) healthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report := a.getHealth(ctx)
+	resp := ItemResponse(report)
+	if !report.Healthy {
+		log.Ctx(ctx).Error().Str("op", "healthHandler").Msg("unhealthy")
+		resp.Status.Code = http.StatusServiceUnavailable
+	}
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// readyHandler tells load balancers whether this instance can serve traffic
+func (a *APIManager) readyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report := a.getHealth(ctx)
+	if !report.Healthy {
+		log.Ctx(ctx).Error().Str("op", "readyHandler").Msg("not ready")
+		resp := ErrorResponse(&ServiceUnavailableError{"backend unavailable"})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemResponse("ready")
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// liveHandler tells Kubernetes the process is responsive, it does not depend on any backend
+func (a *APIManager) liveHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	resp := ItemResponse("alive")
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// routeETag returns the entity tag of a stored route, the status is ignored as it depends on the instance serving the request
+func routeETag(routeConfig *route.Config) string {
+	if routeConfig == nil {
+		return ""
+	}
+	rc := *routeConfig
+	rc.Status = ""
+	return configETag(rc)
+}
+
+// configETag returns the entity tag of a stored config item
+func configETag(item interface{}) string {
+	buf, _ := json.Marshal(item)
+	return `"` + hasher.String(string(buf)) + `"`
+}
+
+// checkIfMatch returns an error if the request has an If-Match header that does not match the entity tag of the current
+// version of an item, an empty entity tag means the item does not exist
+func checkIfMatch(r *http.Request, currentETag string) ApiError {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+	if currentETag == "" {
+		return &PreconditionFailedError{"item does not exist"}
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == currentETag {
+			return nil
+		}
+	}
+	return &PreconditionFailedError{"item has been modified, current version is " + currentETag}
+}
+
+// routeIfMatch returns the precondition checking the If-Match header of a route write and where the precondition
+// keeps the route it was checked against
+func routeIfMatch(r *http.Request) (**route.Config, tablemgr.RoutePrecondition) {
+	var oldRoute *route.Config
+	return &oldRoute, func(current *route.Config) error {
+		oldRoute = current
+		return checkIfMatch(r, routeETag(current))
+	}
+}
+
 func getBearerToken(req *http.Request) string {
 	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
 }
@@ -275,16 +548,17 @@ func (a *APIManager) webhookHandler(w http.ResponseWriter, r *http.Request) {
 	// Solution A: Internally forward request to correct handler function and set necessary URL vars.
 	// This solution is the most efficient but also the least flexible due to hard coding.
 	ctx := r.Context()
-	if a.globalWebhookOrg == "" || a.globalWebhookApp == "" || a.globalWebhookRouteId == "" {
-		log.Ctx(ctx).Error().Str("op", "webhookHandler").Str("error", "no global webhook configured").Msg("no global webhook configured")
-		resp := ErrorResponse(convertToApiError(ctx, errors.New("no global webhook configured")))
+	mapping := a.findWebhookMapping(r)
+	if mapping == nil {
+		log.Ctx(ctx).Error().Str("op", "webhookHandler").Str("error", "no webhook mapping matches").Str("path", r.URL.Path).Msg("no webhook mapping matches")
+		resp := ErrorResponse(&NotFoundError{"no webhook mapping matches request"})
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
 	r = mux.SetURLVars(r, map[string]string{
-		"orgId":   a.globalWebhookOrg,
-		"appId":   a.globalWebhookApp,
-		"routeId": a.globalWebhookRouteId,
+		"orgId":   mapping.orgId,
+		"appId":   mapping.appId,
+		"routeId": mapping.routeId,
 	})
 	a.sendEventHandler(w, r)
 	// Solution B: Forward request via network stack. Does create an extra hop but it allows for a more
@@ -360,15 +634,15 @@ func (a *APIManager) sendEventHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(err.Error())
-		resp := ErrorResponse(&InternalServerError{err})
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_EVENT)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(apiErr.Error())
+		resp := ErrorResponse(apiErr)
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
 	var payload interface{}
-	err = json.Unmarshal(body, &payload)
+	err := json.Unmarshal(body, &payload)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(err.Error())
 		a.addRouteFailureRecorder.Add(ctx, 1.0)
@@ -390,6 +664,23 @@ func (a *APIManager) sendEventHandler(w http.ResponseWriter, r *http.Request) {
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	if a.quotaManager != nil {
+		err = a.quotaManager.Allow(ctx, *tid)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(err.Error())
+			apiErr := convertToApiError(ctx, err)
+			if tooMany, ok := apiErr.(*TooManyRequestsError); ok {
+				w.Header().Set("Retry-After", strconv.Itoa(tooMany.RetryAfter()))
+			}
+			resp := ErrorResponse(apiErr)
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	if r.URL.Query().Get("async") == "true" {
+		a.sendEventAsync(w, r, *tid, routeId, payload)
+		return
+	}
 	traceId, err := a.routingTableMgr.RouteEvent(ctx, *tid, routeId, payload)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(err.Error())
@@ -404,6 +695,106 @@ func (a *APIManager) sendEventHandler(w http.ResponseWriter, r *http.Request) {
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+// sendEventAsync routes an event without waiting for the outcome which can be polled via the event status API
+func (a *APIManager) sendEventAsync(w http.ResponseWriter, r *http.Request, tid tenant.Id, routeId string, payload interface{}) {
+	ctx := r.Context()
+	eventId := uuid.New().String()
+	// status must be known before routing because the event may be acked right away
+	a.eventStatusCache.Add(tid, EventStatus{
+		EventId:   eventId,
+		RouteId:   routeId,
+		Status:    EventStatusPending,
+		Submitted: time.Now().UnixNano() / 1e6,
+	})
+	traceId, err := a.routingTableMgr.RouteEventAsync(ctx, tid, routeId, eventId, payload, func(err error) {
+		a.eventStatusCache.Update(tid, eventId, func(status *EventStatus) {
+			status.Completed = time.Now().UnixNano() / 1e6
+			if err != nil {
+				status.Status = EventStatusNacked
+				status.Error = err.Error()
+			} else {
+				status.Status = EventStatusAcked
+			}
+		})
+	})
+	if err != nil {
+		a.eventStatusCache.Remove(tid, eventId)
+		log.Ctx(ctx).Error().Str("op", "sendEventHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	a.eventStatusCache.Update(tid, eventId, func(status *EventStatus) {
+		status.TraceId = traceId
+	})
+	item := make(map[string]string)
+	item["routeId"] = routeId
+	item["eventId"] = eventId
+	item["tx.traceId"] = traceId
+	resp := AcceptedResponse(item)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+func (a *APIManager) getEventStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getEventStatusHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	eventId := vars["eventId"]
+	status := a.eventStatusCache.Get(*tid, eventId)
+	if status == nil {
+		log.Ctx(ctx).Error().Str("op", "getEventStatusHandler").Str("eventId", eventId).Msg("unknown event")
+		resp := ErrorResponse(&NotFoundError{"no status for event " + eventId})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemResponse(status)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+func (a *APIManager) simulateRouteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "simulateRouteHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_SIMULATE)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "simulateRouteHandler").Msg(apiErr.Error())
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	var payload interface{}
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "simulateRouteHandler").Msg(err.Error())
+		resp := ErrorResponse(&BadRequestError{"cannot unmarshal request body", err})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	routeId := vars["routeId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
+	result, err := a.routingTableMgr.SimulateRoute(ctx, *tid, routeId, payload)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "simulateRouteHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemResponse(result)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
 func (a *APIManager) addRouteHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -423,11 +814,11 @@ func (a *APIManager) addRouteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	routeId := vars["routeId"]
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Ctx(ctx).Error().Str("op", "addRouteHandler").Msg(err.Error())
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_ROUTE)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "addRouteHandler").Msg(apiErr.Error())
 		a.addRouteFailureRecorder.Add(ctx, 1.0)
-		resp := ErrorResponse(&InternalServerError{err})
+		resp := ErrorResponse(apiErr)
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
@@ -454,7 +845,21 @@ func (a *APIManager) addRouteHandler(w http.ResponseWriter, r *http.Request) {
 	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
 	route.TenantId.AppId = tid.AppId
 	route.TenantId.OrgId = tid.OrgId
-	err = a.routingTableMgr.AddRoute(ctx, &route)
+	if doDryRun(r) {
+		err = a.routingTableMgr.ValidateRoute(ctx, &route)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("op", "addRouteHandler").Bool("dryRun", true).Msg(err.Error())
+			resp := ErrorResponse(convertToApiError(ctx, err))
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+		resp := ItemResponse(route)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	// the If-Match header is checked by the table manager so that the route cannot change before it is written
+	oldRoute, precondition := routeIfMatch(r)
+	err = a.routingTableMgr.AddRouteIf(ctx, &route, precondition)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "addRouteHandler").Msg(err.Error())
 		a.addRouteFailureRecorder.Add(ctx, 1.0)
@@ -464,6 +869,15 @@ func (a *APIManager) addRouteHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		a.addRouteSuccessRecorder.Add(ctx, 1.0)
 	}
+	newRoute, err := a.routingTableMgr.GetRoute(ctx, *tid, route.Id)
+	if err == nil {
+		w.Header().Set("ETag", routeETag(newRoute))
+	}
+	if *oldRoute != nil {
+		a.recordAudit(r, *tid, audit.ItemTypeRoute, route.Id, audit.ActionUpdate, *oldRoute, route)
+	} else {
+		a.recordAudit(r, *tid, audit.ItemTypeRoute, route.Id, audit.ActionCreate, nil, route)
+	}
 	resp := ItemResponse(route)
 	resp.Respond(ctx, w, doYaml(r))
 }
@@ -481,7 +895,8 @@ func (a *APIManager) removeRouteHandler(w http.ResponseWriter, r *http.Request)
 	}
 	routeId := vars["routeId"]
 	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
-	err := a.routingTableMgr.RemoveRoute(ctx, *tid, routeId)
+	oldRoute, precondition := routeIfMatch(r)
+	err := a.routingTableMgr.RemoveRouteIf(ctx, *tid, routeId, precondition)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "removeRouteHandler").Msg(err.Error())
 		a.removeRouteFailureRecorder.Add(ctx, 1.0)
@@ -491,6 +906,7 @@ func (a *APIManager) removeRouteHandler(w http.ResponseWriter, r *http.Request)
 	} else {
 		a.removeRouteSuccessRecorder.Add(ctx, 1.0)
 	}
+	a.recordAudit(r, *tid, audit.ItemTypeRoute, routeId, audit.ActionDelete, *oldRoute, nil)
 	resp := ItemResponse(routeId)
 	resp.Respond(ctx, w, doYaml(r))
 }
@@ -514,10 +930,222 @@ func (a *APIManager) getRouteHandler(w http.ResponseWriter, r *http.Request) {
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	w.Header().Set("ETag", routeETag(routeConfig))
 	resp := ItemResponse(routeConfig)
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+func (a *APIManager) getRouteStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getRouteStatsHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	routeId := vars["routeId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
+	stats, err := a.routingTableMgr.GetRouteStats(ctx, *tid, routeId)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getRouteStatsHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemResponse(stats)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// getRouteErrorsHandler returns the most recent filter, sender and parse errors of a route for quick triage
+func (a *APIManager) getRouteErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getRouteErrorsHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	routeId := vars["routeId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
+	routeErrors, err := a.routingTableMgr.GetRouteErrors(ctx, *tid, routeId)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getRouteErrorsHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemsResponse(routeErrors)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+func (a *APIManager) restartRouteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "restartRouteHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	routeId := vars["routeId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
+	err := a.routingTableMgr.RestartRoute(ctx, *tid, routeId)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "restartRouteHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	item := make(map[string]string)
+	item["routeId"] = routeId
+	resp := ItemResponse(item)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// tailRouteHandler streams copies of a sample of the events flowing through a route as server sent events until
+// the timeout expires, the maximum number of events has been sent or the client disconnects
+func (a *APIManager) tailRouteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	routeId := vars["routeId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSRouteId.String(routeId))
+	query := r.URL.Query()
+	sampleRate := 1.0
+	if s := query.Get("sample"); s != "" {
+		var err error
+		sampleRate, err = strconv.ParseFloat(s, 64)
+		if err != nil || sampleRate <= 0 || sampleRate > 1 {
+			log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg("bad sample rate " + s)
+			resp := ErrorResponse(&BadRequestError{"sample must be a number greater than 0 and at most 1", err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	timeoutSecs := DEFAULT_TAIL_TIMEOUT_SECS
+	if s := query.Get("timeout"); s != "" {
+		var err error
+		timeoutSecs, err = strconv.Atoi(s)
+		if err != nil || timeoutSecs <= 0 || timeoutSecs > MAX_TAIL_TIMEOUT_SECS {
+			log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg("bad timeout " + s)
+			resp := ErrorResponse(&BadRequestError{"timeout must be between 1 and " + strconv.Itoa(MAX_TAIL_TIMEOUT_SECS) + " seconds", err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	maxEvents := 0
+	if s := query.Get("maxEvents"); s != "" {
+		var err error
+		maxEvents, err = strconv.Atoi(s)
+		if err != nil || maxEvents < 0 {
+			log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg("bad max events " + s)
+			resp := ErrorResponse(&BadRequestError{"maxEvents must be a non-negative integer", err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	redactPaths := query["redact"]
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg("streaming not supported")
+		resp := ErrorResponse(&InternalServerError{errors.New("streaming not supported")})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	events := make(chan event.Event, TAIL_BUFFER_SIZE)
+	removeTap, err := a.routingTableMgr.TapRoute(ctx, *tid, routeId, sampleRate, func(e event.Event) {
+		// drop events rather than slowing down the route when the client cannot keep up
+		select {
+		case events <- e:
+		default:
+		}
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	defer removeTap()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	timer := time.NewTimer(time.Duration(timeoutSecs) * time.Second)
+	defer timer.Stop()
+	cnt := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			w.Write([]byte("event: timeout\ndata: {}\n\n"))
+			flusher.Flush()
+			return
+		case e := <-events:
+			for _, path := range redactPaths {
+				if v, _, _ := e.GetPathValue(path); v != nil {
+					e.SetPathValue(path, TAIL_REDACTED_VALUE, false)
+				}
+			}
+			buf, err := json.Marshal(map[string]interface{}{
+				"id":       e.Id(),
+				"metadata": e.Metadata(),
+				"payload":  e.Payload(),
+			})
+			if err != nil {
+				log.Ctx(ctx).Error().Str("op", "tailRouteHandler").Msg(err.Error())
+				continue
+			}
+			w.Write([]byte("id: " + e.Id() + "\ndata: " + string(buf) + "\n\n"))
+			flusher.Flush()
+			cnt++
+			if maxEvents > 0 && cnt >= maxEvents {
+				return
+			}
+		}
+	}
+}
+
+func (a *APIManager) getTopologyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getTopologyHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	topology, err := a.routingTableMgr.GetTopology(ctx, *tid)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getTopologyHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	if routeId := r.URL.Query().Get("routeId"); routeId != "" {
+		topology = topology.ForRoute(routeId)
+	}
+	if r.URL.Query().Get("shared") == "true" {
+		topology = topology.SharedOnly()
+	}
+	resp := ItemResponse(topology)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
 func (a *APIManager) getAllTenantRoutesHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -528,6 +1156,13 @@ func (a *APIManager) getAllTenantRoutesHandler(w http.ResponseWriter, r *http.Re
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	ls, err := route.ParseLabelSelector(r.URL.Query()["label"])
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "GetAllTenantRoutes").Msg(err.Error())
+		resp := ErrorResponse(&BadRequestError{"bad label selector", err})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
 	allRouteConfigs, err := a.routingTableMgr.GetAllTenantRoutes(ctx, *tid)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "GetAllTenantRoutes").Msg(err.Error())
@@ -535,6 +1170,7 @@ func (a *APIManager) getAllTenantRoutesHandler(w http.ResponseWriter, r *http.Re
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	allRouteConfigs = route.FilterByLabels(allRouteConfigs, ls)
 	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("routeCount", len(allRouteConfigs)))
 	resp := ItemsResponse(allRouteConfigs)
 	resp.Respond(ctx, w, doYaml(r))
@@ -542,6 +1178,13 @@ func (a *APIManager) getAllTenantRoutesHandler(w http.ResponseWriter, r *http.Re
 
 func (a *APIManager) getAllRoutesHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	ls, err := route.ParseLabelSelector(r.URL.Query()["label"])
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "GetAllRoutes").Msg(err.Error())
+		resp := ErrorResponse(&BadRequestError{"bad label selector", err})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
 	allRouteConfigs := make([]route.Config, 0)
 	configs, err := a.tenantStorer.GetAllConfigs(ctx)
 	if err != nil {
@@ -558,7 +1201,7 @@ func (a *APIManager) getAllRoutesHandler(w http.ResponseWriter, r *http.Request)
 			resp.Respond(ctx, w, doYaml(r))
 			return
 		}
-		allRouteConfigs = append(allRouteConfigs, tenantRouteConfigs...)
+		allRouteConfigs = append(allRouteConfigs, route.FilterByLabels(tenantRouteConfigs, ls)...)
 	}
 	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("routeCount", len(allRouteConfigs)))
 	resp := ItemsResponse(allRouteConfigs)
@@ -702,6 +1345,29 @@ func (a *APIManager) getFragmentHandler(w http.ResponseWriter, r *http.Request)
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+func (a *APIManager) getFragmentUsageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getFragmentUsageHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	fragmentId := vars["fragmentId"]
+	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSFragmentId.String(fragmentId))
+	usage, err := a.routingTableMgr.GetFragmentUsage(ctx, *tid, fragmentId)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getFragmentUsageHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemsResponse(usage)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
 func (a *APIManager) removeFragmentHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -715,7 +1381,9 @@ func (a *APIManager) removeFragmentHandler(w http.ResponseWriter, r *http.Reques
 	}
 	fragmentId := vars["fragmentId"]
 	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSFragmentId.String(fragmentId))
-	err := a.routingTableMgr.RemoveFragment(ctx, *tid, fragmentId)
+	oldFragment, oldErr := a.routingTableMgr.GetFragment(ctx, *tid, fragmentId)
+	force := r.URL.Query().Get("force") == "true"
+	err := a.routingTableMgr.RemoveFragment(ctx, *tid, fragmentId, force)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "removeFragmentHandler").Msg(err.Error())
 		a.removeRouteFailureRecorder.Add(ctx, 1.0)
@@ -725,6 +1393,11 @@ func (a *APIManager) removeFragmentHandler(w http.ResponseWriter, r *http.Reques
 	} else {
 		a.removeRouteSuccessRecorder.Add(ctx, 1.0)
 	}
+	var before interface{}
+	if oldErr == nil {
+		before = oldFragment
+	}
+	a.recordAudit(r, *tid, audit.ItemTypeFragment, fragmentId, audit.ActionDelete, before, nil)
 	resp := ItemResponse(fragmentId)
 	resp.Respond(ctx, w, doYaml(r))
 }
@@ -748,11 +1421,11 @@ func (a *APIManager) addFragmentHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	fragmentId := vars["fragmentId"]
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Ctx(ctx).Error().Str("op", "addFragmentHandler").Msg(err.Error())
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_FRAGMENT)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "addFragmentHandler").Msg(apiErr.Error())
 		a.addRouteFailureRecorder.Add(ctx, 1.0)
-		resp := ErrorResponse(&InternalServerError{err})
+		resp := ErrorResponse(apiErr)
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
@@ -782,6 +1455,7 @@ func (a *APIManager) addFragmentHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	trace.SpanFromContext(ctx).SetAttributes(rtsemconv.EARSFragmentId.String(fragmentId))
+	oldFragment, oldErr := a.routingTableMgr.GetFragment(ctx, *tid, fragmentConfig.FragmentName)
 	err = a.routingTableMgr.AddFragment(ctx, *tid, fragmentConfig)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "addFragmentHandler").Msg(err.Error())
@@ -789,6 +1463,11 @@ func (a *APIManager) addFragmentHandler(w http.ResponseWriter, r *http.Request)
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	if oldErr == nil {
+		a.recordAudit(r, *tid, audit.ItemTypeFragment, fragmentConfig.FragmentName, audit.ActionUpdate, oldFragment, fragmentConfig)
+	} else {
+		a.recordAudit(r, *tid, audit.ItemTypeFragment, fragmentConfig.FragmentName, audit.ActionCreate, nil, fragmentConfig)
+	}
 	resp := ItemResponse(fragmentConfig)
 	resp.Respond(ctx, w, doYaml(r))
 }
@@ -810,10 +1489,60 @@ func (a *APIManager) getTenantConfigHandler(w http.ResponseWriter, r *http.Reque
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	w.Header().Set("ETag", configETag(config))
 	resp := ItemResponse(config)
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+type tenantUsageReport struct {
+	QuotaEventsPerSec         int `json:"quotaEventsPerSec"`
+	QuotaBurst                int `json:"quotaBurst,omitempty"`
+	InstanceQuotaEventsPerSec int `json:"instanceQuotaEventsPerSec"` // share of the quota currently granted to this instance
+	*tablemgr.TenantUsage
+}
+
+func (a *APIManager) getTenantUsageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	tid, apiErr := getTenant(ctx, vars)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "getTenantUsageHandler").Str("error", apiErr.Error()).Msg("orgId or appId empty")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	config, err := a.tenantStorer.GetConfig(ctx, *tid)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getTenantUsageHandler").Str("error", err.Error()).Msg("error getting tenant config")
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	usage, err := a.routingTableMgr.GetTenantUsage(ctx, *tid)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getTenantUsageHandler").Str("error", err.Error()).Msg("error getting tenant usage")
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	report := tenantUsageReport{
+		QuotaEventsPerSec: config.Quota.EventsPerSec,
+		QuotaBurst:        config.Quota.Burst,
+		TenantUsage:       usage,
+	}
+	if a.quotaManager != nil {
+		report.InstanceQuotaEventsPerSec = a.quotaManager.TenantInstanceLimit(ctx, *tid)
+	}
+	// utilization is measured against the share of the quota this instance may consume
+	if report.InstanceQuotaEventsPerSec > 0 {
+		for idx := range usage.Windows {
+			usage.Windows[idx].Utilization = usage.Windows[idx].EventsPerSec / float64(report.InstanceQuotaEventsPerSec)
+		}
+	}
+	resp := ItemResponse(report)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
 func (a *APIManager) getAllTenantConfigsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	configs, err := a.tenantStorer.GetAllConfigs(ctx)
@@ -837,15 +1566,15 @@ func (a *APIManager) setTenantConfigHandler(w http.ResponseWriter, r *http.Reque
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Str("error", err.Error()).Msg("error reading request body")
-		resp := ErrorResponse(&InternalServerError{err})
+	body, apiErr := a.readBody(r, BODY_ENDPOINT_TENANT)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Str("error", apiErr.Error()).Msg("error reading request body")
+		resp := ErrorResponse(apiErr)
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
 	var tenantConfig tenant.Config
-	err = yaml.Unmarshal(body, &tenantConfig)
+	err := yaml.Unmarshal(body, &tenantConfig)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Str("error", err.Error()).Msg("error unmarshal request body")
 		resp := ErrorResponse(&BadRequestError{"Cannot unmarshal request body", err})
@@ -853,6 +1582,47 @@ func (a *APIManager) setTenantConfigHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	tenantConfig.Tenant = *tid
+	if !tenantConfig.Quota.Valid() {
+		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Msg("invalid quota")
+		resp := ErrorResponse(&BadRequestError{"quota eventsPerSec and burst cannot be negative and burst cannot be below eventsPerSec", nil})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	if tenantConfig.QuotaAlert != nil && !tenantConfig.QuotaAlert.Valid() {
+		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Msg("invalid quota alert")
+		resp := ErrorResponse(&BadRequestError{"quota alert needs positive thresholds and a url or route ID", nil})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	if tenantConfig.TraceSampling != nil && !tenantConfig.TraceSampling.Valid() {
+		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Msg("invalid trace sampling")
+		resp := ErrorResponse(&BadRequestError{"trace sampling ratio must be between 0 and 1", nil})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	for _, m := range tenantConfig.RoleMappings {
+		if m.Claim == "" || m.Value == "" || !tenant.ValidRole(m.Role) {
+			log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Msg("invalid role mapping")
+			resp := ErrorResponse(&BadRequestError{"role mappings need a claim, a value and one of the roles reader, writer or admin", nil})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	// the tenant config must not change between the If-Match check and the write
+	a.tenantConfigLock.Lock()
+	defer a.tenantConfigLock.Unlock()
+	oldConfig, _ := a.tenantStorer.GetConfig(ctx, *tid)
+	oldETag := ""
+	if oldConfig != nil {
+		oldETag = configETag(oldConfig)
+	}
+	apiErr = checkIfMatch(r, oldETag)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Str("error", apiErr.Error()).Msg("tenant config precondition failed")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
 	err = a.tenantStorer.SetConfig(ctx, tenantConfig)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "setTenantConfigHandler").Str("error", err.Error()).Msg("error setting tenant config")
@@ -860,6 +1630,15 @@ func (a *APIManager) setTenantConfigHandler(w http.ResponseWriter, r *http.Reque
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	newConfig, err := a.tenantStorer.GetConfig(ctx, *tid)
+	if err == nil {
+		w.Header().Set("ETag", configETag(newConfig))
+	}
+	if oldConfig != nil {
+		a.recordAudit(r, *tid, audit.ItemTypeTenant, tid.Key(), audit.ActionUpdate, oldConfig, tenantConfig)
+	} else {
+		a.recordAudit(r, *tid, audit.ItemTypeTenant, tid.Key(), audit.ActionCreate, nil, tenantConfig)
+	}
 	a.quotaManager.PublishQuota(ctx, *tid)
 	resp := ItemResponse(tenantConfig)
 	resp.Respond(ctx, w, doYaml(r))
@@ -888,6 +1667,20 @@ func (a *APIManager) deleteTenantConfigHandler(w http.ResponseWriter, r *http.Re
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	a.tenantConfigLock.Lock()
+	defer a.tenantConfigLock.Unlock()
+	oldConfig, _ := a.tenantStorer.GetConfig(ctx, *tid)
+	oldETag := ""
+	if oldConfig != nil {
+		oldETag = configETag(oldConfig)
+	}
+	apiErr = checkIfMatch(r, oldETag)
+	if apiErr != nil {
+		log.Ctx(ctx).Error().Str("op", "deleteTenantConfigHandler").Str("error", apiErr.Error()).Msg("tenant config precondition failed")
+		resp := ErrorResponse(apiErr)
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
 	err = a.tenantStorer.DeleteConfig(ctx, *tid)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "deleteTenantConfigHandler").Str("error", err.Error()).Msg("error deleting tenant config")
@@ -895,10 +1688,55 @@ func (a *APIManager) deleteTenantConfigHandler(w http.ResponseWriter, r *http.Re
 		resp.Respond(ctx, w, doYaml(r))
 		return
 	}
+	a.recordAudit(r, *tid, audit.ItemTypeTenant, tid.Key(), audit.ActionDelete, oldConfig, nil)
 	resp := ItemResponse(tid)
 	resp.Respond(ctx, w, doYaml(r))
 }
 
+func (a *APIManager) getAuditRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+	query := audit.Query{
+		OrgId:    params.Get("orgId"),
+		AppId:    params.Get("appId"),
+		ItemType: params.Get("type"),
+		ItemId:   params.Get("id"),
+		Subject:  params.Get("subject"),
+	}
+	for param, val := range map[string]*int64{"since": &query.Since, "until": &query.Until} {
+		if params.Get(param) == "" {
+			continue
+		}
+		ts, err := strconv.ParseInt(params.Get(param), 10, 64)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("op", "getAuditRecordsHandler").Str("error", err.Error()).Msg("invalid " + param)
+			resp := ErrorResponse(&BadRequestError{"invalid " + param, err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+		*val = ts
+	}
+	if params.Get("limit") != "" {
+		limit, err := strconv.Atoi(params.Get("limit"))
+		if err != nil || limit < 0 {
+			log.Ctx(ctx).Error().Str("op", "getAuditRecordsHandler").Msg("invalid limit")
+			resp := ErrorResponse(&BadRequestError{"invalid limit", err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+		query.Limit = limit
+	}
+	records, err := a.auditStorer.GetRecords(ctx, query)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getAuditRecordsHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemsResponse(records)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
 func convertToApiError(ctx context.Context, err error) ApiError {
 	span := trace.SpanFromContext(ctx)
 	span.RecordError(err)
@@ -908,9 +1746,21 @@ func convertToApiError(ctx context.Context, err error) ApiError {
 	var routeValidationError *tablemgr.RouteValidationError
 	var routeRegistrationError *tablemgr.RouteRegistrationError
 	var routeNotFound *route.RouteNotFoundError
+	var routeNotOwned *tablemgr.RouteNotOwnedError
+	var fragmentNotFound *fragments.FragmentNotFoundError
+	var fragmentInUse *tablemgr.FragmentInUseError
+	var routePluginsShared *tablemgr.RoutePluginsSharedError
 	var jwtAuthError *jwt.JWTAuthError
 	var jwtUnauthorizedError *jwt.UnauthorizedError
-	if errors.As(err, &tenantNotFound) {
+	var jwtForbiddenError *jwt.ForbiddenError
+	var readOnly *db.ReadOnlyError
+	var snapshotNotFound *snapshot.SnapshotNotFoundError
+	var badSnapshotUrl *snapshot.BadSnapshotUrlError
+	var quotaExceeded *ratelimit.QuotaExceeded
+	var preconditionFailed *PreconditionFailedError
+	if errors.As(err, &preconditionFailed) {
+		return preconditionFailed
+	} else if errors.As(err, &tenantNotFound) {
 		return &NotFoundError{"tenant " + tenantNotFound.Tenant.ToString() + " not found"}
 	} else if errors.As(err, &badTenantConfig) {
 		return &BadRequestError{"bad tenant config", err}
@@ -922,10 +1772,79 @@ func convertToApiError(ctx context.Context, err error) ApiError {
 		return &BadRequestError{"bad route config", err}
 	} else if errors.As(err, &routeNotFound) {
 		return &NotFoundError{"route " + routeNotFound.RouteId + " not found"}
+	} else if errors.As(err, &routeNotOwned) {
+		return &MisdirectedRequestError{"route " + routeNotOwned.RouteId + " runs on node " + routeNotOwned.Owner, err}
+	} else if errors.As(err, &fragmentNotFound) {
+		return &NotFoundError{"fragment " + fragmentNotFound.FragmentName + " not found"}
+	} else if errors.As(err, &fragmentInUse) {
+		return &ConflictError{"fragment " + fragmentInUse.FragmentId + " in use by routes " + strings.Join(fragmentInUse.RouteIds, ","), err}
+	} else if errors.As(err, &routePluginsShared) {
+		return &ConflictError{"route " + routePluginsShared.RouteId + " shares plugin instances with routes " + strings.Join(routePluginsShared.RouteIds, ",") + " and cannot be restarted on its own", err}
 	} else if errors.As(err, &jwtAuthError) {
 		return &BadRequestError{"bad or missing jwt token", err}
 	} else if errors.As(err, &jwtUnauthorizedError) {
 		return &BadRequestError{"jwt authorization failed", err}
+	} else if errors.As(err, &jwtForbiddenError) {
+		return &ForbiddenError{"jwt role does not permit this request", err}
+	} else if errors.As(err, &readOnly) {
+		return &MethodNotAllowedError{"config is read only and managed by the " + readOnly.Storer + " storer", err}
+	} else if errors.As(err, &snapshotNotFound) {
+		return &NotFoundError{"snapshot " + snapshotNotFound.Url + " not found"}
+	} else if errors.As(err, &badSnapshotUrl) {
+		return &BadRequestError{"bad snapshot url", err}
+	} else if errors.As(err, &quotaExceeded) {
+		return &TooManyRequestsError{fmt.Sprintf("tenant %s exceeded its quota of %d events per second (burst %d)",
+			quotaExceeded.Tenant.ToString(), quotaExceeded.EventsPerSec, quotaExceeded.Burst), quotaExceeded.RetryAfterSeconds()}
 	}
 	return &InternalServerError{err}
 }
+
+func (a *APIManager) searchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if query == "" {
+		log.Ctx(ctx).Error().Str("op", "searchHandler").Msg("missing search query")
+		resp := ErrorResponse(&BadRequestError{"missing search query q", nil})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	matches := make([]SearchMatch, 0)
+	routes, err := a.routingTableMgr.GetAllRoutes(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "searchHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	for idx := range routes {
+		fields := searchRoute(&routes[idx], query)
+		if len(fields) > 0 {
+			matches = append(matches, SearchMatch{Kind: SEARCH_KIND_ROUTE, Tenant: routes[idx].TenantId, Id: routes[idx].Id, Name: routes[idx].Name, Fields: fields})
+		}
+	}
+	configs, err := a.tenantStorer.GetAllConfigs(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "searchHandler").Str("error", err.Error()).Msg("tenant configs read error")
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	for _, config := range configs {
+		fragments, err := a.routingTableMgr.GetAllTenantFragments(ctx, config.Tenant)
+		if err != nil {
+			log.Ctx(ctx).Error().Str("op", "searchHandler").Msg(err.Error())
+			resp := ErrorResponse(convertToApiError(ctx, err))
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+		for idx := range fragments {
+			fields := searchFragment(&fragments[idx], query)
+			if len(fields) > 0 {
+				matches = append(matches, SearchMatch{Kind: SEARCH_KIND_FRAGMENT, Tenant: config.Tenant, Id: fragments[idx].FragmentName, Name: fragments[idx].Name, Fields: fields})
+			}
+		}
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("matchCount", len(matches)))
+	resp := ItemsResponse(matches)
+	resp.Respond(ctx, w, doYaml(r))
+}
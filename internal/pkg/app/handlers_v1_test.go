@@ -23,8 +23,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,6 +36,7 @@ import (
 	"github.com/rs/zerolog/log"
 	goldie "github.com/sebdah/goldie/v2"
 	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/db"
 	"github.com/xmidt-org/ears/internal/pkg/db/dynamo"
@@ -39,6 +44,7 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/jwt"
 	"github.com/xmidt-org/ears/internal/pkg/plugin"
 	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
 	"github.com/xmidt-org/ears/internal/pkg/syncer"
 	redissyncer "github.com/xmidt-org/ears/internal/pkg/syncer/redis"
 	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
@@ -656,7 +662,7 @@ func setupRestApi(config config.Config, storageMgr route.RouteStorer, setupQuota
 		}
 	}
 	jwtMgr, _ := jwt.NewJWTConsumer("", nil, false, "", "", nil, nil, nil)
-	apiMgr, err := NewAPIManager(routingMgr, tenantStorer, quotaMgr, jwtMgr, nil)
+	apiMgr, err := NewAPIManager(routingMgr, tenantStorer, quotaMgr, jwtMgr, nil, nil, nil)
 	if err != nil {
 		return &EarsRuntime{config, nil, nil, storageMgr, nil, nil}, err
 	}
@@ -772,7 +778,7 @@ func TestRestVersionHandler(t *testing.T) {
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/version", nil)
 	jwtMgr, _ := jwt.NewJWTConsumer("", nil, false, "", "", nil, nil, nil)
-	api, err := NewAPIManager(&tablemgr.DefaultRoutingTableManager{}, nil, nil, jwtMgr, nil)
+	api, err := NewAPIManager(&tablemgr.DefaultRoutingTableManager{}, nil, nil, jwtMgr, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("Fail to setup api manager: %s\n", err.Error())
 	}
@@ -1220,6 +1226,68 @@ func TestRestGetMultipleRoutesHandler(t *testing.T) {
 	t.Logf("deleted route with id: %s", rtId)
 }
 
+func TestRestGetRoutesByLabelHandler(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	var rt map[string]interface{}
+	err = json.Unmarshal(buf, &rt)
+	if err != nil {
+		t.Fatalf("cannot unmarshal route: %s", err.Error())
+	}
+	rt["labels"] = map[string]interface{}{"team": "xfi", "env": "test"}
+	buf, err = json.Marshal(rt)
+	if err != nil {
+		t.Fatalf("cannot marshal route: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", bytes.NewReader(buf))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	testCases := []struct {
+		query    string
+		status   int
+		numItems int
+	}{
+		{query: "", status: http.StatusOK, numItems: 1},
+		{query: "?label=team%3Dxfi", status: http.StatusOK, numItems: 1},
+		{query: "?label=team%3Dxfi&label=env", status: http.StatusOK, numItems: 1},
+		{query: "?label=team%3Dxfi,env%21%3Dtest", status: http.StatusOK, numItems: 0},
+		{query: "?label=team%3Dother", status: http.StatusOK, numItems: 0},
+		{query: "?label=%3Dxfi", status: http.StatusBadRequest, numItems: 0},
+	}
+	for _, tc := range testCases {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes"+tc.query, nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != tc.status {
+			t.Fatalf("unexpected status %d for %s: %s", w.Code, tc.query, w.Body.String())
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var data map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &data)
+		if err != nil {
+			t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+		}
+		items, _ := data["items"].([]interface{})
+		if len(items) != tc.numItems {
+			t.Fatalf("unexpected number of routes %d for %s", len(items), tc.query)
+		}
+	}
+	// delete routes
+	rtId := "r100"
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/"+rtId, nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	t.Logf("deleted route with id: %s", rtId)
+}
+
 func TestRestDeleteRouteHandler(t *testing.T) {
 	routeFileName := "testdata/simpleRoute.json"
 	simpleRouteReader, err := os.Open(routeFileName)
@@ -1266,6 +1334,87 @@ func TestRestDeleteRouteHandler(t *testing.T) {
 
 // tests for various error conditions
 
+func TestRestPostRouteDryRunHandler(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	testCases := []struct {
+		name   string
+		route  string
+		status int
+	}{
+		{name: "valid", route: string(buf), status: http.StatusOK},
+		{name: "unknownPlugin", route: strings.Replace(string(buf), `"plugin": "debug"`, `"plugin": "nosuchplugin"`, 1), status: http.StatusBadRequest},
+		{name: "noUser", route: strings.Replace(string(buf), `"userId": "boris",`, "", 1), status: http.StatusBadRequest},
+		{name: "badReceiverConfig", route: strings.Replace(string(buf), `"rounds": 5`, `"rounds": "five"`, 1), status: http.StatusBadRequest},
+		{name: "badSenderConfig", route: strings.Replace(string(buf), `"destination": "stdout"`, `"destination": "nowhere"`, 1), status: http.StatusBadRequest},
+		{name: "badFilterConfig", route: strings.Replace(string(buf), `"deliveryMode"`, `"filterChain": [{"plugin": "match", "name": "mymatch", "config": {"mode": "sometimes"}}], "deliveryMode"`, 1), status: http.StatusBadRequest},
+		{name: "goodFilterConfig", route: strings.Replace(string(buf), `"deliveryMode"`, `"filterChain": [{"plugin": "match", "name": "mymatch", "config": {"mode": "allow", "matcher": "regex", "pattern": ".*"}}], "deliveryMode"`, 1), status: http.StatusOK},
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes?dryRun=true", strings.NewReader(tc.route))
+			runtime.apiManager.muxRouter.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+	// a dry run must not store the route
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("route stored during dry run: %s", w.Body.String())
+	}
+}
+
+func TestRestSimulateRouteHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleFilterSplitRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/f108/simulate", strings.NewReader(`[{"foo":"bar"},{"foo":"baz"},{"foo":"qux"}]`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item tablemgr.SimulationResult `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if len(data.Item.Steps) != 1 || data.Item.Steps[0].Plugin != "split" || data.Item.Steps[0].EventsIn != 1 || data.Item.Steps[0].EventsOut != 3 {
+		t.Fatalf("unexpected simulation steps: %s", w.Body.String())
+	}
+	if len(data.Item.Events) != 3 || data.Item.Events[1].Payload.(map[string]interface{})["foo"] != "baz" {
+		t.Fatalf("unexpected simulated events: %s", w.Body.String())
+	}
+	// simulating a missing route fails
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/fakeid/simulate", strings.NewReader(`{"foo":"bar"}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/f108", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
 func TestRestRouteHandlerIdMismatch(t *testing.T) {
 	w := httptest.NewRecorder()
 	routeFileName := "testdata/simpleRoute.json"
@@ -1604,3 +1753,1259 @@ func TestRestGetRouteWithFragmentsHandler(t *testing.T) {
 	runtime.apiManager.muxRouter.ServeHTTP(w, r)
 	t.Logf("deleted route with id: %s", rtId)
 }
+
+func TestRestGetAuditRecordsHandler(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	// the test client and the last proxy it went through are trusted proxies
+	v := viper.New()
+	v.Set("ears.api.trustedProxies", "192.0.2.1,10.0.0.2")
+	runtime.apiManager.trustedProxies, err = parseTrustedProxies(v)
+	if err != nil {
+		t.Fatalf("cannot parse trusted proxies: %s", err.Error())
+	}
+	for _, route := range []string{string(buf), strings.Replace(string(buf), `"name": "simpleRoute",`, `"name": "simpleRouteUpdated",`, 1)} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", strings.NewReader(route))
+		r.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("cannot add route: %s", w.Body.String())
+		}
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot delete route: %s", w.Body.String())
+	}
+	testCases := []struct {
+		name    string
+		query   string
+		status  int
+		actions []string
+	}{
+		{name: "all", query: "", status: http.StatusOK, actions: []string{audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate}},
+		{name: "limit", query: "?type=route&id=r100&limit=1", status: http.StatusOK, actions: []string{audit.ActionDelete}},
+		{name: "otherTenant", query: "?orgId=nosuchorg", status: http.StatusOK, actions: []string{}},
+		{name: "badSince", query: "?since=yesterday", status: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/ears/v1/audit"+tc.query, nil)
+			runtime.apiManager.muxRouter.ServeHTTP(w, r)
+			if w.Code != tc.status {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var data struct {
+				Items []audit.Record `json:"items"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &data)
+			if err != nil {
+				t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+			}
+			if len(data.Items) != len(tc.actions) {
+				t.Fatalf("unexpected audit records: %s", w.Body.String())
+			}
+			for idx, action := range tc.actions {
+				if data.Items[idx].Action != action {
+					t.Fatalf("unexpected audit record: %s", w.Body.String())
+				}
+			}
+		})
+	}
+	records, err := runtime.apiManager.auditStorer.GetRecords(context.Background(), audit.Query{ItemType: audit.ItemTypeRoute})
+	if err != nil {
+		t.Fatalf("cannot get audit records: %s", err.Error())
+	}
+	if records[1].SourceIp != "10.0.0.1" || records[1].Before == nil || records[1].After == nil {
+		t.Fatalf("unexpected update audit record: %+v", records[1])
+	}
+}
+
+func TestRestRouteETagHandler(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", bytes.NewReader(buf))
+	r.Header.Set("If-Match", "*")
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected precondition failure for new route but got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", bytes.NewReader(buf))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if etag == "" || w.Header().Get("ETag") != etag {
+		t.Fatalf("unexpected etag %s vs %s", w.Header().Get("ETag"), etag)
+	}
+	updated := strings.Replace(string(buf), `"name": "simpleRoute",`, `"name": "simpleRouteUpdated",`, 1)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", strings.NewReader(updated))
+	r.Header.Set("If-Match", etag)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot update route: %s", w.Body.String())
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Fatalf("etag unchanged after update")
+	}
+	// a second writer holding the old version must be rejected
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", bytes.NewReader(buf))
+	r.Header.Set("If-Match", etag)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected precondition failure for stale update but got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	r.Header.Set("If-Match", etag)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected precondition failure for stale delete but got %d: %s", w.Code, w.Body.String())
+	}
+	// of several writers holding the same version only one wins
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	etag = w.Header().Get("ETag")
+	var wg sync.WaitGroup
+	codes := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := strings.Replace(string(buf), `"name": "simpleRoute",`, `"name": "simpleRoute`+strconv.Itoa(i)+`",`, 1)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/r100", strings.NewReader(body))
+			r.Header.Set("If-Match", etag)
+			runtime.apiManager.muxRouter.ServeHTTP(w, r)
+			codes <- w.Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+	succeeded := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			succeeded++
+		} else if code != http.StatusPreconditionFailed {
+			t.Fatalf("unexpected status code %d", code)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one successful update but got %d", succeeded)
+	}
+}
+
+func TestRestHealthHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	for _, path := range []string{"/ears/live", "/ears/ready"} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, path, nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d for %s: %s", w.Code, path, w.Body.String())
+		}
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/health", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item healthReport `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if !data.Item.Healthy || !data.Item.RouteStorer.Healthy || !data.Item.TenantStorer.Healthy || !data.Item.Syncer.Healthy {
+		t.Fatalf("unexpected health report: %s", w.Body.String())
+	}
+	if data.Item.RegisteredRoutes != 1 || len(data.Item.Receivers) != 1 || data.Item.Receivers[0].RouteId != "r100" || !data.Item.Receivers[0].Running {
+		t.Fatalf("unexpected receiver health: %s", w.Body.String())
+	}
+}
+
+func TestRestRouteStatsHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	// debug receiver emits 5 events 10ms apart
+	time.Sleep(500 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100/stats", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item route.StatsSnapshot `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if data.Item.EventsReceived != 5 || data.Item.SendSuccess != 5 || data.Item.LastEvent == 0 {
+		t.Fatalf("unexpected route stats: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/fakeid/stats", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestRouteErrorsHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100/errors", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Items []route.RouteError `json:"items"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if data.Items == nil || len(data.Items) != 0 {
+		t.Fatalf("unexpected route errors: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/fakeid/errors", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestTailRouteHandler(t *testing.T) {
+	routeConfig, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	// keep the debug receiver emitting events long enough to tail them
+	routeStr := strings.Replace(string(routeConfig), `"intervalMs": 10,`, `"intervalMs": 50,`, 1)
+	routeStr = strings.Replace(routeStr, `"rounds": 5,`, `"rounds": 100,`, 1)
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", strings.NewReader(routeStr))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100/tail?maxEvents=2&timeout=10&redact=.foo", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if strings.Count(body, "data: ") != 2 {
+		t.Fatalf("expected 2 tailed events: %s", body)
+	}
+	if strings.Contains(body, "bar") || !strings.Contains(body, `"foo":"***"`) {
+		t.Fatalf("payload not redacted: %s", body)
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100/tail?sample=2", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/fakeid/tail", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestRestartRouteHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	// debug receiver emits 5 events 10ms apart
+	time.Sleep(500 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/r100/restart", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	// restarted debug receiver emits another 5 events into fresh route statistics
+	time.Sleep(500 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/r100/stats", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item route.StatsSnapshot `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if data.Item.EventsReceived != 5 || data.Item.SendSuccess != 5 {
+		t.Fatalf("unexpected route stats after restart: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/fakeid/restart", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestRestartSharedRouteHandler(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	receiver := `{"plugin": "debug", "name": "mydebug", "config": {"intervalMs": 10, "maxHistory": 100, "payload": {"foo": "bar"}, "rounds": 5}}`
+	for _, routeId := range []string{"r100", "r101"} {
+		route := `{"id": "` + routeId + `", "userId": "boris", "name": "sharedRoute", "receiver": ` + receiver +
+			`, "sender": {"plugin": "debug", "name": "` + routeId + `Sender", "config": {"destination": "stdout", "maxHistory": 100}}, "deliveryMode": "whoCares"}`
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", strings.NewReader(route))
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("cannot add route %s: %s", routeId, w.Body.String())
+		}
+	}
+	// both routes share the receiver, restarting one of them must not tear it down underneath the other
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/r100/restart", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "r101") {
+		t.Fatalf("expected sharing route r101 in error: %s", w.Body.String())
+	}
+	for _, routeId := range []string{"r100", "r101"} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/"+routeId+"/stats", nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("route %s no longer live after refused restart: %d %s", routeId, w.Code, w.Body.String())
+		}
+	}
+	// once the receiver is no longer shared the remaining route can be restarted
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r101", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot delete route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/r100/restart", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestTenantQuotaBurst(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 10, "burst": 5}}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 10, "burst": 50}}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot set tenant config: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/config", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	var data struct {
+		Item tenant.Config `json:"item"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if data.Item.Quota.EventsPerSec != 10 || data.Item.Quota.Burst != 50 {
+		t.Fatalf("unexpected quota %+v", data.Item.Quota)
+	}
+}
+
+func TestRestSendEventQuotaExceeded(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	// a tenant of its own so the in memory quota is not shared with other tests
+	throttledTenantPath := "/orgs/myorg/applications/mythrottledapp"
+	runtime := setupSimpleApi(t, "inmemory")
+	// the quota manager only limits tenants once it knows about running ears instances
+	runtime.deltaSyncer.StartListeningForSyncRequests()
+	defer runtime.deltaSyncer.StopListeningForSyncRequests()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/ears/v1"+throttledTenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 5}, "openEventApi": true}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot set tenant config: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+throttledTenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	// give the route time to start receiving
+	time.Sleep(500 * time.Millisecond)
+	// send events faster than the quota allows until they get throttled
+	for i := 0; i < 20; i++ {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/ears/v1"+throttledTenantPath+"/routes/r100/event", strings.NewReader(`{"foo": "bar"}`))
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			break
+		}
+	}
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("unexpected Retry-After header %s", w.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(w.Body.String(), "quota of 5 events per second") {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
+
+func TestSourceIp(t *testing.T) {
+	v := viper.New()
+	v.Set("ears.api.trustedProxies", "10.0.0.0/8, 192.168.1.1")
+	proxies, err := parseTrustedProxies(v)
+	if err != nil {
+		t.Fatalf("cannot parse trusted proxies: %s", err.Error())
+	}
+	a := &APIManager{trustedProxies: proxies}
+	cases := []struct {
+		remoteAddr string
+		forwarded  string
+		sourceIp   string
+	}{
+		{"1.2.3.4:1234", "", "1.2.3.4"},
+		{"1.2.3.4:1234", "5.6.7.8", "1.2.3.4"},
+		{"10.1.2.3:1234", "5.6.7.8", "5.6.7.8"},
+		{"10.1.2.3:1234", "6.6.6.6, 5.6.7.8, 192.168.1.1", "5.6.7.8"},
+		{"192.168.1.1:1234", "10.0.0.1", "10.0.0.1"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/ears/v1/audit", nil)
+		r.RemoteAddr = c.remoteAddr
+		if c.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if ip := a.getSourceIp(r); ip != c.sourceIp {
+			t.Fatalf("expected source ip %s for %s forwarded for %s but got %s", c.sourceIp, c.remoteAddr, c.forwarded, ip)
+		}
+	}
+	v.Set("ears.api.trustedProxies", "10.0.0.0/33")
+	_, err = parseTrustedProxies(v)
+	if err == nil {
+		t.Fatalf("bad trusted proxy accepted")
+	}
+}
+
+func TestRouteNotOwnedError(t *testing.T) {
+	apiErr := convertToApiError(context.Background(), &tablemgr.RouteNotOwnedError{RouteId: "r100", Owner: "node-b"})
+	if apiErr.StatusCode() != http.StatusMisdirectedRequest {
+		t.Fatalf("unexpected status %d", apiErr.StatusCode())
+	}
+	if !strings.Contains(apiErr.Error(), "route r100 runs on node node-b") {
+		t.Fatalf("unexpected error %s", apiErr.Error())
+	}
+}
+
+func TestRestSendEventAsyncHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 10}, "openEventApi": true}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot set tenant config: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	// give the route time to start receiving
+	time.Sleep(500 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes/r100/event?async=true", strings.NewReader(`{"foo": "bar"}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item map[string]string `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	eventId := data.Item["eventId"]
+	if eventId == "" {
+		t.Fatalf("missing event ID: %s", w.Body.String())
+	}
+	var status struct {
+		Item EventStatus `json:"item"`
+	}
+	for i := 0; i < 50; i++ {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/events/"+eventId+"/status", nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &status)
+		if err != nil {
+			t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+		}
+		if status.Item.Status != EventStatusPending {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status.Item.Status != EventStatusAcked || status.Item.RouteId != "r100" || status.Item.Completed == 0 {
+		t.Fatalf("unexpected event status: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1/orgs/otherorg/applications/otherapp/events/"+eventId+"/status", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/config", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestFragmentUsageHandler(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	for _, fragmentFileName := range []string{"testdata/fragments/debugSender.json", "testdata/fragments/debugFoobarReceiver.json"} {
+		fragmentReader, err := os.Open(fragmentFileName)
+		if err != nil {
+			t.Fatalf("cannot read file: %s", err.Error())
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/fragments", fragmentReader)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("cannot add fragment: %s", w.Body.String())
+		}
+	}
+	routeReader, err := os.Open("testdata/fragments/route.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/fragments/debugSender/usage", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Items []tablemgr.FragmentUsage `json:"items"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if len(data.Items) != 1 || data.Items[0].RouteId != "fragment101" || len(data.Items[0].Locations) != 1 || data.Items[0].Locations[0] != "sender" {
+		t.Fatalf("unexpected fragment usage: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/fragments/fakeid/usage", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	// fragments in use cannot be deleted unless forced
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/fragments/debugSender", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/fragments/debugSender?force=true", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	// route keeps the fragment config but no longer references the fragment
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/routes/fragment101", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	var routeData struct {
+		Item route.Config `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &routeData)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if routeData.Item.Sender.FragmentName != "" || routeData.Item.Sender.Config == nil || routeData.Item.Receiver.FragmentName != "debugFoobarReceiver" {
+		t.Fatalf("unexpected route after fragment deletion: %s", w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/fragment101", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/fragments/debugFoobarReceiver", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRestTopologyHandler(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	otherRoute := strings.Replace(string(buf), `"name": "simpleRoute",`, `"name": "otherRoute",`, 1)
+	otherRoute = strings.Replace(otherRoute, `"name": "simpleRouteSender",`, `"name": "otherRouteSender",`, 1)
+	routes := map[string]string{"r100": string(buf), "r101": strings.Replace(otherRoute, `"id": "r100",`, `"id": "r101",`, 1)}
+	for routeId, route := range routes {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/routes/"+routeId, strings.NewReader(route))
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("cannot add route: %s", w.Body.String())
+		}
+	}
+	testCases := []struct {
+		name    string
+		query   string
+		plugins int
+	}{
+		{name: "all", query: "", plugins: 3},
+		{name: "shared", query: "?shared=true", plugins: 1},
+		{name: "route", query: "?routeId=r101", plugins: 2},
+		{name: "unknownRoute", query: "?routeId=fakeid", plugins: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/topology"+tc.query, nil)
+			runtime.apiManager.muxRouter.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			var data struct {
+				Item tablemgr.Topology `json:"item"`
+			}
+			err = json.Unmarshal(w.Body.Bytes(), &data)
+			if err != nil {
+				t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+			}
+			if len(data.Item.Plugins) != tc.plugins {
+				t.Fatalf("unexpected topology: %s", w.Body.String())
+			}
+			// the shared receiver comes first
+			if tc.plugins > 0 && (data.Item.Plugins[0].Type != tablemgr.TopologyTypeReceiver || !data.Item.Plugins[0].Shared || len(data.Item.Plugins[0].Routes) != 2) {
+				t.Fatalf("unexpected topology: %s", w.Body.String())
+			}
+		})
+	}
+	for routeId := range routes {
+		r := httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/"+routeId, nil)
+		w := httptest.NewRecorder()
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	}
+}
+
+func TestRestTenantUsageHandler(t *testing.T) {
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/ears/v1/orgs/noorg/applications/noapp/config/usage", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 10}}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot set tenant config: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	// debug receiver emits 5 events 10ms apart
+	time.Sleep(500 * time.Millisecond)
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/config/usage", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var data struct {
+		Item struct {
+			QuotaEventsPerSec int `json:"quotaEventsPerSec"`
+			tablemgr.TenantUsage
+		} `json:"item"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	usage := data.Item
+	if usage.QuotaEventsPerSec != 10 || usage.RouteCount != 1 || usage.RunningRouteCount != 1 || len(usage.Windows) != len(tablemgr.UsageWindowSecs) {
+		t.Fatalf("unexpected tenant usage: %s", w.Body.String())
+	}
+	for _, uw := range usage.Windows {
+		if uw.EventsReceived != 5 || uw.EventsPerSec != 5/float64(uw.WindowSecs) {
+			t.Fatalf("unexpected tenant usage window: %s", w.Body.String())
+		}
+	}
+	if len(usage.Routes) != 1 || usage.Routes[0].RouteId != "r100" || usage.Routes[0].Windows[0].EventsReceived != 5 {
+		t.Fatalf("unexpected route usage: %s", w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 100}}`))
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestSearchHandler(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	for _, fileName := range []string{"testdata/fragments/debugSender.json", "testdata/fragments/debugFoobarReceiver.json"} {
+		fragmentReader, err := os.Open(fileName)
+		if err != nil {
+			t.Fatalf("cannot read file: %s", err.Error())
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/fragments", fragmentReader)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("cannot add fragment: %s", w.Body.String())
+		}
+	}
+	routeReader, err := os.Open("testdata/fragments/route.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1/search", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	search := func(query string) []SearchMatch {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/ears/v1/search?q="+url.QueryEscape(query), nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+		var data struct {
+			Items []SearchMatch `json:"items"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &data)
+		if err != nil {
+			t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+		}
+		return data.Items
+	}
+	matches := search("STDOUT")
+	if len(matches) != 2 {
+		t.Fatalf("unexpected search matches: %v", matches)
+	}
+	for _, m := range matches {
+		switch m.Kind {
+		case SEARCH_KIND_ROUTE:
+			if m.Id != "fragment101" || m.Tenant.OrgId != "myorg" || len(m.Fields) != 1 || m.Fields[0] != "sender" {
+				t.Fatalf("unexpected route match: %v", m)
+			}
+		case SEARCH_KIND_FRAGMENT:
+			if m.Id != "debugSender" || m.Tenant.AppId != "myapp" || len(m.Fields) != 1 || m.Fields[0] != "config" {
+				t.Fatalf("unexpected fragment match: %v", m)
+			}
+		default:
+			t.Fatalf("unexpected match kind: %v", m)
+		}
+	}
+	matches = search("[0-9]+")
+	if len(matches) != 1 || matches[0].Kind != SEARCH_KIND_ROUTE || len(matches[0].Fields) != 1 || matches[0].Fields[0] != "filterChain[0]" {
+		t.Fatalf("unexpected search matches: %v", matches)
+	}
+	matches = search("simpleRegexFilterRoute")
+	if len(matches) != 1 || len(matches[0].Fields) != 2 || matches[0].Fields[0] != "name" || matches[0].Fields[1] != "filterChain[0]" {
+		t.Fatalf("unexpected search matches: %v", matches)
+	}
+	if matches = search("nosuchthing"); len(matches) != 0 {
+		t.Fatalf("unexpected search matches: %v", matches)
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/fragment101", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	for _, fragmentId := range []string{"debugSender", "debugFoobarReceiver"} {
+		r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/fragments/"+fragmentId, nil)
+		w = httptest.NewRecorder()
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	}
+}
+
+func TestRestBodyLimit(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("ears.api.maxBodyBytes.default", 2048)
+	cfg.Set("ears.api.maxBodyBytes.event", 100)
+	limits := parseMaxBodyBytes(cfg)
+	if limits[BODY_ENDPOINT_EVENT] != 100 || limits[BODY_ENDPOINT_ROUTE] != 2048 || limits[BODY_ENDPOINT_TENANT] != 2048 {
+		t.Fatalf("unexpected body limits: %v", limits)
+	}
+	if limits = parseMaxBodyBytes(nil); limits[BODY_ENDPOINT_ROUTE] != DEFAULT_MAX_BODY_BYTES {
+		t.Fatalf("unexpected default body limits: %v", limits)
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	runtime.apiManager.maxBodyBytes[BODY_ENDPOINT_ROUTE] = 100
+	route, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", bytes.NewReader(route))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	// bodies of unknown length are cut off once they exceed the limit
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", ioutil.NopCloser(bytes.NewReader(route)))
+	r.ContentLength = -1
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	runtime.apiManager.maxBodyBytes[BODY_ENDPOINT_ROUTE] = int64(len(route))
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", bytes.NewReader(route))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestTenantRoleMappings(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 100}, "roleMappings": [{"claim": "groups", "value": "ears", "role": "owner"}]}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 100}, "roleMappings": [{"claim": "groups", "value": "ears-readers", "role": "reader"}, {"claim": "sub", "value": "ops", "role": "admin"}]}`))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/v1"+tenantPath+"/config", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	var data struct {
+		Item tenant.Config `json:"item"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &data)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	if len(data.Item.RoleMappings) != 2 || data.Item.RoleMappings[1].Role != tenant.ROLE_ADMIN {
+		t.Fatalf("unexpected role mappings: %s", w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodPut, "/ears/v1"+tenantPath+"/config", strings.NewReader(`{"quota": {"eventsPerSec": 100}}`))
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestGraphqlHandler(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	time.Sleep(500 * time.Millisecond)
+	query := `query($org: String) {
+		routes(orgId: $org, appId: "myapp") { id name sender { plugin name } stats { eventsReceived } }
+		myTenant: tenants(orgId: $org, appId: "myapp") { tenant { orgId appId } quota { eventsPerSec } }
+		senders(orgId: $org) { id Name __typename }
+	}`
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": map[string]interface{}{"org": "myorg"}})
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/graphql", bytes.NewReader(body))
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Routes []struct {
+				Id     string                 `json:"id"`
+				Name   string                 `json:"name"`
+				Sender map[string]interface{} `json:"sender"`
+				Stats  struct {
+					EventsReceived int `json:"eventsReceived"`
+				} `json:"stats"`
+				Receiver interface{} `json:"receiver"`
+			} `json:"routes"`
+			MyTenant []tenant.Config          `json:"myTenant"`
+			Senders  []map[string]interface{} `json:"senders"`
+		} `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatalf("cannot unmarshal response %s into json %s", w.Body.String(), err.Error())
+	}
+	routes := resp.Data.Routes
+	if len(routes) != 1 || routes[0].Id != "r100" || routes[0].Name != "simpleRoute" || routes[0].Receiver != nil || routes[0].Stats.EventsReceived != 5 {
+		t.Fatalf("unexpected routes: %s", w.Body.String())
+	}
+	if len(routes[0].Sender) != 2 || routes[0].Sender["name"] != "simpleRouteSender" {
+		t.Fatalf("unexpected route sender: %s", w.Body.String())
+	}
+	if len(resp.Data.MyTenant) != 1 || resp.Data.MyTenant[0].Quota.EventsPerSec != 100 || resp.Data.MyTenant[0].Tenant.AppId != "myapp" {
+		t.Fatalf("unexpected tenants: %s", w.Body.String())
+	}
+	if len(resp.Data.Senders) != 1 || resp.Data.Senders[0]["Name"] != "simpleRouteSender" || resp.Data.Senders[0]["id"] == "" {
+		t.Fatalf("unexpected senders: %s", w.Body.String())
+	}
+	if len(resp.Errors) != 0 || resp.Data.Senders[0]["__typename"] != "Sender" {
+		t.Fatalf("unexpected errors: %s", w.Body.String())
+	}
+	// fragments and introspection
+	query = `{
+		routes { ...routeFields }
+		__type(name: "Route") { fields { name } }
+	}
+	fragment routeFields on Route { id receiver { plugin } }`
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/graphql", strings.NewReader(query))
+	r.Header.Set("Content-Type", "application/graphql")
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"receiver":{"plugin":"debug"}`) || !strings.Contains(w.Body.String(), `{"name":"stats"}`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	// unknown fields fail validation
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/graphql?query="+url.QueryEscape(`{ routes { id } nope }`), nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "nope") {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	// plain queries via GET
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/ears/graphql?query="+url.QueryEscape(`{ routes(status: "stopped") { id } }`), nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "{\"data\":{\"routes\":[]}}\n" {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/graphql", strings.NewReader(`mutation { removeRoute(id: "r100") { id } }`))
+	r.Header.Set("Content-Type", "application/graphql")
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+}
+
+func TestRestWatchHandler(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/ears/v1/watch?type=foo", nil)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+	}
+	watchRecorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		r := httptest.NewRequest(http.MethodGet, "/ears/v1/watch?type=route&orgId=myorg&timeout=2", nil)
+		runtime.apiManager.muxRouter.ServeHTTP(watchRecorder, r)
+		close(done)
+	}()
+	for i := 0; runtime.apiManager.watchHub.WatcherCount() == 0; i++ {
+		if i > 100 {
+			t.Fatalf("watcher not subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	routeReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", routeReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %s", w.Body.String())
+	}
+	r = httptest.NewRequest(http.MethodDelete, "/ears/v1"+tenantPath+"/routes/r100", nil)
+	w = httptest.NewRecorder()
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	<-done
+	if watchRecorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", watchRecorder.Code, watchRecorder.Body.String())
+	}
+	body := watchRecorder.Body.String()
+	if strings.Count(body, "event: route\n") != 2 {
+		t.Fatalf("expected 2 route changes: %s", body)
+	}
+	if !strings.Contains(body, `"action":"create"`) || !strings.Contains(body, `"action":"delete"`) {
+		t.Fatalf("missing actions: %s", body)
+	}
+	if !strings.Contains(body, "event: timeout") {
+		t.Fatalf("missing timeout: %s", body)
+	}
+	if runtime.apiManager.watchHub.WatcherCount() != 0 {
+		t.Fatalf("watcher not unsubscribed")
+	}
+}
+
+func TestRestSnapshotsNotConfigured(t *testing.T) {
+	runtime := setupSimpleApi(t, "inmemory")
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/ears/v1/snapshots", nil)
+		runtime.apiManager.muxRouter.ServeHTTP(w, r)
+		if w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSnapshotRestorer(t *testing.T) {
+	buf, err := ioutil.ReadFile("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	var routeConfig route.Config
+	err = json.Unmarshal(buf, &routeConfig)
+	if err != nil {
+		t.Fatalf("cannot unmarshal route: %s", err.Error())
+	}
+	runtime := setupSimpleApi(t, "inmemory")
+	ctx := context.Background()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	routeConfig.TenantId = tid
+	s := &snapshot.Snapshot{
+		Tenants: []tenant.Config{{Tenant: tid, Quota: tenant.Quota{EventsPerSec: 10}}},
+		Fragments: []snapshot.TenantFragments{{Tenant: tid, Fragments: []route.PluginConfig{
+			{FragmentName: "mysender", Plugin: "debug", Name: "mysender", Config: map[string]interface{}{"destination": "stdout"}},
+		}}},
+		Routes: []route.Config{routeConfig},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1/snapshots/restore", nil)
+	result := (&snapshot.SnapshotManager{}).Restore(ctx, s, &snapshotRestorer{runtime.apiManager, r})
+	if result.Tenants != 1 || result.Fragments != 1 || result.Routes != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected restore result %+v", result)
+	}
+	registered, err := runtime.apiManager.routingTableMgr.GetAllRegisteredRoutes()
+	if err != nil || len(registered) != 1 || registered[0].Id != routeConfig.Id {
+		t.Fatalf("restored route not running: %v %v", registered, err)
+	}
+	records, err := runtime.apiManager.auditStorer.GetRecords(ctx, audit.Query{OrgId: tid.OrgId, AppId: tid.AppId})
+	if err != nil {
+		t.Fatalf("cannot get audit records: %s", err.Error())
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected one audit record per restored item: %+v", records)
+	}
+}
+
+// unavailableRouteStorer fails to list routes as a route storer would that cannot be reached yet
+type unavailableRouteStorer struct {
+	route.RouteStorer
+}
+
+func (s *unavailableRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	return nil, errors.New("route storer unavailable")
+}
+
+func TestRouteTableWarmStart(t *testing.T) {
+	config, err := getConfig()
+	if err != nil {
+		t.Fatalf("cannot get config: %s", err.Error())
+	}
+	cachePath := filepath.Join(t.TempDir(), "routes.json")
+	viper.Set("ears.routingTable.cache.path", cachePath)
+	defer viper.Set("ears.routingTable.cache.path", "")
+	runtime, err := setupRestApi(config, db.NewInMemoryRouteStorer(config), false)
+	if err != nil {
+		t.Fatalf("cannot create api manager: %s\n", err.Error())
+	}
+	simpleRouteReader, err := os.Open("testdata/simpleRoute.json")
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err.Error())
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/ears/v1"+tenantPath+"/routes", simpleRouteReader)
+	runtime.apiManager.muxRouter.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cannot add route: %d %s", w.Code, w.Body.String())
+	}
+	// shutting down saves the routing table
+	runtime.routingTableManager.UnregisterAllRoutes()
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		t.Fatalf("routing table not cached: %s", err.Error())
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected route cache permissions %v", info.Mode().Perm())
+	}
+	// a restarting node runs the cached routes even if the route storer cannot be reached
+	restarted, err := setupRestApi(config, &unavailableRouteStorer{db.NewInMemoryRouteStorer(config)}, false)
+	if err != nil {
+		t.Fatalf("cannot create api manager: %s\n", err.Error())
+	}
+	err = restarted.routingTableManager.RegisterAllRoutes()
+	if err != nil {
+		t.Fatalf("warm start failed: %s", err.Error())
+	}
+	routes, _ := restarted.routingTableManager.GetAllRegisteredRoutes()
+	if len(routes) != 1 || routes[0].Id != "r100" {
+		t.Fatalf("unexpected routes after warm start %v", routes)
+	}
+	restarted.routingTableManager.UnregisterAllRoutes()
+	// without a cache the route storer is required
+	viper.Set("ears.routingTable.cache.path", "")
+	cold, err := setupRestApi(config, &unavailableRouteStorer{db.NewInMemoryRouteStorer(config)}, false)
+	if err != nil {
+		t.Fatalf("cannot create api manager: %s\n", err.Error())
+	}
+	err = cold.routingTableManager.RegisterAllRoutes()
+	if err == nil {
+		t.Fatalf("expected cold start to fail")
+	}
+}
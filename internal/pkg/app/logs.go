@@ -33,7 +33,9 @@ func ProvideLogger(config config.Config) (*zerolog.Logger, error) {
 	if hostname == "" {
 		hostname, _ = os.Hostname()
 	}
-	logger := zerolog.New(os.Stdout).Level(logLevel).With().
+	// the level is set globally so that it can be changed when the config is reloaded
+	zerolog.SetGlobalLevel(logLevel)
+	logger := zerolog.New(os.Stdout).With().
 		Str(rtsemconv.EarsLogHostnameKey, hostname).
 		Timestamp().Logger()
 	zerolog.LevelFieldName = "log.level"
@@ -15,6 +15,7 @@
 package app
 
 import (
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -31,6 +32,8 @@ import (
 	"strings"
 )
 
+type subjectCtxKey struct{}
+
 var middlewareLogger *zerolog.Logger
 var jwtMgr jwt.JWTConsumer
 var eventUrlValidator = regexp.MustCompile(`^\/ears\/v1\/orgs\/[a-zA-Z0-9][a-zA-Z0-9_\-]*[a-zA-Z0-9]\/applications\/[a-zA-Z0-9][a-zA-Z0-9_\-]*[a-zA-Z0-9]\/routes\/[a-zA-Z0-9][a-zA-Z0-9_\-\.]*[a-zA-Z0-9]\/event$`)
@@ -44,6 +47,7 @@ func NewMiddleware(logger *zerolog.Logger, jwtManager jwt.JWTConsumer) []func(ne
 		authenticateMiddleware,
 		otelMiddleware,
 		initRequestMiddleware,
+		gzipMiddleware,
 	}
 }
 
@@ -76,7 +80,8 @@ func initRequestMiddleware(next http.Handler) http.Handler {
 func authenticateMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		if r.URL.Path == "/ears/version" {
+		// the probes of load balancers and kubernetes are not authenticated, the detailed health report is for admins only
+		if r.URL.Path == "/ears/version" || r.URL.Path == "/ears/ready" || r.URL.Path == "/ears/live" {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -95,7 +100,14 @@ func authenticateMiddleware(next http.Handler) http.Handler {
 			strings.HasPrefix(r.URL.Path, "/ears/v1/receivers") ||
 			strings.HasPrefix(r.URL.Path, "/ears/v1/filters") ||
 			strings.HasPrefix(r.URL.Path, "/ears/v1/fragments") ||
-			strings.HasPrefix(r.URL.Path, "/ears/v1/tenants") {
+			strings.HasPrefix(r.URL.Path, "/ears/v1/tenants") ||
+			strings.HasPrefix(r.URL.Path, "/ears/v1/audit") ||
+			strings.HasPrefix(r.URL.Path, "/ears/v1/search") ||
+			strings.HasPrefix(r.URL.Path, "/ears/v1/watch") ||
+			strings.HasPrefix(r.URL.Path, "/ears/v1/snapshots") ||
+			strings.HasPrefix(r.URL.Path, "/ears/v1/config/") ||
+			strings.HasPrefix(r.URL.Path, "/ears/graphql") ||
+			r.URL.Path == "/ears/health" {
 		} else {
 			var tenantErr ApiError
 			vars := mux.Vars(r)
@@ -113,13 +125,17 @@ func authenticateMiddleware(next http.Handler) http.Handler {
 			}
 		}
 		bearerToken := getBearerToken(r)
-		_, _, authErr := jwtMgr.VerifyToken(ctx, bearerToken, r.URL.Path, r.Method, tid)
+		_, sub, authErr := jwtMgr.VerifyToken(ctx, bearerToken, r.URL.Path, r.Method, tid)
 		if authErr != nil {
 			log.Ctx(ctx).Error().Str("op", "authenticateMiddleware").Str("error", authErr.Error()).Msg("authorization error")
 			resp := ErrorResponse(convertToApiError(ctx, authErr))
 			resp.Respond(ctx, w, doYaml(r))
 			return
 		}
+		if sub != "" {
+			// remember caller for audit records
+			r = r.WithContext(context.WithValue(ctx, subjectCtxKey{}, sub))
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -15,13 +15,17 @@
 package app
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/jwt"
 	testLog "github.com/xmidt-org/ears/test/log"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -91,3 +95,95 @@ func TestAuthMiddleware(t *testing.T) {
 
 	m.ServeHTTP(w, r.WithContext(subCtx))
 }
+
+func TestAuthMiddlewareHealth(t *testing.T) {
+	logger := zerolog.New(ioutil.Discard)
+	jwtMgr, err := jwt.NewJWTConsumer("https://keys.example.com", func(path, method, scope string) bool { return true }, true, "ears", "api", []string{"admin"}, nil, nil)
+	if err != nil {
+		t.Fatalf("cannot create jwt consumer: %s", err.Error())
+	}
+	middleware := NewMiddleware(&logger, jwtMgr)
+	m := middleware[0](&Validator{func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}})
+	// probes do not need a token but the detailed health report does
+	for path, status := range map[string]int{"/ears/live": http.StatusOK, "/ears/ready": http.StatusOK, "/ears/health": http.StatusBadRequest} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		m.ServeHTTP(w, r.WithContext(logger.WithContext(context.Background())))
+		if w.Code != status {
+			t.Fatalf("unexpected status %d for %s", w.Code, path)
+		}
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	listener := testLog.NewLogListener()
+	logger := zerolog.New(listener)
+	jwtMgr, _ := jwt.NewJWTConsumer("", nil, false, "", "", nil, nil, nil)
+	middleware := NewMiddleware(&logger, jwtMgr)
+	payload := strings.Repeat(`{"foo":"bar"}`, 100)
+
+	//4th middleware should be the gzipMiddleware
+	m := middleware[3](&Validator{func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("cannot read request body: %s", err.Error())
+		}
+		if string(body) != payload {
+			t.Fatalf("unexpected request body: %s", string(body))
+		}
+		w.Write(body)
+	}})
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write([]byte(payload))
+	gw.Close()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" || w.Body.Len() >= len(payload) {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("cannot read gzip response: %s", err.Error())
+	}
+	body, err := ioutil.ReadAll(gr)
+	if err != nil || string(body) != payload {
+		t.Fatalf("unexpected response body: %s", string(body))
+	}
+
+	//plain request without compressed response
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	r.Header.Set("Accept-Encoding", "gzip;q=0")
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" || w.Body.String() != payload {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	//responses without body are not compressed
+	m = middleware[3](&Validator{func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}})
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	m.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified || w.Header().Get("Content-Encoding") != "" || w.Body.Len() != 0 {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	//corrupt request body
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	r.Header.Set("Content-Encoding", "gzip")
+	m.ServeHTTP(w, r.WithContext(logger.WithContext(context.Background())))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status %d", w.Code)
+	}
+}
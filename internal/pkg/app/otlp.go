@@ -0,0 +1,319 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/app"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/export/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	OtlpProtocolGrpc = "grpc"
+	OtlpProtocolHttp = "http"
+
+	DefaultOtlpTimeoutSecs       = 10
+	DefaultTelemetryPushInterval = 5 * time.Second
+
+	otlpTracesPath  = "/v1/traces"
+	otlpMetricsPath = "/v1/metrics"
+)
+
+// otlpConfig is the connection to the otel collector as set by ears.opentelemetry.otel-collector
+type otlpConfig struct {
+	Protocol    string            // grpc or http
+	Endpoint    string            // host:port of the collector
+	UrlPath     string            // prefix of the /v1/traces and /v1/metrics paths of the http protocol
+	Headers     map[string]string // sent with every export, e.g. api keys of hosted collectors
+	TimeoutSecs int
+	TLS         bool // plaintext unless set
+	CaFile      string
+	CertFile    string // client certificate for mutual tls
+	KeyFile     string
+	SkipVerify  bool
+}
+
+func newOtlpConfig(config config.Config) (otlpConfig, error) {
+	c := otlpConfig{
+		Protocol:    strings.ToLower(config.GetString("ears.opentelemetry.otel-collector.protocol")),
+		Endpoint:    config.GetString("ears.opentelemetry.otel-collector.endpoint"),
+		UrlPath:     strings.TrimSuffix(config.GetString("ears.opentelemetry.otel-collector.urlPath"), "/"),
+		TimeoutSecs: config.GetInt("ears.opentelemetry.otel-collector.timeoutSecs"),
+		TLS:         config.GetBool("ears.opentelemetry.otel-collector.tls.active"),
+		CaFile:      config.GetString("ears.opentelemetry.otel-collector.tls.caFile"),
+		CertFile:    config.GetString("ears.opentelemetry.otel-collector.tls.certFile"),
+		KeyFile:     config.GetString("ears.opentelemetry.otel-collector.tls.keyFile"),
+		SkipVerify:  config.GetBool("ears.opentelemetry.otel-collector.tls.insecureSkipVerify"),
+	}
+	if c.Protocol == "" {
+		c.Protocol = OtlpProtocolGrpc
+	}
+	if c.Protocol != OtlpProtocolGrpc && c.Protocol != OtlpProtocolHttp {
+		return c, fmt.Errorf("unknown otel collector protocol %s", c.Protocol)
+	}
+	if c.TimeoutSecs <= 0 {
+		c.TimeoutSecs = DefaultOtlpTimeoutSecs
+	}
+	var err error
+	c.Headers, err = parseKeyValues(config.GetString("ears.opentelemetry.otel-collector.headers"))
+	if err != nil {
+		return c, fmt.Errorf("bad otel collector headers: %s", err.Error())
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return c, errors.New("otel collector client certificate requires both certFile and keyFile")
+	}
+	return c, nil
+}
+
+func (c otlpConfig) equal(other otlpConfig) bool {
+	return reflect.DeepEqual(c, other)
+}
+
+func (c otlpConfig) timeout() time.Duration {
+	return time.Duration(c.TimeoutSecs) * time.Second
+}
+
+func (c otlpConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.SkipVerify}
+	if c.CaFile != "" {
+		pem, err := ioutil.ReadFile(c.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CaFile)
+		}
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// parseKeyValues parses a comma separated list of key=value pairs
+func parseKeyValues(s string) (map[string]string, error) {
+	kvs := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("expected key=value but got %s", kv)
+		}
+		kvs[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return kvs, nil
+}
+
+// telemetryResource describes this instance in all exported traces and metrics, ears.opentelemetry.resourceAttributes
+// adds attributes such as the deployment environment or overrides the defaults
+func telemetryResource(config config.Config) (*resource.Resource, error) {
+	var hostname, _ = os.Hostname()
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(rtsemconv.EARSServiceName),
+		semconv.ServiceVersionKey.String(app.Version),
+		semconv.NetHostNameKey.String(hostname),
+	}
+	if config != nil {
+		kvs, err := parseKeyValues(config.GetString("ears.opentelemetry.resourceAttributes"))
+		if err != nil {
+			return nil, fmt.Errorf("bad resource attributes: %s", err.Error())
+		}
+		// later attributes win so configured ones override the defaults
+		for k, v := range kvs {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
+
+// telemetryPushInterval is the interval metrics are pushed to the otel collector or stdout in
+func telemetryPushInterval(config config.Config) time.Duration {
+	secs := config.GetInt("ears.opentelemetry.pushIntervalSecs")
+	if secs <= 0 {
+		return DefaultTelemetryPushInterval
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// newOtelCollectorTraceProvider creates a trace provider exporting to the otel collector
+func newOtelCollectorTraceProvider(ctx context.Context, c otlpConfig, res *resource.Resource, sampler sdktrace.Sampler) (*sdktrace.TracerProvider, error) {
+	var client otlptrace.Client
+	if c.Protocol == OtlpProtocolHttp {
+		httpClient, err := newOtlpHttpClient(c, otlpTracesPath)
+		if err != nil {
+			return nil, err
+		}
+		client = httpClient
+	} else {
+		// grpc does not allow a uri path which makes it hard to set this up behind a proxy or load balancer
+		options := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(c.Endpoint),
+			otlptracegrpc.WithHeaders(c.Headers),
+			otlptracegrpc.WithTimeout(c.timeout()),
+		}
+		if c.TLS {
+			tlsConfig, err := c.tlsConfig()
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		client = otlptracegrpc.NewClient(options...)
+	}
+	traceExporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// newOtelCollectorMetricExporter creates a metric exporter pushing to the otel collector
+func newOtelCollectorMetricExporter(ctx context.Context, c otlpConfig) (*otlpmetric.Exporter, error) {
+	var client otlpmetric.Client
+	if c.Protocol == OtlpProtocolHttp {
+		httpClient, err := newOtlpHttpClient(c, otlpMetricsPath)
+		if err != nil {
+			return nil, err
+		}
+		client = httpClient
+	} else {
+		options := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(c.Endpoint),
+			otlpmetricgrpc.WithHeaders(c.Headers),
+			otlpmetricgrpc.WithTimeout(c.timeout()),
+		}
+		if c.TLS {
+			tlsConfig, err := c.tlsConfig()
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		} else {
+			options = append(options, otlpmetricgrpc.WithInsecure())
+		}
+		client = otlpmetricgrpc.NewClient(options...)
+	}
+	return otlpmetric.New(ctx, client, otlpmetric.WithMetricExportKindSelector(sdkmetric.DeltaExportKindSelector()))
+}
+
+// otlpHttpClient posts protobuf encoded traces or metrics to the otlp http endpoint of a collector
+type otlpHttpClient struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newOtlpHttpClient(c otlpConfig, path string) (*otlpHttpClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	scheme := "http"
+	if c.TLS {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+		scheme = "https"
+	}
+	return &otlpHttpClient{
+		url:     scheme + "://" + c.Endpoint + c.UrlPath + path,
+		headers: c.Headers,
+		client:  &http.Client{Transport: transport, Timeout: c.timeout()},
+	}, nil
+}
+
+func (c *otlpHttpClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *otlpHttpClient) Stop(ctx context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+func (c *otlpHttpClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	return c.upload(ctx, &coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+}
+
+func (c *otlpHttpClient) UploadMetrics(ctx context.Context, protoMetrics []*metricpb.ResourceMetrics) error {
+	return c.upload(ctx, &colmetricpb.ExportMetricsServiceRequest{ResourceMetrics: protoMetrics})
+}
+
+func (c *otlpHttpClient) upload(ctx context.Context, msg proto.Message) error {
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("otel collector %s responded with status %d", c.url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOtlpConfig(t *testing.T) {
+	v := viper.New()
+	c, err := newOtlpConfig(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c.Protocol != OtlpProtocolGrpc || c.TimeoutSecs != DefaultOtlpTimeoutSecs || c.TLS || len(c.Headers) != 0 {
+		t.Fatalf("unexpected defaults %+v", c)
+	}
+	v.Set("ears.opentelemetry.otel-collector.protocol", "HTTP")
+	v.Set("ears.opentelemetry.otel-collector.urlPath", "/otlp/")
+	v.Set("ears.opentelemetry.otel-collector.headers", "x-api-key=secret, x-env = prod")
+	c2, err := newOtlpConfig(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if c2.Protocol != OtlpProtocolHttp || c2.UrlPath != "/otlp" || c2.Headers["x-api-key"] != "secret" || c2.Headers["x-env"] != "prod" {
+		t.Fatalf("unexpected config %+v", c2)
+	}
+	if c.equal(c2) {
+		t.Fatalf("configs should differ")
+	}
+	v.Set("ears.opentelemetry.otel-collector.headers", "x-api-key")
+	_, err = newOtlpConfig(v)
+	if err == nil {
+		t.Fatalf("expected error for bad headers")
+	}
+	v.Set("ears.opentelemetry.otel-collector.headers", "")
+	v.Set("ears.opentelemetry.otel-collector.protocol", "udp")
+	_, err = newOtlpConfig(v)
+	if err == nil {
+		t.Fatalf("expected error for unknown protocol")
+	}
+	v.Set("ears.opentelemetry.otel-collector.protocol", "grpc")
+	v.Set("ears.opentelemetry.otel-collector.tls.certFile", "client.pem")
+	_, err = newOtlpConfig(v)
+	if err == nil {
+		t.Fatalf("expected error for certificate without key")
+	}
+}
+
+func TestTelemetryResource(t *testing.T) {
+	v := viper.New()
+	v.Set("ears.opentelemetry.resourceAttributes", "deployment.environment=staging,service.name=ears-staging")
+	res, err := telemetryResource(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range res.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	if attrs["deployment.environment"] != "staging" || attrs["service.name"] != "ears-staging" {
+		t.Fatalf("unexpected resource attributes %+v", attrs)
+	}
+	v.Set("ears.opentelemetry.resourceAttributes", "staging")
+	_, err = telemetryResource(v)
+	if err == nil {
+		t.Fatalf("expected error for bad resource attributes")
+	}
+}
+
+func TestOtlpHttpClient(t *testing.T) {
+	var received coltracepb.ExportTraceServiceRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/otlp/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" || r.Header.Get("x-api-key") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		err := proto.Unmarshal(body, &received)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}))
+	defer collector.Close()
+	c := otlpConfig{
+		Protocol:    OtlpProtocolHttp,
+		Endpoint:    strings.TrimPrefix(collector.URL, "http://"),
+		UrlPath:     "/otlp",
+		Headers:     map[string]string{"x-api-key": "secret"},
+		TimeoutSecs: 1,
+	}
+	client, err := newOtlpHttpClient(c, otlpTracesPath)
+	if err != nil {
+		t.Fatalf("cannot create client: %s", err.Error())
+	}
+	err = client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{{SchemaUrl: "test"}})
+	if err != nil {
+		t.Fatalf("upload failed: %s", err.Error())
+	}
+	if len(received.ResourceSpans) != 1 || received.ResourceSpans[0].SchemaUrl != "test" {
+		t.Fatalf("unexpected export request %+v", received.ResourceSpans)
+	}
+	client.headers = nil
+	err = client.UploadTraces(context.Background(), []*tracepb.ResourceSpans{{SchemaUrl: "test"}})
+	if err == nil {
+		t.Fatalf("expected error for rejected upload")
+	}
+}
+
+func TestScrapedMetricsPusher(t *testing.T) {
+	exporter, err := NewPrometheusExporter(resource.Empty())
+	if err != nil {
+		t.Fatalf("cannot create prometheus exporter: %s", err.Error())
+	}
+	var out bytes.Buffer
+	pushExporter, err := stdoutmetric.New(stdoutmetric.WithWriter(&out))
+	if err != nil {
+		t.Fatalf("cannot create stdout exporter: %s", err.Error())
+	}
+	logger := zerolog.Nop()
+	pusher := newScrapedMetricsPusher(exporter.Controller(), pushExporter, time.Hour, &logger)
+	err = pusher.Start(context.Background())
+	if err != nil {
+		t.Fatalf("cannot start pusher: %s", err.Error())
+	}
+	counter := metric.Must(exporter.MeterProvider().Meter("ears")).NewInt64Counter("ears.test.pushed")
+	counter.Add(context.Background(), 3, attribute.String("ears.route.id", "r100"))
+	// scraping still works while the pipeline is pushed
+	w := httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := ioutil.ReadAll(w.Body)
+	if !strings.Contains(string(body), `ears_test_pushed{ears_route_id="r100"} 3`) {
+		t.Fatalf("metric missing in scrape output: %s", string(body))
+	}
+	// stopping pushes the metrics one last time
+	err = pusher.Stop(context.Background())
+	if err != nil {
+		t.Fatalf("cannot stop pusher: %s", err.Error())
+	}
+	if !strings.Contains(out.String(), `"Name":"ears.test.pushed{`) || !strings.Contains(out.String(), `"Sum":3`) {
+		t.Fatalf("metric missing in pushed output: %s", out.String())
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const quotaAlertTimeout = 10 * time.Second
+
+// quotaAlerter delivers quota utilization alerts to the url and the route of the tenant's quota alert
+type quotaAlerter struct {
+	routingTableMgr tablemgr.RoutingTableManager
+	client          *http.Client
+	logger          *zerolog.Logger
+}
+
+// SetupQuotaAlerts lets the quota manager deliver utilization alerts to the destinations configured by the tenants
+func SetupQuotaAlerts(quotaManager *quota.QuotaManager, routingTableMgr tablemgr.RoutingTableManager, logger *zerolog.Logger) {
+	if quotaManager == nil {
+		return
+	}
+	alerter := &quotaAlerter{
+		routingTableMgr: routingTableMgr,
+		client:          &http.Client{Timeout: quotaAlertTimeout},
+		logger:          logger,
+	}
+	quotaManager.SetAlertHandler(alerter.alert)
+}
+
+func (a *quotaAlerter) alert(ctx context.Context, config tenant.QuotaAlert, alert quota.UtilizationAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		a.logger.Error().Str("op", "quotaAlerter.alert").Str("tenantId", alert.Tenant.ToString()).Str("error", err.Error()).Msg("cannot marshal quota alert")
+		return
+	}
+	if config.Url != "" {
+		err = a.post(ctx, config.Url, body)
+		if err != nil {
+			a.logger.Error().Str("op", "quotaAlerter.alert").Str("tenantId", alert.Tenant.ToString()).Str("url", config.Url).Str("error", err.Error()).Msg("cannot post quota alert")
+		}
+	}
+	if config.RouteId != "" {
+		var payload interface{}
+		json.Unmarshal(body, &payload)
+		_, err = a.routingTableMgr.RouteEventAsync(ctx, alert.Tenant, config.RouteId, "", payload, func(err error) {
+			if err != nil {
+				a.logger.Error().Str("op", "quotaAlerter.alert").Str("tenantId", alert.Tenant.ToString()).Str("routeId", config.RouteId).Str("error", err.Error()).Msg("quota alert nacked")
+			}
+		})
+		if err != nil {
+			a.logger.Error().Str("op", "quotaAlerter.alert").Str("tenantId", alert.Tenant.ToString()).Str("routeId", config.RouteId).Str("error", err.Error()).Msg("cannot route quota alert")
+		}
+	}
+}
+
+func (a *quotaAlerter) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return &QuotaAlertError{Url: url, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
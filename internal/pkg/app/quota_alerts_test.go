@@ -0,0 +1,59 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestQuotaAlerterWebhook(t *testing.T) {
+	received := make(chan quota.UtilizationAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert quota.UtilizationAlert
+		err := json.NewDecoder(r.Body).Decode(&alert)
+		if err != nil {
+			t.Errorf("cannot decode quota alert: %s", err.Error())
+		}
+		received <- alert
+	}))
+	defer server.Close()
+	logger := zerolog.New(os.Stdout)
+	alerter := &quotaAlerter{client: server.Client(), logger: &logger}
+	alert := quota.UtilizationAlert{
+		Tenant:       tenant.Id{OrgId: "myorg", AppId: "myapp"},
+		EventsPerSec: 10,
+		Usage:        9,
+		Utilization:  90,
+		Threshold:    80,
+	}
+	alerter.alert(context.Background(), tenant.QuotaAlert{Thresholds: []int{80}, Url: server.URL}, alert)
+	select {
+	case got := <-received:
+		if got != alert {
+			t.Fatalf("unexpected quota alert %+v", got)
+		}
+	default:
+		t.Fatalf("quota alert not posted")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"go.uber.org/fx"
+)
+
+// ConfigReader re-reads ears.yaml into the application config
+type ConfigReader func() error
+
+// Reloadable is implemented by components that can apply a changed configuration without a restart
+type Reloadable interface {
+	ReloadConfig(config config.Config) error
+}
+
+// ReloadableFunc adapts a function to the Reloadable interface
+type ReloadableFunc func(config config.Config) error
+
+func (f ReloadableFunc) ReloadConfig(config config.Config) error {
+	return f(config)
+}
+
+// ConfigReloader re-reads the configuration and hands it to all registered components. Routes, receivers and
+// senders are not touched so events in flight are not dropped.
+type ConfigReloader struct {
+	sync.Mutex
+	config      config.Config
+	read        ConfigReader
+	logger      *zerolog.Logger
+	names       []string
+	reloadables []Reloadable
+}
+
+func NewConfigReloader(config config.Config, read ConfigReader, logger *zerolog.Logger) *ConfigReloader {
+	return &ConfigReloader{
+		config: config,
+		read:   read,
+		logger: logger,
+	}
+}
+
+// Register adds a component to be reloaded, components are reloaded in the order they are registered
+func (r *ConfigReloader) Register(name string, reloadable Reloadable) {
+	r.Lock()
+	defer r.Unlock()
+	r.names = append(r.names, name)
+	r.reloadables = append(r.reloadables, reloadable)
+}
+
+// Reload re-reads the configuration and reloads all components. A component failing to reload does not keep the
+// others from reloading, the first error is returned.
+func (r *ConfigReloader) Reload() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.read != nil {
+		err := r.read()
+		if err != nil {
+			r.logger.Error().Str("op", "ConfigReloader.Reload").Str("error", err.Error()).Msg("cannot read config")
+			return &ConfigReloadError{Component: "config", Err: err}
+		}
+	}
+	var firstErr error
+	for idx, reloadable := range r.reloadables {
+		err := reloadable.ReloadConfig(r.config)
+		if err != nil {
+			r.logger.Error().Str("op", "ConfigReloader.Reload").Str("component", r.names[idx]).Str("error", err.Error()).Msg("cannot reload config")
+			if firstErr == nil {
+				firstErr = &ConfigReloadError{Component: r.names[idx], Err: err}
+			}
+		}
+	}
+	if firstErr == nil {
+		r.logger.Info().Str("op", "ConfigReloader.Reload").Strs("components", r.names).Msg("config reloaded")
+	}
+	return firstErr
+}
+
+// reloadLogLevel applies ears.logLevel to all loggers
+func reloadLogLevel(config config.Config) error {
+	logLevel, err := zerolog.ParseLevel(config.GetString("ears.logLevel"))
+	if err != nil {
+		return &InvalidOptionError{
+			Option: "loglevel " + config.GetString("ears.logLevel") + " is not valid",
+		}
+	}
+	zerolog.SetGlobalLevel(logLevel)
+	return nil
+}
+
+// SetupConfigReload registers the reloadable components and reloads the configuration on SIGHUP
+func SetupConfigReload(lifecycle fx.Lifecycle, reloader *ConfigReloader, api *APIManager, quotaManager *quota.QuotaManager, logger *zerolog.Logger) {
+	reloader.Register("logLevel", ReloadableFunc(reloadLogLevel))
+	reloader.Register("webhook", api)
+	if quotaManager != nil {
+		reloader.Register("quota", quotaManager)
+	}
+	api.configReloader = reloader
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lifecycle.Append(
+		fx.Hook{
+			OnStart: func(context.Context) error {
+				signal.Notify(signals, syscall.SIGHUP)
+				go func() {
+					for {
+						select {
+						case <-signals:
+							logger.Info().Str("op", "SetupConfigReload").Msg("SIGHUP received, reloading config")
+							reloader.Reload()
+						case <-done:
+							return
+						}
+					}
+				}()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				signal.Stop(signals)
+				close(done)
+				return nil
+			},
+		},
+	)
+}
+
+func (a *APIManager) reloadConfigHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if a.configReloader == nil {
+		log.Ctx(ctx).Error().Str("op", "reloadConfigHandler").Msg("config reload not configured")
+		resp := ErrorResponse(&PreconditionFailedError{"config reload not configured"})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	err := a.configReloader.Reload()
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "reloadConfigHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	log.Ctx(ctx).Info().Str("op", "reloadConfigHandler").Msg("config reloaded")
+	resp := ItemResponse("config reloaded")
+	resp.Respond(ctx, w, doYaml(r))
+}
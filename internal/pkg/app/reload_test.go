@@ -0,0 +1,78 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+)
+
+func TestConfigReload(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	logger := zerolog.New(os.Stdout)
+	v := viper.New()
+	v.Set("ears.logLevel", "info")
+	settings := map[string]interface{}{}
+	read := func() error {
+		for k, val := range settings {
+			v.Set(k, val)
+		}
+		return nil
+	}
+	reloader := NewConfigReloader(v, read, &logger)
+	api := &APIManager{}
+	reloader.Register("logLevel", ReloadableFunc(reloadLogLevel))
+	reloader.Register("webhook", api)
+	reloaded := 0
+	reloader.Register("counter", ReloadableFunc(func(config config.Config) error {
+		reloaded++
+		return nil
+	}))
+
+	settings["ears.logLevel"] = "warn"
+	settings["ears.api.webhook.mappings"] = "/ears/v1/events/github=myorg/myapp/githubRoute"
+	err := reloader.Reload()
+	if err != nil {
+		t.Fatalf("cannot reload config: %s", err.Error())
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Fatalf("expected log level warn but got %s", zerolog.GlobalLevel().String())
+	}
+	mapping := api.findWebhookMapping(httptest.NewRequest(http.MethodPost, "/ears/v1/events/github", nil))
+	if mapping == nil || mapping.routeId != "githubRoute" {
+		t.Fatalf("expected reloaded webhook mapping but got %+v", mapping)
+	}
+
+	// a component failing to reload does not keep the others from reloading
+	settings["ears.logLevel"] = "loud"
+	err = reloader.Reload()
+	var reloadErr *ConfigReloadError
+	if !errors.As(err, &reloadErr) || reloadErr.Component != "logLevel" {
+		t.Fatalf("expected ConfigReloadError for logLevel but got %v", err)
+	}
+	if reloaded != 2 {
+		t.Fatalf("expected 2 reloads but got %d", reloaded)
+	}
+	if zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Fatalf("expected log level warn but got %s", zerolog.GlobalLevel().String())
+	}
+}
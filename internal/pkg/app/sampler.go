@@ -0,0 +1,191 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultTraceSamplingRatio = 1.0
+	tenantSamplingRefresh     = 30 * time.Second
+)
+
+// traceSampler samples the traces of events by tenant and route. Events are sampled when received at the ratio of
+// their tenant, or at the highest ratio of the routes fed by the receiver if higher or if all of these routes set
+// their own ratio. Spans of a route are sampled among the sampled events at the ratio of the route, decisions are
+// taken on the trace id so that a lower ratio always samples a subset of the traces of a higher ratio. Debug events
+// are always sampled. All other spans follow their parent or the default ratio if they have none.
+type traceSampler struct {
+	sync.RWMutex
+	ratio        float64               // ears.opentelemetry.sampling.ratio
+	tenants      map[tenant.Id]float64 // ratios of the tenants with a trace sampling config
+	tenantStorer tenant.TenantStorer
+	logger       *zerolog.Logger
+	done         chan struct{}
+}
+
+func newTraceSampler(config config.Config, tenantStorer tenant.TenantStorer, logger *zerolog.Logger) *traceSampler {
+	s := &traceSampler{
+		ratio:        DefaultTraceSamplingRatio,
+		tenants:      make(map[tenant.Id]float64),
+		tenantStorer: tenantStorer,
+		logger:       logger,
+	}
+	err := s.ReloadConfig(config)
+	if err != nil {
+		logger.Error().Str("op", "newTraceSampler").Msg(err.Error())
+	}
+	return s
+}
+
+// ReloadConfig sets the default ratio and the path of the debug flag of events
+func (s *traceSampler) ReloadConfig(config config.Config) error {
+	event.SetDebugPath(config.GetString("ears.opentelemetry.sampling.debugPath"))
+	ratio := DefaultTraceSamplingRatio
+	if value := config.GetString("ears.opentelemetry.sampling.ratio"); value != "" {
+		var err error
+		ratio, err = strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return &InvalidOptionError{fmt.Sprintf("invalid ears.opentelemetry.sampling.ratio %s, must be between 0 and 1", value)}
+		}
+	}
+	s.Lock()
+	s.ratio = ratio
+	s.Unlock()
+	return nil
+}
+
+// start refreshes the ratios of the tenants periodically until stopped
+func (s *traceSampler) start(ctx context.Context) {
+	s.done = make(chan struct{})
+	s.refreshTenants(ctx)
+	go func() {
+		ticker := time.NewTicker(tenantSamplingRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.refreshTenants(ctx)
+			}
+		}
+	}()
+}
+
+func (s *traceSampler) stop() {
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+}
+
+func (s *traceSampler) refreshTenants(ctx context.Context) {
+	if s.tenantStorer == nil {
+		return
+	}
+	configs, err := s.tenantStorer.GetAllConfigs(ctx)
+	if err != nil {
+		s.logger.Error().Str("op", "traceSampler.refreshTenants").Msg(err.Error())
+		return
+	}
+	tenants := make(map[tenant.Id]float64)
+	for _, config := range configs {
+		if config.TraceSampling != nil {
+			tenants[config.Tenant] = config.TraceSampling.Ratio
+		}
+	}
+	s.Lock()
+	s.tenants = tenants
+	s.Unlock()
+}
+
+// tenantRatio returns the ratio of a tenant, the default ratio if the tenant has none
+func (s *traceSampler) tenantRatio(tid tenant.Id) float64 {
+	s.RLock()
+	defer s.RUnlock()
+	if ratio, ok := s.tenants[tid]; ok {
+		return ratio
+	}
+	return s.ratio
+}
+
+func (s *traceSampler) defaultRatio() float64 {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ratio
+}
+
+func (s *traceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if event.IsDebug(p.ParentContext) {
+		return sdktrace.AlwaysSample().ShouldSample(p)
+	}
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	if route.IdFromContext(p.ParentContext) != "" {
+		// span of a route
+		if parent.IsValid() && !parent.IsSampled() {
+			return sdktrace.NeverSample().ShouldSample(p)
+		}
+		if sampling := route.TraceSamplingFromContext(p.ParentContext); sampling != nil {
+			return sdktrace.TraceIDRatioBased(sampling.Ratio).ShouldSample(p)
+		}
+		if parent.IsValid() {
+			return sdktrace.AlwaysSample().ShouldSample(p)
+		}
+		return sdktrace.TraceIDRatioBased(s.defaultRatio()).ShouldSample(p)
+	}
+	if tid, ok := samplingTenant(p); ok && !(parent.IsValid() && parent.IsRemote()) {
+		// span of an event received by a receiver, the decision of an upstream system is kept
+		ratio := s.tenantRatio(tid)
+		routeRatio, inherit := route.ReceiverTraceSampling(tid, p.Name)
+		if !inherit || routeRatio > ratio {
+			ratio = routeRatio
+		}
+		return sdktrace.TraceIDRatioBased(ratio).ShouldSample(p)
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.defaultRatio())).ShouldSample(p)
+}
+
+func (s *traceSampler) Description() string {
+	return "EarsTraceSampler"
+}
+
+// samplingTenant returns the tenant of the span of a received event
+func samplingTenant(p sdktrace.SamplingParameters) (tenant.Id, bool) {
+	var tid tenant.Id
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case rtsemconv.EARSOrgId:
+			tid.OrgId = attr.Value.AsString()
+		case rtsemconv.EARSAppId:
+			tid.AppId = attr.Value.AsString()
+		}
+	}
+	return tid, tid.OrgId != "" || tid.AppId != ""
+}
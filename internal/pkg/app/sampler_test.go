@@ -0,0 +1,93 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceSamplerTenantRatio(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	quiet := tenant.Id{OrgId: "myorg", AppId: "quiet"}
+	sampler := &traceSampler{
+		ratio:   1,
+		tenants: map[tenant.Id]float64{quiet: 0},
+		logger:  &logger,
+	}
+	traceId := trace.TraceID{0x01}
+	params := func(tid tenant.Id) sdktrace.SamplingParameters {
+		return sdktrace.SamplingParameters{
+			ParentContext: context.Background(),
+			TraceID:       traceId,
+			Name:          "myReceiver",
+			Attributes:    []attribute.KeyValue{rtsemconv.EARSOrgId.String(tid.OrgId), rtsemconv.EARSAppId.String(tid.AppId)},
+		}
+	}
+	if d := sampler.ShouldSample(params(quiet)).Decision; d != sdktrace.Drop {
+		t.Errorf("expected events of the quiet tenant to be dropped, got %v", d)
+	}
+	if d := sampler.ShouldSample(params(tenant.Id{OrgId: "myorg", AppId: "loud"})).Decision; d != sdktrace.RecordAndSample {
+		t.Errorf("expected events of the loud tenant to be sampled, got %v", d)
+	}
+}
+
+func TestTraceSamplerDebugEvent(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	quiet := tenant.Id{OrgId: "myorg", AppId: "quiet"}
+	sampler := &traceSampler{
+		ratio:   0,
+		tenants: map[tenant.Id]float64{},
+		logger:  &logger,
+	}
+	event.SetDebugPath("payload.debug")
+	defer event.SetDebugPath("")
+	testCases := []struct {
+		payload []byte
+		sampled bool
+	}{
+		{[]byte(`{"debug":true}`), true},
+		{[]byte(`{"debug":false}`), false},
+		{[]byte(`{"foo":"bar"}`), false},
+	}
+	for _, tc := range testCases {
+		e, err := event.New(context.Background(), nil, event.WithRawPayload(tc.payload), event.WithTenant(quiet), event.WithOtelTracing("myReceiver"))
+		if err != nil {
+			t.Fatalf("cannot create event: %s", err.Error())
+		}
+		if event.IsDebug(e.Context()) != tc.sampled {
+			t.Errorf("expected debug=%t for payload %s", tc.sampled, tc.payload)
+		}
+		p := sdktrace.SamplingParameters{
+			ParentContext: e.Context(),
+			TraceID:       trace.TraceID{0x01},
+			Name:          "myReceiver",
+			Attributes:    []attribute.KeyValue{rtsemconv.EARSOrgId.String(quiet.OrgId), rtsemconv.EARSAppId.String(quiet.AppId)},
+		}
+		sampled := sampler.ShouldSample(p).Decision == sdktrace.RecordAndSample
+		if sampled != tc.sampled {
+			t.Errorf("expected sampled=%t for payload %s", tc.sampled, tc.payload)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	SEARCH_KIND_ROUTE    = "route"
+	SEARCH_KIND_FRAGMENT = "fragment"
+)
+
+// SearchMatch is a route or fragment matching a search query along with the fields the query was found in
+type SearchMatch struct {
+	Kind   string    `json:"kind"`
+	Tenant tenant.Id `json:"tenant"`
+	Id     string    `json:"id"`
+	Name   string    `json:"name,omitempty"`
+	Fields []string  `json:"fields"`
+}
+
+// containsValue reports whether any string, number or boolean nested in v contains the lower case query
+func containsValue(v interface{}, query string) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.Contains(strings.ToLower(val), query)
+	case map[string]interface{}:
+		for k, e := range val {
+			if strings.Contains(strings.ToLower(k), query) || containsValue(e, query) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, e := range val {
+			if containsValue(k, query) || containsValue(e, query) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range val {
+			if containsValue(e, query) {
+				return true
+			}
+		}
+	default:
+		return strings.Contains(strings.ToLower(fmt.Sprint(val)), query)
+	}
+	return false
+}
+
+// pluginConfigMatches reports whether the plugin type, name, fragment name or config of a plugin contain the query
+func pluginConfigMatches(pc *route.PluginConfig, query string) bool {
+	return containsValue(pc.Plugin, query) ||
+		containsValue(pc.Name, query) ||
+		containsValue(pc.FragmentName, query) ||
+		containsValue(pc.Config, query)
+}
+
+// searchRoute returns the fields of a route containing the lower case query
+func searchRoute(routeConfig *route.Config, query string) []string {
+	fields := make([]string, 0)
+	if containsValue(routeConfig.Id, query) {
+		fields = append(fields, "id")
+	}
+	if containsValue(routeConfig.Name, query) {
+		fields = append(fields, "name")
+	}
+	if pluginConfigMatches(&routeConfig.Receiver, query) {
+		fields = append(fields, "receiver")
+	}
+	for idx := range routeConfig.FilterChain {
+		if pluginConfigMatches(&routeConfig.FilterChain[idx], query) {
+			fields = append(fields, "filterChain["+strconv.Itoa(idx)+"]")
+		}
+	}
+	if pluginConfigMatches(&routeConfig.Sender, query) {
+		fields = append(fields, "sender")
+	}
+	if routeConfig.DeadLetter != nil && pluginConfigMatches(routeConfig.DeadLetter, query) {
+		fields = append(fields, "deadLetter")
+	}
+	names := make([]string, 0, len(routeConfig.Branches))
+	for name := range routeConfig.Branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b := routeConfig.Branches[name]
+		if pluginConfigMatches(&b, query) {
+			fields = append(fields, "branches."+name)
+		}
+	}
+	return fields
+}
+
+// searchFragment returns the fields of a fragment containing the lower case query
+func searchFragment(fragment *route.PluginConfig, query string) []string {
+	fields := make([]string, 0)
+	if containsValue(fragment.FragmentName, query) {
+		fields = append(fields, "id")
+	}
+	if containsValue(fragment.Name, query) {
+		fields = append(fields, "name")
+	}
+	if containsValue(fragment.Plugin, query) {
+		fields = append(fields, "plugin")
+	}
+	if containsValue(fragment.Config, query) {
+		fields = append(fields, "config")
+	}
+	return fields
+}
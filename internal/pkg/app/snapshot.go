@@ -0,0 +1,146 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// snapshotRestorer writes the items of a restored snapshot the same way the api handlers do, so routes are started
+// on all instances, quotas are synced and every restored item is audited
+type snapshotRestorer struct {
+	a *APIManager
+	r *http.Request
+}
+
+func (s *snapshotRestorer) SetTenantConfig(ctx context.Context, config tenant.Config) error {
+	s.a.tenantConfigLock.Lock()
+	defer s.a.tenantConfigLock.Unlock()
+	oldConfig, _ := s.a.tenantStorer.GetConfig(ctx, config.Tenant)
+	err := s.a.tenantStorer.SetConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+	if oldConfig != nil {
+		s.a.recordAudit(s.r, config.Tenant, audit.ItemTypeTenant, config.Tenant.Key(), audit.ActionUpdate, oldConfig, config)
+	} else {
+		s.a.recordAudit(s.r, config.Tenant, audit.ItemTypeTenant, config.Tenant.Key(), audit.ActionCreate, nil, config)
+	}
+	if s.a.quotaManager != nil {
+		s.a.quotaManager.PublishQuota(ctx, config.Tenant)
+	}
+	return nil
+}
+
+func (s *snapshotRestorer) AddFragment(ctx context.Context, tid tenant.Id, fragmentConfig route.PluginConfig) error {
+	oldFragment, oldErr := s.a.routingTableMgr.GetFragment(ctx, tid, fragmentConfig.FragmentName)
+	err := s.a.routingTableMgr.AddFragment(ctx, tid, fragmentConfig)
+	if err != nil {
+		return err
+	}
+	if oldErr == nil {
+		s.a.recordAudit(s.r, tid, audit.ItemTypeFragment, fragmentConfig.FragmentName, audit.ActionUpdate, oldFragment, fragmentConfig)
+	} else {
+		s.a.recordAudit(s.r, tid, audit.ItemTypeFragment, fragmentConfig.FragmentName, audit.ActionCreate, nil, fragmentConfig)
+	}
+	return nil
+}
+
+func (s *snapshotRestorer) AddRoute(ctx context.Context, routeConfig *route.Config) error {
+	oldRoute, oldErr := s.a.routingTableMgr.GetRoute(ctx, routeConfig.TenantId, routeConfig.Id)
+	err := s.a.routingTableMgr.AddRoute(ctx, routeConfig)
+	if err != nil {
+		return err
+	}
+	if oldErr == nil {
+		s.a.recordAudit(s.r, routeConfig.TenantId, audit.ItemTypeRoute, routeConfig.Id, audit.ActionUpdate, *oldRoute, *routeConfig)
+	} else {
+		s.a.recordAudit(s.r, routeConfig.TenantId, audit.ItemTypeRoute, routeConfig.Id, audit.ActionCreate, nil, *routeConfig)
+	}
+	return nil
+}
+
+// snapshotsConfigured responds with an error if no snapshot location is configured
+func (a *APIManager) snapshotsConfigured(w http.ResponseWriter, r *http.Request, op string) bool {
+	if a.snapshotManager != nil {
+		return true
+	}
+	ctx := r.Context()
+	log.Ctx(ctx).Error().Str("op", op).Msg("snapshots not configured")
+	resp := ErrorResponse(&PreconditionFailedError{"snapshots not configured, set ears.snapshot.url"})
+	resp.Respond(ctx, w, doYaml(r))
+	return false
+}
+
+func (a *APIManager) getSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.snapshotsConfigured(w, r, "getSnapshotsHandler") {
+		return
+	}
+	ctx := r.Context()
+	urls, err := a.snapshotManager.List(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "getSnapshotsHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	resp := ItemsResponse(urls)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+func (a *APIManager) takeSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.snapshotsConfigured(w, r, "takeSnapshotHandler") {
+		return
+	}
+	ctx := r.Context()
+	url, err := a.snapshotManager.Save(ctx, time.Now())
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "takeSnapshotHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	log.Ctx(ctx).Info().Str("op", "takeSnapshotHandler").Str("url", url).Msg("saved snapshot")
+	resp := ItemResponse(url)
+	resp.Respond(ctx, w, doYaml(r))
+}
+
+// restoreSnapshotHandler restores the snapshot given by the url parameter, by default the latest one. Items are
+// written through the routing table manager and the tenant storer like the api does, so that routes are started
+// on all instances.
+func (a *APIManager) restoreSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.snapshotsConfigured(w, r, "restoreSnapshotHandler") {
+		return
+	}
+	ctx := r.Context()
+	s, err := a.snapshotManager.Load(ctx, r.URL.Query().Get("url"))
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "restoreSnapshotHandler").Msg(err.Error())
+		resp := ErrorResponse(convertToApiError(ctx, err))
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	result := a.snapshotManager.Restore(ctx, s, &snapshotRestorer{a, r})
+	log.Ctx(ctx).Info().Str("op", "restoreSnapshotHandler").Int("routes", result.Routes).Int("errors", len(result.Errors)).Msg("restored snapshot")
+	resp := ItemResponse(result)
+	resp.Respond(ctx, w, doYaml(r))
+}
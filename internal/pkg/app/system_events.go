@@ -0,0 +1,130 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
+	"github.com/xmidt-org/ears/internal/pkg/tablemgr"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.uber.org/fx"
+)
+
+const systemEventsQueueSize = 1000
+
+// systemEvents routes the system events of EARS to the route set by ears.systemEvents.orgId, appId and routeId.
+// Events are queued so that emitters never block and are dropped while the queue is full. Problems of the system
+// events route itself are not routed to avoid feedback loops.
+type systemEvents struct {
+	sync.RWMutex
+	tid             tenant.Id
+	routeId         string // system events are dropped if empty
+	routingTableMgr tablemgr.RoutingTableManager
+	logger          *zerolog.Logger
+	queue           chan sysevent.Event
+	done            chan struct{}
+}
+
+// SetupSystemEvents routes the system events of EARS to the configured system events route
+func SetupSystemEvents(lifecycle fx.Lifecycle, config config.Config, routingTableMgr tablemgr.RoutingTableManager, logger *zerolog.Logger, reloader *ConfigReloader) {
+	s := &systemEvents{
+		routingTableMgr: routingTableMgr,
+		logger:          logger,
+		queue:           make(chan sysevent.Event, systemEventsQueueSize),
+		done:            make(chan struct{}),
+	}
+	s.ReloadConfig(config)
+	reloader.Register("systemEvents", s)
+	lifecycle.Append(
+		fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				sysevent.SetHandler(s.enqueue)
+				go s.run()
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				sysevent.SetHandler(nil)
+				close(s.done)
+				return nil
+			},
+		},
+	)
+}
+
+// ReloadConfig sets the system events route
+func (s *systemEvents) ReloadConfig(config config.Config) error {
+	s.Lock()
+	defer s.Unlock()
+	s.tid = tenant.Id{OrgId: config.GetString("ears.systemEvents.orgId"), AppId: config.GetString("ears.systemEvents.appId")}
+	s.routeId = config.GetString("ears.systemEvents.routeId")
+	return nil
+}
+
+func (s *systemEvents) route() (tenant.Id, string) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.tid, s.routeId
+}
+
+// enqueue queues a system event unless it is a problem of the system events route itself or the queue is full
+func (s *systemEvents) enqueue(e sysevent.Event) {
+	tid, routeId := s.route()
+	if routeId == "" || (e.RouteId == routeId && e.Tenant.Equal(tid)) {
+		return
+	}
+	select {
+	case s.queue <- e:
+	default:
+		s.logger.Warn().Str("op", "systemEvents.enqueue").Str("type", e.Type).Msg("system events queue full, dropping system event")
+	}
+}
+
+func (s *systemEvents) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case e := <-s.queue:
+			s.send(context.Background(), e)
+		}
+	}
+}
+
+func (s *systemEvents) send(ctx context.Context, e sysevent.Event) {
+	tid, routeId := s.route()
+	if routeId == "" {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		s.logger.Error().Str("op", "systemEvents.send").Str("type", e.Type).Str("error", err.Error()).Msg("cannot marshal system event")
+		return
+	}
+	var payload interface{}
+	json.Unmarshal(body, &payload)
+	_, err = s.routingTableMgr.RouteEventAsync(ctx, tid, routeId, "", payload, func(err error) {
+		if err != nil {
+			s.logger.Error().Str("op", "systemEvents.send").Str("tenantId", tid.ToString()).Str("routeId", routeId).Str("error", err.Error()).Msg("system event nacked")
+		}
+	})
+	if err != nil {
+		s.logger.Error().Str("op", "systemEvents.send").Str("tenantId", tid.ToString()).Str("routeId", routeId).Str("error", err.Error()).Msg("cannot route system event")
+	}
+}
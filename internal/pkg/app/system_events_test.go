@@ -0,0 +1,72 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestSystemEventsEnqueue(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	s := &systemEvents{
+		logger: &logger,
+		queue:  make(chan sysevent.Event, 2),
+	}
+	v := viper.New()
+	s.ReloadConfig(v)
+	sysTid := tenant.Id{OrgId: "ears", AppId: "system"}
+	failure := sysevent.Event{Type: sysevent.TypeDeliveryFailure, Tenant: tenant.Id{OrgId: "myorg", AppId: "myapp"}, RouteId: "r100"}
+	s.enqueue(failure)
+	if len(s.queue) != 0 {
+		t.Fatalf("system event queued without a system events route")
+	}
+	v.Set("ears.systemEvents.orgId", sysTid.OrgId)
+	v.Set("ears.systemEvents.appId", sysTid.AppId)
+	v.Set("ears.systemEvents.routeId", "alerts")
+	s.ReloadConfig(v)
+	// problems of the system events route itself must not feed back into it
+	s.enqueue(sysevent.Event{Type: sysevent.TypeDeliveryFailure, Tenant: sysTid, RouteId: "alerts"})
+	if len(s.queue) != 0 {
+		t.Fatalf("problem of the system events route queued")
+	}
+	s.enqueue(failure)
+	s.enqueue(sysevent.Event{Type: sysevent.TypeRegistrationError, Tenant: sysTid, RouteId: "other"})
+	s.enqueue(failure)
+	if len(s.queue) != 2 {
+		t.Fatalf("expected 2 queued system events, got %d", len(s.queue))
+	}
+	if e := <-s.queue; e != failure {
+		t.Fatalf("unexpected system event %+v", e)
+	}
+}
+
+func TestSystemEventsEmit(t *testing.T) {
+	received := make(chan sysevent.Event, 1)
+	sysevent.SetHandler(func(e sysevent.Event) {
+		received <- e
+	})
+	defer sysevent.SetHandler(nil)
+	sysevent.Emit(sysevent.Event{Type: sysevent.TypePluginPanic, Error: "boom"})
+	e := <-received
+	if e.Type != sysevent.TypePluginPanic || e.Error != "boom" || e.Time == 0 {
+		t.Fatalf("unexpected system event %+v", e)
+	}
+}
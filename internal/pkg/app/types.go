@@ -90,6 +90,15 @@ func ItemResponse(item interface{}) Response {
 	}
 }
 
+func AcceptedResponse(item interface{}) Response {
+	return Response{
+		Status: &Status{
+			Code: http.StatusAccepted,
+		},
+		Item: item,
+	}
+}
+
 func ItemsResponse(item interface{}) Response {
 	return Response{
 		Status: &Status{
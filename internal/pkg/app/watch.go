@@ -0,0 +1,215 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	DEFAULT_WATCH_KEEPALIVE_SECS = 15
+	MAX_WATCH_TIMEOUT_SECS       = 86400
+	WATCH_BUFFER_SIZE            = 100
+)
+
+// watcher is a single subscriber to config change notifications
+type watcher struct {
+	query    audit.Query
+	changes  chan audit.Record
+	lost     chan struct{} // closed once a change had to be dropped because the watcher could not keep up
+	lostOnce sync.Once
+}
+
+// WatchHub fans out route, fragment and tenant config changes to all watchers. Changes made through the api of this
+// instance carry the item before and after the change, changes synced from other instances or made outside the api
+// only carry the id of the item.
+type WatchHub struct {
+	watchers map[*watcher]struct{}
+	sync.RWMutex
+}
+
+func NewWatchHub() *WatchHub {
+	return &WatchHub{
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// Subscribe registers a watcher for changes matching query. The second channel is closed once changes were dropped
+// for the watcher, it then has to resync. The returned function must be called to unsubscribe.
+func (h *WatchHub) Subscribe(query audit.Query) (<-chan audit.Record, <-chan struct{}, func()) {
+	w := &watcher{
+		query:   query,
+		changes: make(chan audit.Record, WATCH_BUFFER_SIZE),
+		lost:    make(chan struct{}),
+	}
+	h.Lock()
+	h.watchers[w] = struct{}{}
+	h.Unlock()
+	return w.changes, w.lost, func() {
+		h.Lock()
+		delete(h.watchers, w)
+		h.Unlock()
+	}
+}
+
+// Publish notifies all matching watchers of a change. Rather than slowing down the api, changes are dropped for
+// watchers that cannot keep up and those watchers are told to resync.
+func (h *WatchHub) Publish(record audit.Record) {
+	h.RLock()
+	defer h.RUnlock()
+	for w := range h.watchers {
+		if !w.query.Matches(record) {
+			continue
+		}
+		select {
+		case w.changes <- record:
+		default:
+			w.lostOnce.Do(func() {
+				close(w.lost)
+			})
+		}
+	}
+}
+
+// localSyncer returns a local syncer publishing changes of items of the given type that were synced from other instances
+// or made outside the api
+func (h *WatchHub) localSyncer(itemType string) *watchSyncer {
+	return &watchSyncer{
+		hub:      h,
+		itemType: itemType,
+	}
+}
+
+// watchSyncer publishes the changes of one item type it is notified of by the delta syncer or by storers
+type watchSyncer struct {
+	hub      *WatchHub
+	itemType string
+}
+
+func (s *watchSyncer) SyncItem(ctx context.Context, tid tenant.Id, itemId string, add bool) error {
+	action := audit.ActionUpdate
+	if !add {
+		action = audit.ActionDelete
+	}
+	if s.itemType == audit.ItemTypeTenant {
+		// tenant sync requests do not carry an item id
+		itemId = tid.Key()
+	}
+	s.hub.Publish(audit.Record{
+		Id:       uuid.New().String(),
+		Time:     time.Now().UnixNano() / int64(time.Millisecond),
+		Tenant:   tid,
+		ItemType: s.itemType,
+		ItemId:   itemId,
+		Action:   action,
+	})
+	return nil
+}
+
+// WatcherCount returns the number of currently subscribed watchers
+func (h *WatchHub) WatcherCount() int {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.watchers)
+}
+
+// watchHandler streams route, fragment and tenant config changes as server sent events until the optional timeout
+// expires or the client disconnects, comments are sent periodically to keep idle connections open. If changes had
+// to be dropped because the client could not keep up a resync event is sent and the stream is closed.
+func (a *APIManager) watchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+	query := audit.Query{
+		OrgId:    params.Get("orgId"),
+		AppId:    params.Get("appId"),
+		ItemType: params.Get("type"),
+		ItemId:   params.Get("id"),
+	}
+	if query.ItemType != "" && query.ItemType != audit.ItemTypeRoute && query.ItemType != audit.ItemTypeFragment && query.ItemType != audit.ItemTypeTenant {
+		log.Ctx(ctx).Error().Str("op", "watchHandler").Msg("bad type " + query.ItemType)
+		resp := ErrorResponse(&BadRequestError{"type must be one of route, fragment or tenant", nil})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	timeoutSecs := 0
+	if s := params.Get("timeout"); s != "" {
+		var err error
+		timeoutSecs, err = strconv.Atoi(s)
+		if err != nil || timeoutSecs < 0 || timeoutSecs > MAX_WATCH_TIMEOUT_SECS {
+			log.Ctx(ctx).Error().Str("op", "watchHandler").Msg("bad timeout " + s)
+			resp := ErrorResponse(&BadRequestError{"timeout must be between 0 and " + strconv.Itoa(MAX_WATCH_TIMEOUT_SECS) + " seconds", err})
+			resp.Respond(ctx, w, doYaml(r))
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Ctx(ctx).Error().Str("op", "watchHandler").Msg("streaming not supported")
+		resp := ErrorResponse(&InternalServerError{errors.New("streaming not supported")})
+		resp.Respond(ctx, w, doYaml(r))
+		return
+	}
+	changes, lost, unsubscribe := a.watchHub.Subscribe(query)
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	var timeout <-chan time.Time
+	if timeoutSecs > 0 {
+		timer := time.NewTimer(time.Duration(timeoutSecs) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	keepAlive := time.NewTicker(DEFAULT_WATCH_KEEPALIVE_SECS * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			w.Write([]byte("event: timeout\ndata: {}\n\n"))
+			flusher.Flush()
+			return
+		case <-lost:
+			w.Write([]byte("event: resync\ndata: {}\n\n"))
+			flusher.Flush()
+			return
+		case <-keepAlive.C:
+			w.Write([]byte(": keepalive\n\n"))
+			flusher.Flush()
+		case record := <-changes:
+			buf, err := json.Marshal(record)
+			if err != nil {
+				log.Ctx(ctx).Error().Str("op", "watchHandler").Msg(err.Error())
+				continue
+			}
+			w.Write([]byte("id: " + record.Id + "\nevent: " + record.ItemType + "\ndata: " + string(buf) + "\n\n"))
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestWatchHubResync(t *testing.T) {
+	hub := NewWatchHub()
+	changes, lost, unsubscribe := hub.Subscribe(audit.Query{})
+	defer unsubscribe()
+	for i := 0; i < WATCH_BUFFER_SIZE; i++ {
+		hub.Publish(audit.Record{ItemType: audit.ItemTypeRoute})
+	}
+	select {
+	case <-lost:
+		t.Fatalf("watcher told to resync before its buffer is full")
+	default:
+	}
+	hub.Publish(audit.Record{ItemType: audit.ItemTypeRoute})
+	hub.Publish(audit.Record{ItemType: audit.ItemTypeRoute})
+	select {
+	case <-lost:
+	default:
+		t.Fatalf("watcher not told to resync after changes were dropped")
+	}
+	if len(changes) != WATCH_BUFFER_SIZE {
+		t.Fatalf("unexpected number of buffered changes %d", len(changes))
+	}
+}
+
+func TestWatchHubSyncedChanges(t *testing.T) {
+	hub := NewWatchHub()
+	changes, _, unsubscribe := hub.Subscribe(audit.Query{OrgId: "myorg"})
+	defer unsubscribe()
+	ctx := context.Background()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	hub.localSyncer(audit.ItemTypeRoute).SyncItem(ctx, tid, "r100", false)
+	hub.localSyncer(audit.ItemTypeTenant).SyncItem(ctx, tid, "ignored", true)
+	hub.localSyncer(audit.ItemTypeRoute).SyncItem(ctx, tenant.Id{OrgId: "otherorg", AppId: "myapp"}, "r100", true)
+	if len(changes) != 2 {
+		t.Fatalf("unexpected number of changes %d", len(changes))
+	}
+	record := <-changes
+	if record.ItemType != audit.ItemTypeRoute || record.ItemId != "r100" || record.Action != audit.ActionDelete || record.Id == "" {
+		t.Fatalf("unexpected route change %+v", record)
+	}
+	record = <-changes
+	if record.ItemType != audit.ItemTypeTenant || record.ItemId != tid.Key() || record.Action != audit.ActionUpdate {
+		t.Fatalf("unexpected tenant change %+v", record)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/ears/internal/pkg/config"
+)
+
+// webhookMapping maps requests to the global events endpoint to a tenant route, a mapping matches requests whose
+// path starts with pathPrefix or carrying header with headerValue, a mapping without either matches all requests
+type webhookMapping struct {
+	pathPrefix  string
+	header      string
+	headerValue string
+	orgId       string
+	appId       string
+	routeId     string
+}
+
+// parseWebhookMappings parses a comma separated list of mappings of the form <match>=<orgId>/<appId>/<routeId>
+// where match is either a path prefix starting with / or a header of the form <name>:<value>
+func parseWebhookMappings(mappings string) ([]webhookMapping, error) {
+	result := make([]webhookMapping, 0)
+	for _, m := range strings.Split(mappings, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		idx := strings.LastIndex(m, "=")
+		if idx < 0 {
+			return nil, errors.New("webhook mapping " + m + " missing target")
+		}
+		match, target := strings.TrimSpace(m[:idx]), strings.TrimSpace(m[idx+1:])
+		ids := strings.Split(target, "/")
+		if len(ids) != 3 || ids[0] == "" || ids[1] == "" || ids[2] == "" {
+			return nil, errors.New("webhook mapping " + m + " target must be of the form orgId/appId/routeId")
+		}
+		mapping := webhookMapping{orgId: ids[0], appId: ids[1], routeId: ids[2]}
+		if strings.HasPrefix(match, "/") {
+			mapping.pathPrefix = match
+		} else {
+			hdr := strings.SplitN(match, ":", 2)
+			if len(hdr) != 2 || strings.TrimSpace(hdr[0]) == "" {
+				return nil, errors.New("webhook mapping " + m + " must match a path prefix or a header")
+			}
+			mapping.header = strings.TrimSpace(hdr[0])
+			mapping.headerValue = strings.TrimSpace(hdr[1])
+		}
+		result = append(result, mapping)
+	}
+	return result, nil
+}
+
+// webhookMappingsFromConfig reads the configured mappings followed by the single global webhook if there is one
+func webhookMappingsFromConfig(config config.Config) ([]webhookMapping, error) {
+	mappings, err := parseWebhookMappings(config.GetString("ears.api.webhook.mappings"))
+	if err != nil {
+		return nil, err
+	}
+	// single global webhook is supported for backward compatibility and catches requests no mapping matches
+	if config.GetString("ears.api.webhook.org") != "" && config.GetString("ears.api.webhook.app") != "" && config.GetString("ears.api.webhook.routeId") != "" {
+		mappings = append(mappings, webhookMapping{
+			orgId:   config.GetString("ears.api.webhook.org"),
+			appId:   config.GetString("ears.api.webhook.app"),
+			routeId: config.GetString("ears.api.webhook.routeId"),
+		})
+	}
+	return mappings, nil
+}
+
+func (m *webhookMapping) matches(r *http.Request) bool {
+	if m.pathPrefix != "" && !strings.HasPrefix(r.URL.Path, m.pathPrefix) {
+		return false
+	}
+	if m.header != "" {
+		values, ok := r.Header[http.CanonicalHeaderKey(m.header)]
+		if !ok {
+			return false
+		}
+		if m.headerValue == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == m.headerValue {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// findWebhookMapping returns the first mapping matching the request or nil if there is none
+func (a *APIManager) findWebhookMapping(r *http.Request) *webhookMapping {
+	a.webhookLock.RLock()
+	mappings := a.webhookMappings
+	a.webhookLock.RUnlock()
+	for idx := range mappings {
+		if mappings[idx].matches(r) {
+			return &mappings[idx]
+		}
+	}
+	return nil
+}
+
+// ReloadConfig replaces the webhook mappings, requests in flight keep using the mappings they started with
+func (a *APIManager) ReloadConfig(config config.Config) error {
+	mappings, err := webhookMappingsFromConfig(config)
+	if err != nil {
+		return err
+	}
+	a.webhookLock.Lock()
+	a.webhookMappings = mappings
+	a.webhookLock.Unlock()
+	return nil
+}
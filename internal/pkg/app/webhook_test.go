@@ -0,0 +1,63 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookMappings(t *testing.T) {
+	mappings, err := parseWebhookMappings("/ears/v1/events/github=myorg/myapp/githubRoute, X-Source:stripe=myorg/myapp/stripeRoute,X-Partner:=otherorg/otherapp/partnerRoute")
+	if err != nil {
+		t.Fatalf("cannot parse webhook mappings: %s", err.Error())
+	}
+	api := &APIManager{webhookMappings: append(mappings, webhookMapping{orgId: "myorg", appId: "myapp", routeId: "defaultRoute"})}
+	testCases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		routeId string
+	}{
+		{name: "path", path: "/ears/v1/events/github/push", routeId: "githubRoute"},
+		{name: "header", path: "/ears/v1/events", headers: map[string]string{"x-source": "stripe"}, routeId: "stripeRoute"},
+		{name: "headerPresent", path: "/ears/v1/events", headers: map[string]string{"X-Partner": "acme"}, routeId: "partnerRoute"},
+		{name: "headerMismatch", path: "/ears/v1/events", headers: map[string]string{"X-Source": "paypal"}, routeId: "defaultRoute"},
+		{name: "default", path: "/eel/v1/events", routeId: "defaultRoute"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			mapping := api.findWebhookMapping(r)
+			if mapping == nil || mapping.routeId != tc.routeId {
+				t.Fatalf("expected route %s but got mapping %+v", tc.routeId, mapping)
+			}
+		})
+	}
+	api.webhookMappings = mappings
+	if api.findWebhookMapping(httptest.NewRequest(http.MethodPost, "/ears/v1/events", nil)) != nil {
+		t.Fatalf("unexpected mapping match")
+	}
+	for _, bad := range []string{"/ears/v1/events/github", "/ears/v1/events/github=myorg/myapp", "github=myorg/myapp/githubRoute", ":stripe=myorg/myapp/stripeRoute"} {
+		_, err = parseWebhookMappings(bad)
+		if err == nil {
+			t.Fatalf("expected error for webhook mapping %s", bad)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package appsecret
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSecretsManagerPrefix is prepended to the names of secrets looked up in AWS Secrets Manager
+	DefaultSecretsManagerPrefix = "ears/"
+	// DefaultParameterStorePrefix is prepended to the names of parameters looked up in SSM Parameter Store
+	DefaultParameterStorePrefix = "/ears/"
+	// DefaultRefreshFrequency is the interval in which cached secrets are fetched again
+	DefaultRefreshFrequency = 5 * time.Minute
+)
+
+// errSecretNotFound is returned by fetch functions for secrets that do not exist
+var errSecretNotFound = errors.New("secret not found")
+
+// fetchFn fetches the current value of a secret by its name
+type fetchFn func(name string) (string, error)
+
+// AwsVault provides secrets from AWS Secrets Manager or SSM Parameter Store. Secrets are cached after their first
+// lookup, including secrets that do not exist, and refreshed periodically to pick up rotated or added secrets.
+// A secret reference secret://myorg.myapp.kafka.caCert is looked up by the name <prefix>myorg/myapp/kafka/caCert
+type AwsVault struct {
+	sync.RWMutex
+	fetch   fetchFn
+	prefix  string
+	refresh time.Duration
+	cache   map[string]string
+	logger  *zerolog.Logger
+	done    chan struct{}
+}
+
+// NewSecretsManagerVault returns a vault providing secrets from AWS Secrets Manager
+func NewSecretsManagerVault(client secretsmanageriface.SecretsManagerAPI, prefix string, refresh time.Duration, logger *zerolog.Logger) *AwsVault {
+	return newAwsVault(func(name string) (string, error) {
+		out, err := client.GetSecretValue(&secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(name),
+		})
+		if err != nil {
+			var awsErr awserr.Error
+			if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+				return "", errSecretNotFound
+			}
+			return "", err
+		}
+		if out.SecretString != nil {
+			return *out.SecretString, nil
+		}
+		return string(out.SecretBinary), nil
+	}, prefix, refresh, logger)
+}
+
+// NewParameterStoreVault returns a vault providing secrets from SSM Parameter Store, secure strings are decrypted
+func NewParameterStoreVault(client ssmiface.SSMAPI, prefix string, refresh time.Duration, logger *zerolog.Logger) *AwsVault {
+	return newAwsVault(func(name string) (string, error) {
+		out, err := client.GetParameter(&ssm.GetParameterInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			var awsErr awserr.Error
+			if errors.As(err, &awsErr) && awsErr.Code() == ssm.ErrCodeParameterNotFound {
+				return "", errSecretNotFound
+			}
+			return "", err
+		}
+		if out.Parameter == nil || out.Parameter.Value == nil {
+			return "", errSecretNotFound
+		}
+		return *out.Parameter.Value, nil
+	}, prefix, refresh, logger)
+}
+
+func newAwsVault(fetch fetchFn, prefix string, refresh time.Duration, logger *zerolog.Logger) *AwsVault {
+	if refresh <= 0 {
+		refresh = DefaultRefreshFrequency
+	}
+	if logger == nil {
+		nop := zerolog.Nop()
+		logger = &nop
+	}
+	return &AwsVault{
+		fetch:   fetch,
+		prefix:  prefix,
+		refresh: refresh,
+		cache:   make(map[string]string),
+		logger:  logger,
+	}
+}
+
+func (v *AwsVault) Secret(key string) string {
+	if !strings.HasPrefix(key, secret.Protocol) {
+		return ""
+	}
+	name := v.prefix + strings.ReplaceAll(key[len(secret.Protocol):], ".", "/")
+	v.RLock()
+	val, ok := v.cache[name]
+	v.RUnlock()
+	if ok {
+		return val
+	}
+	val, err := v.fetch(name)
+	if err != nil && !errors.Is(err, errSecretNotFound) {
+		// not cached so that the next lookup tries again
+		v.logger.Error().Str("op", "AwsVault.Secret").Str("name", name).Str("error", err.Error()).Msg("cannot fetch secret")
+		return ""
+	}
+	v.Lock()
+	v.cache[name] = val
+	v.Unlock()
+	return val
+}
+
+// Start periodically refreshes the cached secrets until the vault is stopped
+func (v *AwsVault) Start() {
+	v.Lock()
+	if v.done != nil {
+		v.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	v.done = done
+	v.Unlock()
+	go func() {
+		ticker := time.NewTicker(v.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				v.Refresh()
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing the cached secrets
+func (v *AwsVault) Stop() {
+	v.Lock()
+	defer v.Unlock()
+	if v.done != nil {
+		close(v.done)
+		v.done = nil
+	}
+}
+
+// Refresh fetches all cached secrets again, secrets that cannot be fetched keep their cached value
+func (v *AwsVault) Refresh() {
+	v.RLock()
+	names := make([]string, 0, len(v.cache))
+	for name := range v.cache {
+		names = append(names, name)
+	}
+	v.RUnlock()
+	for _, name := range names {
+		val, err := v.fetch(name)
+		if err != nil && !errors.Is(err, errSecretNotFound) {
+			v.logger.Error().Str("op", "AwsVault.Refresh").Str("name", name).Str("error", err.Error()).Msg("cannot refresh secret")
+			continue
+		}
+		v.Lock()
+		v.cache[name] = val
+		v.Unlock()
+	}
+}
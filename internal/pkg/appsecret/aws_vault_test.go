@@ -0,0 +1,136 @@
+package appsecret_test
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/xmidt-org/ears/internal/pkg/appsecret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"sync"
+	"testing"
+	"time"
+)
+
+type secretsManagerMock struct {
+	secretsmanageriface.SecretsManagerAPI
+	sync.Mutex
+	secrets map[string]string
+	calls   map[string]int
+	err     error
+}
+
+func (m *secretsManagerMock) GetSecretValue(in *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.calls[*in.SecretId]++
+	if m.err != nil {
+		return nil, m.err
+	}
+	val, ok := m.secrets[*in.SecretId]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(val)}, nil
+}
+
+func (m *secretsManagerMock) set(name string, val string, err error) {
+	m.Lock()
+	defer m.Unlock()
+	m.secrets[name] = val
+	m.err = err
+}
+
+func (m *secretsManagerMock) callCount(name string) int {
+	m.Lock()
+	defer m.Unlock()
+	return m.calls[name]
+}
+
+type parameterStoreMock struct {
+	ssmiface.SSMAPI
+	parameters map[string]string
+}
+
+func (m *parameterStoreMock) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	if in.WithDecryption == nil || !*in.WithDecryption {
+		return nil, errors.New("expected decryption")
+	}
+	val, ok := m.parameters[*in.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: in.Name, Value: aws.String(val)}}, nil
+}
+
+func TestSecretsManagerVault(t *testing.T) {
+	client := &secretsManagerMock{
+		secrets: map[string]string{"ears/myorg/myapp/kafka/secret1": "abcd"},
+		calls:   make(map[string]int),
+	}
+	v := appsecret.NewSecretsManagerVault(client, appsecret.DefaultSecretsManagerPrefix, time.Hour, nil)
+	val := v.Secret("secret://myorg.myapp.kafka.secret1")
+	if val != "abcd" {
+		t.Fatalf("unexpected secret %s\n", val)
+	}
+	if v.Secret("myorg.myapp.kafka.secret1") != "" {
+		t.Fatalf("expected empty secret without protocol\n")
+	}
+
+	//cached secrets, including missing ones, are not fetched again
+	v.Secret("secret://myorg.myapp.kafka.secret1")
+	v.Secret("secret://myorg.myapp.kafka.secret2")
+	val = v.Secret("secret://myorg.myapp.kafka.secret2")
+	if val != "" {
+		t.Fatalf("expected empty secret, got %s\n", val)
+	}
+	if client.callCount("ears/myorg/myapp/kafka/secret1") != 1 || client.callCount("ears/myorg/myapp/kafka/secret2") != 1 {
+		t.Fatalf("unexpected fetches %+v\n", client.calls)
+	}
+
+	//refresh picks up rotated and added secrets
+	client.set("ears/myorg/myapp/kafka/secret1", "efgh", nil)
+	client.set("ears/myorg/myapp/kafka/secret2", "ijkl", nil)
+	v.Refresh()
+	if v.Secret("secret://myorg.myapp.kafka.secret1") != "efgh" || v.Secret("secret://myorg.myapp.kafka.secret2") != "ijkl" {
+		t.Fatalf("secrets not refreshed\n")
+	}
+
+	//failed refreshes keep the cached value, failed lookups are not cached
+	client.set("ears/myorg/myapp/kafka/secret1", "mnop", errors.New("throttled"))
+	v.Refresh()
+	if v.Secret("secret://myorg.myapp.kafka.secret1") != "efgh" {
+		t.Fatalf("cached secret lost on failed refresh\n")
+	}
+	v.Secret("secret://myorg.myapp.kafka.secret3")
+	client.set("ears/myorg/myapp/kafka/secret3", "qrst", nil)
+	if v.Secret("secret://myorg.myapp.kafka.secret3") != "qrst" {
+		t.Fatalf("failed lookup was cached\n")
+	}
+}
+
+func TestParameterStoreVault(t *testing.T) {
+	client := &parameterStoreMock{
+		parameters: map[string]string{
+			"/ears/myorg/myapp/kafka/secret1": "abcd",
+			"/ears/all/all/kafka/secret2":     "efgh",
+		},
+	}
+	v := appsecret.NewTenantConfigVault(tenant.Id{OrgId: "myorg", AppId: "myapp"},
+		appsecret.NewParameterStoreVault(client, appsecret.DefaultParameterStorePrefix, time.Hour, nil))
+	val := v.Secret("secret://kafka.secret1")
+	if val != "abcd" {
+		t.Fatalf("unexpected secret %s\n", val)
+	}
+	val = v.Secret("secret://kafka.secret2")
+	if val != "efgh" {
+		t.Fatalf("unexpected global secret %s\n", val)
+	}
+	val = v.Secret("secret://kafka.secret3")
+	if val != "" {
+		t.Fatalf("expected empty secret, got %s\n", val)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	DefaultMaxRecords = 10000
+)
+
+// InMemoryAuditStorer keeps the most recent audit records in a ring buffer
+type InMemoryAuditStorer struct {
+	records    []Record
+	next       int
+	full       bool
+	maxRecords int
+	sync.RWMutex
+}
+
+func NewInMemoryAuditStorer(maxRecords int) *InMemoryAuditStorer {
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+	return &InMemoryAuditStorer{
+		records:    make([]Record, maxRecords),
+		maxRecords: maxRecords,
+	}
+}
+
+func (s *InMemoryAuditStorer) AddRecord(ctx context.Context, record Record) error {
+	record = record.WithDefaults()
+	s.Lock()
+	defer s.Unlock()
+	s.records[s.next] = record
+	s.next = (s.next + 1) % s.maxRecords
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+func (s *InMemoryAuditStorer) GetRecords(ctx context.Context, query Query) ([]Record, error) {
+	s.RLock()
+	defer s.RUnlock()
+	count := s.next
+	if s.full {
+		count = s.maxRecords
+	}
+	records := make([]Record, 0)
+	for i := 1; i <= count; i++ {
+		record := s.records[(s.next-i+s.maxRecords)%s.maxRecords]
+		if !query.Matches(record) {
+			continue
+		}
+		records = append(records, record)
+		if query.Limit > 0 && len(records) >= query.Limit {
+			break
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestInMemoryAuditStorer(t *testing.T) {
+	ctx := context.Background()
+	s := audit.NewInMemoryAuditStorer(3)
+	for i := 0; i < 5; i++ {
+		err := s.AddRecord(ctx, audit.Record{
+			Time:     int64(i + 1),
+			Tenant:   tenant.Id{OrgId: "myorg", AppId: "myapp" + strconv.Itoa(i%2)},
+			ItemType: audit.ItemTypeRoute,
+			ItemId:   "r" + strconv.Itoa(i),
+			Action:   audit.ActionCreate,
+		})
+		if err != nil {
+			t.Fatalf("audit test failed: %s\n", err.Error())
+		}
+	}
+	records, err := s.GetRecords(ctx, audit.Query{})
+	if err != nil {
+		t.Fatalf("audit test failed: %s\n", err.Error())
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records but got %d\n", len(records))
+	}
+	if records[0].ItemId != "r4" || records[2].ItemId != "r2" {
+		t.Fatalf("unexpected record order %s %s\n", records[0].ItemId, records[2].ItemId)
+	}
+	if records[0].Id == "" {
+		t.Fatalf("missing record id\n")
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{AppId: "myapp0"})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records for myapp0 but got %d\n", len(records))
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{Since: 4})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records since 4 but got %d\n", len(records))
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{Limit: 1})
+	if len(records) != 1 || records[0].ItemId != "r4" {
+		t.Fatalf("expected most recent record only\n")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	ItemTypeRoute    = "route"
+	ItemTypeFragment = "fragment"
+	ItemTypeTenant   = "tenant"
+
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Record describes a single configuration change, before and after hold the
+// previous and the new version of the item so that the diff can be reconstructed
+type Record struct {
+	Id       string      `json:"id"`
+	Time     int64       `json:"time"` // unix time in milliseconds
+	Tenant   tenant.Id   `json:"tenant"`
+	ItemType string      `json:"itemType"`
+	ItemId   string      `json:"itemId"`
+	Action   string      `json:"action"`
+	Subject  string      `json:"subject,omitempty"` // jwt subject of the caller if known
+	SourceIp string      `json:"sourceIp,omitempty"`
+	Before   interface{} `json:"before,omitempty"`
+	After    interface{} `json:"after,omitempty"`
+}
+
+// Query selects audit records, empty fields match everything
+type Query struct {
+	OrgId    string
+	AppId    string
+	ItemType string
+	ItemId   string
+	Subject  string
+	Since    int64 // unix time in milliseconds
+	Until    int64 // unix time in milliseconds
+	Limit    int
+}
+
+type AuditStorer interface {
+	AddRecord(ctx context.Context, record Record) error
+	// GetRecords returns matching records, most recent first
+	GetRecords(ctx context.Context, query Query) ([]Record, error)
+}
+
+// WithDefaults returns the record with a new ID and the current time unless they are set already
+func (r Record) WithDefaults() Record {
+	if r.Id == "" {
+		r.Id = uuid.New().String()
+	}
+	if r.Time == 0 {
+		r.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	return r
+}
+
+func (q Query) Matches(record Record) bool {
+	if q.OrgId != "" && q.OrgId != record.Tenant.OrgId {
+		return false
+	}
+	if q.AppId != "" && q.AppId != record.Tenant.AppId {
+		return false
+	}
+	if q.ItemType != "" && q.ItemType != record.ItemType {
+		return false
+	}
+	if q.ItemId != "" && q.ItemId != record.ItemId {
+		return false
+	}
+	if q.Subject != "" && q.Subject != record.Subject {
+		return false
+	}
+	if q.Since > 0 && record.Time < q.Since {
+		return false
+	}
+	if q.Until > 0 && record.Time > q.Until {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func testAuditStorer(s audit.AuditStorer, t *testing.T) {
+	ctx := context.Background()
+	// an org of its own so that records left by earlier runs against the same storage do not match
+	orgId := "myorg" + uuid.New().String()
+	for i := 0; i < 5; i++ {
+		err := s.AddRecord(ctx, audit.Record{
+			Time:     int64(i + 1),
+			Tenant:   tenant.Id{OrgId: orgId, AppId: "myapp" + strconv.Itoa(i%2)},
+			ItemType: audit.ItemTypeRoute,
+			ItemId:   "r" + strconv.Itoa(i),
+			Action:   audit.ActionUpdate,
+			Subject:  "user" + strconv.Itoa(i%2),
+			Before:   map[string]interface{}{"id": "r" + strconv.Itoa(i)},
+		})
+		if err != nil {
+			t.Fatalf("AddRecord error: %s\n", err.Error())
+		}
+	}
+	records, err := s.GetRecords(ctx, audit.Query{OrgId: orgId})
+	if err != nil {
+		t.Fatalf("GetRecords error: %s\n", err.Error())
+	}
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records but got %d\n", len(records))
+	}
+	if records[0].ItemId != "r4" || records[4].ItemId != "r0" {
+		t.Fatalf("unexpected record order %s %s\n", records[0].ItemId, records[4].ItemId)
+	}
+	if records[0].Id == "" || records[0].Before == nil {
+		t.Fatalf("incomplete record %+v\n", records[0])
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{OrgId: orgId, AppId: "myapp0"})
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records for myapp0 but got %d\n", len(records))
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{OrgId: orgId, Subject: "user1", ItemId: "r3"})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record for user1 and r3 but got %d\n", len(records))
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{OrgId: orgId, Since: 2, Until: 4})
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records from 2 until 4 but got %d\n", len(records))
+	}
+	records, _ = s.GetRecords(ctx, audit.Query{OrgId: orgId, Limit: 2})
+	if len(records) != 2 || records[0].ItemId != "r4" {
+		t.Fatalf("expected the 2 most recent records\n")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltAuditStorer keeps audit records keyed by time so that the most recent records are read first
+type BoltAuditStorer struct {
+	db   *bolt.DB
+	path string
+}
+
+func NewBoltAuditStorer(config Config) (*BoltAuditStorer, error) {
+	boltDb, path, err := open(config, "audit", AUDIT_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltAuditStorer{
+		db:   boltDb,
+		path: path,
+	}, nil
+}
+
+// Close releases the bolt file
+func (s *BoltAuditStorer) Close() error {
+	return release(s.path)
+}
+
+func (s *BoltAuditStorer) AddRecord(ctx context.Context, record audit.Record) error {
+	_, span := db.CreateSpan(ctx, "addAuditRecord", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(AUDIT_BUCKET))
+	defer span.End()
+	record = record.WithDefaults()
+	val, err := json.Marshal(record)
+	if err != nil {
+		return &audit.InternalStorageError{Wrapped: err}
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(AUDIT_BUCKET)).Put(auditKey(record), val)
+	})
+	if err != nil {
+		return &audit.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
+
+func (s *BoltAuditStorer) GetRecords(ctx context.Context, query audit.Query) ([]audit.Record, error) {
+	_, span := db.CreateSpan(ctx, "getAuditRecords", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(AUDIT_BUCKET))
+	defer span.End()
+	records := make([]audit.Record, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(AUDIT_BUCKET)).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var record audit.Record
+			err := json.Unmarshal(v, &record)
+			if err != nil {
+				return err
+			}
+			if query.Since > 0 && record.Time < query.Since {
+				break
+			}
+			if !query.Matches(record) {
+				continue
+			}
+			records = append(records, record)
+			if query.Limit > 0 && len(records) >= query.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &audit.InternalStorageError{Wrapped: err}
+	}
+	return records, nil
+}
+
+// auditKey orders records by time, the record ID keeps records of the same millisecond apart
+func auditKey(record audit.Record) []byte {
+	key := make([]byte, 8, 8+len(record.Id))
+	binary.BigEndian.PutUint64(key, uint64(record.Time))
+	return append(key, record.Id...)
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"encoding/base64"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DEFAULT_PATH = "ears.db"
+
+	ROUTE_BUCKET    = "routes"
+	TENANT_BUCKET   = "tenants"
+	FRAGMENT_BUCKET = "fragments"
+	AUDIT_BUCKET    = "audit"
+
+	openTimeout = 10 * time.Second
+)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// bolt locks its file exclusively, storers configured with the same path share a single handle
+var (
+	handles   = make(map[string]*handle)
+	handlesMu sync.Mutex
+)
+
+type handle struct {
+	db   *bolt.DB
+	refs int
+}
+
+// open opens (or creates) the file configured for the given storage, e.g. ears.storage.route.path, and makes
+// sure bucket exists
+func open(config Config, storage string, bucket string) (*bolt.DB, string, error) {
+	path := config.GetString("ears.storage." + storage + ".path")
+	if path == "" {
+		path = DEFAULT_PATH
+	}
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+	h, ok := handles[path]
+	if !ok {
+		db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+		if err != nil {
+			return nil, "", &BoltOpenError{path, err}
+		}
+		h = &handle{db: db}
+		handles[path] = h
+	}
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		if h.refs == 0 {
+			h.db.Close()
+			delete(handles, path)
+		}
+		return nil, "", &BoltOpenError{path, err}
+	}
+	h.refs++
+	return h.db, path, nil
+}
+
+// release closes the file once the last storer using it is closed
+func release(path string) error {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+	h, ok := handles[path]
+	if !ok {
+		return nil
+	}
+	h.refs--
+	if h.refs > 0 {
+		return nil
+	}
+	delete(handles, path)
+	return h.db.Close()
+}
+
+// tenantPrefix is the key prefix of all items of a tenant, ids are base64 encoded so that the prefix of one tenant
+// can never match items of another
+func tenantPrefix(orgId string, appId string) string {
+	return base64.StdEncoding.EncodeToString([]byte(orgId)) + "." + base64.StdEncoding.EncodeToString([]byte(appId)) + "."
+}
+
+func itemKey(orgId string, appId string, itemId string) []byte {
+	return []byte(tenantPrefix(orgId, appId) + base64.StdEncoding.EncodeToString([]byte(itemId)))
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	bolt "go.etcd.io/bbolt"
+)
+
+type BoltFragmentStorer struct {
+	db   *bolt.DB
+	path string
+}
+
+func NewBoltFragmentStorer(config Config) (*BoltFragmentStorer, error) {
+	boltDb, path, err := open(config, "fragment", FRAGMENT_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltFragmentStorer{
+		db:   boltDb,
+		path: path,
+	}, nil
+}
+
+// Close releases the bolt file
+func (s *BoltFragmentStorer) Close() error {
+	return release(s.path)
+}
+
+func (s *BoltFragmentStorer) GetAllFragments(ctx context.Context) ([]route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getFragments", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return s.getFragments(nil)
+}
+
+func (s *BoltFragmentStorer) GetFragment(ctx context.Context, tid tenant.Id, id string) (route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getFragment", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	f := route.PluginConfig{}
+	var val []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		val = copyBytes(tx.Bucket([]byte(FRAGMENT_BUCKET)).Get(itemKey(tid.OrgId, tid.AppId, id)))
+		return nil
+	})
+	if val == nil {
+		return f, &fragments.FragmentNotFoundError{TenantId: tid, FragmentName: id}
+	}
+	err := json.Unmarshal(val, &f)
+	return f, err
+}
+
+func (s *BoltFragmentStorer) GetAllTenantFragments(ctx context.Context, tid tenant.Id) ([]route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getTenantFragments", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return s.getFragments([]byte(tenantPrefix(tid.OrgId, tid.AppId)))
+}
+
+func (s *BoltFragmentStorer) getFragments(prefix []byte) ([]route.PluginConfig, error) {
+	fragments := make([]route.PluginConfig, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(FRAGMENT_BUCKET)).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var f route.PluginConfig
+			err := json.Unmarshal(v, &f)
+			if err != nil {
+				return err
+			}
+			fragments = append(fragments, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fragments, nil
+}
+
+func (s *BoltFragmentStorer) SetFragment(ctx context.Context, tid tenant.Id, f route.PluginConfig) error {
+	_, span := db.CreateSpan(ctx, "storeFragment", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return s.setFragments(tid, []route.PluginConfig{f})
+}
+
+func (s *BoltFragmentStorer) SetFragments(ctx context.Context, tid tenant.Id, fragments []route.PluginConfig) error {
+	_, span := db.CreateSpan(ctx, "storeFragments", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return s.setFragments(tid, fragments)
+}
+
+func (s *BoltFragmentStorer) setFragments(tid tenant.Id, fragments []route.PluginConfig) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(FRAGMENT_BUCKET))
+		for _, f := range fragments {
+			val, err := json.Marshal(f)
+			if err != nil {
+				return err
+			}
+			err = b.Put(itemKey(tid.OrgId, tid.AppId, f.FragmentName), val)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltFragmentStorer) DeleteFragment(ctx context.Context, tid tenant.Id, id string) error {
+	_, span := db.CreateSpan(ctx, "deleteFragment", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return deleteItems(s.db, FRAGMENT_BUCKET, tid, []string{id})
+}
+
+func (s *BoltFragmentStorer) DeleteFragments(ctx context.Context, tid tenant.Id, ids []string) error {
+	_, span := db.CreateSpan(ctx, "deleteFragments", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(FRAGMENT_BUCKET))
+	defer span.End()
+	return deleteItems(s.db, FRAGMENT_BUCKET, tid, ids)
+}
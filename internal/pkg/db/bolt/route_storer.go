@@ -0,0 +1,182 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltRouteStorer keeps routes as json in a bucket of an embedded bolt file so that a standalone instance keeps its
+// routes across restarts without any external dependencies
+type BoltRouteStorer struct {
+	db   *bolt.DB
+	path string
+}
+
+func NewBoltRouteStorer(config Config) (*BoltRouteStorer, error) {
+	boltDb, path, err := open(config, "route", ROUTE_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltRouteStorer{
+		db:   boltDb,
+		path: path,
+	}, nil
+}
+
+// Close releases the bolt file
+func (s *BoltRouteStorer) Close() error {
+	return release(s.path)
+}
+
+func (s *BoltRouteStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getRoute", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	r := route.Config{}
+	var val []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		val = copyBytes(tx.Bucket([]byte(ROUTE_BUCKET)).Get(itemKey(tid.OrgId, tid.AppId, id)))
+		return nil
+	})
+	if val == nil {
+		return r, &route.RouteNotFoundError{TenantId: tid, RouteId: id}
+	}
+	err := json.Unmarshal(val, &r)
+	return r, err
+}
+
+func (s *BoltRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getRoutes", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	return s.getRoutes(nil)
+}
+
+func (s *BoltRouteStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getTenantRoutes", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	return s.getRoutes([]byte(tenantPrefix(tid.OrgId, tid.AppId)))
+}
+
+func (s *BoltRouteStorer) getRoutes(prefix []byte) ([]route.Config, error) {
+	routes := make([]route.Config, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(ROUTE_BUCKET)).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var r route.Config
+			err := json.Unmarshal(v, &r)
+			if err != nil {
+				return err
+			}
+			routes = append(routes, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+func (s *BoltRouteStorer) SetRoute(ctx context.Context, r route.Config) error {
+	_, span := db.CreateSpan(ctx, "storeRoute", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	return s.setRoutes([]route.Config{r})
+}
+
+func (s *BoltRouteStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	_, span := db.CreateSpan(ctx, "storeRoutes", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	if routes == nil {
+		return fmt.Errorf("no routes to store in bolt")
+	}
+	return s.setRoutes(routes)
+}
+
+// setRoutes stores all routes in a single transaction keeping the create times of existing routes
+func (s *BoltRouteStorer) setRoutes(routes []route.Config) error {
+	now := time.Now().Unix()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(ROUTE_BUCKET))
+		for _, r := range routes {
+			if r.Id == "" {
+				return fmt.Errorf("no route to store in bolt")
+			}
+			key := itemKey(r.TenantId.OrgId, r.TenantId.AppId, r.Id)
+			r.Modified = now
+			r.Created = now
+			if old := b.Get(key); old != nil {
+				var oldRoute route.Config
+				if json.Unmarshal(old, &oldRoute) == nil {
+					r.Created = oldRoute.Created
+				}
+			}
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			err = b.Put(key, val)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltRouteStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id string) error {
+	_, span := db.CreateSpan(ctx, "deleteRoute", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	if id == "" {
+		return fmt.Errorf("no route to delete in bolt")
+	}
+	return deleteItems(s.db, ROUTE_BUCKET, tid, []string{id})
+}
+
+func (s *BoltRouteStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []string) error {
+	_, span := db.CreateSpan(ctx, "deleteRoutes", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(ROUTE_BUCKET))
+	defer span.End()
+	return deleteItems(s.db, ROUTE_BUCKET, tid, ids)
+}
+
+func deleteItems(boltDb *bolt.DB, bucket string, tid tenant.Id, ids []string) error {
+	return boltDb.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		for _, id := range ids {
+			err := b.Delete(itemKey(tid.OrgId, tid.AppId, id))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyBytes copies a value read from bolt which is only valid during the transaction
+func copyBytes(val []byte) []byte {
+	if val == nil {
+		return nil
+	}
+	return append([]byte{}, val...)
+}
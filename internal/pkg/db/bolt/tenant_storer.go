@@ -0,0 +1,116 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	bolt "go.etcd.io/bbolt"
+)
+
+type BoltTenantStorer struct {
+	db   *bolt.DB
+	path string
+}
+
+func NewBoltTenantStorer(config Config) (*BoltTenantStorer, error) {
+	boltDb, path, err := open(config, "tenant", TENANT_BUCKET)
+	if err != nil {
+		return nil, err
+	}
+	return &BoltTenantStorer{
+		db:   boltDb,
+		path: path,
+	}, nil
+}
+
+// Close releases the bolt file
+func (s *BoltTenantStorer) Close() error {
+	return release(s.path)
+}
+
+func (s *BoltTenantStorer) GetAllConfigs(ctx context.Context) ([]tenant.Config, error) {
+	_, span := db.CreateSpan(ctx, "getAllTenantConfigs", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(TENANT_BUCKET))
+	defer span.End()
+	configs := make([]tenant.Config, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(TENANT_BUCKET)).ForEach(func(k, v []byte) error {
+			var config tenant.Config
+			err := json.Unmarshal(v, &config)
+			if err != nil {
+				return err
+			}
+			configs = append(configs, config)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return configs, nil
+}
+
+func (s *BoltTenantStorer) GetConfig(ctx context.Context, id tenant.Id) (*tenant.Config, error) {
+	_, span := db.CreateSpan(ctx, "getTenantConfig", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(TENANT_BUCKET))
+	defer span.End()
+	var val []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		val = copyBytes(tx.Bucket([]byte(TENANT_BUCKET)).Get([]byte(id.Key())))
+		return nil
+	})
+	if val == nil {
+		return nil, &tenant.TenantNotFoundError{Tenant: id}
+	}
+	var config tenant.Config
+	err := json.Unmarshal(val, &config)
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return &config, nil
+}
+
+func (s *BoltTenantStorer) SetConfig(ctx context.Context, config tenant.Config) error {
+	_, span := db.CreateSpan(ctx, "setTenantConfig", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(TENANT_BUCKET))
+	defer span.End()
+	config.Modified = time.Now().Unix()
+	val, err := json.Marshal(config)
+	if err != nil {
+		return &tenant.BadConfigError{}
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(TENANT_BUCKET)).Put([]byte(config.Tenant.Key()), val)
+	})
+	if err != nil {
+		return &tenant.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
+
+func (s *BoltTenantStorer) DeleteConfig(ctx context.Context, id tenant.Id) error {
+	_, span := db.CreateSpan(ctx, "deleteTenantConfig", rtsemconv.DBSystemBolt, rtsemconv.DBTable.String(TENANT_BUCKET))
+	defer span.End()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(TENANT_BUCKET)).Delete([]byte(id.Key()))
+	})
+	if err != nil {
+		return &tenant.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
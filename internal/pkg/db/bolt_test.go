@@ -0,0 +1,130 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !integration
+// +build !integration
+
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db/bolt"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func boltConfig(t *testing.T) config.Config {
+	v := viper.New()
+	path := filepath.Join(t.TempDir(), "ears.db")
+	v.Set("ears.storage.route.path", path)
+	v.Set("ears.storage.tenant.path", path)
+	v.Set("ears.storage.fragment.path", path)
+	v.Set("ears.storage.audit.path", path)
+	return v
+}
+
+func TestBoltRouteStorer(t *testing.T) {
+	s, err := bolt.NewBoltRouteStorer(boltConfig(t))
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer s.Close()
+	testRouteStorer(s, t)
+}
+
+func TestBoltTenantStorer(t *testing.T) {
+	s, err := bolt.NewBoltTenantStorer(boltConfig(t))
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer s.Close()
+	testTenantStorer(s, t)
+}
+
+func TestBoltFragmentStorer(t *testing.T) {
+	s, err := bolt.NewBoltFragmentStorer(boltConfig(t))
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer s.Close()
+	testFragmentStorer(s, t)
+}
+
+func TestBoltAuditStorer(t *testing.T) {
+	s, err := bolt.NewBoltAuditStorer(boltConfig(t))
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer s.Close()
+	testAuditStorer(s, t)
+}
+
+func TestBoltPersistence(t *testing.T) {
+	ctx := context.Background()
+	cfg := boltConfig(t)
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	routeStorer, err := bolt.NewBoltRouteStorer(cfg)
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	// storers configured with the same file share it
+	tenantStorer, err := bolt.NewBoltTenantStorer(cfg)
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	err = routeStorer.SetRoute(ctx, route.Config{Id: "r1", TenantId: tid})
+	if err != nil {
+		t.Fatalf("Fail to set route %s\n", err.Error())
+	}
+	err = tenantStorer.SetConfig(ctx, tenant.Config{Tenant: tid, Quota: tenant.Quota{EventsPerSec: 10}})
+	if err != nil {
+		t.Fatalf("Fail to set config %s\n", err.Error())
+	}
+	routeStorer.Close()
+	tenantStorer.Close()
+	// reopen
+	routeStorer, err = bolt.NewBoltRouteStorer(cfg)
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer routeStorer.Close()
+	tenantStorer, err = bolt.NewBoltTenantStorer(cfg)
+	if err != nil {
+		t.Fatalf("Error instantiate bolt %s\n", err.Error())
+	}
+	defer tenantStorer.Close()
+	r, err := routeStorer.GetRoute(ctx, tid, "r1")
+	if err != nil {
+		t.Fatalf("Fail to get route %s\n", err.Error())
+	}
+	if r.Created == 0 || r.Created != r.Modified {
+		t.Fatalf("unexpected timestamps %d %d\n", r.Created, r.Modified)
+	}
+	routes, err := routeStorer.GetAllTenantRoutes(ctx, tenant.Id{OrgId: "myOrg", AppId: "myApp2"})
+	if err != nil || len(routes) != 0 {
+		t.Fatalf("unexpected routes of other tenant %v %v\n", routes, err)
+	}
+	config, err := tenantStorer.GetConfig(ctx, tid)
+	if err != nil {
+		t.Fatalf("Fail to get config %s\n", err.Error())
+	}
+	if config.Quota.EventsPerSec != 10 {
+		t.Fatalf("unexpected quota %d\n", config.Quota.EventsPerSec)
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/xmidt-org/ears/pkg/route"
+)
+
+const (
+	// VALUE_PREFIX marks encrypted values, followed by the wrapped data key and the ciphertext separated by a colon
+	VALUE_PREFIX = "enc:v1:"
+
+	// DEFAULT_FIELDS are the names of plugin config fields that typically hold credentials or queue urls
+	DEFAULT_FIELDS = "password,accessKey,awsAccessKeyId,awsSecretAccessKey,oauthClientSecret,botToken,privateKey,hmacSecret,queueUrl,deadLetterQueueUrl,url"
+
+	// maxCachedKeys bounds the cache of unwrapped data keys, the cache is cleared when it is full
+	maxCachedKeys = 10000
+
+	// secretPrefix marks references to secrets resolved by the secret vault which are not secrets themselves
+	secretPrefix = "secret://"
+)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// Encrypter encrypts the values of sensitive fields in plugin configs with envelope encryption. The values of a
+// route or fragment are encrypted with a random data key which is stored with each value wrapped by the key
+// wrapper, so the key wrapper, e.g. KMS, is called once per write and once per distinct data key on reads.
+// Fields are matched by name, case insensitive, at any depth of the plugin config.
+type Encrypter struct {
+	wrapper KeyWrapper
+	fields  map[string]struct{}
+	keys    map[string][]byte
+	sync.Mutex
+}
+
+// NewEncrypter returns the encrypter configured as ears.storage.encryption, or nil if no encryption is configured
+func NewEncrypter(config Config) (*Encrypter, error) {
+	var wrapper KeyWrapper
+	var err error
+	encryptionType := config.GetString("ears.storage.encryption.type")
+	switch encryptionType {
+	case "":
+		return nil, nil
+	case "local":
+		key := config.GetString("ears.storage.encryption.key")
+		if key == "" {
+			return nil, &MissingConfigError{"ears.storage.encryption.key"}
+		}
+		wrapper, err = NewLocalKeyWrapper(key)
+	case "kms":
+		keyId := config.GetString("ears.storage.encryption.keyId")
+		if keyId == "" {
+			return nil, &MissingConfigError{"ears.storage.encryption.keyId"}
+		}
+		wrapper, err = NewKmsKeyWrapper(config.GetString("ears.storage.encryption.region"), keyId)
+	default:
+		return nil, &UnsupportedEncryptionError{encryptionType}
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := config.GetString("ears.storage.encryption.fields")
+	if fields == "" {
+		fields = DEFAULT_FIELDS
+	}
+	return NewEncrypterWithWrapper(wrapper, strings.Split(fields, ",")), nil
+}
+
+func NewEncrypterWithWrapper(wrapper KeyWrapper, fields []string) *Encrypter {
+	e := &Encrypter{
+		wrapper: wrapper,
+		fields:  make(map[string]struct{}),
+		keys:    make(map[string][]byte),
+	}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			e.fields[strings.ToLower(field)] = struct{}{}
+		}
+	}
+	return e
+}
+
+// dataKey is created lazily so that plugin configs without sensitive fields do not cost a call to the wrapper
+type dataKey struct {
+	key     []byte
+	wrapped string
+}
+
+func (e *Encrypter) EncryptRoute(ctx context.Context, r route.Config) (route.Config, error) {
+	dk := &dataKey{}
+	err := forEachPluginConfig(&r, func(pc *route.PluginConfig) error {
+		return e.encryptPluginConfig(ctx, pc, dk)
+	})
+	if err != nil {
+		return r, &EncryptionError{err}
+	}
+	return r, nil
+}
+
+func (e *Encrypter) DecryptRoute(ctx context.Context, r route.Config) (route.Config, error) {
+	err := forEachPluginConfig(&r, func(pc *route.PluginConfig) error {
+		return e.decryptPluginConfig(ctx, pc)
+	})
+	if err != nil {
+		return r, &DecryptionError{err}
+	}
+	return r, nil
+}
+
+func (e *Encrypter) EncryptFragment(ctx context.Context, fragment route.PluginConfig) (route.PluginConfig, error) {
+	err := e.encryptPluginConfig(ctx, &fragment, &dataKey{})
+	if err != nil {
+		return fragment, &EncryptionError{err}
+	}
+	return fragment, nil
+}
+
+func (e *Encrypter) DecryptFragment(ctx context.Context, fragment route.PluginConfig) (route.PluginConfig, error) {
+	err := e.decryptPluginConfig(ctx, &fragment)
+	if err != nil {
+		return fragment, &DecryptionError{err}
+	}
+	return fragment, nil
+}
+
+// forEachPluginConfig calls f for all plugin configs of a route, the route is copied so that the caller's
+// plugin configs are not modified
+func forEachPluginConfig(r *route.Config, f func(pc *route.PluginConfig) error) error {
+	err := f(&r.Receiver)
+	if err != nil {
+		return err
+	}
+	err = f(&r.Sender)
+	if err != nil {
+		return err
+	}
+	if r.FilterChain != nil {
+		filterChain := make([]route.PluginConfig, len(r.FilterChain))
+		copy(filterChain, r.FilterChain)
+		r.FilterChain = filterChain
+		for idx := range r.FilterChain {
+			err = f(&r.FilterChain[idx])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if r.DeadLetter != nil {
+		deadLetter := *r.DeadLetter
+		r.DeadLetter = &deadLetter
+		err = f(r.DeadLetter)
+		if err != nil {
+			return err
+		}
+	}
+	if r.Branches != nil {
+		branches := make(map[string]route.PluginConfig, len(r.Branches))
+		for name, branch := range r.Branches {
+			err = f(&branch)
+			if err != nil {
+				return err
+			}
+			branches[name] = branch
+		}
+		r.Branches = branches
+	}
+	return nil
+}
+
+func (e *Encrypter) encryptPluginConfig(ctx context.Context, pc *route.PluginConfig, dk *dataKey) error {
+	if pc.Config == nil {
+		return nil
+	}
+	// work on a generic copy of the config, which also leaves the caller's config untouched
+	var config interface{}
+	buf, err := json.Marshal(pc.Config)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(buf, &config)
+	if err != nil {
+		return err
+	}
+	config, err = e.walk(config, false, func(val interface{}) (interface{}, error) {
+		return e.encryptValue(ctx, val, dk)
+	})
+	if err != nil {
+		return err
+	}
+	pc.Config = config
+	return nil
+}
+
+func (e *Encrypter) decryptPluginConfig(ctx context.Context, pc *route.PluginConfig) error {
+	if pc.Config == nil {
+		return nil
+	}
+	config, err := e.walk(pc.Config, true, func(val interface{}) (interface{}, error) {
+		return e.decryptValue(ctx, val)
+	})
+	if err != nil {
+		return err
+	}
+	pc.Config = config
+	return nil
+}
+
+// walk replaces the values of sensitive fields with the result of f, or all encrypted string values when
+// decrypting so that values stay readable if the configured fields change
+func (e *Encrypter) walk(val interface{}, decrypt bool, f func(val interface{}) (interface{}, error)) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			var err error
+			_, sensitive := e.fields[strings.ToLower(key)]
+			if sensitive && !decrypt && item != nil {
+				result[key], err = f(item)
+			} else {
+				result[key], err = e.walk(item, decrypt, f)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			var err error
+			result[idx], err = e.walk(item, decrypt, f)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case string:
+		if decrypt && strings.HasPrefix(v, VALUE_PREFIX) {
+			return f(v)
+		}
+	}
+	return val, nil
+}
+
+func (e *Encrypter) encryptValue(ctx context.Context, val interface{}, dk *dataKey) (interface{}, error) {
+	if s, ok := val.(string); ok && (strings.HasPrefix(s, VALUE_PREFIX) || strings.HasPrefix(s, secretPrefix) || s == "") {
+		return val, nil
+	}
+	if dk.key == nil {
+		key := make([]byte, 32)
+		_, err := io.ReadFull(rand.Reader, key)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := e.wrapper.WrapKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		dk.key = key
+		dk.wrapped = base64.StdEncoding.EncodeToString(wrapped)
+	}
+	plaintext, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(dk.key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return VALUE_PREFIX + dk.wrapped + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (e *Encrypter) decryptValue(ctx context.Context, val interface{}) (interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(val.(string), VALUE_PREFIX), ":")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed encrypted value")
+	}
+	key, err := e.unwrapKey(ctx, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(plaintext, &result)
+	return result, err
+}
+
+func (e *Encrypter) unwrapKey(ctx context.Context, wrapped string) ([]byte, error) {
+	hash := sha256.Sum256([]byte(wrapped))
+	cacheKey := string(hash[:])
+	e.Lock()
+	key, ok := e.keys[cacheKey]
+	e.Unlock()
+	if ok {
+		return key, nil
+	}
+	buf, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	key, err = e.wrapper.UnwrapKey(ctx, buf)
+	if err != nil {
+		return nil, err
+	}
+	e.Lock()
+	if len(e.keys) >= maxCachedKeys {
+		e.keys = make(map[string][]byte)
+	}
+	e.keys[cacheKey] = key
+	e.Unlock()
+	return key, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+type MissingConfigError struct {
+	configName string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "MissingConfigError (configName=" + e.configName + ")"
+}
+
+type UnsupportedEncryptionError struct {
+	encryptionType string
+}
+
+func (e *UnsupportedEncryptionError) Error() string {
+	return "UnsupportedEncryptionError (type=" + e.encryptionType + ")"
+}
+
+type InvalidKeyError struct {
+	message string
+}
+
+func (e *InvalidKeyError) Error() string {
+	return "InvalidKeyError: " + e.message
+}
+
+type EncryptionError struct {
+	Source error
+}
+
+func (e *EncryptionError) Error() string {
+	return "EncryptionError: " + e.Source.Error()
+}
+
+func (e *EncryptionError) Unwrap() error {
+	return e.Source
+}
+
+type DecryptionError struct {
+	Source error
+}
+
+func (e *DecryptionError) Error() string {
+	return "DecryptionError: " + e.Source.Error()
+}
+
+func (e *DecryptionError) Unwrap() error {
+	return e.Source
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"context"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// EncryptedFragmentStorer encrypts sensitive plugin config fields of fragments before they are written to the
+// wrapped fragment storer and decrypts them transparently when fragments are loaded
+type EncryptedFragmentStorer struct {
+	fragments.FragmentStorer
+	encrypter *Encrypter
+}
+
+func NewEncryptedFragmentStorer(fragmentStorer fragments.FragmentStorer, encrypter *Encrypter) *EncryptedFragmentStorer {
+	return &EncryptedFragmentStorer{
+		FragmentStorer: fragmentStorer,
+		encrypter:      encrypter,
+	}
+}
+
+func (s *EncryptedFragmentStorer) GetAllFragments(ctx context.Context) ([]route.PluginConfig, error) {
+	fragments, err := s.FragmentStorer.GetAllFragments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptFragments(ctx, fragments)
+}
+
+func (s *EncryptedFragmentStorer) GetFragment(ctx context.Context, tid tenant.Id, name string) (route.PluginConfig, error) {
+	fragment, err := s.FragmentStorer.GetFragment(ctx, tid, name)
+	if err != nil {
+		return fragment, err
+	}
+	return s.encrypter.DecryptFragment(ctx, fragment)
+}
+
+func (s *EncryptedFragmentStorer) GetAllTenantFragments(ctx context.Context, tid tenant.Id) ([]route.PluginConfig, error) {
+	fragments, err := s.FragmentStorer.GetAllTenantFragments(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptFragments(ctx, fragments)
+}
+
+func (s *EncryptedFragmentStorer) SetFragment(ctx context.Context, tid tenant.Id, fragment route.PluginConfig) error {
+	fragment, err := s.encrypter.EncryptFragment(ctx, fragment)
+	if err != nil {
+		return err
+	}
+	return s.FragmentStorer.SetFragment(ctx, tid, fragment)
+}
+
+func (s *EncryptedFragmentStorer) SetFragments(ctx context.Context, tid tenant.Id, fragments []route.PluginConfig) error {
+	encrypted := make([]route.PluginConfig, len(fragments))
+	for idx, fragment := range fragments {
+		var err error
+		encrypted[idx], err = s.encrypter.EncryptFragment(ctx, fragment)
+		if err != nil {
+			return err
+		}
+	}
+	return s.FragmentStorer.SetFragments(ctx, tid, encrypted)
+}
+
+func (s *EncryptedFragmentStorer) ReadOnly() bool {
+	return db.IsReadOnly(s.FragmentStorer)
+}
+
+func (s *EncryptedFragmentStorer) decryptFragments(ctx context.Context, fragments []route.PluginConfig) ([]route.PluginConfig, error) {
+	decrypted := make([]route.PluginConfig, len(fragments))
+	for idx, fragment := range fragments {
+		var err error
+		decrypted[idx], err = s.encrypter.DecryptFragment(ctx, fragment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// KeyWrapper encrypts and decrypts the data keys that encrypt config values with a key encryption key that never
+// leaves the wrapper, e.g. a KMS key
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyWrapper wraps data keys with a locally configured AES-256 key
+type LocalKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper takes a base64 encoded 32 byte key
+func NewLocalKeyWrapper(key string) (*LocalKeyWrapper, error) {
+	buf, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(buf) != 32 {
+		return nil, &InvalidKeyError{"key must be 32 bytes base64 encoded"}
+	}
+	aead, err := newAead(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyWrapper{aead: aead}, nil
+}
+
+func (w *LocalKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	return seal(w.aead, key)
+}
+
+func (w *LocalKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return open(w.aead, wrapped)
+}
+
+// KmsKeyWrapper wraps data keys with an AWS KMS key
+type KmsKeyWrapper struct {
+	svc   *kms.KMS
+	keyId string
+}
+
+func NewKmsKeyWrapper(region string, keyId string) (*KmsKeyWrapper, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &KmsKeyWrapper{
+		svc:   kms.New(sess),
+		keyId: keyId,
+	}, nil
+}
+
+func (w *KmsKeyWrapper) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := w.svc.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(w.keyId),
+		Plaintext: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (w *KmsKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := w.svc.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(w.keyId),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce which is prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, &InvalidKeyError{"ciphertext too short"}
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], nil)
+}
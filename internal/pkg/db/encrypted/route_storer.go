@@ -0,0 +1,104 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypted
+
+import (
+	"context"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// EncryptedRouteStorer encrypts sensitive plugin config fields of routes before they are written to the
+// wrapped route storer and decrypts them transparently when routes are loaded
+type EncryptedRouteStorer struct {
+	route.RouteStorer
+	encrypter *Encrypter
+}
+
+func NewEncryptedRouteStorer(routeStorer route.RouteStorer, encrypter *Encrypter) *EncryptedRouteStorer {
+	return &EncryptedRouteStorer{
+		RouteStorer: routeStorer,
+		encrypter:   encrypter,
+	}
+}
+
+func (s *EncryptedRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	routes, err := s.RouteStorer.GetAllRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptRoutes(ctx, routes)
+}
+
+func (s *EncryptedRouteStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	r, err := s.RouteStorer.GetRoute(ctx, tid, id)
+	if err != nil {
+		return r, err
+	}
+	return s.encrypter.DecryptRoute(ctx, r)
+}
+
+func (s *EncryptedRouteStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	routes, err := s.RouteStorer.GetAllTenantRoutes(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return s.decryptRoutes(ctx, routes)
+}
+
+func (s *EncryptedRouteStorer) SetRoute(ctx context.Context, r route.Config) error {
+	r, err := s.encrypter.EncryptRoute(ctx, r)
+	if err != nil {
+		return err
+	}
+	return s.RouteStorer.SetRoute(ctx, r)
+}
+
+func (s *EncryptedRouteStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	encrypted := make([]route.Config, len(routes))
+	for idx, r := range routes {
+		var err error
+		encrypted[idx], err = s.encrypter.EncryptRoute(ctx, r)
+		if err != nil {
+			return err
+		}
+	}
+	return s.RouteStorer.SetRoutes(ctx, encrypted)
+}
+
+func (s *EncryptedRouteStorer) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	if watcher, ok := s.RouteStorer.(syncer.ItemWatcher); ok {
+		watcher.RegisterLocalSyncer(itemType, localSyncer)
+	}
+}
+
+func (s *EncryptedRouteStorer) ReadOnly() bool {
+	return db.IsReadOnly(s.RouteStorer)
+}
+
+func (s *EncryptedRouteStorer) decryptRoutes(ctx context.Context, routes []route.Config) ([]route.Config, error) {
+	decrypted := make([]route.Config, len(routes))
+	for idx, r := range routes {
+		var err error
+		decrypted[idx], err = s.encrypter.DecryptRoute(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !integration
+// +build !integration
+
+package db_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/db/encrypted"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func newTestEncrypter(t *testing.T, key string, fields string) *encrypted.Encrypter {
+	v := viper.New()
+	v.Set("ears.storage.encryption.type", "local")
+	v.Set("ears.storage.encryption.key", base64.StdEncoding.EncodeToString([]byte(key)))
+	v.Set("ears.storage.encryption.fields", fields)
+	encrypter, err := encrypted.NewEncrypter(v)
+	if err != nil {
+		t.Fatalf("NewEncrypter error: %s\n", err.Error())
+	}
+	return encrypter
+}
+
+func TestEncryptedRouteStorer(t *testing.T) {
+	encrypter := newTestEncrypter(t, "0123456789abcdef0123456789abcdef", "key")
+	s := encrypted.NewEncryptedRouteStorer(db.NewInMemoryRouteStorer(nil), encrypter)
+	testRouteStorer(s, t)
+}
+
+func TestEncryptedFragmentStorer(t *testing.T) {
+	encrypter := newTestEncrypter(t, "0123456789abcdef0123456789abcdef", "key")
+	s := encrypted.NewEncryptedFragmentStorer(db.NewInMemoryFragmentStorer(nil), encrypter)
+	testFragmentStorer(s, t)
+}
+
+func TestEncryptedRouteStorerAtRest(t *testing.T) {
+	ctx := context.Background()
+	inner := db.NewInMemoryRouteStorer(nil)
+	encrypter := newTestEncrypter(t, "0123456789abcdef0123456789abcdef", "")
+	s := encrypted.NewEncryptedRouteStorer(inner, encrypter)
+
+	var r route.Config
+	err := json.Unmarshal([]byte(`{
+		"id": "r1",
+		"tenant": {"orgId": "myOrg", "appId": "myApp"},
+		"receiver": {"plugin": "sqs", "config": {"queueUrl": "https://sqs.us-west-2.amazonaws.com/123/in", "maxNumberOfMessages": 10}},
+		"sender": {"plugin": "http", "config": {"url": "https://example.com", "headers": {"password": "secret"}}},
+		"filterChain": [{"plugin": "decode", "config": {"hmacSecret": {"k": [1, 2]}}}],
+		"deliveryMode": "fire_and_forget"
+	}`), &r)
+	if err != nil {
+		t.Fatalf("Unmarshal error: %s\n", err.Error())
+	}
+	expected, _ := json.Marshal(r.Receiver.Config)
+	err = s.SetRoute(ctx, r)
+	if err != nil {
+		t.Fatalf("SetRoute error: %s\n", err.Error())
+	}
+
+	//sensitive values are encrypted in the wrapped storer
+	raw, err := inner.GetRoute(ctx, r.TenantId, r.Id)
+	if err != nil {
+		t.Fatalf("GetRoute error: %s\n", err.Error())
+	}
+	buf, _ := json.Marshal(raw)
+	for _, plaintext := range []string{"sqs.us-west-2", "example.com", "secret"} {
+		if strings.Contains(string(buf), plaintext) {
+			t.Fatalf("Plaintext %s stored unencrypted: %s\n", plaintext, string(buf))
+		}
+	}
+	if !strings.Contains(string(buf), `"maxNumberOfMessages":10`) {
+		t.Fatalf("Non sensitive field unexpectedly changed: %s\n", string(buf))
+	}
+	if !strings.HasPrefix(raw.Receiver.Config.(map[string]interface{})["queueUrl"].(string), encrypted.VALUE_PREFIX) {
+		t.Fatalf("Unexpected stored queueUrl: %s\n", string(buf))
+	}
+
+	//and decrypted transparently on load
+	loaded, err := s.GetRoute(ctx, r.TenantId, r.Id)
+	if err != nil {
+		t.Fatalf("GetRoute error: %s\n", err.Error())
+	}
+	actual, _ := json.Marshal(loaded.Receiver.Config)
+	if string(actual) != string(expected) {
+		t.Fatalf("Unexpected receiver config %s, expected %s\n", string(actual), string(expected))
+	}
+	if loaded.Hash(ctx) != r.Hash(ctx) {
+		t.Fatalf("Route hash changed by encryption\n")
+	}
+	routes, err := s.GetAllTenantRoutes(ctx, r.TenantId)
+	if err != nil || len(routes) != 1 {
+		t.Fatalf("GetAllTenantRoutes unexpected result %v %v\n", routes, err)
+	}
+	if routes[0].FilterChain[0].Config.(map[string]interface{})["hmacSecret"].(map[string]interface{})["k"] == nil {
+		t.Fatalf("Unexpected filter config %v\n", routes[0].FilterChain[0].Config)
+	}
+
+	//the caller's route is not modified
+	if r.Receiver.Config.(map[string]interface{})["queueUrl"] != "https://sqs.us-west-2.amazonaws.com/123/in" {
+		t.Fatalf("SetRoute modified the route config\n")
+	}
+
+	//fail closed with the wrong key
+	wrongKey := encrypted.NewEncryptedRouteStorer(inner, newTestEncrypter(t, "fedcba9876543210fedcba9876543210", ""))
+	_, err = wrongKey.GetRoute(ctx, r.TenantId, r.Id)
+	var decryptionErr *encrypted.DecryptionError
+	if !errors.As(err, &decryptionErr) {
+		t.Fatalf("Expected DecryptionError, got %v\n", err)
+	}
+	_, err = s.GetRoute(ctx, tenant.Id{OrgId: "myOrg", AppId: "myApp"}, "does_not_exist")
+	var routeNotFound *route.RouteNotFoundError
+	if !errors.As(err, &routeNotFound) {
+		t.Fatalf("Expected RouteNotFoundError, got %v\n", err)
+	}
+}
+
+func TestNewEncrypterConfig(t *testing.T) {
+	v := viper.New()
+	encrypter, err := encrypted.NewEncrypter(v)
+	if encrypter != nil || err != nil {
+		t.Fatalf("Expected no encrypter without config, got %v %v\n", encrypter, err)
+	}
+	v.Set("ears.storage.encryption.type", "local")
+	_, err = encrypted.NewEncrypter(v)
+	var missingConfig *encrypted.MissingConfigError
+	if !errors.As(err, &missingConfig) {
+		t.Fatalf("Expected MissingConfigError, got %v\n", err)
+	}
+	v.Set("ears.storage.encryption.key", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = encrypted.NewEncrypter(v)
+	var invalidKey *encrypted.InvalidKeyError
+	if !errors.As(err, &invalidKey) {
+		t.Fatalf("Expected InvalidKeyError, got %v\n", err)
+	}
+	v.Set("ears.storage.encryption.type", "rot13")
+	_, err = encrypted.NewEncrypter(v)
+	var unsupported *encrypted.UnsupportedEncryptionError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedEncryptionError, got %v\n", err)
+	}
+}
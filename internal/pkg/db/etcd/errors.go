@@ -0,0 +1,55 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+type MissingConfigError struct {
+	configName string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "MissingConfigError (configName=" + e.configName + ")"
+}
+
+type EtcdConnectError struct {
+	Source error
+}
+
+func (e *EtcdConnectError) Error() string {
+	return "EtcdConnectError: " + e.Source.Error()
+}
+
+func (e *EtcdConnectError) Unwrap() error {
+	return e.Source
+}
+
+type EtcdRequestError struct {
+	Source error
+}
+
+func (e *EtcdRequestError) Error() string {
+	return "EtcdRequestError: " + e.Source.Error()
+}
+
+func (e *EtcdRequestError) Unwrap() error {
+	return e.Source
+}
+
+type ConcurrentModificationError struct {
+	Key string
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return "ConcurrentModificationError (key=" + e.Key + ")"
+}
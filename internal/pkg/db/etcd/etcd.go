@@ -0,0 +1,72 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	DEFAULT_PREFIX = "/ears"
+
+	// MAX_WRITE_ATTEMPTS is the number of times a write is retried when the item is modified concurrently
+	MAX_WRITE_ATTEMPTS = 5
+
+	// MAX_TXN_ITEMS keeps transactions below the default limit of 128 operations per transaction of etcd
+	MAX_TXN_ITEMS = 100
+
+	dialTimeout = 10 * time.Second
+)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// open connects to the comma separated etcd endpoints configured for the given storage, e.g.
+// ears.storage.route.endpoint, and returns the client and the key prefix of the storage
+func open(config Config, storage string) (*clientv3.Client, string, error) {
+	endpoint := config.GetString("ears.storage." + storage + ".endpoint")
+	if endpoint == "" {
+		return nil, "", &MissingConfigError{"ears.storage." + storage + ".endpoint"}
+	}
+	prefix := config.GetString("ears.storage." + storage + ".prefix")
+	if prefix == "" {
+		prefix = DEFAULT_PREFIX
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(endpoint, ","),
+		Username:    config.GetString("ears.storage." + storage + ".username"),
+		Password:    config.GetString("ears.storage." + storage + ".password"),
+		DialTimeout: dialTimeout,
+	})
+	if err != nil {
+		return nil, "", &EtcdConnectError{err}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	// the client connects lazily, a read makes sure the cluster is reachable and the credentials are valid
+	_, err = client.Get(ctx, prefix, clientv3.WithCountOnly())
+	if err != nil {
+		client.Close()
+		return nil, "", &EtcdConnectError{err}
+	}
+	return client, strings.TrimSuffix(prefix, "/") + "/" + storage + "s/", nil
+}
@@ -0,0 +1,302 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdRouteStorer keeps routes as json values under <prefix>/routes/ in etcd. It watches the prefix and notifies
+// registered local syncers of every route added, updated or deleted by any instance so that the routing tables of
+// all instances are synchronized without polling or a separate pub/sub channel.
+type EtcdRouteStorer struct {
+	sync.RWMutex
+	client       *clientv3.Client
+	prefix       string
+	logger       *zerolog.Logger
+	localSyncers []syncer.LocalSyncer
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+func NewEtcdRouteStorer(config Config, logger *zerolog.Logger) (*EtcdRouteStorer, error) {
+	client, prefix, err := open(config, "route")
+	if err != nil {
+		return nil, err
+	}
+	s := &EtcdRouteStorer{
+		client: client,
+		prefix: prefix,
+		logger: logger,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	resp, err := client.Get(s.ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		s.Close()
+		return nil, &EtcdConnectError{err}
+	}
+	go s.watch(resp.Header.Revision)
+	return s, nil
+}
+
+// Close stops watching and closes the client
+func (s *EtcdRouteStorer) Close() error {
+	s.cancel()
+	return s.client.Close()
+}
+
+func (s *EtcdRouteStorer) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	if itemType != syncer.ITEM_TYPE_ROUTE {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.localSyncers = append(s.localSyncers, localSyncer)
+}
+
+func (s *EtcdRouteStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoute", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	r := route.Config{}
+	resp, err := s.client.Get(ctx, s.prefix+tid.KeyWithRoute(id))
+	if err != nil {
+		return r, &EtcdRequestError{err}
+	}
+	if len(resp.Kvs) == 0 {
+		return r, &route.RouteNotFoundError{TenantId: tid, RouteId: id}
+	}
+	err = json.Unmarshal(resp.Kvs[0].Value, &r)
+	return r, err
+}
+
+func (s *EtcdRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoutes", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	return s.getRoutes(ctx, s.prefix)
+}
+
+func (s *EtcdRouteStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getTenantRoutes", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	// base64 never contains the delimiter so that the prefix of a tenant does not match routes of other tenants
+	return s.getRoutes(ctx, s.prefix+tid.KeyWithRoute(""))
+}
+
+func (s *EtcdRouteStorer) getRoutes(ctx context.Context, prefix string) ([]route.Config, error) {
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, &EtcdRequestError{err}
+	}
+	routes := make([]route.Config, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var r route.Config
+		err = json.Unmarshal(kv.Value, &r)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func (s *EtcdRouteStorer) SetRoute(ctx context.Context, r route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoute", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	if r.Id == "" {
+		return fmt.Errorf("no route to store in etcd")
+	}
+	return s.setRoutes(ctx, []route.Config{r})
+}
+
+func (s *EtcdRouteStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoutes", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	if routes == nil {
+		return fmt.Errorf("no routes to store in etcd")
+	}
+	for _, r := range routes {
+		if r.Id == "" {
+			return fmt.Errorf("no route to store in etcd")
+		}
+	}
+	for start := 0; start < len(routes); start += MAX_TXN_ITEMS {
+		end := start + MAX_TXN_ITEMS
+		if end > len(routes) {
+			end = len(routes)
+		}
+		err := s.setRoutes(ctx, routes[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRoutes stores routes in a single transaction keeping the create times of existing routes, the transaction
+// only succeeds if none of the routes was modified since it was read and is retried otherwise
+func (s *EtcdRouteStorer) setRoutes(ctx context.Context, routes []route.Config) error {
+	keys := make([]string, len(routes))
+	gets := make([]clientv3.Op, len(routes))
+	for i, r := range routes {
+		keys[i] = s.prefix + r.TenantId.KeyWithRoute(r.Id)
+		gets[i] = clientv3.OpGet(keys[i])
+	}
+	for attempt := 0; attempt < MAX_WRITE_ATTEMPTS; attempt++ {
+		resp, err := s.client.Txn(ctx).Then(gets...).Commit()
+		if err != nil {
+			return &EtcdRequestError{err}
+		}
+		now := time.Now().Unix()
+		cmps := make([]clientv3.Cmp, len(routes))
+		puts := make([]clientv3.Op, len(routes))
+		for i, r := range routes {
+			r.Created = now
+			r.Modified = now
+			var modRevision int64
+			if kvs := resp.Responses[i].GetResponseRange().Kvs; len(kvs) > 0 {
+				modRevision = kvs[0].ModRevision
+				var old route.Config
+				if json.Unmarshal(kvs[0].Value, &old) == nil {
+					r.Created = old.Created
+				}
+			}
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			// the mod revision of a missing key is 0 so that concurrent creates are detected as well
+			cmps[i] = clientv3.Compare(clientv3.ModRevision(keys[i]), "=", modRevision)
+			puts[i] = clientv3.OpPut(keys[i], string(val))
+		}
+		txnResp, err := s.client.Txn(ctx).If(cmps...).Then(puts...).Commit()
+		if err != nil {
+			return &EtcdRequestError{err}
+		}
+		if txnResp.Succeeded {
+			return nil
+		}
+	}
+	return &ConcurrentModificationError{keys[0]}
+}
+
+func (s *EtcdRouteStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoute", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	if id == "" {
+		return fmt.Errorf("no route to delete in etcd")
+	}
+	_, err := s.client.Delete(ctx, s.prefix+tid.KeyWithRoute(id))
+	if err != nil {
+		return &EtcdRequestError{err}
+	}
+	return nil
+}
+
+func (s *EtcdRouteStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoutes", rtsemconv.DBSystemEtcd, rtsemconv.DBTable.String(s.prefix))
+	defer span.End()
+	for start := 0; start < len(ids); start += MAX_TXN_ITEMS {
+		end := start + MAX_TXN_ITEMS
+		if end > len(ids) {
+			end = len(ids)
+		}
+		deletes := make([]clientv3.Op, 0, end-start)
+		for _, id := range ids[start:end] {
+			deletes = append(deletes, clientv3.OpDelete(s.prefix+tid.KeyWithRoute(id)))
+		}
+		_, err := s.client.Txn(ctx).Then(deletes...).Commit()
+		if err != nil {
+			return &EtcdRequestError{err}
+		}
+	}
+	return nil
+}
+
+// watch notifies local syncers of all route changes after the given revision. If the watch fails it is resumed
+// from the last revision seen, if that revision has been compacted already the changes in between are lost and
+// are repaired by the periodic sync check of the routing table manager.
+func (s *EtcdRouteStorer) watch(rev int64) {
+	for s.ctx.Err() == nil {
+		watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(s.ctx))
+		for resp := range s.client.Watch(watchCtx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+			if resp.CompactRevision > 0 {
+				s.logger.Warn().Str("op", "EtcdRouteStorer.watch").Int64("revision", rev).Int64("compactRevision", resp.CompactRevision).Msg("route changes compacted before they were seen")
+				rev = resp.CompactRevision - 1
+				break
+			}
+			if err := resp.Err(); err != nil {
+				s.logger.Error().Str("op", "EtcdRouteStorer.watch").Msg(err.Error())
+				break
+			}
+			for _, event := range resp.Events {
+				rev = event.Kv.ModRevision
+				s.notify(string(event.Kv.Key), event.Type == clientv3.EventTypePut)
+			}
+		}
+		cancel()
+		select {
+		case <-s.ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *EtcdRouteStorer) notify(key string, add bool) {
+	tid, routeId, ok := s.parseKey(key)
+	if !ok {
+		s.logger.Error().Str("op", "EtcdRouteStorer.notify").Str("key", key).Msg("unexpected route key")
+		return
+	}
+	s.RLock()
+	localSyncers := append([]syncer.LocalSyncer{}, s.localSyncers...)
+	s.RUnlock()
+	for _, localSyncer := range localSyncers {
+		err := localSyncer.SyncItem(s.ctx, tid, routeId, add)
+		if err != nil {
+			s.logger.Error().Str("op", "EtcdRouteStorer.notify").Str("tenant", tid.ToString()).Str("routeId", routeId).Msg("failed to sync route: " + err.Error())
+		}
+	}
+}
+
+// parseKey reverses tenant.Id.KeyWithRoute
+func (s *EtcdRouteStorer) parseKey(key string) (tenant.Id, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, s.prefix), ".")
+	if len(parts) != 3 {
+		return tenant.Id{}, "", false
+	}
+	ids := make([]string, len(parts))
+	for i, part := range parts {
+		id, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return tenant.Id{}, "", false
+		}
+		ids[i] = string(id)
+	}
+	return tenant.Id{OrgId: ids[0], AppId: ids[1]}, ids[2], true
+}
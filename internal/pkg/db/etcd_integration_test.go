@@ -0,0 +1,77 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+// +build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db/etcd"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func etcdConfig() config.Config {
+	v := viper.New()
+	v.Set("ears.storage.route.endpoint", "127.0.0.1:2379")
+	v.Set("ears.storage.route.prefix", "/ears-test")
+	return v
+}
+
+func TestEtcdRouteStorer(t *testing.T) {
+	logger := zerolog.Nop()
+	s, err := etcd.NewEtcdRouteStorer(etcdConfig(), &logger)
+	if err != nil {
+		t.Fatalf("Error instantiate etcd %s\n", err.Error())
+	}
+	defer s.Close()
+	testRouteStorer(s, t)
+}
+
+func TestEtcdRouteStorerWatch(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	s1, err := etcd.NewEtcdRouteStorer(etcdConfig(), &logger)
+	if err != nil {
+		t.Fatalf("Error instantiate etcd %s\n", err.Error())
+	}
+	defer s1.Close()
+	// a second instance sees the changes made through the first one
+	s2, err := etcd.NewEtcdRouteStorer(etcdConfig(), &logger)
+	if err != nil {
+		t.Fatalf("Error instantiate etcd %s\n", err.Error())
+	}
+	defer s2.Close()
+	recorder := &syncRecorder{items: make(map[string]bool)}
+	s2.RegisterLocalSyncer(syncer.ITEM_TYPE_ROUTE, recorder)
+	tid := tenant.Id{OrgId: "myOrg", AppId: "my.App"}
+	err = s1.SetRoute(ctx, route.Config{Id: "watched", TenantId: tid})
+	if err != nil {
+		t.Fatalf("Fail to set route %s\n", err.Error())
+	}
+	recorder.waitFor(t, tid, "watched", true)
+	err = s1.DeleteRoute(ctx, tid, "watched")
+	if err != nil {
+		t.Fatalf("Fail to delete route %s\n", err.Error())
+	}
+	recorder.waitFor(t, tid, "watched", false)
+}
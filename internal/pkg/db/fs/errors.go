@@ -0,0 +1,36 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+type MissingConfigError struct {
+	key string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "MissingConfigError (key=" + e.key + ")"
+}
+
+type FileError struct {
+	Path   string
+	Source error
+}
+
+func (e *FileError) Error() string {
+	return "FileError (path=" + e.Path + "): " + e.Source.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Source
+}
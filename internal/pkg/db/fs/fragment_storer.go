@@ -0,0 +1,91 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func (s *FsStorer) GetFragment(ctx context.Context, tid tenant.Id, name string) (route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getFragment", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(FRAGMENTS_DIR_NAME))
+	defer span.End()
+	s.RLock()
+	defer s.RUnlock()
+	fragment, ok := s.fragments[tid.KeyWithFragment(name)]
+	if !ok {
+		return route.PluginConfig{}, &fragments.FragmentNotFoundError{TenantId: tid, FragmentName: name}
+	}
+	var c route.PluginConfig
+	err := clone(fragment, &c)
+	return c, err
+}
+
+func (s *FsStorer) GetAllFragments(ctx context.Context) ([]route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getFragments", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(FRAGMENTS_DIR_NAME))
+	defer span.End()
+	return s.getFragments("")
+}
+
+func (s *FsStorer) GetAllTenantFragments(ctx context.Context, tid tenant.Id) ([]route.PluginConfig, error) {
+	_, span := db.CreateSpan(ctx, "getTenantFragments", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(FRAGMENTS_DIR_NAME))
+	defer span.End()
+	return s.getFragments(tid.KeyWithFragment(""))
+}
+
+func (s *FsStorer) getFragments(prefix string) ([]route.PluginConfig, error) {
+	s.RLock()
+	defer s.RUnlock()
+	keys := make([]string, 0)
+	for key := range s.fragments {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	result := make([]route.PluginConfig, 0)
+	for _, key := range keys {
+		var c route.PluginConfig
+		err := clone(s.fragments[key], &c)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func (s *FsStorer) SetFragment(ctx context.Context, tid tenant.Id, fragment route.PluginConfig) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) SetFragments(ctx context.Context, tid tenant.Id, fragments []route.PluginConfig) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) DeleteFragment(ctx context.Context, tid tenant.Id, name string) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) DeleteFragments(ctx context.Context, tid tenant.Id, names []string) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
@@ -0,0 +1,393 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	yaml "github.com/goccy/go-yaml"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	TENANT_FILE_NAME   = "tenant"
+	ROUTES_DIR_NAME    = "routes"
+	FRAGMENTS_DIR_NAME = "fragments"
+
+	// changes are applied once no further change has been seen for this long, editors and git checkouts
+	// typically touch several files in a row
+	reloadDelay = 500 * time.Millisecond
+)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// FsStorer serves routes, fragments and tenant configs from yaml or json files in a directory tree of the form
+//
+//	<path>/<orgId>/<appId>/tenant.yaml
+//	<path>/<orgId>/<appId>/routes/<routeId>.yaml
+//	<path>/<orgId>/<appId>/fragments/<fragmentName>.yaml
+//
+// The tenant of an item is taken from its directory, route ids and fragment names default to the file name.
+// Fragment references in routes are inflated when the files are loaded. The storer is read only, changes are
+// made by changing the files which are watched and reloaded, registered local syncers are notified of all routes
+// and tenants that changed.
+type FsStorer struct {
+	sync.RWMutex
+	path         string
+	logger       *zerolog.Logger
+	routes       map[string]route.Config
+	fragments    map[string]route.PluginConfig
+	tenants      map[string]tenant.Config
+	localSyncers map[string][]syncer.LocalSyncer
+	watcher      *fsnotify.Watcher
+}
+
+// fs storers configured with the same path share a single instance so that the tree is loaded and watched once
+var (
+	storers   = make(map[string]*FsStorer)
+	storersMu sync.Mutex
+)
+
+// NewFsStorer returns the storer for the directory configured for the given storage, e.g. ears.storage.route.path
+func NewFsStorer(config Config, storage string, logger *zerolog.Logger) (*FsStorer, error) {
+	path := config.GetString("ears.storage." + storage + ".path")
+	if path == "" {
+		return nil, &MissingConfigError{"ears.storage." + storage + ".path"}
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	storersMu.Lock()
+	defer storersMu.Unlock()
+	if s, ok := storers[path]; ok {
+		return s, nil
+	}
+	s := &FsStorer{
+		path:         path,
+		logger:       logger,
+		localSyncers: make(map[string][]syncer.LocalSyncer),
+	}
+	s.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	err = s.load()
+	if err != nil {
+		s.watcher.Close()
+		return nil, err
+	}
+	go s.watch()
+	storers[path] = s
+	logger.Info().Str("path", path).Int("routes", len(s.routes)).Int("fragments", len(s.fragments)).Int("tenants", len(s.tenants)).Msg("loaded config files")
+	return s, nil
+}
+
+// Close stops watching the directory tree
+func (s *FsStorer) Close() error {
+	storersMu.Lock()
+	delete(storers, s.path)
+	storersMu.Unlock()
+	return s.watcher.Close()
+}
+
+func (s *FsStorer) ReadOnly() bool {
+	return true
+}
+
+func (s *FsStorer) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	s.Lock()
+	defer s.Unlock()
+	s.localSyncers[itemType] = append(s.localSyncers[itemType], localSyncer)
+}
+
+// load reads the whole tree and replaces the current items, if any file cannot be read or parsed the current items
+// are kept so that a broken file does not take down the routes of all tenants
+func (s *FsStorer) load() error {
+	routes := make(map[string]route.Config)
+	fragmentConfigs := make(map[string]route.PluginConfig)
+	tenants := make(map[string]tenant.Config)
+	routeFiles := make(map[string]tenant.Id)
+	err := filepath.Walk(s.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(s.path, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") && path != s.path {
+				return filepath.SkipDir
+			}
+			return s.watcher.Add(path)
+		}
+		if !isConfigFile(info.Name()) {
+			return nil
+		}
+		if len(parts) < 3 {
+			return nil
+		}
+		tid := tenant.Id{OrgId: parts[0], AppId: parts[1]}
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		switch {
+		case len(parts) == 3 && name == TENANT_FILE_NAME:
+			var config tenant.Config
+			err = readFile(path, &config)
+			if err != nil {
+				return err
+			}
+			config.Tenant = tid
+			config.Modified = info.ModTime().Unix()
+			tenants[tid.Key()] = config
+		case len(parts) == 4 && parts[2] == FRAGMENTS_DIR_NAME:
+			var fragment route.PluginConfig
+			err = readFile(path, &fragment)
+			if err != nil {
+				return err
+			}
+			if fragment.FragmentName == "" {
+				fragment.FragmentName = name
+			}
+			fragmentConfigs[tid.KeyWithFragment(fragment.FragmentName)] = fragment
+		case len(parts) == 4 && parts[2] == ROUTES_DIR_NAME:
+			routeFiles[path] = tid
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// routes are read last so that their fragment references can be inflated
+	for path, tid := range routeFiles {
+		var r route.Config
+		err = readFile(path, &r)
+		if err != nil {
+			return err
+		}
+		if r.Id == "" {
+			r.Id = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		r.TenantId = tid
+		err = inflateFragments(&r, fragmentConfigs)
+		if err != nil {
+			return &FileError{path, err}
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			r.Modified = info.ModTime().Unix()
+			r.Created = r.Modified
+		}
+		routes[tid.KeyWithRoute(r.Id)] = r
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.routes != nil {
+		// keep create times of routes that existed before
+		for key, r := range routes {
+			if old, ok := s.routes[key]; ok {
+				r.Created = old.Created
+				routes[key] = r
+			}
+		}
+	}
+	s.routes = routes
+	s.fragments = fragmentConfigs
+	s.tenants = tenants
+	return nil
+}
+
+// reload loads the tree again and notifies local syncers of changed, added and removed routes and tenants
+func (s *FsStorer) reload() {
+	ctx := context.Background()
+	s.RLock()
+	oldRoutes, oldTenants := s.routes, s.tenants
+	s.RUnlock()
+	err := s.load()
+	if err != nil {
+		s.logger.Error().Str("op", "FsStorer.reload").Str("path", s.path).Msg("keeping previous config: " + err.Error())
+		return
+	}
+	s.RLock()
+	newRoutes, newTenants := s.routes, s.tenants
+	routeSyncers := append([]syncer.LocalSyncer{}, s.localSyncers[syncer.ITEM_TYPE_ROUTE]...)
+	tenantSyncers := append([]syncer.LocalSyncer{}, s.localSyncers[syncer.ITEM_TYPE_TENANT]...)
+	s.RUnlock()
+	changes := 0
+	for key, r := range newRoutes {
+		if old, ok := oldRoutes[key]; !ok || old.Hash(ctx) != r.Hash(ctx) {
+			changes++
+			notify(ctx, s.logger, routeSyncers, r.TenantId, r.Id, true)
+		}
+	}
+	for key, r := range oldRoutes {
+		if _, ok := newRoutes[key]; !ok {
+			changes++
+			notify(ctx, s.logger, routeSyncers, r.TenantId, r.Id, false)
+		}
+	}
+	for key, t := range newTenants {
+		if old, ok := oldTenants[key]; !ok || !sameTenant(old, t) {
+			changes++
+			notify(ctx, s.logger, tenantSyncers, t.Tenant, "ignored", true)
+		}
+	}
+	for key, t := range oldTenants {
+		if _, ok := newTenants[key]; !ok {
+			changes++
+			notify(ctx, s.logger, tenantSyncers, t.Tenant, "ignored", false)
+		}
+	}
+	s.logger.Info().Str("op", "FsStorer.reload").Str("path", s.path).Int("changes", changes).Msg("reloaded config files")
+}
+
+// sameTenant compares tenant configs ignoring the modified time which changes whenever the file is touched
+func sameTenant(a, b tenant.Config) bool {
+	a.Modified, b.Modified = 0, 0
+	return reflect.DeepEqual(a, b)
+}
+
+func notify(ctx context.Context, logger *zerolog.Logger, localSyncers []syncer.LocalSyncer, tid tenant.Id, itemId string, add bool) {
+	for _, localSyncer := range localSyncers {
+		err := localSyncer.SyncItem(ctx, tid, itemId, add)
+		if err != nil {
+			logger.Error().Str("op", "FsStorer.reload").Str("tenant", tid.ToString()).Str("itemId", itemId).Msg("failed to sync item: " + err.Error())
+		}
+	}
+}
+
+func (s *FsStorer) watch() {
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-s.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Chmod == event.Op {
+				continue
+			}
+			timer = time.After(reloadDelay)
+		case err, ok := <-s.watcher.Errors:
+			if !ok {
+				return
+			}
+			s.logger.Error().Str("op", "FsStorer.watch").Str("path", s.path).Msg(err.Error())
+		case <-timer:
+			timer = nil
+			s.reload()
+		}
+	}
+}
+
+func isConfigFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// readFile parses a yaml or json file into v using the json field names of v
+func readFile(path string, v interface{}) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	buf, err = yaml.YAMLToJSON(buf)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	err = json.Unmarshal(buf, v)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	return nil
+}
+
+// inflateFragment mirrors the inflation done by the routing table manager for routes added through the api
+func inflateFragment(tid tenant.Id, pc route.PluginConfig, fragmentConfigs map[string]route.PluginConfig) (route.PluginConfig, error) {
+	if pc.FragmentName == "" {
+		return pc, nil
+	}
+	fragment, ok := fragmentConfigs[tid.KeyWithFragment(pc.FragmentName)]
+	if !ok {
+		return pc, &fragments.FragmentNotFoundError{TenantId: tid, FragmentName: pc.FragmentName}
+	}
+	if fragment.Plugin == "" {
+		return pc, errors.New("fragment " + pc.FragmentName + " has no plugin type")
+	}
+	if fragment.Config == nil {
+		return pc, errors.New("fragment " + pc.FragmentName + " has no config")
+	}
+	if pc.Plugin != "" && pc.Plugin != fragment.Plugin {
+		return pc, errors.New("fragment type mismatch " + pc.Plugin + " vs " + fragment.Plugin)
+	}
+	if pc.Name != "" {
+		fragment.Name = pc.Name
+	}
+	return fragment, nil
+}
+
+func inflateFragments(r *route.Config, fragmentConfigs map[string]route.PluginConfig) error {
+	var err error
+	r.Sender, err = inflateFragment(r.TenantId, r.Sender, fragmentConfigs)
+	if err != nil {
+		return err
+	}
+	r.Receiver, err = inflateFragment(r.TenantId, r.Receiver, fragmentConfigs)
+	if err != nil {
+		return err
+	}
+	for idx, filter := range r.FilterChain {
+		r.FilterChain[idx], err = inflateFragment(r.TenantId, filter, fragmentConfigs)
+		if err != nil {
+			return err
+		}
+	}
+	if r.DeadLetter != nil {
+		deadLetter, err := inflateFragment(r.TenantId, *r.DeadLetter, fragmentConfigs)
+		if err != nil {
+			return err
+		}
+		r.DeadLetter = &deadLetter
+	}
+	for name, branch := range r.Branches {
+		r.Branches[name], err = inflateFragment(r.TenantId, branch, fragmentConfigs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func (s *FsStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getRoute", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(ROUTES_DIR_NAME))
+	defer span.End()
+	s.RLock()
+	defer s.RUnlock()
+	r, ok := s.routes[tid.KeyWithRoute(id)]
+	if !ok {
+		return route.Config{}, &route.RouteNotFoundError{TenantId: tid, RouteId: id}
+	}
+	var c route.Config
+	err := clone(r, &c)
+	return c, err
+}
+
+func (s *FsStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getRoutes", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(ROUTES_DIR_NAME))
+	defer span.End()
+	return s.getRoutes(nil)
+}
+
+func (s *FsStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	_, span := db.CreateSpan(ctx, "getTenantRoutes", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(ROUTES_DIR_NAME))
+	defer span.End()
+	return s.getRoutes(&tid)
+}
+
+func (s *FsStorer) getRoutes(tid *tenant.Id) ([]route.Config, error) {
+	s.RLock()
+	defer s.RUnlock()
+	routes := make([]route.Config, 0)
+	for _, r := range s.routes {
+		if tid != nil && !tid.Equal(r.TenantId) {
+			continue
+		}
+		var c route.Config
+		err := clone(r, &c)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, c)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].TenantId.KeyWithRoute(routes[i].Id) < routes[j].TenantId.KeyWithRoute(routes[j].Id)
+	})
+	return routes, nil
+}
+
+func (s *FsStorer) SetRoute(ctx context.Context, r route.Config) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id string) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []string) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+// clone deep copies an item so that callers cannot modify the loaded items
+func clone(src interface{}, dst interface{}) error {
+	buf, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, dst)
+}
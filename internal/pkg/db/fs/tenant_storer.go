@@ -0,0 +1,69 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"sort"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func (s *FsStorer) GetAllConfigs(ctx context.Context) ([]tenant.Config, error) {
+	_, span := db.CreateSpan(ctx, "getTenantConfigs", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(TENANT_FILE_NAME))
+	defer span.End()
+	s.RLock()
+	defer s.RUnlock()
+	configs := make([]tenant.Config, 0)
+	for _, config := range s.tenants {
+		var c tenant.Config
+		err := clone(config, &c)
+		if err != nil {
+			return nil, &tenant.InternalStorageError{Wrapped: err}
+		}
+		configs = append(configs, c)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Tenant.Key() < configs[j].Tenant.Key()
+	})
+	return configs, nil
+}
+
+func (s *FsStorer) GetConfig(ctx context.Context, id tenant.Id) (*tenant.Config, error) {
+	_, span := db.CreateSpan(ctx, "getTenantConfig", rtsemconv.DBSystemFs, rtsemconv.DBTable.String(TENANT_FILE_NAME))
+	defer span.End()
+	s.RLock()
+	defer s.RUnlock()
+	config, ok := s.tenants[id.Key()]
+	if !ok {
+		return nil, &tenant.TenantNotFoundError{Tenant: id}
+	}
+	var c tenant.Config
+	err := clone(config, &c)
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return &c, nil
+}
+
+func (s *FsStorer) SetConfig(ctx context.Context, config tenant.Config) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
+
+func (s *FsStorer) DeleteConfig(ctx context.Context, id tenant.Id) error {
+	return &db.ReadOnlyError{Storer: "fs"}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !integration
+// +build !integration
+
+package db_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/db/fs"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatalf("Fail to create dir %s\n", err.Error())
+	}
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Fail to write file %s\n", err.Error())
+	}
+}
+
+const fsRoute = `
+receiver:
+  plugin: debug
+sender:
+  fragmentName: mySender
+  name: s1
+`
+
+const fsFragment = `
+plugin: debug
+config:
+  destination: stdout
+`
+
+func fsStorer(t *testing.T, dir string) *fs.FsStorer {
+	v := viper.New()
+	v.Set("ears.storage.route.path", dir)
+	v.Set("ears.storage.tenant.path", dir)
+	logger := zerolog.Nop()
+	s, err := fs.NewFsStorer(v, "route", &logger)
+	if err != nil {
+		t.Fatalf("Error instantiate fs storer %s\n", err.Error())
+	}
+	// storers configured with the same path share it
+	s2, err := fs.NewFsStorer(v, "tenant", &logger)
+	if err != nil || s2 != s {
+		t.Fatalf("expected shared fs storer %v\n", err)
+	}
+	return s
+}
+
+func TestFsStorer(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "tenant.yaml"), "quota:\n  eventsPerSec: 10\n")
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "fragments", "mySender.yaml"), fsFragment)
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "routes", "r1.yaml"), fsRoute)
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "routes", "README.md"), "not a route")
+	s := fsStorer(t, dir)
+	defer s.Close()
+	r, err := s.GetRoute(ctx, tid, "r1")
+	if err != nil {
+		t.Fatalf("Fail to get route %s\n", err.Error())
+	}
+	if !r.TenantId.Equal(tid) || r.Sender.Plugin != "debug" || r.Sender.Name != "s1" || r.Sender.Config == nil {
+		t.Fatalf("unexpected route %v\n", r)
+	}
+	routes, err := s.GetAllTenantRoutes(ctx, tenant.Id{OrgId: "myOrg", AppId: "myApp2"})
+	if err != nil || len(routes) != 0 {
+		t.Fatalf("unexpected routes of other tenant %v %v\n", routes, err)
+	}
+	var routeNotFound *route.RouteNotFoundError
+	_, err = s.GetRoute(ctx, tid, "r2")
+	if !errors.As(err, &routeNotFound) {
+		t.Fatalf("expected RouteNotFoundError, got %v\n", err)
+	}
+	fragments, err := s.GetAllTenantFragments(ctx, tid)
+	if err != nil || len(fragments) != 1 || fragments[0].FragmentName != "mySender" {
+		t.Fatalf("unexpected fragments %v %v\n", fragments, err)
+	}
+	config, err := s.GetConfig(ctx, tid)
+	if err != nil {
+		t.Fatalf("Fail to get config %s\n", err.Error())
+	}
+	if config.Quota.EventsPerSec != 10 || !config.Tenant.Equal(tid) {
+		t.Fatalf("unexpected config %v\n", config)
+	}
+	if !db.IsReadOnly(s) {
+		t.Fatalf("expected read only storer\n")
+	}
+	var readOnly *db.ReadOnlyError
+	if err = s.SetRoute(ctx, r); !errors.As(err, &readOnly) {
+		t.Fatalf("expected ReadOnlyError, got %v\n", err)
+	}
+	if err = s.DeleteConfig(ctx, tid); !errors.As(err, &readOnly) {
+		t.Fatalf("expected ReadOnlyError, got %v\n", err)
+	}
+	if err = s.DeleteFragment(ctx, tid, "mySender"); !errors.As(err, &readOnly) {
+		t.Fatalf("expected ReadOnlyError, got %v\n", err)
+	}
+}
+
+func TestFsStorerReload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "fragments", "mySender.yaml"), fsFragment)
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "routes", "r1.yaml"), fsRoute)
+	s := fsStorer(t, dir)
+	defer s.Close()
+	recorder := &syncRecorder{items: make(map[string]bool)}
+	s.RegisterLocalSyncer(syncer.ITEM_TYPE_ROUTE, recorder)
+	// new tenant directory
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp2", "routes", "r2.json"), `{"id":"r3","receiver":{"plugin":"debug"},"sender":{"plugin":"debug"}}`)
+	recorder.waitFor(t, tenant.Id{OrgId: "myOrg", AppId: "myApp2"}, "r3", true)
+	// changed fragment changes the route using it
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "fragments", "mySender.yaml"), "plugin: debug\nconfig:\n  destination: stderr\n")
+	recorder.waitFor(t, tid, "r1", true)
+	r, err := s.GetRoute(ctx, tid, "r1")
+	if err != nil {
+		t.Fatalf("Fail to get route %s\n", err.Error())
+	}
+	if r.Sender.Config.(map[string]interface{})["destination"] != "stderr" {
+		t.Fatalf("fragment change not applied %v\n", r.Sender)
+	}
+	// broken files keep the previous config
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "routes", "r1.yaml"), "receiver: [")
+	time.Sleep(time.Second)
+	_, err = s.GetRoute(ctx, tid, "r1")
+	if err != nil {
+		t.Fatalf("expected previous route to be kept %s\n", err.Error())
+	}
+	err = os.Remove(filepath.Join(dir, "myOrg", "myApp", "routes", "r1.yaml"))
+	if err != nil {
+		t.Fatalf("Fail to remove file %s\n", err.Error())
+	}
+	recorder.waitFor(t, tid, "r1", false)
+}
+
+func TestFsStorerBadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "myOrg", "myApp", "routes", "r1.yaml"), "receiver:\n  fragmentName: missing\n")
+	v := viper.New()
+	v.Set("ears.storage.route.path", dir)
+	logger := zerolog.Nop()
+	_, err := fs.NewFsStorer(v, "route", &logger)
+	var fileError *fs.FileError
+	if !errors.As(err, &fileError) {
+		t.Fatalf("expected FileError, got %v\n", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DEFAULT_DATABASE = "ears"
+
+	connectTimeout = 10 * time.Second
+)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// Connect connects to the mongodb deployment at uri and checks it can be reached
+func Connect(uri string) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, &MongoConnectError{err}
+	}
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, &MongoConnectError{err}
+	}
+	return client, nil
+}
+
+// open connects to the collection configured for the given storage, e.g. ears.storage.route.endpoint,
+// ears.storage.route.database and ears.storage.route.tableName
+func open(config Config, storage string, defaultCollection string) (*mongo.Collection, error) {
+	uri := config.GetString("ears.storage." + storage + ".endpoint")
+	if uri == "" {
+		return nil, &MissingConfigError{"ears.storage." + storage + ".endpoint"}
+	}
+	database := config.GetString("ears.storage." + storage + ".database")
+	if database == "" {
+		database = DEFAULT_DATABASE
+	}
+	collection := config.GetString("ears.storage." + storage + ".tableName")
+	if collection == "" {
+		collection = defaultCollection
+	}
+	client, err := Connect(uri)
+	if err != nil {
+		return nil, err
+	}
+	return client.Database(database).Collection(collection), nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// routeDocument is the stored form of a route, the route config is kept as json so that plugin configs with
+// arbitrary keys survive the round trip unchanged
+type routeDocument struct {
+	Key      string `bson:"_id"`
+	OrgId    string `bson:"orgId"`
+	AppId    string `bson:"appId"`
+	RouteId  string `bson:"routeId"`
+	Config   string `bson:"config"`
+	Created  int64  `bson:"created"`
+	Modified int64  `bson:"modified"`
+}
+
+type MongoRouteStorer struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRouteStorer(config Config) (*MongoRouteStorer, error) {
+	collection, err := open(config, "route", "routes")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orgId", Value: 1}, {Key: "appId", Value: 1}},
+	})
+	if err != nil {
+		return nil, &MongoQueryError{err}
+	}
+	return &MongoRouteStorer{
+		collection: collection,
+	}, nil
+}
+
+func (s *MongoRouteStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoute", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	r := route.Config{}
+	var doc routeDocument
+	err := s.collection.FindOne(ctx, bson.M{"_id": tid.KeyWithRoute(id)}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return r, &route.RouteNotFoundError{TenantId: tid, RouteId: id}
+	}
+	if err != nil {
+		return r, &MongoQueryError{err}
+	}
+	err = json.Unmarshal([]byte(doc.Config), &r)
+	return r, err
+}
+
+func (s *MongoRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoutes", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	return s.findRoutes(ctx, bson.M{})
+}
+
+func (s *MongoRouteStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getTenantRoutes", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	return s.findRoutes(ctx, bson.M{"orgId": tid.OrgId, "appId": tid.AppId})
+}
+
+func (s *MongoRouteStorer) findRoutes(ctx context.Context, filter bson.M) ([]route.Config, error) {
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, &MongoQueryError{err}
+	}
+	defer cursor.Close(ctx)
+	routes := make([]route.Config, 0)
+	for cursor.Next(ctx) {
+		var doc routeDocument
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return nil, &MongoQueryError{err}
+		}
+		var r route.Config
+		err = json.Unmarshal([]byte(doc.Config), &r)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, &MongoQueryError{err}
+	}
+	return routes, nil
+}
+
+func (s *MongoRouteStorer) SetRoute(ctx context.Context, r route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoute", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	return s.SetRoutes(ctx, []route.Config{r})
+}
+
+func (s *MongoRouteStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoutes", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	if routes == nil {
+		return fmt.Errorf("no routes to store in mongo")
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	keys := make([]string, len(routes))
+	for i, r := range routes {
+		if r.Id == "" {
+			return fmt.Errorf("no route to store in mongo")
+		}
+		keys[i] = r.TenantId.KeyWithRoute(r.Id)
+	}
+	// keep create times of existing routes
+	created := make(map[string]int64)
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": keys}}, options.Find().SetProjection(bson.M{"created": 1}))
+	if err != nil {
+		return &MongoQueryError{err}
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc routeDocument
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return &MongoQueryError{err}
+		}
+		created[doc.Key] = doc.Created
+	}
+	now := time.Now().Unix()
+	writes := make([]mongo.WriteModel, 0, len(routes))
+	for i, r := range routes {
+		r.Modified = now
+		r.Created = now
+		if ts, ok := created[keys[i]]; ok {
+			r.Created = ts
+		}
+		val, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		doc := routeDocument{
+			Key:      keys[i],
+			OrgId:    r.TenantId.OrgId,
+			AppId:    r.TenantId.AppId,
+			RouteId:  r.Id,
+			Config:   string(val),
+			Created:  r.Created,
+			Modified: r.Modified,
+		}
+		writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": keys[i]}).SetReplacement(doc).SetUpsert(true))
+	}
+	_, err = s.collection.BulkWrite(ctx, writes)
+	if err != nil {
+		return &MongoQueryError{err}
+	}
+	return nil
+}
+
+func (s *MongoRouteStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoute", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	if id == "" {
+		return fmt.Errorf("no route to delete in mongo")
+	}
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": tid.KeyWithRoute(id)})
+	if err != nil {
+		return &MongoQueryError{err}
+	}
+	return nil
+}
+
+func (s *MongoRouteStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoutes", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = tid.KeyWithRoute(id)
+	}
+	_, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": keys}})
+	if err != nil {
+		return &MongoQueryError{err}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+type tenantDocument struct {
+	Key      string `bson:"_id"`
+	OrgId    string `bson:"orgId"`
+	AppId    string `bson:"appId"`
+	Config   string `bson:"config"`
+	Modified int64  `bson:"modified"`
+}
+
+type MongoTenantStorer struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTenantStorer(config Config) (*MongoTenantStorer, error) {
+	collection, err := open(config, "tenant", "tenants")
+	if err != nil {
+		return nil, err
+	}
+	return &MongoTenantStorer{
+		collection: collection,
+	}, nil
+}
+
+func (s *MongoTenantStorer) GetAllConfigs(ctx context.Context) ([]tenant.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getAllTenantConfigs", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	defer cursor.Close(ctx)
+	configs := make([]tenant.Config, 0)
+	for cursor.Next(ctx) {
+		var doc tenantDocument
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return nil, &tenant.InternalStorageError{Wrapped: err}
+		}
+		var config tenant.Config
+		err = json.Unmarshal([]byte(doc.Config), &config)
+		if err != nil {
+			return nil, &tenant.InternalStorageError{Wrapped: err}
+		}
+		configs = append(configs, config)
+	}
+	if err = cursor.Err(); err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return configs, nil
+}
+
+func (s *MongoTenantStorer) GetConfig(ctx context.Context, id tenant.Id) (*tenant.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getTenantConfig", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	var doc tenantDocument
+	err := s.collection.FindOne(ctx, bson.M{"_id": id.Key()}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, &tenant.TenantNotFoundError{Tenant: id}
+	}
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	var config tenant.Config
+	err = json.Unmarshal([]byte(doc.Config), &config)
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return &config, nil
+}
+
+func (s *MongoTenantStorer) SetConfig(ctx context.Context, config tenant.Config) error {
+	ctx, span := db.CreateSpan(ctx, "setTenantConfig", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	config.Modified = time.Now().Unix()
+	val, err := json.Marshal(config)
+	if err != nil {
+		return &tenant.BadConfigError{}
+	}
+	doc := tenantDocument{
+		Key:      config.Tenant.Key(),
+		OrgId:    config.Tenant.OrgId,
+		AppId:    config.Tenant.AppId,
+		Config:   string(val),
+		Modified: config.Modified,
+	}
+	_, err = s.collection.ReplaceOne(ctx, bson.M{"_id": doc.Key}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return &tenant.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
+
+func (s *MongoTenantStorer) DeleteConfig(ctx context.Context, id tenant.Id) error {
+	ctx, span := db.CreateSpan(ctx, "deleteTenantConfig", semconv.DBSystemMongoDB, rtsemconv.DBTable.String(s.collection.Name()))
+	defer span.End()
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": id.Key()})
+	if err != nil {
+		return &tenant.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
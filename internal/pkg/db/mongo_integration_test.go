@@ -0,0 +1,50 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+// +build integration
+
+package db_test
+
+import (
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db/mongo"
+	"testing"
+)
+
+func mongoConfig() config.Config {
+	v := viper.New()
+	v.Set("ears.storage.route.endpoint", "mongodb://127.0.0.1:27017")
+	v.Set("ears.storage.route.database", "ears_test")
+	v.Set("ears.storage.tenant.endpoint", "mongodb://127.0.0.1:27017")
+	v.Set("ears.storage.tenant.database", "ears_test")
+	return v
+}
+
+func TestMongoRouteStorer(t *testing.T) {
+	s, err := mongo.NewMongoRouteStorer(mongoConfig())
+	if err != nil {
+		t.Fatalf("Error instantiate mongo %s\n", err.Error())
+	}
+	testRouteStorer(s, t)
+}
+
+func TestMongoTenantStorer(t *testing.T) {
+	s, err := mongo.NewMongoTenantStorer(mongoConfig())
+	if err != nil {
+		t.Fatalf("Error instantiate mongo %s\n", err.Error())
+	}
+	testTenantStorer(s, t)
+}
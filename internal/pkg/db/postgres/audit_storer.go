@@ -0,0 +1,142 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+var auditMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS {table} (
+		id text PRIMARY KEY,
+		time bigint NOT NULL,
+		org_id text NOT NULL,
+		app_id text NOT NULL,
+		item_type text NOT NULL,
+		item_id text NOT NULL,
+		subject text NOT NULL,
+		record jsonb NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS {table}_time_idx ON {table} (time)`,
+}
+
+type PostgresAuditStorer struct {
+	db        *sql.DB
+	tableName string
+}
+
+func NewPostgresAuditStorer(config Config) (*PostgresAuditStorer, error) {
+	conn, tableName, err := open(config, "audit", "audit")
+	if err != nil {
+		return nil, err
+	}
+	err = migrate(context.Background(), conn, tableName, auditMigrations)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &PostgresAuditStorer{
+		db:        conn,
+		tableName: tableName,
+	}, nil
+}
+
+func (s *PostgresAuditStorer) AddRecord(ctx context.Context, record audit.Record) error {
+	ctx, span := db.CreateSpan(ctx, "addAuditRecord", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	record = record.WithDefaults()
+	val, err := json.Marshal(record)
+	if err != nil {
+		return &audit.InternalStorageError{Wrapped: err}
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO "+s.tableName+" (id, time, org_id, app_id, item_type, item_id, subject, record) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		record.Id, record.Time, record.Tenant.OrgId, record.Tenant.AppId, record.ItemType, record.ItemId, record.Subject, val)
+	if err != nil {
+		return &audit.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
+
+func (s *PostgresAuditStorer) GetRecords(ctx context.Context, query audit.Query) ([]audit.Record, error) {
+	ctx, span := db.CreateSpan(ctx, "getAuditRecords", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+	if query.OrgId != "" {
+		where("org_id =", query.OrgId)
+	}
+	if query.AppId != "" {
+		where("app_id =", query.AppId)
+	}
+	if query.ItemType != "" {
+		where("item_type =", query.ItemType)
+	}
+	if query.ItemId != "" {
+		where("item_id =", query.ItemId)
+	}
+	if query.Subject != "" {
+		where("subject =", query.Subject)
+	}
+	if query.Since > 0 {
+		where("time >=", query.Since)
+	}
+	if query.Until > 0 {
+		where("time <=", query.Until)
+	}
+	statement := "SELECT record FROM " + s.tableName
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY time DESC, id DESC"
+	if query.Limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(query.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, &audit.InternalStorageError{Wrapped: err}
+	}
+	defer rows.Close()
+	records := make([]audit.Record, 0)
+	for rows.Next() {
+		var val []byte
+		err = rows.Scan(&val)
+		if err != nil {
+			return nil, &audit.InternalStorageError{Wrapped: err}
+		}
+		var record audit.Record
+		err = json.Unmarshal(val, &record)
+		if err != nil {
+			return nil, &audit.InternalStorageError{Wrapped: err}
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, &audit.InternalStorageError{Wrapped: err}
+	}
+	return records, nil
+}
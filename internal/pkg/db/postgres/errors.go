@@ -0,0 +1,77 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+type MissingConfigError struct {
+	configName string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "MissingConfigError (configName=" + e.configName + ")"
+}
+
+type InvalidTableNameError struct {
+	tableName string
+}
+
+func (e *InvalidTableNameError) Error() string {
+	return "InvalidTableNameError (tableName=" + e.tableName + ")"
+}
+
+type PostgresConnectError struct {
+	Source error
+}
+
+func (e *PostgresConnectError) Error() string {
+	return "PostgresConnectError: " + e.Source.Error()
+}
+
+func (e *PostgresConnectError) Unwrap() error {
+	return e.Source
+}
+
+type PostgresMigrationError struct {
+	Migration string
+	Source    error
+}
+
+func (e *PostgresMigrationError) Error() string {
+	return "PostgresMigrationError (migration=" + e.Migration + "): " + e.Source.Error()
+}
+
+func (e *PostgresMigrationError) Unwrap() error {
+	return e.Source
+}
+
+type PostgresQueryError struct {
+	Source error
+}
+
+func (e *PostgresQueryError) Error() string {
+	return "PostgresQueryError: " + e.Source.Error()
+}
+
+func (e *PostgresQueryError) Unwrap() error {
+	return e.Source
+}
+
+// ConcurrentModificationError is returned when an item keeps being modified by other instances while it is written
+type ConcurrentModificationError struct {
+	Key string
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return "ConcurrentModificationError (key=" + e.Key + ")"
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+const (
+	DRIVER_NAME = "postgres"
+
+	// MIGRATIONS_TABLE keeps track of the schema migrations applied to the tables of all storers
+	MIGRATIONS_TABLE = "ears_schema_migrations"
+
+	// MAX_WRITE_ATTEMPTS is the number of times a write is retried when the item is modified concurrently
+	MAX_WRITE_ATTEMPTS = 5
+
+	// migrationLockId is the key of the advisory lock serializing migrations of concurrently starting instances
+	migrationLockId = 4711
+)
+
+var tableNameValidator = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,62}$`)
+
+type Config interface {
+	GetString(key string) string
+	GetInt(key string) int
+	GetBool(key string) bool
+}
+
+// open connects to the database at the connection string configured for the given storage, e.g.
+// ears.storage.route.endpoint, and checks the table name before it is used in any statement
+func open(config Config, storage string, defaultTableName string) (*sql.DB, string, error) {
+	endpoint := config.GetString("ears.storage." + storage + ".endpoint")
+	if endpoint == "" {
+		return nil, "", &MissingConfigError{"ears.storage." + storage + ".endpoint"}
+	}
+	tableName := config.GetString("ears.storage." + storage + ".tableName")
+	if tableName == "" {
+		tableName = defaultTableName
+	}
+	if !tableNameValidator.MatchString(tableName) {
+		return nil, "", &InvalidTableNameError{tableName}
+	}
+	db, err := sql.Open(DRIVER_NAME, endpoint)
+	if err != nil {
+		return nil, "", &PostgresConnectError{err}
+	}
+	if maxConns := config.GetInt("ears.storage." + storage + ".maxConnections"); maxConns > 0 {
+		db.SetMaxOpenConns(maxConns)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, "", &PostgresConnectError{err}
+	}
+	return db, tableName, nil
+}
+
+// migrate applies the migrations of a table that have not been applied yet in order, each migration in its own
+// transaction. Statements refer to the table as {table}. Migrations must never be changed once released, schema
+// changes are made by appending a migration.
+func migrate(ctx context.Context, db *sql.DB, tableName string, migrations []string) error {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+MIGRATIONS_TABLE+" (name text PRIMARY KEY, applied bigint NOT NULL)")
+	if err != nil {
+		return &PostgresMigrationError{MIGRATIONS_TABLE, err}
+	}
+	for i, migration := range migrations {
+		name := tableName + "_" + strconv.Itoa(i+1)
+		err := applyMigration(ctx, db, name, strings.ReplaceAll(migration, "{table}", tableName))
+		if err != nil {
+			return &PostgresMigrationError{name, err}
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, name string, statement string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockId)
+	if err != nil {
+		return err
+	}
+	var applied int64
+	err = tx.QueryRowContext(ctx, "SELECT applied FROM "+MIGRATIONS_TABLE+" WHERE name = $1", name).Scan(&applied)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+MIGRATIONS_TABLE+" (name, applied) VALUES ($1, $2)", name, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
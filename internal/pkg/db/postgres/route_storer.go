@@ -0,0 +1,244 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// routeMigrations create and evolve the route table, every route row carries a version which is incremented on
+// each update so that concurrent writers do not overwrite each other's changes unnoticed
+var routeMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS {table} (
+		org_id text NOT NULL,
+		app_id text NOT NULL,
+		route_id text NOT NULL,
+		config jsonb NOT NULL,
+		created bigint NOT NULL,
+		modified bigint NOT NULL,
+		version bigint NOT NULL,
+		PRIMARY KEY (org_id, app_id, route_id)
+	)`,
+}
+
+type PostgresRouteStorer struct {
+	db        *sql.DB
+	tableName string
+}
+
+func NewPostgresRouteStorer(config Config) (*PostgresRouteStorer, error) {
+	conn, tableName, err := open(config, "route", "routes")
+	if err != nil {
+		return nil, err
+	}
+	err = migrate(context.Background(), conn, tableName, routeMigrations)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &PostgresRouteStorer{
+		db:        conn,
+		tableName: tableName,
+	}, nil
+}
+
+func (s *PostgresRouteStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoute", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	r := route.Config{}
+	var val []byte
+	err := s.db.QueryRowContext(ctx, "SELECT config FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2 AND route_id = $3",
+		tid.OrgId, tid.AppId, id).Scan(&val)
+	if err == sql.ErrNoRows {
+		return r, &route.RouteNotFoundError{TenantId: tid, RouteId: id}
+	}
+	if err != nil {
+		return r, &PostgresQueryError{err}
+	}
+	err = json.Unmarshal(val, &r)
+	return r, err
+}
+
+func (s *PostgresRouteStorer) GetAllRoutes(ctx context.Context) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getRoutes", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	return s.queryRoutes(ctx, "SELECT config FROM "+s.tableName)
+}
+
+func (s *PostgresRouteStorer) GetAllTenantRoutes(ctx context.Context, tid tenant.Id) ([]route.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getTenantRoutes", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	return s.queryRoutes(ctx, "SELECT config FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2", tid.OrgId, tid.AppId)
+}
+
+func (s *PostgresRouteStorer) queryRoutes(ctx context.Context, query string, args ...interface{}) ([]route.Config, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, &PostgresQueryError{err}
+	}
+	defer rows.Close()
+	routes := make([]route.Config, 0)
+	for rows.Next() {
+		var val []byte
+		err = rows.Scan(&val)
+		if err != nil {
+			return nil, &PostgresQueryError{err}
+		}
+		var r route.Config
+		err = json.Unmarshal(val, &r)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, &PostgresQueryError{err}
+	}
+	return routes, nil
+}
+
+func (s *PostgresRouteStorer) SetRoute(ctx context.Context, r route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoute", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.setRoute(ctx, tx, r)
+	})
+}
+
+func (s *PostgresRouteStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
+	ctx, span := db.CreateSpan(ctx, "storeRoutes", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	if routes == nil {
+		return fmt.Errorf("no routes to store in postgres")
+	}
+	// all routes are stored or none
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, r := range routes {
+			err := s.setRoute(ctx, tx, r)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setRoute inserts or updates a route keeping its create time, the update only succeeds if the route has not been
+// modified since it was read and is retried otherwise
+func (s *PostgresRouteStorer) setRoute(ctx context.Context, tx *sql.Tx, r route.Config) error {
+	if r.Id == "" {
+		return fmt.Errorf("no route to store in postgres")
+	}
+	r.Modified = time.Now().Unix()
+	for i := 0; i < MAX_WRITE_ATTEMPTS; i++ {
+		var created, version int64
+		err := tx.QueryRowContext(ctx, "SELECT created, version FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2 AND route_id = $3",
+			r.TenantId.OrgId, r.TenantId.AppId, r.Id).Scan(&created, &version)
+		if err != nil && err != sql.ErrNoRows {
+			return &PostgresQueryError{err}
+		}
+		var result sql.Result
+		if err == sql.ErrNoRows {
+			r.Created = r.Modified
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			result, err = tx.ExecContext(ctx, "INSERT INTO "+s.tableName+" (org_id, app_id, route_id, config, created, modified, version) VALUES ($1, $2, $3, $4, $5, $6, 1) ON CONFLICT DO NOTHING",
+				r.TenantId.OrgId, r.TenantId.AppId, r.Id, val, r.Created, r.Modified)
+			if err != nil {
+				return &PostgresQueryError{err}
+			}
+		} else {
+			r.Created = created
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			result, err = tx.ExecContext(ctx, "UPDATE "+s.tableName+" SET config = $4, modified = $5, version = version + 1 WHERE org_id = $1 AND app_id = $2 AND route_id = $3 AND version = $6",
+				r.TenantId.OrgId, r.TenantId.AppId, r.Id, val, r.Modified, version)
+			if err != nil {
+				return &PostgresQueryError{err}
+			}
+		}
+		cnt, err := result.RowsAffected()
+		if err != nil {
+			return &PostgresQueryError{err}
+		}
+		if cnt == 1 {
+			return nil
+		}
+	}
+	return &ConcurrentModificationError{r.TenantId.KeyWithRoute(r.Id)}
+}
+
+func (s *PostgresRouteStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoute", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	if id == "" {
+		return fmt.Errorf("no route to delete in postgres")
+	}
+	_, err := s.db.ExecContext(ctx, "DELETE FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2 AND route_id = $3", tid.OrgId, tid.AppId, id)
+	if err != nil {
+		return &PostgresQueryError{err}
+	}
+	return nil
+}
+
+func (s *PostgresRouteStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []string) error {
+	ctx, span := db.CreateSpan(ctx, "deleteRoutes", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, id := range ids {
+			_, err := tx.ExecContext(ctx, "DELETE FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2 AND route_id = $3", tid.OrgId, tid.AppId, id)
+			if err != nil {
+				return &PostgresQueryError{err}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *PostgresRouteStorer) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return inTx(ctx, s.db, fn)
+}
+
+// inTx runs fn in a transaction which is committed if fn succeeds and rolled back otherwise
+func inTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return &PostgresQueryError{err}
+	}
+	defer tx.Rollback()
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return &PostgresQueryError{err}
+	}
+	return nil
+}
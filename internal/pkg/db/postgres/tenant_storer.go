@@ -0,0 +1,157 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+var tenantMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS {table} (
+		org_id text NOT NULL,
+		app_id text NOT NULL,
+		config jsonb NOT NULL,
+		modified bigint NOT NULL,
+		version bigint NOT NULL,
+		PRIMARY KEY (org_id, app_id)
+	)`,
+}
+
+type PostgresTenantStorer struct {
+	db        *sql.DB
+	tableName string
+}
+
+func NewPostgresTenantStorer(config Config) (*PostgresTenantStorer, error) {
+	conn, tableName, err := open(config, "tenant", "tenants")
+	if err != nil {
+		return nil, err
+	}
+	err = migrate(context.Background(), conn, tableName, tenantMigrations)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &PostgresTenantStorer{
+		db:        conn,
+		tableName: tableName,
+	}, nil
+}
+
+func (s *PostgresTenantStorer) GetAllConfigs(ctx context.Context) ([]tenant.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getAllTenantConfigs", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	rows, err := s.db.QueryContext(ctx, "SELECT config FROM "+s.tableName)
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	defer rows.Close()
+	configs := make([]tenant.Config, 0)
+	for rows.Next() {
+		var val []byte
+		err = rows.Scan(&val)
+		if err != nil {
+			return nil, &tenant.InternalStorageError{Wrapped: err}
+		}
+		var config tenant.Config
+		err = json.Unmarshal(val, &config)
+		if err != nil {
+			return nil, &tenant.InternalStorageError{Wrapped: err}
+		}
+		configs = append(configs, config)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return configs, nil
+}
+
+func (s *PostgresTenantStorer) GetConfig(ctx context.Context, id tenant.Id) (*tenant.Config, error) {
+	ctx, span := db.CreateSpan(ctx, "getTenantConfig", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	var val []byte
+	err := s.db.QueryRowContext(ctx, "SELECT config FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2", id.OrgId, id.AppId).Scan(&val)
+	if err == sql.ErrNoRows {
+		return nil, &tenant.TenantNotFoundError{Tenant: id}
+	}
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	var config tenant.Config
+	err = json.Unmarshal(val, &config)
+	if err != nil {
+		return nil, &tenant.InternalStorageError{Wrapped: err}
+	}
+	return &config, nil
+}
+
+// SetConfig inserts or updates a tenant config, the update only succeeds if the config has not been modified
+// since it was read and is retried otherwise
+func (s *PostgresTenantStorer) SetConfig(ctx context.Context, config tenant.Config) error {
+	ctx, span := db.CreateSpan(ctx, "setTenantConfig", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	config.Modified = time.Now().Unix()
+	val, err := json.Marshal(config)
+	if err != nil {
+		return &tenant.BadConfigError{}
+	}
+	id := config.Tenant
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		for i := 0; i < MAX_WRITE_ATTEMPTS; i++ {
+			var version int64
+			err := tx.QueryRowContext(ctx, "SELECT version FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2", id.OrgId, id.AppId).Scan(&version)
+			if err != nil && err != sql.ErrNoRows {
+				return &tenant.InternalStorageError{Wrapped: err}
+			}
+			var result sql.Result
+			if err == sql.ErrNoRows {
+				result, err = tx.ExecContext(ctx, "INSERT INTO "+s.tableName+" (org_id, app_id, config, modified, version) VALUES ($1, $2, $3, $4, 1) ON CONFLICT DO NOTHING",
+					id.OrgId, id.AppId, val, config.Modified)
+			} else {
+				result, err = tx.ExecContext(ctx, "UPDATE "+s.tableName+" SET config = $3, modified = $4, version = version + 1 WHERE org_id = $1 AND app_id = $2 AND version = $5",
+					id.OrgId, id.AppId, val, config.Modified, version)
+			}
+			if err != nil {
+				return &tenant.InternalStorageError{Wrapped: err}
+			}
+			cnt, err := result.RowsAffected()
+			if err != nil {
+				return &tenant.InternalStorageError{Wrapped: err}
+			}
+			if cnt == 1 {
+				return nil
+			}
+		}
+		return &tenant.InternalStorageError{Wrapped: &ConcurrentModificationError{id.Key()}}
+	})
+}
+
+func (s *PostgresTenantStorer) DeleteConfig(ctx context.Context, id tenant.Id) error {
+	ctx, span := db.CreateSpan(ctx, "deleteTenantConfig", semconv.DBSystemPostgreSQL, rtsemconv.DBTable.String(s.tableName))
+	defer span.End()
+	_, err := s.db.ExecContext(ctx, "DELETE FROM "+s.tableName+" WHERE org_id = $1 AND app_id = $2", id.OrgId, id.AppId)
+	if err != nil {
+		return &tenant.InternalStorageError{Wrapped: err}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+// ReadOnlyStorer is implemented by storers whose items are managed outside of the api, e.g. as files under version
+// control, ReadOnly reports whether writes through the api are rejected
+type ReadOnlyStorer interface {
+	ReadOnly() bool
+}
+
+// ReadOnlyError is returned when an item is written to a read only storer
+type ReadOnlyError struct {
+	Storer string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return "ReadOnlyError (storer=" + e.Storer + ")"
+}
+
+// IsReadOnly returns true if storer is a read only storer
+func IsReadOnly(storer interface{}) bool {
+	ro, ok := storer.(ReadOnlyStorer)
+	return ok && ro.ReadOnly()
+}
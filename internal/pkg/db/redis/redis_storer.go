@@ -28,6 +28,14 @@ import (
 	"time"
 )
 
+const (
+	DEFAULT_TABLE_NAME = "routes"
+)
+
+// RedisDbStorer stores all routes in a single redis hash named after the table name (routes by default), the hash
+// field of a route is its key of the form base64(orgId).base64(appId).base64(routeId) and its value is the route
+// config as json. Routes never expire, for routes to survive redis restarts redis must persist its data set
+// (preferably using AOF) which can be enforced with ears.storage.route.requireAof
 type RedisDbStorer struct {
 	client    *redis.Client
 	endpoint  string
@@ -42,24 +50,50 @@ type Config interface {
 	GetBool(key string) bool
 }
 
-//TODO: handle timestamps here
-
 func NewRedisDbStorer(config Config, logger *zerolog.Logger) (*RedisDbStorer, error) {
 	rdb := &RedisDbStorer{
 		endpoint:  config.GetString("ears.storage.route.endpoint"),
-		tableName: "routes",
+		tableName: config.GetString("ears.storage.route.tableName"),
 		logger:    logger,
 		config:    config,
 	}
+	if rdb.tableName == "" {
+		rdb.tableName = DEFAULT_TABLE_NAME
+	}
 	rdb.client = redis.NewClient(&redis.Options{
 		Addr:     rdb.endpoint,
-		Password: "",
-		DB:       0,
+		Password: config.GetString("ears.storage.route.password"),
+		DB:       config.GetInt("ears.storage.route.db"),
 	})
-	logger.Info().Msg("connected to redis storage layer at " + rdb.endpoint)
+	if config.GetBool("ears.storage.route.requireAof") {
+		err := rdb.ensureAof()
+		if err != nil {
+			rdb.client.Close()
+			return nil, err
+		}
+	}
+	logger.Info().Str("table", rdb.tableName).Msg("connected to redis storage layer at " + rdb.endpoint)
 	return rdb, nil
 }
 
+// ensureAof turns on append only file persistence if it is not already on, this fails on managed redis services
+// which do not allow CONFIG commands in which case persistence must be configured with the service
+func (d *RedisDbStorer) ensureAof() error {
+	result, err := d.client.ConfigGet("appendonly").Result()
+	if err != nil {
+		return fmt.Errorf("could not get redis persistence config: %v", err)
+	}
+	if len(result) == 2 && result[1] == "yes" {
+		return nil
+	}
+	_, err = d.client.ConfigSet("appendonly", "yes").Result()
+	if err != nil {
+		return fmt.Errorf("could not turn on redis append only file persistence: %v", err)
+	}
+	d.logger.Info().Msg("turned on redis append only file persistence at " + d.endpoint)
+	return nil
+}
+
 func (d *RedisDbStorer) GetRoute(ctx context.Context, tid tenant.Id, id string) (route.Config, error) {
 	_, span := db.CreateSpan(ctx, "getRoute", semconv.DBSystemRedis,
 		semconv.DBConnectionStringKey.String(d.endpoint), rtsemconv.DBTable.String(d.tableName))
@@ -143,19 +177,48 @@ func (d *RedisDbStorer) SetRoute(ctx context.Context, r route.Config) error {
 }
 
 func (d *RedisDbStorer) SetRoutes(ctx context.Context, routes []route.Config) error {
-
 	_, span := db.CreateSpan(ctx, "storeRoutes", semconv.DBSystemRedis,
 		semconv.DBConnectionStringKey.String(d.endpoint), rtsemconv.DBTable.String(d.tableName))
 	defer span.End()
-
 	if routes == nil {
-		return fmt.Errorf("no routes to store in bolt")
+		return fmt.Errorf("no routes to store in redis")
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	keys := make([]string, len(routes))
+	for i, r := range routes {
+		if r.Id == "" {
+			return fmt.Errorf("no route to store in redis")
+		}
+		keys[i] = r.TenantId.KeyWithRoute(r.Id)
 	}
-	for _, r := range routes {
-		err := d.SetRoute(ctx, r)
+	// keep create times of existing routes
+	olds, err := d.client.HMGet(d.tableName, keys...).Result()
+	if err != nil {
+		return fmt.Errorf("could not get routes from redis: %v", err)
+	}
+	now := time.Now().Unix()
+	fields := make(map[string]interface{}, len(routes))
+	for i, r := range routes {
+		r.Modified = now
+		r.Created = now
+		if old, ok := olds[i].(string); ok && old != "" {
+			var oldRoute route.Config
+			if json.Unmarshal([]byte(old), &oldRoute) == nil {
+				r.Created = oldRoute.Created
+			}
+		}
+		val, err := json.Marshal(r)
 		if err != nil {
 			return err
 		}
+		fields[keys[i]] = string(val)
+	}
+	// all routes are stored in a single command so that either all or none are stored
+	_, err = d.client.HMSet(d.tableName, fields).Result()
+	if err != nil {
+		return fmt.Errorf("could not insert routes into redis: %v", err)
 	}
 	return nil
 }
@@ -165,15 +228,12 @@ func (d *RedisDbStorer) DeleteRoute(ctx context.Context, tid tenant.Id, id strin
 		semconv.DBConnectionStringKey.String(d.endpoint), rtsemconv.DBTable.String(d.tableName))
 	defer span.End()
 	if id == "" {
-		return fmt.Errorf("no route to delete in bolt")
+		return fmt.Errorf("no route to delete in redis")
 	}
 	_, err := d.client.HDel(d.tableName, tid.KeyWithRoute(id)).Result()
 	if err != nil {
 		return fmt.Errorf("could not delete route from redis: %v", err)
 	}
-	//if num != 1 {
-	//return fmt.Errorf("could not delete route from redis")
-	//}
 	return nil
 }
 
@@ -181,11 +241,16 @@ func (d *RedisDbStorer) DeleteRoutes(ctx context.Context, tid tenant.Id, ids []s
 	_, span := db.CreateSpan(ctx, "deleteRoutes", semconv.DBSystemRedis,
 		semconv.DBConnectionStringKey.String(d.endpoint), rtsemconv.DBTable.String(d.tableName))
 	defer span.End()
-	for _, id := range ids {
-		err := d.DeleteRoute(ctx, tid, id)
-		if err != nil {
-			return err
-		}
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = tid.KeyWithRoute(id)
+	}
+	_, err := d.client.HDel(d.tableName, keys...).Result()
+	if err != nil {
+		return fmt.Errorf("could not delete routes from redis: %v", err)
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"testing"
+)
+
+func TestRedisDbStorerDefaults(t *testing.T) {
+	v := viper.New()
+	v.Set("ears.storage.route.endpoint", "127.0.0.1:6379")
+	logger := zerolog.Nop()
+	d, err := NewRedisDbStorer(v, &logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer d.client.Close()
+	if d.tableName != DEFAULT_TABLE_NAME {
+		t.Fatalf("expected default table name %s but got %s", DEFAULT_TABLE_NAME, d.tableName)
+	}
+	options := d.client.Options()
+	if options.Addr != "127.0.0.1:6379" || options.Password != "" || options.DB != 0 {
+		t.Fatalf("unexpected client options %+v", options)
+	}
+}
+
+func TestRedisDbStorerConfig(t *testing.T) {
+	v := viper.New()
+	v.Set("ears.storage.route.endpoint", "redis.example.com:6380")
+	v.Set("ears.storage.route.tableName", "myroutes")
+	v.Set("ears.storage.route.password", "secret")
+	v.Set("ears.storage.route.db", 3)
+	logger := zerolog.Nop()
+	d, err := NewRedisDbStorer(v, &logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	defer d.client.Close()
+	if d.tableName != "myroutes" {
+		t.Fatalf("expected table name myroutes but got %s", d.tableName)
+	}
+	options := d.client.Options()
+	if options.Addr != "redis.example.com:6380" || options.Password != "secret" || options.DB != 3 {
+		t.Fatalf("unexpected client options %+v", options)
+	}
+}
+
+func TestRedisDbStorerRequireAof(t *testing.T) {
+	v := viper.New()
+	// nothing listens on port 1, the persistence config cannot be verified
+	v.Set("ears.storage.route.endpoint", "127.0.0.1:1")
+	v.Set("ears.storage.route.requireAof", true)
+	logger := zerolog.Nop()
+	_, err := NewRedisDbStorer(v, &logger)
+	if err == nil {
+		t.Fatalf("expected error when append only file persistence cannot be verified")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/sebdah/goldie/v2"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -397,3 +398,28 @@ func testRouteStorer(s route.RouteStorer, t *testing.T) {
 		t.Fatalf("Expect 0 routes but get %d instead\n", len(routes))
 	}
 }
+
+type syncRecorder struct {
+	sync.Mutex
+	items map[string]bool
+}
+
+func (r *syncRecorder) SyncItem(ctx context.Context, tid tenant.Id, itemId string, add bool) error {
+	r.Lock()
+	defer r.Unlock()
+	r.items[tid.KeyWithRoute(itemId)] = add
+	return nil
+}
+
+func (r *syncRecorder) waitFor(t *testing.T, tid tenant.Id, itemId string, add bool) {
+	for i := 0; i < 50; i++ {
+		r.Lock()
+		a, ok := r.items[tid.KeyWithRoute(itemId)]
+		r.Unlock()
+		if ok && a == add {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no sync for item %s add=%v\n", itemId, add)
+}
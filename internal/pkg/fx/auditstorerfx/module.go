@@ -0,0 +1,106 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditstorerfx
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/audit"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db/bolt"
+	"github.com/xmidt-org/ears/internal/pkg/db/postgres"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		ProvideAuditStorer,
+	),
+)
+
+type StorageIn struct {
+	fx.In
+	Config config.Config
+	Logger *zerolog.Logger
+}
+
+type StorageOut struct {
+	fx.Out
+	AuditStorer audit.AuditStorer
+}
+
+// ProvideAuditStorer provides the audit storer configured with ears.storage.audit.type. It defaults to the route
+// storage and its connection settings if audit records can be kept there and to an in memory ring buffer otherwise.
+func ProvideAuditStorer(in StorageIn) (StorageOut, error) {
+	out := StorageOut{}
+	cfg := in.Config
+	storageType := cfg.GetString("ears.storage.audit.type")
+	if storageType == "" {
+		storageType = cfg.GetString("ears.storage.route.type")
+		if storageType == "bolt" || storageType == "postgres" {
+			cfg = &routeStorageConfig{cfg}
+		} else {
+			in.Logger.Warn().Str("op", "ProvideAuditStorer").Str("routeStorageType", storageType).Msg("audit records are kept in memory only, configure ears.storage.audit.type to persist them")
+			storageType = "inmemory"
+		}
+	}
+	switch storageType {
+	case "inmemory":
+		out.AuditStorer = audit.NewInMemoryAuditStorer(cfg.GetInt("ears.audit.maxRecords"))
+	case "bolt":
+		auditStorer, err := bolt.NewBoltAuditStorer(cfg)
+		if err != nil {
+			return out, err
+		}
+		out.AuditStorer = auditStorer
+	case "postgres":
+		auditStorer, err := postgres.NewPostgresAuditStorer(cfg)
+		if err != nil {
+			return out, err
+		}
+		out.AuditStorer = auditStorer
+	default:
+		return out, &UnsupportedAuditStorageError{storageType}
+	}
+	return out, nil
+}
+
+// routeStorageConfig falls back to the route storage settings for audit storage settings that are not configured,
+// except for the table name so that audit records never end up in the route table
+type routeStorageConfig struct {
+	config.Config
+}
+
+func (c *routeStorageConfig) GetString(key string) string {
+	if val := c.Config.GetString(key); val != "" || key == "ears.storage.audit.tableName" {
+		return val
+	}
+	return c.Config.GetString(routeStorageKey(key))
+}
+
+func (c *routeStorageConfig) GetInt(key string) int {
+	if val := c.Config.GetInt(key); val != 0 {
+		return val
+	}
+	return c.Config.GetInt(routeStorageKey(key))
+}
+
+func routeStorageKey(key string) string {
+	if !strings.HasPrefix(key, "ears.storage.audit.") {
+		return key
+	}
+	return "ears.storage.route." + strings.TrimPrefix(key, "ears.storage.audit.")
+}
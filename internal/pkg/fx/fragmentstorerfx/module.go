@@ -18,7 +18,10 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/db/bolt"
 	"github.com/xmidt-org/ears/internal/pkg/db/dynamo"
+	"github.com/xmidt-org/ears/internal/pkg/db/encrypted"
+	"github.com/xmidt-org/ears/internal/pkg/db/fs"
 	"github.com/xmidt-org/ears/pkg/fragments"
 	"go.uber.org/fx"
 )
@@ -52,8 +55,27 @@ func ProvideFragmentStorer(in StorageIn) (StorageOut, error) {
 			return out, err
 		}
 		out.FragmentStorer = fragmentStorer
+	case "bolt":
+		fragmentStorer, err := bolt.NewBoltFragmentStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.FragmentStorer = fragmentStorer
+	case "fs":
+		fragmentStorer, err := fs.NewFsStorer(in.Config, "fragment", in.Logger)
+		if err != nil {
+			return out, err
+		}
+		out.FragmentStorer = fragmentStorer
 	default:
 		return out, &UnsupportedFragmentStorageError{storageType}
 	}
+	encrypter, err := encrypted.NewEncrypter(in.Config)
+	if err != nil {
+		return out, err
+	}
+	if encrypter != nil {
+		out.FragmentStorer = encrypted.NewEncryptedFragmentStorer(out.FragmentStorer, encrypter)
+	}
 	return out, nil
 }
@@ -22,6 +22,7 @@ import (
 	"go.uber.org/fx"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var Module = fx.Options(
@@ -56,7 +57,31 @@ func ProvideJWTManager(in JWTIn) (JWTOut, error) {
 	if in.Config.GetString("ears.jwt.capabilityPrefixes") != "" {
 		capabilityPrefixes = strings.Split(in.Config.GetString("ears.jwt.capabilityPrefixes"), ",")
 	}
-	out.JWTManager, _ = jwt.NewJWTConsumer(publicKeyEndpoint, DefaultJWTVerifier, requireBearerToken, domain, component, adminClientIds, capabilityPrefixes, in.TenantStorer)
+	options := []jwt.Option{}
+	if in.Config.GetString("ears.jwt.oidc.issuer") != "" {
+		oidcConfig := jwt.OIDCConfig{
+			Issuer:             in.Config.GetString("ears.jwt.oidc.issuer"),
+			JwksUri:            in.Config.GetString("ears.jwt.oidc.jwksUri"),
+			ClockSkew:          time.Duration(in.Config.GetInt("ears.jwt.oidc.clockSkewSecs")) * time.Second,
+			KeyRefreshInterval: time.Duration(in.Config.GetInt("ears.jwt.oidc.keyRefreshSecs")) * time.Second,
+			ClientIdClaim:      in.Config.GetString("ears.jwt.oidc.clientIdClaim"),
+		}
+		for _, aud := range strings.Split(in.Config.GetString("ears.jwt.oidc.audience"), ",") {
+			if aud = strings.TrimSpace(aud); aud != "" {
+				oidcConfig.Audience = append(oidcConfig.Audience, aud)
+			}
+		}
+		options = append(options, jwt.WithOIDC(oidcConfig))
+	}
+	if in.Config.GetBool("ears.jwt.requireScopes") {
+		options = append(options, jwt.WithRequiredScopes())
+	}
+	var err error
+	out.JWTManager, err = jwt.NewJWTConsumer(publicKeyEndpoint, DefaultJWTVerifier, requireBearerToken, domain, component, adminClientIds, capabilityPrefixes, in.TenantStorer, options...)
+	if err != nil && len(options) > 0 {
+		// options are opt-in, so misconfiguring them must fail loudly rather than leaving the API unprotected
+		return out, err
+	}
 	return out, nil
 }
 
@@ -15,22 +15,32 @@
 package pluginmanagerfx
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
 	p "github.com/xmidt-org/ears/internal/pkg/plugin"
 	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"github.com/xmidt-org/ears/pkg/plugin/external"
 	"github.com/xmidt-org/ears/pkg/plugin/manager"
+	"github.com/xmidt-org/ears/pkg/plugins/annotate"
 	"github.com/xmidt-org/ears/pkg/plugins/batch"
 	"github.com/xmidt-org/ears/pkg/plugins/block"
+	"github.com/xmidt-org/ears/pkg/plugins/branch"
+	"github.com/xmidt-org/ears/pkg/plugins/cloudevents"
 	"github.com/xmidt-org/ears/pkg/plugins/debug"
 	"github.com/xmidt-org/ears/pkg/plugins/decode"
 	"github.com/xmidt-org/ears/pkg/plugins/dedup"
+	"github.com/xmidt-org/ears/pkg/plugins/defaults"
 	"github.com/xmidt-org/ears/pkg/plugins/discord"
 	"github.com/xmidt-org/ears/pkg/plugins/encode"
+	"github.com/xmidt-org/ears/pkg/plugins/enrich"
 	"github.com/xmidt-org/ears/pkg/plugins/gears"
 	"github.com/xmidt-org/ears/pkg/plugins/hash"
 	"github.com/xmidt-org/ears/pkg/plugins/http"
+	"github.com/xmidt-org/ears/pkg/plugins/inject"
 	"github.com/xmidt-org/ears/pkg/plugins/js"
 	"github.com/xmidt-org/ears/pkg/plugins/kafka"
 	"github.com/xmidt-org/ears/pkg/plugins/kinesis"
@@ -42,10 +52,14 @@ import (
 	"github.com/xmidt-org/ears/pkg/plugins/modify"
 	"github.com/xmidt-org/ears/pkg/plugins/nop"
 	"github.com/xmidt-org/ears/pkg/plugins/pass"
+	"github.com/xmidt-org/ears/pkg/plugins/project"
+	"github.com/xmidt-org/ears/pkg/plugins/ratelimit"
 	"github.com/xmidt-org/ears/pkg/plugins/redis"
 	"github.com/xmidt-org/ears/pkg/plugins/regex"
+	"github.com/xmidt-org/ears/pkg/plugins/rename"
 	"github.com/xmidt-org/ears/pkg/plugins/s3"
 	"github.com/xmidt-org/ears/pkg/plugins/sample"
+	"github.com/xmidt-org/ears/pkg/plugins/sftp"
 	"github.com/xmidt-org/ears/pkg/plugins/split"
 	"github.com/xmidt-org/ears/pkg/plugins/sqs"
 	"github.com/xmidt-org/ears/pkg/plugins/syslog"
@@ -53,7 +67,9 @@ import (
 	"github.com/xmidt-org/ears/pkg/plugins/transform"
 	"github.com/xmidt-org/ears/pkg/plugins/ttl"
 	"github.com/xmidt-org/ears/pkg/plugins/unwrap"
+	"github.com/xmidt-org/ears/pkg/plugins/useragent"
 	"github.com/xmidt-org/ears/pkg/plugins/validate"
+	"github.com/xmidt-org/ears/pkg/plugins/verify"
 	"github.com/xmidt-org/ears/pkg/plugins/ws"
 	"github.com/xmidt-org/ears/pkg/secret"
 
@@ -70,6 +86,8 @@ var Module = fx.Options(
 type PluginIn struct {
 	fx.In
 
+	Lifecycle    fx.Lifecycle
+	Config       config.Config
 	Logger       *zerolog.Logger
 	QuotaManager *quota.QuotaManager
 	Secrets      secret.Vault
@@ -232,6 +250,54 @@ func ProvidePluginManager(in PluginIn) (PluginOut, error) {
 			name:   "syslog",
 			plugin: toArr(syslog.NewPluginVersion("syslog", "", ""))[0].(pkgplugin.Pluginer),
 		},
+		{
+			name:   "sftp",
+			plugin: toArr(sftp.NewPluginVersion("sftp", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "verify",
+			plugin: toArr(verify.NewPluginVersion("verify", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "enrich",
+			plugin: toArr(enrich.NewPluginVersion("enrich", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "useragent",
+			plugin: toArr(useragent.NewPluginVersion("useragent", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "rename",
+			plugin: toArr(rename.NewPluginVersion("rename", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "project",
+			plugin: toArr(project.NewPluginVersion("project", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "defaults",
+			plugin: toArr(defaults.NewPluginVersion("defaults", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "branch",
+			plugin: toArr(branch.NewPluginVersion("branch", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "annotate",
+			plugin: toArr(annotate.NewPluginVersion("annotate", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "cloudevents",
+			plugin: toArr(cloudevents.NewPluginVersion("cloudevents", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "inject",
+			plugin: toArr(inject.NewPluginVersion("inject", "", ""))[0].(pkgplugin.Pluginer),
+		},
+		{
+			name:   "ratelimit",
+			plugin: toArr(ratelimit.NewPluginVersion("ratelimit", "", ""))[0].(pkgplugin.Pluginer),
+		},
 	}
 
 	for _, plug := range defaultPlugins {
@@ -241,6 +307,22 @@ func ProvidePluginManager(in PluginIn) (PluginOut, error) {
 		}
 	}
 
+	// Go plugins built with -buildmode=plugin are registered under their file name
+	if dir := in.Config.GetString("ears.plugins.directory"); dir != "" {
+		plugs, err := mgr.LoadPlugins(dir)
+		if err != nil {
+			return out, fmt.Errorf("could not load plugins from %s: %w", dir, err)
+		}
+		for _, plug := range plugs {
+			in.Logger.Info().Str("op", "ProvidePluginManager").Str("directory", dir).Str("plugin", plug.Name()).Str("version", plug.Version()).Msg("loaded plugin")
+		}
+	}
+
+	err = registerExternalPlugins(mgr, in)
+	if err != nil {
+		return out, err
+	}
+
 	m, err := p.NewManager(p.WithPluginManager(mgr),
 		p.WithLogger(in.Logger),
 		p.WithQuotaManager(in.QuotaManager),
@@ -254,3 +336,40 @@ func ProvidePluginManager(in PluginIn) (PluginOut, error) {
 	return out, nil
 
 }
+
+// registerExternalPlugins starts the plugin binaries listed in ears.plugins.external as name=path pairs separated
+// by commas and registers them under their name, the plugin processes are stopped with EARS
+func registerExternalPlugins(mgr manager.Manager, in PluginIn) error {
+	var plugins []*external.Plugin
+	in.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			for _, plug := range plugins {
+				plug.Stop()
+			}
+			return nil
+		},
+	})
+	for _, entry := range strings.Split(in.Config.GetString("ears.plugins.external"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("external plugin %s is not of the form name=path", entry)
+		}
+		plug, err := external.NewPlugin(external.Config{
+			Name: strings.TrimSpace(kv[0]),
+			Path: strings.TrimSpace(kv[1]),
+		}, in.Logger)
+		if err != nil {
+			return fmt.Errorf("could not start external plugin %s: %w", entry, err)
+		}
+		plugins = append(plugins, plug)
+		err = mgr.RegisterPlugin(plug.Name(), plug)
+		if err != nil {
+			return fmt.Errorf("could register %s plugin: %w", plug.Name(), err)
+		}
+	}
+	return nil
+}
@@ -18,7 +18,13 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/db/bolt"
 	"github.com/xmidt-org/ears/internal/pkg/db/dynamo"
+	"github.com/xmidt-org/ears/internal/pkg/db/encrypted"
+	"github.com/xmidt-org/ears/internal/pkg/db/etcd"
+	"github.com/xmidt-org/ears/internal/pkg/db/fs"
+	"github.com/xmidt-org/ears/internal/pkg/db/mongo"
+	"github.com/xmidt-org/ears/internal/pkg/db/postgres"
 	"github.com/xmidt-org/ears/internal/pkg/db/redis"
 	"github.com/xmidt-org/ears/pkg/route"
 	"go.uber.org/fx"
@@ -59,8 +65,45 @@ func ProvideRouteStorer(in StorageIn) (StorageOut, error) {
 			return out, err
 		}
 		out.RouteStorer = routeStorer
+	case "postgres":
+		routeStorer, err := postgres.NewPostgresRouteStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.RouteStorer = routeStorer
+	case "mongo":
+		routeStorer, err := mongo.NewMongoRouteStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.RouteStorer = routeStorer
+	case "bolt":
+		routeStorer, err := bolt.NewBoltRouteStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.RouteStorer = routeStorer
+	case "etcd":
+		routeStorer, err := etcd.NewEtcdRouteStorer(in.Config, in.Logger)
+		if err != nil {
+			return out, err
+		}
+		out.RouteStorer = routeStorer
+	case "fs":
+		routeStorer, err := fs.NewFsStorer(in.Config, "route", in.Logger)
+		if err != nil {
+			return out, err
+		}
+		out.RouteStorer = routeStorer
 	default:
 		return out, &UnsupportedRouteStorageError{storageType}
 	}
+	encrypter, err := encrypted.NewEncrypter(in.Config)
+	if err != nil {
+		return out, err
+	}
+	if encrypter != nil {
+		out.RouteStorer = encrypted.NewEncryptedRouteStorer(out.RouteStorer, encrypter)
+	}
 	return out, nil
 }
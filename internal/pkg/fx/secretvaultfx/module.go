@@ -0,0 +1,109 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secretvaultfx
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/appsecret"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"go.uber.org/fx"
+	"time"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		ProvideSecretVault,
+	),
+)
+
+type SecretVaultIn struct {
+	fx.In
+	Config config.Config
+	Logger *zerolog.Logger
+}
+
+type SecretVaultOut struct {
+	fx.Out
+	Vault secret.Vault
+}
+
+// ProvideSecretVault provides the secret vault selected by ears.secretProvider.type, secrets are taken from the
+// ears configuration unless a type is given
+func ProvideSecretVault(in SecretVaultIn) (SecretVaultOut, error) {
+	out := SecretVaultOut{}
+	providerType := in.Config.GetString("ears.secretProvider.type")
+	prefix := in.Config.GetString("ears.secretProvider.prefix")
+	refresh := time.Duration(in.Config.GetInt("ears.secretProvider.refreshFrequencySeconds")) * time.Second
+	switch providerType {
+	case "", "config":
+		out.Vault = appsecret.NewConfigVault(in.Config)
+	case "secretsmanager":
+		sess, err := newSession(in.Config)
+		if err != nil {
+			return out, err
+		}
+		if prefix == "" {
+			prefix = appsecret.DefaultSecretsManagerPrefix
+		}
+		out.Vault = appsecret.NewSecretsManagerVault(secretsmanager.New(sess), prefix, refresh, in.Logger)
+	case "ssm":
+		sess, err := newSession(in.Config)
+		if err != nil {
+			return out, err
+		}
+		if prefix == "" {
+			prefix = appsecret.DefaultParameterStorePrefix
+		}
+		out.Vault = appsecret.NewParameterStoreVault(ssm.New(sess), prefix, refresh, in.Logger)
+	default:
+		return out, &UnsupportedSecretProviderError{providerType}
+	}
+	return out, nil
+}
+
+func newSession(config config.Config) (*session.Session, error) {
+	return session.NewSession(&aws.Config{
+		Region: aws.String(config.GetString("ears.secretProvider.region")),
+	})
+}
+
+// SetupSecretVault periodically refreshes the cached secrets of vaults backed by an external secret store
+func SetupSecretVault(lifecycle fx.Lifecycle, logger *zerolog.Logger, vault secret.Vault) error {
+	awsVault, ok := vault.(*appsecret.AwsVault)
+	if !ok {
+		return nil
+	}
+	lifecycle.Append(
+		fx.Hook{
+			OnStart: func(context.Context) error {
+				awsVault.Start()
+				logger.Info().Msg("Secret Vault Refresh Started")
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				awsVault.Stop()
+				logger.Info().Msg("Secret Vault Refresh Stopped")
+				return nil
+			},
+		},
+	)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotfx
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/aws/s3"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.uber.org/fx"
+)
+
+var Module = fx.Options(
+	fx.Provide(
+		ProvideSnapshotManager,
+	),
+)
+
+type SnapshotManagerIn struct {
+	fx.In
+	Config         config.Config
+	RouteStorer    route.RouteStorer
+	FragmentStorer fragments.FragmentStorer
+	TenantStorer   tenant.TenantStorer
+	Logger         *zerolog.Logger
+}
+
+type SnapshotManagerOut struct {
+	fx.Out
+	SnapshotManager *snapshot.SnapshotManager
+}
+
+// ProvideSnapshotManager provides a nil snapshot manager if no snapshot location is configured
+func ProvideSnapshotManager(in SnapshotManagerIn) (SnapshotManagerOut, error) {
+	out := SnapshotManagerOut{}
+	if in.Config.GetString("ears.snapshot.url") == "" {
+		return out, nil
+	}
+	cfg := aws.NewConfig()
+	if region := in.Config.GetString("ears.snapshot.region"); region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+	store, err := s3.New(s3.WithConfig(cfg))
+	if err != nil {
+		return out, err
+	}
+	out.SnapshotManager, err = snapshot.NewSnapshotManager(in.Config, store, in.RouteStorer, in.FragmentStorer, in.TenantStorer, in.Logger)
+	return out, err
+}
+
+func SetupSnapshotManager(lifecycle fx.Lifecycle, logger *zerolog.Logger, snapshotManager *snapshot.SnapshotManager) error {
+	if snapshotManager == nil {
+		return nil
+	}
+	lifecycle.Append(
+		fx.Hook{
+			OnStart: func(context.Context) error {
+				snapshotManager.Start()
+				logger.Info().Msg("Snapshot Manager Started")
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				snapshotManager.Stop()
+				logger.Info().Msg("Snapshot Manager Stopped")
+				return nil
+			},
+		},
+	)
+	return nil
+}
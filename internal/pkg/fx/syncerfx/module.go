@@ -20,6 +20,8 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/internal/pkg/syncer/kafka"
+	"github.com/xmidt-org/ears/internal/pkg/syncer/mongo"
 	"github.com/xmidt-org/ears/internal/pkg/syncer/redis"
 	"go.uber.org/fx"
 )
@@ -49,6 +51,18 @@ func ProvideRouteTableSyncer(in TableSyncerIn) (TableSyncerOut, error) {
 		out.RoutingTableDeltaSyncer = syncer.NewInMemoryDeltaSyncer(in.Logger, in.Config)
 	case "redis":
 		out.RoutingTableDeltaSyncer = redis.NewRedisDeltaSyncer(in.Logger, in.Config)
+	case "mongo":
+		deltaSyncer, err := mongo.NewMongoDeltaSyncer(in.Logger, in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.RoutingTableDeltaSyncer = deltaSyncer
+	case "kafka":
+		deltaSyncer, err := kafka.NewKafkaDeltaSyncer(in.Logger, in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.RoutingTableDeltaSyncer = deltaSyncer
 	default:
 		return out, errors.New("unsupported table syncer type " + tableSyncerType)
 	}
@@ -18,7 +18,11 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/db/bolt"
 	"github.com/xmidt-org/ears/internal/pkg/db/dynamo"
+	"github.com/xmidt-org/ears/internal/pkg/db/fs"
+	"github.com/xmidt-org/ears/internal/pkg/db/mongo"
+	"github.com/xmidt-org/ears/internal/pkg/db/postgres"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.uber.org/fx"
 )
@@ -52,6 +56,30 @@ func ProvideTenantStorer(in StorageIn) (StorageOut, error) {
 			return out, err
 		}
 		out.TenantStorer = tenantStorer
+	case "postgres":
+		tenantStorer, err := postgres.NewPostgresTenantStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.TenantStorer = tenantStorer
+	case "mongo":
+		tenantStorer, err := mongo.NewMongoTenantStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.TenantStorer = tenantStorer
+	case "bolt":
+		tenantStorer, err := bolt.NewBoltTenantStorer(in.Config)
+		if err != nil {
+			return out, err
+		}
+		out.TenantStorer = tenantStorer
+	case "fs":
+		tenantStorer, err := fs.NewFsStorer(in.Config, "tenant", in.Logger)
+		if err != nil {
+			return out, err
+		}
+		out.TenantStorer = tenantStorer
 	default:
 		return out, &UnsupportedTenantStorageError{storageType}
 	}
@@ -0,0 +1,197 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql derives GraphQL object types from the Go types EARS serves as JSON so that the GraphQL schema
+// follows the REST API without being maintained by hand. Values are resolved from their generic JSON form, maps,
+// slices and scalars, field by field using the JSON names of the struct fields.
+package graphql
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// JSON is a scalar for values without a fixed shape, such as plugin configs and labels, they are returned whole
+var JSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Arbitrary JSON value",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: parseLiteral,
+})
+
+func parseLiteral(value ast.Value) interface{} {
+	switch v := value.(type) {
+	case *ast.StringValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.IntValue:
+		i, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return i
+	case *ast.FloatValue:
+		f, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	case *ast.ListValue:
+		list := make([]interface{}, 0, len(v.Values))
+		for _, e := range v.Values {
+			list = append(list, parseLiteral(e))
+		}
+		return list
+	case *ast.ObjectValue:
+		obj := make(map[string]interface{}, len(v.Fields))
+		for _, f := range v.Fields {
+			obj[f.Name.Value] = parseLiteral(f.Value)
+		}
+		return obj
+	}
+	return nil
+}
+
+var nameRegex = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Types builds object types from Go struct types, each struct type becomes a single object type shared by all
+// fields referring to it
+type Types struct {
+	objects map[reflect.Type]*graphql.Object
+	names   map[string]reflect.Type
+}
+
+func NewTypes() *Types {
+	return &Types{
+		objects: make(map[reflect.Type]*graphql.Object),
+		names:   make(map[string]reflect.Type),
+	}
+}
+
+// Object returns an object type with the given name for the struct type of the sample value, extended by extra
+// fields that are not part of the JSON form of the struct, e.g. fields with their own resolvers
+func (t *Types) Object(name string, sample interface{}, extra graphql.Fields) *graphql.Object {
+	typ := reflect.TypeOf(sample)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	fields := t.fields(typ)
+	for fieldName, f := range extra {
+		fields[fieldName] = f
+	}
+	t.names[name] = typ
+	return graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: fields})
+}
+
+// Output returns the output type of a Go type, structs become objects, slices and arrays become lists, booleans,
+// strings and numbers become the matching scalars and everything else, including maps, becomes JSON
+func (t *Types) Output(typ reflect.Type) graphql.Output {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Implements(jsonMarshaler) || reflect.PtrTo(typ).Implements(jsonMarshaler) {
+		return JSON
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return graphql.Boolean
+	case reflect.String:
+		return graphql.String
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return graphql.Int
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		// GraphQL integers are 32 bit, timestamps in milliseconds and counters do not fit
+		return graphql.Float
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// byte slices are base64 strings in JSON
+			return graphql.String
+		}
+		return graphql.NewList(t.Output(typ.Elem()))
+	case reflect.Struct:
+		return t.object(typ)
+	}
+	return JSON
+}
+
+func (t *Types) object(typ reflect.Type) *graphql.Object {
+	if obj, ok := t.objects[typ]; ok {
+		return obj
+	}
+	name := typ.Name()
+	if other, ok := t.names[name]; name == "" || (ok && other != typ) {
+		pkg := typ.PkgPath()[strings.LastIndex(typ.PkgPath(), "/")+1:]
+		name = strings.Title(pkg) + name
+	}
+	t.names[name] = typ
+	obj := graphql.NewObject(graphql.ObjectConfig{Name: name, Fields: t.fields(typ)})
+	t.objects[typ] = obj
+	return obj
+}
+
+// fields returns the fields of a struct type named like in its JSON form, embedded structs are flattened
+func (t *Types) fields(typ reflect.Type) graphql.Fields {
+	fields := make(graphql.Fields)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for fieldName, f := range t.fields(embedded) {
+					if _, ok := fields[fieldName]; !ok {
+						fields[fieldName] = f
+					}
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if !nameRegex.MatchString(name) {
+			continue
+		}
+		fields[name] = &graphql.Field{Type: t.Output(sf.Type)}
+	}
+	return fields
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+type testPlugin struct {
+	Plugin string      `json:"plugin,omitempty"`
+	Config interface{} `json:"config,omitempty"`
+}
+
+type testRoute struct {
+	Id       string            `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Receiver testPlugin        `json:"receiver"`
+	Branches []testPlugin      `json:"branches,omitempty"`
+	Created  int64             `json:"created,omitempty"`
+	Secret   string            `json:"-"`
+	internal string
+}
+
+func testSchema(t *testing.T, data string) graphql.Schema {
+	var routes interface{}
+	err := json.Unmarshal([]byte(data), &routes)
+	if err != nil {
+		t.Fatalf("cannot unmarshal data: %s", err.Error())
+	}
+	types := NewTypes()
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"routes": &graphql.Field{
+				Type: graphql.NewList(types.Object("Route", testRoute{}, graphql.Fields{"extra": &graphql.Field{Type: graphql.String}})),
+				Args: graphql.FieldConfigArgument{"filter": &graphql.ArgumentConfig{Type: JSON}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if p.Args["filter"] != nil {
+						return []interface{}{p.Args["filter"]}, nil
+					}
+					return routes, nil
+				},
+			},
+		},
+	})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		t.Fatalf("cannot build schema: %s", err.Error())
+	}
+	return schema
+}
+
+func TestTypes(t *testing.T) {
+	schema := testSchema(t, `[{"id": "r1", "name": "one", "labels": {"team": "xfi"}, "receiver": {"plugin": "debug", "config": {"rounds": 5}}, "created": 1634567890123}, {"id": "r2"}]`)
+	route := schema.Type("Route").(*graphql.Object).Fields()
+	for _, name := range []string{"id", "name", "labels", "receiver", "branches", "created", "extra"} {
+		if _, ok := route[name]; !ok {
+			t.Fatalf("missing field %s", name)
+		}
+	}
+	if len(route) != 7 {
+		t.Fatalf("unexpected fields %v", route)
+	}
+	if route["labels"].Type != JSON || route["created"].Type != graphql.Float || route["receiver"].Type.Name() != "testPlugin" {
+		t.Fatalf("unexpected field types %v", route)
+	}
+	result := graphql.Do(graphql.Params{
+		Schema: schema,
+		RequestString: `
+			query Routes {
+				routes { ...routeFields __typename receiver { config } }
+			}
+			fragment routeFields on Route { id routeName: name labels created }`,
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	buf, _ := json.Marshal(result.Data)
+	var actual, expected interface{}
+	json.Unmarshal(buf, &actual)
+	json.Unmarshal([]byte(`{"routes": [
+		{"id": "r1", "routeName": "one", "labels": {"team": "xfi"}, "created": 1634567890123, "__typename": "Route", "receiver": {"config": {"rounds": 5}}},
+		{"id": "r2", "routeName": null, "labels": null, "created": null, "__typename": "Route", "receiver": null}
+	]}`), &expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected result %s", string(buf))
+	}
+}
+
+func TestJSONLiteral(t *testing.T) {
+	schema := testSchema(t, `[]`)
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `{ routes(filter: {id: "r1", labels: {team: "xfi"}, created: 5, branches: [true, 1.5, ENUM]}) { id labels created } }`,
+	})
+	if result.HasErrors() {
+		t.Fatalf("unexpected errors %v", result.Errors)
+	}
+	buf, _ := json.Marshal(result.Data)
+	if string(buf) != `{"routes":[{"created":5,"id":"r1","labels":{"team":"xfi"}}]}` {
+		t.Fatalf("unexpected result %s", string(buf))
+	}
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ routes { id { foo } } }`})
+	if !result.HasErrors() {
+		t.Fatalf("expected error selecting fields of scalar")
+	}
+}
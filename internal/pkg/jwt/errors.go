@@ -12,6 +12,13 @@ const (
 	MissingClientId        = "missing jwt client id"
 	MissingTenantId        = "missing tenant id"
 	UnauthorizedClientId   = "unauthorized jwt client id"
+	InsufficientRole       = "insufficient role"
+	MissingScopes          = "missing jwt scopes"
+	NoMatchingScopes       = "no matching jwt scopes"
+	InvalidIssuer          = "invalid jwt issuer"
+	InvalidAudience        = "invalid jwt audience"
+	TokenExpired           = "jwt expired"
+	TokenNotValidYet       = "jwt not valid yet"
 	UnauthorizedPartnerId  = "unauthorized jwt partner id"
 	NoAllowedPartners      = "no allowed partners"
 	InvalidSATFormat       = "invalid sat format"
@@ -21,18 +21,20 @@ func (nfe *UnauthorizedError) Error() string {
 	return nfe.Msg
 }
 
-func NewJWTConsumer(publicKeyEndpoint string, verifier Verifier, requireBearerToken bool, domain string, component string, adminClientIds []string, capabilityPrefixes []string, tenantStorer tenant.TenantStorer) (JWTConsumer, error) {
-	if requireBearerToken {
-		if publicKeyEndpoint == "" {
-			return nil, errors.New("missing public key endpoint for jwt")
-		}
-		if verifier == nil {
-			return nil, errors.New("missing jwt verifier")
-		}
-		if domain == "" || component == "" {
-			return nil, errors.New("missing jwt domain or component")
+// WithOIDC additionally accepts tokens of a standard OAuth2 / OIDC issuer, SAT verification is only required
+// if a public key endpoint is configured
+func WithOIDC(config OIDCConfig) Option {
+	return func(sc *DefaultJWTConsumer) error {
+		ov, err := newOIDCVerifier(config)
+		if err != nil {
+			return err
 		}
+		sc.oidc = ov
+		return nil
 	}
+}
+
+func NewJWTConsumer(publicKeyEndpoint string, verifier Verifier, requireBearerToken bool, domain string, component string, adminClientIds []string, capabilityPrefixes []string, tenantStorer tenant.TenantStorer, options ...Option) (JWTConsumer, error) {
 	sc := DefaultJWTConsumer{
 		publicKeyEndpoint:  publicKeyEndpoint,
 		verifier:           verifier,
@@ -47,6 +49,23 @@ func NewJWTConsumer(publicKeyEndpoint string, verifier Verifier, requireBearerTo
 			Timeout: 30 * time.Second,
 		},
 	}
+	for _, option := range options {
+		err := option(&sc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if requireBearerToken && (sc.oidc == nil || publicKeyEndpoint != "") {
+		if publicKeyEndpoint == "" {
+			return nil, errors.New("missing public key endpoint for jwt")
+		}
+		if verifier == nil {
+			return nil, errors.New("missing jwt verifier")
+		}
+		if domain == "" || component == "" {
+			return nil, errors.New("missing jwt domain or component")
+		}
+	}
 	return &sc, nil
 }
 
@@ -128,7 +147,21 @@ func (sc *DefaultJWTConsumer) VerifyToken(ctx context.Context, token string, api
 	if token == "" {
 		return nil, "", &UnauthorizedError{MissingToken}
 	}
-	partners, sub, capabilities, err := sc.extractToken(token)
+	var (
+		partners     []string
+		sub          string
+		capabilities []string
+		claims       jwt.MapClaims
+		err          error
+	)
+	oidcToken := sc.oidc != nil && sc.oidc.issued(token)
+	if oidcToken {
+		sub, claims, err = sc.oidc.verify(ctx, token)
+	} else if sc.oidc != nil && sc.publicKeyEndpoint == "" {
+		err = &UnauthorizedError{InvalidIssuer}
+	} else {
+		partners, sub, capabilities, claims, err = sc.extractToken(token)
+	}
 	if nil != err {
 		return nil, "", err
 	}
@@ -136,6 +169,11 @@ func (sc *DefaultJWTConsumer) VerifyToken(ctx context.Context, token string, api
 	if "" == sub {
 		return nil, "", &UnauthorizedError{MissingClientId}
 	}
+	// scopes may limit any caller, including admin clients, to specific tenants and operations
+	err = sc.verifyScopes(claims, api, method, tid)
+	if err != nil {
+		return nil, "", err
+	}
 	// check if this is an admin client
 	if 0 < len(sc.adminClientIds) {
 		for _, clientId := range sc.adminClientIds {
@@ -176,6 +214,16 @@ func (sc *DefaultJWTConsumer) VerifyToken(ctx context.Context, token string, api
 			return nil, "", &UnauthorizedError{UnauthorizedPartnerId}
 		}
 	}
+	// verify role if the tenant maps claims to roles
+	if len(tenantConfig.RoleMappings) > 0 {
+		if !RoleIncludes(MappedRole(claims, tenantConfig.RoleMappings), RequiredRole(api, method)) {
+			return nil, "", &ForbiddenError{InsufficientRole}
+		}
+	}
+	// capabilities are specific to SAT tokens
+	if oidcToken {
+		return partners, sub, nil
+	}
 	// verify capabilities
 	for _, cap := range capabilities {
 		if sc.isValid(api, method, cap) {
@@ -186,9 +234,9 @@ func (sc *DefaultJWTConsumer) VerifyToken(ctx context.Context, token string, api
 }
 
 // extractToken returns array of partner strings (from claims.allowedResources.allowedPartners),
-// subject string (aka clientId), an array of capabilities and finally an error which is nil if the
+// subject string (aka clientId), an array of capabilities, all claims and finally an error which is nil if the
 // token is valid
-func (sc *DefaultJWTConsumer) extractToken(token string) ([]string, string, []string, error) {
+func (sc *DefaultJWTConsumer) extractToken(token string) ([]string, string, []string, jwt.MapClaims, error) {
 	var (
 		sat *jwt.Token
 		err error
@@ -207,21 +255,21 @@ func (sc *DefaultJWTConsumer) extractToken(token string) ([]string, string, []st
 		if errors.As(err, &ve) {
 			var veInner *UnauthorizedError
 			if errors.As(ve.Inner, &veInner) {
-				return nil, "", nil, veInner
+				return nil, "", nil, nil, veInner
 				// token expired or not valid yet
 			} else {
-				return nil, "", nil, &UnauthorizedError{ve.Error()}
+				return nil, "", nil, nil, &UnauthorizedError{ve.Error()}
 			}
 		}
 		// internal error
-		return nil, "", nil, err
+		return nil, "", nil, nil, err
 	}
 	if !sat.Valid {
-		return nil, "", nil, &UnauthorizedError{InvalidSignature}
+		return nil, "", nil, nil, &UnauthorizedError{InvalidSignature}
 	}
 	claims, ok := sat.Claims.(jwt.MapClaims)
 	if !ok {
-		return nil, "", nil, &UnauthorizedError{InvalidSATFormat}
+		return nil, "", nil, nil, &UnauthorizedError{InvalidSATFormat}
 	}
 	sub, _ := claims["sub"].(string)
 	// get partners
@@ -236,11 +284,11 @@ func (sc *DefaultJWTConsumer) extractToken(token string) ([]string, string, []st
 		}
 	}
 	if len(partners) == 0 {
-		return nil, "", nil, &UnauthorizedError{NoAllowedPartners}
+		return nil, "", nil, nil, &UnauthorizedError{NoAllowedPartners}
 	}
 	os, ok := claims[Capabilities].([]interface{})
 	if !ok {
-		return nil, "", nil, &UnauthorizedError{MissingCapabilities}
+		return nil, "", nil, nil, &UnauthorizedError{MissingCapabilities}
 	}
 	caps := make([]string, 0, len(os))
 	for _, o := range os {
@@ -248,7 +296,7 @@ func (sc *DefaultJWTConsumer) extractToken(token string) ([]string, string, []st
 			caps = append(caps, s)
 		}
 	}
-	return partners, sub, caps, nil
+	return partners, sub, caps, claims, nil
 }
 
 // isValid verifies capability in two formats:
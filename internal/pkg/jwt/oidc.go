@@ -0,0 +1,332 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+)
+
+const (
+	DefaultClockSkew          = 60 * time.Second
+	DefaultKeyRefreshInterval = time.Hour
+	// unknown key ids trigger a key refresh at most this often
+	MinKeyRefreshInterval = 30 * time.Second
+	DefaultClientIdClaim  = "sub"
+)
+
+// OIDCConfig configures verification of tokens issued by a standard OAuth2 / OIDC issuer, for example tokens
+// obtained with the client credentials grant
+type OIDCConfig struct {
+	Issuer             string        // issuer url, must match the iss claim of tokens
+	JwksUri            string        // optional, discovered from the issuer's openid configuration if blank
+	Audience           []string      // required, the aud claim must contain one of these audiences
+	ClockSkew          time.Duration // tolerance when checking exp, nbf and iat claims
+	KeyRefreshInterval time.Duration // how often signing keys are reloaded from the jwks uri
+	ClientIdClaim      string        // claim holding the client id, e.g. sub, azp or client_id
+}
+
+type oidcVerifier struct {
+	sync.RWMutex
+	config      OIDCConfig
+	client      *http.Client
+	jwksUri     string
+	keys        map[string]interface{}
+	lastRefresh time.Time
+	refreshing  chan struct{} // closed once the key refresh in flight is done, nil if there is none
+	refreshErr  error         // outcome of the last key refresh
+}
+
+func newOIDCVerifier(config OIDCConfig) (*oidcVerifier, error) {
+	if config.Issuer == "" {
+		return nil, errors.New("missing oidc issuer")
+	}
+	// without an audience tokens the issuer grants to any other service would be accepted
+	if len(config.Audience) == 0 {
+		return nil, errors.New("missing oidc audience")
+	}
+	if config.ClockSkew <= 0 {
+		config.ClockSkew = DefaultClockSkew
+	}
+	if config.KeyRefreshInterval <= 0 {
+		config.KeyRefreshInterval = DefaultKeyRefreshInterval
+	}
+	if config.ClientIdClaim == "" {
+		config.ClientIdClaim = DefaultClientIdClaim
+	}
+	return &oidcVerifier{
+		config:  config,
+		jwksUri: config.JwksUri,
+		keys:    make(map[string]interface{}),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}, nil
+}
+
+// issued returns true if the unverified iss claim of a token is the configured issuer
+func (ov *oidcVerifier) issued(token string) bool {
+	claims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(token, claims)
+	if err != nil {
+		return false
+	}
+	iss, _ := claims["iss"].(string)
+	return strings.TrimSuffix(iss, "/") == strings.TrimSuffix(ov.config.Issuer, "/")
+}
+
+func (ov *oidcVerifier) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ov.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code %d from %s", resp.StatusCode, url)
+	}
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, v)
+}
+
+// fetchKeys loads the signing keys of the issuer, discovering the jwks uri first if it is blank, and returns the jwks
+// uri along with the keys
+func (ov *oidcVerifier) fetchKeys(ctx context.Context, jwksUri string) (string, map[string]interface{}, error) {
+	if jwksUri == "" {
+		var discovery struct {
+			JwksUri string `json:"jwks_uri"`
+		}
+		err := ov.getJSON(ctx, strings.TrimSuffix(ov.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+		if err != nil {
+			return "", nil, err
+		}
+		if discovery.JwksUri == "" {
+			return "", nil, errors.New("missing jwks_uri in openid configuration of " + ov.config.Issuer)
+		}
+		jwksUri = discovery.JwksUri
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := ov.getJSON(ctx, jwksUri, &jwks)
+	if err != nil {
+		return jwksUri, nil, err
+	}
+	keys := make(map[string]interface{})
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// skip key types we cannot use rather than failing all tokens
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return jwksUri, keys, nil
+}
+
+// refreshKeys reloads the signing keys of the issuer without holding the lock during the http calls, callers
+// arriving while a refresh is in flight wait for its outcome instead of starting another one
+func (ov *oidcVerifier) refreshKeys(ctx context.Context) error {
+	ov.Lock()
+	if done := ov.refreshing; done != nil {
+		ov.Unlock()
+		return ov.awaitRefresh(ctx, done)
+	}
+	done := make(chan struct{})
+	ov.refreshing = done
+	ov.lastRefresh = time.Now()
+	jwksUri := ov.jwksUri
+	ov.Unlock()
+	jwksUri, keys, err := ov.fetchKeys(ctx, jwksUri)
+	ov.Lock()
+	if jwksUri != "" {
+		ov.jwksUri = jwksUri
+	}
+	if err == nil {
+		ov.keys = keys
+	}
+	ov.refreshErr = err
+	ov.refreshing = nil
+	ov.Unlock()
+	close(done)
+	return err
+}
+
+// awaitRefresh waits for the key refresh in flight and returns its outcome
+func (ov *oidcVerifier) awaitRefresh(ctx context.Context, done chan struct{}) error {
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	ov.RLock()
+	defer ov.RUnlock()
+	return ov.refreshErr
+}
+
+// getKey returns the signing key for a key id, keys are reloaded periodically as well as for unknown
+// key ids to pick up rotated keys
+func (ov *oidcVerifier) getKey(ctx context.Context, kid string) (interface{}, error) {
+	ov.RLock()
+	key, has := ov.keys[kid]
+	sinceRefresh := time.Since(ov.lastRefresh)
+	refreshing := ov.refreshing
+	ov.RUnlock()
+	var err error
+	if refreshing == nil && (sinceRefresh > ov.config.KeyRefreshInterval || (!has && sinceRefresh > MinKeyRefreshInterval)) {
+		err = ov.refreshKeys(ctx)
+	} else if refreshing != nil && !has {
+		// the key may be among the keys being loaded
+		err = ov.awaitRefresh(ctx, refreshing)
+	} else if has {
+		return key, nil
+	}
+	ov.RLock()
+	key, has = ov.keys[kid]
+	noKeys := len(ov.keys) == 0
+	ov.RUnlock()
+	if err != nil && noKeys {
+		return nil, err
+	}
+	if !has {
+		return nil, &UnauthorizedError{InvalidKid}
+	}
+	return key, nil
+}
+
+// verify validates signature, issuer, audience and time claims of a token and returns client id and claims
+func (ov *oidcVerifier) verify(ctx context.Context, token string) (string, jwt.MapClaims, error) {
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}, SkipClaimsValidation: true}
+	sat, err := parser.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, &UnauthorizedError{MissingKid}
+		}
+		return ov.getKey(ctx, kid)
+	})
+	if err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) {
+			var veInner *UnauthorizedError
+			if errors.As(ve.Inner, &veInner) {
+				return "", nil, veInner
+			}
+			return "", nil, &UnauthorizedError{ve.Error()}
+		}
+		return "", nil, err
+	}
+	claims, ok := sat.Claims.(jwt.MapClaims)
+	if !sat.Valid || !ok {
+		return "", nil, &UnauthorizedError{InvalidSignature}
+	}
+	if !ov.issued(token) {
+		return "", nil, &UnauthorizedError{InvalidIssuer}
+	}
+	if !containsAny(claimValues(claims["aud"]), ov.config.Audience) {
+		return "", nil, &UnauthorizedError{InvalidAudience}
+	}
+	now := time.Now()
+	skew := ov.config.ClockSkew
+	if exp, ok := numericClaim(claims, "exp"); !ok || now.After(exp.Add(skew)) {
+		return "", nil, &UnauthorizedError{TokenExpired}
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Before(nbf.Add(-skew)) {
+		return "", nil, &UnauthorizedError{TokenNotValidYet}
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok && now.Before(iat.Add(-skew)) {
+		return "", nil, &UnauthorizedError{TokenNotValidYet}
+	}
+	clientId, _ := claims[ov.config.ClientIdClaim].(string)
+	return clientId, claims, nil
+}
+
+func numericClaim(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		return time.Unix(n, 0), err == nil
+	}
+	return time.Time{}, false
+}
+
+func containsAny(values []string, candidates []string) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (jwk *jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		nb, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		eb, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(new(big.Int).SetBytes(eb).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("unsupported curve " + jwk.Crv)
+		}
+		xb, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		yb, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xb), Y: new(big.Int).SetBytes(yb)}, nil
+	}
+	return nil, errors.New("unsupported key type " + jwk.Kty)
+}
@@ -0,0 +1,180 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+type testIssuer struct {
+	server      *httptest.Server
+	keys        map[string]*rsa.PrivateKey
+	keyRequests int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	ti := &testIssuer{keys: make(map[string]*rsa.PrivateKey)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": ti.server.URL, "jwks_uri": ti.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ti.keyRequests, 1)
+		time.Sleep(50 * time.Millisecond)
+		keys := make([]map[string]string, 0)
+		for kid, key := range ti.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	ti.server = httptest.NewServer(mux)
+	ti.addKey(t, "key1")
+	return ti
+}
+
+func (ti *testIssuer) addKey(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate key: %s", err.Error())
+	}
+	ti.keys[kid] = key
+}
+
+func (ti *testIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(ti.keys[kid])
+	if err != nil {
+		t.Fatalf("cannot sign token: %s", err.Error())
+	}
+	return signed
+}
+
+func TestOIDCVerifyToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	defer issuer.server.Close()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	tenantStorer := db.NewTenantInmemoryStorer()
+	tenantStorer.SetConfig(ctx, tenant.Config{Tenant: tid, ClientIds: []string{"myclient"}})
+	consumer, err := NewJWTConsumer("", nil, true, "", "", []string{"adminclient"}, nil, tenantStorer, WithOIDC(OIDCConfig{
+		Issuer:    issuer.server.URL,
+		Audience:  []string{"ears"},
+		ClockSkew: 30 * time.Second,
+	}))
+	if err != nil {
+		t.Fatalf("cannot create jwt consumer: %s", err.Error())
+	}
+	now := time.Now().Unix()
+	claims := func(sub string, aud interface{}, exp int64) jwt.MapClaims {
+		return jwt.MapClaims{"iss": issuer.server.URL, "sub": sub, "aud": aud, "exp": exp, "iat": now}
+	}
+	testCases := []struct {
+		name   string
+		kid    string
+		claims jwt.MapClaims
+		tid    *tenant.Id
+		err    string
+	}{
+		{"valid", "key1", claims("myclient", "ears", now+60), &tid, ""},
+		{"audienceList", "key1", claims("myclient", []string{"other", "ears"}, now+60), &tid, ""},
+		{"admin", "key1", claims("adminclient", "ears", now+60), nil, ""},
+		{"withinSkew", "key1", claims("myclient", "ears", now-10), &tid, ""},
+		{"expired", "key1", claims("myclient", "ears", now-60), &tid, TokenExpired},
+		{"wrongAudience", "key1", claims("myclient", "other", now+60), &tid, InvalidAudience},
+		{"unknownClient", "key1", claims("otherclient", "ears", now+60), &tid, UnauthorizedClientId},
+		{"wrongIssuer", "key1", jwt.MapClaims{"iss": "https://other.example.com", "sub": "myclient", "aud": "ears", "exp": now + 60}, &tid, InvalidIssuer},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, sub, err := consumer.VerifyToken(ctx, issuer.token(t, tc.kid, tc.claims), "/ears/v1/orgs/myorg/applications/myapp/routes", http.MethodGet, tc.tid)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err.Error())
+				}
+				if sub != tc.claims["sub"] {
+					t.Fatalf("unexpected subject %s", sub)
+				}
+				return
+			}
+			var ue *UnauthorizedError
+			if !errors.As(err, &ue) || ue.Msg != tc.err {
+				t.Fatalf("expected error %s but got %v", tc.err, err)
+			}
+		})
+	}
+	// rotated keys are picked up once the minimum refresh interval has passed
+	issuer.addKey(t, "key2")
+	token := issuer.token(t, "key2", claims("myclient", "ears", now+60))
+	_, _, err = consumer.VerifyToken(ctx, token, "/ears/v1/orgs/myorg/applications/myapp/routes", http.MethodGet, &tid)
+	var ue *UnauthorizedError
+	if !errors.As(err, &ue) || ue.Msg != InvalidKid {
+		t.Fatalf("expected error %s but got %v", InvalidKid, err)
+	}
+	consumer.(*DefaultJWTConsumer).oidc.lastRefresh = time.Now().Add(-MinKeyRefreshInterval)
+	_, _, err = consumer.VerifyToken(ctx, token, "/ears/v1/orgs/myorg/applications/myapp/routes", http.MethodGet, &tid)
+	if err != nil {
+		t.Fatalf("unexpected error after key rotation: %s", err.Error())
+	}
+}
+
+func TestOIDCKeyRefresh(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	defer issuer.server.Close()
+	_, err := NewJWTConsumer("", nil, true, "", "", []string{"adminclient"}, nil, nil, WithOIDC(OIDCConfig{
+		Issuer: issuer.server.URL,
+	}))
+	if err == nil {
+		t.Fatalf("expected error for missing audience")
+	}
+	consumer, err := NewJWTConsumer("", nil, true, "", "", []string{"adminclient"}, nil, nil, WithOIDC(OIDCConfig{
+		Issuer:   issuer.server.URL,
+		Audience: []string{"ears"},
+	}))
+	if err != nil {
+		t.Fatalf("cannot create jwt consumer: %s", err.Error())
+	}
+	token := issuer.token(t, "key1", jwt.MapClaims{"iss": issuer.server.URL, "sub": "adminclient", "aud": "ears", "exp": time.Now().Unix() + 60})
+	// concurrent verifications share a single key refresh
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := consumer.VerifyToken(ctx, token, "/ears/v1/routes", http.MethodGet, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err.Error())
+		}
+	}
+	if n := atomic.LoadInt32(&issuer.keyRequests); n != 1 {
+		t.Fatalf("expected one key request but got %d", n)
+	}
+}
@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"strings"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var roleRanks = map[string]int{
+	tenant.ROLE_READER: 1,
+	tenant.ROLE_WRITER: 2,
+	tenant.ROLE_ADMIN:  3,
+}
+
+// RequiredRole returns the role needed to call an api with a given method, reads require the reader role,
+// changes to the tenant config the admin role and all other changes, including sending events, the writer role
+func RequiredRole(api string, method string) string {
+	if ScopeAction(api, method) == ScopeActionRead {
+		return tenant.ROLE_READER
+	}
+	if strings.HasSuffix(strings.TrimSuffix(api, "/"), "/config") {
+		return tenant.ROLE_ADMIN
+	}
+	return tenant.ROLE_WRITER
+}
+
+// RoleIncludes returns true if role grants at least the permissions of the required role
+func RoleIncludes(role string, required string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[required]
+}
+
+// claimValues returns the values of a string or string array claim, string claims are split on spaces
+func claimValues(claim interface{}) []string {
+	switch c := claim.(type) {
+	case string:
+		return strings.Fields(c)
+	case []interface{}:
+		values := make([]string, 0, len(c))
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return c
+	}
+	return nil
+}
+
+// MappedRole returns the highest role the role mappings grant for the given claims or blank if none applies
+func MappedRole(claims map[string]interface{}, mappings []tenant.RoleMapping) string {
+	role := ""
+	for _, m := range mappings {
+		if roleRanks[m.Role] <= roleRanks[role] {
+			continue
+		}
+		for _, v := range claimValues(claims[m.Claim]) {
+			if v == m.Value {
+				role = m.Role
+				break
+			}
+		}
+	}
+	return role
+}
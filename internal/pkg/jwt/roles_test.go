@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestRequiredRole(t *testing.T) {
+	testCases := []struct {
+		api    string
+		method string
+		role   string
+	}{
+		{"/ears/v1/orgs/myorg/applications/myapp/routes", http.MethodGet, tenant.ROLE_READER},
+		{"/ears/v1/orgs/myorg/applications/myapp/routes/r1", http.MethodPut, tenant.ROLE_WRITER},
+		{"/ears/v1/orgs/myorg/applications/myapp/routes/r1/event", http.MethodPost, tenant.ROLE_WRITER},
+		{"/ears/v1/orgs/myorg/applications/myapp/config", http.MethodGet, tenant.ROLE_READER},
+		{"/ears/v1/orgs/myorg/applications/myapp/config", http.MethodPut, tenant.ROLE_ADMIN},
+		{"/ears/v1/orgs/myorg/applications/myapp/config", http.MethodDelete, tenant.ROLE_ADMIN},
+	}
+	for _, tc := range testCases {
+		if role := RequiredRole(tc.api, tc.method); role != tc.role {
+			t.Fatalf("%s %s: expected role %s but got %s", tc.method, tc.api, tc.role, role)
+		}
+	}
+}
+
+func TestMappedRole(t *testing.T) {
+	mappings := []tenant.RoleMapping{
+		{Claim: "groups", Value: "ears-readers", Role: tenant.ROLE_READER},
+		{Claim: "scope", Value: "ears:write", Role: tenant.ROLE_WRITER},
+		{Claim: "sub", Value: "ops-client", Role: tenant.ROLE_ADMIN},
+	}
+	testCases := []struct {
+		name   string
+		claims map[string]interface{}
+		role   string
+	}{
+		{"none", map[string]interface{}{"sub": "other"}, ""},
+		{"reader", map[string]interface{}{"groups": []interface{}{"x", "ears-readers"}}, tenant.ROLE_READER},
+		{"writer", map[string]interface{}{"groups": []interface{}{"ears-readers"}, "scope": "openid ears:write"}, tenant.ROLE_WRITER},
+		{"admin", map[string]interface{}{"sub": "ops-client", "scope": "ears:write"}, tenant.ROLE_ADMIN},
+	}
+	for _, tc := range testCases {
+		if role := MappedRole(tc.claims, mappings); role != tc.role {
+			t.Fatalf("%s: expected role %s but got %s", tc.name, tc.role, role)
+		}
+	}
+	if !RoleIncludes(tenant.ROLE_ADMIN, tenant.ROLE_READER) || RoleIncludes(tenant.ROLE_READER, tenant.ROLE_WRITER) || RoleIncludes("", tenant.ROLE_READER) {
+		t.Fatalf("unexpected role hierarchy")
+	}
+}
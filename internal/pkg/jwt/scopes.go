@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	ScopePrefix = "ears"
+
+	ScopeResourceRoutes    = "routes"
+	ScopeResourceFragments = "fragments"
+	ScopeResourceTenants   = "tenants"
+	ScopeResourceEvents    = "events"
+
+	ScopeActionRead  = "read"
+	ScopeActionWrite = "write"
+
+	scopeWildcard = "*"
+)
+
+// ScopeResource returns the resource an api belongs to, events sent to routes are a resource of their own, tenant
+// configs belong to tenants and all other apis, such as plugin status and topology, belong to routes, the graphql
+// api spans all resources
+func ScopeResource(api string) string {
+	segments := strings.Split(strings.Trim(api, "/"), "/")
+	if len(segments) > 0 && segments[len(segments)-1] == "event" {
+		return ScopeResourceEvents
+	}
+	if len(segments) > 0 && segments[len(segments)-1] == "graphql" {
+		return scopeWildcard
+	}
+	// skip ears/v1 and tenant path if present
+	if len(segments) >= 2 {
+		segments = segments[2:]
+	}
+	if len(segments) >= 4 && segments[0] == "orgs" && segments[2] == "applications" {
+		segments = segments[4:]
+	}
+	if len(segments) == 0 {
+		return ScopeResourceRoutes
+	}
+	switch segments[0] {
+	case "fragments":
+		return ScopeResourceFragments
+	case "config", "tenants":
+		return ScopeResourceTenants
+	case "events":
+		return ScopeResourceEvents
+	}
+	return ScopeResourceRoutes
+}
+
+// ScopeAction returns read for GET, HEAD and OPTIONS requests as well as graphql queries and write otherwise
+func ScopeAction(api string, method string) string {
+	if strings.HasSuffix(strings.TrimSuffix(api, "/"), "/graphql") {
+		return ScopeActionRead
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeActionRead
+	}
+	return ScopeActionWrite
+}
+
+// earsScopes returns the scopes of the scope or scp claim starting with the ears prefix
+func earsScopes(claims map[string]interface{}) []string {
+	scopes := make([]string, 0)
+	for _, claim := range []string{"scope", "scp"} {
+		for _, s := range claimValues(claims[claim]) {
+			if strings.HasPrefix(s, ScopePrefix+":") {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+func scopePartMatches(part string, value string) bool {
+	return part == scopeWildcard || part == value
+}
+
+// ScopeAllows checks a scope of the form ears:<resource>:<action>[:<orgId>[:<appId>]] where each part may be * and
+// omitted org and app IDs match all tenants, write scopes include read, apis without tenant need a scope for all tenants
+func ScopeAllows(scope string, resource string, action string, tid *tenant.Id) bool {
+	parts := strings.Split(scope, ":")
+	if len(parts) < 3 || len(parts) > 5 || parts[0] != ScopePrefix {
+		return false
+	}
+	if !scopePartMatches(parts[1], resource) {
+		return false
+	}
+	if !scopePartMatches(parts[2], action) && !(parts[2] == ScopeActionWrite && action == ScopeActionRead) {
+		return false
+	}
+	for idx, part := range parts[3:] {
+		if part == scopeWildcard {
+			continue
+		}
+		if tid == nil {
+			return false
+		}
+		if (idx == 0 && part != tid.OrgId) || (idx == 1 && part != tid.AppId) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyScopes returns nil if one of the ears scopes of the token allows the request, tokens without ears scopes
+// are only rejected if scopes are required
+func (sc *DefaultJWTConsumer) verifyScopes(claims map[string]interface{}, api string, method string, tid *tenant.Id) error {
+	scopes := earsScopes(claims)
+	if len(scopes) == 0 {
+		if sc.requireScopes {
+			return &ForbiddenError{MissingScopes}
+		}
+		return nil
+	}
+	resource := ScopeResource(api)
+	action := ScopeAction(api, method)
+	for _, scope := range scopes {
+		if ScopeAllows(scope, resource, action, tid) {
+			return nil
+		}
+	}
+	return &ForbiddenError{NoMatchingScopes}
+}
+
+// WithRequiredScopes rejects tokens without ears scopes instead of granting them unrestricted access
+func WithRequiredScopes() Option {
+	return func(sc *DefaultJWTConsumer) error {
+		sc.requireScopes = true
+		return nil
+	}
+}
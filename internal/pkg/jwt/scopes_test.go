@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestScopeResource(t *testing.T) {
+	testCases := map[string]string{
+		"/ears/v1/orgs/myorg/applications/myapp/routes/r1":       ScopeResourceRoutes,
+		"/ears/v1/orgs/myorg/applications/myapp/routes/r1/event": ScopeResourceEvents,
+		"/ears/v1/orgs/myorg/applications/myapp/fragments/f1":    ScopeResourceFragments,
+		"/ears/v1/orgs/myorg/applications/myapp/config":          ScopeResourceTenants,
+		"/ears/v1/orgs/myorg/applications/myapp/senders":         ScopeResourceRoutes,
+		"/ears/v1/tenants": ScopeResourceTenants,
+		"/ears/v1/routes":  ScopeResourceRoutes,
+		"/ears/graphql":    "*",
+	}
+	for api, resource := range testCases {
+		if r := ScopeResource(api); r != resource {
+			t.Fatalf("%s: expected resource %s but got %s", api, resource, r)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	tid := &tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	other := &tenant.Id{OrgId: "myorg", AppId: "otherapp"}
+	testCases := []struct {
+		scope    string
+		resource string
+		action   string
+		tid      *tenant.Id
+		allowed  bool
+	}{
+		{"ears:routes:write:myorg:myapp", ScopeResourceRoutes, ScopeActionWrite, tid, true},
+		{"ears:routes:write:myorg:myapp", ScopeResourceRoutes, ScopeActionRead, tid, true},
+		{"ears:routes:write:myorg:myapp", ScopeResourceRoutes, ScopeActionWrite, other, false},
+		{"ears:routes:write:myorg:myapp", ScopeResourceFragments, ScopeActionWrite, tid, false},
+		{"ears:routes:read:myorg", ScopeResourceRoutes, ScopeActionWrite, tid, false},
+		{"ears:routes:read:myorg", ScopeResourceRoutes, ScopeActionRead, other, true},
+		{"ears:*:read", ScopeResourceTenants, ScopeActionRead, nil, true},
+		{"ears:tenants:read:myorg", ScopeResourceTenants, ScopeActionRead, nil, false},
+		{"ears:events:*:myorg:*", ScopeResourceEvents, ScopeActionWrite, tid, true},
+		{"other:routes:write", ScopeResourceRoutes, ScopeActionWrite, tid, false},
+		{"ears:routes", ScopeResourceRoutes, ScopeActionRead, tid, false},
+	}
+	for _, tc := range testCases {
+		if allowed := ScopeAllows(tc.scope, tc.resource, tc.action, tc.tid); allowed != tc.allowed {
+			t.Fatalf("%s for %s %s: expected %t", tc.scope, tc.action, tc.resource, tc.allowed)
+		}
+	}
+}
+
+func TestVerifyScopes(t *testing.T) {
+	tid := &tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	api := "/ears/v1/orgs/myorg/applications/myapp/routes/r1"
+	sc := &DefaultJWTConsumer{}
+	if err := sc.verifyScopes(map[string]interface{}{"scope": "openid"}, api, http.MethodPut, tid); err != nil {
+		t.Fatalf("unexpected error without ears scopes: %s", err.Error())
+	}
+	if err := sc.verifyScopes(map[string]interface{}{"scope": "openid ears:routes:read:myorg:myapp"}, api, http.MethodPut, tid); err == nil {
+		t.Fatalf("expected error for read scope")
+	}
+	if err := sc.verifyScopes(map[string]interface{}{"scp": []interface{}{"ears:routes:write:myorg:myapp"}}, api, http.MethodPut, tid); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if err := sc.verifyScopes(map[string]interface{}{"scope": "ears:routes:read"}, "/ears/graphql", http.MethodPost, nil); err == nil {
+		t.Fatalf("expected error for graphql query with routes scope")
+	}
+	if err := sc.verifyScopes(map[string]interface{}{"scope": "ears:*:read"}, "/ears/graphql", http.MethodPost, nil); err != nil {
+		t.Fatalf("unexpected error for graphql query: %s", err.Error())
+	}
+	sc.requireScopes = true
+	if err := sc.verifyScopes(map[string]interface{}{"scope": "openid"}, api, http.MethodGet, tid); err == nil {
+		t.Fatalf("expected error for missing scopes")
+	}
+}
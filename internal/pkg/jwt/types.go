@@ -25,11 +25,24 @@ type (
 		adminClientIds     []string
 		capabilityPrefixes []string
 		tenantStorer       tenant.TenantStorer
+		oidc               *oidcVerifier
+		requireScopes      bool
 	}
 	Verifier func(path, method, scope string) bool
+	// Option configures optional features of the DefaultJWTConsumer
+	Option func(*DefaultJWTConsumer) error
 )
 
 //401 errors
 type UnauthorizedError struct {
 	Msg string
 }
+
+//403 errors
+type ForbiddenError struct {
+	Msg string
+}
+
+func (fe *ForbiddenError) Error() string {
+	return fe.Msg
+}
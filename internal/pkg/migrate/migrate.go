@@ -0,0 +1,164 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// ROUTE_BATCH_SIZE is the number of routes written with a single SetRoutes call
+const ROUTE_BATCH_SIZE = 100
+
+type (
+	// Storers are the storers of one storage backend
+	Storers struct {
+		Routes    route.RouteStorer
+		Fragments fragments.FragmentStorer
+		Tenants   tenant.TenantStorer
+	}
+
+	// Result counts the items copied and lists the items that differ between source and target after copying,
+	// or before copying in a dry run
+	Result struct {
+		Tenants    int      `json:"tenants"`
+		Fragments  int      `json:"fragments"`
+		Routes     int      `json:"routes"`
+		Mismatches []string `json:"mismatches,omitempty"`
+	}
+)
+
+// Migrate copies all tenant configs, fragments and routes from one backend to another and verifies that the
+// target holds the same items afterwards. Items only present in the target are kept. Create and modify times
+// are set by the target storers. In a dry run nothing is written and the items that would change are reported
+// as mismatches.
+func Migrate(ctx context.Context, from Storers, to Storers, dryRun bool) (*Result, error) {
+	source, err := snapshot.Collect(ctx, from.Routes, from.Fragments, from.Tenants)
+	if err != nil {
+		return nil, &MigrationError{"read source", err}
+	}
+	result := &Result{}
+	for _, tf := range source.Fragments {
+		result.Fragments += len(tf.Fragments)
+	}
+	result.Tenants = len(source.Tenants)
+	result.Routes = len(source.Routes)
+	if !dryRun {
+		err = write(ctx, source, to)
+		if err != nil {
+			return result, err
+		}
+	}
+	target, err := snapshot.Collect(ctx, to.Routes, to.Fragments, to.Tenants)
+	if err != nil {
+		return result, &MigrationError{"read target", err}
+	}
+	result.Mismatches = compare(source, target)
+	return result, nil
+}
+
+func write(ctx context.Context, source *snapshot.Snapshot, to Storers) error {
+	for _, config := range source.Tenants {
+		err := to.Tenants.SetConfig(ctx, config)
+		if err != nil {
+			return &MigrationError{"write tenant " + config.Tenant.ToString(), err}
+		}
+	}
+	for _, tf := range source.Fragments {
+		err := to.Fragments.SetFragments(ctx, tf.Tenant, tf.Fragments)
+		if err != nil {
+			return &MigrationError{"write fragments of " + tf.Tenant.ToString(), err}
+		}
+	}
+	for start := 0; start < len(source.Routes); start += ROUTE_BATCH_SIZE {
+		end := start + ROUTE_BATCH_SIZE
+		if end > len(source.Routes) {
+			end = len(source.Routes)
+		}
+		err := to.Routes.SetRoutes(ctx, source.Routes[start:end])
+		if err != nil {
+			return &MigrationError{"write routes", err}
+		}
+	}
+	return nil
+}
+
+// compare returns the items of the source that are missing or different in the target
+func compare(source *snapshot.Snapshot, target *snapshot.Snapshot) []string {
+	mismatches := make([]string, 0)
+	tenants := make(map[string]string)
+	for _, config := range target.Tenants {
+		config.Modified = 0
+		tenants[config.Tenant.Key()] = canonical(config)
+	}
+	for _, config := range source.Tenants {
+		config.Modified = 0
+		if tenants[config.Tenant.Key()] != canonical(config) {
+			mismatches = append(mismatches, "tenant "+config.Tenant.ToString())
+		}
+	}
+	fragmentConfigs := make(map[string]string)
+	for _, tf := range target.Fragments {
+		for _, fragment := range tf.Fragments {
+			fragmentConfigs[tf.Tenant.KeyWithFragment(fragment.FragmentName)] = canonical(fragment)
+		}
+	}
+	for _, tf := range source.Fragments {
+		for _, fragment := range tf.Fragments {
+			if fragmentConfigs[tf.Tenant.KeyWithFragment(fragment.FragmentName)] != canonical(fragment) {
+				mismatches = append(mismatches, "fragment "+fragment.FragmentName+" of "+tf.Tenant.ToString())
+			}
+		}
+	}
+	routes := make(map[string]string)
+	for _, r := range target.Routes {
+		routes[r.TenantId.KeyWithRoute(r.Id)] = canonicalRoute(r)
+	}
+	for _, r := range source.Routes {
+		if routes[r.TenantId.KeyWithRoute(r.Id)] != canonicalRoute(r) {
+			mismatches = append(mismatches, "route "+r.Id+" of "+r.TenantId.ToString())
+		}
+	}
+	return mismatches
+}
+
+// canonicalRoute ignores the fields set by the storers
+func canonicalRoute(r route.Config) string {
+	r.Created = 0
+	r.Modified = 0
+	r.Status = ""
+	return canonical(r)
+}
+
+// canonical returns the json of an item, json maps have sorted keys so that plugin configs decoded by different
+// storers compare equal
+func canonical(item interface{}) string {
+	buf, err := json.Marshal(item)
+	if err != nil {
+		return ""
+	}
+	var v interface{}
+	err = json.Unmarshal(buf, &v)
+	if err != nil {
+		return ""
+	}
+	buf, _ = json.Marshal(v)
+	return string(buf)
+}
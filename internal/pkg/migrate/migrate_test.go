@@ -0,0 +1,74 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/migrate"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func inMemoryStorers() migrate.Storers {
+	v := viper.New()
+	return migrate.Storers{
+		Routes:    db.NewInMemoryRouteStorer(v),
+		Fragments: db.NewInMemoryFragmentStorer(v),
+		Tenants:   db.NewTenantInmemoryStorer(),
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	from := inMemoryStorers()
+	to := inMemoryStorers()
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	from.Tenants.SetConfig(ctx, tenant.Config{Tenant: tid, Quota: tenant.Quota{EventsPerSec: 10}})
+	from.Fragments.SetFragment(ctx, tid, route.PluginConfig{FragmentName: "f1", Plugin: "debug", Config: map[string]interface{}{"rounds": 3}})
+	from.Routes.SetRoute(ctx, route.Config{Id: "r1", TenantId: tid, Receiver: route.PluginConfig{Plugin: "debug"}})
+	from.Routes.SetRoute(ctx, route.Config{Id: "r2", TenantId: tenant.Id{OrgId: "myOrg", AppId: "myApp2"}})
+	// items only in the target are kept
+	to.Routes.SetRoute(ctx, route.Config{Id: "r3", TenantId: tid})
+	result, err := migrate.Migrate(ctx, from, to, true)
+	if err != nil {
+		t.Fatalf("Fail to migrate %s\n", err.Error())
+	}
+	if result.Tenants != 1 || result.Fragments != 1 || result.Routes != 2 || len(result.Mismatches) != 4 {
+		t.Fatalf("unexpected dry run result %v\n", result)
+	}
+	routes, _ := to.Routes.GetAllRoutes(ctx)
+	if len(routes) != 1 {
+		t.Fatalf("dry run wrote routes %v\n", routes)
+	}
+	result, err = migrate.Migrate(ctx, from, to, false)
+	if err != nil {
+		t.Fatalf("Fail to migrate %s\n", err.Error())
+	}
+	if result.Tenants != 1 || result.Fragments != 1 || result.Routes != 2 || len(result.Mismatches) != 0 {
+		t.Fatalf("unexpected result %v\n", result)
+	}
+	routes, _ = to.Routes.GetAllRoutes(ctx)
+	if len(routes) != 3 {
+		t.Fatalf("unexpected routes %v\n", routes)
+	}
+	fragment, err := to.Fragments.GetFragment(ctx, tid, "f1")
+	if err != nil || fragment.Plugin != "debug" {
+		t.Fatalf("fragment not migrated %v %v\n", fragment, err)
+	}
+}
@@ -16,8 +16,15 @@ package plugin
 
 import (
 	"context"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
 	"sync"
+	"time"
 
 	"github.com/xmidt-org/ears/pkg/event"
 	pkgfilter "github.com/xmidt-org/ears/pkg/filter"
@@ -25,6 +32,7 @@ import (
 )
 
 var _ pkgfilter.Filterer = (*filter)(nil)
+var _ pkgfilter.Flusher = (*filter)(nil)
 
 type filter struct {
 	sync.Mutex
@@ -39,9 +47,33 @@ type filter struct {
 
 	active   bool
 	filterer pkgfilter.Filterer
+	config   interface{} // config with unresolved secret references, nil if there are none
+
+	duration metric.Int64Histogram
+	labels   []attribute.KeyValue
+}
+
+// newFilterDuration returns the histogram of the time a filter spends on an event and the labels of the filter
+func newFilterDuration(tid tenant.Id, plugin string, name string) (metric.Int64Histogram, []attribute.KeyValue) {
+	labels := []attribute.KeyValue{
+		attribute.String(rtsemconv.EARSPluginTypeLabel, plugin+"Filter"),
+		attribute.String(rtsemconv.EARSPluginNameLabel, name),
+		attribute.String(rtsemconv.EARSAppIdLabel, tid.AppId),
+		attribute.String(rtsemconv.EARSOrgIdLabel, tid.OrgId),
+	}
+	histogram := metric.Must(global.Meter(rtsemconv.EARSMeterName)).NewInt64Histogram(
+		rtsemconv.EARSMetricFilterDuration,
+		metric.WithDescription("measures the time a filter spends on an event"),
+		metric.WithUnit(unit.Milliseconds),
+	)
+	return histogram, labels
 }
 
 func (f *filter) Filter(e event.Event) []event.Event {
+	return f.FilterWithFlush(e, nil)
+}
+
+func (f *filter) FilterWithFlush(e event.Event, flush pkgfilter.FlushFn) []event.Event {
 	if f.filterer == nil {
 		e.Nack(&pkgmanager.NilPluginError{})
 		return nil
@@ -55,7 +87,34 @@ func (f *filter) Filter(e event.Event) []event.Event {
 		}
 		f.Unlock()
 	}
-	return f.filterer.Filter(e)
+	if ct := e.ContentType(); ct != event.ContentTypeJSON {
+		if bf, ok := f.filterer.(pkgfilter.BinaryFilterer); !ok || !bf.SupportsBinaryPayload() {
+			e.Nack(&pkgfilter.UnsupportedPayloadError{Filter: f.name, ContentType: ct})
+			return nil
+		}
+	}
+	start := time.Now()
+	var events []event.Event
+	if fl, ok := f.filterer.(pkgfilter.Flusher); ok && flush != nil {
+		events = fl.FilterWithFlush(e, flush)
+	} else {
+		events = f.filterer.Filter(e)
+	}
+	route.AddJourneyStage(e.Context(), f.plugin+"/"+f.name, time.Since(start))
+	f.recordDuration(e, start)
+	return events
+}
+
+// recordDuration records the time the filter spent on an event, labeled with the route the event flows through
+func (f *filter) recordDuration(e event.Event, start time.Time) {
+	if f.duration.SyncImpl() == nil {
+		return
+	}
+	labels := f.labels
+	if routeId := route.IdFromContext(e.Context()); routeId != "" {
+		labels = append(labels[:len(labels):len(labels)], rtsemconv.EARSRouteId.String(routeId))
+	}
+	f.duration.Record(e.Context(), time.Since(start).Milliseconds(), labels...)
 }
 
 func (f *filter) Unregister(ctx context.Context) error {
@@ -74,6 +133,9 @@ func (f *filter) Unregister(ctx context.Context) error {
 }
 
 func (f *filter) Config() interface{} {
+	if f.config != nil {
+		return f.config
+	}
 	return f.filterer.Config()
 }
 
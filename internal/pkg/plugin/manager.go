@@ -23,6 +23,8 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/appsecret"
 	"github.com/xmidt-org/ears/internal/pkg/quota"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
+	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/logs"
 	"github.com/xmidt-org/ears/pkg/panics"
 	"github.com/xmidt-org/ears/pkg/secret"
@@ -150,9 +152,20 @@ func (m *manager) RegisterReceiver(
 			secrets = appsecret.NewTenantConfigVault(tid, m.secrets)
 		}
 
+		var pluginConfig interface{}
+		pluginConfig, err = resolveConfig(ns, config, secrets)
+		if err != nil {
+			return nil, &RegistrationError{
+				Message: "could not resolve config references",
+				Plugin:  plugin,
+				Name:    name,
+				Err:     err,
+			}
+		}
+
 		receiverChan := make(chan pkgreceiver.Receiver, 1)
 		go func() {
-			r, err = ns.NewReceiver(tid, plugin, name, config, secrets)
+			r, err = ns.NewReceiver(tid, plugin, name, pluginConfig, secrets)
 			receiverChan <- r
 		}()
 		select {
@@ -183,6 +196,15 @@ func (m *manager) RegisterReceiver(
 						panicErr := panics.ToError(p)
 						log.Ctx(e.Context()).Error().Str("op", "receiverNext").Str("error", panicErr.Error()).
 							Str("stackTrace", panicErr.StackTrace()).Msg("A panic has occurred")
+						sysevent.Emit(sysevent.Event{
+							Type:    sysevent.TypePluginPanic,
+							Tenant:  tid,
+							Plugin:  plugin,
+							Name:    name,
+							EventId: e.Id(),
+							Error:   panicErr.Error(),
+							Stack:   panicErr.StackTrace(),
+						})
 					}
 				}()
 
@@ -227,6 +249,7 @@ func (m *manager) RegisterReceiver(
 		manager:  m,
 		receiver: r,
 		active:   true,
+		config:   unresolvedConfig(config),
 	}
 
 	m.receiversWrapped[w.id] = w
@@ -238,6 +261,50 @@ func (m *manager) RegisterReceiver(
 
 }
 
+// ValidateReceiver checks a receiver config with the validator of the plugin without creating the receiver,
+// since creating receivers may already connect to the message source
+func (m *manager) ValidateReceiver(
+	ctx context.Context, plugin string,
+	name string, config interface{},
+	tid tenant.Id,
+) error {
+
+	ns, err := m.pm.Receiverer(plugin)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not get plugin",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	pluginConfig, err := m.validationConfig(ns, tid, config)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not resolve config references",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	if v, ok := ns.(pkgreceiver.ConfigValidator); ok {
+		err = v.ValidateReceiverConfig(pluginConfig)
+		if err != nil {
+			return &RegistrationError{
+				Message: "invalid receiver config",
+				Plugin:  plugin,
+				Name:    name,
+				Err:     err,
+			}
+		}
+	}
+
+	return nil
+
+}
+
 func (m *manager) Receivers() map[string]pkgreceiver.Receiver {
 	m.Lock()
 	defer m.Unlock()
@@ -265,6 +332,28 @@ func (m *manager) ReceiversStatus() map[string]ReceiverStatus {
 	return receivers
 }
 
+// resolveConfig expands environment variable references in a plugin config and then resolves its secret references,
+// unless the plugin resolves them itself with the vault it is given
+func resolveConfig(factory interface{}, config interface{}, secrets secret.Vault) (interface{}, error) {
+	config, err := pkgconfig.ExpandEnvConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := factory.(secret.Resolver); ok && r.ResolvesSecrets() {
+		return config, nil
+	}
+	return secret.Interpolate(secrets, config)
+}
+
+// unresolvedConfig returns a plugin config containing secret references as given, so that plugin configs
+// reported by the plugin manager show the references rather than the resolved secrets
+func unresolvedConfig(config interface{}) interface{} {
+	if secret.HasReferences(config) {
+		return config
+	}
+	return nil
+}
+
 // next iterates through all receiver functions that have registered for
 // a receiver (unique by name + config hash).  These must be independent,
 // so no error can actually be returned to the receiver if a problem occurs.
@@ -429,9 +518,20 @@ func (m *manager) RegisterFilter(
 			secrets = appsecret.NewTenantConfigVault(tid, m.secrets)
 		}
 
+		var pluginConfig interface{}
+		pluginConfig, err = resolveConfig(factory, config, secrets)
+		if err != nil {
+			return nil, &RegistrationError{
+				Message: "could not resolve config references",
+				Plugin:  plugin,
+				Name:    name,
+				Err:     err,
+			}
+		}
+
 		filterChan := make(chan pkgfilter.Filterer, 1)
 		go func() {
-			f, err = factory.NewFilterer(tid, plugin, name, config, secrets)
+			f, err = factory.NewFilterer(tid, plugin, name, pluginConfig, secrets)
 			filterChan <- f
 		}()
 		select {
@@ -473,7 +573,9 @@ func (m *manager) RegisterFilter(
 
 		filterer: f,
 		active:   true,
+		config:   unresolvedConfig(config),
 	}
+	w.duration, w.labels = newFilterDuration(tid, plugin, name)
 
 	m.filtersWrapped[w.id] = w
 	m.filtersCount[key]++
@@ -482,6 +584,67 @@ func (m *manager) RegisterFilter(
 
 }
 
+// ValidateFilter checks a filter config by creating the filterer and tearing it down again right away
+func (m *manager) ValidateFilter(
+	ctx context.Context, plugin string,
+	name string, config interface{},
+	tid tenant.Id,
+) error {
+
+	factory, err := m.pm.Filterer(plugin)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not get plugin",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	var secrets secret.Vault
+	if m.secrets != nil {
+		secrets = appsecret.NewTenantConfigVault(tid, m.secrets)
+	}
+
+	pluginConfig, err := resolveConfig(factory, config, secrets)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not resolve config references",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	var f pkgfilter.Filterer
+	filterChan := make(chan pkgfilter.Filterer, 1)
+	go func() {
+		f, err = factory.NewFilterer(tid, plugin, name, pluginConfig, secrets)
+		filterChan <- f
+	}()
+	select {
+	case flt := <-filterChan:
+		f = flt
+	case <-time.After(pluginRegistrationDeadline):
+		err = errors.New("filter validation timed out")
+	}
+	if err != nil {
+		return &RegistrationError{
+			Message: "invalid filter config",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	if stopper, ok := f.(pkgfilter.Stopper); ok {
+		go stopper.StopFiltering(ctx)
+	}
+
+	return nil
+
+}
+
 func (m *manager) Filters() map[string]pkgfilter.Filterer {
 	m.Lock()
 	defer m.Unlock()
@@ -526,6 +689,9 @@ func (m *manager) UnregisterFilter(ctx context.Context, pf pkgfilter.Filterer) e
 		m.filtersCount[key]--
 
 		if m.filtersCount[key] <= 0 {
+			if stopper, ok := m.filters[key].(pkgfilter.Stopper); ok {
+				go stopper.StopFiltering(ctx)
+			}
 			delete(m.filtersCount, key)
 			delete(m.filters, key)
 		}
@@ -596,9 +762,20 @@ func (m *manager) RegisterSender(
 			secrets = appsecret.NewTenantConfigVault(tid, m.secrets)
 		}
 
+		var pluginConfig interface{}
+		pluginConfig, err = resolveConfig(ns, config, secrets)
+		if err != nil {
+			return nil, &RegistrationError{
+				Message: "could not resolve config references",
+				Plugin:  plugin,
+				Name:    name,
+				Err:     err,
+			}
+		}
+
 		senderChan := make(chan pkgsender.Sender, 1)
 		go func() {
-			s, err = ns.NewSender(tid, plugin, name, config, secrets)
+			s, err = ns.NewSender(tid, plugin, name, pluginConfig, secrets)
 			senderChan <- s
 		}()
 		select {
@@ -639,6 +816,7 @@ func (m *manager) RegisterSender(
 		manager: m,
 		sender:  s,
 		active:  true,
+		config:  unresolvedConfig(config),
 	}
 
 	m.sendersWrapped[w.id] = w
@@ -647,6 +825,50 @@ func (m *manager) RegisterSender(
 	return w, nil
 }
 
+// ValidateSender checks a sender config with the validator of the plugin without creating the sender,
+// since creating senders may already connect to the destination
+func (m *manager) ValidateSender(
+	ctx context.Context, plugin string,
+	name string, config interface{},
+	tid tenant.Id,
+) error {
+
+	ns, err := m.pm.Senderer(plugin)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not get plugin",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	pluginConfig, err := m.validationConfig(ns, tid, config)
+	if err != nil {
+		return &RegistrationError{
+			Message: "could not resolve config references",
+			Plugin:  plugin,
+			Name:    name,
+			Err:     err,
+		}
+	}
+
+	if v, ok := ns.(pkgsender.ConfigValidator); ok {
+		err = v.ValidateSenderConfig(pluginConfig)
+		if err != nil {
+			return &RegistrationError{
+				Message: "invalid sender config",
+				Plugin:  plugin,
+				Name:    name,
+				Err:     err,
+			}
+		}
+	}
+
+	return nil
+
+}
+
 func (m *manager) Senders() map[string]pkgsender.Sender {
 	m.Lock()
 	defer m.Unlock()
@@ -699,6 +921,15 @@ func (m *manager) UnregisterSender(ctx context.Context, ps pkgsender.Sender) err
 
 // === Helper Functions ==============================================
 
+// validationConfig resolves the references of a plugin config the same way registering the plugin does
+func (m *manager) validationConfig(factory interface{}, tid tenant.Id, config interface{}) (interface{}, error) {
+	var secrets secret.Vault
+	if m.secrets != nil {
+		secrets = appsecret.NewTenantConfigVault(tid, m.secrets)
+	}
+	return resolveConfig(factory, config, secrets)
+}
+
 func (m *manager) mapkey(tid tenant.Id, name string, hash string) string {
 	return tid.OrgId + "/" + tid.AppId + "/" + name + "/" + hash
 }
@@ -22,8 +22,10 @@ import (
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/xmidt-org/ears/internal/pkg/plugin"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/bit"
 	pkgevent "github.com/xmidt-org/ears/pkg/event"
 	pkgfilter "github.com/xmidt-org/ears/pkg/filter"
@@ -32,7 +34,10 @@ import (
 	pkgmanager "github.com/xmidt-org/ears/pkg/plugin/manager"
 	"github.com/xmidt-org/ears/pkg/receiver"
 	pkgreceiver "github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
 	pkgsender "github.com/xmidt-org/ears/pkg/sender"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/metrictest"
 
 	. "github.com/onsi/gomega"
 )
@@ -168,8 +173,112 @@ func TestFilterLifecycle(t *testing.T) {
 
 }
 
+type stoppingFilter struct {
+	pkgfilter.FiltererMock
+	stopped chan struct{}
+}
+
+func (f *stoppingFilter) StopFiltering(ctx context.Context) {
+	close(f.stopped)
+}
+
+func TestFilterStop(t *testing.T) {
+	ctx := context.Background()
+	a := NewWithT(t)
+
+	pm, err := pkgmanager.New()
+	a.Expect(err).To(BeNil())
+	mock := newFiltererPluginMock{}
+	mock.FiltererHashFunc = func(config interface{}) (string, error) {
+		return "filter_" + hasher.Hash(config), nil
+	}
+	filterer := &stoppingFilter{stopped: make(chan struct{})}
+	mock.NewFiltererFunc = func(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (pkgfilter.Filterer, error) {
+		return filterer, nil
+	}
+	pm.RegisterPlugin("filter", &mock)
+	m, err := plugin.NewManager(plugin.WithPluginManager(pm))
+	a.Expect(err).To(BeNil())
+
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	f1, err := m.RegisterFilter(ctx, "filter", "testfilter-1", "noconfig", tid)
+	a.Expect(err).To(BeNil())
+	f2, err := m.RegisterFilter(ctx, "filter", "testfilter-1", "noconfig", tid)
+	a.Expect(err).To(BeNil())
+
+	// the shared filterer is only stopped once the last route unregisters it
+	err = m.UnregisterFilter(ctx, f1)
+	a.Expect(err).To(BeNil())
+	a.Consistently(filterer.stopped, 100*time.Millisecond).ShouldNot(BeClosed())
+	err = m.UnregisterFilter(ctx, f2)
+	a.Expect(err).To(BeNil())
+	a.Eventually(filterer.stopped).Should(BeClosed())
+}
+
 // === Sender =========================================
 
+func TestFilterBinaryPayload(t *testing.T) {
+	ctx := context.Background()
+	a := NewWithT(t)
+
+	m := newManager(t)
+
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+
+	f, err := m.RegisterFilter(ctx, "filter", "testfilter-1", "noconfig", tid)
+	a.Expect(err).To(BeNil())
+
+	nacked := make(chan error, 1)
+	e, err := pkgevent.New(ctx, nil,
+		pkgevent.WithBinaryPayload([]byte{0xca, 0xfe}, "application/octet-stream"),
+		pkgevent.WithAck(
+			func(e pkgevent.Event) {},
+			func(e pkgevent.Event, err error) {
+				nacked <- err
+			}))
+	a.Expect(err).To(BeNil())
+
+	// the mock filterer does not support binary payloads
+	a.Expect(f.Filter(e)).To(BeEmpty())
+	var payloadErr *pkgfilter.UnsupportedPayloadError
+	a.Expect(errors.As(<-nacked, &payloadErr)).To(BeTrue())
+	a.Expect(payloadErr.ContentType).To(Equal("application/octet-stream"))
+
+	e, err = pkgevent.New(ctx, map[string]interface{}{"foo": "bar"})
+	a.Expect(err).To(BeNil())
+	a.Expect(f.Filter(e)).To(HaveLen(1))
+}
+
+func TestFilterDuration(t *testing.T) {
+	ctx := context.Background()
+	a := NewWithT(t)
+
+	meter, provider := metrictest.NewMeterProvider()
+	global.SetMeterProvider(provider)
+
+	m := newManager(t)
+
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+
+	f, err := m.RegisterFilter(ctx, "filter", "testfilter-1", "noconfig", tid)
+	a.Expect(err).To(BeNil())
+
+	e, err := pkgevent.New(route.NewContext(ctx, "myRoute"), map[string]interface{}{"foo": "bar"})
+	a.Expect(err).To(BeNil())
+	a.Expect(f.Filter(e)).To(HaveLen(1))
+
+	var durations []metrictest.Measured
+	for _, measured := range metrictest.AsStructs(meter.MeasurementBatches) {
+		if measured.Name == rtsemconv.EARSMetricFilterDuration {
+			durations = append(durations, measured)
+		}
+	}
+	a.Expect(durations).To(HaveLen(1))
+	a.Expect(durations[0].Labels[rtsemconv.EARSRouteId].AsString()).To(Equal("myRoute"))
+	a.Expect(durations[0].Labels[rtsemconv.EARSPluginNameLabel].AsString()).To(Equal("testfilter-1"))
+	a.Expect(durations[0].Labels[rtsemconv.EARSAppIdLabel].AsString()).To(Equal("myApp"))
+}
+
 func TestSenderRegisterErrors(t *testing.T) {
 
 	testCases := []struct {
@@ -457,6 +566,87 @@ func TestReceiverUnregister(t *testing.T) {
 
 }*/
 
+func TestSenderSecretInterpolation(t *testing.T) {
+	ctx := context.Background()
+	a := NewWithT(t)
+
+	pm, err := pkgmanager.New()
+	a.Expect(err).To(BeNil())
+	mock := newSendererPluginMock{}
+	mock.SenderHashFunc = func(config interface{}) (string, error) {
+		return "sender_" + hasher.Hash(config), nil
+	}
+	var senderConfig interface{}
+	mock.NewSenderFunc = func(tid tenant.Id, pluginType string, name string, config interface{}, secrets secret.Vault) (pkgsender.Sender, error) {
+		senderConfig = config
+		return &pkgsender.SenderMock{}, nil
+	}
+	pm.RegisterPlugin("sender", &mock)
+	m, err := plugin.NewManager(
+		plugin.WithPluginManager(pm),
+		plugin.WithSecretVaults(secretVault{"secret://myOrg.myApp.kafka.brokers": "localhost:9092"}),
+	)
+	a.Expect(err).To(BeNil())
+
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+
+	config := `{"brokers":"${secret://kafka.brokers}"}`
+	_, err = m.RegisterSender(ctx, "sender", "testsender-1", config, tid)
+	a.Expect(err).To(BeNil())
+	a.Expect(senderConfig).To(Equal(`{"brokers":"localhost:9092"}`))
+
+	// the status shows the secret reference rather than the secret
+	for _, status := range m.SendersStatus() {
+		a.Expect(status.Config).To(Equal(config))
+	}
+
+	_, err = m.RegisterSender(ctx, "sender", "testsender-2", `{"brokers":"${secret://kafka.unknown}"}`, tid)
+	var unresolvedErr *secret.UnresolvedSecretError
+	a.Expect(errors.As(err, &unresolvedErr)).To(BeTrue())
+}
+
+type resolvingSendererPluginMock struct {
+	newSendererPluginMock
+}
+
+func (p *resolvingSendererPluginMock) ResolvesSecrets() bool {
+	return true
+}
+
+func TestSenderSecretReferences(t *testing.T) {
+	ctx := context.Background()
+	a := NewWithT(t)
+
+	pm, err := pkgmanager.New()
+	a.Expect(err).To(BeNil())
+	mock := resolvingSendererPluginMock{}
+	mock.SenderHashFunc = func(config interface{}) (string, error) {
+		return "sender_" + hasher.Hash(config), nil
+	}
+	var senderConfig interface{}
+	var senderSecrets secret.Vault
+	mock.NewSenderFunc = func(tid tenant.Id, pluginType string, name string, config interface{}, secrets secret.Vault) (pkgsender.Sender, error) {
+		senderConfig = config
+		senderSecrets = secrets
+		return &pkgsender.SenderMock{}, nil
+	}
+	pm.RegisterPlugin("sender", &mock)
+	m, err := plugin.NewManager(
+		plugin.WithPluginManager(pm),
+		plugin.WithSecretVaults(secretVault{"secret://myOrg.myApp.kafka.brokers": "localhost:9092"}),
+	)
+	a.Expect(err).To(BeNil())
+
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+
+	// plugins resolving secrets themselves get the references along with the vault of the tenant
+	config := `{"brokers":"${secret://kafka.brokers}"}`
+	_, err = m.RegisterSender(ctx, "sender", "testsender-1", config, tid)
+	a.Expect(err).To(BeNil())
+	a.Expect(senderConfig).To(Equal(config))
+	a.Expect(senderSecrets.Secret("secret://kafka.brokers")).To(Equal("localhost:9092"))
+}
+
 // === Helper Methods =========================================
 
 func newManager(t *testing.T) plugin.Manager {
@@ -486,6 +676,12 @@ func newPluginManager(t *testing.T) pkgmanager.Manager {
 	return m
 }
 
+type secretVault map[string]string
+
+func (v secretVault) Secret(key string) string {
+	return v[key]
+}
+
 // === FILTERER ==========================================================
 
 type filterFn func(e pkgevent.Event) []pkgevent.Event
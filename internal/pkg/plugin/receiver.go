@@ -26,6 +26,9 @@ import (
 )
 
 var _ pkgreceiver.Receiver = (*receiver)(nil)
+var _ pkgreceiver.LagReporter = (*receiver)(nil)
+var _ pkgreceiver.ParseErrorReporter = (*receiver)(nil)
+var _ pkgreceiver.HealthChecker = (*receiver)(nil)
 
 type receiver struct {
 	sync.Mutex
@@ -41,11 +44,15 @@ type receiver struct {
 	active  bool
 
 	receiver pkgreceiver.Receiver
+	config   interface{} // config with unresolved secret references, nil if there are none
 
 	done chan struct{}
 }
 
 func (r *receiver) Config() interface{} {
+	if r.config != nil {
+		return r.config
+	}
 	return r.receiver.Config()
 }
 
@@ -71,6 +78,30 @@ func (r *receiver) Trigger(e event.Event) {
 	}
 }
 
+// Lag returns the lag of the wrapped receiver if it reports any
+func (r *receiver) Lag() []pkgreceiver.Lag {
+	if lr, ok := r.receiver.(pkgreceiver.LagReporter); ok {
+		return lr.Lag()
+	}
+	return nil
+}
+
+// ParseErrors returns the parse errors of the wrapped receiver if it reports any
+func (r *receiver) ParseErrors() []pkgreceiver.ParseError {
+	if pr, ok := r.receiver.(pkgreceiver.ParseErrorReporter); ok {
+		return pr.ParseErrors()
+	}
+	return nil
+}
+
+// CheckHealth checks the health of the wrapped receiver if it can tell
+func (r *receiver) CheckHealth(ctx context.Context) error {
+	if hc, ok := r.receiver.(pkgreceiver.HealthChecker); ok {
+		return hc.CheckHealth(ctx)
+	}
+	return nil
+}
+
 func (r *receiver) Receive(next pkgreceiver.NextFn) error {
 	if r == nil {
 		return &pkgmanager.NilPluginError{}
@@ -25,6 +25,7 @@ import (
 )
 
 var _ pkgsender.Sender = (*sender)(nil)
+var _ pkgsender.HealthChecker = (*sender)(nil)
 
 type sender struct {
 	sync.Mutex
@@ -36,13 +37,25 @@ type sender struct {
 	manager *manager
 	active  bool
 	sender  pkgsender.Sender
+	config  interface{} // config with unresolved secret references, nil if there are none
 }
 
 func (s *sender) Unwrap() pkgsender.Sender {
 	return s.sender
 }
 
+// CheckHealth checks the health of the wrapped sender if it can tell
+func (s *sender) CheckHealth(ctx context.Context) error {
+	if hc, ok := s.sender.(pkgsender.HealthChecker); ok {
+		return hc.CheckHealth(ctx)
+	}
+	return nil
+}
+
 func (s *sender) Config() interface{} {
+	if s.config != nil {
+		return s.config
+	}
 	return s.sender.Config()
 }
 
@@ -35,6 +35,11 @@ type Manager interface {
 		name string, config interface{},
 		tid tenant.Id,
 	) (pkgreceiver.Receiver, error)
+	ValidateReceiver(
+		ctx context.Context, plugin string,
+		name string, config interface{},
+		tid tenant.Id,
+	) error
 	Receivers() map[string]pkgreceiver.Receiver
 	ReceiversStatus() map[string]ReceiverStatus
 	UnregisterReceiver(ctx context.Context, r pkgreceiver.Receiver) error
@@ -45,6 +50,11 @@ type Manager interface {
 		name string, config interface{},
 		tid tenant.Id,
 	) (pkgfilter.Filterer, error)
+	ValidateFilter(
+		ctx context.Context, plugin string,
+		name string, config interface{},
+		tid tenant.Id,
+	) error
 	Filters() map[string]pkgfilter.Filterer
 	FiltersStatus() map[string]FilterStatus
 	UnregisterFilter(ctx context.Context, f pkgfilter.Filterer) error
@@ -55,6 +65,11 @@ type Manager interface {
 		name string, config interface{},
 		tid tenant.Id,
 	) (pkgsender.Sender, error)
+	ValidateSender(
+		ctx context.Context, plugin string,
+		name string, config interface{},
+		tid tenant.Id,
+	) error
 	Senders() map[string]pkgsender.Sender
 	SendersStatus() map[string]SenderStatus
 	UnregisterSender(ctx context.Context, s pkgsender.Sender) error
@@ -108,6 +123,16 @@ type ReceiverStatus struct {
 	Config         interface{}
 	ReferenceCount int
 	Tid            tenant.Id
+	Health         *PluginHealth `json:",omitempty"` // nil until the route supervisor checked the plugin
+}
+
+// PluginHealth is the result of the latest check of a receiver or sender by the route supervisor
+type PluginHealth struct {
+	Healthy     bool   `json:"healthy"`
+	Error       string `json:"error,omitempty"`
+	CheckedAt   int64  `json:"checkedAt"`             // time of the latest check in unix milliseconds
+	Restarts    int    `json:"restarts"`              // restarts since the plugin was last found healthy
+	RestartedAt int64  `json:"restartedAt,omitempty"` // time of the latest restart in unix milliseconds
 }
 
 type SenderStatus struct {
@@ -116,6 +141,7 @@ type SenderStatus struct {
 	Config         interface{}
 	ReferenceCount int
 	Tid            tenant.Id
+	Health         *PluginHealth `json:",omitempty"` // nil until the route supervisor checked the plugin
 }
 
 type FilterStatus struct {
@@ -17,12 +17,16 @@ package quota
 import (
 	"context"
 	"errors"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/config"
 	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/ratelimit"
+	"github.com/xmidt-org/ears/pkg/ratelimit/redis"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,7 +37,10 @@ type QuotaManager struct {
 	lock               *sync.Mutex
 	backendLimiterType string
 	redisAddr          string
+	redisClient        *goredis.Client
+	defaultRqs         int64
 	logger             *zerolog.Logger
+	alertHandler       AlertHandler
 
 	ticker *time.Ticker
 	done   context.CancelFunc
@@ -58,29 +65,82 @@ func NewQuotaManager(logger *zerolog.Logger, tenantStorer tenant.TenantStorer, s
 	}
 
 	redisAddr := ""
+	var redisClient *goredis.Client
 	if backendLimiterType == LimiterTypeRedis {
 		redisAddr = config.GetString("ears.ratelimiter.endpoint")
 		if redisAddr == "" {
 			return nil, &ConfigNotFoundError{"ears.ratelimiter.endpoint"}
 		}
+		// the token buckets of all tenants live in the same redis and are shared by all instances, so the
+		// limiters share one client
+		redisClient = goredis.NewClient(&goredis.Options{
+			Addr: redisAddr,
+		})
+	} else if backendLimiterType == LimiterTypeInMemory && config.GetString("ears.synchronization.type") == "redis" {
+		logger.Warn().Str("op", "NewQuotaManager").Msg("in memory rate limiter enforces tenant quotas per instance, use the redis rate limiter to enforce them cluster wide")
 	}
 
-	return &QuotaManager{
+	m := &QuotaManager{
 		limiters:           make(map[string]*QuotaLimiter),
 		tenantStorer:       tenantStorer,
 		syncer:             syncer,
 		lock:               &sync.Mutex{},
 		backendLimiterType: backendLimiterType,
 		redisAddr:          redisAddr,
+		redisClient:        redisClient,
+		defaultRqs:         int64(defaultEventsPerSec(config)),
 		logger:             logger,
-	}, nil
+	}
+	m.registerMetrics()
+	return m, nil
+}
+
+func defaultEventsPerSec(config config.Config) int {
+	rqs := config.GetInt("ears.ratelimiter.defaultEventsPerSec")
+	if rqs < 0 {
+		return 0
+	}
+	return rqs
+}
+
+// ReloadConfig applies a new default quota to all tenants without a quota of their own
+func (m *QuotaManager) ReloadConfig(config config.Config) error {
+	rqs := defaultEventsPerSec(config)
+	if atomic.SwapInt64(&m.defaultRqs, int64(rqs)) != int64(rqs) {
+		m.logger.Info().Str("op", "QuotaManager.ReloadConfig").Int("defaultEventsPerSec", rqs).Msg("default quota changed")
+		m.syncAllItems()
+	}
+	return nil
+}
+
+// tenantConfig returns the config of a tenant with its quota falling back to the default quota for tenants without one
+func (m *QuotaManager) tenantConfig(ctx context.Context, tid tenant.Id) (tenant.Config, error) {
+	config := tenant.Config{Tenant: tid}
+	storedConfig, err := m.tenantStorer.GetConfig(ctx, tid)
+	if err != nil {
+		var tenantNotFound *tenant.TenantNotFoundError
+		if !errors.As(err, &tenantNotFound) {
+			return config, err
+		}
+	} else {
+		config = *storedConfig
+	}
+	if config.Quota.EventsPerSec <= 0 {
+		config.Quota = tenant.Quota{EventsPerSec: int(atomic.LoadInt64(&m.defaultRqs))}
+	}
+	return config, nil
 }
 
 func (m *QuotaManager) Start() {
 	m.syncer.RegisterLocalSyncer("tenant", m)
+	if watcher, ok := m.tenantStorer.(syncer.ItemWatcher); ok {
+		watcher.RegisterLocalSyncer("tenant", m)
+	}
 
 	//Start backup quota syncer that wakes up every minute to sync on tenant quota
 	ticker := time.NewTicker(time.Minute)
+	//check the quota utilization of the tenants every second to raise alerts
+	utilizationTicker := time.NewTicker(time.Second)
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -89,13 +149,16 @@ func (m *QuotaManager) Start() {
 	m.ticker = ticker
 
 	go func() {
+		defer utilizationTicker.Stop()
 		for {
-			m.logger.Info().Str("op", "PeriodicQuotaSync").Msg("sync tenant quotas")
 			select {
 			case <-m.ctx.Done():
 				return
 			case <-m.ticker.C:
+				m.logger.Info().Str("op", "PeriodicQuotaSync").Msg("sync tenant quotas")
 				m.syncAllItems()
+			case <-utilizationTicker.C:
+				m.checkUtilization(m.ctx)
 			}
 		}
 	}()
@@ -119,6 +182,15 @@ func (m *QuotaManager) Wait(ctx context.Context, tid tenant.Id) error {
 	return limiter.Wait(ctx)
 }
 
+// Allow takes quota for one event without waiting and returns a QuotaExceeded error if the tenant is out of quota
+func (m *QuotaManager) Allow(ctx context.Context, tid tenant.Id) error {
+	limiter, err := m.getLimiter(ctx, tid)
+	if limiter == nil || err != nil {
+		return nil
+	}
+	return limiter.Allow(ctx)
+}
+
 func (m *QuotaManager) TenantLimit(ctx context.Context, tid tenant.Id) int {
 	limiter, err := m.getLimiter(ctx, tid)
 	if err != nil {
@@ -127,6 +199,15 @@ func (m *QuotaManager) TenantLimit(ctx context.Context, tid tenant.Id) int {
 	return limiter.Limit()
 }
 
+// TenantInstanceLimit returns the share of the tenant quota currently granted to this instance
+func (m *QuotaManager) TenantInstanceLimit(ctx context.Context, tid tenant.Id) int {
+	limiter, err := m.getLimiter(ctx, tid)
+	if limiter == nil || err != nil {
+		return 0
+	}
+	return limiter.AdaptiveLimit()
+}
+
 func (m *QuotaManager) SyncItem(ctx context.Context, tid tenant.Id, itemId string, add bool) error {
 	limiter, err := m.getLimiter(ctx, tid)
 	if limiter == nil {
@@ -135,17 +216,12 @@ func (m *QuotaManager) SyncItem(ctx context.Context, tid tenant.Id, itemId strin
 	if err != nil {
 		return err
 	}
-	config, err := m.tenantStorer.GetConfig(ctx, tid)
-	tenantRqs := 0
+	config, err := m.tenantConfig(ctx, tid)
 	if err != nil {
-		var tenantNotFound *tenant.TenantNotFoundError
-		if !errors.As(err, &tenantNotFound) {
-			return err
-		}
-	} else {
-		tenantRqs = config.Quota.EventsPerSec
+		return err
 	}
-	return limiter.SetLimit(tenantRqs)
+	limiter.setAlert(config.QuotaAlert)
+	return limiter.SetQuota(config.Quota)
 }
 
 //PublishQuota publishes tenant quota to ratelimiters in all nodes so they can sync to the new quota
@@ -172,41 +248,52 @@ func (m *QuotaManager) getLimiter(ctx context.Context, tid tenant.Id) (*QuotaLim
 		return limiter, nil
 	}
 
-	config, err := m.tenantStorer.GetConfig(ctx, tid)
-	tenantRqs := 0
+	config, err := m.tenantConfig(ctx, tid)
 	if err != nil {
-		var tenantNotFound *tenant.TenantNotFoundError
-		if !errors.As(err, &tenantNotFound) {
-			return nil, err
-		}
-	} else {
-		tenantRqs = config.Quota.EventsPerSec
+		return nil, err
 	}
+	quota := config.Quota
 
 	instanceCount := m.syncer.GetInstanceCount(ctx)
 	if instanceCount == 0 {
 		return nil, &NoEarsInstances{}
 	}
 
-	initialRqs := tenantRqs / instanceCount
+	initialRqs := quota.EventsPerSec / instanceCount
 
-	limiter = NewQuotaLimiter(tid, m.backendLimiterType, m.redisAddr, initialRqs, tenantRqs)
+	var backendLimiter ratelimit.RateLimiter
+	if m.redisClient != nil {
+		backendLimiter = redis.NewSharedRedisRateLimiter(m.redisClient, tid, quota.EventsPerSec)
+	} else {
+		backendLimiter = ratelimit.NewInMemoryBackendLimiter(tid, quota.EventsPerSec)
+	}
+	limiter = newQuotaLimiter(tid, backendLimiter, initialRqs, quota)
+	limiter.setAlert(config.QuotaAlert)
 	m.limiters[tid.Key()] = limiter
 	return limiter, nil
 }
 
-func (m *QuotaManager) syncAllItems() {
-	//make a copy of quota limiters
+//copyLimiters returns a copy of the quota limiters so they can be used without holding the lock
+func (m *QuotaManager) copyLimiters() []*QuotaLimiter {
 	m.lock.Lock()
+	defer m.lock.Unlock()
 	limiters := make([]*QuotaLimiter, len(m.limiters))
 	i := 0
 	for _, limiter := range m.limiters {
 		limiters[i] = limiter
 		i++
 	}
-	m.lock.Unlock()
+	return limiters
+}
 
+func (m *QuotaManager) syncAllItems() {
+	limiters := m.copyLimiters()
+
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	for _, limiter := range limiters {
-		m.SyncItem(m.ctx, limiter.tid, "ignored", true)
+		m.SyncItem(ctx, limiter.tid, "ignored", true)
 	}
 }
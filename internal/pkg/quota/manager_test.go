@@ -157,6 +157,80 @@ func TestQuotaManagerRateLimit(t *testing.T) {
 	quotaMgr.Stop()
 }
 
+func TestQuotaManagerDefaultQuota(t *testing.T) {
+	tenantStorer := db.NewTenantInmemoryStorer()
+	ctx := context.Background()
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myDefaultQuotaApp"}
+
+	quotaMgr, err := setup(tenantStorer)
+	if err != nil {
+		t.Fatalf("Fail to start quota manager %s\n", err.Error())
+	}
+	quotaMgr.Start()
+	defer quotaMgr.Stop()
+
+	if quotaMgr.TenantLimit(ctx, tid) != 0 {
+		t.Fatalf("Expect limit=0 instead, got limit=%d\n", quotaMgr.TenantLimit(ctx, tid))
+	}
+
+	config := testConfig().(*viper.Viper)
+	config.Set("ears.ratelimiter.defaultEventsPerSec", 15)
+	err = quotaMgr.ReloadConfig(config)
+	if err != nil {
+		t.Fatalf("Fail to reload config %s\n", err.Error())
+	}
+	if quotaMgr.TenantLimit(ctx, tid) != 15 {
+		t.Fatalf("Expect limit=15 instead, got limit=%d\n", quotaMgr.TenantLimit(ctx, tid))
+	}
+
+	//a tenant quota takes precedence over the default
+	tenantStorer.SetConfig(ctx, tenant.Config{Tenant: tid, Quota: tenant.Quota{EventsPerSec: 5}})
+	quotaMgr.SyncItem(ctx, tid, "", true)
+	if quotaMgr.TenantLimit(ctx, tid) != 5 {
+		t.Fatalf("Expect limit=5 instead, got limit=%d\n", quotaMgr.TenantLimit(ctx, tid))
+	}
+}
+
+func TestQuotaManagerUtilizationAlert(t *testing.T) {
+	tenantStorer := db.NewTenantInmemoryStorer()
+	ctx := context.Background()
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myAlertApp"}
+	tenantStorer.SetConfig(ctx, tenant.Config{
+		Tenant:     tid,
+		Quota:      tenant.Quota{EventsPerSec: 10},
+		QuotaAlert: &tenant.QuotaAlert{Thresholds: []int{30, 500}, RouteId: "alerts"},
+	})
+
+	quotaMgr, err := setup(tenantStorer)
+	if err != nil {
+		t.Fatalf("Fail to start quota manager %s\n", err.Error())
+	}
+	alerts := make(chan quota.UtilizationAlert, 10)
+	quotaMgr.SetAlertHandler(func(ctx context.Context, config tenant.QuotaAlert, alert quota.UtilizationAlert) {
+		if config.RouteId != "alerts" {
+			t.Errorf("Unexpected quota alert config %+v\n", config)
+		}
+		alerts <- alert
+	})
+	quotaMgr.Start()
+	defer quotaMgr.Stop()
+
+	//consume about 8 events per second
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		quotaMgr.Wait(ctx, tid)
+		select {
+		case alert := <-alerts:
+			if alert.Tenant != tid || alert.EventsPerSec != 10 || alert.Threshold != 30 || alert.Utilization < 30 {
+				t.Fatalf("Unexpected quota alert %+v\n", alert)
+			}
+			return
+		case <-time.After(125 * time.Millisecond):
+		}
+	}
+	t.Fatalf("Expect a quota alert")
+}
+
 var TestErr_FailToReachRps = errors.New("Cannot reach desired RPS")
 
 func validateQuotaMgrRps(mgr *quota.QuotaManager, tid tenant.Id, rps int) error {
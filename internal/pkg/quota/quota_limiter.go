@@ -18,9 +18,14 @@ import (
 	"context"
 	"errors"
 	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/ratelimit/redis"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"sync"
 	"time"
 )
 
@@ -29,6 +34,14 @@ type QuotaLimiter struct {
 	tid             tenant.Id
 	adaptiveLimiter *ratelimit.AdaptiveRateLimiter
 	wakeup          chan bool
+	throttled       metric.BoundInt64Counter
+
+	usageLock  sync.Mutex
+	usageStart time.Time //start of the current one second usage window
+	usageCount int       //events taken in the current window
+	lastUsage  int       //events taken in the previous window
+	alert      *tenant.QuotaAlert
+	alertLevel int //highest alert threshold the utilization is currently above
 }
 
 func NewQuotaLimiter(tid tenant.Id, backendLimiterType string, redisAddr string, initialRqs int, tenantRqs int) *QuotaLimiter {
@@ -39,15 +52,33 @@ func NewQuotaLimiter(tid tenant.Id, backendLimiterType string, redisAddr string,
 	} else {
 		backendLimiter = ratelimit.NewInMemoryBackendLimiter(tid, tenantRqs)
 	}
-	limiter := ratelimit.NewAdaptiveRateLimiter(backendLimiter, initialRqs, tenantRqs)
+	return newQuotaLimiter(tid, backendLimiter, initialRqs, tenant.Quota{EventsPerSec: tenantRqs})
+}
+
+func newQuotaLimiter(tid tenant.Id, backendLimiter ratelimit.RateLimiter, initialRqs int, quota tenant.Quota) *QuotaLimiter {
+	limiter := ratelimit.NewAdaptiveRateLimiter(backendLimiter, initialRqs, quota.EventsPerSec)
+	limiter.SetBurst(quota.Burst)
 
 	return &QuotaLimiter{
 		tid:             tid,
 		adaptiveLimiter: limiter,
 		wakeup:          make(chan bool),
+		usageStart:      time.Now(),
+		throttled: metric.Must(global.Meter(rtsemconv.EARSMeterName)).
+			NewInt64Counter(
+				rtsemconv.EARSMetricTenantThrottled,
+				metric.WithDescription("measures the number of events of a tenant rejected because the tenant is out of quota"),
+			).Bind(
+			attribute.String(rtsemconv.EARSAppIdLabel, tid.AppId),
+			attribute.String(rtsemconv.EARSOrgIdLabel, tid.OrgId),
+		),
 	}
 }
 
+//quotaRetryAfter is how long a throttled client should wait before retrying. The adaptive limiter
+//retunes its share of the quota once a second.
+const quotaRetryAfter = time.Second
+
 func (r *QuotaLimiter) Wait(ctx context.Context) error {
 	for {
 		err := r.Take(ctx, 1)
@@ -56,14 +87,22 @@ func (r *QuotaLimiter) Wait(ctx context.Context) error {
 		}
 		sleepTO := time.Second * 5
 		var limitReached *ratelimit.LimitReached
-		if errors.As(err, &limitReached) {
+		throttled := errors.As(err, &limitReached)
+		if throttled {
 			//TODO figure out what's the optimal way of waiting
 			sleepTO = time.Millisecond * 100
 		} else {
 			log.Ctx(ctx).Error().Str("op", "QuotaLimiter.Wait").Str("error", err.Error()).Msg("Error taking quota")
 		}
+		if deadline, ok := ctx.Deadline(); ok && throttled && time.Now().Add(sleepTO).After(deadline) {
+			//give up before the deadline so that the event is nacked as throttled rather than timed out
+			return r.quotaExceeded(ctx)
+		}
 		select {
 		case <-ctx.Done():
+			if throttled {
+				return r.quotaExceeded(ctx)
+			}
 			return &ratelimit.ContextCancelled{}
 		case <-r.wakeup:
 			//keep looping
@@ -73,8 +112,89 @@ func (r *QuotaLimiter) Wait(ctx context.Context) error {
 	}
 }
 
+//Allow takes one unit of quota without waiting. It returns a QuotaExceeded error if the tenant is out of quota.
+func (r *QuotaLimiter) Allow(ctx context.Context) error {
+	err := r.Take(ctx, 1)
+	if err == nil {
+		return nil
+	}
+	var limitReached *ratelimit.LimitReached
+	if errors.As(err, &limitReached) {
+		return r.quotaExceeded(ctx)
+	}
+	return err
+}
+
+func (r *QuotaLimiter) quotaExceeded(ctx context.Context) error {
+	r.throttled.Add(ctx, 1)
+	return &ratelimit.QuotaExceeded{
+		Tenant:       r.tid,
+		EventsPerSec: r.Limit(),
+		Burst:        r.Burst(),
+		RetryAfter:   quotaRetryAfter,
+	}
+}
+
 func (r *QuotaLimiter) Take(ctx context.Context, unit int) error {
-	return r.adaptiveLimiter.Take(ctx, unit)
+	err := r.adaptiveLimiter.Take(ctx, unit)
+	if err == nil {
+		r.usageLock.Lock()
+		r.rollUsage(time.Now())
+		r.usageCount += unit
+		r.usageLock.Unlock()
+	}
+	return err
+}
+
+//Usage returns the number of events taken during the last second
+func (r *QuotaLimiter) Usage() int {
+	r.usageLock.Lock()
+	defer r.usageLock.Unlock()
+	r.rollUsage(time.Now())
+	return r.lastUsage
+}
+
+func (r *QuotaLimiter) rollUsage(now time.Time) {
+	elapsed := now.Sub(r.usageStart)
+	if elapsed < time.Second {
+		return
+	}
+	if elapsed < 2*time.Second {
+		r.lastUsage = r.usageCount
+	} else {
+		//no events in the previous window
+		r.lastUsage = 0
+	}
+	r.usageCount = 0
+	r.usageStart = r.usageStart.Add(elapsed.Truncate(time.Second))
+}
+
+func (r *QuotaLimiter) setAlert(alert *tenant.QuotaAlert) {
+	r.usageLock.Lock()
+	defer r.usageLock.Unlock()
+	r.alert = alert
+}
+
+//checkUtilization returns the alert config and the threshold if the utilization rose above a threshold since the
+//last check
+func (r *QuotaLimiter) checkUtilization(utilization int) (*tenant.QuotaAlert, int) {
+	r.usageLock.Lock()
+	defer r.usageLock.Unlock()
+	if r.alert == nil {
+		return nil, 0
+	}
+	level := 0
+	for _, threshold := range r.alert.Thresholds {
+		if utilization >= threshold && threshold > level {
+			level = threshold
+		}
+	}
+	crossed := level > r.alertLevel
+	r.alertLevel = level
+	if !crossed {
+		return nil, 0
+	}
+	return r.alert, level
 }
 
 func (r *QuotaLimiter) Limit() int {
@@ -85,13 +205,26 @@ func (r *QuotaLimiter) AdaptiveLimit() int {
 	return r.adaptiveLimiter.AdaptiveLimit()
 }
 
+func (r *QuotaLimiter) Burst() int {
+	return r.adaptiveLimiter.Burst()
+}
+
 func (r *QuotaLimiter) SetLimit(newLimit int) error {
-	if r.Limit() == newLimit {
-		//limit is not changed
+	return r.SetQuota(tenant.Quota{EventsPerSec: newLimit, Burst: r.Burst()})
+}
+
+// SetQuota sets both the sustained rate and the burst of the limiter
+func (r *QuotaLimiter) SetQuota(quota tenant.Quota) error {
+	if r.Limit() == quota.EventsPerSec && r.Burst() == quota.Burst {
+		//quota is not changed
 		return nil
 	}
 
-	err := r.adaptiveLimiter.SetLimit(newLimit)
+	err := r.adaptiveLimiter.SetLimit(quota.EventsPerSec)
+	if err != nil {
+		return err
+	}
+	err = r.adaptiveLimiter.SetBurst(quota.Burst)
 	if err != nil {
 		return err
 	}
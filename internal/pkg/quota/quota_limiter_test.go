@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/xmidt-org/ears/internal/pkg/quota"
+	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"testing"
 	"time"
@@ -107,6 +108,33 @@ func TestQuotaLimiter(t *testing.T) {
 	}
 }
 
+func TestQuotaLimiterExceeded(t *testing.T) {
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myThrottledApp"}
+	limiter := quota.NewQuotaLimiter(tid, "inmemory", "", 1, 1)
+
+	ctx := context.Background()
+	err := limiter.Allow(ctx)
+	if err != nil {
+		t.Fatalf("Expect first event to be allowed, error=%s\n", err.Error())
+	}
+	err = limiter.Allow(ctx)
+	var quotaExceeded *ratelimit.QuotaExceeded
+	if !errors.As(err, &quotaExceeded) {
+		t.Fatalf("Expect QuotaExceeded error, got %v\n", err)
+	}
+	if quotaExceeded.Tenant != tid || quotaExceeded.EventsPerSec != 1 || quotaExceeded.RetryAfterSeconds() != 1 {
+		t.Fatalf("Unexpected QuotaExceeded error %s\n", quotaExceeded.Error())
+	}
+
+	//a deadline too short to wait for quota fails as throttled rather than cancelled
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = limiter.Wait(ctx)
+	if !errors.As(err, &quotaExceeded) {
+		t.Fatalf("Expect QuotaExceeded error, got %v\n", err)
+	}
+}
+
 func validateRps(limiter *quota.QuotaLimiter, rps int) error {
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -0,0 +1,105 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"context"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"time"
+)
+
+// UtilizationAlert is raised when the quota utilization of a tenant rises above one of the thresholds of its quota alert
+type UtilizationAlert struct {
+	Tenant       tenant.Id `json:"tenant"`
+	EventsPerSec int       `json:"eventsPerSec"`    // tenant quota
+	Burst        int       `json:"burst,omitempty"` // tenant burst
+	Usage        int       `json:"usage"`           // events per second taken by the instance raising the alert
+	Utilization  int       `json:"utilization"`     // usage in percent of eventsPerSec
+	Threshold    int       `json:"threshold"`       // threshold the utilization rose above
+	Timestamp    int64     `json:"timestamp"`       // time of the alert in ms since epoch
+}
+
+// AlertHandler delivers a utilization alert to the destinations of the tenant's quota alert
+type AlertHandler func(ctx context.Context, config tenant.QuotaAlert, alert UtilizationAlert)
+
+// SetAlertHandler sets the handler called when the quota utilization of a tenant rises above a threshold
+func (m *QuotaManager) SetAlertHandler(handler AlertHandler) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.alertHandler = handler
+}
+
+// checkUtilization raises an alert for each tenant whose utilization rose above a threshold since the last check
+func (m *QuotaManager) checkUtilization(ctx context.Context) {
+	m.lock.Lock()
+	handler := m.alertHandler
+	m.lock.Unlock()
+	for _, limiter := range m.copyLimiters() {
+		limit := limiter.Limit()
+		if limit <= 0 {
+			continue
+		}
+		usage := limiter.Usage()
+		utilization := usage * 100 / limit
+		alertConfig, threshold := limiter.checkUtilization(utilization)
+		if alertConfig == nil {
+			continue
+		}
+		m.logger.Info().Str("op", "QuotaManager.checkUtilization").Str("tenantId", limiter.tid.ToString()).
+			Int("utilization", utilization).Int("threshold", threshold).Msg("quota utilization above threshold")
+		if handler == nil {
+			continue
+		}
+		go handler(ctx, *alertConfig, UtilizationAlert{
+			Tenant:       limiter.tid,
+			EventsPerSec: limit,
+			Burst:        limiter.Burst(),
+			Usage:        usage,
+			Utilization:  utilization,
+			Threshold:    threshold,
+			Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+		})
+	}
+}
+
+// registerMetrics reports the quota and the quota usage of all tenants known to this instance
+func (m *QuotaManager) registerMetrics() {
+	var quotaGauge, usageGauge metric.Int64GaugeObserver
+	batch := metric.Must(global.Meter(rtsemconv.EARSMeterName)).NewBatchObserver(
+		func(ctx context.Context, result metric.BatchObserverResult) {
+			for _, limiter := range m.copyLimiters() {
+				result.Observe(
+					[]attribute.KeyValue{
+						attribute.String(rtsemconv.EARSAppIdLabel, limiter.tid.AppId),
+						attribute.String(rtsemconv.EARSOrgIdLabel, limiter.tid.OrgId),
+					},
+					quotaGauge.Observation(int64(limiter.Limit())),
+					usageGauge.Observation(int64(limiter.Usage())),
+				)
+			}
+		})
+	quotaGauge = batch.NewInt64GaugeObserver(
+		rtsemconv.EARSMetricTenantQuota,
+		metric.WithDescription("measures the quota of a tenant in events per second"),
+	)
+	usageGauge = batch.NewInt64GaugeObserver(
+		rtsemconv.EARSMetricTenantQuotaUsage,
+		metric.WithDescription("measures the events per second of a tenant taken from its quota by this instance"),
+	)
+}
@@ -36,9 +36,11 @@ const (
 	EARSPluginTypeHttpSender    = "httpSender"
 	EARSPluginTypeRedisSender   = "redisSender"
 	EARSPluginTypeDiscordSender = "discordSender"
+	EARSPluginTypeSFTPSender    = "sftpSender"
 
 	EARSPluginTypeMetricFilter = "metricFilter"
 	EARSPluginTypeTtlFilter    = "ttlFilter"
+	EARSPluginTypeDedupFilter  = "dedupFilter"
 
 	EARSPluginTypeNopReceiver     = "nopReceiver"
 	EARSPluginTypeDebugReceiver   = "debugReceiver"
@@ -57,25 +59,39 @@ const (
 	EARSMetricEventSendOutTime    = "ears.eventSendOutTime"
 	EARSMetricEventQueueDepth     = "ears.eventQueueDepth"
 	EARSMetricEventTtlExpiration  = "ears.eventTtlExpiration"
+	EARSMetricDedupTableSize      = "ears.dedupTableSize"
 	EARSMetricAddRouteSuccess     = "ears.addRouteSuccess"
 	EARSMetricAddRouteFailure     = "ears.addRouteFailure"
 	EARSMetricRemoveRouteSuccess  = "ears.removeRouteSuccess"
 	EARSMetricRemoveRouteFailure  = "ears.removeRouteFailure"
 	EARSMetricMillisBehindLatest  = "ears.millisBehindLatest"
 	EARSMetricTrueLagMillis       = "ears.trueLagMillis"
+	EARSMetricRouteWorkersBusy    = "ears.routeWorkersBusy"
+	EARSMetricRouteWorkersWaits   = "ears.routeWorkersWaits"
+	EARSMetricRouteEventsExpired  = "ears.routeEventsExpired"
+	EARSMetricTenantThrottled     = "ears.tenantThrottled"
+	EARSMetricTenantQuota         = "ears.tenantQuota"
+	EARSMetricTenantQuotaUsage    = "ears.tenantQuotaUsage"
+	EARSMetricReceiverQueueDepth  = "ears.receiverQueueDepth"
+	EARSMetricReceiverConsumerLag = "ears.receiverConsumerLag"
+	EARSMetricReceiverIteratorAge = "ears.receiverIteratorAgeMillis"
+	EARSMetricFilterDuration      = "ears.filterDuration"
 
 	EARSRouteId    = attribute.Key("ears.routeId")
 	EARSFragmentId = attribute.Key("ears.fragmentId")
 
+	EARSRouteLabelPrefix = "ears.label." // prefix of route labels added to event metrics
+
 	EARSAppId = attribute.Key("ears.appId")
 	EARSOrgId = attribute.Key("ears.orgId")
 
 	EARSInstanceId = attribute.Key("ears.instance")
 	EARSTraceId    = attribute.Key("trace.id")
 
-	EARSAppIdLabel   = "ears.appId"
-	EARSOrgIdLabel   = "ears.orgId"
-	EARSReceiverName = "ears.receiver"
+	EARSAppIdLabel     = "ears.appId"
+	EARSOrgIdLabel     = "ears.orgId"
+	EARSReceiverName   = "ears.receiver"
+	EARSPartitionLabel = "ears.partition"
 
 	DBTable = attribute.Key("db.table")
 
@@ -84,6 +100,7 @@ const (
 	RedisChannelLabel      = "redis.channel"
 	SQSQueueUrlLabel       = "sqs.QueueUrl"
 	S3Bucket               = "s3.Bucket"
+	SFTPHostLabel          = "sftp.Host"
 	KinesisStreamNameLabel = "kinesis.StreamName"
 	KinesisShardIdxLabel   = "kinesis.ShardIdx"
 	HostnameLabel          = "hostname"
@@ -98,4 +115,7 @@ var (
 	EARSAPITrace   = attribute.Key("ears.op").String("api")
 
 	DBSystemInMemory = semconv.DBSystemKey.String("inmemory")
+	DBSystemBolt     = semconv.DBSystemKey.String("bolt")
+	DBSystemFs       = semconv.DBSystemKey.String("fs")
+	DBSystemEtcd     = semconv.DBSystemKey.String("etcd")
 )
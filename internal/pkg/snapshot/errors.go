@@ -0,0 +1,52 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+type MissingConfigError struct {
+	configName string
+}
+
+func (e *MissingConfigError) Error() string {
+	return "MissingConfigError (configName=" + e.configName + ")"
+}
+
+type BadSnapshotUrlError struct {
+	Url string
+}
+
+func (e *BadSnapshotUrlError) Error() string {
+	return "BadSnapshotUrlError (url=" + e.Url + ")"
+}
+
+type SnapshotNotFoundError struct {
+	Url string
+}
+
+func (e *SnapshotNotFoundError) Error() string {
+	return "SnapshotNotFoundError (url=" + e.Url + ")"
+}
+
+type SnapshotError struct {
+	Op     string
+	Source error
+}
+
+func (e *SnapshotError) Error() string {
+	return "SnapshotError (op=" + e.Op + "): " + e.Source.Error()
+}
+
+func (e *SnapshotError) Unwrap() error {
+	return e.Source
+}
@@ -0,0 +1,307 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/fragments"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// SnapshotManager periodically writes snapshots of all routes, fragments and tenant configs to an object store
+// such as S3 so that they can be restored independently of the storers, e.g. after losing a DynamoDB table
+type SnapshotManager struct {
+	sync.Mutex
+	routeStorer    route.RouteStorer
+	fragmentStorer fragments.FragmentStorer
+	tenantStorer   tenant.TenantStorer
+	store          ObjectStore
+	url            string
+	interval       time.Duration
+	retention      int
+	logger         *zerolog.Logger
+	done           chan struct{}
+}
+
+// NewSnapshotManager returns a snapshot manager writing to the location configured as ears.snapshot.url, e.g.
+// s3://bucket/ears/prod
+func NewSnapshotManager(config Config, store ObjectStore, routeStorer route.RouteStorer, fragmentStorer fragments.FragmentStorer, tenantStorer tenant.TenantStorer, logger *zerolog.Logger) (*SnapshotManager, error) {
+	url := strings.TrimSuffix(config.GetString("ears.snapshot.url"), "/")
+	if url == "" {
+		return nil, &MissingConfigError{"ears.snapshot.url"}
+	}
+	if _, _, err := splitUrl(url); err != nil {
+		return nil, err
+	}
+	interval := config.GetInt("ears.snapshot.intervalSecs")
+	if interval == 0 {
+		interval = DEFAULT_INTERVAL_SECS
+	}
+	retention := config.GetInt("ears.snapshot.retention")
+	if retention == 0 {
+		retention = DEFAULT_RETENTION
+	}
+	return &SnapshotManager{
+		routeStorer:    routeStorer,
+		fragmentStorer: fragmentStorer,
+		tenantStorer:   tenantStorer,
+		store:          store,
+		url:            url,
+		interval:       time.Duration(interval) * time.Second,
+		retention:      retention,
+		logger:         logger,
+	}, nil
+}
+
+// Start writes a snapshot every interval. Snapshots are named after the start of the interval so that all
+// instances of a cluster write the same object instead of one snapshot each. A negative interval disables
+// periodic snapshots.
+func (m *SnapshotManager) Start() {
+	if m.interval < 0 {
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.done = make(chan struct{})
+	go func(done chan struct{}) {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				url, err := m.Save(context.Background(), now.Truncate(m.interval))
+				if err != nil {
+					m.logger.Error().Str("op", "SnapshotManager.Start").Msg("failed to save snapshot: " + err.Error())
+				} else {
+					m.logger.Info().Str("op", "SnapshotManager.Start").Str("url", url).Msg("saved snapshot")
+				}
+			}
+		}
+	}(m.done)
+}
+
+func (m *SnapshotManager) Stop() {
+	m.Lock()
+	defer m.Unlock()
+	if m.done != nil {
+		close(m.done)
+		m.done = nil
+	}
+}
+
+// Take reads all items from the storers
+func (m *SnapshotManager) Take(ctx context.Context) (*Snapshot, error) {
+	return Collect(ctx, m.routeStorer, m.fragmentStorer, m.tenantStorer)
+}
+
+// Collect reads all items from the given storers. Fragments are read for every tenant that has a config or routes.
+func Collect(ctx context.Context, routeStorer route.RouteStorer, fragmentStorer fragments.FragmentStorer, tenantStorer tenant.TenantStorer) (*Snapshot, error) {
+	hostname, _ := os.Hostname()
+	s := &Snapshot{
+		Created:   time.Now().Unix(),
+		Hostname:  hostname,
+		Fragments: make([]TenantFragments, 0),
+	}
+	var err error
+	s.Tenants, err = tenantStorer.GetAllConfigs(ctx)
+	if err != nil {
+		return nil, &SnapshotError{"getTenants", err}
+	}
+	s.Routes, err = routeStorer.GetAllRoutes(ctx)
+	if err != nil {
+		return nil, &SnapshotError{"getRoutes", err}
+	}
+	tenants := make(map[string]tenant.Id)
+	for _, t := range s.Tenants {
+		tenants[t.Tenant.Key()] = t.Tenant
+	}
+	for _, r := range s.Routes {
+		tenants[r.TenantId.Key()] = r.TenantId
+	}
+	keys := make([]string, 0, len(tenants))
+	for key := range tenants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tenantFragments, err := fragmentStorer.GetAllTenantFragments(ctx, tenants[key])
+		if err != nil {
+			return nil, &SnapshotError{"getFragments", err}
+		}
+		if len(tenantFragments) > 0 {
+			s.Fragments = append(s.Fragments, TenantFragments{Tenant: tenants[key], Fragments: tenantFragments})
+		}
+	}
+	return s, nil
+}
+
+// Save takes a snapshot, writes it as an object named after the given time and deletes the oldest snapshots
+// exceeding the retention
+func (m *SnapshotManager) Save(ctx context.Context, t time.Time) (string, error) {
+	s, err := m.Take(ctx)
+	if err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return "", &SnapshotError{"marshal", err}
+	}
+	url := m.url + "/" + OBJECT_PREFIX + t.UTC().Format("20060102T150405Z") + ".json"
+	err = m.store.PutObject(url, string(buf))
+	if err != nil {
+		return "", &SnapshotError{"put", err}
+	}
+	urls, err := m.List(ctx)
+	if err != nil {
+		return url, err
+	}
+	for i := m.retention; i < len(urls); i++ {
+		err = m.store.DeleteObject(urls[i])
+		if err != nil {
+			m.logger.Error().Str("op", "SnapshotManager.Save").Str("url", urls[i]).Msg("failed to delete old snapshot: " + err.Error())
+		}
+	}
+	return url, nil
+}
+
+// List returns the urls of all snapshots, newest first
+func (m *SnapshotManager) List(ctx context.Context) ([]string, error) {
+	bucket, path, _ := splitUrl(m.url)
+	keys, err := m.store.ListFiles(m.url + "/" + OBJECT_PREFIX)
+	if err != nil {
+		return nil, &SnapshotError{"list", err}
+	}
+	prefix := OBJECT_PREFIX
+	if path != "" {
+		prefix = path + "/" + OBJECT_PREFIX
+	}
+	urls := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// only snapshots directly under the configured path, not those of other clusters sharing a prefix
+		if strings.HasPrefix(key, prefix) && !strings.Contains(key[len(prefix):], "/") {
+			urls = append(urls, "s3://"+bucket+"/"+key)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(urls)))
+	return urls, nil
+}
+
+// Load reads a snapshot, the url "latest" refers to the newest snapshot
+func (m *SnapshotManager) Load(ctx context.Context, url string) (*Snapshot, error) {
+	if url == "" || url == "latest" {
+		urls, err := m.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(urls) == 0 {
+			return nil, &SnapshotNotFoundError{m.url + "/latest"}
+		}
+		url = urls[0]
+	}
+	if _, _, err := splitUrl(url); err != nil {
+		return nil, err
+	}
+	data, err := m.store.GetObject(url)
+	if err != nil {
+		return nil, &SnapshotNotFoundError{url}
+	}
+	var s Snapshot
+	err = json.Unmarshal([]byte(data), &s)
+	if err != nil {
+		return nil, &SnapshotError{"unmarshal", err}
+	}
+	return &s, nil
+}
+
+// Restore writes all items of a snapshot, items that are not part of the snapshot are kept. Items are written
+// through the restore target if there is one, otherwise they are written to the storers and routes are picked up
+// by the periodic sync of running instances. Items that cannot be restored are reported in the result and do not
+// stop the restore.
+func (m *SnapshotManager) Restore(ctx context.Context, s *Snapshot, target RestoreTarget) *RestoreResult {
+	if target == nil {
+		target = &storerTarget{m}
+	}
+	result := &RestoreResult{}
+	for _, config := range s.Tenants {
+		err := target.SetTenantConfig(ctx, config)
+		if err != nil {
+			result.Errors = append(result.Errors, "tenant "+config.Tenant.ToString()+": "+err.Error())
+			continue
+		}
+		result.Tenants++
+	}
+	for _, tf := range s.Fragments {
+		for _, fragment := range tf.Fragments {
+			err := target.AddFragment(ctx, tf.Tenant, fragment)
+			if err != nil {
+				result.Errors = append(result.Errors, "fragment "+fragment.FragmentName+" of "+tf.Tenant.ToString()+": "+err.Error())
+				continue
+			}
+			result.Fragments++
+		}
+	}
+	for _, r := range s.Routes {
+		err := target.AddRoute(ctx, &r)
+		if err != nil {
+			result.Errors = append(result.Errors, "route "+r.Id+" of "+r.TenantId.ToString()+": "+err.Error())
+			continue
+		}
+		result.Routes++
+	}
+	return result
+}
+
+// storerTarget restores items by writing them straight to the storers
+type storerTarget struct {
+	m *SnapshotManager
+}
+
+func (t *storerTarget) SetTenantConfig(ctx context.Context, config tenant.Config) error {
+	return t.m.tenantStorer.SetConfig(ctx, config)
+}
+
+func (t *storerTarget) AddFragment(ctx context.Context, tid tenant.Id, fragmentConfig route.PluginConfig) error {
+	return t.m.fragmentStorer.SetFragment(ctx, tid, fragmentConfig)
+}
+
+func (t *storerTarget) AddRoute(ctx context.Context, routeConfig *route.Config) error {
+	return t.m.routeStorer.SetRoute(ctx, *routeConfig)
+}
+
+// splitUrl splits s3://bucket/path into bucket and path
+func splitUrl(url string) (string, string, error) {
+	if !strings.HasPrefix(url, "s3://") {
+		return "", "", &BadSnapshotUrlError{url}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(url, "s3://"), "/", 2)
+	if parts[0] == "" {
+		return "", "", &BadSnapshotUrlError{url}
+	}
+	if len(parts) == 1 {
+		return parts[0], "", nil
+	}
+	return parts[0], parts[1], nil
+}
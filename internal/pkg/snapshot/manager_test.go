@@ -0,0 +1,155 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/snapshot"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// memoryStore is an object store keeping objects in a map keyed by url
+type memoryStore struct {
+	sync.Mutex
+	objects map[string]string
+}
+
+func (s *memoryStore) PutObject(url string, data string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.objects[url] = data
+	return nil
+}
+
+func (s *memoryStore) GetObject(url string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	data, ok := s.objects[url]
+	if !ok {
+		return "", errors.New("no such key")
+	}
+	return data, nil
+}
+
+// ListFiles returns the keys without bucket like the s3 client
+func (s *memoryStore) ListFiles(url string) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	keys := make([]string, 0)
+	for u := range s.objects {
+		if strings.HasPrefix(u, url) {
+			keys = append(keys, strings.SplitN(strings.TrimPrefix(u, "s3://"), "/", 2)[1])
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memoryStore) DeleteObject(url string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.objects, url)
+	return nil
+}
+
+func newManager(t *testing.T, store *memoryStore) (*snapshot.SnapshotManager, *db.InMemoryRouteStorer, *db.InMemoryFragmentStorer, *db.InMemoryStorer) {
+	v := viper.New()
+	v.Set("ears.snapshot.url", "s3://bucket/ears/test/")
+	v.Set("ears.snapshot.retention", 2)
+	logger := zerolog.Nop()
+	routeStorer := db.NewInMemoryRouteStorer(v)
+	fragmentStorer := db.NewInMemoryFragmentStorer(v)
+	tenantStorer := db.NewTenantInmemoryStorer()
+	m, err := snapshot.NewSnapshotManager(v, store, routeStorer, fragmentStorer, tenantStorer, &logger)
+	if err != nil {
+		t.Fatalf("Fail to create snapshot manager %s\n", err.Error())
+	}
+	return m, routeStorer, fragmentStorer, tenantStorer
+}
+
+func TestSnapshotSaveRestore(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{objects: make(map[string]string)}
+	// snapshots of other clusters sharing the prefix are ignored
+	store.objects["s3://bucket/ears/test/other/ears-snapshot-20200101T000000Z.json"] = "{}"
+	m, routeStorer, fragmentStorer, tenantStorer := newManager(t, store)
+	tid := tenant.Id{OrgId: "myOrg", AppId: "myApp"}
+	tenantStorer.SetConfig(ctx, tenant.Config{Tenant: tid, Quota: tenant.Quota{EventsPerSec: 10}})
+	fragmentStorer.SetFragment(ctx, tid, route.PluginConfig{FragmentName: "f1", Plugin: "debug"})
+	routeStorer.SetRoute(ctx, route.Config{Id: "r1", TenantId: tid, Receiver: route.PluginConfig{Plugin: "debug"}})
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := m.Save(ctx, start.Add(time.Duration(i)*time.Hour))
+		if err != nil {
+			t.Fatalf("Fail to save snapshot %s\n", err.Error())
+		}
+	}
+	urls, err := m.List(ctx)
+	if err != nil {
+		t.Fatalf("Fail to list snapshots %s\n", err.Error())
+	}
+	expected := []string{"s3://bucket/ears/test/ears-snapshot-20210101T020000Z.json", "s3://bucket/ears/test/ears-snapshot-20210101T010000Z.json"}
+	if strings.Join(urls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected snapshots %v\n", urls)
+	}
+	// restore into empty storers
+	m2, routeStorer2, fragmentStorer2, tenantStorer2 := newManager(t, store)
+	s, err := m2.Load(ctx, "latest")
+	if err != nil {
+		t.Fatalf("Fail to load snapshot %s\n", err.Error())
+	}
+	result := m2.Restore(ctx, s, nil)
+	if result.Tenants != 1 || result.Fragments != 1 || result.Routes != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected restore result %v\n", result)
+	}
+	config, err := tenantStorer2.GetConfig(ctx, tid)
+	if err != nil || config.Quota.EventsPerSec != 10 {
+		t.Fatalf("tenant config not restored %v %v\n", config, err)
+	}
+	_, err = fragmentStorer2.GetFragment(ctx, tid, "f1")
+	if err != nil {
+		t.Fatalf("fragment not restored %s\n", err.Error())
+	}
+	_, err = routeStorer2.GetRoute(ctx, tid, "r1")
+	if err != nil {
+		t.Fatalf("route not restored %s\n", err.Error())
+	}
+}
+
+func TestSnapshotLoadErrors(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, _ := newManager(t, &memoryStore{objects: make(map[string]string)})
+	var notFound *snapshot.SnapshotNotFoundError
+	_, err := m.Load(ctx, "latest")
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected SnapshotNotFoundError, got %v\n", err)
+	}
+	var badUrl *snapshot.BadSnapshotUrlError
+	_, err = m.Load(ctx, "/tmp/snapshot.json")
+	if !errors.As(err, &badUrl) {
+		t.Fatalf("expected BadSnapshotUrlError, got %v\n", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"context"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	// OBJECT_PREFIX is the common prefix of the names of all snapshot objects
+	OBJECT_PREFIX = "ears-snapshot-"
+
+	DEFAULT_INTERVAL_SECS = 3600
+	DEFAULT_RETENTION     = 24
+)
+
+type (
+	// Snapshot is a copy of all routes, fragments and tenant configs at a point in time
+	Snapshot struct {
+		Created   int64             `json:"created"`            // time the snapshot was taken, in unix timestamp seconds
+		Hostname  string            `json:"hostname,omitempty"` // instance that took the snapshot
+		Tenants   []tenant.Config   `json:"tenants"`
+		Fragments []TenantFragments `json:"fragments"`
+		Routes    []route.Config    `json:"routes"`
+	}
+
+	// TenantFragments are the fragments of a tenant, fragment configs do not carry their tenant
+	TenantFragments struct {
+		Tenant    tenant.Id            `json:"tenant"`
+		Fragments []route.PluginConfig `json:"fragments"`
+	}
+
+	// RestoreResult counts the restored items and lists the items that could not be restored
+	RestoreResult struct {
+		Tenants   int      `json:"tenants"`
+		Fragments int      `json:"fragments"`
+		Routes    int      `json:"routes"`
+		Errors    []string `json:"errors,omitempty"`
+	}
+
+	// ObjectStore stores snapshots as objects addressed by urls such as s3://bucket/path/name, it is implemented
+	// by the s3 client
+	ObjectStore interface {
+		PutObject(url string, data string) error
+		GetObject(url string) (string, error)
+		ListFiles(url string) ([]string, error)
+		DeleteObject(url string) error
+	}
+
+	// RestoreTarget takes the items of a snapshot restored into a running instance, it writes them the same way the
+	// api does so that restored routes are started right away and all instances pick up the restored items
+	RestoreTarget interface {
+		SetTenantConfig(ctx context.Context, config tenant.Config) error
+		AddFragment(ctx context.Context, tid tenant.Id, fragmentConfig route.PluginConfig) error
+		AddRoute(ctx context.Context, routeConfig *route.Config) error
+	}
+
+	Config interface {
+		GetString(key string) string
+		GetInt(key string) int
+		GetBool(key string) bool
+	}
+)
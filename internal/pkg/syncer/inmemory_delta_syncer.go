@@ -192,3 +192,7 @@ func (s *InmemoryDeltaSyncer) GetInstanceCount(ctx context.Context) int {
 
 	return len(syncerGroup.syncers)
 }
+
+func (s *InmemoryDeltaSyncer) CheckHealth(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,569 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/logs"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+
+	// sync requests and instance heartbeats are published to this compacted topic, which keeps the latest
+	// record of each item and instance
+	EARS_KAFKA_SYNC_TOPIC = "ears_sync"
+
+	EARS_KAFKA_HEARTBEAT_INTERVAL = 10 * time.Second
+
+	// instances whose heartbeat is older than this are no longer counted and their records are removed
+	EARS_KAFKA_INSTANCE_TTL = 30 * time.Second
+
+	// maximum time to wait for the compacted topic to be read up to its end when listening starts
+	EARS_KAFKA_BOOTSTRAP_TIMEOUT = 30 * time.Second
+
+	// maximum time to wait for the topic to be read up to its end before counting instances
+	EARS_KAFKA_CATCH_UP_TIMEOUT = 2 * time.Second
+
+	itemKeyPrefix     = "item/"
+	instanceKeyPrefix = "instance/"
+)
+
+type (
+	// KafkaDeltaSyncer distributes sync requests through a compacted kafka topic, every instance reads all
+	// partitions of the topic without a consumer group. Records are keyed by item and by instance so that
+	// compaction bounds the topic by the number of routes, tenants and instances, and instances bootstrap their
+	// view of live instances by reading the topic from the beginning when they start listening
+	KafkaDeltaSyncer struct {
+		sync.Mutex
+		brokers      []string
+		topic        string
+		active       bool
+		client       sarama.Client
+		producer     sarama.SyncProducer
+		consumer     sarama.Consumer
+		localSyncers map[string][]syncer.LocalSyncer
+		instances    map[string]time.Time
+		offsets      map[int32]int64
+		logger       *zerolog.Logger
+		config       config.Config
+		instanceId   string
+		started      time.Time
+		cancel       context.CancelFunc
+		wg           sync.WaitGroup
+	}
+
+	syncRecord struct {
+		syncer.SyncCommand
+		Created time.Time `json:"created"`
+	}
+
+	instanceRecord struct {
+		InstanceId string    `json:"instanceId"`
+		LastSeen   time.Time `json:"lastSeen"`
+	}
+)
+
+func NewKafkaDeltaSyncer(logger *zerolog.Logger, config config.Config) (syncer.DeltaSyncer, error) {
+	s := new(KafkaDeltaSyncer)
+	s.logger = logger
+	s.config = config
+	s.localSyncers = make(map[string][]syncer.LocalSyncer)
+	s.instances = make(map[string]time.Time)
+	s.offsets = make(map[int32]int64)
+	hostname, _ := os.Hostname()
+	s.instanceId = hostname + "_" + uuid.New().String()
+	s.active = config.GetBool("ears.synchronization.active")
+	if !s.active {
+		logger.Info().Msg("Kafka Delta Syncer Not Activated")
+		return s, nil
+	}
+	endpoint := config.GetString("ears.synchronization.endpoint")
+	if endpoint == "" {
+		return nil, errors.New("missing kafka sync endpoint ears.synchronization.endpoint")
+	}
+	for _, broker := range strings.Split(endpoint, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			s.brokers = append(s.brokers, broker)
+		}
+	}
+	s.topic = config.GetString("ears.synchronization.topic")
+	if s.topic == "" {
+		s.topic = EARS_KAFKA_SYNC_TOPIC
+	}
+	saramaConfig, err := s.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	err = s.createTopic(saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.client, err = sarama.NewClient(s.brokers, saramaConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.producer, err = sarama.NewSyncProducerFromClient(s.client)
+	if err != nil {
+		s.client.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *KafkaDeltaSyncer) saramaConfig() (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	// creating topics with the broker's default replication factor requires kafka 2.4
+	saramaConfig.Version = sarama.V2_4_0_0
+	version := s.config.GetString("ears.synchronization.version")
+	if version != "" {
+		v, err := sarama.ParseKafkaVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Version = v
+	}
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Consumer.Return.Errors = true
+	saramaConfig.Net.TLS.Enable = s.config.GetBool("ears.synchronization.tlsEnable")
+	username := s.config.GetString("ears.synchronization.username")
+	if username != "" {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		saramaConfig.Net.SASL.User = username
+		saramaConfig.Net.SASL.Password = s.config.GetString("ears.synchronization.password")
+	}
+	return saramaConfig, nil
+}
+
+// createTopic creates the compacted sync topic unless it already exists, the topic may also be created up front
+// with cleanup.policy=compact
+func (s *KafkaDeltaSyncer) createTopic(saramaConfig *sarama.Config) error {
+	admin, err := sarama.NewClusterAdmin(s.brokers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	topics, err := admin.ListTopics()
+	if err != nil {
+		return err
+	}
+	if _, ok := topics[s.topic]; ok {
+		return nil
+	}
+	partitions := s.config.GetInt("ears.synchronization.partitions")
+	if partitions <= 0 {
+		partitions = 1
+	}
+	replicationFactor := s.config.GetInt("ears.synchronization.replicationFactor")
+	if replicationFactor <= 0 {
+		replicationFactor = -1
+	}
+	compact := "compact"
+	err = admin.CreateTopic(s.topic, &sarama.TopicDetail{
+		NumPartitions:     int32(partitions),
+		ReplicationFactor: int16(replicationFactor),
+		ConfigEntries:     map[string]*string{"cleanup.policy": &compact},
+	}, false)
+	if errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+func (s *KafkaDeltaSyncer) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	s.Lock()
+	defer s.Unlock()
+	s.localSyncers[itemType] = append(s.localSyncers[itemType], localSyncer)
+}
+
+func (s *KafkaDeltaSyncer) UnregisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	s.Lock()
+	defer s.Unlock()
+	syncers, ok := s.localSyncers[itemType]
+	if !ok {
+		return
+	}
+	for i, syncer := range syncers {
+		if syncer == localSyncer {
+			syncers[i] = syncers[len(syncers)-1]
+			syncers[len(syncers)-1] = nil
+			syncers = syncers[:len(syncers)-1]
+			s.localSyncers[itemType] = syncers
+			return
+		}
+	}
+}
+
+// PublishSyncRequest asks others to sync their routing tables, requests for the same item share a key and
+// therefore a partition so that they are delivered in order
+func (s *KafkaDeltaSyncer) PublishSyncRequest(ctx context.Context, tid tenant.Id, itemType string, itemId string, add bool) {
+	if !s.active {
+		return
+	}
+	cmd := syncer.EARS_REMOVE_ITEM_CMD
+	if add {
+		cmd = syncer.EARS_ADD_ITEM_CMD
+	}
+	record := syncRecord{
+		SyncCommand: syncer.SyncCommand{
+			Cmd:        cmd,
+			ItemType:   itemType,
+			ItemId:     itemId,
+			InstanceId: s.instanceId,
+			Sid:        uuid.New().String(),
+			Tenant:     tid,
+		},
+		Created: time.Now(),
+	}
+	err := s.publish(itemKeyPrefix+itemType+"/"+tid.KeyWithRoute(itemId), record)
+	if err != nil {
+		s.logger.Error().Str("op", "PublishSyncRequest").Err(err).Msg("Fail to publish sync request")
+	}
+}
+
+// publish sends a record to the sync topic, a nil record is published as a tombstone that removes the key on
+// compaction
+func (s *KafkaDeltaSyncer) publish(key string, record interface{}) error {
+	msg := &sarama.ProducerMessage{
+		Topic: s.topic,
+		Key:   sarama.StringEncoder(key),
+	}
+	if record != nil {
+		buf, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		msg.Value = sarama.ByteEncoder(buf)
+	}
+	_, _, err := s.producer.SendMessage(msg)
+	return err
+}
+
+// StartListeningForSyncRequests reads all partitions of the sync topic from the beginning and returns once the
+// records that existed at startup were read, or the bootstrap timeout expired. Routing tables are loaded from the
+// route storer, so only sync requests published after this instance started are passed to local syncers while
+// the existing records bootstrap the instance count
+func (s *KafkaDeltaSyncer) StartListeningForSyncRequests() {
+	if !s.active {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logs.SubLoggerCtx(ctx, s.logger)
+	consumer, err := sarama.NewConsumerFromClient(s.client)
+	if err != nil {
+		cancel()
+		s.logger.Error().Str("op", "ListenForSyncRequests").Msg(err.Error())
+		return
+	}
+	s.Lock()
+	s.cancel = cancel
+	s.consumer = consumer
+	s.started = time.Now()
+	s.Unlock()
+	partitions, err := s.client.Partitions(s.topic)
+	if err != nil {
+		s.logger.Error().Str("op", "ListenForSyncRequests").Msg(err.Error())
+	}
+	var bootstrap sync.WaitGroup
+	for _, partition := range partitions {
+		// the offset of the next record at startup, records before it are part of the bootstrap
+		end, err := s.client.GetOffset(s.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Int32("partition", partition).Msg(err.Error())
+			continue
+		}
+		start, err := s.client.GetOffset(s.topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Int32("partition", partition).Msg(err.Error())
+			continue
+		}
+		pc, err := consumer.ConsumePartition(s.topic, partition, start)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Int32("partition", partition).Msg(err.Error())
+			continue
+		}
+		bootstrap.Add(1)
+		s.wg.Add(1)
+		go s.consume(ctx, pc, start, end, &bootstrap)
+	}
+	done := make(chan struct{})
+	go func() {
+		bootstrap.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(EARS_KAFKA_BOOTSTRAP_TIMEOUT):
+		s.logger.Error().Str("op", "ListenForSyncRequests").Msg("timed out reading sync topic")
+	}
+	// count this instance right away, then keep the heartbeat going
+	s.beat()
+	s.wg.Add(1)
+	go s.heartbeat(ctx)
+}
+
+func (s *KafkaDeltaSyncer) consume(ctx context.Context, pc sarama.PartitionConsumer, start int64, end int64, bootstrap *sync.WaitGroup) {
+	defer s.wg.Done()
+	defer pc.Close()
+	bootstrapping := start < end
+	if !bootstrapping {
+		bootstrap.Done()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if bootstrapping {
+				bootstrap.Done()
+			}
+			return
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return
+			}
+			s.logger.Error().Str("op", "ListenForSyncRequests").Int32("partition", err.Partition).Msg(err.Error())
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				if bootstrapping {
+					bootstrap.Done()
+				}
+				return
+			}
+			s.handleRecord(ctx, msg)
+			s.Lock()
+			s.offsets[msg.Partition] = msg.Offset + 1
+			s.Unlock()
+			if bootstrapping && msg.Offset >= end-1 {
+				bootstrapping = false
+				bootstrap.Done()
+			}
+		}
+	}
+}
+
+func (s *KafkaDeltaSyncer) handleRecord(ctx context.Context, msg *sarama.ConsumerMessage) {
+	key := string(msg.Key)
+	switch {
+	case strings.HasPrefix(key, instanceKeyPrefix):
+		instanceId := strings.TrimPrefix(key, instanceKeyPrefix)
+		s.Lock()
+		defer s.Unlock()
+		if msg.Value == nil {
+			delete(s.instances, instanceId)
+			return
+		}
+		var record instanceRecord
+		err := json.Unmarshal(msg.Value, &record)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Str("error", err.Error()).Msg("bad instance record structure")
+			return
+		}
+		if record.LastSeen.After(s.instances[instanceId]) {
+			s.instances[instanceId] = record.LastSeen
+		}
+	case strings.HasPrefix(key, itemKeyPrefix):
+		if msg.Value == nil {
+			return
+		}
+		var record syncRecord
+		err := json.Unmarshal(msg.Value, &record)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Str("error", err.Error()).Msg("bad sync request structure")
+			return
+		}
+		s.Lock()
+		started := s.started
+		s.Unlock()
+		if record.Created.Before(started) {
+			return
+		}
+		s.handleSyncRequest(ctx, record.SyncCommand)
+	}
+}
+
+func (s *KafkaDeltaSyncer) handleSyncRequest(ctx context.Context, syncCmd syncer.SyncCommand) {
+	if syncCmd.InstanceId == s.instanceId {
+		s.logger.Info().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Msg("no need to sync myself")
+		return
+	}
+	if syncCmd.Cmd != syncer.EARS_ADD_ITEM_CMD && syncCmd.Cmd != syncer.EARS_REMOVE_ITEM_CMD {
+		s.logger.Error().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("routeId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Msg("bad command " + syncCmd.Cmd)
+		return
+	}
+	add := syncCmd.Cmd == syncer.EARS_ADD_ITEM_CMD
+	s.logger.Info().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("itemType", syncCmd.ItemType).Str("itemId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Bool("add", add).Msg("received sync request")
+	s.Lock()
+	defer s.Unlock()
+	for _, localSyncer := range s.localSyncers[syncCmd.ItemType] {
+		err := localSyncer.SyncItem(ctx, syncCmd.Tenant, syncCmd.ItemId, add)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("itemId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Msg("failed to sync item: " + err.Error())
+		}
+	}
+}
+
+func (s *KafkaDeltaSyncer) heartbeat(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(EARS_KAFKA_HEARTBEAT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.beat()
+			s.removeExpiredInstances()
+		}
+	}
+}
+
+func (s *KafkaDeltaSyncer) beat() {
+	now := time.Now()
+	s.Lock()
+	s.instances[s.instanceId] = now
+	s.Unlock()
+	err := s.publish(instanceKeyPrefix+s.instanceId, instanceRecord{InstanceId: s.instanceId, LastSeen: now})
+	if err != nil {
+		s.logger.Error().Str("op", "heartbeat").Msg(err.Error())
+	}
+}
+
+// removeExpiredInstances publishes tombstones for instances that stopped without removing their record, e.g.
+// because they crashed, so that their records are eventually compacted away
+func (s *KafkaDeltaSyncer) removeExpiredInstances() {
+	since := time.Now().Add(-EARS_KAFKA_INSTANCE_TTL)
+	var expired []string
+	s.Lock()
+	for instanceId, lastSeen := range s.instances {
+		if lastSeen.Before(since) {
+			expired = append(expired, instanceId)
+			delete(s.instances, instanceId)
+		}
+	}
+	s.Unlock()
+	for _, instanceId := range expired {
+		err := s.publish(instanceKeyPrefix+instanceId, nil)
+		if err != nil {
+			s.logger.Error().Str("op", "heartbeat").Str("instanceId", instanceId).Msg(err.Error())
+		}
+	}
+}
+
+// StopListeningForSyncRequests stops listening for sync requests and removes this instance from the instance count
+func (s *KafkaDeltaSyncer) StopListeningForSyncRequests() {
+	if !s.active {
+		return
+	}
+	s.Lock()
+	cancel := s.cancel
+	consumer := s.consumer
+	s.cancel = nil
+	s.consumer = nil
+	s.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+	err := consumer.Close()
+	if err != nil {
+		s.logger.Error().Str("op", "StopListeningForSyncRequests").Msg(err.Error())
+	}
+	s.Lock()
+	delete(s.instances, s.instanceId)
+	s.Unlock()
+	err = s.publish(instanceKeyPrefix+s.instanceId, nil)
+	if err != nil {
+		s.logger.Error().Str("op", "StopListeningForSyncRequests").Msg(err.Error())
+	}
+}
+
+// GetInstanceCount gets number of live ears instances, the sync topic is read up to its current end first so
+// that instances which just started are counted
+func (s *KafkaDeltaSyncer) GetInstanceCount(ctx context.Context) int {
+	if !s.active {
+		return 0
+	}
+	err := s.catchUp(ctx)
+	if err != nil {
+		s.logger.Error().Str("op", "GetInstanceCount").Msg(err.Error())
+	}
+	since := time.Now().Add(-EARS_KAFKA_INSTANCE_TTL)
+	cnt := 0
+	s.Lock()
+	defer s.Unlock()
+	for _, lastSeen := range s.instances {
+		if !lastSeen.Before(since) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// catchUp waits until all records published so far were read, or the catch up timeout expired
+func (s *KafkaDeltaSyncer) catchUp(ctx context.Context) error {
+	partitions, err := s.client.Partitions(s.topic)
+	if err != nil {
+		return err
+	}
+	ends := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		ends[partition], err = s.client.GetOffset(s.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, EARS_KAFKA_CATCH_UP_TIMEOUT)
+	defer cancel()
+	for {
+		s.Lock()
+		caughtUp := true
+		for partition, end := range ends {
+			if s.offsets[partition] < end {
+				caughtUp = false
+				break
+			}
+		}
+		s.Unlock()
+		if caughtUp {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out reading sync topic")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (s *KafkaDeltaSyncer) CheckHealth(ctx context.Context) error {
+	if !s.active {
+		return nil
+	}
+	return s.client.RefreshMetadata(s.topic)
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+// +build integration
+
+package syncer_test
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/internal/pkg/syncer/kafka"
+	"testing"
+)
+
+func KafkaConfig() config.Config {
+	v := viper.New()
+	v.Set("ears.synchronization.endpoint", "localhost:9092")
+	v.Set("ears.synchronization.topic", "ears_sync_test")
+	v.Set("ears.synchronization.replicationFactor", 1)
+	v.Set("ears.synchronization.active", true)
+	return v
+}
+
+func newKafkaDeltaSyncer() syncer.DeltaSyncer {
+	s, err := kafka.NewKafkaDeltaSyncer(&log.Logger, KafkaConfig())
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestKafkaDeltaSyncer(t *testing.T) {
+	testSyncers(newKafkaDeltaSyncer, t)
+}
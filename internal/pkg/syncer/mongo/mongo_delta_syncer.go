@@ -0,0 +1,331 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	earsmongo "github.com/xmidt-org/ears/internal/pkg/db/mongo"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/logs"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+
+	// sync requests are inserted into this collection and picked up by all instances watching its change stream
+	EARS_MONGO_SYNC_COLLECTION = "ears_sync"
+
+	// each instance keeps a heartbeat document in this collection while it is listening for sync requests
+	EARS_MONGO_INSTANCE_COLLECTION = "ears_instances"
+
+	EARS_MONGO_RETRY_INTERVAL = 10 * time.Second
+
+	EARS_MONGO_HEARTBEAT_INTERVAL = 10 * time.Second
+
+	// instances whose heartbeat is older than this are no longer counted and eventually removed
+	EARS_MONGO_INSTANCE_TTL_SECONDS = 30
+
+	// sync requests are removed after an hour, instances that were disconnected longer than that rely on the
+	// periodic sync to catch up
+	EARS_MONGO_SYNC_TTL_SECONDS = 3600
+)
+
+type (
+	// MongoDeltaSyncer distributes sync requests through a mongodb change stream, change streams require a
+	// replica set or sharded cluster
+	MongoDeltaSyncer struct {
+		sync.Mutex
+		endpoint           string
+		active             bool
+		client             *mongo.Client
+		syncCollection     *mongo.Collection
+		instanceCollection *mongo.Collection
+		localSyncers       map[string][]syncer.LocalSyncer
+		logger             *zerolog.Logger
+		config             config.Config
+		instanceId         string
+		cancel             context.CancelFunc
+	}
+
+	syncDocument struct {
+		syncer.SyncCommand `bson:",inline"`
+		Created            time.Time `bson:"created"`
+	}
+
+	instanceDocument struct {
+		InstanceId string    `bson:"_id"`
+		LastSeen   time.Time `bson:"lastSeen"`
+	}
+)
+
+func NewMongoDeltaSyncer(logger *zerolog.Logger, config config.Config) (syncer.DeltaSyncer, error) {
+	s := new(MongoDeltaSyncer)
+	s.logger = logger
+	s.config = config
+	s.endpoint = config.GetString("ears.synchronization.endpoint")
+	s.localSyncers = make(map[string][]syncer.LocalSyncer)
+	hostname, _ := os.Hostname()
+	s.instanceId = hostname + "_" + uuid.New().String()
+	s.active = config.GetBool("ears.synchronization.active")
+	if !s.active {
+		logger.Info().Msg("Mongo Delta Syncer Not Activated")
+		return s, nil
+	}
+	if s.endpoint == "" {
+		return nil, errors.New("missing mongo sync endpoint ears.synchronization.endpoint")
+	}
+	client, err := earsmongo.Connect(s.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	database := config.GetString("ears.synchronization.database")
+	if database == "" {
+		database = earsmongo.DEFAULT_DATABASE
+	}
+	s.client = client
+	s.syncCollection = client.Database(database).Collection(EARS_MONGO_SYNC_COLLECTION)
+	s.instanceCollection = client.Database(database).Collection(EARS_MONGO_INSTANCE_COLLECTION)
+	ctx, cancel := context.WithTimeout(context.Background(), EARS_MONGO_RETRY_INTERVAL)
+	defer cancel()
+	_, err = s.syncCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(EARS_MONGO_SYNC_TTL_SECONDS),
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.instanceCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "lastSeen", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(EARS_MONGO_INSTANCE_TTL_SECONDS),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MongoDeltaSyncer) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	s.Lock()
+	defer s.Unlock()
+	s.localSyncers[itemType] = append(s.localSyncers[itemType], localSyncer)
+}
+
+func (s *MongoDeltaSyncer) UnregisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	s.Lock()
+	defer s.Unlock()
+	syncers, ok := s.localSyncers[itemType]
+	if !ok {
+		return
+	}
+	for i, syncer := range syncers {
+		if syncer == localSyncer {
+			syncers[i] = syncers[len(syncers)-1]
+			syncers[len(syncers)-1] = nil
+			syncers = syncers[:len(syncers)-1]
+			s.localSyncers[itemType] = syncers
+			return
+		}
+	}
+}
+
+// PublishSyncRequest asks others to sync their routing tables, unlike pub/sub messages the request is persisted
+// so that instances whose change stream is briefly interrupted pick it up when they resume
+func (s *MongoDeltaSyncer) PublishSyncRequest(ctx context.Context, tid tenant.Id, itemType string, itemId string, add bool) {
+	if !s.active {
+		return
+	}
+	cmd := syncer.EARS_REMOVE_ITEM_CMD
+	if add {
+		cmd = syncer.EARS_ADD_ITEM_CMD
+	}
+	doc := syncDocument{
+		SyncCommand: syncer.SyncCommand{
+			Cmd:        cmd,
+			ItemType:   itemType,
+			ItemId:     itemId,
+			InstanceId: s.instanceId,
+			Sid:        uuid.New().String(),
+			Tenant:     tid,
+		},
+		Created: time.Now(),
+	}
+	_, err := s.syncCollection.InsertOne(ctx, doc)
+	if err != nil {
+		s.logger.Error().Str("op", "PublishSyncRequest").Err(err).Msg("Fail to publish sync request")
+	}
+}
+
+// StartListeningForSyncRequests opens the change stream before returning so that no request published after
+// startup is missed, and starts the heartbeat counting this instance
+func (s *MongoDeltaSyncer) StartListeningForSyncRequests() {
+	if !s.active {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logs.SubLoggerCtx(ctx, s.logger)
+	s.Lock()
+	s.cancel = cancel
+	s.Unlock()
+	stream, err := s.watch(ctx, nil)
+	if err != nil {
+		s.logger.Error().Str("op", "ListenForSyncRequests").Msg(err.Error())
+	}
+	// count this instance right away, then keep the heartbeat going
+	s.beat(ctx)
+	go s.heartbeat(ctx)
+	go func() {
+		var resumeToken bson.Raw
+		for {
+			if stream == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(EARS_MONGO_RETRY_INTERVAL):
+				}
+				stream, err = s.watch(ctx, resumeToken)
+				if err != nil {
+					s.logger.Error().Str("op", "ListenForSyncRequests").Msg(err.Error())
+					continue
+				}
+			}
+			for stream.Next(ctx) {
+				var change struct {
+					FullDocument syncDocument `bson:"fullDocument"`
+				}
+				err = stream.Decode(&change)
+				if err != nil {
+					s.logger.Error().Str("op", "ListenForSyncRequests").Str("error", err.Error()).Msg("bad sync request structure")
+					continue
+				}
+				s.handleSyncRequest(ctx, change.FullDocument.SyncCommand)
+			}
+			resumeToken = stream.ResumeToken()
+			err = stream.Err()
+			stream.Close(context.Background())
+			stream = nil
+			if ctx.Err() != nil {
+				s.logger.Info().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Msg("stopped listening")
+				return
+			}
+			if err != nil {
+				s.logger.Error().Str("op", "ListenForSyncRequests").Msg(err.Error())
+			}
+		}
+	}()
+}
+
+func (s *MongoDeltaSyncer) watch(ctx context.Context, resumeToken bson.Raw) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "operationType", Value: "insert"}}}}}
+	opts := options.ChangeStream()
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+	return s.syncCollection.Watch(ctx, pipeline, opts)
+}
+
+func (s *MongoDeltaSyncer) handleSyncRequest(ctx context.Context, syncCmd syncer.SyncCommand) {
+	if syncCmd.InstanceId == s.instanceId {
+		s.logger.Info().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Msg("no need to sync myself")
+		return
+	}
+	if syncCmd.Cmd != syncer.EARS_ADD_ITEM_CMD && syncCmd.Cmd != syncer.EARS_REMOVE_ITEM_CMD {
+		s.logger.Error().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("routeId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Msg("bad command " + syncCmd.Cmd)
+		return
+	}
+	add := syncCmd.Cmd == syncer.EARS_ADD_ITEM_CMD
+	s.logger.Info().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("itemType", syncCmd.ItemType).Str("itemId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Bool("add", add).Msg("received sync request")
+	s.Lock()
+	defer s.Unlock()
+	for _, localSyncer := range s.localSyncers[syncCmd.ItemType] {
+		err := localSyncer.SyncItem(ctx, syncCmd.Tenant, syncCmd.ItemId, add)
+		if err != nil {
+			s.logger.Error().Str("op", "ListenForSyncRequests").Str("instanceId", s.instanceId).Str("itemId", syncCmd.ItemId).Str("sid", syncCmd.Sid).Msg("failed to sync item: " + err.Error())
+		}
+	}
+}
+
+func (s *MongoDeltaSyncer) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(EARS_MONGO_HEARTBEAT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.beat(ctx)
+		}
+	}
+}
+
+func (s *MongoDeltaSyncer) beat(ctx context.Context) {
+	_, err := s.instanceCollection.ReplaceOne(ctx, bson.M{"_id": s.instanceId},
+		instanceDocument{InstanceId: s.instanceId, LastSeen: time.Now()}, options.Replace().SetUpsert(true))
+	if err != nil && ctx.Err() == nil {
+		s.logger.Error().Str("op", "heartbeat").Msg(err.Error())
+	}
+}
+
+// StopListeningForSyncRequests stops listening for sync requests and removes this instance from the instance count
+func (s *MongoDeltaSyncer) StopListeningForSyncRequests() {
+	if !s.active {
+		return
+	}
+	s.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	ctx, done := context.WithTimeout(context.Background(), EARS_MONGO_RETRY_INTERVAL)
+	defer done()
+	_, err := s.instanceCollection.DeleteOne(ctx, bson.M{"_id": s.instanceId})
+	if err != nil {
+		s.logger.Error().Str("op", "StopListeningForSyncRequests").Msg(err.Error())
+	}
+}
+
+// GetInstanceCount gets number of live ears instances
+func (s *MongoDeltaSyncer) GetInstanceCount(ctx context.Context) int {
+	if !s.active {
+		return 0
+	}
+	since := time.Now().Add(-EARS_MONGO_INSTANCE_TTL_SECONDS * time.Second)
+	cnt, err := s.instanceCollection.CountDocuments(ctx, bson.M{"lastSeen": bson.M{"$gte": since}})
+	if err != nil {
+		s.logger.Error().Str("op", "GetInstanceCount").Msg(err.Error())
+		return 0
+	}
+	return int(cnt)
+}
+
+func (s *MongoDeltaSyncer) CheckHealth(ctx context.Context) error {
+	if !s.active {
+		return nil
+	}
+	return s.client.Ping(ctx, nil)
+}
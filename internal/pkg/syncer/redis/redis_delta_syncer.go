@@ -352,3 +352,10 @@ func (s *RedisDeltaSyncer) GetInstanceCount(ctx context.Context) int {
 	s.logger.Debug().Str("op", "GetInstanceCount").Msg(fmt.Sprintf("num subscribers for channel %s is %d", EARS_REDIS_SYNC_CHANNEL, numSubscribers))
 	return int(numSubscribers)
 }
+
+func (s *RedisDeltaSyncer) CheckHealth(ctx context.Context) error {
+	if !s.active {
+		return nil
+	}
+	return s.client.Ping().Err()
+}
@@ -45,6 +45,12 @@ type (
 		SyncItem(ctx context.Context, tid tenant.Id, itemId string, add bool) error
 	}
 
+	// ItemWatcher is implemented by storers whose items can change outside of the api, e.g. config files edited
+	// on disk, it notifies registered local syncers of such changes
+	ItemWatcher interface {
+		RegisterLocalSyncer(itemType string, localSyncer LocalSyncer)
+	}
+
 	DeltaSyncer interface {
 		// StartListeningForSyncRequests
 		StartListeningForSyncRequests() // do we need this still?
@@ -58,5 +64,7 @@ type (
 		PublishSyncRequest(ctx context.Context, tenantId tenant.Id, itemType string, itemId string, add bool)
 		// GetInstanceCount
 		GetInstanceCount(ctx context.Context) int
+		// CheckHealth returns an error if the sync backend cannot be reached
+		CheckHealth(ctx context.Context) error
 	}
 )
@@ -0,0 +1,72 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sysevent lets any part of EARS report its own problems as system events, which the app routes to the
+// system events route configured by ears.systemEvents so operators can handle them like any other event.
+package sysevent
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	TypeDeliveryFailure   = "deliveryFailure"   // a sender nacked an event
+	TypePluginPanic       = "pluginPanic"       // a plugin panicked while handling an event
+	TypeRegistrationError = "registrationError" // a route could not be registered
+)
+
+// Event is a structured report of a problem of EARS
+type Event struct {
+	Type     string    `json:"type"`                 // one of TypeDeliveryFailure, TypePluginPanic or TypeRegistrationError
+	Time     int64     `json:"time"`                 // time of the problem in unix milliseconds
+	Hostname string    `json:"hostname"`             // instance that ran into the problem
+	Tenant   tenant.Id `json:"tenant"`               // tenant of the route or plugin, empty if unknown
+	RouteId  string    `json:"routeId,omitempty"`    // route the problem occurred on, empty if unknown
+	Plugin   string    `json:"plugin,omitempty"`     // plugin type, e.g. kafka
+	Name     string    `json:"name,omitempty"`       // plugin name
+	EventId  string    `json:"eventId,omitempty"`    // id of the event that could not be handled
+	Error    string    `json:"error"`                // error message
+	Stack    string    `json:"stackTrace,omitempty"` // stack trace of a panic
+}
+
+// Handler receives system events, it must not block
+type Handler func(e Event)
+
+var handler atomic.Value
+
+var hostname, _ = os.Hostname()
+
+// SetHandler sets the handler receiving all system events, a nil handler drops them
+func SetHandler(h Handler) {
+	handler.Store(h)
+}
+
+// Emit hands a system event to the handler, the time and hostname are filled in if empty
+func Emit(e Event) {
+	h, _ := handler.Load().(Handler)
+	if h == nil {
+		return
+	}
+	if e.Time == 0 {
+		e.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	if e.Hostname == "" {
+		e.Hostname = hostname
+	}
+	h(e)
+}
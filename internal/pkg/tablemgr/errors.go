@@ -14,7 +14,11 @@
 
 package tablemgr
 
-import "github.com/xmidt-org/ears/pkg/errs"
+import (
+	"strings"
+
+	"github.com/xmidt-org/ears/pkg/errs"
+)
 
 // standard set of errors for route manager
 
@@ -42,6 +46,37 @@ func (e *RouteNotFoundError) Error() string {
 	return errs.String("RouteNotFoundError", map[string]interface{}{"id": e.Id}, nil)
 }
 
+// RouteNotOwnedError is returned for requests that need the live route on a node that does not run the route
+// because route sharding assigned it to another node
+type RouteNotOwnedError struct {
+	RouteId string
+	Owner   string
+}
+
+func (e *RouteNotOwnedError) Error() string {
+	return errs.String("RouteNotOwnedError", map[string]interface{}{"routeId": e.RouteId, "owner": e.Owner}, nil)
+}
+
+// RoutePluginsSharedError is returned when restarting a route whose receiver or senders are shared with other
+// routes, restarting it would not give it fresh plugin instances
+type RoutePluginsSharedError struct {
+	RouteId  string
+	RouteIds []string
+}
+
+func (e *RoutePluginsSharedError) Error() string {
+	return errs.String("RoutePluginsSharedError", map[string]interface{}{"routeId": e.RouteId, "routeIds": strings.Join(e.RouteIds, ",")}, nil)
+}
+
+type FragmentInUseError struct {
+	FragmentId string
+	RouteIds   []string
+}
+
+func (e *FragmentInUseError) Error() string {
+	return errs.String("FragmentInUseError", map[string]interface{}{"fragmentId": e.FragmentId, "routeIds": strings.Join(e.RouteIds, ",")}, nil)
+}
+
 type BadConfigError struct {
 	Wrapped error
 }
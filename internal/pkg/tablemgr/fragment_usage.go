@@ -0,0 +1,142 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/db"
+	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// FragmentUsage lists the places a route references a fragment, for example receiver, filterChain[1] or branches.alerts
+type FragmentUsage struct {
+	RouteId   string   `json:"routeId"`
+	Locations []string `json:"locations"`
+}
+
+// fragmentLocations returns the places a route references a fragment
+func fragmentLocations(routeConfig *route.Config, fragmentId string) []string {
+	locations := make([]string, 0)
+	if routeConfig.Receiver.FragmentName == fragmentId {
+		locations = append(locations, "receiver")
+	}
+	for idx, f := range routeConfig.FilterChain {
+		if f.FragmentName == fragmentId {
+			locations = append(locations, "filterChain["+strconv.Itoa(idx)+"]")
+		}
+	}
+	if routeConfig.Sender.FragmentName == fragmentId {
+		locations = append(locations, "sender")
+	}
+	if routeConfig.DeadLetter != nil && routeConfig.DeadLetter.FragmentName == fragmentId {
+		locations = append(locations, "deadLetter")
+	}
+	names := make([]string, 0, len(routeConfig.Branches))
+	for name, b := range routeConfig.Branches {
+		if b.FragmentName == fragmentId {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		locations = append(locations, "branches."+name)
+	}
+	return locations
+}
+
+// detachFragment drops references to a fragment from a route, routes carry the inflated fragment config
+// so the route keeps working unchanged and its hash does not change either
+func detachFragment(routeConfig *route.Config, fragmentId string) {
+	if routeConfig.Receiver.FragmentName == fragmentId {
+		routeConfig.Receiver.FragmentName = ""
+	}
+	for idx := range routeConfig.FilterChain {
+		if routeConfig.FilterChain[idx].FragmentName == fragmentId {
+			routeConfig.FilterChain[idx].FragmentName = ""
+		}
+	}
+	if routeConfig.Sender.FragmentName == fragmentId {
+		routeConfig.Sender.FragmentName = ""
+	}
+	if routeConfig.DeadLetter != nil && routeConfig.DeadLetter.FragmentName == fragmentId {
+		routeConfig.DeadLetter.FragmentName = ""
+	}
+	for name, b := range routeConfig.Branches {
+		if b.FragmentName == fragmentId {
+			b.FragmentName = ""
+			routeConfig.Branches[name] = b
+		}
+	}
+}
+
+func (r *DefaultRoutingTableManager) GetFragmentUsage(ctx context.Context, tid tenant.Id, fragmentId string) ([]FragmentUsage, error) {
+	_, err := r.fragmentMgr.GetFragment(ctx, tid, fragmentId)
+	if err != nil {
+		return nil, err
+	}
+	return r.fragmentUsage(ctx, tid, fragmentId)
+}
+
+func (r *DefaultRoutingTableManager) fragmentUsage(ctx context.Context, tid tenant.Id, fragmentId string) ([]FragmentUsage, error) {
+	routes, err := r.storageMgr.GetAllTenantRoutes(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]FragmentUsage, 0)
+	for idx := range routes {
+		locations := fragmentLocations(&routes[idx], fragmentId)
+		if len(locations) > 0 {
+			usage = append(usage, FragmentUsage{RouteId: routes[idx].Id, Locations: locations})
+		}
+	}
+	return usage, nil
+}
+
+func (r *DefaultRoutingTableManager) RemoveFragment(ctx context.Context, tid tenant.Id, fragmentId string, force bool) error {
+	if db.IsReadOnly(r.fragmentMgr) {
+		return r.fragmentMgr.DeleteFragment(ctx, tid, fragmentId)
+	}
+	usage, err := r.fragmentUsage(ctx, tid, fragmentId)
+	if err != nil {
+		return err
+	}
+	if len(usage) > 0 && !force {
+		routeIds := make([]string, len(usage))
+		for idx, u := range usage {
+			routeIds[idx] = u.RouteId
+		}
+		return &FragmentInUseError{FragmentId: fragmentId, RouteIds: routeIds}
+	}
+	for _, u := range usage {
+		routeConfig, err := r.storageMgr.GetRoute(ctx, tid, u.RouteId)
+		if err != nil {
+			return err
+		}
+		detachFragment(&routeConfig, fragmentId)
+		err = r.storageMgr.SetRoute(ctx, routeConfig)
+		if err != nil {
+			return err
+		}
+		r.rtSyncer.PublishSyncRequest(ctx, tid, syncer.ITEM_TYPE_ROUTE, routeConfig.Id, true)
+		log.Ctx(ctx).Info().Str("op", "RemoveFragment").Str("fragmentId", fragmentId).Str("routeId", routeConfig.Id).Msg("detached fragment from route")
+	}
+	return r.fragmentMgr.DeleteFragment(ctx, tid, fragmentId)
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	healthProbeId = "_health"
+)
+
+// ComponentHealth describes the connectivity of a backend EARS depends on
+type ComponentHealth struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReceiverHealth describes the run state of the receiver of a live route
+type ReceiverHealth struct {
+	RouteId string    `json:"routeId"`
+	Tenant  tenant.Id `json:"tenant"`
+	Plugin  string    `json:"plugin"`
+	Name    string    `json:"name,omitempty"`
+	Running bool      `json:"running"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Health is a snapshot of the health of the routing table on this instance
+type Health struct {
+	RouteStorer        ComponentHealth  `json:"routeStorer"`
+	Syncer             ComponentHealth  `json:"syncer"`
+	RegisteredRoutes   int              `json:"registeredRoutes"`
+	RegistrationErrors int64            `json:"registrationErrors"`
+	Receivers          []ReceiverHealth `json:"receivers"`
+}
+
+func newComponentHealth(err error) ComponentHealth {
+	if err != nil {
+		return ComponentHealth{Healthy: false, Error: err.Error()}
+	}
+	return ComponentHealth{Healthy: true}
+}
+
+func (r *DefaultRoutingTableManager) GetHealth(ctx context.Context) *Health {
+	health := &Health{
+		RegistrationErrors: atomic.LoadInt64(&r.regErrCnt),
+		Receivers:          make([]ReceiverHealth, 0),
+	}
+	// probe the route storer with a lookup that is expected to miss
+	_, err := r.storageMgr.GetRoute(ctx, tenant.Id{OrgId: healthProbeId, AppId: healthProbeId}, healthProbeId)
+	var notFoundErr *route.RouteNotFoundError
+	if errors.As(err, &notFoundErr) {
+		err = nil
+	}
+	health.RouteStorer = newComponentHealth(err)
+	health.Syncer = newComponentHealth(r.rtSyncer.CheckHealth(ctx))
+	r.Lock()
+	for _, lrw := range r.liveRouteMap {
+		running, runErr := lrw.RunState()
+		rh := ReceiverHealth{
+			RouteId: lrw.Config.Id,
+			Tenant:  lrw.Config.TenantId,
+			Plugin:  lrw.Config.Receiver.Plugin,
+			Name:    lrw.Config.Receiver.Name,
+			Running: running,
+		}
+		if runErr != nil {
+			rh.Error = runErr.Error()
+		}
+		health.Receivers = append(health.Receivers, rh)
+	}
+	health.RegisteredRoutes = len(r.liveRouteMap)
+	r.Unlock()
+	sort.Slice(health.Receivers, func(i, j int) bool {
+		return health.Receivers[i].Tenant.KeyWithRoute(health.Receivers[i].RouteId) < health.Receivers[j].Tenant.KeyWithRoute(health.Receivers[j].RouteId)
+	})
+	return health
+}
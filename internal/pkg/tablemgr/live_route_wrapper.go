@@ -16,22 +16,33 @@ package tablemgr
 
 import (
 	"context"
+	"github.com/xmidt-org/ears/internal/pkg/wal"
 	"github.com/xmidt-org/ears/pkg/filter"
+	pkgbranch "github.com/xmidt-org/ears/pkg/filter/branch"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
+	pluginbranch "github.com/xmidt-org/ears/pkg/plugins/branch"
 	"github.com/xmidt-org/ears/pkg/receiver"
 	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type LiveRouteWrapper struct {
 	sync.Mutex
 	Route       *route.Route
 	Sender      sender.Sender
+	DeadLetter  sender.Sender
+	Branches    map[string]sender.Sender
 	Receiver    receiver.Receiver
 	FilterChain *filter.Chain
 	Config      route.Config
 	RefCnt      int32
+	running     bool
+	runErr      error
+	ceChain     *filter.Chain
+	journal     *journalReceiver
 }
 
 func NewLiveRouteWrapper(routeConfig route.Config) *LiveRouteWrapper {
@@ -55,6 +66,133 @@ func (lrw *LiveRouteWrapper) RemoveRouteReference() int {
 	return int(lrw.RefCnt)
 }
 
+// RouteSender returns the sender the route delivers events to, which delivers events tagged with a
+// branch name to the branch sender, retries failed deliveries according to the delivery policy and
+// forwards events that still fail to the dead letter sender if the route has one
+func (lrw *LiveRouteWrapper) RouteSender() sender.Sender {
+	s := lrw.Sender
+	if len(lrw.Branches) > 0 {
+		s = sender.NewBranchSender(s, lrw.Branches, branchPath(&lrw.Config))
+	}
+	if dp := lrw.Config.DeliveryPolicy; dp != nil {
+		s = sender.NewRetrySender(s, retryPolicy(dp))
+	}
+	if lrw.DeadLetter != nil {
+		s = sender.NewDeadLetterSender(s, lrw.DeadLetter)
+	}
+	return s
+}
+
+// branchPath returns the path the branch filters of a route write branch names to, if the filter chain holds
+// several branch filters the path of the last one is used since that filter has the final say
+func branchPath(routeConfig *route.Config) string {
+	path := sender.DefaultBranchPath
+	for _, fc := range routeConfig.FilterChain {
+		if fc.Plugin != pluginbranch.Name {
+			continue
+		}
+		cfg, err := pkgbranch.NewConfig(fc.Config)
+		if err != nil {
+			continue
+		}
+		path = cfg.WithDefaults().ToPath
+	}
+	return path
+}
+
+// RouteReceiver returns the receiver the route takes events from, which journals events in the write ahead log
+// if the route is journaled
+func (lrw *LiveRouteWrapper) RouteReceiver() receiver.Receiver {
+	if lrw.journal != nil {
+		return lrw.journal
+	}
+	return lrw.Receiver
+}
+
+// replayJournal feeds events journaled by a previous run into the route
+func (lrw *LiveRouteWrapper) replayJournal(records []wal.Record) {
+	if lrw.journal == nil {
+		return
+	}
+	lrw.journal.Replay(records)
+}
+
+// RouteFilter returns the filter chain the route passes events through, which in cloud events mode turns events
+// into cloud events ahead of the configured filter chain and encodes them for the sender after it
+func (lrw *LiveRouteWrapper) RouteFilter() filter.Filterer {
+	if lrw.ceChain != nil {
+		return lrw.ceChain
+	}
+	return lrw.FilterChain
+}
+
+// retryPolicy returns the retry policy of a route delivery policy with defaults applied
+func retryPolicy(dp *route.DeliveryPolicyConfig) sender.RetryPolicy {
+	policy := sender.RetryPolicy{
+		AckTimeout:     time.Duration(dp.AckTimeoutSeconds) * time.Second,
+		MaxAttempts:    dp.MaxAttempts,
+		Backoff:        dp.Backoff,
+		InitialBackoff: time.Duration(dp.BackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(dp.MaxBackoffMs) * time.Millisecond,
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Backoff == "" {
+		policy.Backoff = sender.BackoffConstant
+	}
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = 10 * time.Second
+	}
+	return policy
+}
+
+// pluginInstances returns the receiver and sender instances of the route keyed like the topology of the tenant
+func (lrw *LiveRouteWrapper) pluginInstances(ctx context.Context) map[string]bool {
+	instances := map[string]bool{
+		TopologyTypeReceiver + ":" + lrw.Config.Receiver.Hash(ctx): true,
+		TopologyTypeSender + ":" + lrw.Config.Sender.Hash(ctx):     true,
+	}
+	if lrw.Config.DeadLetter != nil {
+		instances[TopologyTypeSender+":"+lrw.Config.DeadLetter.Hash(ctx)] = true
+	}
+	for _, b := range lrw.Config.Branches {
+		instances[TopologyTypeSender+":"+b.Hash(ctx)] = true
+	}
+	return instances
+}
+
+// stopSenders stops the senders of the route, which unregistering leaves running
+func (lrw *LiveRouteWrapper) stopSenders(ctx context.Context) {
+	if lrw.Sender != nil {
+		lrw.Sender.StopSending(ctx)
+	}
+	if lrw.DeadLetter != nil {
+		lrw.DeadLetter.StopSending(ctx)
+	}
+	for _, branch := range lrw.Branches {
+		branch.StopSending(ctx)
+	}
+}
+
+// setRunState records whether the route is currently running and the error it stopped with if any
+func (lrw *LiveRouteWrapper) setRunState(running bool, err error) {
+	lrw.Lock()
+	defer lrw.Unlock()
+	lrw.running = running
+	lrw.runErr = err
+}
+
+// RunState returns true if the route is running and otherwise the error the route stopped with if any
+func (lrw *LiveRouteWrapper) RunState() (bool, error) {
+	lrw.Lock()
+	defer lrw.Unlock()
+	return lrw.running, lrw.runErr
+}
+
 func (lrw *LiveRouteWrapper) Unregister(ctx context.Context, r *DefaultRoutingTableManager) error {
 	lrw.Lock()
 	defer lrw.Unlock()
@@ -74,6 +212,20 @@ func (lrw *LiveRouteWrapper) Unregister(ctx context.Context, r *DefaultRoutingTa
 		}
 	}
 
+	if lrw.DeadLetter != nil {
+		err = r.pluginMgr.UnregisterSender(ctx, lrw.DeadLetter)
+		if err != nil {
+			e = err
+		}
+	}
+
+	for _, branch := range lrw.Branches {
+		err = r.pluginMgr.UnregisterSender(ctx, branch)
+		if err != nil {
+			e = err
+		}
+	}
+
 	if lrw.FilterChain != nil {
 		for _, filter := range lrw.FilterChain.Filterers() {
 			err = r.pluginMgr.UnregisterFilter(ctx, filter)
@@ -98,17 +250,55 @@ func (lrw *LiveRouteWrapper) Register(ctx context.Context, r *DefaultRoutingTabl
 			lrw.FilterChain.Add(filter)
 		}
 	}
+	// set up optional cloud events mode, the ingress and egress steps are not plugins and are kept out of the
+	// filter chain so they are not unregistered with the plugin manager
+	lrw.ceChain = nil
+	if ce := lrw.Config.CloudEvents; ce != nil {
+		egress, err := cloudevents.NewRouteEgress(tid, ce.Format)
+		if err != nil {
+			lrw.Unregister(ctx, r)
+			return err
+		}
+		lrw.ceChain = &filter.Chain{}
+		lrw.ceChain.Add(cloudevents.NewRouteIngress(tid, lrw.Config.Id, ce.Source, ce.Type))
+		lrw.ceChain.Add(lrw.FilterChain)
+		lrw.ceChain.Add(egress)
+	}
 	// set up sender
 	lrw.Sender, err = r.pluginMgr.RegisterSender(ctx, lrw.Config.Sender.Plugin, lrw.Config.Sender.Name, stringify(lrw.Config.Sender.Config), tid)
 	if err != nil {
 		lrw.Unregister(ctx, r)
 		return err
 	}
+	// set up optional dead letter sender
+	if lrw.Config.DeadLetter != nil {
+		lrw.DeadLetter, err = r.pluginMgr.RegisterSender(ctx, lrw.Config.DeadLetter.Plugin, lrw.Config.DeadLetter.Name, stringify(lrw.Config.DeadLetter.Config), tid)
+		if err != nil {
+			lrw.Unregister(ctx, r)
+			return err
+		}
+	}
+	// set up optional branch senders
+	for name, b := range lrw.Config.Branches {
+		branch, err := r.pluginMgr.RegisterSender(ctx, b.Plugin, b.Name, stringify(b.Config), tid)
+		if err != nil {
+			lrw.Unregister(ctx, r)
+			return err
+		}
+		if lrw.Branches == nil {
+			lrw.Branches = make(map[string]sender.Sender)
+		}
+		lrw.Branches[name] = branch
+	}
 	// set up receiver
 	lrw.Receiver, err = r.pluginMgr.RegisterReceiver(ctx, lrw.Config.Receiver.Plugin, lrw.Config.Receiver.Name, stringify(lrw.Config.Receiver.Config), tid)
 	if err != nil {
 		lrw.Unregister(ctx, r)
 		return err
 	}
+	lrw.journal = nil
+	if r.journal.journals(&lrw.Config) {
+		lrw.journal = newJournalReceiver(lrw.Receiver, r.journal, tid, lrw.Config.Id)
+	}
 	return nil
 }
@@ -0,0 +1,91 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+type (
+	// RouteReport describes what a live route holds on to
+	RouteReport struct {
+		Tenant      tenant.Id `json:"tenant"`
+		RouteId     string    `json:"routeId"`
+		RouteIds    []string  `json:"routeIds"`    // IDs of identical routes sharing the live route
+		References  int       `json:"references"`  // number of routes sharing the live route
+		ConfigBytes int       `json:"configBytes"` // size of the route config as JSON
+		Errors      int       `json:"errors"`      // entries in the error log of the route
+		WorkersBusy int       `json:"workersBusy"` // events currently being delivered
+	}
+
+	// TableReport describes the size of the routing table on this instance to help finding memory hogs
+	TableReport struct {
+		RouteIds    int            `json:"routeIds"`    // route IDs in the routing table
+		LiveRoutes  int            `json:"liveRoutes"`  // live routes after sharing identical routes
+		Receivers   int            `json:"receivers"`   // receiver instances
+		Filters     int            `json:"filters"`     // filter instances
+		Senders     int            `json:"senders"`     // sender instances
+		ConfigBytes int            `json:"configBytes"` // size of all live route configs as JSON
+		Routes      []*RouteReport `json:"routes"`      // live routes, largest config first
+	}
+)
+
+func (r *DefaultRoutingTableManager) GetTableReport(ctx context.Context) *TableReport {
+	report := &TableReport{Routes: make([]*RouteReport, 0)}
+	live := make(map[*LiveRouteWrapper]*RouteReport)
+	r.Lock()
+	report.RouteIds = len(r.liveRouteMap)
+	for _, lrw := range r.liveRouteMap {
+		if rr, ok := live[lrw]; ok {
+			rr.RouteIds = append(rr.RouteIds, lrw.Config.Id)
+			continue
+		}
+		live[lrw] = &RouteReport{
+			Tenant:     lrw.Config.TenantId,
+			RouteId:    lrw.Config.Id,
+			RouteIds:   []string{lrw.Config.Id},
+			References: lrw.GetReferenceCount(),
+		}
+	}
+	r.Unlock()
+	for lrw, rr := range live {
+		buf, err := json.Marshal(lrw.Config)
+		if err == nil {
+			rr.ConfigBytes = len(buf)
+		}
+		if lrw.Route != nil {
+			rr.Errors = len(lrw.Route.Errors())
+			rr.WorkersBusy = lrw.Route.Stats().WorkersBusy
+		}
+		sort.Strings(rr.RouteIds)
+		report.ConfigBytes += rr.ConfigBytes
+		report.Routes = append(report.Routes, rr)
+	}
+	report.LiveRoutes = len(report.Routes)
+	report.Receivers = len(r.pluginMgr.ReceiversStatus())
+	report.Filters = len(r.pluginMgr.FiltersStatus())
+	report.Senders = len(r.pluginMgr.SendersStatus())
+	sort.Slice(report.Routes, func(i, j int) bool {
+		if report.Routes[i].ConfigBytes != report.Routes[j].ConfigBytes {
+			return report.Routes[i].ConfigBytes > report.Routes[j].ConfigBytes
+		}
+		return report.Routes[i].Tenant.KeyWithRoute(report.Routes[i].RouteId) < report.Routes[j].Tenant.KeyWithRoute(report.Routes[j].RouteId)
+	})
+	return report
+}
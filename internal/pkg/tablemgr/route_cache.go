@@ -0,0 +1,142 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/route"
+)
+
+const (
+	routeCacheVersion = 1
+
+	// cached routing tables older than this are ignored unless configured otherwise
+	defaultRouteCacheMaxAgeSeconds = 86400
+)
+
+// routeCache is a local snapshot of the live routing table, a restarting node registers the cached routes right away
+// and reconciles them against the route storer in the background
+type routeCache struct {
+	Version int            `json:"version"`
+	Created int64          `json:"created"`
+	Routes  []route.Config `json:"routes"`
+}
+
+// routeCachePath returns the path of the routing table cache or a blank string if warm starts are disabled
+func (r *DefaultRoutingTableManager) routeCachePath() string {
+	if r.config == nil {
+		return ""
+	}
+	return r.config.GetString("ears.routingTable.cache.path")
+}
+
+// saveRouteCache writes the live routing table to the cache file, the file is replaced atomically so that a crash
+// while writing leaves the previous cache intact
+func (r *DefaultRoutingTableManager) saveRouteCache(ctx context.Context) {
+	path := r.routeCachePath()
+	if path == "" {
+		return
+	}
+	cache := routeCache{
+		Version: routeCacheVersion,
+		Created: time.Now().Unix(),
+	}
+	// identical routes under different IDs share a live route which is cached once, the other IDs are restored by
+	// the reconciliation with the route storer
+	seen := make(map[*LiveRouteWrapper]bool)
+	r.Lock()
+	for _, lrw := range r.liveRouteMap {
+		if seen[lrw] {
+			continue
+		}
+		seen[lrw] = true
+		cache.Routes = append(cache.Routes, lrw.Config)
+	}
+	r.Unlock()
+	err := writeRouteCache(path, &cache)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "saveRouteCache").Str("path", path).Msg(err.Error())
+		return
+	}
+	log.Ctx(ctx).Info().Str("op", "saveRouteCache").Str("path", path).Int("routes", len(cache.Routes)).Msg("routing table cached")
+}
+
+func writeRouteCache(path string, cache *routeCache) error {
+	buf, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	// route configs may contain credentials, temp files are only readable by the owner
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(buf)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadRouteCache returns the cached routes, or an error if there is no usable cache
+func (r *DefaultRoutingTableManager) loadRouteCache() ([]route.Config, error) {
+	path := r.routeCachePath()
+	if path == "" {
+		return nil, errors.New("no route cache configured")
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cache routeCache
+	err = json.Unmarshal(buf, &cache)
+	if err != nil {
+		return nil, err
+	}
+	if cache.Version != routeCacheVersion {
+		return nil, errors.New("unsupported route cache version")
+	}
+	maxAge := r.config.GetInt("ears.routingTable.cache.maxAgeSeconds")
+	if maxAge <= 0 {
+		maxAge = defaultRouteCacheMaxAgeSeconds
+	}
+	if time.Since(time.Unix(cache.Created, 0)) > time.Duration(maxAge)*time.Second {
+		return nil, errors.New("route cache expired")
+	}
+	return cache.Routes, nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/wal"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	// sources that cannot redeliver events, pull based sources like kafka or sqs redeliver unacked events themselves
+	defaultJournaledReceivers = "http,syslog"
+
+	// journaled events older than this are not replayed unless configured otherwise
+	defaultJournalMaxAgeSeconds = 86400
+
+	// time a replayed event may take to be processed
+	journalReplayTimeout = 60 * time.Second
+)
+
+// routeJournal journals events received by routes in a write ahead log until they are acked or nacked, so that
+// events in flight when the process crashed are replayed into their routes after a restart
+type routeJournal struct {
+	sync.Mutex
+	log       *wal.Log
+	receivers map[string]bool
+	replay    map[string][]wal.Record // records left over from a previous run by route key
+	logger    *zerolog.Logger
+}
+
+// newRouteJournal returns nil unless the write ahead log is enabled with ears.wal.path
+func newRouteJournal(config config.Config, logger *zerolog.Logger) (*routeJournal, error) {
+	if config == nil || config.GetString("ears.wal.path") == "" {
+		return nil, nil
+	}
+	maxAgeSeconds := config.GetInt("ears.wal.maxAgeSeconds")
+	if maxAgeSeconds <= 0 {
+		maxAgeSeconds = defaultJournalMaxAgeSeconds
+	}
+	l, err := wal.Open(config.GetString("ears.wal.path"), config.GetBool("ears.wal.fsync"), int64(config.GetInt("ears.wal.maxBytes")), time.Duration(maxAgeSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	j := &routeJournal{
+		log:       l,
+		receivers: make(map[string]bool),
+		replay:    make(map[string][]wal.Record),
+		logger:    logger,
+	}
+	receivers := defaultJournaledReceivers
+	if config.GetString("ears.wal.receivers") != "" {
+		receivers = config.GetString("ears.wal.receivers")
+	}
+	for _, plugin := range strings.Split(receivers, ",") {
+		j.receivers[strings.TrimSpace(plugin)] = true
+	}
+	pending := l.Pending()
+	for _, rec := range pending {
+		key := rec.TenantId.KeyWithRoute(rec.RouteId)
+		j.replay[key] = append(j.replay[key], rec)
+	}
+	if len(pending) > 0 {
+		logger.Info().Str("op", "newRouteJournal").Int("events", len(pending)).Msg("replaying journaled events")
+	}
+	return j, nil
+}
+
+// journals returns true if events received by the route are journaled
+func (j *routeJournal) journals(routeConfig *route.Config) bool {
+	return j != nil && j.receivers[routeConfig.Receiver.Plugin]
+}
+
+// takeReplay returns the journaled events of a previous run to be replayed into a route, each event is handed out
+// only once
+func (j *routeJournal) takeReplay(tid tenant.Id, routeId string) []wal.Record {
+	if j == nil {
+		return nil
+	}
+	j.Lock()
+	defer j.Unlock()
+	key := tid.KeyWithRoute(routeId)
+	records := j.replay[key]
+	delete(j.replay, key)
+	return records
+}
+
+// journalReceiver journals the events of a route before they enter the filter chain and marks them done once they
+// have been acked or nacked
+type journalReceiver struct {
+	receiver.Receiver
+	journal *routeJournal
+	tid     tenant.Id
+	routeId string
+	lock    sync.Mutex
+	next    receiver.NextFn
+	backlog []wal.Record
+}
+
+func newJournalReceiver(r receiver.Receiver, journal *routeJournal, tid tenant.Id, routeId string) *journalReceiver {
+	return &journalReceiver{
+		Receiver: r,
+		journal:  journal,
+		tid:      tid,
+		routeId:  routeId,
+	}
+}
+
+func (r *journalReceiver) Receive(next receiver.NextFn) error {
+	r.lock.Lock()
+	r.next = next
+	backlog := r.backlog
+	r.backlog = nil
+	r.lock.Unlock()
+	if len(backlog) > 0 {
+		go r.replay(next, backlog)
+	}
+	return r.Receiver.Receive(func(e event.Event) {
+		next(r.journalEvent(e))
+	})
+}
+
+// Lag returns the lag of the journaled receiver if it reports any
+func (r *journalReceiver) Lag() []receiver.Lag {
+	if lr, ok := r.Receiver.(receiver.LagReporter); ok {
+		return lr.Lag()
+	}
+	return nil
+}
+
+// Replay feeds journaled events of a previous run into the route, events are held back until the route is running
+func (r *journalReceiver) Replay(records []wal.Record) {
+	if len(records) == 0 {
+		return
+	}
+	r.lock.Lock()
+	next := r.next
+	if next == nil {
+		r.backlog = append(r.backlog, records...)
+	}
+	r.lock.Unlock()
+	if next != nil {
+		go r.replay(next, records)
+	}
+}
+
+func (r *journalReceiver) replay(next receiver.NextFn, records []wal.Record) {
+	for _, rec := range records {
+		seq := rec.Seq
+		ctx, cancel := context.WithTimeout(context.Background(), journalReplayTimeout)
+		options := []event.EventOption{
+			event.WithId(rec.EventId),
+			event.WithTenant(rec.TenantId),
+			event.WithMetadata(rec.Metadata),
+			event.WithAck(
+				func(e event.Event) {
+					r.done(seq)
+					cancel()
+				},
+				func(e event.Event, err error) {
+					log.Ctx(e.Context()).Error().Str("op", "journalReceiver.replay").Str("routeId", r.routeId).Str("eventId", e.Id()).Msg("replayed event failed: " + err.Error())
+					r.done(seq)
+					cancel()
+				}),
+		}
+		var err error
+		if rec.ContentType != "" {
+			// binary payloads are journaled base64 encoded
+			var data []byte
+			encoded, _ := rec.Payload.(string)
+			data, err = base64.StdEncoding.DecodeString(encoded)
+			options = append(options, event.WithBinaryPayload(data, rec.ContentType))
+		}
+		var e event.Event
+		if err == nil {
+			e, err = event.New(route.NewContext(ctx, r.routeId), rec.Payload, options...)
+		}
+		if err != nil {
+			r.journal.logger.Error().Str("op", "journalReceiver.replay").Str("routeId", r.routeId).Msg(err.Error())
+			r.done(seq)
+			cancel()
+			continue
+		}
+		next(e)
+	}
+}
+
+// journalEvent journals a received event and returns an event that marks the journal entry done when it is acked or
+// nacked, events that cannot be journaled are processed anyway
+func (r *journalReceiver) journalEvent(e event.Event) event.Event {
+	rec := wal.Record{
+		TenantId: r.tid,
+		RouteId:  r.routeId,
+		EventId:  e.Id(),
+		Metadata: e.Metadata(),
+	}
+	if raw, ok := e.RawPayload(); ok {
+		rec.Payload = json.RawMessage(raw)
+	} else {
+		rec.Payload = e.Payload()
+	}
+	if ct := e.ContentType(); ct != event.ContentTypeJSON {
+		rec.ContentType = ct
+	}
+	seq, err := r.journal.log.Append(rec)
+	if err != nil {
+		log.Ctx(e.Context()).Error().Str("op", "journalReceiver.journalEvent").Str("routeId", r.routeId).Msg(err.Error())
+		return e
+	}
+	journaled, err := event.New(e.Context(), nil,
+		event.WithPayloadOf(e),
+		event.WithId(e.Id()),
+		event.WithTenant(e.Tenant()),
+		event.WithMetadata(e.Metadata()),
+		event.WithAck(
+			func(evt event.Event) {
+				r.done(seq)
+				e.Ack()
+			},
+			func(evt event.Event, err error) {
+				r.done(seq)
+				e.Nack(err)
+			}))
+	if err != nil {
+		r.done(seq)
+		return e
+	}
+	return journaled
+}
+
+func (r *journalReceiver) done(seq uint64) {
+	err := r.journal.log.Done(seq)
+	if err != nil {
+		r.journal.logger.Error().Str("op", "journalReceiver.done").Str("routeId", r.routeId).Msg(err.Error())
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sharder"
+)
+
+const (
+	defaultRouteRebalanceSeconds = 10
+
+	// receivers that listen for pushed events must run on every node behind the load balancer, kinesis receivers
+	// distribute their stream shards across all nodes themselves
+	defaultUnshardedReceivers = "http,syslog,kinesis"
+)
+
+// routeSharder assigns routes to cluster nodes by consistent hashing of their tenant and route IDs, so that each
+// node only runs the routes it owns rather than every node consuming every source
+type routeSharder struct {
+	sync.Mutex
+	identity         string
+	nodeManager      sharder.NodeStateManager
+	ring             *sharder.HashRing
+	unsharded        map[string]bool
+	rebalanceSeconds int
+	logger           *zerolog.Logger
+}
+
+// newRouteSharder returns nil unless route sharding is enabled with ears.sharder.routes.enabled
+func newRouteSharder(config config.Config, logger *zerolog.Logger) (*routeSharder, error) {
+	if config == nil || !config.GetBool("ears.sharder.routes.enabled") {
+		return nil, nil
+	}
+	sharder.InitDistributorConfigs(config)
+	controllerConfig := sharder.DefaultControllerConfig()
+	nodeManager, err := sharder.GetDefaultNodeStateManager(controllerConfig.Identity, controllerConfig.StorageConfig)
+	if err != nil {
+		return nil, err
+	}
+	s := &routeSharder{
+		identity:         controllerConfig.Identity,
+		nodeManager:      nodeManager,
+		unsharded:        make(map[string]bool),
+		rebalanceSeconds: config.GetInt("ears.sharder.routes.rebalanceSeconds"),
+		logger:           logger,
+	}
+	if s.rebalanceSeconds <= 0 {
+		s.rebalanceSeconds = defaultRouteRebalanceSeconds
+	}
+	unsharded := defaultUnshardedReceivers
+	if config.GetString("ears.sharder.routes.unshardedReceivers") != "" {
+		unsharded = config.GetString("ears.sharder.routes.unshardedReceivers")
+	}
+	for _, plugin := range strings.Split(unsharded, ",") {
+		s.unsharded[strings.TrimSpace(plugin)] = true
+	}
+	s.updateNodes()
+	return s, nil
+}
+
+// updateNodes rebuilds the hash ring from the active nodes and returns true if the membership changed, this node
+// is always part of the ring because it may not have registered itself yet
+func (s *routeSharder) updateNodes() bool {
+	nodes, err := s.nodeManager.GetActiveNodes()
+	if err != nil {
+		s.logger.Error().Str("op", "routeSharder.updateNodes").Msg(err.Error())
+		if s.ring != nil {
+			return false
+		}
+	}
+	ring := sharder.NewHashRing(append(nodes, s.identity), sharder.DefaultVirtualNodes)
+	s.Lock()
+	defer s.Unlock()
+	if s.ring != nil && strings.Join(s.ring.Nodes(), ",") == strings.Join(ring.Nodes(), ",") {
+		return false
+	}
+	s.ring = ring
+	s.logger.Info().Str("op", "routeSharder.updateNodes").Strs("nodes", ring.Nodes()).Msg("route sharding membership changed")
+	return true
+}
+
+// owns returns true if this node should run the route
+func (s *routeSharder) owns(routeConfig *route.Config) bool {
+	if s == nil || s.unsharded[routeConfig.Receiver.Plugin] {
+		return true
+	}
+	return s.owner(routeConfig) == s.identity
+}
+
+// owner returns the identity of the node the route is assigned to
+func (s *routeSharder) owner(routeConfig *route.Config) string {
+	s.Lock()
+	defer s.Unlock()
+	return s.ring.Owner(routeConfig.TenantId.KeyWithRoute(routeConfig.Id))
+}
+
+// monitor checks the node membership periodically and calls rebalance when it changed
+func (s *routeSharder) monitor(rebalance func()) {
+	go func() {
+		for {
+			time.Sleep(time.Duration(s.rebalanceSeconds) * time.Second)
+			if s.updateNodes() {
+				rebalance()
+			}
+		}
+	}()
+}
@@ -22,22 +22,30 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/db"
 	"github.com/xmidt-org/ears/internal/pkg/plugin"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/internal/pkg/syncer"
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/fragments"
 	"github.com/xmidt-org/ears/pkg/logs"
 	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type DefaultRoutingTableManager struct {
 	sync.Mutex
+	writeLock    sync.Mutex // serializes route writes so that route preconditions hold until the write
 	pluginMgr    plugin.Manager
 	storageMgr   route.RouteStorer
 	fragmentMgr  fragments.FragmentStorer
@@ -46,6 +54,15 @@ type DefaultRoutingTableManager struct {
 	routeHashMap map[string]*LiveRouteWrapper // references to live routes by hash
 	logger       *zerolog.Logger
 	config       config.Config
+	regErrCnt    int64            // number of failed route registrations since startup
+	sharder      *routeSharder    // nil unless routes are sharded across cluster nodes
+	journal      *routeJournal    // nil unless received events are journaled in a write ahead log
+	scheduler    *route.Scheduler // workers shared by all routes, nil if only the worker pools of the routes limit deliveries
+	metricLabels string           // route labels added to the event metrics of senders, see ears.metrics.routeLabels
+	errorRedact  []string         // paths of payload values redacted in the error logs of routes, see ears.routeErrors.redact
+	slowEvents   time.Duration    // threshold above which events are logged with their journey, see ears.slowEvents.thresholdMs
+	supervisor   *supervisor      // nil unless unhealthy routes are restarted, see ears.supervisor
+	heartbeat    time.Duration    // interval of heartbeat events of routes without their own, see ears.heartbeat.intervalSecs
 }
 
 func stringify(data interface{}) string {
@@ -65,10 +82,12 @@ func SetupRoutingManager(lifecycle fx.Lifecycle, logger *zerolog.Logger, routing
 			OnStart: func(context.Context) error {
 				routingTableMgr.RegisterAllRoutes()
 				routingTableMgr.StartGlobalSyncChecker()
+				routingTableMgr.StartSupervisor()
 				logger.Info().Msg("Routing Manager Service Started")
 				return nil
 			},
 			OnStop: func(ctx context.Context) error {
+				routingTableMgr.StopSupervisor()
 				routingTableMgr.UnregisterAllRoutes()
 				logger.Info().Msg("Routing Manager Stopped")
 				return nil
@@ -86,14 +105,80 @@ func NewRoutingTableManager(pluginMgr plugin.Manager, storageMgr route.RouteStor
 		rtSyncer:    tableSyncer,
 		logger:      logger,
 		config:      config}
+	var err error
+	rtm.sharder, err = newRouteSharder(config, logger)
+	if err != nil {
+		// every node runs all routes, which is safe but defeats the purpose of sharding
+		logger.Error().Str("op", "NewRoutingTableManager").Msg("route sharding disabled: " + err.Error())
+	}
+	rtm.journal, err = newRouteJournal(config, logger)
+	if err != nil {
+		logger.Error().Str("op", "NewRoutingTableManager").Msg("write ahead log disabled: " + err.Error())
+	}
+	rtm.scheduler = newScheduler(config)
+	rtm.metricLabels = metricLabelsMode(config, logger)
+	rtm.errorRedact = errorRedactPaths(config)
+	if config != nil {
+		rtm.slowEvents = time.Duration(config.GetInt("ears.slowEvents.thresholdMs")) * time.Millisecond
+		rtm.heartbeat = time.Duration(config.GetInt("ears.heartbeat.intervalSecs")) * time.Second
+	}
+	rtm.supervisor = newSupervisor(config, logger)
 	rtm.Lock()
 	defer rtm.Unlock()
 	rtm.liveRouteMap = make(map[string]*LiveRouteWrapper)
 	rtm.routeHashMap = make(map[string]*LiveRouteWrapper)
 	tableSyncer.RegisterLocalSyncer(syncer.ITEM_TYPE_ROUTE, rtm) // register self as observer
+	if watcher, ok := storageMgr.(syncer.ItemWatcher); ok {
+		// routes managed outside of the api are synced when the storer sees them change
+		watcher.RegisterLocalSyncer(syncer.ITEM_TYPE_ROUTE, rtm)
+	}
 	return rtm
 }
 
+// newScheduler returns the workers shared by all routes, their number is set by ears.scheduler.workers and a negative
+// number disables the shared limit
+func newScheduler(config config.Config) *route.Scheduler {
+	workers := 0
+	if config != nil {
+		workers = config.GetInt("ears.scheduler.workers")
+	}
+	if workers < 0 {
+		return nil
+	}
+	return route.NewScheduler(workers)
+}
+
+// metricLabelsMode returns which route labels are added to the event metrics of senders as set by
+// ears.metrics.routeLabels, none by default to keep the number of time series low
+func metricLabelsMode(config config.Config, logger *zerolog.Logger) string {
+	mode := route.MetricLabelsNone
+	if config != nil && config.GetString("ears.metrics.routeLabels") != "" {
+		mode = config.GetString("ears.metrics.routeLabels")
+	}
+	switch mode {
+	case route.MetricLabelsNone, route.MetricLabelsRouteId, route.MetricLabelsAll:
+		return mode
+	}
+	logger.Error().Str("op", "NewRoutingTableManager").Str("routeLabels", mode).Msg("unknown ears.metrics.routeLabels, route labels disabled")
+	return route.MetricLabelsNone
+}
+
+// errorRedactPaths returns the paths of payload values redacted in the error logs of routes as set by the comma
+// separated list ears.routeErrors.redact
+func errorRedactPaths(config config.Config) []string {
+	paths := make([]string, 0)
+	if config == nil {
+		return paths
+	}
+	for _, path := range strings.Split(config.GetString("ears.routeErrors.redact"), ",") {
+		path = strings.TrimSpace(path)
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 func (r *DefaultRoutingTableManager) unregisterAndStopRoute(ctx context.Context, tid tenant.Id, routeId string) error {
 	tracer := otel.Tracer(rtsemconv.EARSTracerName)
 	ctx, span := tracer.Start(ctx, "unregisterAndStopRoute")
@@ -158,6 +243,11 @@ func (r *DefaultRoutingTableManager) registerAndRunRoute(ctx context.Context, ro
 		log.Ctx(ctx).Info().Str("op", "registerAndRunRoute").Str("routeId", routeConfig.Id).Msg("ignore inactive route")
 		return nil
 	}
+	// with route sharding only the node owning the route runs it
+	if !r.sharder.owns(routeConfig) {
+		log.Ctx(ctx).Info().Str("op", "registerAndRunRoute").Str("routeId", routeConfig.Id).Msg("ignore route owned by different node")
+		return nil
+	}
 	// An identical route already exists under a different ID.
 	// It would be ok to simply create another route here because plugin manager will ensure we share receiver and sender
 	// plugin for performance. However, simply creating another route would cause event duplication. Instead, we need to
@@ -175,6 +265,7 @@ func (r *DefaultRoutingTableManager) registerAndRunRoute(ctx context.Context, ro
 		log.Ctx(ctx).Info().Str("op", "registerAndRunRoute").Str("routeId", routeConfig.Id).Msg("adding route ID to identical route which already exists under different ID " + existingLiveRoute.Config.Id)
 		existingLiveRoute.AddRouteReference()
 		r.liveRouteMap[routeConfig.TenantId.KeyWithRoute(routeConfig.Id)] = existingLiveRoute
+		existingLiveRoute.replayJournal(r.journal.takeReplay(routeConfig.TenantId, routeConfig.Id))
 		return nil
 	}
 	// otherwise we create a brand-new route
@@ -183,26 +274,157 @@ func (r *DefaultRoutingTableManager) registerAndRunRoute(ctx context.Context, ro
 	err = lrw.Register(ctx, r)
 	if err != nil {
 		log.Ctx(ctx).Error().Str("op", "registerAndRunRoute").Str("routeId", routeConfig.Id).Msg("failed to register new route: " + err.Error())
+		atomic.AddInt64(&r.regErrCnt, 1)
+		emitRegistrationError(routeConfig.TenantId, routeConfig.Id, err)
 		return err
 	}
-	// create live route
-	lrw.Route = &route.Route{}
 	r.liveRouteMap[routeConfig.TenantId.KeyWithRoute(routeConfig.Id)] = lrw
 	r.routeHashMap[routeConfig.Hash(ctx)] = lrw
 	log.Ctx(ctx).Info().Str("op", "registerAndRunRoute").Str("routeId", routeConfig.Id).Msg("starting route")
+	r.runLiveRoute(ctx, lrw)
+	lrw.replayJournal(r.journal.takeReplay(routeConfig.TenantId, routeConfig.Id))
+	return nil
+}
+
+// emitRegistrationError reports a route that could not be registered as a system event
+func emitRegistrationError(tid tenant.Id, routeId string, err error) {
+	sysevent.Emit(sysevent.Event{
+		Type:    sysevent.TypeRegistrationError,
+		Tenant:  tid,
+		RouteId: routeId,
+		Error:   err.Error(),
+	})
+}
+
+// runLiveRoute creates the live route for a registered live route wrapper and runs it in the background
+func (r *DefaultRoutingTableManager) runLiveRoute(ctx context.Context, lrw *LiveRouteWrapper) {
+	lrw.Route = &route.Route{
+		Id:                 lrw.Config.Id,
+		TenantId:           lrw.Config.TenantId,
+		Workers:            lrw.Config.Workers,
+		Ttl:                lrw.Config.Ttl,
+		Priority:           lrw.Config.Priority,
+		Scheduler:          r.scheduler,
+		MetricLabels:       route.NewMetricLabels(r.metricLabels, lrw.Config.Id, lrw.Config.Labels),
+		TraceSampling:      lrw.Config.TraceSampling,
+		ErrorRedact:        r.errorRedact,
+		SlowEventThreshold: r.slowEvents,
+		HeartbeatInterval:  r.heartbeatInterval(lrw.Config),
+	}
+	if lrw.Config.Ordering != nil {
+		lrw.Route.OrderBy = lrw.Config.Ordering.KeyPath
+	}
+	lrw.setRunState(true, nil)
 	go func() {
-		err = lrw.Route.Run(lrw.Receiver, lrw.FilterChain, lrw.Sender) // run is blocking
+		err := lrw.Route.Run(lrw.RouteReceiver(), lrw.RouteFilter(), lrw.RouteSender()) // run is blocking
+		lrw.setRunState(false, err)
 		if err != nil {
-			log.Ctx(ctx).Error().Str("op", "registerAndRunRoute").Msg(err.Error())
+			log.Ctx(ctx).Error().Str("op", "runLiveRoute").Str("routeId", lrw.Config.Id).Msg(err.Error())
 		}
 	}()
+}
+
+// heartbeatInterval returns the interval of the heartbeat events of a route, the route config overrides the default
+func (r *DefaultRoutingTableManager) heartbeatInterval(routeConfig route.Config) time.Duration {
+	if routeConfig.Heartbeat != nil {
+		return time.Duration(routeConfig.Heartbeat.IntervalSecs) * time.Second
+	}
+	return r.heartbeat
+}
+
+// RestartRoute replaces the live route with a freshly registered one, identical routes sharing the live route
+// under different IDs are restarted as well. Routes sharing their receiver or senders with other routes cannot be
+// restarted because the plugin manager would hand the same plugin instances to the new route.
+func (r *DefaultRoutingTableManager) RestartRoute(ctx context.Context, tid tenant.Id, routeId string) error {
+	tracer := otel.Tracer(rtsemconv.EARSTracerName)
+	ctx, span := tracer.Start(ctx, "restartRoute")
+	defer span.End()
+	r.Lock()
+	defer r.Unlock()
+	lrw, ok := r.liveRouteMap[tid.KeyWithRoute(routeId)]
+	if !ok || lrw.Route == nil {
+		return r.liveRouteNotFound(ctx, tid, routeId)
+	}
+	shared := r.sharingRoutes(ctx, lrw)
+	if len(shared) > 0 {
+		return &RoutePluginsSharedError{RouteId: routeId, RouteIds: shared}
+	}
+	// unregistering stops receiving, the senders are stopped explicitly so that the old route is gone before the
+	// new one is registered
+	err := lrw.Unregister(ctx, r)
+	if err != nil {
+		log.Ctx(ctx).Error().Str("op", "RestartRoute").Str("routeId", routeId).Msg("failed to unregister route: " + err.Error())
+	}
+	lrw.stopSenders(ctx)
+	newLrw := NewLiveRouteWrapper(lrw.Config)
+	newLrw.RefCnt = lrw.RefCnt
+	err = newLrw.Register(ctx, r)
+	if err != nil {
+		// the old route is gone already so we drop it from the routing table, the next sync will retry
+		log.Ctx(ctx).Error().Str("op", "RestartRoute").Str("routeId", routeId).Msg("failed to register route: " + err.Error())
+		atomic.AddInt64(&r.regErrCnt, 1)
+		emitRegistrationError(tid, routeId, err)
+		for key, l := range r.liveRouteMap {
+			if l == lrw {
+				delete(r.liveRouteMap, key)
+			}
+		}
+		delete(r.routeHashMap, lrw.Config.Hash(ctx))
+		return &RouteRegistrationError{err}
+	}
+	for key, l := range r.liveRouteMap {
+		if l == lrw {
+			r.liveRouteMap[key] = newLrw
+		}
+	}
+	r.routeHashMap[lrw.Config.Hash(ctx)] = newLrw
+	log.Ctx(ctx).Info().Str("op", "RestartRoute").Str("routeId", routeId).Msg("restarting route")
+	r.runLiveRoute(ctx, newLrw)
 	return nil
 }
 
+// sharingRoutes returns the IDs of the other live routes of the tenant that share the receiver or a sender with the
+// live route, identical plugin configs with the same name share a single plugin instance within a tenant
+func (r *DefaultRoutingTableManager) sharingRoutes(ctx context.Context, lrw *LiveRouteWrapper) []string {
+	instances := lrw.pluginInstances(ctx)
+	shared := make(map[string]bool)
+	for _, l := range r.liveRouteMap {
+		if l == lrw || l.Config.TenantId != lrw.Config.TenantId {
+			continue
+		}
+		for id := range l.pluginInstances(ctx) {
+			if instances[id] {
+				shared[l.Config.Id] = true
+				break
+			}
+		}
+	}
+	ids := make([]string, 0, len(shared))
+	for id := range shared {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (r *DefaultRoutingTableManager) RemoveRoute(ctx context.Context, tid tenant.Id, routeId string) error {
+	return r.RemoveRouteIf(ctx, tid, routeId, nil)
+}
+
+func (r *DefaultRoutingTableManager) RemoveRouteIf(ctx context.Context, tid tenant.Id, routeId string, precondition RoutePrecondition) error {
 	if routeId == "" {
 		return errors.New("missing route ID")
 	}
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+	err := r.checkPrecondition(ctx, tid, routeId, precondition)
+	if err != nil {
+		return err
+	}
+	// check before stopping the route because a read only storer would keep it
+	if db.IsReadOnly(r.storageMgr) {
+		return r.storageMgr.DeleteRoute(ctx, tid, routeId)
+	}
 	storageErr := r.storageMgr.DeleteRoute(ctx, tid, routeId)
 	if storageErr != nil {
 		// even if the route cannot be deleted from storage we should still proceed to try to sync the delta
@@ -218,114 +440,224 @@ func (r *DefaultRoutingTableManager) RemoveRoute(ctx context.Context, tid tenant
 }
 
 func (r *DefaultRoutingTableManager) RouteEvent(ctx context.Context, tid tenant.Id, routeId string, payload interface{}) (string, error) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	// no need to cancel context here because RouteEvent is only used synchronously via API call
+	traceId, err := r.routeEvent(ctx, tid, routeId, "", payload, func(err error) {
+		wg.Done()
+	})
+	if err != nil {
+		return "", err
+	}
+	wg.Wait()
+	return traceId, nil
+}
+
+func (r *DefaultRoutingTableManager) RouteEventAsync(ctx context.Context, tid tenant.Id, routeId string, eventId string, payload interface{}, done func(err error)) (string, error) {
+	// the event outlives the request so it must not be canceled along with the request context
+	ctx = trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+	return r.routeEvent(ctx, tid, routeId, eventId, payload, done)
+}
+
+// routeEvent triggers the receiver of a route with a new event and calls done with nil once the event is acked or
+// with the error it was nacked with, the event gets a new ID if eventId is blank
+func (r *DefaultRoutingTableManager) routeEvent(ctx context.Context, tid tenant.Id, routeId string, eventId string, payload interface{}, done func(err error)) (string, error) {
+	r.Lock()
 	lrw, ok := r.liveRouteMap[tid.KeyWithRoute(routeId)]
+	r.Unlock()
 	if !ok {
-		return "", errors.New("no route " + routeId)
+		return "", r.liveRouteNotFound(ctx, tid, routeId)
 	}
 	if lrw.Receiver == nil {
 		return "", errors.New("no receiver for route " + routeId)
 	}
-	var wg sync.WaitGroup
-	wg.Add(1)
-	//sctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	//sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	// no need to cancel context here because RouteEvent is only used synchronously via API call
-	e, err := event.New(ctx, payload, event.WithAck(
-		func(evt event.Event) {
-			wg.Done()
-			//cancel()
-		}, func(evt event.Event, err error) {
-			r.logger.Error().Str("op", "routeTestEvent").Msg("failed to process message: " + err.Error())
-			wg.Done()
-			//cancel()
-		}),
+	options := []event.EventOption{
+		event.WithAck(
+			func(evt event.Event) {
+				done(nil)
+			}, func(evt event.Event, err error) {
+				r.logger.Error().Str("op", "routeTestEvent").Msg("failed to process message: " + err.Error())
+				done(err)
+			}),
 		event.WithOtelTracing("routeTestEvent"),
 		event.WithTenant(tid),
 		event.WithTracePayloadOnNack(false),
-	)
+	}
+	if eventId != "" {
+		options = append(options, event.WithId(eventId))
+	}
+	e, err := event.New(ctx, payload, options...)
 	if err != nil {
 		return "", errors.New("bad test event for route " + routeId)
 	}
 	traceId, _, _ := e.GetPathValue("trace.id")
 	traceIdStr, _ := traceId.(string)
 	lrw.Receiver.Trigger(e)
-	wg.Wait()
 	return traceIdStr, nil
 }
 
-func (r *DefaultRoutingTableManager) AddRoute(ctx context.Context, routeConfig *route.Config) error {
-	if routeConfig == nil {
-		return errors.New("missing route config")
+// inflateFragment returns the fragment referenced by a plugin config or the plugin config itself if it does not reference a fragment
+func (r *DefaultRoutingTableManager) inflateFragment(ctx context.Context, tid tenant.Id, pc route.PluginConfig) (route.PluginConfig, error) {
+	if pc.FragmentName == "" {
+		return pc, nil
 	}
-	// inflate fragments if any are present
-	if routeConfig.Sender.FragmentName != "" {
-		fragment, err := r.fragmentMgr.GetFragment(ctx, routeConfig.TenantId, routeConfig.Sender.FragmentName)
+	fragment, err := r.fragmentMgr.GetFragment(ctx, tid, pc.FragmentName)
+	if err != nil {
+		return pc, err
+	}
+	if fragment.Plugin == "" {
+		return pc, errors.New("fragment " + pc.FragmentName + " has no plugin type")
+	}
+	if fragment.Config == nil {
+		return pc, errors.New("fragment " + pc.FragmentName + " has no config")
+	}
+	if pc.Plugin != "" && pc.Plugin != fragment.Plugin {
+		return pc, errors.New("fragment type mismatch " + pc.Plugin + " vs " + fragment.Plugin)
+	}
+	if pc.Name != "" {
+		fragment.Name = pc.Name
+	}
+	return fragment, nil
+}
+
+// inflateFragments replaces all fragment references in a route config with the fragments
+func (r *DefaultRoutingTableManager) inflateFragments(ctx context.Context, routeConfig *route.Config) error {
+	var err error
+	routeConfig.Sender, err = r.inflateFragment(ctx, routeConfig.TenantId, routeConfig.Sender)
+	if err != nil {
+		return err
+	}
+	routeConfig.Receiver, err = r.inflateFragment(ctx, routeConfig.TenantId, routeConfig.Receiver)
+	if err != nil {
+		return err
+	}
+	for idx, filter := range routeConfig.FilterChain {
+		routeConfig.FilterChain[idx], err = r.inflateFragment(ctx, routeConfig.TenantId, filter)
 		if err != nil {
 			return err
 		}
-		if fragment.Plugin == "" {
-			return errors.New("fragment " + routeConfig.Sender.FragmentName + " has no plugin type")
-		}
-		if fragment.Config == nil {
-			return errors.New("fragment " + routeConfig.Sender.FragmentName + " has no config")
-		}
-		if routeConfig.Sender.Plugin != "" && routeConfig.Sender.Plugin != fragment.Plugin {
-			return errors.New("fragment type mismatch " + routeConfig.Sender.Plugin + " vs " + fragment.Plugin)
-		}
-		if routeConfig.Sender.Name != "" {
-			fragment.Name = routeConfig.Sender.Name
+	}
+	if routeConfig.DeadLetter != nil {
+		deadLetter, err := r.inflateFragment(ctx, routeConfig.TenantId, *routeConfig.DeadLetter)
+		if err != nil {
+			return err
 		}
-		routeConfig.Sender = fragment
+		routeConfig.DeadLetter = &deadLetter
 	}
-	if routeConfig.Receiver.FragmentName != "" {
-		fragment, err := r.fragmentMgr.GetFragment(ctx, routeConfig.TenantId, routeConfig.Receiver.FragmentName)
+	for name, branch := range routeConfig.Branches {
+		routeConfig.Branches[name], err = r.inflateFragment(ctx, routeConfig.TenantId, branch)
 		if err != nil {
 			return err
 		}
-		if fragment.Plugin == "" {
-			return errors.New("fragment " + routeConfig.Receiver.FragmentName + " has no plugin type")
+	}
+	return nil
+}
+
+// validatePlugins checks that all plugins of a route are known and that their configs are accepted by the plugins,
+// receivers and senders are checked by the validators of their plugins, filters are created and torn down again
+func (r *DefaultRoutingTableManager) validatePlugins(ctx context.Context, routeConfig *route.Config) error {
+	tid := routeConfig.TenantId
+	if _, ok := r.pluginMgr.Receiverers()[routeConfig.Receiver.Plugin]; !ok {
+		return errors.New("unknown receiver plugin " + routeConfig.Receiver.Plugin)
+	}
+	err := r.pluginMgr.ValidateReceiver(ctx, routeConfig.Receiver.Plugin, routeConfig.Receiver.Name, stringify(routeConfig.Receiver.Config), tid)
+	if err != nil {
+		return err
+	}
+	senderers := r.pluginMgr.Senderers()
+	senders := []route.PluginConfig{routeConfig.Sender}
+	if routeConfig.DeadLetter != nil {
+		senders = append(senders, *routeConfig.DeadLetter)
+	}
+	for _, branch := range routeConfig.Branches {
+		senders = append(senders, branch)
+	}
+	for _, sc := range senders {
+		if _, ok := senderers[sc.Plugin]; !ok {
+			return errors.New("unknown sender plugin " + sc.Plugin)
 		}
-		if fragment.Config == nil {
-			return errors.New("fragment " + routeConfig.Receiver.FragmentName + " has no config")
+		err = r.pluginMgr.ValidateSender(ctx, sc.Plugin, sc.Name, stringify(sc.Config), tid)
+		if err != nil {
+			return err
 		}
-		if routeConfig.Receiver.Plugin != "" && routeConfig.Receiver.Plugin != fragment.Plugin {
-			return errors.New("fragment type mismatch " + routeConfig.Receiver.Plugin + " vs " + fragment.Plugin)
+	}
+	filterers := r.pluginMgr.Filterers()
+	for _, fc := range routeConfig.FilterChain {
+		if _, ok := filterers[fc.Plugin]; !ok {
+			return errors.New("unknown filter plugin " + fc.Plugin)
 		}
-		if routeConfig.Receiver.Name != "" {
-			fragment.Name = routeConfig.Receiver.Name
+		err = r.pluginMgr.ValidateFilter(ctx, fc.Plugin, fc.Name, stringify(fc.Config), tid)
+		if err != nil {
+			return err
 		}
-		routeConfig.Receiver = fragment
 	}
-	for idx, filter := range routeConfig.FilterChain {
-		if filter.FragmentName != "" {
-			fragment, err := r.fragmentMgr.GetFragment(ctx, routeConfig.TenantId, filter.FragmentName)
-			if err != nil {
-				return err
-			}
-			if fragment.Plugin == "" {
-				return errors.New("fragment " + filter.FragmentName + " has no plugin type")
-			}
-			if fragment.Config == nil {
-				return errors.New("fragment " + filter.FragmentName + " has no config")
-			}
-			if filter.Plugin != "" && filter.Plugin != fragment.Plugin {
-				return errors.New("fragment type mismatch " + filter.Plugin + " vs " + fragment.Plugin)
-			}
-			if filter.Name != "" {
-				fragment.Name = filter.Name
-			}
-			routeConfig.FilterChain[idx] = fragment
+	return nil
+}
+
+func (r *DefaultRoutingTableManager) ValidateRoute(ctx context.Context, routeConfig *route.Config) error {
+	if routeConfig == nil {
+		return errors.New("missing route config")
+	}
+	err := r.inflateFragments(ctx, routeConfig)
+	if err != nil {
+		return err
+	}
+	if routeConfig.Id == "" {
+		routeConfig.Id = routeConfig.Hash(ctx)
+	}
+	err = routeConfig.Validate(ctx)
+	if err != nil {
+		return &RouteValidationError{err}
+	}
+	err = r.validatePlugins(ctx, routeConfig)
+	if err != nil {
+		return &RouteValidationError{err}
+	}
+	return nil
+}
+
+func (r *DefaultRoutingTableManager) AddRoute(ctx context.Context, routeConfig *route.Config) error {
+	return r.AddRouteIf(ctx, routeConfig, nil)
+}
+
+func (r *DefaultRoutingTableManager) AddRouteIf(ctx context.Context, routeConfig *route.Config, precondition RoutePrecondition) error {
+	if routeConfig == nil {
+		return errors.New("missing route config")
+	}
+	r.writeLock.Lock()
+	defer r.writeLock.Unlock()
+	if db.IsReadOnly(r.storageMgr) {
+		err := r.checkPrecondition(ctx, routeConfig.TenantId, routeConfig.Id, precondition)
+		if err != nil {
+			return err
 		}
+		return r.storageMgr.SetRoute(ctx, *routeConfig)
+	}
+	// inflate fragments if any are present
+	err := r.inflateFragments(ctx, routeConfig)
+	if err != nil {
+		return err
 	}
 	// use hashed ID if none is provided - this ID will be returned by the AddRoute REST API
 	routeHash := routeConfig.Hash(ctx)
 	if routeConfig.Id == "" {
 		routeConfig.Id = routeHash
 	}
-	err := routeConfig.Validate(ctx)
+	err = r.checkPrecondition(ctx, routeConfig.TenantId, routeConfig.Id, precondition)
+	if err != nil {
+		return err
+	}
+	err = routeConfig.Validate(ctx)
 	if err != nil {
 		return &RouteValidationError{err}
 	}
+	if !r.sharder.owns(routeConfig) {
+		// the owning node registers the route, checking the plugins here still rejects bad configs right away
+		err = r.validatePlugins(ctx, routeConfig)
+		if err != nil {
+			return &RouteValidationError{err}
+		}
+	}
 	err = r.registerAndRunRoute(ctx, routeConfig)
 	if err != nil {
 		return &RouteRegistrationError{err}
@@ -343,6 +675,19 @@ func (r *DefaultRoutingTableManager) AddRoute(ctx context.Context, routeConfig *
 	return nil
 }
 
+// checkPrecondition checks the precondition of a route write against the currently stored route, caller must hold
+// the write lock
+func (r *DefaultRoutingTableManager) checkPrecondition(ctx context.Context, tid tenant.Id, routeId string, precondition RoutePrecondition) error {
+	if precondition == nil {
+		return nil
+	}
+	current, err := r.GetRoute(ctx, tid, routeId)
+	if err != nil {
+		current = nil
+	}
+	return precondition(current)
+}
+
 func (r *DefaultRoutingTableManager) setRunningStatus(routes []route.Config) {
 	for idx, _ := range routes {
 		rid := routes[idx].TenantId.KeyWithRoute(routes[idx].Id)
@@ -373,6 +718,49 @@ func (r *DefaultRoutingTableManager) GetRoute(ctx context.Context, tid tenant.Id
 	return &rte, nil
 }
 
+// liveRouteNotFound returns the error for a route missing from the routing table, which tells the caller which node
+// to ask if route sharding assigned the route to another node
+func (r *DefaultRoutingTableManager) liveRouteNotFound(ctx context.Context, tid tenant.Id, routeId string) error {
+	if r.sharder != nil {
+		routeConfig, err := r.storageMgr.GetRoute(ctx, tid, routeId)
+		if err == nil && !r.sharder.owns(&routeConfig) {
+			return &RouteNotOwnedError{RouteId: routeId, Owner: r.sharder.owner(&routeConfig)}
+		}
+	}
+	return &route.RouteNotFoundError{TenantId: tid, RouteId: routeId}
+}
+
+func (r *DefaultRoutingTableManager) GetRouteStats(ctx context.Context, tid tenant.Id, routeId string) (*route.StatsSnapshot, error) {
+	r.Lock()
+	lrw, ok := r.liveRouteMap[tid.KeyWithRoute(routeId)]
+	r.Unlock()
+	if !ok || lrw.Route == nil {
+		return nil, r.liveRouteNotFound(ctx, tid, routeId)
+	}
+	stats := lrw.Route.Stats()
+	return &stats, nil
+}
+
+func (r *DefaultRoutingTableManager) GetRouteErrors(ctx context.Context, tid tenant.Id, routeId string) ([]route.RouteError, error) {
+	r.Lock()
+	lrw, ok := r.liveRouteMap[tid.KeyWithRoute(routeId)]
+	r.Unlock()
+	if !ok || lrw.Route == nil {
+		return nil, r.liveRouteNotFound(ctx, tid, routeId)
+	}
+	return lrw.Route.Errors(), nil
+}
+
+func (r *DefaultRoutingTableManager) TapRoute(ctx context.Context, tid tenant.Id, routeId string, sampleRate float64, fn route.TapFn) (func(), error) {
+	r.Lock()
+	lrw, ok := r.liveRouteMap[tid.KeyWithRoute(routeId)]
+	r.Unlock()
+	if !ok || lrw.Route == nil {
+		return nil, r.liveRouteNotFound(ctx, tid, routeId)
+	}
+	return lrw.Route.AddTap(sampleRate, fn), nil
+}
+
 func (r *DefaultRoutingTableManager) GetAllTenantRoutes(ctx context.Context, tenantId tenant.Id) ([]route.Config, error) {
 	routes, err := r.storageMgr.GetAllTenantRoutes(ctx, tenantId)
 	if err != nil {
@@ -394,11 +782,19 @@ func (r *DefaultRoutingTableManager) GetAllRoutes(ctx context.Context) ([]route.
 
 func (r *DefaultRoutingTableManager) GetAllSendersStatus(ctx context.Context) (map[string]plugin.SenderStatus, error) {
 	senders := r.pluginMgr.SendersStatus()
+	for key, status := range senders {
+		status.Health = r.supervisor.pluginHealth(pluginKindSender, status.Tid, status.Plugin, status.Name)
+		senders[key] = status
+	}
 	return senders, nil
 }
 
 func (r *DefaultRoutingTableManager) GetAllReceiversStatus(ctx context.Context) (map[string]plugin.ReceiverStatus, error) {
 	receivers := r.pluginMgr.ReceiversStatus()
+	for key, status := range receivers {
+		status.Health = r.supervisor.pluginHealth(pluginKindReceiver, status.Tid, status.Plugin, status.Name)
+		receivers[key] = status
+	}
 	return receivers, nil
 }
 
@@ -412,11 +808,6 @@ func (r *DefaultRoutingTableManager) AddFragment(ctx context.Context, tid tenant
 	return err
 }
 
-func (r *DefaultRoutingTableManager) RemoveFragment(ctx context.Context, tid tenant.Id, fragmentId string) error {
-	err := r.fragmentMgr.DeleteFragment(ctx, tid, fragmentId)
-	return err
-}
-
 func (r *DefaultRoutingTableManager) GetFragment(ctx context.Context, tid tenant.Id, fragmentId string) (route.PluginConfig, error) {
 	fragment, err := r.fragmentMgr.GetFragment(ctx, tid, fragmentId)
 	return fragment, err
@@ -432,6 +823,18 @@ func (r *DefaultRoutingTableManager) GetAllTenantFragments(ctx context.Context,
 	return fragments, err
 }
 
+func (r *DefaultRoutingTableManager) RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer) {
+	if r.rtSyncer != nil {
+		r.rtSyncer.RegisterLocalSyncer(itemType, localSyncer)
+	}
+	if itemType != syncer.ITEM_TYPE_ROUTE {
+		return
+	}
+	if watcher, ok := r.storageMgr.(syncer.ItemWatcher); ok {
+		watcher.RegisterLocalSyncer(itemType, localSyncer)
+	}
+}
+
 func (r *DefaultRoutingTableManager) SyncItem(ctx context.Context, tid tenant.Id, routeId string, add bool) error {
 	if add {
 		routeConfig, err := r.storageMgr.GetRoute(ctx, tid, routeId)
@@ -445,6 +848,16 @@ func (r *DefaultRoutingTableManager) SyncItem(ctx context.Context, tid tenant.Id
 }
 
 func (r *DefaultRoutingTableManager) StartGlobalSyncChecker() {
+	if r.sharder != nil {
+		// routes move between nodes when nodes join or leave the cluster
+		r.sharder.monitor(func() {
+			cnt, err := r.SynchronizeAllRoutes()
+			if err != nil {
+				r.logger.Error().Str("op", "rebalanceRoutes").Msg(err.Error())
+			}
+			r.logger.Info().Str("op", "rebalanceRoutes").Int("mutated", cnt).Msg("routes rebalanced")
+		})
+	}
 	go func() {
 		time.Sleep(5 * time.Second)
 		for {
@@ -464,10 +877,16 @@ func (r *DefaultRoutingTableManager) StartGlobalSyncChecker() {
 
 func (r *DefaultRoutingTableManager) IsSynchronized() (bool, error) {
 	ctx := context.Background()
-	storedRoutes, err := r.storageMgr.GetAllRoutes(ctx)
+	routes, err := r.storageMgr.GetAllRoutes(ctx)
 	if err != nil {
 		return true, err
 	}
+	storedRoutes := make([]route.Config, 0, len(routes))
+	for idx := range routes {
+		if r.sharder.owns(&routes[idx]) {
+			storedRoutes = append(storedRoutes, routes[idx])
+		}
+	}
 	r.Lock()
 	defer r.Unlock()
 	if len(storedRoutes) != len(r.liveRouteMap) {
@@ -507,7 +926,10 @@ func (r *DefaultRoutingTableManager) SynchronizeAllRoutes() (int, error) {
 	}
 	storedRouteMap := make(map[string]route.Config)
 	for _, storedRoute := range storedRoutes {
-		storedRouteMap[storedRoute.TenantId.KeyWithRoute(storedRoute.Id)] = storedRoute
+		// routes owned by other nodes are stopped here, which moves them off this node after a rebalance
+		if r.sharder.owns(&storedRoute) {
+			storedRouteMap[storedRoute.TenantId.KeyWithRoute(storedRoute.Id)] = storedRoute
+		}
 	}
 	mutated := 0
 	r.Lock()
@@ -536,7 +958,7 @@ func (r *DefaultRoutingTableManager) SynchronizeAllRoutes() (int, error) {
 	for _, storedRoute := range storedRoutes {
 		_, ok := lrm[storedRoute.TenantId.KeyWithRoute(storedRoute.Id)]
 		if !ok {
-			if !storedRoute.Inactive {
+			if !storedRoute.Inactive && r.sharder.owns(&storedRoute) {
 				if storedRoute.Region == "" || storedRoute.Region == r.config.GetString("ears.region") {
 					log.Ctx(ctx).Error().Str("op", "synchronize").Str("routeId", storedRoute.Id).Str("keyWithRoute", storedRoute.TenantId.KeyWithRoute(storedRoute.Id)).Msg("missing route started")
 					rc := storedRoute
@@ -546,12 +968,15 @@ func (r *DefaultRoutingTableManager) SynchronizeAllRoutes() (int, error) {
 			}
 		}
 	}
+	r.saveRouteCache(ctx)
 	return mutated, nil
 }
 
 func (r *DefaultRoutingTableManager) UnregisterAllRoutes() error {
 	ctx := logs.SubLoggerCtx(context.Background(), r.logger)
 	log.Ctx(ctx).Info().Str("op", "UnregisterAllRoutes").Msg("starting to unregister all routes")
+	// keep the routing table for the next warm start
+	r.saveRouteCache(ctx)
 	var err error
 	for _, lrw := range r.liveRouteMap {
 		log.Ctx(ctx).Info().Str("op", "UnregisterAllRoutes").Msg("unregistering route " + lrw.Config.Id)
@@ -569,9 +994,18 @@ func (r *DefaultRoutingTableManager) RegisterAllRoutes() error {
 	ctx := logs.SubLoggerCtx(context.Background(), r.logger)
 	log.Ctx(ctx).Info().Str("op", "RegisterAllRoutes").Msg("starting to register all routes")
 	var err error
-	routeConfigs, err := r.storageMgr.GetAllRoutes(ctx)
-	if err != nil {
-		return err
+	routeConfigs, cacheErr := r.loadRouteCache()
+	warmStart := cacheErr == nil
+	if warmStart {
+		log.Ctx(ctx).Info().Str("op", "RegisterAllRoutes").Int("routes", len(routeConfigs)).Msg("warm start from cached routing table")
+	} else {
+		if r.routeCachePath() != "" && !os.IsNotExist(cacheErr) {
+			log.Ctx(ctx).Error().Str("op", "RegisterAllRoutes").Msg("ignoring route cache: " + cacheErr.Error())
+		}
+		routeConfigs, err = r.storageMgr.GetAllRoutes(ctx)
+		if err != nil {
+			return err
+		}
 	}
 	for _, routeConfig := range routeConfigs {
 		rc := routeConfig
@@ -583,6 +1017,19 @@ func (r *DefaultRoutingTableManager) RegisterAllRoutes() error {
 		}
 	}
 	log.Ctx(ctx).Info().Str("op", "RegisterAllRoutes").Msg("done registering all routes")
+	if warmStart {
+		// the cached routes are running, bring them up to date with the route storer
+		go func() {
+			cnt, err := r.SynchronizeAllRoutes()
+			if err != nil {
+				log.Ctx(ctx).Error().Str("op", "RegisterAllRoutes").Msg("failed to reconcile cached routing table: " + err.Error())
+				return
+			}
+			log.Ctx(ctx).Info().Str("op", "RegisterAllRoutes").Int("mutated", cnt).Msg("cached routing table reconciled")
+		}()
+	} else {
+		r.saveRouteCache(ctx)
+	}
 	return nil
 }
 
@@ -0,0 +1,145 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/boriwo/deepcopy"
+	"github.com/google/uuid"
+	"github.com/xmidt-org/ears/pkg/event"
+	pkgfilter "github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+type (
+	// SimulatedEvent is a snapshot of an event taken while simulating a route
+	SimulatedEvent struct {
+		Payload  interface{}            `json:"payload"`
+		Metadata map[string]interface{} `json:"metadata,omitempty"`
+		Branch   string                 `json:"branch,omitempty"` // branch sender the event would be delivered to, if any
+	}
+
+	// SimulationStep traces a single filter of a simulated filter chain
+	SimulationStep struct {
+		Plugin    string           `json:"plugin"`
+		Name      string           `json:"name,omitempty"`
+		EventsIn  int              `json:"eventsIn"`
+		EventsOut int              `json:"eventsOut"`
+		Duration  int64            `json:"durationUs"` // time spent in filter in microseconds
+		Events    []SimulatedEvent `json:"events"`
+	}
+
+	// SimulationResult holds the events that would be delivered to the senders of a route along with a trace of all filters
+	SimulationResult struct {
+		RouteId string           `json:"routeId"`
+		Events  []SimulatedEvent `json:"events"`
+		Steps   []SimulationStep `json:"steps"`
+	}
+)
+
+func snapshot(evts []event.Event) []SimulatedEvent {
+	snapshots := make([]SimulatedEvent, 0, len(evts))
+	for _, e := range evts {
+		md, _ := deepcopy.DeepCopy(e.Metadata()).(map[string]interface{})
+		snapshots = append(snapshots, SimulatedEvent{
+			Payload:  deepcopy.DeepCopy(e.Payload()),
+			Metadata: md,
+		})
+	}
+	return snapshots
+}
+
+// SimulateRoute runs a payload through fresh instances of the filters of a route and captures the resulting events
+// instead of sending them. Filters are not shared with running routes so that stateful filters are not affected.
+func (r *DefaultRoutingTableManager) SimulateRoute(ctx context.Context, tid tenant.Id, routeId string, payload interface{}) (*SimulationResult, error) {
+	routeConfig, err := r.storageMgr.GetRoute(ctx, tid, routeId)
+	if err != nil {
+		return nil, err
+	}
+	// a unique name per simulation guarantees new filter instances
+	suffix := "_simulation_" + uuid.New().String()
+	filterers := make([]pkgfilter.Filterer, 0, len(routeConfig.FilterChain))
+	defer func() {
+		for _, f := range filterers {
+			r.pluginMgr.UnregisterFilter(ctx, f)
+		}
+	}()
+	for _, fc := range routeConfig.FilterChain {
+		f, err := r.pluginMgr.RegisterFilter(ctx, fc.Plugin, fc.Name+suffix, stringify(fc.Config), tid)
+		if err != nil {
+			return nil, &RouteRegistrationError{err}
+		}
+		filterers = append(filterers, f)
+	}
+	// in cloud events mode the route turns events into cloud events before and after the filter chain
+	stages := filterers
+	names := make([]route.PluginConfig, 0, len(filterers)+2)
+	names = append(names, routeConfig.FilterChain...)
+	if ce := routeConfig.CloudEvents; ce != nil {
+		egress, err := cloudevents.NewRouteEgress(tid, ce.Format)
+		if err != nil {
+			return nil, &RouteRegistrationError{err}
+		}
+		ingress := cloudevents.NewRouteIngress(tid, routeId, ce.Source, ce.Type)
+		stages = append([]pkgfilter.Filterer{ingress}, filterers...)
+		stages = append(stages, egress)
+		names = append([]route.PluginConfig{{Plugin: ingress.Plugin(), Name: ingress.Name()}}, names...)
+		names = append(names, route.PluginConfig{Plugin: egress.Plugin(), Name: egress.Name()})
+	}
+	e, err := event.New(route.NewContext(ctx, routeId), payload, event.WithTenant(tid))
+	if err != nil {
+		return nil, errors.New("bad test event for route " + routeId)
+	}
+	result := &SimulationResult{
+		RouteId: routeId,
+		Steps:   make([]SimulationStep, 0, len(stages)),
+	}
+	evts := []event.Event{e}
+	for idx, f := range stages {
+		step := SimulationStep{
+			Plugin:   names[idx].Plugin,
+			Name:     names[idx].Name,
+			EventsIn: len(evts),
+		}
+		start := time.Now()
+		out := make([]event.Event, 0)
+		for _, e := range evts {
+			out = append(out, f.Filter(e)...)
+		}
+		step.Duration = time.Since(start).Microseconds()
+		step.EventsOut = len(out)
+		step.Events = snapshot(out)
+		result.Steps = append(result.Steps, step)
+		evts = out
+	}
+	result.Events = snapshot(evts)
+	if len(routeConfig.Branches) > 0 {
+		path := branchPath(&routeConfig)
+		for idx, e := range evts {
+			branch, _, _ := e.GetPathValue(path)
+			if name, ok := branch.(string); ok {
+				if _, ok := routeConfig.Branches[name]; ok {
+					result.Events[idx].Branch = name
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/internal/pkg/config"
+	"github.com/xmidt-org/ears/internal/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	supervisorDefaultIntervalSecs   = 30
+	supervisorDefaultBackoffSecs    = 10
+	supervisorDefaultMaxBackoffSecs = 600
+	supervisorCheckTimeout          = 5 * time.Second
+)
+
+const (
+	pluginKindReceiver = "receiver"
+	pluginKindSender   = "sender"
+)
+
+// supervisor periodically checks the receivers and senders of the live routes and restarts routes with an unhealthy
+// plugin. A plugin is unhealthy if its route stopped running, its health check fails, its route did not receive any
+// event for longer than maxIdle or its sender nacked at least maxFailureStreak events in a row. Restarts of a route
+// back off exponentially until its plugins are found healthy again.
+type supervisor struct {
+	sync.Mutex
+	interval         time.Duration
+	maxIdle          time.Duration // zero disables the idle check
+	maxFailureStreak int64         // zero disables the failure streak check
+	backoff          time.Duration
+	maxBackoff       time.Duration
+	health           map[string]*plugin.PluginHealth // latest check by plugin key, see pluginHealthKey
+	restarts         map[string]*routeRestarts       // restart state by route key
+	logger           *zerolog.Logger
+	done             chan struct{}
+}
+
+type routeRestarts struct {
+	count int
+	last  time.Time
+	next  time.Time // no restart before this time
+}
+
+// newSupervisor returns nil unless ears.supervisor.active is set
+func newSupervisor(config config.Config, logger *zerolog.Logger) *supervisor {
+	if config == nil || !config.GetBool("ears.supervisor.active") {
+		return nil
+	}
+	s := &supervisor{
+		interval:         time.Duration(config.GetInt("ears.supervisor.intervalSecs")) * time.Second,
+		maxIdle:          time.Duration(config.GetInt("ears.supervisor.maxIdleSecs")) * time.Second,
+		maxFailureStreak: int64(config.GetInt("ears.supervisor.maxFailureStreak")),
+		backoff:          time.Duration(config.GetInt("ears.supervisor.backoffSecs")) * time.Second,
+		maxBackoff:       time.Duration(config.GetInt("ears.supervisor.maxBackoffSecs")) * time.Second,
+		health:           make(map[string]*plugin.PluginHealth),
+		restarts:         make(map[string]*routeRestarts),
+		logger:           logger,
+	}
+	if s.interval <= 0 {
+		s.interval = supervisorDefaultIntervalSecs * time.Second
+	}
+	if s.backoff <= 0 {
+		s.backoff = supervisorDefaultBackoffSecs * time.Second
+	}
+	if s.maxBackoff < s.backoff {
+		s.maxBackoff = supervisorDefaultMaxBackoffSecs * time.Second
+		if s.maxBackoff < s.backoff {
+			s.maxBackoff = s.backoff
+		}
+	}
+	return s
+}
+
+func pluginHealthKey(kind string, tid tenant.Id, pluginType string, name string) string {
+	return kind + "/" + tid.Key() + "/" + pluginType + "/" + name
+}
+
+// supervisedRoute is a live route to check, identical routes sharing a live route are only checked once
+type supervisedRoute struct {
+	tid     tenant.Id
+	routeId string
+	lrw     *LiveRouteWrapper
+}
+
+// StartSupervisor starts checking the health of the plugins of the live routes if ears.supervisor.active is set
+func (r *DefaultRoutingTableManager) StartSupervisor() {
+	if r.supervisor == nil {
+		return
+	}
+	r.supervisor.Lock()
+	if r.supervisor.done != nil {
+		r.supervisor.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	r.supervisor.done = done
+	r.supervisor.Unlock()
+	go func() {
+		ticker := time.NewTicker(r.supervisor.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.superviseRoutes(context.Background())
+			}
+		}
+	}()
+}
+
+// StopSupervisor stops checking the health of the plugins of the live routes
+func (r *DefaultRoutingTableManager) StopSupervisor() {
+	if r.supervisor == nil {
+		return
+	}
+	r.supervisor.Lock()
+	defer r.supervisor.Unlock()
+	if r.supervisor.done != nil {
+		close(r.supervisor.done)
+		r.supervisor.done = nil
+	}
+}
+
+// superviseRoutes checks the plugins of all live routes once and restarts the routes with unhealthy plugins
+func (r *DefaultRoutingTableManager) superviseRoutes(ctx context.Context) {
+	routes := make([]supervisedRoute, 0)
+	seen := make(map[*LiveRouteWrapper]bool)
+	r.Lock()
+	for _, lrw := range r.liveRouteMap {
+		if seen[lrw] || lrw.Route == nil {
+			continue
+		}
+		seen[lrw] = true
+		routes = append(routes, supervisedRoute{tid: lrw.Config.TenantId, routeId: lrw.Config.Id, lrw: lrw})
+	}
+	r.Unlock()
+	s := r.supervisor
+	live := make(map[string]bool)
+	for _, sr := range routes {
+		routeKey := sr.tid.KeyWithRoute(sr.routeId)
+		live[routeKey] = true
+		receiverErr, senderErr := s.check(ctx, sr.lrw)
+		now := time.Now()
+		s.Lock()
+		rr, ok := s.restarts[routeKey]
+		if !ok {
+			rr = &routeRestarts{}
+			s.restarts[routeKey] = rr
+		}
+		healthy := receiverErr == nil && senderErr == nil
+		if healthy {
+			rr.count = 0
+		}
+		restart := !healthy && !now.Before(rr.next)
+		if restart {
+			rr.count++
+			rr.last = now
+			rr.next = now.Add(s.restartBackoff(rr.count))
+		}
+		s.setHealth(pluginKindReceiver, sr.tid, sr.lrw.Config.Receiver.Plugin, sr.lrw.Config.Receiver.Name, receiverErr, now, rr)
+		s.setHealth(pluginKindSender, sr.tid, sr.lrw.Config.Sender.Plugin, sr.lrw.Config.Sender.Name, senderErr, now, rr)
+		s.Unlock()
+		if !restart {
+			continue
+		}
+		unhealthy := receiverErr
+		if unhealthy == nil {
+			unhealthy = senderErr
+		}
+		s.logger.Warn().Str("op", "supervisor").Str("tenantId", sr.tid.ToString()).Str("routeId", sr.routeId).
+			Int("restarts", rr.count).Str("error", unhealthy.Error()).Msg("restarting unhealthy route")
+		err := r.RestartRoute(ctx, sr.tid, sr.routeId)
+		if err != nil {
+			s.logger.Error().Str("op", "supervisor").Str("tenantId", sr.tid.ToString()).Str("routeId", sr.routeId).
+				Str("error", err.Error()).Msg("failed to restart route")
+		}
+	}
+	// forget routes that are gone
+	s.Lock()
+	for routeKey := range s.restarts {
+		if !live[routeKey] {
+			delete(s.restarts, routeKey)
+		}
+	}
+	for key, h := range s.health {
+		if time.Duration(time.Now().UnixNano()/int64(time.Millisecond)-h.CheckedAt)*time.Millisecond > 2*s.interval {
+			delete(s.health, key)
+		}
+	}
+	s.Unlock()
+}
+
+// check returns the problems of the receiver and sender of a live route, nil if they are healthy
+func (s *supervisor) check(ctx context.Context, lrw *LiveRouteWrapper) (error, error) {
+	ctx, cancel := context.WithTimeout(ctx, supervisorCheckTimeout)
+	defer cancel()
+	var receiverErr, senderErr error
+	running, runErr := lrw.RunState()
+	if !running {
+		receiverErr = runErr
+		if receiverErr == nil {
+			receiverErr = errors.New("route stopped")
+		}
+	} else if hc, ok := lrw.Receiver.(receiver.HealthChecker); ok {
+		receiverErr = hc.CheckHealth(ctx)
+	}
+	stats := lrw.Route.Stats()
+	if receiverErr == nil && s.maxIdle > 0 && stats.LastEvent > 0 {
+		idle := time.Since(time.Unix(0, stats.LastEvent*int64(time.Millisecond)))
+		if idle > s.maxIdle {
+			receiverErr = fmt.Errorf("no events received for %s", idle.Truncate(time.Second))
+		}
+	}
+	if hc, ok := lrw.Sender.(sender.HealthChecker); ok {
+		senderErr = hc.CheckHealth(ctx)
+	}
+	if senderErr == nil && s.maxFailureStreak > 0 && stats.FailureStreak >= s.maxFailureStreak {
+		senderErr = fmt.Errorf("%d events nacked in a row", stats.FailureStreak)
+	}
+	return receiverErr, senderErr
+}
+
+// restartBackoff returns the time to wait after the nth restart of a route before restarting it again
+func (s *supervisor) restartBackoff(n int) time.Duration {
+	backoff := s.backoff
+	for i := 1; i < n && backoff < s.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > s.maxBackoff {
+		backoff = s.maxBackoff
+	}
+	return backoff
+}
+
+// setHealth records the result of a plugin check, must be called while holding the lock
+func (s *supervisor) setHealth(kind string, tid tenant.Id, pluginType string, name string, err error, now time.Time, rr *routeRestarts) {
+	h := &plugin.PluginHealth{
+		Healthy:   err == nil,
+		CheckedAt: now.UnixNano() / int64(time.Millisecond),
+		Restarts:  rr.count,
+	}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	if !rr.last.IsZero() {
+		h.RestartedAt = rr.last.UnixNano() / int64(time.Millisecond)
+	}
+	s.health[pluginHealthKey(kind, tid, pluginType, name)] = h
+}
+
+// pluginHealth returns a copy of the latest check of a plugin, nil if it has not been checked
+func (s *supervisor) pluginHealth(kind string, tid tenant.Id, pluginType string, name string) *plugin.PluginHealth {
+	if s == nil {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	h, ok := s.health[pluginHealthKey(kind, tid, pluginType, name)]
+	if !ok {
+		return nil
+	}
+	health := *h
+	return &health
+}
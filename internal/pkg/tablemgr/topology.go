@@ -0,0 +1,121 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"sort"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	TopologyTypeReceiver = "receiver"
+	TopologyTypeFilter   = "filter"
+	TopologyTypeSender   = "sender"
+)
+
+type (
+	// TopologyPlugin is a plugin instance and the routes sharing it, identical plugin configs with the same
+	// name share a single plugin instance within a tenant
+	TopologyPlugin struct {
+		Id     string   `json:"id"`
+		Type   string   `json:"type"`
+		Plugin string   `json:"plugin"`
+		Name   string   `json:"name,omitempty"`
+		Routes []string `json:"routes"`
+		Shared bool     `json:"shared"`
+	}
+
+	// Topology is the graph of plugin instances of a tenant and the routes using them
+	Topology struct {
+		Plugins []*TopologyPlugin `json:"plugins"`
+	}
+)
+
+// ForRoute reduces the topology to the plugins of a route, each listing all routes affected by changing it
+func (t *Topology) ForRoute(routeId string) *Topology {
+	result := &Topology{Plugins: make([]*TopologyPlugin, 0)}
+	for _, p := range t.Plugins {
+		for _, rid := range p.Routes {
+			if rid == routeId {
+				result.Plugins = append(result.Plugins, p)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// SharedOnly reduces the topology to plugins used by more than one route
+func (t *Topology) SharedOnly() *Topology {
+	result := &Topology{Plugins: make([]*TopologyPlugin, 0)}
+	for _, p := range t.Plugins {
+		if p.Shared {
+			result.Plugins = append(result.Plugins, p)
+		}
+	}
+	return result
+}
+
+func (r *DefaultRoutingTableManager) GetTopology(ctx context.Context, tid tenant.Id) (*Topology, error) {
+	routes, err := r.storageMgr.GetAllTenantRoutes(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	plugins := make(map[string]*TopologyPlugin)
+	add := func(typ string, pc route.PluginConfig, routeId string) {
+		id := typ + ":" + pc.Hash(ctx)
+		p, ok := plugins[id]
+		if !ok {
+			p = &TopologyPlugin{Id: id, Type: typ, Plugin: pc.Plugin, Name: pc.Name, Routes: make([]string, 0)}
+			plugins[id] = p
+		}
+		// a route may use the same sender more than once, e.g. as sender and as branch
+		for _, rid := range p.Routes {
+			if rid == routeId {
+				return
+			}
+		}
+		p.Routes = append(p.Routes, routeId)
+	}
+	for _, rc := range routes {
+		add(TopologyTypeReceiver, rc.Receiver, rc.Id)
+		for _, f := range rc.FilterChain {
+			add(TopologyTypeFilter, f, rc.Id)
+		}
+		add(TopologyTypeSender, rc.Sender, rc.Id)
+		if rc.DeadLetter != nil {
+			add(TopologyTypeSender, *rc.DeadLetter, rc.Id)
+		}
+		for _, b := range rc.Branches {
+			add(TopologyTypeSender, b, rc.Id)
+		}
+	}
+	topology := &Topology{Plugins: make([]*TopologyPlugin, 0, len(plugins))}
+	for _, p := range plugins {
+		sort.Strings(p.Routes)
+		p.Shared = len(p.Routes) > 1
+		topology.Plugins = append(topology.Plugins, p)
+	}
+	sort.Slice(topology.Plugins, func(i, j int) bool {
+		if len(topology.Plugins[i].Routes) != len(topology.Plugins[j].Routes) {
+			return len(topology.Plugins[i].Routes) > len(topology.Plugins[j].Routes)
+		}
+		return topology.Plugins[i].Id < topology.Plugins[j].Id
+	})
+	return topology, nil
+}
@@ -24,14 +24,25 @@ import (
 
 type (
 
+	// A RoutePrecondition is checked against the currently stored route, nil if there is none, right before a route
+	// is written. Route writes of a node are serialized so the route cannot change on this node between the check
+	// and the write. An error returned by the precondition aborts the write and is returned as is.
+	RoutePrecondition func(current *route.Config) error
+
 	// A RoutingTableManager supports modifying and querying an EARS routing table
 	RoutingTableManager interface {
 		RoutingTableGlobalSyncer // routing table manager delegates to routing table global syncer for startup and tear down
 		syncer.LocalSyncer       // to sync routing table upon receipt of an update notification for a single route
 		// AddRoute adds a route to live routing table and runs it and also stores the route in the persistence layer
 		AddRoute(ctx context.Context, route *route.Config) error
+		// AddRouteIf adds a route like AddRoute if the precondition accepts the currently stored route
+		AddRouteIf(ctx context.Context, route *route.Config, precondition RoutePrecondition) error
+		// ValidateRoute resolves fragments and validates a route including its plugin configs without storing or running it
+		ValidateRoute(ctx context.Context, route *route.Config) error
 		// RemoveRoute removes a route from a live routing table and stops it and also removes the route from the persistence layer
 		RemoveRoute(ctx context.Context, tenantId tenant.Id, routeId string) error
+		// RemoveRouteIf removes a route like RemoveRoute if the precondition accepts the currently stored route
+		RemoveRouteIf(ctx context.Context, tenantId tenant.Id, routeId string, precondition RoutePrecondition) error
 		// GetRoute gets a single route by its ID from persistence layer
 		GetRoute(ctx context.Context, tenantId tenant.Id, routeId string) (*route.Config, error)
 		// GetAllTenantRoutes gets all routes for a tenant from persistence layer
@@ -50,17 +61,44 @@ type (
 		GetAllTenantFragments(ctx context.Context, tenantId tenant.Id) ([]route.PluginConfig, error)
 		// GetFragment gets a single fragment
 		GetFragment(ctx context.Context, tenantId tenant.Id, fragmentId string) (route.PluginConfig, error)
-		// DeleteFragment delete a fragment by its name
-		RemoveFragment(ctx context.Context, tenantId tenant.Id, fragmentId string) error
+		// RemoveFragment deletes a fragment by its name, fragments still referenced by routes are only deleted if force is true
+		// in which case the references are dropped from the routes which keep the fragment config
+		RemoveFragment(ctx context.Context, tenantId tenant.Id, fragmentId string, force bool) error
+		// GetTenantUsage gets the event consumption of a tenant and its routes running on this instance over recent windows
+		GetTenantUsage(ctx context.Context, tenantId tenant.Id) (*TenantUsage, error)
+		// GetTopology gets the plugin instances of a tenant and the routes sharing them
+		GetTopology(ctx context.Context, tenantId tenant.Id) (*Topology, error)
+		// GetFragmentUsage lists all routes referencing a fragment
+		GetFragmentUsage(ctx context.Context, tenantId tenant.Id, fragmentId string) ([]FragmentUsage, error)
 		// AddFragment adds a new fragment
 		AddFragment(ctx context.Context, tid tenant.Id, fragmentConfig route.PluginConfig) error
 		// Send test event to route
 		RouteEvent(ctx context.Context, tid tenant.Id, routeId string, payload interface{}) (string, error)
+		// Send event to route without waiting for it to be processed, done is called with the ack or nack outcome
+		RouteEventAsync(ctx context.Context, tid tenant.Id, routeId string, eventId string, payload interface{}, done func(err error)) (string, error)
+		// Simulate route by running a payload through the filter chain of a route without sending it
+		SimulateRoute(ctx context.Context, tid tenant.Id, routeId string, payload interface{}) (*SimulationResult, error)
+		// GetRouteStats gets runtime statistics of a route running on this instance
+		GetRouteStats(ctx context.Context, tid tenant.Id, routeId string) (*route.StatsSnapshot, error)
+		// GetRouteErrors gets the most recent errors of a route running on this instance
+		GetRouteErrors(ctx context.Context, tid tenant.Id, routeId string) ([]route.RouteError, error)
+		// TapRoute registers a tap receiving copies of a sample of the events flowing through a route running on this instance
+		TapRoute(ctx context.Context, tid tenant.Id, routeId string, sampleRate float64, fn route.TapFn) (func(), error)
+		// RestartRoute tears down and re-instantiates receiver, filters and sender of a route running on this instance
+		RestartRoute(ctx context.Context, tid tenant.Id, routeId string) error
+		// GetHealth reports storer and sync backend connectivity and the run state of all live routes on this instance
+		GetHealth(ctx context.Context) *Health
+		// GetTableReport reports the size of the routing table and its live routes on this instance
+		GetTableReport(ctx context.Context) *TableReport
 	}
 
 	RoutingTableGlobalSyncer interface {
 		// StartGlobalSyncChecker
 		StartGlobalSyncChecker()
+		// StartSupervisor starts restarting routes with unhealthy receivers or senders if enabled by ears.supervisor.active
+		StartSupervisor()
+		// StopSupervisor stops restarting routes with unhealthy receivers or senders
+		StopSupervisor()
 		// RegisterAllRoutes
 		RegisterAllRoutes() error
 		// UnregisterAllRoutes
@@ -71,5 +109,8 @@ type (
 		IsSynchronized() (bool, error)
 		// GetAllRegisteredRoutes gets all routes that are currently registered and running on ears instance
 		GetAllRegisteredRoutes() ([]route.Config, error)
+		// RegisterLocalSyncer registers an observer of items synced from other instances and of routes changed
+		// outside the api
+		RegisterLocalSyncer(itemType string, localSyncer syncer.LocalSyncer)
 	}
 )
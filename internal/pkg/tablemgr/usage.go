@@ -0,0 +1,117 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablemgr
+
+import (
+	"context"
+	"sort"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// UsageWindowSecs are the recent windows tenant usage is reported for
+var UsageWindowSecs = []int{10, 60}
+
+type (
+	// UsageWindow is the event consumption within the most recent seconds
+	UsageWindow struct {
+		WindowSecs     int     `json:"windowSecs"`
+		EventsReceived int64   `json:"eventsReceived"`
+		EventsPerSec   float64 `json:"eventsPerSec"`
+		SendSuccess    int64   `json:"sendSuccess"`
+		SendFailure    int64   `json:"sendFailure"`
+		Utilization    float64 `json:"utilization,omitempty"` // share of the quota consumed, only reported for tenants
+	}
+
+	RouteUsage struct {
+		RouteId string        `json:"routeId"`
+		Running bool          `json:"running"`
+		Windows []UsageWindow `json:"windows"`
+	}
+
+	// TenantUsage is the event consumption of a tenant and its routes running on this instance
+	TenantUsage struct {
+		RouteCount        int           `json:"routeCount"`
+		RunningRouteCount int           `json:"runningRouteCount"`
+		Windows           []UsageWindow `json:"windows"`
+		Routes            []RouteUsage  `json:"routes"`
+	}
+)
+
+func usageWindow(snapshot route.StatsSnapshot) UsageWindow {
+	return UsageWindow{
+		WindowSecs:     snapshot.WindowSecs,
+		EventsReceived: snapshot.EventsReceived,
+		EventsPerSec:   snapshot.EventsPerSec,
+		SendSuccess:    snapshot.SendSuccess,
+		SendFailure:    snapshot.SendFailure,
+	}
+}
+
+func (r *DefaultRoutingTableManager) GetTenantUsage(ctx context.Context, tid tenant.Id) (*TenantUsage, error) {
+	routes, err := r.storageMgr.GetAllTenantRoutes(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	usage := &TenantUsage{
+		RouteCount: len(routes),
+		Windows:    make([]UsageWindow, len(UsageWindowSecs)),
+		Routes:     make([]RouteUsage, 0, len(routes)),
+	}
+	for idx, secs := range UsageWindowSecs {
+		usage.Windows[idx].WindowSecs = secs
+	}
+	// identical routes share a live route and its statistics, so tenant totals count each live route once
+	counted := make(map[*LiveRouteWrapper]bool)
+	for _, rc := range routes {
+		ru := RouteUsage{RouteId: rc.Id, Windows: make([]UsageWindow, 0, len(UsageWindowSecs))}
+		r.Lock()
+		lrw, ok := r.liveRouteMap[tid.KeyWithRoute(rc.Id)]
+		r.Unlock()
+		if ok && lrw.Route != nil {
+			ru.Running = true
+			usage.RunningRouteCount++
+			for idx, secs := range UsageWindowSecs {
+				w := usageWindow(lrw.Route.StatsWindow(secs))
+				ru.Windows = append(ru.Windows, w)
+				if !counted[lrw] {
+					usage.Windows[idx].EventsReceived += w.EventsReceived
+					usage.Windows[idx].SendSuccess += w.SendSuccess
+					usage.Windows[idx].SendFailure += w.SendFailure
+				}
+			}
+			counted[lrw] = true
+		}
+		usage.Routes = append(usage.Routes, ru)
+	}
+	for idx := range usage.Windows {
+		usage.Windows[idx].EventsPerSec = float64(usage.Windows[idx].EventsReceived) / float64(usage.Windows[idx].WindowSecs)
+	}
+	// heaviest consumers first
+	received := func(ru RouteUsage) int64 {
+		if len(ru.Windows) == 0 {
+			return -1
+		}
+		return ru.Windows[len(ru.Windows)-1].EventsReceived
+	}
+	sort.SliceStable(usage.Routes, func(i, j int) bool {
+		if received(usage.Routes[i]) != received(usage.Routes[j]) {
+			return received(usage.Routes[i]) > received(usage.Routes[j])
+		}
+		return usage.Routes[i].RouteId < usage.Routes[j].RouteId
+	})
+	return usage, nil
+}
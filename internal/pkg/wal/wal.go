@@ -0,0 +1,279 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	opAdd  = "add"
+	opDone = "done"
+
+	// the journal is compacted once it grows beyond this size unless configured otherwise
+	DefaultMaxBytes = 64 * 1024 * 1024
+)
+
+// Record is a received event journaled by the write ahead log
+type Record struct {
+	Seq         uint64                 `json:"seq"`
+	Op          string                 `json:"op"`
+	TenantId    tenant.Id              `json:"tenant,omitempty"`
+	RouteId     string                 `json:"routeId,omitempty"`
+	EventId     string                 `json:"eventId,omitempty"`
+	Payload     interface{}            `json:"payload,omitempty"`
+	ContentType string                 `json:"contentType,omitempty"` // set for binary payloads, which are journaled base64 encoded
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Created     int64                  `json:"created,omitempty"` // time the event was journaled, in unix milliseconds
+}
+
+// Log is a write ahead log journaling received events to a local file until they are acked or nacked. Every event
+// appends an add record and a done record once it has been processed. The journal is truncated whenever no events are
+// in flight and rewritten with only the pending records once it grows too large, so records left in the journal after
+// a crash are exactly the events that were never processed.
+type Log struct {
+	sync.Mutex
+	path     string
+	fsync    bool
+	maxBytes int64
+	file     *os.File
+	size     int64
+	live     int64 // size of the journal right after the last compaction
+	seq      uint64
+	pending  map[uint64]*Record
+}
+
+// Open opens the write ahead log at path and loads the records left over from a previous run, records older than
+// maxAge are discarded (no limit if zero). If fsync is true every record is flushed to disk before Append returns,
+// otherwise records survive crashes of the process but not necessarily of the host.
+func Open(path string, fsync bool, maxBytes int64, maxAge time.Duration) (*Log, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	l := &Log{
+		path:     path,
+		fsync:    fsync,
+		maxBytes: maxBytes,
+		pending:  make(map[uint64]*Record),
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, &WalError{Op: "open", Source: err}
+	}
+	err = l.load(maxAge)
+	if err != nil {
+		return nil, &WalError{Op: "load", Source: err}
+	}
+	err = l.compact()
+	if err != nil {
+		return nil, &WalError{Op: "compact", Source: err}
+	}
+	return l, nil
+}
+
+// load reads the records of a previous run, a partially written last record of a crashed process is ignored
+func (l *Log) load(maxAge time.Duration) error {
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), int(l.maxBytes))
+	for scanner.Scan() {
+		var rec Record
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+			continue
+		}
+		if rec.Seq > l.seq {
+			l.seq = rec.Seq
+		}
+		switch rec.Op {
+		case opAdd:
+			l.pending[rec.Seq] = &rec
+		case opDone:
+			delete(l.pending, rec.Seq)
+		}
+	}
+	if maxAge > 0 {
+		oldest := time.Now().Add(-maxAge).UnixNano() / int64(time.Millisecond)
+		for seq, rec := range l.pending {
+			if rec.Created < oldest {
+				delete(l.pending, seq)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// compact replaces the journal with one holding only the pending records, the file is replaced atomically so that
+// a crash while compacting leaves the previous journal intact
+func (l *Log) compact() error {
+	tmp, err := os.OpenFile(l.path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, rec := range l.sortedPending() {
+		buf, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		n, _ := w.Write(append(buf, '\n'))
+		size += int64(n)
+	}
+	err = w.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = os.Rename(tmp.Name(), l.path)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file, err = os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	l.size = size
+	l.live = size
+	return nil
+}
+
+func (l *Log) sortedPending() []*Record {
+	records := make([]*Record, 0, len(l.pending))
+	for _, rec := range l.pending {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Seq < records[j].Seq
+	})
+	return records
+}
+
+func (l *Log) write(rec *Record) error {
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	n, err := l.file.Write(append(buf, '\n'))
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if l.fsync {
+		return l.file.Sync()
+	}
+	return nil
+}
+
+// Append journals a received event and returns the sequence number to mark it done with
+func (l *Log) Append(rec Record) (uint64, error) {
+	l.Lock()
+	defer l.Unlock()
+	l.seq++
+	rec.Seq = l.seq
+	rec.Op = opAdd
+	if rec.Created == 0 {
+		rec.Created = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	err := l.write(&rec)
+	if err != nil {
+		return 0, &WalError{Op: "append", Source: err}
+	}
+	l.pending[rec.Seq] = &rec
+	return rec.Seq, nil
+}
+
+// Done removes an event from the journal once it has been acked or nacked
+func (l *Log) Done(seq uint64) error {
+	l.Lock()
+	defer l.Unlock()
+	if _, ok := l.pending[seq]; !ok {
+		return nil
+	}
+	delete(l.pending, seq)
+	if l.file == nil {
+		return &WalError{Op: "done", Source: os.ErrClosed}
+	}
+	var err error
+	if len(l.pending) == 0 {
+		// nothing in flight, start over with an empty journal
+		err = l.file.Truncate(0)
+		if err == nil {
+			l.size = 0
+			l.live = 0
+			return nil
+		}
+	} else if l.size > l.maxBytes && l.size > 2*l.live {
+		// only compact if it frees a good part of the journal, a large backlog of pending events would otherwise be
+		// rewritten on every ack
+		err = l.compact()
+		if err == nil {
+			return nil
+		}
+	} else {
+		err = l.write(&Record{Seq: seq, Op: opDone})
+	}
+	if err != nil {
+		return &WalError{Op: "done", Source: err}
+	}
+	return nil
+}
+
+// Pending returns the journaled events that have not been acked or nacked yet in the order they were received
+func (l *Log) Pending() []Record {
+	l.Lock()
+	defer l.Unlock()
+	records := make([]Record, 0, len(l.pending))
+	for _, rec := range l.sortedPending() {
+		records = append(records, *rec)
+	}
+	return records
+}
+
+func (l *Log) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
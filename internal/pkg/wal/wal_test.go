@@ -0,0 +1,149 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/wal"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestWal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wal", "events.log")
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	l, err := wal.Open(path, true, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open wal: %s", err.Error())
+	}
+	seqs := make([]uint64, 0)
+	for i := 0; i < 3; i++ {
+		seq, err := l.Append(wal.Record{TenantId: tid, RouteId: "r1", EventId: "e", Payload: map[string]interface{}{"n": float64(i)}})
+		if err != nil {
+			t.Fatalf("cannot append: %s", err.Error())
+		}
+		seqs = append(seqs, seq)
+	}
+	err = l.Done(seqs[1])
+	if err != nil {
+		t.Fatalf("cannot mark done: %s", err.Error())
+	}
+	// simulate a crash leaving a partially written record behind
+	l.Close()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("cannot open journal: %s", err.Error())
+	}
+	f.WriteString(`{"seq":4,"op":"add","pay`)
+	f.Close()
+	l, err = wal.Open(path, false, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot reopen wal: %s", err.Error())
+	}
+	pending := l.Pending()
+	if len(pending) != 2 || pending[0].Seq != seqs[0] || pending[1].Seq != seqs[2] {
+		t.Fatalf("unexpected pending records %v", pending)
+	}
+	if pending[0].TenantId != tid || pending[0].RouteId != "r1" || !reflect.DeepEqual(pending[1].Payload, map[string]interface{}{"n": float64(2)}) {
+		t.Fatalf("unexpected pending record %v", pending[0])
+	}
+	// sequence numbers continue after a restart
+	seq, err := l.Append(wal.Record{TenantId: tid, RouteId: "r1"})
+	if err != nil {
+		t.Fatalf("cannot append: %s", err.Error())
+	}
+	if seq <= seqs[2] {
+		t.Fatalf("sequence number %d reused", seq)
+	}
+	// the journal is truncated once nothing is in flight
+	for _, s := range []uint64{seqs[0], seqs[2], seq} {
+		err = l.Done(s)
+		if err != nil {
+			t.Fatalf("cannot mark done: %s", err.Error())
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("cannot stat journal: %s", err.Error())
+	}
+	if info.Size() != 0 {
+		t.Fatalf("expected empty journal but size is %d", info.Size())
+	}
+	l.Close()
+}
+
+func TestWalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	l, err := wal.Open(path, false, 1024, 0)
+	if err != nil {
+		t.Fatalf("cannot open wal: %s", err.Error())
+	}
+	defer l.Close()
+	first, err := l.Append(wal.Record{RouteId: "r1"})
+	if err != nil {
+		t.Fatalf("cannot append: %s", err.Error())
+	}
+	for i := 0; i < 100; i++ {
+		seq, err := l.Append(wal.Record{RouteId: "r1", Payload: "0123456789"})
+		if err != nil {
+			t.Fatalf("cannot append: %s", err.Error())
+		}
+		err = l.Done(seq)
+		if err != nil {
+			t.Fatalf("cannot mark done: %s", err.Error())
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("cannot stat journal: %s", err.Error())
+	}
+	if info.Size() > 2048 {
+		t.Fatalf("journal was not compacted, size is %d", info.Size())
+	}
+	pending := l.Pending()
+	if len(pending) != 1 || pending[0].Seq != first {
+		t.Fatalf("unexpected pending records %v", pending)
+	}
+}
+
+func TestWalMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.log")
+	l, err := wal.Open(path, false, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot open wal: %s", err.Error())
+	}
+	_, err = l.Append(wal.Record{RouteId: "old", Created: time.Now().Add(-2*time.Hour).UnixNano() / int64(time.Millisecond)})
+	if err != nil {
+		t.Fatalf("cannot append: %s", err.Error())
+	}
+	_, err = l.Append(wal.Record{RouteId: "new"})
+	if err != nil {
+		t.Fatalf("cannot append: %s", err.Error())
+	}
+	l.Close()
+	l, err = wal.Open(path, false, 0, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot reopen wal: %s", err.Error())
+	}
+	defer l.Close()
+	pending := l.Pending()
+	if len(pending) != 1 || pending[0].RouteId != "new" {
+		t.Fatalf("unexpected pending records %v", pending)
+	}
+}
@@ -169,8 +169,20 @@ func TestConfigRead(t *testing.T) {
 				"array":   []interface{}{"one", "two", "three"},
 			},
 		},
+		{
+			file: "file://./testdata/cli_env.yaml",
+			values: map[string]interface{}{
+				"string":  "Here is a default",
+				"boolean": true,
+				"number":  12,
+				"array":   []interface{}{"one", "two", "${CLI_TEST_ARRAY_ITEM}"},
+			},
+		},
 	}
 
+	t.Setenv("CLI_TEST_BOOLEAN", "true")
+	t.Setenv("CLI_TEST_ARRAY_ITEM", "two")
+
 	for _, test := range testCases {
 
 		viper.Set("config", test.file)
@@ -23,6 +23,8 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/xmidt-org/ears/internal/pkg/aws/s3"
+	pkgconfig "github.com/xmidt-org/ears/pkg/config"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -88,6 +90,9 @@ func ViperAddArguments(cmd *cobra.Command, aList []Argument) error {
 
 var configFile = ""
 
+// envPrefix and configName of the last call of ViperConfig, used to read the config again
+var lastEnvPrefix, lastConfigName = "", ""
+
 // ViperConfigFile is only for visibility/debugging so that the user knows exactly which
 // config file is used
 func ViperConfigFile() string {
@@ -100,6 +105,7 @@ func ViperConfigFile() string {
 //   cli.ViperConfig("ears")
 func ViperConfig(envPrefix, configName string) error {
 
+	lastEnvPrefix, lastConfigName = envPrefix, configName
 	viper.SetEnvPrefix(envPrefix)
 	viper.SetConfigName(configName)
 
@@ -125,6 +131,11 @@ func ViperConfig(envPrefix, configName string) error {
 			if err != nil && !errors.As(err, &fileNotFoundErr) {
 				return &ConfigError{err, config}
 			}
+			return nil
+		}
+		err = readExpandedConfigFile(viper.ConfigFileUsed())
+		if err != nil {
+			return &ConfigError{err, config}
 		}
 		return nil
 	}
@@ -156,7 +167,7 @@ func ViperConfig(envPrefix, configName string) error {
 			return &ConfigError{err, config}
 		}
 
-		err = viper.ReadConfig(strings.NewReader(data))
+		err = viper.ReadConfig(strings.NewReader(pkgconfig.ExpandEnv(data)))
 		if err != nil {
 			return &ConfigError{err, config}
 		}
@@ -177,8 +188,29 @@ func ViperConfig(envPrefix, configName string) error {
 			// Return an error
 			return &ConfigError{err, config}
 		}
+		if err := readExpandedConfigFile(viper.ConfigFileUsed()); err != nil {
+			return &ConfigError{err, config}
+		}
 
 	}
 
 	return nil
 }
+
+// ViperReloadConfig reads the config again from the same location as the last call of ViperConfig
+func ViperReloadConfig() error {
+	return ViperConfig(lastEnvPrefix, lastConfigName)
+}
+
+// readExpandedConfigFile reads a config file again with references to environment variables expanded
+func readExpandedConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ext := strings.TrimLeft(filepath.Ext(path), ".")
+	if ext != "" {
+		viper.SetConfigType(ext)
+	}
+	return viper.ReadConfig(strings.NewReader(pkgconfig.ExpandEnv(string(data))))
+}
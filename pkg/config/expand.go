@@ -0,0 +1,141 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"github.com/goccy/go-yaml"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envReferencePrefix starts an environment variable reference, it also starts secret references
+const envReferencePrefix = "${"
+
+// envReferenceRegex matches ${VAR} and ${VAR:-default}, a leading $$ escapes the reference
+var envReferenceRegex = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnv replaces ${VAR} references to environment variables with their values. ${VAR:-default} uses the
+// default if the variable is unset or empty, unset variables without default expand to an empty string. $${VAR}
+// is not expanded and becomes ${VAR}. Other references such as ${secret://...} are left unchanged.
+func ExpandEnv(s string) string {
+	if !strings.Contains(s, envReferencePrefix) {
+		return s
+	}
+	return envReferenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		m := envReferenceRegex.FindStringSubmatch(ref)
+		val := os.Getenv(m[1])
+		if val == "" && m[2] != "" {
+			return m[3]
+		}
+		return val
+	})
+}
+
+// ExpandEnvConfig expands environment variable references in the string values of a plugin config
+// as described in ExpandEnv, see MapStringValues for the returned config.
+func ExpandEnvConfig(config interface{}) (interface{}, error) {
+	return MapStringValues(config, envReferencePrefix, func(s string) (string, error) {
+		return ExpandEnv(s), nil
+	})
+}
+
+// MapStringValues applies fn to all string values of a plugin config that contains the marker. Configs without
+// the marker are returned unchanged, otherwise the mapped config is returned as a json string, which all plugins
+// accept in place of their config struct. Json and yaml strings are decoded before mapping their values.
+// The config itself is not modified.
+func MapStringValues(config interface{}, marker string, fn func(string) (string, error)) (interface{}, error) {
+	if !HasMarker(config, marker) {
+		return config, nil
+	}
+	var doc interface{}
+	switch c := config.(type) {
+	case string:
+		// configs of routes are json, configs given directly to the plugin manager may also be yaml
+		err := json.Unmarshal([]byte(c), &doc)
+		if err != nil {
+			err = yaml.Unmarshal([]byte(c), &doc)
+		}
+		if err != nil {
+			return nil, &DataParseError{Err: err}
+		}
+	case []byte:
+		return MapStringValues(string(c), marker, fn)
+	default:
+		buf, err := json.Marshal(config)
+		if err != nil {
+			return nil, &DataParseError{Err: err}
+		}
+		err = json.Unmarshal(buf, &doc)
+		if err != nil {
+			return nil, &DataParseError{Err: err}
+		}
+	}
+	doc, err := mapStringValues(doc, marker, fn)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return nil, &DataParseError{Err: err}
+	}
+	return string(buf), nil
+}
+
+// HasMarker returns true if the string values, or keys, of a plugin config may contain the marker
+func HasMarker(config interface{}, marker string) bool {
+	switch c := config.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.Contains(c, marker)
+	case []byte:
+		return strings.Contains(string(c), marker)
+	default:
+		buf, err := json.Marshal(config)
+		return err == nil && strings.Contains(string(buf), marker)
+	}
+}
+
+func mapStringValues(doc interface{}, marker string, fn func(string) (string, error)) (interface{}, error) {
+	switch d := doc.(type) {
+	case string:
+		if !strings.Contains(d, marker) {
+			return d, nil
+		}
+		return fn(d)
+	case map[string]interface{}:
+		for k, v := range d {
+			val, err := mapStringValues(v, marker, fn)
+			if err != nil {
+				return nil, err
+			}
+			d[k] = val
+		}
+	case []interface{}:
+		for i, v := range d {
+			val, err := mapStringValues(v, marker, fn)
+			if err != nil {
+				return nil, err
+			}
+			d[i] = val
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"github.com/xmidt-org/ears/pkg/config"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("EARS_TEST_HOST", "kafka.example.com")
+	t.Setenv("EARS_TEST_EMPTY", "")
+	testCases := []struct {
+		in       string
+		expected string
+	}{
+		{"no references", "no references"},
+		{"${EARS_TEST_HOST}:9092", "kafka.example.com:9092"},
+		{"${EARS_TEST_UNSET}", ""},
+		{"${EARS_TEST_UNSET:-localhost}:${EARS_TEST_PORT:-9092}", "localhost:9092"},
+		{"${EARS_TEST_EMPTY:-localhost}", "localhost"},
+		{"$${EARS_TEST_HOST}", "${EARS_TEST_HOST}"},
+		{"${secret://kafka.brokers}", "${secret://kafka.brokers}"},
+		{"$EARS_TEST_HOST", "$EARS_TEST_HOST"},
+	}
+	for _, tc := range testCases {
+		res := config.ExpandEnv(tc.in)
+		if res != tc.expected {
+			t.Errorf("expected %s for %s but got %s", tc.expected, tc.in, res)
+		}
+	}
+}
+
+func TestExpandEnvConfig(t *testing.T) {
+	t.Setenv("EARS_TEST_HOST", "kafka \"example\"")
+	cfg := `{"brokers":"${EARS_TEST_HOST}:9092","topics":["${EARS_TEST_TOPIC:-events}"],"password":"${secret://kafka.password}"}`
+	res, err := config.ExpandEnvConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err.Error())
+	}
+	expected := `{"brokers":"kafka \"example\":9092","password":"${secret://kafka.password}","topics":["events"]}`
+	if res != expected {
+		t.Fatalf("expected %s but got %v", expected, res)
+	}
+	res, err = config.ExpandEnvConfig(`{"brokers":"localhost:9092"}`)
+	if err != nil || res != `{"brokers":"localhost:9092"}` {
+		t.Fatalf("unexpected config %v %v", res, err)
+	}
+}
@@ -18,6 +18,7 @@
 package event
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -33,6 +34,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -44,6 +46,10 @@ import (
 type event struct {
 	metadata           map[string]interface{}
 	payload            interface{}
+	raw                []byte // undecoded json payload, decoded on first access of the payload
+	payloadErr         error  // error decoding the raw payload
+	payloadLock        sync.Mutex
+	contentType        string // content type of a binary payload, empty for json payloads
 	ctx                context.Context
 	ack                ack.SubTree
 	tid                tenant.Id
@@ -60,6 +66,25 @@ type EventOption func(*event) error
 
 var logger atomic.Value
 
+var debugPath atomic.Value
+
+type debugKey struct{}
+
+//SetDebugPath sets the path of a flag in the payload of events, traced events with a true flag are marked as debug
+//events in their context so that their traces are always sampled, an empty path disables the flag
+func SetDebugPath(path string) {
+	debugPath.Store(path)
+}
+
+//IsDebug returns true if ctx belongs to a debug event, see SetDebugPath
+func IsDebug(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
 func SetEventLogger(l *zerolog.Logger) {
 	logger.Store(l)
 }
@@ -88,15 +113,21 @@ func New(ctx context.Context, payload interface{}, options ...EventOption) (Even
 			return nil, err
 		}
 	}
+	if e.tracePayloadOnNack && e.raw != nil {
+		e.tracePayload = json.RawMessage(e.raw)
+	}
 	traceId := uuid.New().String()
 
 	// enable otel tracing
 	if e.spanName != "" {
+		if path, _ := debugPath.Load().(string); path != "" && e.debug(path) {
+			ctx = context.WithValue(ctx, debugKey{}, true)
+		}
 		tracer := otel.Tracer(rtsemconv.EARSTracerName)
 		var span trace.Span
-		ctx, span = tracer.Start(ctx, e.spanName)
+		// the tenant is passed on start so that samplers can sample by tenant
+		ctx, span = tracer.Start(ctx, e.spanName, trace.WithAttributes(rtsemconv.EARSOrgId.String(e.tid.OrgId), rtsemconv.EARSAppId.String(e.tid.AppId)))
 		span.SetAttributes(rtsemconv.EARSEventTrace)
-		span.SetAttributes(rtsemconv.EARSOrgId.String(e.tid.OrgId), rtsemconv.EARSAppId.String(e.tid.AppId))
 		traceId = span.SpanContext().TraceID().String()
 		span.SetAttributes(rtsemconv.EARSTraceId.String(traceId))
 		e.span = span
@@ -165,6 +196,75 @@ func WithTracePayloadOnNack(tracePayloadOnNack bool) EventOption {
 	}
 }
 
+// WithRawPayload sets the payload of the event to a json document that is only decoded once the payload is accessed,
+// so that routes passing events through unmodified never decode them. The payload parameter of New is ignored. The
+// document must be valid json (see ValidPayload) and must not be modified afterwards. A nil document leaves the
+// payload untouched.
+func WithRawPayload(raw []byte) EventOption {
+	return func(e *event) error {
+		if raw == nil {
+			return nil
+		}
+		e.raw = raw
+		e.payload = nil
+		return nil
+	}
+}
+
+// WithBinaryPayload sets the payload of the event to binary data of the given content type, which is passed through
+// to senders as is. Filters that require a json payload do not process such events. The payload parameter of New is
+// ignored.
+func WithBinaryPayload(data []byte, contentType string) EventOption {
+	return func(e *event) error {
+		if contentType == "" || contentType == ContentTypeJSON {
+			return &InvalidPayloadError{}
+		}
+		e.payload = data
+		e.raw = nil
+		e.contentType = contentType
+		return nil
+	}
+}
+
+// WithPayloadOf sets the payload of the event to the payload of another event without decoding a raw json payload
+// and keeping the content type of a binary payload
+func WithPayloadOf(other Event) EventOption {
+	return func(e *event) error {
+		if raw, ok := other.RawPayload(); ok {
+			return WithRawPayload(raw)(e)
+		}
+		if ct := other.ContentType(); ct != ContentTypeJSON {
+			data, _ := other.Payload().([]byte)
+			return WithBinaryPayload(data, ct)(e)
+		}
+		e.payload = other.Payload()
+		e.raw = nil
+		return nil
+	}
+}
+
+// ValidPayload returns an error if a raw payload is not a valid json document, which is much cheaper than decoding it
+func ValidPayload(raw []byte) error {
+	if !json.Valid(raw) {
+		return &InvalidPayloadError{}
+	}
+	return nil
+}
+
+// MarshalPayload returns the payload of an event encoded as json, an event with a raw payload that has not been
+// decoded or with a binary payload is passed through as is
+func MarshalPayload(e Event) ([]byte, error) {
+	if raw, ok := e.RawPayload(); ok {
+		return raw, nil
+	}
+	if e.ContentType() != ContentTypeJSON {
+		if data, ok := e.Payload().([]byte); ok {
+			return data, nil
+		}
+	}
+	return json.Marshal(e.Payload())
+}
+
 func WithId(eid string) EventOption {
 	return func(e *event) error {
 		e.eid = eid
@@ -212,15 +312,57 @@ func (e *event) Id() string {
 	return e.eid
 }
 
+// Payload decodes a raw payload on first access, the payload is nil if it cannot be decoded (see PayloadError)
 func (e *event) Payload() interface{} {
+	e.payloadLock.Lock()
+	defer e.payloadLock.Unlock()
+	if e.raw != nil {
+		// the decoded payload may be modified by the caller so the raw payload is no longer valid
+		var payload interface{}
+		err := json.Unmarshal(e.raw, &payload)
+		if err != nil {
+			// a partially decoded payload must not be mistaken for the event payload
+			payload = nil
+			e.payloadErr = &InvalidPayloadError{err}
+		}
+		e.payload = payload
+		e.raw = nil
+	}
 	return e.payload
 }
 
+func (e *event) PayloadError() error {
+	e.payloadLock.Lock()
+	defer e.payloadLock.Unlock()
+	return e.payloadErr
+}
+
+func (e *event) RawPayload() ([]byte, bool) {
+	e.payloadLock.Lock()
+	defer e.payloadLock.Unlock()
+	return e.raw, e.raw != nil
+}
+
+func (e *event) ContentType() string {
+	if e.contentType == "" {
+		return ContentTypeJSON
+	}
+	return e.contentType
+}
+
 func (e *event) SetPayload(payload interface{}) error {
 	if e.ack != nil && e.ack.IsAcked() {
 		return &ack.AlreadyAckedError{}
 	}
+	e.payloadLock.Lock()
+	defer e.payloadLock.Unlock()
 	e.payload = payload
+	e.raw = nil
+	e.payloadErr = nil
+	// a binary payload replaced by a decoded document becomes json
+	if _, ok := payload.([]byte); !ok {
+		e.contentType = ""
+	}
 	return nil
 }
 
@@ -385,6 +527,22 @@ func (e *event) Evaluate(expression interface{}) (interface{}, interface{}, stri
 	return expression, nil, ""
 }
 
+// debug returns true if the payload carries a true debug flag at path, raw payloads are only decoded if they contain
+// the name of the flag
+func (e *event) debug(path string) bool {
+	if e.contentType != "" {
+		return false
+	}
+	if raw, ok := e.RawPayload(); ok {
+		segments := e.splitPath(path)
+		if !bytes.Contains(raw, []byte(`"`+segments[len(segments)-1]+`"`)) {
+			return false
+		}
+	}
+	flag, _, _ := e.GetPathValue(path)
+	return flag == true
+}
+
 func (e *event) splitPath(path string) []string {
 	path = strings.Replace(path, `\.`, `\\`, -1)
 	segments := strings.Split(path, ".")
@@ -408,9 +566,13 @@ func (e *event) GetPathValue(path string) (interface{}, interface{}, string) {
 	if path == TIMESTAMP {
 		return strconv.Itoa(int(time.Now().UnixNano() / 1e6)), nil, ""
 	}
-	obj := e.Payload()
+	path = expandCloudEventPath(path)
+	var obj interface{}
 	if strings.HasPrefix(path, METADATA+".") || path == METADATA {
 		obj = e.Metadata()
+	} else {
+		// metadata paths never decode a raw payload
+		obj = e.Payload()
 	}
 	if obj == nil {
 		return nil, nil, ""
@@ -452,11 +614,23 @@ func (e *event) GetPathValue(path string) (interface{}, interface{}, string) {
 	return obj, parent, key
 }
 
+// expandCloudEventPath replaces the ce prefix of a path with the metadata path of the cloud event attributes
+func expandCloudEventPath(path string) string {
+	if path == CLOUDEVENT {
+		return CLOUDEVENT_METADATA
+	}
+	if strings.HasPrefix(path, CLOUDEVENT+".") || strings.HasPrefix(path, CLOUDEVENT+"[") {
+		return CLOUDEVENT_METADATA + path[len(CLOUDEVENT):]
+	}
+	return path
+}
+
 func (e *event) SetPathValue(path string, val interface{}, createPath bool) (interface{}, string, error) {
 	if e.ack != nil && e.ack.IsAcked() {
 		return nil, "", &ack.AlreadyAckedError{}
 	}
-	obj := e.Payload()
+	path = expandCloudEventPath(path)
+	var obj interface{}
 	if strings.HasPrefix(path, METADATA+".") || path == METADATA {
 		metaObj := e.Metadata()
 		if metaObj == nil {
@@ -479,6 +653,8 @@ func (e *event) SetPathValue(path string, val interface{}, createPath bool) (int
 	if path == PAYLOAD || path == PAYLOAD+"." {
 		err := e.SetPayload(val)
 		return nil, "", err
+	} else if e.contentType != "" && strings.HasPrefix(path, PAYLOAD+".") {
+		return nil, "", &BinaryPayloadError{ContentType: e.contentType}
 	} else if path == METADATA || path == METADATA+"." {
 		valMap, ok := val.(map[string]interface{})
 		if ok {
@@ -489,6 +665,10 @@ func (e *event) SetPathValue(path string, val interface{}, createPath bool) (int
 	if !strings.HasPrefix(path, PAYLOAD+".") && !strings.HasPrefix(path, METADATA+".") {
 		return nil, "", errors.New("bad path " + path)
 	}
+	if strings.HasPrefix(path, PAYLOAD+".") {
+		// the payload is only decoded once a path inside it is set
+		obj = e.Payload()
+	}
 	var parent interface{}
 	var key string
 	var ok bool
@@ -575,14 +755,20 @@ func (e *event) Clone(ctx context.Context) (Event, error) {
 			return nil, err
 		}
 	}
+	e.payloadLock.Lock()
+	payload, raw, payloadErr := e.payload, e.raw, e.payloadErr
+	e.payloadLock.Unlock()
 	return &event{
-		payload:  e.Payload(),
-		metadata: e.Metadata(),
-		ctx:      ctx,
-		ack:      subTree,
-		eid:      e.eid,
-		tid:      e.tid,
-		created:  e.created,
+		payload:     payload,
+		raw:         raw,
+		payloadErr:  payloadErr,
+		contentType: e.contentType,
+		metadata:    e.Metadata(),
+		ctx:         ctx,
+		ack:         subTree,
+		eid:         e.eid,
+		tid:         e.tid,
+		created:     e.created,
 	}, nil
 }
 
@@ -594,7 +780,12 @@ func (e *event) DeepCopy() error {
 	e.deepcopied = true
 	// gohobby deepcopy without reflection
 	// benchmark for 1.7 kb event: 6,000 ns / op
-	e.payload = deepcopy.DeepCopy(e.payload)
+	// a raw payload is never modified and decodes into a private copy
+	e.payloadLock.Lock()
+	if e.raw == nil {
+		e.payload = deepcopy.DeepCopy(e.payload)
+	}
+	e.payloadLock.Unlock()
 	e.metadata = deepcopy.DeepCopy(e.metadata).(map[string]interface{})
 	return nil
 }
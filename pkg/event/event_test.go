@@ -22,6 +22,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/event"
 	"io/ioutil"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -418,3 +419,200 @@ func TestEventTimeout(t *testing.T) {
 
 	<-done
 }
+
+func TestEventRawPayload(t *testing.T) {
+	ctx := context.Background()
+	raw := []byte(`{"field1":"abcd","field2":1234,"field3":{"field4":1.02}}`)
+
+	e1, err := event.New(ctx, nil, event.WithRawPayload(raw))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	buf, ok := e1.RawPayload()
+	if !ok || string(buf) != string(raw) {
+		t.Errorf("unexpected raw payload %s\n", string(buf))
+	}
+	buf, err = event.MarshalPayload(e1)
+	if err != nil || string(buf) != string(raw) {
+		t.Errorf("raw payload not passed through %s\n", string(buf))
+	}
+
+	//a clone shares the raw payload but decodes into its own copy
+	e2, err := e1.Clone(ctx)
+	if err != nil {
+		t.Fatalf("Fail to clone event %s\n", err.Error())
+	}
+	e2.DeepCopy()
+	_, _, err = e2.SetPathValue(".field1", "efgh", false)
+	if err != nil {
+		t.Fatalf("Fail to set path value %s\n", err.Error())
+	}
+	if _, ok := e2.RawPayload(); ok {
+		t.Error("raw payload still set after payload was modified")
+	}
+	buf, err = event.MarshalPayload(e2)
+	if err != nil {
+		t.Fatalf("Fail to marshal payload %s\n", err.Error())
+	}
+	if string(buf) != `{"field1":"efgh","field2":1234,"field3":{"field4":1.02}}` {
+		t.Errorf("unexpected payload %s\n", string(buf))
+	}
+
+	val, _, _ := e1.GetPathValue(".field3.field4")
+	if val != 1.02 {
+		t.Errorf("unexpected field4 value +%v\n", val)
+	}
+	val, _, _ = e1.GetPathValue(".field1")
+	if val != "abcd" {
+		t.Errorf("unexpected field1 value in original event +%v\n", val)
+	}
+
+	err = e1.SetPayload(map[string]interface{}{"field1": "xyz"})
+	if err != nil {
+		t.Fatalf("Fail to set payload %s\n", err.Error())
+	}
+	if _, ok := e1.RawPayload(); ok {
+		t.Error("raw payload still set after SetPayload")
+	}
+
+	//a nil raw payload leaves the payload untouched
+	e3, err := event.New(ctx, map[string]interface{}{"field1": "abcd"}, event.WithRawPayload(nil))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	if _, ok := e3.RawPayload(); ok {
+		t.Error("unexpected raw payload")
+	}
+	if !reflect.DeepEqual(e3.Payload(), map[string]interface{}{"field1": "abcd"}) {
+		t.Errorf("unexpected payload +%v\n", e3.Payload())
+	}
+}
+
+func TestEventRawPayloadDecoding(t *testing.T) {
+	ctx := context.Background()
+
+	//metadata paths leave a raw payload undecoded
+	e1, err := event.New(ctx, nil, event.WithRawPayload([]byte(`{"field1":"abcd"}`)), event.WithMetadata(map[string]interface{}{"key": "val"}))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	val, _, _ := e1.GetPathValue("metadata.key")
+	if val != "val" {
+		t.Errorf("unexpected metadata value +%v\n", val)
+	}
+	_, _, err = e1.SetPathValue("metadata.other", "val", true)
+	if err != nil {
+		t.Fatalf("Fail to set path value %s\n", err.Error())
+	}
+	if _, ok := e1.RawPayload(); !ok {
+		t.Error("raw payload decoded for metadata paths")
+	}
+
+	//concurrent accesses decode the raw payload once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, _, _ := e1.GetPathValue(".field1")
+			if val != "abcd" {
+				t.Errorf("unexpected field1 value +%v\n", val)
+			}
+		}()
+	}
+	wg.Wait()
+
+	//a raw payload that cannot be decoded reports the error
+	e2, err := event.New(ctx, nil, event.WithRawPayload([]byte(`{"field1":1e400}`)))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	if e2.PayloadError() != nil {
+		t.Error("payload error before payload was decoded")
+	}
+	if e2.Payload() != nil {
+		t.Errorf("unexpected payload +%v\n", e2.Payload())
+	}
+	var invalidErr *event.InvalidPayloadError
+	if !errors.As(e2.PayloadError(), &invalidErr) || invalidErr.Err == nil {
+		t.Errorf("expected InvalidPayloadError but got +%v\n", e2.PayloadError())
+	}
+	err = e2.SetPayload(map[string]interface{}{"field1": 1})
+	if err != nil || e2.PayloadError() != nil {
+		t.Error("payload error not cleared by SetPayload")
+	}
+}
+
+func TestValidPayload(t *testing.T) {
+	if err := event.ValidPayload([]byte(`{"a":[1,2,3]}`)); err != nil {
+		t.Errorf("unexpected error %s\n", err.Error())
+	}
+	var invalidErr *event.InvalidPayloadError
+	if err := event.ValidPayload([]byte(`{"a":`)); !errors.As(err, &invalidErr) {
+		t.Errorf("expected InvalidPayloadError but got +%v\n", err)
+	}
+}
+
+func TestEventBinaryPayload(t *testing.T) {
+	ctx := context.Background()
+	data := []byte{0x00, 0x01, 0xfe, 0xff}
+
+	_, err := event.New(ctx, nil, event.WithBinaryPayload(data, event.ContentTypeJSON))
+	var invalidErr *event.InvalidPayloadError
+	if !errors.As(err, &invalidErr) {
+		t.Errorf("expected InvalidPayloadError but got +%v\n", err)
+	}
+
+	e1, err := event.New(ctx, nil, event.WithBinaryPayload(data, "application/octet-stream"),
+		event.WithMetadataKeyValue("key", "abcd"))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	if e1.ContentType() != "application/octet-stream" {
+		t.Errorf("unexpected content type %s\n", e1.ContentType())
+	}
+	buf, err := event.MarshalPayload(e1)
+	if err != nil || !reflect.DeepEqual(buf, data) {
+		t.Errorf("binary payload not passed through +%v\n", buf)
+	}
+
+	//metadata remains accessible while the payload cannot be navigated
+	val, _, _ := e1.GetPathValue("metadata.key")
+	if val != "abcd" {
+		t.Errorf("unexpected metadata value +%v\n", val)
+	}
+	val, _, _ = e1.GetPathValue(".field1")
+	if val != nil {
+		t.Errorf("unexpected payload value +%v\n", val)
+	}
+	_, _, err = e1.SetPathValue(".field1.field2", "efgh", true)
+	var binaryErr *event.BinaryPayloadError
+	if !errors.As(err, &binaryErr) {
+		t.Errorf("expected BinaryPayloadError but got +%v\n", err)
+	}
+
+	//clones and events created from the payload of another event keep the content type
+	e2, err := e1.Clone(ctx)
+	if err != nil {
+		t.Fatalf("Fail to clone event %s\n", err.Error())
+	}
+	e3, err := event.New(ctx, nil, event.WithPayloadOf(e2))
+	if err != nil {
+		t.Fatalf("Fail to create new event %s\n", err.Error())
+	}
+	if e3.ContentType() != "application/octet-stream" || !reflect.DeepEqual(e3.Payload(), data) {
+		t.Errorf("unexpected payload %s +%v\n", e3.ContentType(), e3.Payload())
+	}
+
+	//replacing the payload with a decoded document turns the event into a json event
+	err = e2.SetPayload(map[string]interface{}{"field1": "abcd"})
+	if err != nil {
+		t.Fatalf("Fail to set payload %s\n", err.Error())
+	}
+	if e2.ContentType() != event.ContentTypeJSON {
+		t.Errorf("unexpected content type %s\n", e2.ContentType())
+	}
+	if e1.ContentType() != "application/octet-stream" {
+		t.Errorf("unexpected content type of original event %s\n", e1.ContentType())
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+// keys of the lineage stamped into the metadata of an event
+const (
+	// LineageReceiver is the name of the receiver the event entered ears through
+	LineageReceiver = "receiver"
+	// LineageReceiverPlugin is the plugin type of the receiver the event entered ears through
+	LineageReceiverPlugin = "receiverPlugin"
+	// LineageRouteId is the id of the route the event is flowing through
+	LineageRouteId = "routeId"
+	// LineageHops is the number of routes the event has flowed through
+	LineageHops = "hops"
+	// LineageParentId is the id of the event a split event was created from
+	LineageParentId = "parentId"
+	// LineageSplitIndex is the position of a split event among its siblings
+	LineageSplitIndex = "splitIndex"
+)
+
+// Lineage returns the lineage stamped into the metadata of an event or nil if there is none
+func Lineage(e Event) map[string]interface{} {
+	if e == nil {
+		return nil
+	}
+	lineage, _ := e.Metadata()[LINEAGE].(map[string]interface{})
+	return lineage
+}
+
+// LineageHopCount returns the number of routes an event has flowed through according to its lineage
+func LineageHopCount(e Event) int {
+	switch hops := Lineage(e)[LineageHops].(type) {
+	case int:
+		return hops
+	case float64:
+		// lineage that went through a json round trip
+		return int(hops)
+	}
+	return 0
+}
+
+// SetLineage merges the given values into the lineage of an event. The metadata of an event may be shared with
+// clones of the event handed to other routes, so the metadata is copied rather than modified in place.
+func SetLineage(e Event, values map[string]interface{}) error {
+	if e == nil || len(values) == 0 {
+		return nil
+	}
+	metadata := make(map[string]interface{}, len(e.Metadata())+1)
+	for k, v := range e.Metadata() {
+		metadata[k] = v
+	}
+	lineage := make(map[string]interface{}, len(values)+4)
+	for k, v := range Lineage(e) {
+		lineage[k] = v
+	}
+	for k, v := range values {
+		lineage[k] = v
+	}
+	metadata[LINEAGE] = lineage
+	return e.SetMetadata(metadata)
+}
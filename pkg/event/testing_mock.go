@@ -26,6 +26,9 @@ var _ Event = &EventMock{}
 // 			CloneFunc: func(ctx context.Context) (Event, error) {
 // 				panic("mock out the Clone method")
 // 			},
+// 			ContentTypeFunc: func() string {
+// 				panic("mock out the ContentType method")
+// 			},
 // 			ContextFunc: func() context.Context {
 // 				panic("mock out the Context method")
 // 			},
@@ -53,6 +56,12 @@ var _ Event = &EventMock{}
 // 			PayloadFunc: func() interface{} {
 // 				panic("mock out the Payload method")
 // 			},
+// 			PayloadErrorFunc: func() error {
+// 				panic("mock out the PayloadError method")
+// 			},
+// 			RawPayloadFunc: func() ([]byte, bool) {
+// 				panic("mock out the RawPayload method")
+// 			},
 // 			SetContextFunc: func(ctx context.Context) error {
 // 				panic("mock out the SetContext method")
 // 			},
@@ -81,6 +90,9 @@ type EventMock struct {
 	// CloneFunc mocks the Clone method.
 	CloneFunc func(ctx context.Context) (Event, error)
 
+	// ContentTypeFunc mocks the ContentType method.
+	ContentTypeFunc func() string
+
 	// ContextFunc mocks the Context method.
 	ContextFunc func() context.Context
 
@@ -108,6 +120,12 @@ type EventMock struct {
 	// PayloadFunc mocks the Payload method.
 	PayloadFunc func() interface{}
 
+	// PayloadErrorFunc mocks the PayloadError method.
+	PayloadErrorFunc func() error
+
+	// RawPayloadFunc mocks the RawPayload method.
+	RawPayloadFunc func() ([]byte, bool)
+
 	// SetContextFunc mocks the SetContext method.
 	SetContextFunc func(ctx context.Context) error
 
@@ -133,6 +151,9 @@ type EventMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// ContentType holds details about calls to the ContentType method.
+		ContentType []struct {
+		}
 		// Context holds details about calls to the Context method.
 		Context []struct {
 		}
@@ -166,6 +187,12 @@ type EventMock struct {
 		// Payload holds details about calls to the Payload method.
 		Payload []struct {
 		}
+		// PayloadError holds details about calls to the PayloadError method.
+		PayloadError []struct {
+		}
+		// RawPayload holds details about calls to the RawPayload method.
+		RawPayload []struct {
+		}
 		// SetContext holds details about calls to the SetContext method.
 		SetContext []struct {
 			// Ctx is the ctx argument value.
@@ -196,6 +223,7 @@ type EventMock struct {
 	}
 	lockAck          sync.RWMutex
 	lockClone        sync.RWMutex
+	lockContentType  sync.RWMutex
 	lockContext      sync.RWMutex
 	lockCreated      sync.RWMutex
 	lockDeepCopy     sync.RWMutex
@@ -205,6 +233,8 @@ type EventMock struct {
 	lockMetadata     sync.RWMutex
 	lockNack         sync.RWMutex
 	lockPayload      sync.RWMutex
+	lockPayloadError sync.RWMutex
+	lockRawPayload   sync.RWMutex
 	lockSetContext   sync.RWMutex
 	lockSetMetadata  sync.RWMutex
 	lockSetPathValue sync.RWMutex
@@ -269,6 +299,32 @@ func (mock *EventMock) CloneCalls() []struct {
 	return calls
 }
 
+// ContentType calls ContentTypeFunc.
+func (mock *EventMock) ContentType() string {
+	if mock.ContentTypeFunc == nil {
+		panic("EventMock.ContentTypeFunc: method is nil but Event.ContentType was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockContentType.Lock()
+	mock.calls.ContentType = append(mock.calls.ContentType, callInfo)
+	mock.lockContentType.Unlock()
+	return mock.ContentTypeFunc()
+}
+
+// ContentTypeCalls gets all the calls that were made to ContentType.
+// Check the length with:
+//     len(mockedEvent.ContentTypeCalls())
+func (mock *EventMock) ContentTypeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockContentType.RLock()
+	calls = mock.calls.ContentType
+	mock.lockContentType.RUnlock()
+	return calls
+}
+
 // Context calls ContextFunc.
 func (mock *EventMock) Context() context.Context {
 	if mock.ContextFunc == nil {
@@ -518,6 +574,58 @@ func (mock *EventMock) PayloadCalls() []struct {
 	return calls
 }
 
+// PayloadError calls PayloadErrorFunc.
+func (mock *EventMock) PayloadError() error {
+	if mock.PayloadErrorFunc == nil {
+		panic("EventMock.PayloadErrorFunc: method is nil but Event.PayloadError was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockPayloadError.Lock()
+	mock.calls.PayloadError = append(mock.calls.PayloadError, callInfo)
+	mock.lockPayloadError.Unlock()
+	return mock.PayloadErrorFunc()
+}
+
+// PayloadErrorCalls gets all the calls that were made to PayloadError.
+// Check the length with:
+//     len(mockedEvent.PayloadErrorCalls())
+func (mock *EventMock) PayloadErrorCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockPayloadError.RLock()
+	calls = mock.calls.PayloadError
+	mock.lockPayloadError.RUnlock()
+	return calls
+}
+
+// RawPayload calls RawPayloadFunc.
+func (mock *EventMock) RawPayload() ([]byte, bool) {
+	if mock.RawPayloadFunc == nil {
+		panic("EventMock.RawPayloadFunc: method is nil but Event.RawPayload was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockRawPayload.Lock()
+	mock.calls.RawPayload = append(mock.calls.RawPayload, callInfo)
+	mock.lockRawPayload.Unlock()
+	return mock.RawPayloadFunc()
+}
+
+// RawPayloadCalls gets all the calls that were made to RawPayload.
+// Check the length with:
+//     len(mockedEvent.RawPayloadCalls())
+func (mock *EventMock) RawPayloadCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockRawPayload.RLock()
+	calls = mock.calls.RawPayload
+	mock.lockRawPayload.RUnlock()
+	return calls
+}
+
 // SetContext calls SetContextFunc.
 func (mock *EventMock) SetContext(ctx context.Context) error {
 	if mock.SetContextFunc == nil {
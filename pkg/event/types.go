@@ -35,12 +35,40 @@ const (
 	TRACE     = "trace"
 	TENANT    = "tenant"
 	TIMESTAMP = "timestamp"
+
+	// ContentTypeJSON is the content type of all payloads other than binary payloads
+	ContentTypeJSON = "application/json"
+
+	// CLOUDEVENT addresses the cloud event attributes of an event, ce.type is short for metadata.cloudEvent.type
+	CLOUDEVENT = "ce"
+	// CLOUDEVENT_METADATA is the metadata path of the cloud event attributes
+	CLOUDEVENT_METADATA = METADATA + ".cloudEvent"
+
+	// LINEAGE is the metadata key under which the lineage of an event is stamped
+	LINEAGE = "lineage"
+	// LINEAGE_METADATA is the metadata path of the lineage of an event
+	LINEAGE_METADATA = METADATA + "." + LINEAGE
+
+	// HEARTBEAT is the metadata key flagging synthetic heartbeat events routes emit to prove their liveness
+	HEARTBEAT = "heartbeat"
+	// HEARTBEAT_METADATA is the metadata path of the heartbeat flag, e.g. for match filters dropping heartbeats
+	HEARTBEAT_METADATA = METADATA + "." + HEARTBEAT
 )
 
 type Event interface {
 	//Get the event payload
 	Payload() interface{}
 
+	//Get the error decoding a raw json payload on first access of the payload, the payload is nil in that case
+	PayloadError() error
+
+	//Get the undecoded json payload of an event created with a raw payload, returns false once the payload has been
+	//accessed or replaced since the raw payload may then be outdated
+	RawPayload() ([]byte, bool)
+
+	//Get the content type of the payload, ContentTypeJSON unless the event carries a binary payload as []byte
+	ContentType() string
+
 	//Get event id
 	Id() string
 
@@ -99,6 +127,27 @@ type Event interface {
 type NoAckHandlersError struct {
 }
 
+type InvalidPayloadError struct {
+	Err error
+}
+
+func (e *InvalidPayloadError) Error() string {
+	return errs.String("InvalidPayloadError", nil, e.Err)
+}
+
+func (e *InvalidPayloadError) Unwrap() error {
+	return e.Err
+}
+
+// BinaryPayloadError is returned when a path inside the payload of an event with a binary payload is set
+type BinaryPayloadError struct {
+	ContentType string
+}
+
+func (e *BinaryPayloadError) Error() string {
+	return errs.String("BinaryPayloadError", map[string]interface{}{"contentType": e.ContentType}, nil)
+}
+
 func (e *NoAckHandlersError) Error() string {
 	return errs.String("NoAckHandlersError", nil, nil)
 }
@@ -0,0 +1,127 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	return f, nil
+}
+
+// Filter adds values from the event to the active trace span, the event itself is not modified
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	span := trace.SpanFromContext(evt.Context())
+	if !span.IsRecording() {
+		return []event.Event{evt}
+	}
+	attrs := f.attributes(evt)
+	if len(attrs) > 0 {
+		if f.config.EventName != "" {
+			span.AddEvent(f.config.EventName, trace.WithAttributes(attrs...))
+		} else {
+			span.SetAttributes(attrs...)
+		}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "annotate").Str("name", f.Name()).Int("attributeCount", len(attrs)).Msg("annotate")
+	return []event.Event{evt}
+}
+
+// attributes returns the span attributes for all configured paths present in the event
+func (f *Filter) attributes(evt event.Event) []attribute.KeyValue {
+	names := make([]string, 0, len(f.config.Attributes))
+	for name := range f.config.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]attribute.KeyValue, 0, len(names))
+	for _, name := range names {
+		v, _, _ := evt.GetPathValue(f.config.Attributes[name])
+		switch vt := v.(type) {
+		case nil:
+			continue
+		case string:
+			attrs = append(attrs, attribute.String(name, vt))
+		case bool:
+			attrs = append(attrs, attribute.Bool(name, vt))
+		case int:
+			attrs = append(attrs, attribute.Int(name, vt))
+		case int64:
+			attrs = append(attrs, attribute.Int64(name, vt))
+		case float64:
+			attrs = append(attrs, attribute.Float64(name, vt))
+		default:
+			buf, err := json.Marshal(vt)
+			if err != nil {
+				continue
+			}
+			attrs = append(attrs, attribute.String(name, string(buf)))
+		}
+	}
+	return attrs
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/annotate"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFilterAnnotate(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	testCases := []struct {
+		name      string
+		eventName string
+	}{
+		{name: "attributes"},
+		{name: "event", eventName: "device"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := annotate.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "annotate", "myannotate", annotate.Config{
+				Attributes: map[string]string{
+					"device.id":    ".device.id",
+					"device.count": ".device.count",
+					"account.id":   "metadata.accountId",
+					"missing":      ".missing",
+				},
+				EventName: tc.eventName,
+			}, nil)
+			if err != nil {
+				t.Fatalf("annotate test failed: %s\n", err.Error())
+			}
+			ctx, span := tp.Tracer("test").Start(context.Background(), tc.name)
+			payload := map[string]interface{}{"device": map[string]interface{}{"id": "abc", "count": float64(3)}}
+			e, err := event.New(ctx, payload, event.WithMetadataKeyValue("accountId", "123"), event.FailOnNack(t))
+			if err != nil {
+				t.Fatalf("annotate test failed: %s\n", err.Error())
+			}
+			evts := f.Filter(e)
+			if len(evts) != 1 {
+				t.Fatalf("wrong number of annotated events: %d\n", len(evts))
+			}
+			span.End()
+			ended := sr.Ended()
+			recorded := ended[len(ended)-1]
+			attrs := recorded.Attributes()
+			if tc.eventName != "" {
+				if len(recorded.Events()) != 1 || recorded.Events()[0].Name != tc.eventName {
+					t.Fatalf("expected span event %s: %v\n", tc.eventName, recorded.Events())
+				}
+				attrs = recorded.Events()[0].Attributes
+			}
+			expected := map[attribute.Key]attribute.Value{
+				"device.id":    attribute.StringValue("abc"),
+				"device.count": attribute.Float64Value(3),
+				"account.id":   attribute.StringValue("123"),
+			}
+			if len(attrs) != len(expected) {
+				t.Fatalf("wrong number of attributes: %v\n", attrs)
+			}
+			for _, attr := range attrs {
+				if expected[attr.Key] != attr.Value {
+					t.Fatalf("unexpected attribute %s=%v\n", attr.Key, attr.Value.AsInterface())
+				}
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/xorcare/pointer"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFilterBatchBasic(t *testing.T) {
@@ -62,3 +63,113 @@ func TestFilterBatchBasic(t *testing.T) {
 		t.Fatalf("wrong payload in batched event: %s\n", pl)
 	}
 }
+
+func TestFilterBatchWindow(t *testing.T) {
+	ctx := context.Background()
+	f, err := batch.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "batch", "mybatch", batch.Config{
+		BatchSize: pointer.Int(10),
+		Window:    pointer.Int(50),
+	}, nil)
+	if err != nil {
+		t.Fatalf("batch test failed: %s\n", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		e, err := event.New(ctx, map[string]interface{}{"idx": float64(i)}, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("batch test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != 0 {
+			t.Fatalf("wrong number of batched events: %d\n", len(evts))
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+	e, err := event.New(ctx, map[string]interface{}{"idx": float64(2)}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("batch test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of batched events: %d\n", len(evts))
+	}
+	res := []interface{}{
+		map[string]interface{}{"idx": float64(0)},
+		map[string]interface{}{"idx": float64(1)},
+		map[string]interface{}{"idx": float64(2)},
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), res) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in batched event: %s\n", pl)
+	}
+}
+
+func TestFilterBatchWindowFlush(t *testing.T) {
+	ctx := context.Background()
+	f, err := batch.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "batch", "mybatch", batch.Config{
+		BatchSize: pointer.Int(10),
+		Window:    pointer.Int(50),
+	}, nil)
+	if err != nil {
+		t.Fatalf("batch test failed: %s\n", err.Error())
+	}
+	flushed := make(chan []event.Event, 1)
+	for i := 0; i < 2; i++ {
+		e, err := event.New(ctx, map[string]interface{}{"idx": float64(i)}, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("batch test failed: %s\n", err.Error())
+		}
+		evts := f.FilterWithFlush(e, func(events []event.Event) {
+			flushed <- events
+		})
+		if len(evts) != 0 {
+			t.Fatalf("wrong number of batched events: %d\n", len(evts))
+		}
+	}
+	// the batch is flushed once the window expires without waiting for another event
+	select {
+	case evts := <-flushed:
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of flushed events: %d\n", len(evts))
+		}
+		res := []interface{}{
+			map[string]interface{}{"idx": float64(0)},
+			map[string]interface{}{"idx": float64(1)},
+		}
+		if !reflect.DeepEqual(evts[0].Payload(), res) {
+			pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+			t.Fatalf("wrong payload in flushed event: %s\n", pl)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("batch not flushed after its window expired\n")
+	}
+}
+
+func TestFilterBatchStop(t *testing.T) {
+	ctx := context.Background()
+	f, err := batch.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "batch", "mybatch", batch.Config{
+		BatchSize: pointer.Int(10),
+		Window:    pointer.Int(20),
+	}, nil)
+	if err != nil {
+		t.Fatalf("batch test failed: %s\n", err.Error())
+	}
+	nacked := make(chan error, 1)
+	e, err := event.New(ctx, map[string]interface{}{"idx": float64(0)}, event.WithAck(func(e event.Event) {
+		t.Errorf("unexpected ack\n")
+	}, func(e event.Event, err error) {
+		nacked <- err
+	}))
+	if err != nil {
+		t.Fatalf("batch test failed: %s\n", err.Error())
+	}
+	f.FilterWithFlush(e, func(events []event.Event) {
+		t.Errorf("batch flushed after the filter was stopped\n")
+	})
+	f.StopFiltering(ctx)
+	select {
+	case <-nacked:
+	case <-time.After(time.Second):
+		t.Fatalf("pending event not nacked\n")
+	}
+	time.Sleep(40 * time.Millisecond)
+}
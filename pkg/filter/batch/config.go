@@ -38,6 +38,9 @@ func (c Config) WithDefaults() *Config {
 	if c.BatchSize == nil {
 		cfg.BatchSize = DefaultConfig.BatchSize
 	}
+	if c.Window == nil {
+		cfg.Window = DefaultConfig.Window
+	}
 	return &cfg
 }
 
@@ -45,6 +48,9 @@ func (c *Config) Validate() error {
 	if *c.BatchSize < 0 || *c.BatchSize > 100 {
 		return errors.New("cache size must be between 0 and 100")
 	}
+	if *c.Window < 0 {
+		return errors.New("window must not be negative")
+	}
 	return nil
 }
 
@@ -15,6 +15,7 @@
 package batch
 
 import (
+	"context"
 	"fmt"
 	//"github.com/gohobby/deepcopy"
 	"github.com/boriwo/deepcopy"
@@ -24,8 +25,12 @@ import (
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+var _ filter.Flusher = (*Filter)(nil)
+var _ filter.Stopper = (*Filter)(nil)
+
 func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
 	cfg, err := NewConfig(config)
 	if err != nil {
@@ -49,6 +54,13 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 }
 
 func (f *Filter) Filter(evt event.Event) []event.Event {
+	return f.FilterWithFlush(evt, nil)
+}
+
+// FilterWithFlush adds an event to the batch and returns the batch once it is full. If the time window of the batch
+// expires first, the batch is handed to the flush function that came with the most recent event. Without a flush
+// function an expired batch is returned along with the next event.
+func (f *Filter) FilterWithFlush(evt event.Event, flush filter.FlushFn) []event.Event {
 	if f == nil {
 		evt.Nack(&filter.InvalidConfigError{
 			Err: fmt.Errorf("<nil> pointer filter"),
@@ -57,60 +69,99 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	}
 	f.Lock()
 	defer f.Unlock()
-	f.batch = append(f.batch, evt)
-	if len(f.batch) >= *f.config.BatchSize {
-		newEvt, err := evt.Clone(evt.Context())
-		if err != nil {
-			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Msg(err.Error())
-			if span := trace.SpanFromContext(evt.Context()); span != nil {
-				span.AddEvent(err.Error())
-			}
-			newEvt.Ack()
-			for _, e := range f.batch {
-				e.Ack()
-			}
-			f.batch = make([]event.Event, 0)
-			return []event.Event{}
+	window := time.Duration(*f.config.Window) * time.Millisecond
+	if len(f.batch) == 0 {
+		f.batchStart = time.Now()
+		if window > 0 {
+			generation := f.generation
+			f.timer = time.AfterFunc(window, func() {
+				f.expire(generation)
+			})
 		}
+	}
+	f.batch = append(f.batch, evt)
+	f.flush = flush
+	// the event completing the window is part of the batch
+	windowElapsed := window > 0 && time.Since(f.batchStart) >= window
+	if len(f.batch) >= *f.config.BatchSize || windowElapsed {
+		return f.emit()
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Int("eventCount", 0).Msg("batch")
+	return []event.Event{}
+}
+
+// expire flushes the batch once its time window expired unless the batch has been emitted already
+func (f *Filter) expire(generation int) {
+	f.Lock()
+	if generation != f.generation || len(f.batch) == 0 || f.flush == nil {
+		f.Unlock()
+		return
+	}
+	flush := f.flush
+	events := f.emit()
+	f.Unlock()
+	if len(events) > 0 {
+		flush(events)
+	}
+}
+
+// emit turns the batch into a single event carrying the payloads of all batched events and the metadata of the
+// most recent one, and starts a new batch. The filter must be locked.
+func (f *Filter) emit() []event.Event {
+	batch := f.batch
+	f.batch = make([]event.Event, 0)
+	f.flush = nil
+	f.generation++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	evt := batch[len(batch)-1]
+	newEvt, err := evt.Clone(evt.Context())
+	if err == nil {
 		batchPayload := make([]interface{}, 0)
-		for _, e := range f.batch {
+		for _, e := range batch {
 			batchPayload = append(batchPayload, e.Payload())
 		}
 		err = newEvt.SetMetadata(deepcopy.DeepCopy(evt.Metadata()).(map[string]interface{}))
-		if err != nil {
-			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Msg(err.Error())
-			if span := trace.SpanFromContext(evt.Context()); span != nil {
-				span.AddEvent(err.Error())
-			}
-			newEvt.Ack()
-			for _, e := range f.batch {
-				e.Ack()
-			}
-			f.batch = make([]event.Event, 0)
-			return []event.Event{}
+		if err == nil {
+			err = newEvt.SetPayload(batchPayload)
+		}
+	}
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
 		}
-		err = newEvt.SetPayload(batchPayload)
-		if err != nil {
-			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Msg(err.Error())
-			if span := trace.SpanFromContext(evt.Context()); span != nil {
-				span.AddEvent(err.Error())
-			}
+		if newEvt != nil {
 			newEvt.Ack()
-			for _, e := range f.batch {
-				e.Ack()
-			}
-			f.batch = make([]event.Event, 0)
-			return []event.Event{}
 		}
-		for _, e := range f.batch {
+		for _, e := range batch {
 			e.Ack()
 		}
-		log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "match").Str("name", f.Name()).Int("eventCount", len(f.batch)).Msg("match")
-		f.batch = make([]event.Event, 0)
-		return []event.Event{newEvt}
+		return []event.Event{}
 	}
-	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Int("eventCount", 0).Msg("batch")
-	return []event.Event{}
+	for _, e := range batch {
+		e.Ack()
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "batch").Str("name", f.Name()).Int("eventCount", len(batch)).Msg("batch")
+	return []event.Event{newEvt}
+}
+
+// StopFiltering stops the window timer and nacks the events of an unfinished batch, which no route can take anymore
+func (f *Filter) StopFiltering(ctx context.Context) {
+	f.Lock()
+	defer f.Unlock()
+	f.generation++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	for _, e := range f.batch {
+		e.Nack(fmt.Errorf("batch filter %s stopped", f.name))
+	}
+	f.batch = make([]event.Event, 0)
+	f.flush = nil
 }
 
 func (f *Filter) Config() interface{} {
@@ -16,26 +16,34 @@ package batch
 
 import (
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"github.com/xorcare/pointer"
 	"sync"
+	"time"
 )
 
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
 type Config struct {
 	BatchSize *int `json:"batchSize,omitempty"`
+	Window    *int `json:"window,omitempty"` // max age of a batch in milliseconds, 0 means no time window
 }
 
 var DefaultConfig = Config{
 	BatchSize: pointer.Int(1),
+	Window:    pointer.Int(0),
 }
 
 type Filter struct {
 	sync.Mutex
-	config Config
-	batch  []event.Event
-	name   string
-	plugin string
-	tid    tenant.Id
+	config     Config
+	batch      []event.Event
+	batchStart time.Time
+	flush      filter.FlushFn // flush function of the most recent event, see FilterWithFlush
+	timer      *time.Timer    // flushes the batch once its time window expires
+	generation int            // incremented whenever a batch is emitted so that stale timers do nothing
+	name       string
+	plugin     string
+	tid        tenant.Id
 }
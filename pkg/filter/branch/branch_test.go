@@ -0,0 +1,73 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/branch"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterBranch(t *testing.T) {
+	ctx := context.Background()
+	cfg := `
+cases:
+- name: alerts
+  match:
+    matcher: pattern
+    pattern:
+      type: alert
+- name: metrics
+  match:
+    matcher: regex
+    path: .type
+    pattern: ^metric.*$
+default: other
+`
+	f, err := branch.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "branch", "mybranch", cfg, nil)
+	if err != nil {
+		t.Fatalf("branch test failed: %s\n", err.Error())
+	}
+	testCases := []struct {
+		payload  map[string]interface{}
+		expected string
+	}{
+		{payload: map[string]interface{}{"type": "alert"}, expected: "alerts"},
+		{payload: map[string]interface{}{"type": "metric.cpu"}, expected: "metrics"},
+		{payload: map[string]interface{}{"type": "log"}, expected: "other"},
+	}
+	for _, tc := range testCases {
+		e, err := event.New(ctx, tc.payload, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("branch test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of branched events: %d\n", len(evts))
+		}
+		if evts[0].Metadata()["branch"] != tc.expected {
+			t.Fatalf("wrong branch for %v: %v\n", tc.payload, evts[0].Metadata()["branch"])
+		}
+	}
+	_, err = branch.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "branch", "mybranch", branch.Config{
+		Cases: []branch.Case{{Name: "nomatch"}},
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for case without match config\n")
+	}
+}
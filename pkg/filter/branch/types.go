@@ -0,0 +1,48 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch
+
+import (
+	"github.com/xmidt-org/ears/pkg/filter/match"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// Case is a named branch taken by events passing the match config
+type Case struct {
+	Name  string        `json:"name,omitempty"`
+	Match *match.Config `json:"match,omitempty"`
+}
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	Cases   []Case `json:"cases,omitempty"`   // conditions evaluated in order, the first match wins
+	Default string `json:"default,omitempty"` // optional branch name for events not matching any case
+	ToPath  string `json:"toPath,omitempty"`  // path the branch name is written to
+}
+
+var DefaultConfig = Config{
+	Cases:   []Case{},
+	Default: "",
+	ToPath:  "metadata.branch",
+}
+
+type Filter struct {
+	config   Config
+	name     string
+	plugin   string
+	tid      tenant.Id
+	matchers []*match.Filter
+}
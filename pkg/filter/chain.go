@@ -16,6 +16,7 @@ package filter
 
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -48,21 +49,33 @@ func (c *Chain) Filterers() []Filterer {
 }
 
 func (c *Chain) Filter(e event.Event) []event.Event {
+	return c.FilterWithFlush(e, nil)
+}
+
+// FilterWithFlush passes an event through the chain, events that filterers of the chain emit later on their own
+// pass through the rest of the chain before they are handed to flush
+func (c *Chain) FilterWithFlush(e event.Event, flush FlushFn) []event.Event {
 	c.Lock()
 	defer c.Unlock()
 	// pass event through in case of empty filter chain
 	if len(c.filterers) == 0 {
 		return []event.Event{e}
 	}
+	return c.filterFrom(0, []event.Event{e}, e.Context(), flush)
+}
+
+// filterFrom passes events through the filterers of the chain starting at index start, the chain must be locked
+func (c *Chain) filterFrom(start int, evts []event.Event, ctx context.Context, flush FlushFn) []event.Event {
 	type work struct {
 		e event.Event
 		f Filterer
 		i int // Index of current filterer
 	}
 	queue := list.New()
-	queue.PushBack(work{e: e, f: c.filterers[0], i: 0})
+	for _, e := range evts {
+		queue.PushBack(work{e: e, f: c.filterers[start], i: start})
+	}
 	events := []event.Event{}
-	ctx := e.Context()
 
 	for elem := queue.Front(); elem != nil; elem = elem.Next() {
 		select {
@@ -71,7 +84,12 @@ func (c *Chain) Filter(e event.Event) []event.Event {
 		default:
 			w := elem.Value.(work)
 
-			evts := w.f.Filter(w.e)
+			var evts []event.Event
+			if fl, ok := w.f.(Flusher); ok && flush != nil {
+				evts = fl.FilterWithFlush(w.e, c.flushFrom(w.i+1, flush))
+			} else {
+				evts = w.f.Filter(w.e)
+			}
 
 			next := w.i + 1
 			if next < len(c.filterers) {
@@ -86,6 +104,24 @@ func (c *Chain) Filter(e event.Event) []event.Event {
 	return events
 }
 
+// flushFrom returns the flush function of the filterer before index next, which passes the events the filterer
+// emits through the rest of the chain
+func (c *Chain) flushFrom(next int, flush FlushFn) FlushFn {
+	return func(events []event.Event) {
+		if len(events) == 0 {
+			return
+		}
+		c.Lock()
+		if next < len(c.filterers) {
+			events = c.filterFrom(next, events, events[0].Context(), flush)
+		}
+		c.Unlock()
+		if len(events) > 0 {
+			flush(events)
+		}
+	}
+}
+
 func (c *Chain) Config() interface{} {
 	return nil
 }
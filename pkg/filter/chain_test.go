@@ -190,3 +190,41 @@ func newDoubleFilterer() filter.Filterer {
 		},
 	}
 }
+
+// holdFilterer holds back all events until release emits them through the flush function of the last event
+type holdFilterer struct {
+	filter.FiltererMock
+	held  []event.Event
+	flush filter.FlushFn
+}
+
+func (h *holdFilterer) FilterWithFlush(e event.Event, flush filter.FlushFn) []event.Event {
+	h.held = append(h.held, e)
+	h.flush = flush
+	return []event.Event{}
+}
+
+func (h *holdFilterer) release() {
+	h.flush(h.held)
+}
+
+func TestChainFlush(t *testing.T) {
+	a := NewWithT(t)
+	hold := &holdFilterer{}
+	var c filter.Chain
+	a.Expect(c.Add(hold)).To(BeNil())
+	a.Expect(c.Add(newDoubleFilterer())).To(BeNil())
+
+	var flushed []event.Event
+	e, err := event.New(context.Background(), map[string]interface{}{"foo": "bar"})
+	a.Expect(err).To(BeNil())
+	evts := c.FilterWithFlush(e, func(events []event.Event) {
+		flushed = append(flushed, events...)
+	})
+	a.Expect(len(evts)).To(Equal(0))
+	a.Expect(len(flushed)).To(Equal(0))
+
+	// held events pass through the rest of the chain once they are emitted
+	hold.release()
+	a.Expect(len(flushed)).To(Equal(2))
+}
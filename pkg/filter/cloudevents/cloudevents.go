@@ -0,0 +1,195 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	return f, nil
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	err := evt.DeepCopy()
+	if err == nil {
+		if f.config.Mode == ModeEncode {
+			err = f.encode(evt)
+		} else {
+			err = f.decode(evt)
+		}
+	}
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "cloudevents").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "cloudevents").Str("name", f.Name()).Str("mode", f.config.Mode).Msg("cloudevents")
+	return []event.Event{evt}
+}
+
+// decode moves the cloud event attributes into the metadata and the cloud event data into the payload
+func (f *Filter) decode(evt event.Event) error {
+	attrs := make(map[string]interface{})
+	if f.config.Format == FormatBinary {
+		headers, _, _ := evt.GetPathValue(f.config.AttributesPath)
+		headerMap, ok := headers.(map[string]interface{})
+		if !ok {
+			return errors.New("no transport headers at " + f.config.AttributesPath)
+		}
+		for k, v := range headerMap {
+			// headers may hold a list of values
+			if vs, ok := v.([]interface{}); ok && len(vs) > 0 {
+				v = vs[0]
+			}
+			lk := strings.ToLower(k)
+			if strings.HasPrefix(lk, "ce-") {
+				attrs[lk[3:]] = v
+			} else if lk == "content-type" {
+				attrs["datacontenttype"] = v
+			}
+		}
+		if attrs["specversion"] == nil {
+			return errors.New("missing ce-specversion header")
+		}
+	} else {
+		obj, ok := evt.Payload().(map[string]interface{})
+		if !ok || obj["specversion"] == nil {
+			return errors.New("payload is not a structured cloud event")
+		}
+		for k, v := range obj {
+			if k != "data" && k != "data_base64" {
+				attrs[k] = v
+			}
+		}
+		data := obj["data"]
+		if b64, ok := obj["data_base64"].(string); ok {
+			buf, err := base64.StdEncoding.DecodeString(b64)
+			if err != nil {
+				return err
+			}
+			// binary data holding a json document is decoded as object
+			var d interface{}
+			if json.Unmarshal(buf, &d) == nil {
+				data = d
+			} else {
+				data = string(buf)
+			}
+		}
+		err := evt.SetPayload(data)
+		if err != nil {
+			return err
+		}
+	}
+	_, _, err := evt.SetPathValue(f.config.MetadataPath, attrs, true)
+	return err
+}
+
+// encode wraps the payload as structured cloud event
+func (f *Filter) encode(evt event.Event) error {
+	id := f.eval(evt, f.config.Id)
+	if id == "" {
+		id = uuid.New().String()
+	}
+	ce := map[string]interface{}{
+		"specversion":     SpecVersion,
+		"id":              id,
+		"source":          f.eval(evt, f.config.Source),
+		"type":            f.eval(evt, f.config.Type),
+		"time":            time.Now().UTC().Format(time.RFC3339Nano),
+		"datacontenttype": "application/json",
+		"data":            evt.Payload(),
+	}
+	if f.config.Subject != "" {
+		ce["subject"] = f.eval(evt, f.config.Subject)
+	}
+	for k, v := range f.config.Extensions {
+		ce[k] = f.eval(evt, v)
+	}
+	return evt.SetPayload(ce)
+}
+
+// eval evaluates a template against the event and returns the result as string
+func (f *Filter) eval(evt event.Event, tmpl string) string {
+	if tmpl == "" {
+		return ""
+	}
+	v, _, _ := evt.Evaluate(tmpl)
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
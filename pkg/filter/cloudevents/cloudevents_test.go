@@ -0,0 +1,210 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterCloudEventsDecode(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name     string
+		config   cloudevents.Config
+		payload  string
+		metadata map[string]interface{}
+		expected string
+		attrs    map[string]interface{}
+	}{
+		{
+			name:     "structured",
+			config:   cloudevents.Config{},
+			payload:  `{"specversion":"1.0","id":"1","source":"/devices","type":"com.example.status","data":{"foo":"bar"}}`,
+			expected: `{"foo":"bar"}`,
+			attrs:    map[string]interface{}{"specversion": "1.0", "id": "1", "source": "/devices", "type": "com.example.status"},
+		},
+		{
+			name:     "structuredBase64",
+			config:   cloudevents.Config{},
+			payload:  `{"specversion":"1.0","id":"2","source":"/devices","type":"com.example.status","data_base64":"eyJmb28iOiJiYXIifQ=="}`,
+			expected: `{"foo":"bar"}`,
+			attrs:    map[string]interface{}{"specversion": "1.0", "id": "2", "source": "/devices", "type": "com.example.status"},
+		},
+		{
+			name:     "binary",
+			config:   cloudevents.Config{Format: cloudevents.FormatBinary, AttributesPath: "metadata.headers"},
+			payload:  `{"foo":"bar"}`,
+			metadata: map[string]interface{}{"headers": map[string]interface{}{"Ce-Specversion": "1.0", "Ce-Id": "3", "Content-Type": "application/json", "Accept": "*/*"}},
+			expected: `{"foo":"bar"}`,
+			attrs:    map[string]interface{}{"specversion": "1.0", "id": "3", "datacontenttype": "application/json"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := cloudevents.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "cloudevents", "mycloudevents", tc.config, nil)
+			if err != nil {
+				t.Fatalf("cloudevents test failed: %s\n", err.Error())
+			}
+			var obj interface{}
+			err = json.Unmarshal([]byte(tc.payload), &obj)
+			if err != nil {
+				t.Fatalf("cloudevents test failed: %s\n", err.Error())
+			}
+			e, err := event.New(ctx, obj, event.WithMetadata(tc.metadata), event.FailOnNack(t))
+			if err != nil {
+				t.Fatalf("cloudevents test failed: %s\n", err.Error())
+			}
+			evts := f.Filter(e)
+			if len(evts) != 1 {
+				t.Fatalf("wrong number of decoded events: %d\n", len(evts))
+			}
+			var res interface{}
+			err = json.Unmarshal([]byte(tc.expected), &res)
+			if err != nil {
+				t.Fatalf("cloudevents test failed: %s\n", err.Error())
+			}
+			if !reflect.DeepEqual(evts[0].Payload(), res) {
+				pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+				t.Fatalf("wrong payload in decoded event: %s\n", pl)
+			}
+			if !reflect.DeepEqual(evts[0].Metadata()["cloudEvent"], tc.attrs) {
+				t.Fatalf("wrong attributes in decoded event: %v\n", evts[0].Metadata())
+			}
+		})
+	}
+}
+
+func TestFilterCloudEventsEncode(t *testing.T) {
+	ctx := context.Background()
+	f, err := cloudevents.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "cloudevents", "mycloudevents", cloudevents.Config{
+		Mode:       cloudevents.ModeEncode,
+		Source:     "/devices/{.deviceId}",
+		Type:       "com.example.status",
+		Extensions: map[string]string{"tenant": "{tenant.orgId}"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("cloudevents test failed: %s\n", err.Error())
+	}
+	payload := map[string]interface{}{"deviceId": "abc"}
+	e, err := event.New(ctx, payload, event.WithTenant(tenant.Id{AppId: "myapp", OrgId: "myorg"}), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cloudevents test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of encoded events: %d\n", len(evts))
+	}
+	ce := evts[0].Payload().(map[string]interface{})
+	if ce["specversion"] != "1.0" || ce["source"] != "/devices/abc" || ce["type"] != "com.example.status" || ce["tenant"] != "myorg" {
+		t.Fatalf("wrong attributes in encoded event: %v\n", ce)
+	}
+	if ce["id"] == "" || ce["time"] == "" {
+		t.Fatalf("missing id or time in encoded event: %v\n", ce)
+	}
+	if !reflect.DeepEqual(ce["data"], payload) {
+		t.Fatalf("wrong data in encoded event: %v\n", ce["data"])
+	}
+	_, err = cloudevents.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "cloudevents", "mycloudevents", cloudevents.Config{
+		Mode: cloudevents.ModeEncode,
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for missing source\n")
+	}
+}
+
+func TestRouteCloudEventsMode(t *testing.T) {
+	ctx := context.Background()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	ingress := cloudevents.NewRouteIngress(tid, "r1", "", "")
+	_, err := cloudevents.NewRouteEgress(tid, "xml")
+	if err == nil {
+		t.Fatalf("expected error for unsupported format")
+	}
+	structured, err := cloudevents.NewRouteEgress(tid, "")
+	if err != nil {
+		t.Fatalf("egress error: %s", err.Error())
+	}
+	binary, err := cloudevents.NewRouteEgress(tid, cloudevents.FormatBinary)
+	if err != nil {
+		t.Fatalf("egress error: %s", err.Error())
+	}
+	// plain events get attributes from the route and leave as structured cloud events
+	evt, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.WithTenant(tid))
+	if err != nil {
+		t.Fatalf("event error: %s", err.Error())
+	}
+	evts := ingress.Filter(evt)
+	if len(evts) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evts))
+	}
+	source, _, _ := evts[0].GetPathValue("ce.source")
+	if source != "ears/myorg/myapp/r1" {
+		t.Errorf("unexpected source %v", source)
+	}
+	_, _, err = evts[0].SetPathValue("ce.subject", "device1", true)
+	if err != nil {
+		t.Fatalf("set path error: %s", err.Error())
+	}
+	evts = structured.Filter(evts[0])
+	if len(evts) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evts))
+	}
+	ce, ok := evts[0].Payload().(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected payload %v", evts[0].Payload())
+	}
+	if ce["type"] != cloudevents.DefaultRouteType || ce["subject"] != "device1" || ce["specversion"] != "1.0" {
+		t.Errorf("unexpected attributes %v", ce)
+	}
+	if !reflect.DeepEqual(ce["data"], map[string]interface{}{"foo": "bar"}) {
+		t.Errorf("unexpected data %v", ce["data"])
+	}
+	// binary cloud events keep their attributes and leave with cloud event headers
+	headers := cloudevents.ReceivedHeaders(map[string]string{"Ce-Specversion": "1.0", "Ce-Id": "42", "Ce-Source": "/devices", "Ce-Type": "com.example.status", "Content-Type": "application/json", "Accept": "*/*"})
+	evt, err = event.New(ctx, map[string]interface{}{"foo": "bar"}, event.WithTenant(tid), event.WithMetadata(map[string]interface{}{cloudevents.ReceivedHeadersKey: headers}))
+	if err != nil {
+		t.Fatalf("event error: %s", err.Error())
+	}
+	evts = ingress.Filter(evt)
+	if len(evts) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evts))
+	}
+	evts = binary.Filter(evts[0])
+	if len(evts) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(evts))
+	}
+	expected := map[string]string{"ce-specversion": "1.0", "ce-id": "42", "ce-source": "/devices", "ce-type": "com.example.status", "content-type": "application/json"}
+	if sent := cloudevents.SendHeaders(evts[0]); !reflect.DeepEqual(sent, expected) {
+		t.Errorf("unexpected headers %v", sent)
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), map[string]interface{}{"foo": "bar"}) {
+		t.Errorf("unexpected payload %v", evts[0].Payload())
+	}
+	// events without attributes are dropped by the egress
+	evt, err = event.New(ctx, map[string]interface{}{"foo": "bar"}, event.WithTenant(tid))
+	if err != nil {
+		t.Fatalf("event error: %s", err.Error())
+	}
+	if evts = structured.Filter(evt); len(evts) != 0 {
+		t.Errorf("expected event to be dropped")
+	}
+}
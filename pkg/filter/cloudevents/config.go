@@ -0,0 +1,109 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/xmidt-org/ears/pkg/config"
+	pkgconfig "github.com/xmidt-org/ears/pkg/config"
+	"github.com/xmidt-org/ears/pkg/errs"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+)
+
+func NewConfig(config interface{}) (*Config, error) {
+	var cfg Config
+	err := pkgconfig.NewConfig(config, &cfg)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	return &cfg, nil
+}
+
+func (c Config) WithDefaults() *Config {
+	cfg := c
+	if c.Mode == "" {
+		cfg.Mode = DefaultConfig.Mode
+	}
+	if c.Format == "" {
+		cfg.Format = DefaultConfig.Format
+	}
+	if c.AttributesPath == "" {
+		cfg.AttributesPath = DefaultConfig.AttributesPath
+	}
+	if c.MetadataPath == "" {
+		cfg.MetadataPath = DefaultConfig.MetadataPath
+	}
+	if c.Extensions == nil {
+		cfg.Extensions = DefaultConfig.Extensions
+	}
+	return &cfg
+}
+
+func (c *Config) Validate() error {
+	if c.Mode != ModeDecode && c.Mode != ModeEncode {
+		return errors.New("unsupported mode " + c.Mode)
+	}
+	if c.Format != FormatStructured && c.Format != FormatBinary {
+		return errors.New("unsupported format " + c.Format)
+	}
+	if !strings.HasPrefix(c.MetadataPath, event.METADATA) {
+		return errors.New("metadataPath must be a metadata path")
+	}
+	if c.Mode == ModeEncode {
+		if c.Source == "" {
+			return errors.New("missing source")
+		}
+		if c.Type == "" {
+			return errors.New("missing type")
+		}
+		validName := regexp.MustCompile(`^[a-z0-9]+$`)
+		for name := range c.Extensions {
+			if !validName.MatchString(name) {
+				return errors.New("invalid extension attribute name " + name)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) String() string {
+	s, err := c.YAML()
+	if err != nil {
+		return errs.String("error", nil, err)
+	}
+	return s
+}
+
+func (c *Config) YAML() (string, error) {
+	return config.ToYAML(c)
+}
+
+func (c *Config) FromYAML(in string) error {
+	return config.FromYAML(in, c)
+}
+
+func (c *Config) JSON() (string, error) {
+	return config.ToJSON(c)
+}
+
+func (c *Config) FromJSON(in string) error {
+	return config.FromJSON(in, c)
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ReceivedHeadersKey is the metadata key receivers store the cloud event transport headers of a received
+	// message under, i.e. ce-* headers and the content type
+	ReceivedHeadersKey = "cloudEventHeaders"
+
+	// SendHeadersKey is the metadata key of the transport headers senders add to a message to emit a binary
+	// cloud event
+	SendHeadersKey = "cloudEventSendHeaders"
+
+	// DefaultRouteType is the type of events that were not received as cloud events
+	DefaultRouteType = "ears.event"
+
+	headerPrefix      = "ce-"
+	headerContentType = "content-type"
+	metadataKey       = "cloudEvent"
+)
+
+// IsHeader returns true if a transport header carries a cloud event attribute in binary format
+func IsHeader(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, headerPrefix) || name == headerContentType
+}
+
+// ReceivedHeaders collects the cloud event headers of a received message for the metadata of its event, it
+// returns nil unless the message is a binary cloud event
+func ReceivedHeaders(headers map[string]string) map[string]interface{} {
+	var ceHeaders map[string]interface{}
+	for name := range headers {
+		if strings.ToLower(name) == headerPrefix+"specversion" {
+			ceHeaders = make(map[string]interface{})
+			break
+		}
+	}
+	if ceHeaders == nil {
+		return nil
+	}
+	for name, value := range headers {
+		if IsHeader(name) {
+			ceHeaders[strings.ToLower(name)] = value
+		}
+	}
+	return ceHeaders
+}
+
+// SendHeaders returns the transport headers a sender adds to emit an event as binary cloud event, or nil if the
+// event is not to be sent as binary cloud event
+func SendHeaders(evt event.Event) map[string]string {
+	md := evt.Metadata()
+	if md == nil {
+		return nil
+	}
+	headers, ok := md[SendHeadersKey].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		result[name] = toString(value)
+	}
+	return result
+}
+
+// RouteIngress makes events entering a route in cloud events mode cloud events, structured and binary cloud events
+// are decoded while the attributes of other events are populated from the event and the route
+type RouteIngress struct {
+	source string
+	typ    string
+	tid    tenant.Id
+}
+
+// RouteEgress emits events leaving a route in cloud events mode as structured or binary cloud events
+type RouteEgress struct {
+	format string
+	tid    tenant.Id
+}
+
+// NewRouteIngress returns the ingress filter of a route, source and typ are templates and default to
+// ears/<orgId>/<appId>/<routeId> and ears.event
+func NewRouteIngress(tid tenant.Id, routeId string, source string, typ string) *RouteIngress {
+	if source == "" {
+		source = "ears/" + tid.OrgId + "/" + tid.AppId + "/" + routeId
+	}
+	if typ == "" {
+		typ = DefaultRouteType
+	}
+	return &RouteIngress{
+		source: source,
+		typ:    typ,
+		tid:    tid,
+	}
+}
+
+// NewRouteEgress returns the egress filter of a route emitting events in the given format, structured by default
+func NewRouteEgress(tid tenant.Id, format string) (*RouteEgress, error) {
+	if format == "" {
+		format = FormatStructured
+	}
+	if format != FormatStructured && format != FormatBinary {
+		return nil, errors.New("unsupported cloud events format " + format)
+	}
+	return &RouteEgress{
+		format: format,
+		tid:    tid,
+	}, nil
+}
+
+func (f *RouteIngress) Filter(evt event.Event) []event.Event {
+	err := f.ingress(evt)
+	if err != nil {
+		return dropEvent(evt, "ingress", err)
+	}
+	return []event.Event{evt}
+}
+
+func (f *RouteIngress) ingress(evt event.Event) error {
+	// receivers may share events between routes
+	err := evt.DeepCopy()
+	if err != nil {
+		return err
+	}
+	md := evt.Metadata()
+	if headers, ok := md[ReceivedHeadersKey].(map[string]interface{}); ok {
+		attrs := make(map[string]interface{})
+		for name, value := range headers {
+			if strings.HasPrefix(name, headerPrefix) {
+				attrs[name[len(headerPrefix):]] = value
+			} else if name == headerContentType {
+				attrs["datacontenttype"] = value
+			}
+		}
+		delete(md, ReceivedHeadersKey)
+		_, _, err = evt.SetPathValue(event.CLOUDEVENT_METADATA, attrs, true)
+		return err
+	}
+	if obj, ok := evt.Payload().(map[string]interface{}); ok && obj["specversion"] != nil {
+		decoder := &Filter{config: *DefaultConfig.WithDefaults()}
+		return decoder.decode(evt)
+	}
+	attrs := map[string]interface{}{
+		"specversion":     SpecVersion,
+		"id":              evt.Id(),
+		"source":          evalString(evt, f.source),
+		"type":            evalString(evt, f.typ),
+		"time":            evt.Created().UTC().Format(time.RFC3339Nano),
+		"datacontenttype": "application/json",
+	}
+	_, _, err = evt.SetPathValue(event.CLOUDEVENT_METADATA, attrs, true)
+	return err
+}
+
+func (f *RouteEgress) Filter(evt event.Event) []event.Event {
+	err := f.egress(evt)
+	if err != nil {
+		return dropEvent(evt, "egress", err)
+	}
+	return []event.Event{evt}
+}
+
+func (f *RouteEgress) egress(evt event.Event) error {
+	err := evt.DeepCopy()
+	if err != nil {
+		return err
+	}
+	attrs, ok := evt.Metadata()[metadataKey].(map[string]interface{})
+	if !ok || attrs["specversion"] == nil {
+		return errors.New("event has no cloud event attributes")
+	}
+	if f.format == FormatBinary {
+		headers := make(map[string]interface{}, len(attrs))
+		for name, value := range attrs {
+			if name == "datacontenttype" {
+				headers[headerContentType] = toString(value)
+			} else {
+				headers[headerPrefix+name] = toString(value)
+			}
+		}
+		_, _, err = evt.SetPathValue(event.METADATA+"."+SendHeadersKey, headers, true)
+		return err
+	}
+	ce := make(map[string]interface{}, len(attrs)+1)
+	for name, value := range attrs {
+		ce[name] = value
+	}
+	ce["data"] = evt.Payload()
+	return evt.SetPayload(ce)
+}
+
+// dropEvent acks an event that cannot be processed as cloud event so that it does not block its receiver
+func dropEvent(evt event.Event, op string, err error) []event.Event {
+	log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "cloudevents").Str("name", op).Msg(err.Error())
+	if span := trace.SpanFromContext(evt.Context()); span != nil {
+		span.AddEvent(err.Error())
+	}
+	evt.Ack()
+	return []event.Event{}
+}
+
+// evalString evaluates a template against the event and returns the result as string
+func evalString(evt event.Event, tmpl string) string {
+	v, _, _ := evt.Evaluate(tmpl)
+	return toString(v)
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func (f *RouteIngress) Config() interface{} {
+	return map[string]interface{}{"source": f.source, "type": f.typ}
+}
+
+func (f *RouteIngress) Name() string {
+	return "ingress"
+}
+
+func (f *RouteIngress) Plugin() string {
+	return "cloudevents"
+}
+
+func (f *RouteIngress) Tenant() tenant.Id {
+	return f.tid
+}
+
+func (f *RouteEgress) Config() interface{} {
+	return map[string]interface{}{"format": f.format}
+}
+
+func (f *RouteEgress) Name() string {
+	return "egress"
+}
+
+func (f *RouteEgress) Plugin() string {
+	return "cloudevents"
+}
+
+func (f *RouteEgress) Tenant() tenant.Id {
+	return f.tid
+}
+
+var _ filter.Filterer = (*RouteIngress)(nil)
+var _ filter.Filterer = (*RouteEgress)(nil)
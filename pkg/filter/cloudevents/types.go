@@ -0,0 +1,60 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudevents
+
+import "github.com/xmidt-org/ears/pkg/tenant"
+
+const (
+	ModeDecode = "decode" // unwrap a cloud event into payload and metadata
+	ModeEncode = "encode" // wrap the payload as structured cloud event
+
+	FormatStructured = "structured" // attributes and data are part of the payload
+	FormatBinary     = "binary"     // attributes are transport headers, the payload is the data
+
+	SpecVersion = "1.0"
+)
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	Mode           string            `json:"mode,omitempty"`
+	Format         string            `json:"format,omitempty"`         // decode only
+	AttributesPath string            `json:"attributesPath,omitempty"` // decode only, path of the transport headers in binary format
+	MetadataPath   string            `json:"metadataPath,omitempty"`   // path of the cloud event attributes in the metadata
+	Id             string            `json:"id,omitempty"`             // encode only, template, a uuid is generated if omitted
+	Source         string            `json:"source,omitempty"`         // encode only, template
+	Type           string            `json:"type,omitempty"`           // encode only, template
+	Subject        string            `json:"subject,omitempty"`        // encode only, optional template
+	Extensions     map[string]string `json:"extensions,omitempty"`     // encode only, optional extension attribute templates
+}
+
+var DefaultConfig = Config{
+	Mode:           ModeDecode,
+	Format:         FormatStructured,
+	AttributesPath: "metadata",
+	MetadataPath:   "metadata.cloudEvent",
+	Id:             "",
+	Source:         "",
+	Type:           "",
+	Subject:        "",
+	Extensions:     map[string]string{},
+}
+
+type Filter struct {
+	config Config
+	name   string
+	plugin string
+	tid    tenant.Id
+}
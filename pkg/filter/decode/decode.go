@@ -145,3 +145,9 @@ func (f *Filter) Plugin() string {
 func (f *Filter) Tenant() tenant.Id {
 	return f.tid
 }
+
+// SupportsBinaryPayload returns true so that a binary payload can be decoded into a json payload
+// with a fromPath of "."
+func (f *Filter) SupportsBinaryPayload() bool {
+	return true
+}
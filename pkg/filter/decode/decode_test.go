@@ -95,3 +95,29 @@ func TestFilterDecodeString(t *testing.T) {
 		t.Fatalf("wrong payload in decoded event: %s\n", pl)
 	}
 }
+
+func TestFilterDecodeBinaryPayload(t *testing.T) {
+	ctx := context.Background()
+	f, err := decode.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "decode", "mydecode", decode.Config{
+		FromPath: ".",
+		Encoding: "string",
+	}, nil)
+	if err != nil {
+		t.Fatalf("decode test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, nil, event.WithBinaryPayload([]byte(`{"foo":"bar"}`), "text/plain"), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("decode test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of decoded events: %d\n", len(evts))
+	}
+	if evts[0].ContentType() != event.ContentTypeJSON {
+		t.Fatalf("wrong content type of decoded event: %s\n", evts[0].ContentType())
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), map[string]interface{}{"foo": "bar"}) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in decoded event: %s\n", pl)
+	}
+}
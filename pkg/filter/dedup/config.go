@@ -41,6 +41,21 @@ func (c Config) WithDefaults() *Config {
 	if c.Path == "" {
 		cfg.Path = DefaultConfig.Path
 	}
+	if c.Paths == nil {
+		cfg.Paths = DefaultConfig.Paths
+	}
+	if c.Ttl == nil {
+		cfg.Ttl = DefaultConfig.Ttl
+	}
+	if c.Mode == "" {
+		cfg.Mode = DefaultConfig.Mode
+	}
+	if c.Endpoint == "" {
+		cfg.Endpoint = DefaultConfig.Endpoint
+	}
+	if c.DB == nil {
+		cfg.DB = DefaultConfig.DB
+	}
 	return &cfg
 }
 
@@ -48,6 +63,18 @@ func (c *Config) Validate() error {
 	if c.CacheSize == nil || *c.CacheSize < 0 || *c.CacheSize > 10000 {
 		return errors.New("cache size must be between 0 and 10000")
 	}
+	if c.Ttl == nil || *c.Ttl < 0 {
+		return errors.New("ttl must not be negative")
+	}
+	if c.Path != "" && len(c.Paths) > 0 {
+		return errors.New("path and paths are mutually exclusive")
+	}
+	if c.Mode != ModeLocal && c.Mode != ModeRedis {
+		return errors.New("unsupported mode " + c.Mode)
+	}
+	if c.Mode == ModeRedis && *c.Ttl == 0 {
+		return errors.New("ttl required in redis mode")
+	}
 	return nil
 }
 
@@ -20,8 +20,10 @@ import (
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter/dedup"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFilterDedupBasic(t *testing.T) {
@@ -58,3 +60,95 @@ func TestFilterDedupBasic(t *testing.T) {
 		t.Fatalf("dedup didn't filter events: %d\n", len(evts))
 	}
 }
+
+func TestFilterDedupTtl(t *testing.T) {
+	ctx := context.Background()
+	f, err := dedup.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "dedup", "mydedup", dedup.Config{
+		Ttl: pointer.Int(1),
+	}, nil)
+	if err != nil {
+		t.Fatalf("dedup test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("dedup test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of deduped events: %d\n", len(evts))
+	}
+	evts = f.Filter(e)
+	if len(evts) != 0 {
+		t.Fatalf("dedup didn't filter events: %d\n", len(evts))
+	}
+	time.Sleep(1100 * time.Millisecond)
+	evts = f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("dedup filtered event after ttl expired: %d\n", len(evts))
+	}
+	_, err = dedup.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "dedup", "mydedup", dedup.Config{
+		Ttl: pointer.Int(-1),
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for negative ttl\n")
+	}
+}
+
+// lookups against an unreachable redis fail open
+func TestFilterDedupRedis(t *testing.T) {
+	ctx := context.Background()
+	_, err := dedup.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "dedup", "mydedup", dedup.Config{
+		Mode: dedup.ModeRedis,
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for missing ttl in redis mode\n")
+	}
+	f, err := dedup.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "dedup", "mydedup", dedup.Config{
+		Mode:     dedup.ModeRedis,
+		Endpoint: "127.0.0.1:1",
+		Ttl:      pointer.Int(60),
+	}, nil)
+	if err != nil {
+		t.Fatalf("dedup test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("dedup test failed: %s\n", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		evts := f.Filter(e)
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of deduped events: %d\n", len(evts))
+		}
+	}
+}
+
+func TestFilterDedupPaths(t *testing.T) {
+	ctx := context.Background()
+	f, err := dedup.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "dedup", "mydedup", dedup.Config{
+		Paths: []string{".deviceId", ".status", "metadata.source"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("dedup test failed: %s\n", err.Error())
+	}
+	testCases := []struct {
+		payload  map[string]interface{}
+		source   string
+		expected int
+	}{
+		{payload: map[string]interface{}{"deviceId": "abc", "status": "on", "ts": 1}, source: "a", expected: 1},
+		{payload: map[string]interface{}{"deviceId": "abc", "status": "on", "ts": 2}, source: "a", expected: 0},
+		{payload: map[string]interface{}{"deviceId": "abc", "status": "on", "ts": 3}, source: "b", expected: 1},
+		{payload: map[string]interface{}{"deviceId": "abc", "status": "off", "ts": 4}, source: "a", expected: 1},
+	}
+	for idx, tc := range testCases {
+		e, err := event.New(ctx, tc.payload, event.WithMetadataKeyValue("source", tc.source), event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("dedup test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != tc.expected {
+			t.Fatalf("wrong number of deduped events for event %d: %d\n", idx, len(evts))
+		}
+	}
+}
@@ -15,18 +15,27 @@
 package dedup
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"github.com/go-redis/redis"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+var _ filter.Stopper = (*Filter)(nil)
+
 func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
 	cfg, err := NewConfig(config)
 	if err != nil {
@@ -45,7 +54,33 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 		plugin: plugin,
 		tid:    tid,
 	}
-	f.lruCache, err = lru.New(*cfg.CacheSize)
+	if cfg.Mode == ModeRedis {
+		password := ""
+		if cfg.Password != "" && secrets != nil {
+			password = secrets.Secret(cfg.Password)
+		}
+		f.client = redis.NewClient(&redis.Options{
+			Addr:     cfg.Endpoint,
+			Password: password,
+			DB:       *cfg.DB,
+		})
+	}
+	// metric recorders
+	meter := global.Meter(rtsemconv.EARSMeterName)
+	commonLabels := []attribute.KeyValue{
+		attribute.String(rtsemconv.EARSPluginTypeLabel, rtsemconv.EARSPluginTypeDedupFilter),
+		attribute.String(rtsemconv.EARSPluginNameLabel, f.Name()),
+		attribute.String(rtsemconv.EARSAppIdLabel, f.tid.AppId),
+		attribute.String(rtsemconv.EARSOrgIdLabel, f.tid.OrgId),
+	}
+	f.tableSize = metric.Must(meter).
+		NewInt64UpDownCounter(
+			rtsemconv.EARSMetricDedupTableSize,
+			metric.WithDescription("measures the number of entries in the dedup table"),
+		).Bind(commonLabels...)
+	f.lruCache, err = lru.NewWithEvict(*cfg.CacheSize, func(key interface{}, value interface{}) {
+		f.tableSize.Add(context.Background(), -1)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +94,7 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		})
 		return nil
 	}
-	obj, _, _ := evt.GetPathValue(f.config.Path)
+	obj := f.identity(evt)
 	if obj == nil {
 		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "dedup").Str("name", f.Name()).Msg("nil object at " + f.config.Path)
 		if span := trace.SpanFromContext(evt.Context()); span != nil {
@@ -78,9 +113,20 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		return []event.Event{}
 	}
 	evtHash := fmt.Sprintf("%x", md5.Sum(buf))
-	_, ok := f.lruCache.Get(evtHash)
-	if !ok {
-		f.lruCache.Add(evtHash, struct{}{})
+	var seen bool
+	if f.config.Mode == ModeRedis {
+		seen, err = f.seenRedis(evtHash)
+		if err != nil {
+			// fail open so that events are not lost while redis is unavailable
+			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "dedup").Str("name", f.Name()).Msg(err.Error())
+			if span := trace.SpanFromContext(evt.Context()); span != nil {
+				span.AddEvent(err.Error())
+			}
+		}
+	} else {
+		seen = f.seen(evt.Context(), evtHash)
+	}
+	if !seen {
 		log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "dedup").Str("name", f.Name()).Int("eventCount", 1).Msg("dedup")
 		return []event.Event{evt}
 	} else {
@@ -90,6 +136,54 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	}
 }
 
+// identity returns the part of the event used to detect duplicates, for composite keys this is the
+// list of values at the configured paths where missing values are represented by nil
+func (f *Filter) identity(evt event.Event) interface{} {
+	if len(f.config.Paths) == 0 {
+		obj, _, _ := evt.GetPathValue(f.config.Path)
+		return obj
+	}
+	values := make([]interface{}, len(f.config.Paths))
+	for idx, path := range f.config.Paths {
+		values[idx], _, _ = evt.GetPathValue(path)
+	}
+	return values
+}
+
+// seen returns true if the hash is in the dedup table and has not expired yet, otherwise the hash is added to the table
+func (f *Filter) seen(ctx context.Context, evtHash string) bool {
+	if expires, ok := f.lruCache.Get(evtHash); ok {
+		if expires.(time.Time).IsZero() || time.Now().Before(expires.(time.Time)) {
+			return true
+		}
+		f.lruCache.Remove(evtHash)
+	}
+	var expires time.Time
+	if *f.config.Ttl > 0 {
+		expires = time.Now().Add(time.Duration(*f.config.Ttl) * time.Second)
+	}
+	f.lruCache.Add(evtHash, expires)
+	f.tableSize.Add(ctx, 1)
+	return false
+}
+
+// seenRedis atomically adds the hash to the dedup table in redis and returns true if it was already present
+func (f *Filter) seenRedis(evtHash string) (bool, error) {
+	key := "ears.dedup." + f.tid.OrgId + "." + f.tid.AppId + "." + f.name + "." + evtHash
+	added, err := f.client.SetNX(key, 1, time.Duration(*f.config.Ttl)*time.Second).Result()
+	if err != nil {
+		return false, err
+	}
+	return !added, nil
+}
+
+// StopFiltering closes the redis client of the filter in redis mode
+func (f *Filter) StopFiltering(ctx context.Context) {
+	if f.client != nil {
+		f.client.Close()
+	}
+}
+
 func (f *Filter) Config() interface{} {
 	if f == nil {
 		return Config{}
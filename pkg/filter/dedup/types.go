@@ -15,21 +15,40 @@
 package dedup
 
 import (
+	"github.com/go-redis/redis"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"github.com/xorcare/pointer"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	ModeLocal = "local"
+	ModeRedis = "redis"
 )
 
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
 type Config struct {
-	CacheSize *int   `json:"cacheSize,omitempty"`
-	Path      string `json:"path,omitempty"`
+	CacheSize *int     `json:"cacheSize,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Paths     []string `json:"paths,omitempty"`    // if set, identity is computed from the values at these payload or metadata paths instead of path
+	Ttl       *int     `json:"ttl,omitempty"`      // ttl of an entry in seconds, 0 means entries only expire by lru eviction
+	Mode      string   `json:"mode,omitempty"`     // local or redis, redis shares the dedup table among ears instances
+	Endpoint  string   `json:"endpoint,omitempty"` // redis endpoint
+	Password  string   `json:"password,omitempty"` // optional secret reference
+	DB        *int     `json:"db,omitempty"`
 }
 
 var DefaultConfig = Config{
 	CacheSize: pointer.Int(1000),
 	Path:      "",
+	Paths:     []string{},
+	Ttl:       pointer.Int(0),
+	Mode:      ModeLocal,
+	Endpoint:  "localhost:6379",
+	Password:  "",
+	DB:        pointer.Int(0),
 }
 
 type Filter struct {
@@ -38,4 +57,7 @@ type Filter struct {
 	plugin   string
 	tid      tenant.Id
 	lruCache *lru.Cache
+	// table size is tracked as up down counter because entries are added and evicted
+	tableSize metric.BoundInt64UpDownCounter
+	client    *redis.Client
 }
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defaults_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/defaults"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterDefaults(t *testing.T) {
+	ctx := context.Background()
+	f, err := defaults.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "defaults", "mydefaults", defaults.Config{
+		Values: map[string]interface{}{
+			".status":         "unknown",
+			".severity":       3,
+			".device.tags":    []interface{}{},
+			"metadata.source": "ears",
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("defaults test failed: %s\n", err.Error())
+	}
+	eventStr := `{"status":"ok", "severity":null, "device":{"id":"abc"}}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("defaults test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("defaults test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of events: %d\n", len(evts))
+	}
+	expected := map[string]interface{}{
+		"status":   "ok",
+		"severity": 3,
+		"device":   map[string]interface{}{"id": "abc", "tags": []interface{}{}},
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), expected) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in event: %s\n", pl)
+	}
+	source, _, _ := evts[0].GetPathValue("metadata.source")
+	if source != "ears" {
+		t.Fatalf("wrong metadata in event: %v\n", evts[0].Metadata())
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boriwo/deepcopy"
+	"github.com/go-redis/redis"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ filter.Stopper = (*Filter)(nil)
+
+// cacheEntry holds the result of a lookup including misses so that
+// unknown keys do not cause a round trip to redis for every event
+type cacheEntry struct {
+	value   interface{}
+	found   bool
+	expires time.Time
+}
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	password := ""
+	if cfg.Password != "" && secrets != nil {
+		password = secrets.Secret(cfg.Password)
+	}
+	f.client = redis.NewClient(&redis.Options{
+		Addr:     cfg.Endpoint,
+		Password: password,
+		DB:       *cfg.DB,
+	})
+	f.lruCache, err = lru.New(*cfg.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	keyObj, _, _ := evt.Evaluate(f.config.Key)
+	key := ""
+	if keyObj != nil {
+		key = fmt.Sprint(keyObj)
+	}
+	var value interface{}
+	found := false
+	if key == "" {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Msg("cannot build key from " + f.config.Key)
+	} else {
+		var err error
+		value, found, err = f.lookup(key)
+		if err != nil {
+			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Str("key", key).Msg(err.Error())
+			if span := trace.SpanFromContext(evt.Context()); span != nil {
+				span.AddEvent(err.Error())
+			}
+		}
+	}
+	if !found {
+		switch f.config.MissPolicy {
+		case MissPolicyDrop:
+			log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Str("key", key).Msg("dropping event on lookup miss")
+			evt.Ack()
+			return []event.Event{}
+		case MissPolicyDefault:
+			value = f.config.Default
+		default:
+			return []event.Event{evt}
+		}
+	}
+	err := evt.DeepCopy()
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	_, _, err = evt.SetPathValue(f.config.ToPath, deepcopy.DeepCopy(value), true)
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "enrich").Str("name", f.Name()).Msg("enrich")
+	return []event.Event{evt}
+}
+
+// lookup returns the value for a key from the local cache or from redis, errors are not cached
+func (f *Filter) lookup(key string) (interface{}, bool, error) {
+	if *f.config.CacheTTL > 0 {
+		if entry, ok := f.lruCache.Get(key); ok {
+			ce := entry.(*cacheEntry)
+			if time.Now().Before(ce.expires) {
+				return ce.value, ce.found, nil
+			}
+			f.lruCache.Remove(key)
+		}
+	}
+	var value interface{}
+	found := false
+	switch f.config.ValueType {
+	case ValueTypeHash:
+		m, err := f.client.HGetAll(key).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(m) > 0 {
+			obj := make(map[string]interface{}, len(m))
+			for k, v := range m {
+				obj[k] = v
+			}
+			value = obj
+			found = true
+		}
+	default:
+		s, err := f.client.Get(key).Result()
+		if err != nil && err != redis.Nil {
+			return nil, false, err
+		}
+		if err == nil {
+			// values holding json documents are merged as objects, everything else as plain string
+			var obj interface{}
+			if json.Unmarshal([]byte(s), &obj) == nil {
+				value = obj
+			} else {
+				value = s
+			}
+			found = true
+		}
+	}
+	if *f.config.CacheTTL > 0 {
+		f.lruCache.Add(key, &cacheEntry{
+			value:   value,
+			found:   found,
+			expires: time.Now().Add(time.Duration(*f.config.CacheTTL) * time.Second),
+		})
+	}
+	return value, found, nil
+}
+
+// StopFiltering closes the redis client of the filter
+func (f *Filter) StopFiltering(ctx context.Context) {
+	f.client.Close()
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/enrich"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// lookups against an unreachable redis are treated as misses
+func TestFilterEnrichMissPolicy(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name       string
+		missPolicy string
+		expected   []string
+	}{
+		{name: "skip", missPolicy: enrich.MissPolicySkip, expected: []string{`{"id":"abc"}`}},
+		{name: "drop", missPolicy: enrich.MissPolicyDrop, expected: []string{}},
+		{name: "default", missPolicy: enrich.MissPolicyDefault, expected: []string{`{"id":"abc","device":{"model":"unknown"}}`}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := enrich.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "enrich", "myenrich", enrich.Config{
+				Endpoint:   "127.0.0.1:1",
+				Key:        "device:{.id}",
+				ToPath:     ".device",
+				MissPolicy: tc.missPolicy,
+				Default:    map[string]interface{}{"model": "unknown"},
+			}, nil)
+			if err != nil {
+				t.Fatalf("enrich test failed: %s\n", err.Error())
+			}
+			e, err := event.New(ctx, map[string]interface{}{"id": "abc"}, event.FailOnNack(t))
+			if err != nil {
+				t.Fatalf("enrich test failed: %s\n", err.Error())
+			}
+			evts := f.Filter(e)
+			if len(evts) != len(tc.expected) {
+				t.Fatalf("wrong number of enriched events: %d\n", len(evts))
+			}
+			for idx, expectedEventStr := range tc.expected {
+				var res interface{}
+				err = json.Unmarshal([]byte(expectedEventStr), &res)
+				if err != nil {
+					t.Fatalf("enrich test failed: %s\n", err.Error())
+				}
+				if !reflect.DeepEqual(evts[idx].Payload(), res) {
+					pl, _ := json.MarshalIndent(evts[idx].Payload(), "", "\t")
+					t.Fatalf("wrong payload in enriched event: %s\n", pl)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"github.com/go-redis/redis"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
+
+const (
+	ValueTypeString = "string"
+	ValueTypeHash   = "hash"
+
+	MissPolicySkip    = "skip"
+	MissPolicyDrop    = "drop"
+	MissPolicyDefault = "default"
+)
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	Endpoint   string      `json:"endpoint,omitempty"`
+	Password   string      `json:"password,omitempty"` // optional secret reference
+	DB         *int        `json:"db,omitempty"`
+	Key        string      `json:"key,omitempty"`       // key expression, e.g. device:{.deviceId}
+	ValueType  string      `json:"valueType,omitempty"` // string values are parsed as json if possible, hash values become objects
+	ToPath     string      `json:"toPath,omitempty"`
+	CacheSize  *int        `json:"cacheSize,omitempty"`  // number of lookups to cache locally
+	CacheTTL   *int        `json:"cacheTTL,omitempty"`   // seconds to cache lookups, 0 disables caching
+	MissPolicy string      `json:"missPolicy,omitempty"` // skip, drop or default
+	Default    interface{} `json:"default,omitempty"`    // value to set on a miss if missPolicy is default
+}
+
+var DefaultConfig = Config{
+	Endpoint:   "localhost:6379",
+	Password:   "",
+	DB:         pointer.Int(0),
+	Key:        "",
+	ValueType:  ValueTypeString,
+	ToPath:     "",
+	CacheSize:  pointer.Int(1000),
+	CacheTTL:   pointer.Int(60),
+	MissPolicy: MissPolicySkip,
+	Default:    nil,
+}
+
+type Filter struct {
+	config   Config
+	name     string
+	plugin   string
+	tid      tenant.Id
+	client   *redis.Client
+	lruCache *lru.Cache
+}
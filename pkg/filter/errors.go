@@ -31,3 +31,7 @@ func (e *InvalidArgumentError) Unwrap() error {
 func (e *InvalidArgumentError) Error() string {
 	return errs.String("InvalidArgumentError", nil, e.Err)
 }
+
+func (e *UnsupportedPayloadError) Error() string {
+	return errs.String("UnsupportedPayloadError", map[string]interface{}{"filter": e.Filter, "contentType": e.ContentType}, nil)
+}
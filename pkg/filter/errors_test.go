@@ -41,6 +41,11 @@ func TestErrorMessage(t *testing.T) {
 			name: "InvalidArgumentError_Err",
 			err:  &filter.InvalidArgumentError{Err: fmt.Errorf("wrapped error")},
 		},
+
+		{
+			name: "UnsupportedPayloadError",
+			err:  &filter.UnsupportedPayloadError{Filter: "match", ContentType: "application/octet-stream"},
+		},
 	}
 
 	for _, tc := range testCases {
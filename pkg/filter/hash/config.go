@@ -15,6 +15,8 @@
 package hash
 
 import (
+	"errors"
+
 	"github.com/xmidt-org/ears/pkg/config"
 	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/errs"
@@ -56,6 +58,26 @@ func (c Config) WithDefaults() *Config {
 }
 
 func (c *Config) Validate() error {
+	switch c.HashAlgorithm {
+	case "fnv", "md5", "sha1", "sha256":
+	case "hmac-md5", "hmac-sha1", "hmac-sha256":
+		if c.Key == "" {
+			return errors.New("key required for " + c.HashAlgorithm)
+		}
+	default:
+		return errors.New("unsupported hashing algorithm " + c.HashAlgorithm)
+	}
+	if c.Encoding != "" && c.Encoding != "hex" && c.Encoding != "base64" {
+		return errors.New("unsupported encoding " + c.Encoding)
+	}
+	if len(c.FromPaths) > 0 {
+		if c.From != "" || c.FromPath != "" {
+			return errors.New("fromPaths cannot be combined with from or fromPath")
+		}
+		if c.ToPath == "" {
+			return errors.New("toPath required for fromPaths")
+		}
+	}
 	return nil
 }
 
@@ -61,7 +61,14 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		return nil
 	}
 	var obj interface{}
-	if f.config.From != "" {
+	if len(f.config.FromPaths) > 0 {
+		// missing values are hashed as null so that the position of each path stays significant
+		values := make([]interface{}, len(f.config.FromPaths))
+		for idx, path := range f.config.FromPaths {
+			values[idx], _, _ = evt.GetPathValue(path)
+		}
+		obj = values
+	} else if f.config.From != "" {
 		obj, _, _ = evt.Evaluate(f.config.From)
 	} else {
 		obj, _, _ = evt.GetPathValue(f.config.FromPath)
@@ -60,3 +60,49 @@ func TestFilterHashBasic(t *testing.T) {
 		t.Fatalf("wrong payload in hashed event: %s\n", pl)
 	}
 }
+
+func TestFilterHashPaths(t *testing.T) {
+	ctx := context.Background()
+	f, err := hash.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "hash", "myhash", hash.Config{
+		FromPaths:     []string{".foo", ".obj"},
+		ToPath:        "metadata.checksum",
+		HashAlgorithm: "sha256",
+		Encoding:      "hex",
+	}, nil)
+	if err != nil {
+		t.Fatalf("hash test failed: %s\n", err.Error())
+	}
+	eventStr := `{ "foo": "bar", "obj": { "b": 2, "a": 1 }, "ignored": true }`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("hash test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("hash test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of hashed events: %d\n", len(evts))
+	}
+	checksum, _, _ := evts[0].GetPathValue("metadata.checksum")
+	if checksum != "5648be56f19f158573590f4c443effe609e20c2337089cfecf14328340c550a2" {
+		t.Fatalf("wrong checksum in hashed event: %v\n", checksum)
+	}
+	_, err = hash.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "hash", "myhash", hash.Config{
+		FromPaths: []string{".foo"},
+		FromPath:  ".foo",
+		ToPath:    ".hash",
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for fromPaths combined with fromPath\n")
+	}
+	_, err = hash.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "hash", "myhash", hash.Config{
+		FromPath:      ".foo",
+		HashAlgorithm: "crc32",
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for unsupported algorithm\n")
+	}
+}
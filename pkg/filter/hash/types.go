@@ -19,17 +19,19 @@ import "github.com/xmidt-org/ears/pkg/tenant"
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
 type Config struct {
-	FromPath      string `json:"fromPath,omitempty"`
-	From          string `json:"from,omitempty"`
-	ToPath        string `json:"toPath,omitempty"`
-	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
-	Key           string `json:"key,omitempty"`      // optional key for certain hash algorithms
-	Encoding      string `json:"encoding,omitempty"` // optional encoding of hash, base64, hex etc.
+	FromPath      string   `json:"fromPath,omitempty"`
+	From          string   `json:"from,omitempty"`
+	FromPaths     []string `json:"fromPaths,omitempty"` // optional list of paths to hash together, values are hashed as json array
+	ToPath        string   `json:"toPath,omitempty"`
+	HashAlgorithm string   `json:"hashAlgorithm,omitempty"`
+	Key           string   `json:"key,omitempty"`      // optional key for certain hash algorithms
+	Encoding      string   `json:"encoding,omitempty"` // optional encoding of hash, base64, hex etc.
 }
 
 var DefaultConfig = Config{
 	FromPath:      "",
 	From:          "",
+	FromPaths:     nil,
 	ToPath:        "",
 	HashAlgorithm: "md5",
 	Key:           "",
@@ -0,0 +1,139 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	f.hostname, _ = os.Hostname()
+	return f, nil
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	err := evt.DeepCopy()
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "inject").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	// inject in a stable order so that overlapping paths behave predictably
+	paths := make([]string, 0, len(f.config.Values))
+	for path := range f.config.Values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	now := time.Now()
+	for _, path := range paths {
+		if !*f.config.Overwrite {
+			if v, _, _ := evt.GetPathValue(path); v != nil {
+				continue
+			}
+		}
+		_, _, err = evt.SetPathValue(path, f.value(evt, f.config.Values[path], now), true)
+		if err != nil {
+			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "inject").Str("name", f.Name()).Str("path", path).Msg(err.Error())
+			if span := trace.SpanFromContext(evt.Context()); span != nil {
+				span.AddEvent(err.Error())
+			}
+			evt.Ack()
+			return []event.Event{}
+		}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "inject").Str("name", f.Name()).Msg("inject")
+	return []event.Event{evt}
+}
+
+// value produces the value of the given kind for an event, values that are unknown become empty strings
+func (f *Filter) value(evt event.Event, kind string, now time.Time) interface{} {
+	switch kind {
+	case ValueUuid:
+		return uuid.New().String()
+	case ValueTimestamp:
+		return now.UnixNano() / int64(time.Millisecond)
+	case ValueTime:
+		return now.UTC().Format(time.RFC3339Nano)
+	case ValueHostname:
+		return f.hostname
+	case ValueRouteId:
+		return route.IdFromContext(evt.Context())
+	case ValueTenantId:
+		tid := evt.Tenant()
+		return map[string]interface{}{"orgId": tid.OrgId, "appId": tid.AppId}
+	case ValueOrgId:
+		return evt.Tenant().OrgId
+	case ValueAppId:
+		return evt.Tenant().AppId
+	}
+	return ""
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/inject"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
+
+func TestFilterInject(t *testing.T) {
+	ctx := route.NewContext(context.Background(), "myroute")
+	tid := tenant.Id{AppId: "myapp", OrgId: "myorg"}
+	f, err := inject.NewFilter(tid, "inject", "myinject", inject.Config{
+		Values: map[string]string{
+			".id":               inject.ValueUuid,
+			".ts":               inject.ValueTimestamp,
+			".host":             inject.ValueHostname,
+			"metadata.route":    inject.ValueRouteId,
+			"metadata.tenant":   inject.ValueTenantId,
+			".provenance.orgId": inject.ValueOrgId,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("inject test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.WithTenant(tid), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("inject test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of injected events: %d\n", len(evts))
+	}
+	id, _, _ := evts[0].GetPathValue(".id")
+	if _, err := uuid.Parse(id.(string)); err != nil {
+		t.Fatalf("invalid uuid %v: %s\n", id, err.Error())
+	}
+	ts, _, _ := evts[0].GetPathValue(".ts")
+	if ts.(int64) <= 0 {
+		t.Fatalf("invalid timestamp %v\n", ts)
+	}
+	hostname, _ := os.Hostname()
+	host, _, _ := evts[0].GetPathValue(".host")
+	if host != hostname {
+		t.Fatalf("wrong hostname %v\n", host)
+	}
+	routeId, _, _ := evts[0].GetPathValue("metadata.route")
+	if routeId != "myroute" {
+		t.Fatalf("wrong route id %v\n", routeId)
+	}
+	tenantObj, _, _ := evts[0].GetPathValue("metadata.tenant")
+	if !reflect.DeepEqual(tenantObj, map[string]interface{}{"orgId": "myorg", "appId": "myapp"}) {
+		t.Fatalf("wrong tenant %v\n", tenantObj)
+	}
+	orgId, _, _ := evts[0].GetPathValue(".provenance.orgId")
+	if orgId != "myorg" {
+		t.Fatalf("wrong org id %v\n", orgId)
+	}
+	// existing values are kept if overwrite is disabled
+	f, err = inject.NewFilter(tid, "inject", "myinject", inject.Config{
+		Values:    map[string]string{".id": inject.ValueUuid},
+		Overwrite: pointer.Bool(false),
+	}, nil)
+	if err != nil {
+		t.Fatalf("inject test failed: %s\n", err.Error())
+	}
+	e, err = event.New(ctx, map[string]interface{}{"id": "abc"}, event.WithTenant(tid), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("inject test failed: %s\n", err.Error())
+	}
+	evts = f.Filter(e)
+	if len(evts) != 1 || !reflect.DeepEqual(evts[0].Payload(), map[string]interface{}{"id": "abc"}) {
+		t.Fatalf("existing value should not be overwritten\n")
+	}
+	_, err = inject.NewFilter(tid, "inject", "myinject", inject.Config{
+		Values: map[string]string{".id": "foo"},
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for unsupported value\n")
+	}
+}
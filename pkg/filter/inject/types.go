@@ -0,0 +1,51 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inject
+
+import (
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
+
+const (
+	ValueUuid      = "uuid"      // random uuid
+	ValueTimestamp = "timestamp" // current time in unix milliseconds
+	ValueTime      = "time"      // current time in RFC3339 format
+	ValueHostname  = "hostname"  // name of the ears host processing the event
+	ValueRouteId   = "routeId"   // id of the route the event is flowing through
+	ValueTenantId  = "tenantId"  // tenant of the event as object with orgId and appId
+	ValueOrgId     = "orgId"
+	ValueAppId     = "appId"
+)
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	Values    map[string]string `json:"values,omitempty"`    // maps target paths to the kind of value to inject
+	Overwrite *bool             `json:"overwrite,omitempty"` // if false existing values are left unchanged
+}
+
+var DefaultConfig = Config{
+	Values:    map[string]string{},
+	Overwrite: pointer.Bool(true),
+}
+
+type Filter struct {
+	config   Config
+	name     string
+	plugin   string
+	tid      tenant.Id
+	hostname string
+}
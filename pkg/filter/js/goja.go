@@ -439,7 +439,7 @@ func (interpreter *Interpreter) Exec(evt event.Event, code string) ([]event.Even
 		return nil, nil
 	case []interface{}:
 		events := make([]event.Event, 0)
-		for _, r := range x {
+		for idx, r := range x {
 			m, is := r.(map[string]interface{})
 			if !is {
 				return nil, errors.New("array element is not map")
@@ -457,6 +457,13 @@ func (interpreter *Interpreter) Exec(evt event.Event, code string) ([]event.Even
 			if err != nil {
 				return nil, err
 			}
+			err = event.SetLineage(nevt, map[string]interface{}{
+				event.LineageParentId:   evt.Id(),
+				event.LineageSplitIndex: idx,
+			})
+			if err != nil {
+				return nil, err
+			}
 			events = append(events, nevt)
 		}
 		return events, nil
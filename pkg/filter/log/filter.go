@@ -100,3 +100,8 @@ func (f *Filter) Plugin() string {
 func (f *Filter) Tenant() tenant.Id {
 	return f.tid
 }
+
+// SupportsBinaryPayload returns true, binary payloads are logged as base64
+func (f *Filter) SupportsBinaryPayload() bool {
+	return true
+}
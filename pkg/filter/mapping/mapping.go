@@ -15,6 +15,7 @@
 package mapping
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/boriwo/deepcopy"
 	"github.com/rs/zerolog/log"
@@ -118,6 +119,17 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 				mapped = true
 			}
 		}
+		if !mapped && f.config.Table != nil {
+			if to, ok := f.config.Table[tableKey(obj)]; ok {
+				if isArray {
+					currEvent.SetPathValue(f.config.Path, deepcopy.DeepCopy(to), true)
+					evt.SetPathValue(f.config.ArrayPath+fmt.Sprintf("[%d]", idx), currEvent.Payload(), true)
+				} else {
+					evt.SetPathValue(f.config.Path, deepcopy.DeepCopy(to), true)
+				}
+				mapped = true
+			}
+		}
 		if !mapped && f.config.DefaultValue != nil {
 			defVal := f.config.DefaultValue
 			switch defStr := f.config.DefaultValue.(type) {
@@ -139,6 +151,22 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	return []event.Event{evt}
 }
 
+// tableKey returns the lookup table key for a value, strings are used as is and
+// all other values in their json encoding, e.g. 404 or true
+func tableKey(obj interface{}) string {
+	switch obj := obj.(type) {
+	case string:
+		return obj
+	case nil:
+		return ""
+	}
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
 func (f *Filter) compare(evt event.Event, cmp *Comparison) bool {
 	if evt == nil || cmp == nil {
 		return true
@@ -131,3 +131,49 @@ func TestFilterMappingArray(t *testing.T) {
 		t.Fatalf("wrong payload in mapped event: %v\n", evts[0])
 	}
 }
+
+func TestFilterMappingTable(t *testing.T) {
+	ctx := context.Background()
+	f, err := mapping.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "mapping", "mymapping", mapping.Config{
+		Path: ".code",
+		Table: map[string]interface{}{
+			"E01": "device offline",
+			"404": "not found",
+		},
+		DefaultValue: "unknown",
+	}, nil)
+	if err != nil {
+		t.Fatalf("mapping test failed: %s\n", err.Error())
+	}
+	testCases := []struct {
+		payload  string
+		expected string
+	}{
+		{payload: `{"code":"E01"}`, expected: `{"code":"device offline"}`},
+		{payload: `{"code":404}`, expected: `{"code":"not found"}`},
+		{payload: `{"code":"E99"}`, expected: `{"code":"unknown"}`},
+	}
+	for _, tc := range testCases {
+		var obj interface{}
+		err = json.Unmarshal([]byte(tc.payload), &obj)
+		if err != nil {
+			t.Fatalf("mapping test failed: %s\n", err.Error())
+		}
+		e, err := event.New(ctx, obj, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("mapping test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of mapped events: %d\n", len(evts))
+		}
+		var res interface{}
+		err = json.Unmarshal([]byte(tc.expected), &res)
+		if err != nil {
+			t.Fatalf("mapping test failed: %s\n", err.Error())
+		}
+		if !reflect.DeepEqual(evts[0].Payload(), res) {
+			t.Fatalf("wrong payload in mapped event: %v\n", evts[0].Payload())
+		}
+	}
+}
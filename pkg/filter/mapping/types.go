@@ -19,10 +19,11 @@ import "github.com/xmidt-org/ears/pkg/tenant"
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
 type Config struct {
-	Map          []FromTo    `json:"map,omitempty"`
-	Path         string      `json:"path,omitempty"`
-	ArrayPath    string      `json:"arrayPath,omitempty"` // if arrayPath points to array, iterate over all elements and apply from and to paths relatively
-	DefaultValue interface{} `json:"defaultValue,omitempty"`
+	Map          []FromTo               `json:"map,omitempty"`
+	Table        map[string]interface{} `json:"table,omitempty"` // lookup table keyed by the string representation of the value at path, applied if no map entry matches
+	Path         string                 `json:"path,omitempty"`
+	ArrayPath    string                 `json:"arrayPath,omitempty"` // if arrayPath points to array, iterate over all elements and apply from and to paths relatively
+	DefaultValue interface{}            `json:"defaultValue,omitempty"`
 }
 
 type FromTo struct {
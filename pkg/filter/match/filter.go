@@ -25,6 +25,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/filter/match/regex"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Ensure supporting matchers implement Matcher interface
@@ -77,12 +78,22 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 			Err: fmt.Errorf("unsupported matcher type: %s", cfg.Matcher.String()),
 		}
 	}
+	var metadataMatcher Matcher
+	if cfg.MetadataPattern != nil {
+		metadataMatcher, err = patternregex.NewMatcher(cfg.MetadataPattern, nil, "", *cfg.ExactArrayMatch, event.METADATA)
+		if err != nil {
+			return nil, &filter.InvalidConfigError{
+				Err: err,
+			}
+		}
+	}
 	f := &Filter{
-		config:  *cfg,
-		name:    name,
-		plugin:  plugin,
-		tid:     tid,
-		matcher: matcher,
+		config:          *cfg,
+		name:            name,
+		plugin:          plugin,
+		tid:             tid,
+		matcher:         matcher,
+		metadataMatcher: metadataMatcher,
 	}
 	return f, nil
 }
@@ -96,11 +107,7 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	}
 	// passes if event matches
 	events := []event.Event{}
-	pass := f.matcher.Match(evt)
-	if f.config.Mode == ModeDeny {
-		pass = !pass
-	}
-	if pass {
+	if f.Matches(evt) {
 		events = []event.Event{evt}
 	} else {
 		evt.Ack()
@@ -109,6 +116,30 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	return events
 }
 
+// Matches returns true if the event passes the filter without acknowledging the event, this allows
+// other filters to reuse the match configuration
+func (f *Filter) Matches(evt event.Event) bool {
+	pass := f.matcher.Match(evt) && f.matchTransport(evt)
+	if f.config.Mode == ModeDeny {
+		pass = !pass
+	}
+	return pass
+}
+
+// matchTransport checks the optional metadata pattern and trace presence conditions
+func (f *Filter) matchTransport(evt event.Event) bool {
+	if f.metadataMatcher != nil && !f.metadataMatcher.Match(evt) {
+		return false
+	}
+	if f.config.TracePresent != nil {
+		hasTrace := trace.SpanFromContext(evt.Context()).SpanContext().HasTraceID()
+		if hasTrace != *f.config.TracePresent {
+			return false
+		}
+	}
+	return true
+}
+
 func (f *Filter) Config() interface{} {
 	if f == nil {
 		return Config{}
@@ -21,6 +21,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/filter/match"
 	"github.com/xmidt-org/ears/pkg/filter/match/comparison"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
 	"reflect"
 	"testing"
 )
@@ -236,3 +237,49 @@ func TestFilterMatchComparison3(t *testing.T) {
 		t.Fatalf("wrong payload in compared event: %s\n", pl)
 	}
 }
+
+func TestFilterMatchMetadata(t *testing.T) {
+	ctx := context.Background()
+	f, err := match.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "match", "mymatch", match.Config{
+		Matcher:         match.MatcherPattern,
+		Pattern:         map[string]interface{}{"foo": "bar"},
+		MetadataPattern: map[string]interface{}{"http": map[string]interface{}{"path": "^/ears/.*$"}},
+		Mode:            match.ModeAllow,
+	}, nil)
+	if err != nil {
+		t.Fatalf("metadata match test failed: %s\n", err.Error())
+	}
+	testCases := []struct {
+		path     string
+		payload  map[string]interface{}
+		expected int
+	}{
+		{path: "/ears/foo", payload: map[string]interface{}{"foo": "bar"}, expected: 1},
+		{path: "/other/foo", payload: map[string]interface{}{"foo": "bar"}, expected: 0},
+		{path: "/ears/foo", payload: map[string]interface{}{"foo": "baz"}, expected: 0},
+	}
+	for idx, tc := range testCases {
+		e, err := event.New(ctx, tc.payload, event.WithMetadataKeyValue("http", map[string]interface{}{"path": tc.path}), event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("metadata match test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != tc.expected {
+			t.Fatalf("wrong number of matched events for event %d: %d\n", idx, len(evts))
+		}
+	}
+	f, err = match.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "match", "mymatch", match.Config{
+		TracePresent: pointer.Bool(true),
+	}, nil)
+	if err != nil {
+		t.Fatalf("metadata match test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("metadata match test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 0 {
+		t.Fatalf("event without trace id matched: %d\n", len(evts))
+	}
+}
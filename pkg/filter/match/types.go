@@ -59,6 +59,8 @@ type Config struct {
 	ExactArrayMatch *bool                          `json:"exactArrayMatch,omitempty"` // if true pattern array must match payload array exactly, otherwise the pattern array can be a partial of the payload array
 	Comparison      *comparison.Comparison         `json:"comparison,omitempty"`      // equal or notEqual comparison
 	ComparisonTree  *comparison.ComparisonTreeNode `json:"comparisonTree,omitempty"`  // boolean tree of comparisons
+	MetadataPattern interface{}                    `json:"metadataPattern,omitempty"` // pattern with optional regex values the event metadata must match in addition to the matcher
+	TracePresent    *bool                          `json:"tracePresent,omitempty"`    // if set, the event must (true) or must not (false) carry a trace id in addition to the matcher
 }
 
 var DefaultConfig = Config{
@@ -69,9 +71,10 @@ var DefaultConfig = Config{
 }
 
 type Filter struct {
-	matcher Matcher
-	config  Config
-	name    string
-	plugin  string
-	tid     tenant.Id
+	matcher         Matcher
+	metadataMatcher Matcher
+	config          Config
+	name            string
+	plugin          string
+	tid             tenant.Id
 }
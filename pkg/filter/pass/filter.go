@@ -69,3 +69,8 @@ func (f *Filter) Plugin() string {
 func (f *Filter) Tenant() tenant.Id {
 	return f.tid
 }
+
+// SupportsBinaryPayload returns true since events are passed on without looking at the payload
+func (f *Filter) SupportsBinaryPayload() bool {
+	return true
+}
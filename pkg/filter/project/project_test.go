@@ -0,0 +1,66 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/project"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterProject(t *testing.T) {
+	ctx := context.Background()
+	f, err := project.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "project", "myproject", project.Config{
+		Paths: []string{".device.id", "payload.status", ".missing"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("project test failed: %s\n", err.Error())
+	}
+	eventStr := `{"device":{"id":"abc","owner":"jane@example.com"}, "status":"ok", "location":{"lat":1,"lon":2}}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("project test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("project test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of projected events: %d\n", len(evts))
+	}
+	expectedEventStr := `{"device":{"id":"abc"}, "status":"ok"}`
+	var res interface{}
+	err = json.Unmarshal([]byte(expectedEventStr), &res)
+	if err != nil {
+		t.Fatalf("project test failed: %s\n", err.Error())
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), res) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in projected event: %s\n", pl)
+	}
+	_, err = project.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "project", "myproject", project.Config{
+		Paths: []string{"metadata.foo"},
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for metadata path\n")
+	}
+}
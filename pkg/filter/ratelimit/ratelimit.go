@@ -0,0 +1,136 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	pkgratelimit "github.com/xmidt-org/ears/pkg/ratelimit"
+	redisratelimit "github.com/xmidt-org/ears/pkg/ratelimit/redis"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ filter.Stopper = (*Filter)(nil)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	password := ""
+	if cfg.Password != "" && secrets != nil {
+		password = secrets.Secret(cfg.Password)
+	}
+	f.client = redis.NewClient(&redis.Options{
+		Addr:     cfg.Endpoint,
+		Password: password,
+		DB:       *cfg.DB,
+	})
+	prefix := "ears.ratelimit." + tid.OrgId + "." + tid.AppId + "." + name + "."
+	f.limiter = redisratelimit.NewRedisKeyRateLimiter(f.client, prefix, *cfg.Limit, time.Duration(*cfg.Period)*time.Second)
+	return f, nil
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	keyObj, _, _ := evt.Evaluate(f.config.Key)
+	key := ""
+	if keyObj != nil {
+		key = fmt.Sprint(keyObj)
+	}
+	if key == "" {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "ratelimit").Str("name", f.Name()).Msg("cannot build key from " + f.config.Key)
+		return []event.Event{evt}
+	}
+	err := f.limiter.Take(evt.Context(), key, 1)
+	if err == nil {
+		return []event.Event{evt}
+	}
+	var limitReached *pkgratelimit.LimitReached
+	if !errors.As(err, &limitReached) {
+		// fail open so that events are not lost while redis is unavailable
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "ratelimit").Str("name", f.Name()).Str("key", key).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		return []event.Event{evt}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "ratelimit").Str("name", f.Name()).Str("key", key).Msg("rate limit reached")
+	if span := trace.SpanFromContext(evt.Context()); span != nil {
+		span.AddEvent("rate limit reached for " + key)
+	}
+	if f.config.OnLimit == OnLimitNack {
+		evt.Nack(err)
+	} else {
+		evt.Ack()
+	}
+	return []event.Event{}
+}
+
+// StopFiltering closes the redis client of the filter
+func (f *Filter) StopFiltering(ctx context.Context) {
+	f.client.Close()
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
+
+// SupportsBinaryPayload returns true since the rate limit does not depend on the payload
+func (f *Filter) SupportsBinaryPayload() bool {
+	return true
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/ratelimit"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
+
+// events pass through while redis is unreachable
+func TestFilterRateLimitFailOpen(t *testing.T) {
+	ctx := context.Background()
+	f, err := ratelimit.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ratelimit", "myratelimit", ratelimit.Config{
+		Endpoint: "127.0.0.1:1",
+		Key:      "{.deviceId}",
+		Limit:    pointer.Int(1),
+	}, nil)
+	if err != nil {
+		t.Fatalf("ratelimit test failed: %s\n", err.Error())
+	}
+	for i := 0; i < 3; i++ {
+		e, err := event.New(ctx, map[string]interface{}{"deviceId": "abc"}, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("ratelimit test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of events: %d\n", len(evts))
+		}
+	}
+}
+
+func TestFilterRateLimitConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config ratelimit.Config
+	}{
+		{name: "missingKey", config: ratelimit.Config{Limit: pointer.Int(10)}},
+		{name: "missingLimit", config: ratelimit.Config{Key: "{.deviceId}"}},
+		{name: "badPeriod", config: ratelimit.Config{Key: "{.deviceId}", Limit: pointer.Int(10), Period: pointer.Int(0)}},
+		{name: "badOnLimit", config: ratelimit.Config{Key: "{.deviceId}", Limit: pointer.Int(10), OnLimit: "block"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ratelimit.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ratelimit", "myratelimit", tc.config, nil)
+			if err == nil {
+				t.Fatalf("expected config error\n")
+			}
+		})
+	}
+}
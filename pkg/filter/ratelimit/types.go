@@ -0,0 +1,58 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"github.com/go-redis/redis/v8"
+	redisratelimit "github.com/xmidt-org/ears/pkg/ratelimit/redis"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
+
+const (
+	OnLimitDrop = "drop"
+	OnLimitNack = "nack"
+)
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Password string `json:"password,omitempty"` // optional secret reference
+	DB       *int   `json:"db,omitempty"`
+	Key      string `json:"key,omitempty"`     // key expression, e.g. {.deviceId}
+	Limit    *int   `json:"limit,omitempty"`   // max number of events per key and period
+	Period   *int   `json:"period,omitempty"`  // period in seconds
+	OnLimit  string `json:"onLimit,omitempty"` // drop or nack events exceeding the limit
+}
+
+var DefaultConfig = Config{
+	Endpoint: "localhost:6379",
+	Password: "",
+	DB:       pointer.Int(0),
+	Key:      "",
+	Limit:    pointer.Int(0),
+	Period:   pointer.Int(1),
+	OnLimit:  OnLimitDrop,
+}
+
+type Filter struct {
+	config  Config
+	name    string
+	plugin  string
+	tid     tenant.Id
+	client  *redis.Client
+	limiter *redisratelimit.RedisKeyRateLimiter
+}
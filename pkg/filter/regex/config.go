@@ -15,6 +15,8 @@
 package regex
 
 import (
+	"errors"
+
 	"github.com/xmidt-org/ears/pkg/config"
 	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/errs"
@@ -43,10 +45,16 @@ func (c Config) WithDefaults() *Config {
 	if c.Regex == "" {
 		cfg.Regex = DefaultConfig.Regex
 	}
+	if c.Capture == nil {
+		cfg.Capture = DefaultConfig.Capture
+	}
 	return &cfg
 }
 
 func (c *Config) Validate() error {
+	if *c.Capture && c.ReplaceAllString != nil {
+		return errors.New("capture and replaceAllString are mutually exclusive")
+	}
 	return nil
 }
 
@@ -91,8 +91,21 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		evt.Ack()
 		return []event.Event{}
 	}
-	var output string
-	if f.config.ReplaceAllString != nil {
+	var output interface{}
+	if *f.config.Capture {
+		match := r.FindStringSubmatch(objAsStr)
+		if match == nil {
+			log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "regex").Str("name", f.Name()).Msg("no match at " + f.config.FromPath)
+			return []event.Event{evt}
+		}
+		captures := make(map[string]interface{})
+		for idx, groupName := range r.SubexpNames() {
+			if idx > 0 && groupName != "" {
+				captures[groupName] = match[idx]
+			}
+		}
+		output = captures
+	} else if f.config.ReplaceAllString != nil {
 		output = r.ReplaceAllString(objAsStr, *f.config.ReplaceAllString)
 	} else {
 		output = r.FindString(objAsStr)
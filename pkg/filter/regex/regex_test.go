@@ -20,6 +20,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter/regex"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
 	"reflect"
 	"testing"
 )
@@ -59,3 +60,40 @@ func TestFilterHashBasic(t *testing.T) {
 		t.Fatalf("wrong payload in regexhashed event: %s\n", pl)
 	}
 }
+
+func TestFilterRegexCapture(t *testing.T) {
+	ctx := context.Background()
+	f, err := regex.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "regex", "myregex", regex.Config{
+		FromPath: ".line",
+		ToPath:   ".parsed",
+		Regex:    `^(?P<level>[A-Z]+) \[(?P<module>\w+)\] (?P<msg>.*)$`,
+		Capture:  pointer.Bool(true),
+	}, nil)
+	if err != nil {
+		t.Fatalf("regex test failed: %s\n", err.Error())
+	}
+	eventStr := `{ "line": "WARN [receiver] queue depth exceeded"}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("regex test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("regex test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of regex events: %d\n", len(evts))
+	}
+	expectedEventStr := `{"line":"WARN [receiver] queue depth exceeded", "parsed":{"level":"WARN","module":"receiver","msg":"queue depth exceeded"}}`
+	var res interface{}
+	err = json.Unmarshal([]byte(expectedEventStr), &res)
+	if err != nil {
+		t.Fatalf("regex test failed: %s\n", err.Error())
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), res) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in regex event: %s\n", pl)
+	}
+}
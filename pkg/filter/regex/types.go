@@ -14,7 +14,10 @@
 
 package regex
 
-import "github.com/xmidt-org/ears/pkg/tenant"
+import (
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
 
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
@@ -23,12 +26,14 @@ type Config struct {
 	ToPath           string  `json:"toPath,omitempty"`
 	Regex            string  `json:"regex,omitempty"`
 	ReplaceAllString *string `json:"replaceAllString,omitempty"`
+	Capture          *bool   `json:"capture,omitempty"` // if true write named capture groups of the first match as an object to toPath
 }
 
 var DefaultConfig = Config{
 	FromPath: "",
 	ToPath:   "",
 	Regex:    "^.*$",
+	Capture:  pointer.Bool(false),
 }
 
 type Filter struct {
@@ -0,0 +1,70 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rename_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/rename"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterRename(t *testing.T) {
+	ctx := context.Background()
+	f, err := rename.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "rename", "myrename", rename.Config{
+		Map: map[string]string{
+			".a":         ".b",
+			".b":         ".a",
+			".device.id": ".deviceId",
+			".missing":   ".other",
+			".ts":        "metadata.timestamp",
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("rename test failed: %s\n", err.Error())
+	}
+	eventStr := `{"a":1, "b":2, "device":{"id":"abc","model":"x"}, "ts":123}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("rename test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("rename test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of renamed events: %d\n", len(evts))
+	}
+	expectedEventStr := `{"a":2, "b":1, "device":{"model":"x"}, "deviceId":"abc"}`
+	var res interface{}
+	err = json.Unmarshal([]byte(expectedEventStr), &res)
+	if err != nil {
+		t.Fatalf("rename test failed: %s\n", err.Error())
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), res) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in renamed event: %s\n", pl)
+	}
+	ts, _, _ := evts[0].GetPathValue("metadata.timestamp")
+	if ts != float64(123) {
+		t.Fatalf("wrong metadata in renamed event: %v\n", evts[0].Metadata())
+	}
+}
@@ -15,6 +15,8 @@
 package sample
 
 import (
+	"errors"
+
 	"github.com/xmidt-org/ears/pkg/config"
 	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/errs"
@@ -41,6 +43,9 @@ func (c Config) WithDefaults() *Config {
 }
 
 func (c *Config) Validate() error {
+	if *c.Percentage < 0 || *c.Percentage > 1 {
+		return errors.New("percentage must be between 0 and 1")
+	}
 	return nil
 }
 
@@ -15,12 +15,15 @@
 package sample
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"math"
 	"math/rand"
 )
 
@@ -53,6 +56,12 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		return nil
 	}
 	dice := rand.Float64()
+	if f.config.KeyPath != "" {
+		// events with the same key are either all sampled or all dropped, in every route using the same percentage
+		if key, _, _ := evt.GetPathValue(f.config.KeyPath); key != nil {
+			dice = consistentDice(fmt.Sprint(key))
+		}
+	}
 	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "sample").Str("name", f.Name()).Msg("sample")
 	if dice <= *f.config.Percentage {
 		return []event.Event{evt}
@@ -60,6 +69,13 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	evt.Ack()
 	return []event.Event{}
 }
+
+// consistentDice maps a key to a number in [0,1)
+func consistentDice(key string) float64 {
+	h := sha256.Sum256([]byte(key))
+	return float64(binary.BigEndian.Uint64(h[:8])) / (math.MaxUint64 + 1.0)
+}
+
 func (f *Filter) Config() interface{} {
 	if f == nil {
 		return Config{}
@@ -78,3 +94,8 @@ func (f *Filter) Plugin() string {
 func (f *Filter) Tenant() tenant.Id {
 	return f.tid
 }
+
+// SupportsBinaryPayload returns true, with a binary payload only metadata key paths can be used
+func (f *Filter) SupportsBinaryPayload() bool {
+	return true
+}
@@ -17,6 +17,7 @@ package sample_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter/sample"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -51,3 +52,49 @@ func TestFilterDecodeBasic(t *testing.T) {
 		t.Fatalf("unexpected number of sampled events: %d\n", evtCnt)
 	}
 }
+
+func TestFilterSampleConsistent(t *testing.T) {
+	ctx := context.Background()
+	f1, err := sample.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "sample", "mysample1", sample.Config{
+		Percentage: pointer.Float64(0.1),
+		KeyPath:    ".id",
+	}, nil)
+	if err != nil {
+		t.Fatalf("sample test failed: %s\n", err.Error())
+	}
+	f2, err := sample.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "sample", "mysample2", sample.Config{
+		Percentage: pointer.Float64(0.1),
+		KeyPath:    ".id",
+	}, nil)
+	if err != nil {
+		t.Fatalf("sample test failed: %s\n", err.Error())
+	}
+	evtCnt := 0
+	for i := 0; i < 1000; i++ {
+		e, err := event.New(ctx, map[string]interface{}{"id": fmt.Sprintf("device-%d", i)}, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("sample test failed: %s\n", err.Error())
+		}
+		evts1 := f1.Filter(e)
+		evts2 := f2.Filter(e)
+		if len(evts1) != len(evts2) {
+			t.Fatalf("inconsistent sampling for device-%d\n", i)
+		}
+		// the same key must always get the same decision
+		for j := 0; j < 3; j++ {
+			if len(f1.Filter(e)) != len(evts1) {
+				t.Fatalf("inconsistent sampling for device-%d\n", i)
+			}
+		}
+		evtCnt += len(evts1)
+	}
+	if evtCnt < 60 || evtCnt > 140 {
+		t.Fatalf("unexpected number of sampled events: %d\n", evtCnt)
+	}
+	_, err = sample.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "sample", "mysample", sample.Config{
+		Percentage: pointer.Float64(10),
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for invalid percentage\n")
+	}
+}
@@ -23,10 +23,12 @@ import (
 // the behavior of the sender.
 type Config struct {
 	Percentage *float64 `json:"percentage,omitempty"`
+	KeyPath    string   `json:"keyPath,omitempty"` // optional path to sample consistently by, e.g. trace.id or .deviceId
 }
 
 var DefaultConfig = Config{
 	Percentage: pointer.Float64(1.0),
+	KeyPath:    "",
 }
 
 type Filter struct {
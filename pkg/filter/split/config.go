@@ -15,6 +15,8 @@
 package split
 
 import (
+	"errors"
+
 	"github.com/xmidt-org/ears/pkg/config"
 	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/errs"
@@ -37,10 +39,28 @@ func (c Config) WithDefaults() *Config {
 	if c.Path == "" {
 		cfg.Path = DefaultConfig.Path
 	}
+	if c.ParentFields == nil {
+		cfg.ParentFields = DefaultConfig.ParentFields
+	}
+	if c.Sequence == nil {
+		cfg.Sequence = DefaultConfig.Sequence
+	}
+	if c.MaxElements == nil {
+		cfg.MaxElements = DefaultConfig.MaxElements
+	}
+	if c.ChunkSize == nil {
+		cfg.ChunkSize = DefaultConfig.ChunkSize
+	}
 	return &cfg
 }
 
 func (c *Config) Validate() error {
+	if *c.MaxElements < 0 {
+		return errors.New("maxElements must not be negative")
+	}
+	if *c.ChunkSize < 1 {
+		return errors.New("chunkSize must be at least 1")
+	}
 	return nil
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/trace"
+	"strings"
 )
 
 // a SplitFilter splits and event into two or more events
@@ -48,7 +49,7 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 	return f, nil
 }
 
-// Filter splits an event containing an array into multiple events
+// Filter splits an event containing an array (or a delimited string) into multiple events
 func (f *Filter) Filter(evt event.Event) []event.Event {
 	if f == nil {
 		evt.Nack(&filter.InvalidConfigError{
@@ -66,6 +67,9 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		return []event.Event{}
 	}
 	arr, ok := obj.([]interface{})
+	if str, isStr := obj.(string); isStr && f.config.Delimiter != "" {
+		arr, ok = f.splitString(str), true
+	}
 	if !ok {
 		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "split").Str("name", f.Name()).Msg("split on non array type at " + f.config.Path)
 		if span := trace.SpanFromContext(evt.Context()); span != nil {
@@ -74,7 +78,27 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		evt.Ack()
 		return []event.Event{}
 	}
-	for _, p := range arr {
+	if *f.config.MaxElements > 0 && len(arr) > *f.config.MaxElements {
+		log.Ctx(evt.Context()).Warn().Str("op", "filter").Str("filterType", "split").Str("name", f.Name()).Int("elementCount", len(arr)).Int("maxElements", *f.config.MaxElements).Msg("dropping elements beyond max elements")
+		arr = arr[:*f.config.MaxElements]
+	}
+	if *f.config.ChunkSize > 1 {
+		arr = f.chunk(arr)
+	}
+	var parent interface{}
+	if f.config.ParentPath != "" {
+		var err error
+		parent, err = f.parentContext(evt)
+		if err != nil {
+			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "split").Str("name", f.Name()).Msg(err.Error())
+			if span := trace.SpanFromContext(evt.Context()); span != nil {
+				span.AddEvent(err.Error())
+			}
+			evt.Ack()
+			return []event.Event{}
+		}
+	}
+	for idx, p := range arr {
 		nevt, err := evt.Clone(evt.Context())
 		if err != nil {
 			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "split").Str("name", f.Name()).Msg(err.Error())
@@ -89,6 +113,15 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		if err == nil {
 			nevt.SetMetadata(deepcopy.DeepCopy(evt.Metadata()).(map[string]interface{}))
 		}
+		if err == nil {
+			err = event.SetLineage(nevt, map[string]interface{}{
+				event.LineageParentId:   evt.Id(),
+				event.LineageSplitIndex: idx,
+			})
+		}
+		if err == nil {
+			err = f.addContext(evt, nevt, parent, idx, len(arr))
+		}
 		if err != nil {
 			log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "split").Str("name", f.Name()).Msg(err.Error())
 			if span := trace.SpanFromContext(evt.Context()); span != nil {
@@ -104,6 +137,78 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	return events
 }
 
+// parentContext returns a copy of the parent payload with the split array removed
+func (f *Filter) parentContext(evt event.Event) (interface{}, error) {
+	pevt, err := event.New(evt.Context(), deepcopy.DeepCopy(evt.Payload()))
+	if err != nil {
+		return nil, err
+	}
+	_, parent, key := pevt.GetPathValue(f.config.Path)
+	if parentMap, ok := parent.(map[string]interface{}); ok {
+		delete(parentMap, key)
+	}
+	return pevt.Payload(), nil
+}
+
+// addContext adds the selected parent fields, the parent payload and the sequence metadata to a child event
+func (f *Filter) addContext(evt event.Event, nevt event.Event, parent interface{}, idx int, total int) error {
+	if len(f.config.ParentFields) > 0 || parent != nil {
+		// the child payload is shared with the parent payload so we must copy it before adding to it
+		err := nevt.SetPayload(deepcopy.DeepCopy(nevt.Payload()))
+		if err != nil {
+			return err
+		}
+	}
+	for _, path := range f.config.ParentFields {
+		val, _, _ := evt.GetPathValue(path)
+		if val == nil {
+			continue
+		}
+		_, _, err := nevt.SetPathValue(path, deepcopy.DeepCopy(val), true)
+		if err != nil {
+			return err
+		}
+	}
+	if parent != nil {
+		_, _, err := nevt.SetPathValue(f.config.ParentPath, deepcopy.DeepCopy(parent), true)
+		if err != nil {
+			return err
+		}
+	}
+	if *f.config.Sequence {
+		_, _, err := nevt.SetPathValue(event.METADATA+".split", map[string]interface{}{"index": idx, "total": total}, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunk groups elements into arrays of up to chunk size elements
+func (f *Filter) chunk(arr []interface{}) []interface{} {
+	chunks := make([]interface{}, 0, (len(arr)+*f.config.ChunkSize-1) / *f.config.ChunkSize)
+	for start := 0; start < len(arr); start += *f.config.ChunkSize {
+		end := start + *f.config.ChunkSize
+		if end > len(arr) {
+			end = len(arr)
+		}
+		chunks = append(chunks, arr[start:end])
+	}
+	return chunks
+}
+
+// splitString splits a string by the configured delimiter, empty elements (e.g. from a trailing newline) are skipped
+func (f *Filter) splitString(str string) []interface{} {
+	arr := []interface{}{}
+	for _, elem := range strings.Split(str, f.config.Delimiter) {
+		if elem == "" {
+			continue
+		}
+		arr = append(arr, elem)
+	}
+	return arr
+}
+
 func (f *Filter) Config() interface{} {
 	if f == nil {
 		return Config{}
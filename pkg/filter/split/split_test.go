@@ -20,6 +20,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter/split"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
 	"reflect"
 	"testing"
 )
@@ -61,3 +62,145 @@ func TestFilterSplitBasic(t *testing.T) {
 		t.Fatalf("wrong payload in splitted event: %s\n", pl)
 	}
 }
+
+func TestFilterSplitDelimiter(t *testing.T) {
+	ctx := context.Background()
+	f, err := split.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "split", "mysplit", split.Config{
+		Path:      ".logs",
+		Delimiter: "\n",
+	}, nil)
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"logs": "line one\nline two\n"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 2 {
+		t.Fatalf("wrong number of splitted events: %d\n", len(evts))
+	}
+	expected := []string{"line one", "line two"}
+	for idx, exp := range expected {
+		if !reflect.DeepEqual(evts[idx].Payload(), exp) {
+			t.Fatalf("wrong payload in splitted event: %v\n", evts[idx].Payload())
+		}
+	}
+}
+
+func TestFilterSplitParentContext(t *testing.T) {
+	ctx := context.Background()
+	f, err := split.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "split", "mysplit", split.Config{
+		Path:         ".batch.items",
+		ParentFields: []string{".batch.deviceId"},
+		ParentPath:   ".parent",
+		Sequence:     pointer.Bool(true),
+	}, nil)
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	eventStr := `{"batch":{"deviceId":"abc","items":[{"foo":"bar"},{"foo":"baz"}]}}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 2 {
+		t.Fatalf("wrong number of splitted events: %d\n", len(evts))
+	}
+	expected := []string{
+		`{"foo":"bar","batch":{"deviceId":"abc"},"parent":{"batch":{"deviceId":"abc"}}}`,
+		`{"foo":"baz","batch":{"deviceId":"abc"},"parent":{"batch":{"deviceId":"abc"}}}`,
+	}
+	for idx, expectedEventStr := range expected {
+		var res interface{}
+		err = json.Unmarshal([]byte(expectedEventStr), &res)
+		if err != nil {
+			t.Fatalf("split test failed: %s\n", err.Error())
+		}
+		if !reflect.DeepEqual(evts[idx].Payload(), res) {
+			pl, _ := json.MarshalIndent(evts[idx].Payload(), "", "\t")
+			t.Fatalf("wrong payload in splitted event: %s\n", pl)
+		}
+		seq := map[string]interface{}{"index": idx, "total": 2}
+		if !reflect.DeepEqual(evts[idx].Metadata()["split"], seq) {
+			t.Fatalf("wrong sequence metadata in splitted event: %v\n", evts[idx].Metadata())
+		}
+	}
+	// the original payload must not be modified
+	items, _, _ := e.GetPathValue(".batch.items")
+	if len(items.([]interface{})) != 2 || len(items.([]interface{})[0].(map[string]interface{})) != 1 {
+		t.Fatalf("original payload modified: %v\n", e.Payload())
+	}
+}
+
+func TestFilterSplitChunks(t *testing.T) {
+	ctx := context.Background()
+	f, err := split.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "split", "mysplit", split.Config{
+		Path:        ".",
+		MaxElements: pointer.Int(5),
+		ChunkSize:   pointer.Int(2),
+	}, nil)
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, []interface{}{"a", "b", "c", "d", "e", "f", "g"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	expected := []interface{}{
+		[]interface{}{"a", "b"},
+		[]interface{}{"c", "d"},
+		[]interface{}{"e"},
+	}
+	if len(evts) != len(expected) {
+		t.Fatalf("wrong number of splitted events: %d\n", len(evts))
+	}
+	for idx, res := range expected {
+		if !reflect.DeepEqual(evts[idx].Payload(), res) {
+			t.Fatalf("wrong payload in splitted event: %v\n", evts[idx].Payload())
+		}
+	}
+	_, err = split.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "split", "mysplit", split.Config{
+		ChunkSize: pointer.Int(0),
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for invalid chunk size\n")
+	}
+}
+
+func TestFilterSplitLineage(t *testing.T) {
+	ctx := context.Background()
+	f, err := split.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "split", "mysplit", split.Config{
+		Path: ".foo",
+	}, nil)
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"foo": []interface{}{"a", "b"}}, event.WithId("parent"),
+		event.WithMetadataKeyValue(event.LINEAGE, map[string]interface{}{event.LineageRouteId: "r1", event.LineageHops: 1}),
+		event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("split test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 2 {
+		t.Fatalf("wrong number of splitted events: %d\n", len(evts))
+	}
+	for idx, evt := range evts {
+		lineage := event.Lineage(evt)
+		if lineage[event.LineageParentId] != "parent" || lineage[event.LineageSplitIndex] != idx ||
+			lineage[event.LineageRouteId] != "r1" || event.LineageHopCount(evt) != 1 {
+			t.Fatalf("unexpected lineage of splitted event %d: %+v\n", idx, lineage)
+		}
+	}
+	if _, ok := event.Lineage(e)[event.LineageParentId]; ok {
+		t.Fatalf("lineage of parent event modified: %+v\n", event.Lineage(e))
+	}
+}
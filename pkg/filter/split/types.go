@@ -14,14 +14,29 @@
 
 package split
 
-import "github.com/xmidt-org/ears/pkg/tenant"
+import (
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+)
 
 type Config struct {
-	Path string `json:"path,omitempty"`
+	Path         string   `json:"path,omitempty"`
+	Delimiter    string   `json:"delimiter,omitempty"`    // if set, a string at path is split by this delimiter instead of requiring an array
+	ParentFields []string `json:"parentFields,omitempty"` // paths copied from the parent payload into each child payload
+	ParentPath   string   `json:"parentPath,omitempty"`   // if set, the parent payload without the split array is added to each child at this path
+	Sequence     *bool    `json:"sequence,omitempty"`     // if true, metadata.split.index and metadata.split.total are set on each child
+	MaxElements  *int     `json:"maxElements,omitempty"`  // elements beyond this limit are dropped, 0 means no limit
+	ChunkSize    *int     `json:"chunkSize,omitempty"`    // if greater than 1, each child carries an array of up to this many elements
 }
 
 var DefaultConfig = Config{
-	Path: "",
+	Path:         "",
+	Delimiter:    "",
+	ParentFields: []string{},
+	ParentPath:   "",
+	Sequence:     pointer.Bool(false),
+	MaxElements:  pointer.Int(0),
+	ChunkSize:    pointer.Int(1),
 }
 
 type Filter struct {
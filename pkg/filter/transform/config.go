@@ -15,6 +15,8 @@
 package transform
 
 import (
+	"errors"
+
 	"github.com/xmidt-org/ears/pkg/config"
 	pkgconfig "github.com/xmidt-org/ears/pkg/config"
 	"github.com/xmidt-org/ears/pkg/errs"
@@ -46,6 +48,9 @@ func (c Config) WithDefaults() *Config {
 
 // Validate will validate configs
 func (c *Config) Validate() error {
+	if c.Expression != "" && c.Transformation != nil {
+		return errors.New("expression cannot be combined with transformation")
+	}
 	return nil
 }
 
@@ -17,6 +17,7 @@ package transform
 import (
 	"encoding/json"
 	"fmt"
+	jsonata "github.com/blues/jsonata-go"
 	//"github.com/mohae/deepcopy"
 	//"github.com/gohobby/deepcopy"
 	"github.com/boriwo/deepcopy"
@@ -47,6 +48,14 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 		plugin: plugin,
 		tid:    tid,
 	}
+	if cfg.Expression != "" {
+		f.expr, err = jsonata.Compile(cfg.Expression)
+		if err != nil {
+			return nil, &filter.InvalidConfigError{
+				Err: err,
+			}
+		}
+	}
 	return f, nil
 }
 
@@ -59,7 +68,7 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 		return nil
 	}
 	events := []event.Event{}
-	if f.config.Transformation == nil {
+	if f.config.Transformation == nil && f.expr == nil {
 		events = append(events, evt)
 	} else {
 		err := evt.DeepCopy()
@@ -89,8 +98,21 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 				evt.Ack()
 				return []event.Event{}
 			}
-			thisTransform := deepcopy.DeepCopy(f.config.Transformation)
-			transform(subEvt, thisTransform, nil, "", -1)
+			var thisTransform interface{}
+			if f.expr != nil {
+				thisTransform, err = f.evaluate(subEvt)
+				if err != nil {
+					log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "transform").Str("name", f.Name()).Msg(err.Error())
+					if span := trace.SpanFromContext(evt.Context()); span != nil {
+						span.AddEvent(err.Error())
+					}
+					evt.Ack()
+					return []event.Event{}
+				}
+			} else {
+				thisTransform = deepcopy.DeepCopy(f.config.Transformation)
+				transform(subEvt, thisTransform, nil, "", -1)
+			}
 			if isArray {
 				_, _, err = evt.SetPathValue(fmt.Sprintf("%s[%d]", f.config.ToPath, idx), thisTransform, true)
 			} else {
@@ -111,6 +133,28 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	return events
 }
 
+// evaluate applies the jsonata expression to the payload of an event, undefined results become null
+func (f *Filter) evaluate(evt event.Event) (interface{}, error) {
+	res, err := f.expr.Eval(evt.Payload())
+	if err == jsonata.ErrUndefined {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// normalize result to plain json types
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	err = json.Unmarshal(buf, &obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
 // transform is a helper function to perform a simple transformation
 func transform(evt event.Event, t interface{}, parent interface{}, key string, idx int) {
 	if evt == nil || t == nil {
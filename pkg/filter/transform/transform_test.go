@@ -94,3 +94,44 @@ func TestFilterTransformArray(t *testing.T) {
 		t.Fatalf("wrong payload in transfomred event: %s\n", pl)
 	}
 }
+
+func TestFilterTransformExpression(t *testing.T) {
+	ctx := context.Background()
+	f, err := transform.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "transform", "mytransform", transform.Config{
+		ToPath:     ".",
+		Expression: `{ "account": account.id, "total": $sum(orders.(price * quantity)), "status": total > 100 ? "gold" : "basic" }`,
+	}, nil)
+	if err != nil {
+		t.Fatalf("transform test failed: %s\n", err.Error())
+	}
+	eventStr := `{"account":{"id":"123"},"orders":[{"price":10,"quantity":2},{"price":5,"quantity":4}],"total":150}`
+	var obj interface{}
+	err = json.Unmarshal([]byte(eventStr), &obj)
+	if err != nil {
+		t.Fatalf("transform test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, obj, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("transform test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of transformed events: %d\n", len(evts))
+	}
+	expectedEventStr := `{"account":"123","total":40,"status":"gold"}`
+	var res interface{}
+	err = json.Unmarshal([]byte(expectedEventStr), &res)
+	if err != nil {
+		t.Fatalf("transform test failed: %s\n", err.Error())
+	}
+	if !reflect.DeepEqual(evts[0].Payload(), res) {
+		pl, _ := json.MarshalIndent(evts[0].Payload(), "", "\t")
+		t.Fatalf("wrong payload in transformed event: %s\n", pl)
+	}
+	_, err = transform.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "transform", "mytransform", transform.Config{
+		Expression: `{ "broken": `,
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for invalid expression\n")
+	}
+}
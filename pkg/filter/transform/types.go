@@ -14,12 +14,16 @@
 
 package transform
 
-import "github.com/xmidt-org/ears/pkg/tenant"
+import (
+	jsonata "github.com/blues/jsonata-go"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
 
 // Config can be passed into NewFilter() in order to configure
 // the behavior of the sender.
 type Config struct {
 	Transformation interface{} `json:"transformation,omitempty"`
+	Expression     string      `json:"expression,omitempty"` // optional jsonata expression, alternative to transformation
 	ToPath         string      `json:"toPath,omitempty"`
 	FromPath       string      `json:"fromPath,omitempty"` // optional, if present apply transformation to sub event at path, if sub event is array apply transformation to all elements of array
 }
@@ -27,6 +31,7 @@ type Config struct {
 var empty interface{}
 var DefaultConfig = Config{
 	Transformation: empty,
+	Expression:     "",
 	ToPath:         "",
 }
 
@@ -35,4 +40,5 @@ type Filter struct {
 	name   string
 	plugin string
 	tid    tenant.Id
+	expr   *jsonata.Expr
 }
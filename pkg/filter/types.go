@@ -18,6 +18,8 @@
 package filter
 
 import (
+	"context"
+
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"sync"
@@ -38,6 +40,13 @@ type InvalidArgumentError struct {
 	Err error
 }
 
+// UnsupportedPayloadError is returned when an event with a binary payload
+// reaches a filterer that requires a json payload
+type UnsupportedPayloadError struct {
+	Filter      string
+	ContentType string
+}
+
 // Hasher defines the hashing interface that a receiver
 // needs to implement
 type Hasher interface {
@@ -66,6 +75,29 @@ type Filterer interface {
 	Tenant() tenant.Id
 }
 
+// BinaryFilterer is implemented by filterers that can process events with a
+// binary (non-JSON) payload, all other filterers nack such events with an
+// UnsupportedPayloadError
+type BinaryFilterer interface {
+	SupportsBinaryPayload() bool
+}
+
+// Stopper is implemented by filterers holding resources such as connections,
+// StopFiltering is called once no route uses the filterer anymore
+type Stopper interface {
+	StopFiltering(ctx context.Context)
+}
+
+// FlushFn routes events a filterer emits on its own as if the filterer had returned them from Filter
+type FlushFn func(events []event.Event)
+
+// Flusher is implemented by filterers holding back events that they emit later on their own, such as batches
+// flushed once their time window expires. Callers that can route such events call FilterWithFlush instead of
+// Filter, flush is nil if they cannot.
+type Flusher interface {
+	FilterWithFlush(e event.Event, flush FlushFn) []event.Event
+}
+
 // Chainer
 // TODO: https://github.com/xmidt-org/ears/issues/74
 type Chainer interface {
@@ -77,6 +109,7 @@ type Chainer interface {
 }
 
 var _ Chainer = (*Chain)(nil)
+var _ Flusher = (*Chain)(nil)
 
 type Chain struct {
 	sync.RWMutex
@@ -0,0 +1,137 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package useragent
+
+import (
+	"fmt"
+
+	ua "github.com/mssola/user_agent"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DeviceTypeBot     = "bot"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeDesktop = "desktop"
+)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	return f, nil
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	obj, _, _ := evt.GetPathValue(f.config.FromPath)
+	uaStr, ok := obj.(string)
+	if !ok || uaStr == "" {
+		// events without user agent are passed on unchanged
+		log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "useragent").Str("name", f.Name()).Msg("no user agent at " + f.config.FromPath)
+		return []event.Event{evt}
+	}
+	err := evt.DeepCopy()
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "useragent").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	_, _, err = evt.SetPathValue(f.config.ToPath, parse(uaStr), true)
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "useragent").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "useragent").Str("name", f.Name()).Msg("useragent")
+	return []event.Event{evt}
+}
+
+// parse breaks a user agent string down into browser, os and device fields
+func parse(uaStr string) map[string]interface{} {
+	agent := ua.New(uaStr)
+	browserName, browserVersion := agent.Browser()
+	osInfo := agent.OSInfo()
+	deviceType := DeviceTypeDesktop
+	if agent.Bot() {
+		deviceType = DeviceTypeBot
+	} else if agent.Mobile() {
+		deviceType = DeviceTypeMobile
+	}
+	return map[string]interface{}{
+		"browser": map[string]interface{}{
+			"name":    browserName,
+			"version": browserVersion,
+		},
+		"os": map[string]interface{}{
+			"name":    osInfo.Name,
+			"version": osInfo.Version,
+		},
+		"device": map[string]interface{}{
+			"type":     deviceType,
+			"platform": agent.Platform(),
+			"model":    agent.Model(),
+		},
+	}
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
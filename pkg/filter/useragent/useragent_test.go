@@ -0,0 +1,69 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package useragent_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/useragent"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestFilterUserAgent(t *testing.T) {
+	ctx := context.Background()
+	f, err := useragent.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "useragent", "myuseragent", useragent.Config{
+		FromPath: ".headers.userAgent",
+		ToPath:   ".client",
+	}, nil)
+	if err != nil {
+		t.Fatalf("useragent test failed: %s\n", err.Error())
+	}
+	uaStr := "Mozilla/5.0 (iPhone; CPU iPhone OS 14_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.1 Mobile/15E148 Safari/604.1"
+	e, err := event.New(ctx, map[string]interface{}{"headers": map[string]interface{}{"userAgent": uaStr}}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("useragent test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of useragent events: %d\n", len(evts))
+	}
+	client, _, _ := evts[0].GetPathValue(".client")
+	expectedStr := `{
+		"browser": {"name": "Safari", "version": "14.1.1"},
+		"os": {"name": "iPhone OS", "version": "14.6"},
+		"device": {"type": "mobile", "platform": "iPhone", "model": "iPhone"}
+	}`
+	var expected interface{}
+	err = json.Unmarshal([]byte(expectedStr), &expected)
+	if err != nil {
+		t.Fatalf("useragent test failed: %s\n", err.Error())
+	}
+	if !reflect.DeepEqual(client, expected) {
+		pl, _ := json.MarshalIndent(client, "", "\t")
+		t.Fatalf("wrong parsed user agent: %s\n", pl)
+	}
+	e, err = event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("useragent test failed: %s\n", err.Error())
+	}
+	evts = f.Filter(e)
+	if len(evts) != 1 || !reflect.DeepEqual(evts[0].Payload(), map[string]interface{}{"foo": "bar"}) {
+		t.Fatalf("event without user agent should pass unchanged\n")
+	}
+}
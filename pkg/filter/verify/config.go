@@ -0,0 +1,97 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+
+	"github.com/xmidt-org/ears/pkg/config"
+	pkgconfig "github.com/xmidt-org/ears/pkg/config"
+	"github.com/xmidt-org/ears/pkg/errs"
+	"github.com/xmidt-org/ears/pkg/filter"
+)
+
+func NewConfig(config interface{}) (*Config, error) {
+	var cfg Config
+	err := pkgconfig.NewConfig(config, &cfg)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	return &cfg, nil
+}
+
+func (c Config) WithDefaults() *Config {
+	cfg := c
+	if c.FromPath == "" {
+		cfg.FromPath = DefaultConfig.FromPath
+	}
+	if c.Algorithm == "" {
+		cfg.Algorithm = DefaultConfig.Algorithm
+	}
+	if c.Encoding == "" {
+		cfg.Encoding = DefaultConfig.Encoding
+	}
+	if c.OnFailure == "" {
+		cfg.OnFailure = DefaultConfig.OnFailure
+	}
+	return &cfg
+}
+
+func (c *Config) Validate() error {
+	if c.SignaturePath == "" {
+		return errors.New("missing signature path")
+	}
+	if c.Key == "" {
+		return errors.New("missing key")
+	}
+	switch c.Algorithm {
+	case AlgorithmHMACSHA1, AlgorithmHMACSHA256, AlgorithmHMACSHA512, AlgorithmRSASHA256, AlgorithmECDSASHA256:
+	default:
+		return errors.New("unsupported algorithm " + c.Algorithm)
+	}
+	if c.Encoding != "hex" && c.Encoding != "base64" {
+		return errors.New("unsupported encoding " + c.Encoding)
+	}
+	if c.OnFailure != OnFailureDrop && c.OnFailure != OnFailureNack {
+		return errors.New("unsupported failure mode " + c.OnFailure)
+	}
+	return nil
+}
+
+func (c *Config) String() string {
+	s, err := c.YAML()
+	if err != nil {
+		return errs.String("error", nil, err)
+	}
+	return s
+}
+
+func (c *Config) YAML() (string, error) {
+	return config.ToYAML(c)
+}
+
+func (c *Config) FromYAML(in string) error {
+	return config.FromYAML(in, c)
+}
+
+func (c *Config) JSON() (string, error) {
+	return config.ToJSON(c)
+}
+
+func (c *Config) FromJSON(in string) error {
+	return config.FromJSON(in, c)
+}
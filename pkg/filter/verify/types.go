@@ -0,0 +1,63 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto"
+
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	AlgorithmHMACSHA1    = "hmac-sha1"
+	AlgorithmHMACSHA256  = "hmac-sha256"
+	AlgorithmHMACSHA512  = "hmac-sha512"
+	AlgorithmRSASHA256   = "rsa-sha256"
+	AlgorithmECDSASHA256 = "ecdsa-sha256"
+
+	OnFailureDrop = "drop"
+	OnFailureNack = "nack"
+)
+
+// Config can be passed into NewFilter() in order to configure
+// the behavior of the sender.
+type Config struct {
+	FromPath        string `json:"fromPath,omitempty"`        // path to the signed data, strings are verified as is, objects are verified in their json encoding
+	SignaturePath   string `json:"signaturePath,omitempty"`   // path to the signature, e.g. metadata.signature
+	SignaturePrefix string `json:"signaturePrefix,omitempty"` // optional prefix to strip from the signature, e.g. sha256=
+	Algorithm       string `json:"algorithm,omitempty"`       // hmac-sha1, hmac-sha256, hmac-sha512, rsa-sha256 or ecdsa-sha256
+	Key             string `json:"key,omitempty"`             // secret reference to the hmac secret or the pem encoded public key
+	Encoding        string `json:"encoding,omitempty"`        // encoding of the signature, hex or base64
+	OnFailure       string `json:"onFailure,omitempty"`       // drop or nack events that fail verification
+}
+
+var DefaultConfig = Config{
+	FromPath:        ".",
+	SignaturePath:   "",
+	SignaturePrefix: "",
+	Algorithm:       AlgorithmHMACSHA256,
+	Key:             "",
+	Encoding:        "hex",
+	OnFailure:       OnFailureDrop,
+}
+
+type Filter struct {
+	config    Config
+	name      string
+	plugin    string
+	tid       tenant.Id
+	hmacKey   []byte
+	publicKey crypto.PublicKey
+}
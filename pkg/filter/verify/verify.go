@@ -0,0 +1,216 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
+	cfg, err := NewConfig(config)
+	if err != nil {
+		return nil, &filter.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{
+		config: *cfg,
+		name:   name,
+		plugin: plugin,
+		tid:    tid,
+	}
+	key := ""
+	if secrets != nil {
+		key = secrets.Secret(cfg.Key)
+	}
+	if key == "" {
+		return nil, &filter.InvalidConfigError{
+			Err: errors.New("cannot resolve key " + cfg.Key),
+		}
+	}
+	switch cfg.Algorithm {
+	case AlgorithmHMACSHA1, AlgorithmHMACSHA256, AlgorithmHMACSHA512:
+		f.hmacKey = []byte(key)
+	default:
+		f.publicKey, err = parsePublicKey(key)
+		if err != nil {
+			return nil, &filter.InvalidConfigError{
+				Err: err,
+			}
+		}
+	}
+	return f, nil
+}
+
+// parsePublicKey parses a pem encoded public key or certificate
+func parsePublicKey(key string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("key is not pem encoded")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	if f == nil {
+		evt.Nack(&filter.InvalidConfigError{
+			Err: fmt.Errorf("<nil> pointer filter"),
+		})
+		return nil
+	}
+	err := f.verify(evt)
+	if err != nil {
+		log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "verify").Str("name", f.Name()).Msg(err.Error())
+		if span := trace.SpanFromContext(evt.Context()); span != nil {
+			span.AddEvent(err.Error())
+		}
+		if f.config.OnFailure == OnFailureNack {
+			evt.Nack(err)
+			return nil
+		}
+		evt.Ack()
+		return []event.Event{}
+	}
+	log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "verify").Str("name", f.Name()).Msg("verify")
+	return []event.Event{evt}
+}
+
+// verify returns an error if the signature of the event is missing or invalid
+func (f *Filter) verify(evt event.Event) error {
+	obj, _, _ := evt.GetPathValue(f.config.FromPath)
+	if obj == nil {
+		return errors.New("no data to verify at " + f.config.FromPath)
+	}
+	var data []byte
+	var err error
+	switch obj := obj.(type) {
+	case string:
+		data = []byte(obj)
+	case []byte:
+		data = obj
+	default:
+		data, err = json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+	}
+	sigObj, _, _ := evt.GetPathValue(f.config.SignaturePath)
+	sigStr, ok := sigObj.(string)
+	if !ok || sigStr == "" {
+		return errors.New("no signature at " + f.config.SignaturePath)
+	}
+	sigStr = strings.TrimPrefix(sigStr, f.config.SignaturePrefix)
+	var sig []byte
+	if f.config.Encoding == "base64" {
+		sig, err = base64.StdEncoding.DecodeString(sigStr)
+	} else {
+		sig, err = hex.DecodeString(sigStr)
+	}
+	if err != nil {
+		return errors.New("cannot decode signature: " + err.Error())
+	}
+	switch f.config.Algorithm {
+	case AlgorithmHMACSHA1:
+		return f.verifyHMAC(sha1.New, data, sig)
+	case AlgorithmHMACSHA256:
+		return f.verifyHMAC(sha256.New, data, sig)
+	case AlgorithmHMACSHA512:
+		return f.verifyHMAC(sha512.New, data, sig)
+	case AlgorithmRSASHA256:
+		pub, ok := f.publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an rsa public key")
+		}
+		digest := sha256.Sum256(data)
+		err = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+		if err != nil {
+			return errors.New("invalid signature: " + err.Error())
+		}
+		return nil
+	case AlgorithmECDSASHA256:
+		pub, ok := f.publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key is not an ecdsa public key")
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported algorithm " + f.config.Algorithm)
+}
+
+func (f *Filter) verifyHMAC(h func() hash.Hash, data []byte, sig []byte) error {
+	mac := hmac.New(h, f.hmacKey)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func (f *Filter) Config() interface{} {
+	if f == nil {
+		return Config{}
+	}
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/verify"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+type testVault map[string]string
+
+func (v testVault) Secret(key string) string {
+	return v[key]
+}
+
+func TestFilterVerifyHMAC(t *testing.T) {
+	ctx := context.Background()
+	vault := testVault{"secret://verify.key": "mykey"}
+	mac := hmac.New(sha256.New, []byte("mykey"))
+	mac.Write([]byte("hello"))
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	f, err := verify.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "verify", "myverify", verify.Config{
+		FromPath:        ".data",
+		SignaturePath:   "metadata.signature",
+		SignaturePrefix: "sha256=",
+		Key:             "secret://verify.key",
+	}, vault)
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"data": "hello"}, event.WithMetadataKeyValue("signature", sig), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("expected verified event but got %d events\n", len(evts))
+	}
+	e, err = event.New(ctx, map[string]interface{}{"data": "tampered"}, event.WithMetadataKeyValue("signature", sig), event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	evts = f.Filter(e)
+	if len(evts) != 0 {
+		t.Fatalf("expected tampered event to be dropped but got %d events\n", len(evts))
+	}
+	f, err = verify.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "verify", "myverify", verify.Config{
+		FromPath:      ".data",
+		SignaturePath: ".sig",
+		Key:           "secret://verify.key",
+		OnFailure:     verify.OnFailureNack,
+	}, vault)
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	e, err = event.New(ctx, map[string]interface{}{"data": "hello"}, event.FailOnAck(t))
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	evts = f.Filter(e)
+	if len(evts) != 0 {
+		t.Fatalf("expected unsigned event to be nacked but got %d events\n", len(evts))
+	}
+	_, err = verify.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "verify", "myverify", verify.Config{
+		SignaturePath: ".sig",
+		Key:           "secret://verify.missing",
+	}, vault)
+	if err == nil {
+		t.Fatalf("expected error for unknown key\n")
+	}
+}
+
+func TestFilterVerifyECDSA(t *testing.T) {
+	ctx := context.Background()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	vault := testVault{"secret://verify.pub": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+	digest := sha256.Sum256([]byte(`{"foo":"bar"}`))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	f, err := verify.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "verify", "myverify", verify.Config{
+		FromPath:      ".data",
+		SignaturePath: ".sig",
+		Algorithm:     verify.AlgorithmECDSASHA256,
+		Encoding:      "base64",
+		Key:           "secret://verify.pub",
+	}, vault)
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	payload := map[string]interface{}{
+		"data": map[string]interface{}{"foo": "bar"},
+		"sig":  base64.StdEncoding.EncodeToString(sig),
+	}
+	e, err := event.New(ctx, payload, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("verify test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("expected verified event but got %d events\n", len(evts))
+	}
+}
@@ -15,6 +15,7 @@
 package ws
 
 import (
+	"errors"
 	"fmt"
 	"github.com/xeipuuv/gojsonschema"
 	"github.com/xmidt-org/ears/pkg/config"
@@ -54,6 +55,9 @@ func (c Config) WithDefaults() *Config {
 	if c.Body == "" {
 		cfg.Body = DefaultConfig.Body
 	}
+	if c.BodyPath == "" {
+		cfg.BodyPath = DefaultConfig.BodyPath
+	}
 	if c.Headers == nil {
 		cfg.Headers = DefaultConfig.Headers
 	}
@@ -63,6 +67,33 @@ func (c Config) WithDefaults() *Config {
 	if c.Auth == nil {
 		cfg.Auth = DefaultConfig.Auth
 	}
+	if c.FailurePolicy == "" {
+		cfg.FailurePolicy = DefaultConfig.FailurePolicy
+	}
+	if c.CircuitBreaker == nil {
+		cfg.CircuitBreaker = DefaultConfig.CircuitBreaker
+	} else {
+		cb := *c.CircuitBreaker
+		if cb.Threshold == nil {
+			cb.Threshold = DefaultConfig.CircuitBreaker.Threshold
+		}
+		if cb.Cooldown == nil {
+			cb.Cooldown = DefaultConfig.CircuitBreaker.Cooldown
+		}
+		cfg.CircuitBreaker = &cb
+	}
+	if c.Timeout == nil {
+		cfg.Timeout = DefaultConfig.Timeout
+	}
+	if c.Retries == nil {
+		cfg.Retries = DefaultConfig.Retries
+	}
+	if c.RetryBackoff == nil {
+		cfg.RetryBackoff = DefaultConfig.RetryBackoff
+	}
+	if c.MaxRetryBackoff == nil {
+		cfg.MaxRetryBackoff = DefaultConfig.MaxRetryBackoff
+	}
 	return &cfg
 }
 
@@ -76,6 +107,12 @@ func (c *Config) Validate() error {
 	if !result.Valid() {
 		return fmt.Errorf(fmt.Sprintf("%+v", result.Errors()))
 	}
+	if c.Body != "" && c.BodyPath != "" {
+		return errors.New("body and bodyPath are mutually exclusive")
+	}
+	if c.RetryBackoff != nil && c.MaxRetryBackoff != nil && *c.MaxRetryBackoff < *c.RetryBackoff {
+		return errors.New("maxRetryBackoff must not be less than retryBackoff")
+	}
 	return nil
 }
 
@@ -114,7 +151,7 @@ const filterSchema = `
             "properties": {
                 "type": {
                     "type": "string",
-					"enum": ["", "basic", "sat", "oauth", "oauth2"]
+					"enum": ["", "basic", "bearer", "sat", "oauth", "oauth2"]
                 },
                 "username": {
                     "type": "string"
@@ -122,6 +159,9 @@ const filterSchema = `
                 "password": {
                     "type": "string"
                 },
+                "token": {
+                    "type": "string"
+                },
                 "clientId": {
                     "type": "string"
                 },
@@ -142,6 +182,23 @@ const filterSchema = `
             ],
             "title": "AuthConfig"
 		},
+		"CircuitBreakerConfig": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+                "threshold": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "cooldown": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            },
+            "required": [
+            ],
+            "title": "CircuitBreakerConfig"
+		},
         "FilterConfig": {
             "type": "object",
             "additionalProperties": false,
@@ -163,6 +220,9 @@ const filterSchema = `
 					"enum": ["GET", "PUT", "POST", "DELETE"]
 				},
 				"body": {
+                    "type": "string"
+				},
+				"bodyPath": {
                     "type": "string"
 				},
 				"headers": {
@@ -174,6 +234,30 @@ const filterSchema = `
 				},
 				"auth": {
                     "$ref": "#/definitions/AuthConfig"
+				},
+				"failurePolicy": {
+                    "type": "string",
+					"enum": ["nack", "skip", "drop"]
+				},
+				"circuitBreaker": {
+                    "$ref": "#/definitions/CircuitBreakerConfig"
+				},
+				"timeout": {
+                    "type": "integer",
+                    "minimum": 0
+				},
+				"retries": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "maximum": 10
+				},
+				"retryBackoff": {
+                    "type": "integer",
+                    "minimum": 0
+				},
+				"maxRetryBackoff": {
+                    "type": "integer",
+                    "minimum": 0
 				}
             },
             "required": [
@@ -30,16 +30,29 @@ type Config struct {
 	Url                    string            `json:"url,omitempty"`
 	UrlPath                string            `json:"urlPath,omitempty"`
 	Method                 string            `json:"method,omitempty"`
-	Body                   string            `json:"body,omitempty"`
-	Headers                map[string]string `json:"headers,omitempty"`
+	Body                   string            `json:"body,omitempty"`     // body template
+	BodyPath               string            `json:"bodyPath,omitempty"` // if set, the value at this path is sent as json body
+	Headers                map[string]string `json:"headers,omitempty"`  // header values may be templates or secret references
 	EmptyPathValueRequired *bool             `json:"emptyPathValueRequired,omitempty"`
 	Auth                   *Auth             `json:"auth,omitempty"`
+	FailurePolicy          string            `json:"failurePolicy,omitempty"` // nack, skip or drop
+	CircuitBreaker         *CircuitBreaker   `json:"circuitBreaker,omitempty"`
+	Timeout                *int              `json:"timeout,omitempty"`         // request timeout in ms, 0 uses the transport defaults
+	Retries                *int              `json:"retries,omitempty"`         // number of retries for failed calls
+	RetryBackoff           *int              `json:"retryBackoff,omitempty"`    // delay in ms before the first retry, doubled for every further retry
+	MaxRetryBackoff        *int              `json:"maxRetryBackoff,omitempty"` // upper bound in ms of the delay between retries
+}
+
+type CircuitBreaker struct {
+	Threshold *int `json:"threshold,omitempty"` // consecutive failures after which the circuit opens, 0 disables the circuit breaker
+	Cooldown  *int `json:"cooldown,omitempty"`  // time in ms calls are short circuited once the circuit is open
 }
 
 type Auth struct {
 	Type         string   `json:"type,omitempty"`
 	Username     string   `json:"username,omitempty"`     // basic auth
 	Password     string   `json:"password,omitempty"`     // basic auth
+	Token        string   `json:"token,omitempty"`        // bearer auth
 	ClientID     string   `json:"clientId,omitempty"`     // oauth2
 	ClientSecret string   `json:"clientSecret,omitempty"` // oauth2
 	TokenURL     string   `json:"tokenUrl,omitempty"`     // oauth2
@@ -53,9 +66,19 @@ var DefaultConfig = Config{
 	UrlPath:                "",
 	Method:                 "GET",
 	Body:                   "",
+	BodyPath:               "",
 	Headers:                map[string]string{},
 	EmptyPathValueRequired: pointer.Bool(false),
 	Auth:                   &Auth{},
+	FailurePolicy:          FailurePolicyNack,
+	CircuitBreaker: &CircuitBreaker{
+		Threshold: pointer.Int(0),
+		Cooldown:  pointer.Int(30000),
+	},
+	Timeout:         pointer.Int(0),
+	Retries:         pointer.Int(0),
+	RetryBackoff:    pointer.Int(100),
+	MaxRetryBackoff: pointer.Int(5000),
 }
 
 type Filter struct {
@@ -65,5 +88,6 @@ type Filter struct {
 	tid     tenant.Id
 	secrets secret.Vault
 	clients map[string]*http.Client
+	breaker *circuitBreaker
 	sync.RWMutex
 }
@@ -29,6 +29,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2/clientcredentials"
 	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/http"
 	"strings"
@@ -37,11 +38,18 @@ import (
 
 const (
 	HTTP_AUTH_TYPE_BASIC  = "basic"
+	HTTP_AUTH_TYPE_BEARER = "bearer"
 	HTTP_AUTH_TYPE_SAT    = "sat"
 	HTTP_AUTH_TYPE_OAUTH  = "oauth"
 	HTTP_AUTH_TYPE_OAUTH2 = "oauth2"
 )
 
+const (
+	FailurePolicyNack = "nack" // nack the event
+	FailurePolicySkip = "skip" // pass the event on without the webservice result
+	FailurePolicyDrop = "drop" // ack and drop the event
+)
+
 func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*Filter, error) {
 	cfg, err := NewConfig(config)
 	if err != nil {
@@ -62,6 +70,9 @@ func NewFilter(tid tenant.Id, plugin string, name string, config interface{}, se
 		secrets: secrets,
 		clients: make(map[string]*http.Client),
 	}
+	if *cfg.CircuitBreaker.Threshold > 0 {
+		f.breaker = newCircuitBreaker(*cfg.CircuitBreaker.Threshold, time.Duration(*cfg.CircuitBreaker.Cooldown)*time.Millisecond)
+	}
 	return f, nil
 }
 
@@ -78,12 +89,36 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 			return []event.Event{evt}
 		}
 	}
-	// execute http request
-	res, _, err := f.hitEndpoint(evt.Context(), evt)
+	if !f.breaker.allow() {
+		return f.fail(evt, errors.New("circuit open for webservice "+f.config.Url))
+	}
+	// execute http request, retrying failed calls
+	var res string
+	var status int
+	var err error
+	for attempt := 0; attempt <= *f.config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(f.retryDelay(attempt)):
+			case <-evt.Context().Done():
+				err = evt.Context().Err()
+			}
+			if evt.Context().Err() != nil {
+				break
+			}
+		}
+		res, status, err = f.hitEndpoint(evt.Context(), evt)
+		if err == nil && status >= http.StatusInternalServerError {
+			err = fmt.Errorf("webservice returned status %d", status)
+		}
+		if err == nil {
+			break
+		}
+		log.Ctx(evt.Context()).Debug().Str("op", "filter").Str("filterType", "ws").Str("name", f.Name()).Int("attempt", attempt).Msg(err.Error())
+	}
+	f.breaker.record(err == nil)
 	if err != nil {
-		// legitimate filter nack because it involves an external service
-		evt.Nack(err)
-		return []event.Event{}
+		return f.fail(evt, err)
 	}
 	var resObj interface{}
 	err = json.Unmarshal([]byte(res), &resObj)
@@ -121,6 +156,42 @@ func (f *Filter) Filter(evt event.Event) []event.Event {
 	return []event.Event{evt}
 }
 
+// fail applies the failure policy to an event for which the webservice call failed
+// retryDelay returns the delay before the given retry, doubling retryBackoff for every retry up to maxRetryBackoff.
+// Half of the delay is randomized so that events failing together do not retry in lockstep.
+func (f *Filter) retryDelay(attempt int) time.Duration {
+	delay := time.Duration(*f.config.RetryBackoff) * time.Millisecond
+	max := time.Duration(*f.config.MaxRetryBackoff) * time.Millisecond
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (f *Filter) fail(evt event.Event, err error) []event.Event {
+	log.Ctx(evt.Context()).Error().Str("op", "filter").Str("filterType", "ws").Str("name", f.Name()).Str("failurePolicy", f.config.FailurePolicy).Msg(err.Error())
+	if span := trace.SpanFromContext(evt.Context()); span != nil {
+		span.AddEvent(err.Error())
+	}
+	switch f.config.FailurePolicy {
+	case FailurePolicySkip:
+		return []event.Event{evt}
+	case FailurePolicyDrop:
+		evt.Ack()
+		return []event.Event{}
+	default:
+		// legitimate filter nack because it involves an external service
+		evt.Nack(err)
+		return []event.Event{}
+	}
+}
+
 func (f *Filter) Config() interface{} {
 	if f == nil {
 		return Config{}
@@ -171,17 +242,47 @@ func (f *Filter) evalStr(evt event.Event, tt string) string {
 	return tt
 }
 
+// secret resolves a secret reference, values that are not secret references are returned unchanged
+func (f *Filter) secret(key string) string {
+	if f.secrets == nil {
+		return key
+	}
+	val := f.secrets.Secret(key)
+	if val == "" {
+		return key
+	}
+	return val
+}
+
+// body returns the request body either from the body template or as json of the value at the body path
+func (f *Filter) body(evt event.Event) (string, error) {
+	if f.config.BodyPath == "" {
+		return f.evalStr(evt, f.config.Body), nil
+	}
+	v, _, _ := evt.GetPathValue(f.config.BodyPath)
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
 func (f *Filter) hitEndpoint(ctx context.Context, evt event.Event) (string, int, error) {
 	e1 := f.evalStr(evt, f.config.Url)
 	e2 := f.evalStr(evt, f.config.UrlPath)
-	url := f.secrets.Secret(e1)
-	if url == "" {
-		url = e1
-	}
+	url := f.secret(e1)
 	url = url + e2
-	payload := f.evalStr(evt, f.config.Body)
+	payload, err := f.body(evt)
+	if err != nil {
+		return "", 0, err
+	}
 	verb := f.evalStr(evt, f.config.Method)
 	headers := f.config.Headers
+	if *f.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*f.config.Timeout)*time.Millisecond)
+		defer cancel()
+	}
 	req, err := http.NewRequestWithContext(ctx, verb, url, bytes.NewBuffer([]byte(payload)))
 	if err != nil {
 		return "", 0, err
@@ -194,25 +295,33 @@ func (f *Filter) hitEndpoint(ctx context.Context, evt event.Event) (string, int,
 	req.Header.Set(traceHeader, traceId)
 	// add supplied headers
 	for hk, hv := range headers {
-		req.Header.Set(hk, hv)
+		req.Header.Set(hk, f.secret(f.evalStr(evt, hv)))
 	}
 	var client *http.Client
 	if f.config.Auth.Type == HTTP_AUTH_TYPE_BASIC {
-		password := f.secrets.Secret(f.config.Auth.Password)
-		if password == "" {
-			password = f.config.Auth.Password
-		}
-		req.SetBasicAuth(f.config.Auth.Username, password)
+		req.SetBasicAuth(f.config.Auth.Username, f.secret(f.config.Auth.Password))
 		var ok bool
 		f.RLock()
 		client, ok = f.clients[HTTP_AUTH_TYPE_BASIC]
 		f.RUnlock()
 		if !ok {
-			client = InitHttpTransportWithDialer()
+			client = f.newClient()
 			f.Lock()
 			f.clients[HTTP_AUTH_TYPE_BASIC] = client
 			f.Unlock()
 		}
+	} else if f.config.Auth.Type == HTTP_AUTH_TYPE_BEARER {
+		req.Header.Set("Authorization", "Bearer "+f.secret(f.config.Auth.Token))
+		var ok bool
+		f.RLock()
+		client, ok = f.clients[HTTP_AUTH_TYPE_BEARER]
+		f.RUnlock()
+		if !ok {
+			client = f.newClient()
+			f.Lock()
+			f.clients[HTTP_AUTH_TYPE_BEARER] = client
+			f.Unlock()
+		}
 	} else if f.config.Auth.Type == HTTP_AUTH_TYPE_SAT {
 		return "", 0, errors.New("sat auth not supported")
 	} else if f.config.Auth.Type == HTTP_AUTH_TYPE_OAUTH {
@@ -224,20 +333,11 @@ func (f *Filter) hitEndpoint(ctx context.Context, evt event.Event) (string, int,
 		f.RUnlock()
 		if !ok {
 			conf := &clientcredentials.Config{
-				ClientID:     f.secrets.Secret(f.config.Auth.ClientID),
-				ClientSecret: f.secrets.Secret(f.config.Auth.ClientSecret),
-				TokenURL:     f.secrets.Secret(f.config.Auth.TokenURL),
+				ClientID:     f.secret(f.config.Auth.ClientID),
+				ClientSecret: f.secret(f.config.Auth.ClientSecret),
+				TokenURL:     f.secret(f.config.Auth.TokenURL),
 				Scopes:       f.config.Auth.Scopes,
 			}
-			if conf.ClientID == "" {
-				conf.ClientID = f.config.Auth.ClientID
-			}
-			if conf.ClientSecret == "" {
-				conf.ClientSecret = f.config.Auth.ClientSecret
-			}
-			if conf.TokenURL == "" {
-				conf.TokenURL = f.config.Auth.TokenURL
-			}
 			client = conf.Client(context.Background())
 			f.Lock()
 			f.clients[HTTP_AUTH_TYPE_OAUTH2+"-"+url] = client
@@ -249,7 +349,7 @@ func (f *Filter) hitEndpoint(ctx context.Context, evt event.Event) (string, int,
 		client, ok = f.clients[HTTP_AUTH_TYPE_BASIC]
 		f.RUnlock()
 		if !ok {
-			client = InitHttpTransportWithDialer()
+			client = f.newClient()
 			f.Lock()
 			f.clients[HTTP_AUTH_TYPE_BASIC] = client
 			f.Unlock()
@@ -281,6 +381,17 @@ func (f *Filter) hitEndpoint(ctx context.Context, evt event.Event) (string, int,
 	return string(body), resp.StatusCode, nil
 }
 
+// newClient returns a client honoring the configured timeout
+func (f *Filter) newClient() *http.Client {
+	client := InitHttpTransportWithDialer()
+	if *f.config.Timeout > 0 {
+		timeout := time.Duration(*f.config.Timeout) * time.Millisecond
+		client.Transport.(*http.Transport).ResponseHeaderTimeout = timeout
+		client.Timeout = timeout
+	}
+	return client
+}
+
 func InitHttpTransportWithDialer() *http.Client {
 	var dialer net.Dialer
 	tr := &http.Transport{
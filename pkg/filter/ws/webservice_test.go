@@ -23,8 +23,11 @@ import (
 	"github.com/xmidt-org/ears/pkg/filter/ws"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"github.com/xorcare/pointer"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFilterWsBasic(t *testing.T) {
@@ -163,3 +166,175 @@ func TestFilterWsEmptyPathValueNotMet(t *testing.T) {
 		t.Fatalf("wrong payload in encoded event: %s\n", pl)
 	}
 }
+
+func TestFilterWsCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(".")
+	err := v.ReadInConfig()
+	if err != nil {
+		t.Fatalf("failed to load test configuration %s\n", err.Error())
+	}
+	secrets := appsecret.NewConfigVault(v)
+	f, err := ws.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ws", "myws", ws.Config{
+		ToPath:        ".value",
+		Url:           srv.URL,
+		Method:        "GET",
+		FailurePolicy: ws.FailurePolicySkip,
+		CircuitBreaker: &ws.CircuitBreaker{
+			Threshold: pointer.Int(2),
+			Cooldown:  pointer.Int(500),
+		},
+	}, secrets)
+	if err != nil {
+		t.Fatalf("ws test failed: %s\n", err.Error())
+	}
+	filter := func() {
+		e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+		if err != nil {
+			t.Fatalf("ws test failed: %s\n", err.Error())
+		}
+		evts := f.Filter(e)
+		if len(evts) != 1 {
+			t.Fatalf("wrong number of events: %d\n", len(evts))
+		}
+		if !reflect.DeepEqual(evts[0].Payload(), map[string]interface{}{"foo": "bar"}) {
+			t.Fatalf("unexpected payload for skipped event: %v\n", evts[0].Payload())
+		}
+	}
+	for i := 0; i < 4; i++ {
+		filter()
+	}
+	if hits != 2 {
+		t.Fatalf("expected circuit to open after 2 failures but got %d calls\n", hits)
+	}
+	time.Sleep(600 * time.Millisecond)
+	filter()
+	if hits != 3 {
+		t.Fatalf("expected call after cooldown but got %d calls\n", hits)
+	}
+}
+
+func TestFilterWsPostWithAuth(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// fail the first call to exercise retries
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var body interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"method": r.Method,
+			"auth":   r.Header.Get("Authorization"),
+			"device": r.Header.Get("X-Device"),
+			"body":   body,
+		})
+	}))
+	defer srv.Close()
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(".")
+	err := v.ReadInConfig()
+	if err != nil {
+		t.Fatalf("failed to load test configuration %s\n", err.Error())
+	}
+	secrets := appsecret.NewConfigVault(v)
+	f, err := ws.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ws", "myws", ws.Config{
+		ToPath:   ".value",
+		Url:      srv.URL,
+		Method:   "POST",
+		BodyPath: ".data",
+		Headers:  map[string]string{"X-Device": "{.deviceId}"},
+		Auth: &ws.Auth{
+			Type:  ws.HTTP_AUTH_TYPE_BEARER,
+			Token: "secret://all.all.foo",
+		},
+		Timeout: pointer.Int(1000),
+		Retries: pointer.Int(1),
+	}, secrets)
+	if err != nil {
+		t.Fatalf("ws test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{"deviceId": "abc", "data": map[string]interface{}{"foo": "bar"}}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("ws test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 1 {
+		t.Fatalf("wrong number of events: %d\n", len(evts))
+	}
+	expected := map[string]interface{}{
+		"method": "POST",
+		"auth":   "Bearer bar",
+		"device": "abc",
+		"body":   map[string]interface{}{"foo": "bar"},
+	}
+	value, _, _ := evts[0].GetPathValue(".value")
+	if !reflect.DeepEqual(value, expected) {
+		t.Fatalf("unexpected webservice result: %v\n", value)
+	}
+	if calls != 2 {
+		t.Fatalf("expected one retry but got %d calls\n", calls)
+	}
+}
+
+func TestFilterWsRetryBackoff(t *testing.T) {
+	ctx := context.Background()
+	var calls []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, time.Now())
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	f, err := ws.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ws", "myws", ws.Config{
+		ToPath:          ".value",
+		Url:             srv.URL,
+		Method:          "GET",
+		Retries:         pointer.Int(3),
+		RetryBackoff:    pointer.Int(40),
+		MaxRetryBackoff: pointer.Int(100),
+	}, nil)
+	if err != nil {
+		t.Fatalf("ws test failed: %s\n", err.Error())
+	}
+	e, err := event.New(ctx, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("ws test failed: %s\n", err.Error())
+	}
+	evts := f.Filter(e)
+	if len(evts) != 0 {
+		t.Fatalf("event passed on after all retries failed\n")
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected three retries but got %d calls\n", len(calls))
+	}
+	// delays double from 40ms and are capped at 100ms, at least half of each delay is not randomized
+	for i, min := range []time.Duration{20, 40, 50} {
+		if gap := calls[i+1].Sub(calls[i]); gap < min*time.Millisecond {
+			t.Fatalf("retry %d after %s, expected a delay of at least %dms\n", i+1, gap, min)
+		}
+	}
+	_, err = ws.NewFilter(tenant.Id{AppId: "myapp", OrgId: "myorg"}, "ws", "myws", ws.Config{
+		ToPath:          ".value",
+		Url:             srv.URL,
+		Method:          "GET",
+		RetryBackoff:    pointer.Int(1000),
+		MaxRetryBackoff: pointer.Int(100),
+	}, nil)
+	if err == nil {
+		t.Fatalf("expected error for maxRetryBackoff less than retryBackoff\n")
+	}
+}
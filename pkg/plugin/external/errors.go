@@ -0,0 +1,37 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import "github.com/xmidt-org/ears/pkg/errs"
+
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+func (e *StartError) Error() string {
+	return errs.String("StartError", map[string]interface{}{"plugin": e.Name}, e.Err)
+}
+
+func (e *ProcessExitedError) Error() string {
+	return errs.String("ProcessExitedError", map[string]interface{}{"plugin": e.Name}, nil)
+}
+
+func (e *StoppedError) Error() string {
+	return errs.String("StoppedError", map[string]interface{}{"plugin": e.Name}, nil)
+}
+
+func (e *NotPluginProcessError) Error() string {
+	return errs.String("NotPluginProcessError", map[string]interface{}{"reason": "plugin binaries are started by ears"}, nil)
+}
@@ -0,0 +1,253 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/plugin/external"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var tid = tenant.Id{OrgId: "myorg", AppId: "myapp"}
+
+type mapVault map[string]string
+
+func (v mapVault) Secret(key string) string {
+	return v[key]
+}
+
+// TestMain turns the test binary into the plugin process when it is started by the external plugin
+func TestMain(m *testing.M) {
+	if os.Getenv(external.MagicCookieKey) == external.MagicCookieValue {
+		p, err := newTestPlugin()
+		if err == nil {
+			err = external.Serve(p)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestPlugin() (*pkgplugin.Plugin, error) {
+	return pkgplugin.NewPlugin(
+		pkgplugin.WithName("test"),
+		pkgplugin.WithVersion("v1.2.3"),
+		pkgplugin.WithNewReceiver(func(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+			stop := make(chan struct{})
+			return &receiver.ReceiverMock{
+				ReceiveFunc: func(next receiver.NextFn) error {
+					for n := 1; n <= 3; n++ {
+						e, err := event.New(context.Background(), map[string]interface{}{"n": n}, event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+						if err != nil {
+							return err
+						}
+						next(e)
+					}
+					<-stop
+					return nil
+				},
+				StopReceivingFunc: func(ctx context.Context) error {
+					close(stop)
+					return nil
+				},
+			}, nil
+		}),
+		pkgplugin.WithNewFilterer(func(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (filter.Filterer, error) {
+			if config == "invalid" {
+				return nil, errors.New("invalid config")
+			}
+			return &filter.FiltererMock{
+				FilterFunc: func(e event.Event) []event.Event {
+					if n, _, _ := e.GetPathValue("payload.n"); n == float64(2) {
+						e.Nack(errors.New("no twos"))
+						return nil
+					}
+					e.SetPathValue("payload.filtered", config, true)
+					return []event.Event{e}
+				},
+			}, nil
+		}),
+		pkgplugin.WithNewSender(func(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+			return &sender.SenderMock{
+				SendFunc: func(e event.Event) {
+					if crash, _, _ := e.GetPathValue("payload.crash"); crash == true {
+						os.Exit(1)
+					}
+					e.Ack()
+				},
+				StopSendingFunc: func(ctx context.Context) {},
+			}, nil
+		}),
+	)
+}
+
+func newEvent(t *testing.T, payload interface{}) (event.Event, chan error) {
+	done := make(chan error, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	e, err := event.New(ctx, payload, event.WithAck(
+		func(event.Event) {
+			done <- nil
+		},
+		func(e event.Event, err error) {
+			done <- err
+		}))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	return e, done
+}
+
+func TestExternalPlugin(t *testing.T) {
+	logger := zerolog.Nop()
+	p, err := external.NewPlugin(external.Config{Name: "test", Path: os.Args[0]}, &logger)
+	if err != nil {
+		t.Fatalf("cannot start plugin: %s", err.Error())
+	}
+	defer p.Stop()
+	types := p.SupportedTypes()
+	if p.Version() != "v1.2.3" || !types.IsSet(pkgplugin.TypeReceiver) || !types.IsSet(pkgplugin.TypeFilter) || !types.IsSet(pkgplugin.TypeSender) {
+		t.Fatalf("unexpected plugin info %+v", p.Info())
+	}
+
+	_, err = p.NewFilterer(tid, "test", "f1", "invalid", nil)
+	var configErr *pkgplugin.InvalidConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected invalid config error, got %v", err)
+	}
+	// secret references are resolved by the plugin process with the vault of EARS
+	vault := mapVault{"secret://app.token": "s3cr3t"}
+	config := map[string]interface{}{"token": "${secret://app.token}"}
+	_, err = p.NewFilterer(tid, "test", "f1", map[string]interface{}{"token": "${secret://app.missing}"}, vault)
+	if !errors.As(err, &configErr) {
+		t.Fatalf("expected invalid config error for unresolved secret, got %v", err)
+	}
+	f, err := p.NewFilterer(tid, "test", "f1", config, vault)
+	if err != nil {
+		t.Fatalf("cannot create filterer: %s", err.Error())
+	}
+	e, done := newEvent(t, map[string]interface{}{"n": 1})
+	events := f.Filter(e)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	if filtered, _, _ := events[0].GetPathValue("payload.filtered"); filtered != `{"token":"s3cr3t"}` {
+		t.Fatalf("unexpected payload %+v", events[0].Payload())
+	}
+	events[0].Ack()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected nack: %s", err.Error())
+	}
+	if f.Config().(map[string]interface{})["token"] != "${secret://app.token}" {
+		t.Fatalf("unexpected config %+v", f.Config())
+	}
+
+	f, err = p.NewFilterer(tid, "test", "f1", "yes", nil)
+	if err != nil {
+		t.Fatalf("cannot create filterer: %s", err.Error())
+	}
+	e, done = newEvent(t, map[string]interface{}{"n": 1})
+	events = f.Filter(e)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	if filtered, _, _ := events[0].GetPathValue("payload.filtered"); filtered != "yes" {
+		t.Fatalf("unexpected payload %+v", events[0].Payload())
+	}
+	events[0].Ack()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected nack: %s", err.Error())
+	}
+	e, done = newEvent(t, map[string]interface{}{"n": 2})
+	if events := f.Filter(e); len(events) != 0 {
+		t.Fatalf("expected no events, got %d", len(events))
+	}
+	if err := <-done; err == nil {
+		t.Fatalf("expected nack from filter")
+	}
+
+	s, err := p.NewSender(tid, "test", "s1", "", nil)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	e, done = newEvent(t, map[string]interface{}{"n": 1})
+	s.Send(e)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected nack: %s", err.Error())
+	}
+	// a crashing plugin process nacks the event and is restarted for the next one
+	e, done = newEvent(t, map[string]interface{}{"crash": true})
+	s.Send(e)
+	if err := <-done; err == nil {
+		t.Fatalf("expected nack from crashed plugin process")
+	}
+	for i := 0; s.(sender.HealthChecker).CheckHealth(context.Background()) == nil; i++ {
+		if i == 100 {
+			t.Fatalf("expected sender of crashed plugin process to be unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(external.DefaultRestartDelay)
+	e, done = newEvent(t, map[string]interface{}{"n": 1})
+	s.Send(e)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected nack after restart: %s", err.Error())
+	}
+
+	r, err := p.NewReceiver(tid, "test", "r1", "", nil)
+	if err != nil {
+		t.Fatalf("cannot create receiver: %s", err.Error())
+	}
+	received := make(chan event.Event, 3)
+	result := make(chan error, 1)
+	go func() {
+		result <- r.Receive(func(e event.Event) {
+			e.Ack()
+			received <- e
+		})
+	}()
+	for n := 1; n <= 3; n++ {
+		select {
+		case e := <-received:
+			if v, _, _ := e.GetPathValue("payload.n"); v != float64(n) || e.Tenant() != tid {
+				t.Fatalf("unexpected event %+v", e.Payload())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event received")
+		}
+	}
+	err = r.StopReceiving(context.Background())
+	if err != nil {
+		t.Fatalf("cannot stop receiver: %s", err.Error())
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected receive error: %s", err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var _ filter.Filterer = (*Filter)(nil)
+
+// Filter hands the event to the filterer in the plugin process. A single resulting event replaces the payload and
+// metadata of the event, several resulting events become child events of it. Events are nacked if the plugin
+// process cannot be reached or the filterer in the plugin process nacked the event.
+func (f *Filter) Filter(evt event.Event) []event.Event {
+	conn, id, err := f.instance.get(evt.Context())
+	if err != nil {
+		evt.Nack(err)
+		return nil
+	}
+	in, err := encodeEvent(evt)
+	if err != nil {
+		evt.Nack(err)
+		return nil
+	}
+	out, err := conn.filterers.Filter(evt.Context(), &proto.FilterRequest{Id: id, Event: in})
+	if err != nil {
+		evt.Nack(pluginError(err))
+		return nil
+	}
+	results := make([]*wireEvent, 0, len(out.Events))
+	for _, pe := range out.Events {
+		w, err := decodeEvent(pe)
+		if err != nil {
+			evt.Nack(err)
+			return nil
+		}
+		results = append(results, w)
+	}
+	switch len(results) {
+	case 0:
+		evt.Ack()
+		return []event.Event{}
+	case 1:
+		err = setEvent(evt, results[0])
+		if err != nil {
+			evt.Nack(err)
+			return nil
+		}
+		return []event.Event{evt}
+	}
+	events := make([]event.Event, 0, len(results))
+	for _, w := range results {
+		nevt, err := evt.Clone(evt.Context())
+		if err == nil {
+			err = setEvent(nevt, w)
+		}
+		if err != nil {
+			for _, e := range events {
+				e.Ack()
+			}
+			evt.Nack(err)
+			return nil
+		}
+		events = append(events, nevt)
+	}
+	evt.Ack()
+	return events
+}
+
+func setEvent(e event.Event, w *wireEvent) error {
+	if w.contentType != "" {
+		return &filter.UnsupportedPayloadError{Filter: "external", ContentType: w.contentType}
+	}
+	err := e.SetPayload(w.payload)
+	if err != nil {
+		return err
+	}
+	if w.metadata != nil {
+		return e.SetMetadata(w.metadata)
+	}
+	return nil
+}
+
+func (f *Filter) Config() interface{} {
+	return f.config
+}
+
+func (f *Filter) Name() string {
+	return f.name
+}
+
+func (f *Filter) Plugin() string {
+	return f.plugin
+}
+
+func (f *Filter) Tenant() tenant.Id {
+	return f.tid
+}
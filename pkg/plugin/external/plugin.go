@@ -0,0 +1,364 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/bit"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/hasher"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var _ pkgplugin.Pluginer = (*Plugin)(nil)
+var _ receiver.NewReceiverer = (*Plugin)(nil)
+var _ filter.NewFilterer = (*Plugin)(nil)
+var _ sender.NewSenderer = (*Plugin)(nil)
+var _ secret.Resolver = (*Plugin)(nil)
+
+// NewPlugin starts the plugin binary and asks the plugin process which plugin types it supports
+func NewPlugin(config Config, logger *zerolog.Logger) (*Plugin, error) {
+	if config.Name == "" || config.Path == "" {
+		return nil, &pkgplugin.InvalidConfigError{
+			Err: fmt.Errorf("external plugin needs a name and a path"),
+		}
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = DefaultStartTimeout
+	}
+	l := logger.With().Str("plugin", config.Name).Logger()
+	p := &Plugin{
+		config: config,
+		logger: &l,
+	}
+	err := p.start()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// start runs a new plugin process, must be called with the lock held or before the plugin is shared. Lines the
+// plugin process writes to stdout or stderr are logged.
+func (p *Plugin) start() error {
+	p.startedAt = time.Now()
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          pluginMap(nil),
+		Cmd:              exec.Command(p.config.Path, p.config.Args...),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		StartTimeout:     p.config.StartTimeout,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:        p.config.Name,
+			Level:       hclog.Warn,
+			Output:      &logWriter{logger: p.logger},
+			DisableTime: true,
+		}),
+		Stderr:     &logWriter{logger: p.logger},
+		SyncStdout: &logWriter{logger: p.logger},
+		SyncStderr: &logWriter{logger: p.logger},
+	})
+	conn, err := dispense(client)
+	if err != nil {
+		client.Kill()
+		return &StartError{Name: p.config.Name, Err: err}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.StartTimeout)
+	defer cancel()
+	info, err := conn.plugin.Info(ctx, &proto.Empty{})
+	if err != nil {
+		client.Kill()
+		return &StartError{Name: p.config.Name, Err: err}
+	}
+	p.info = Info{
+		Name:     info.Name,
+		Version:  info.Version,
+		CommitID: info.CommitId,
+	}
+	for _, t := range info.Types {
+		switch t {
+		case "receiver":
+			p.info.Types.Set(pkgplugin.TypeReceiver)
+		case "filter":
+			p.info.Types.Set(pkgplugin.TypeFilter)
+		case "sender":
+			p.info.Types.Set(pkgplugin.TypeSender)
+		}
+	}
+	p.client = client
+	p.conn = conn
+	p.logger.Info().Str("op", "external.start").Str("path", p.config.Path).Msg("plugin process started")
+	return nil
+}
+
+func dispense(client *plugin.Client) (*grpcClient, error) {
+	rpc, err := client.Client()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rpc.Dispense(PluginName)
+	if err != nil {
+		return nil, err
+	}
+	return raw.(*grpcClient), nil
+}
+
+// process returns the clients of the running plugin process, a plugin process that exited is restarted
+func (p *Plugin) process() (*grpcClient, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.stopped {
+		return nil, &StoppedError{Name: p.config.Name}
+	}
+	if !p.client.Exited() {
+		return p.conn, nil
+	}
+	if time.Since(p.startedAt) < DefaultRestartDelay {
+		return nil, &ProcessExitedError{Name: p.config.Name}
+	}
+	p.logger.Warn().Str("op", "external.process").Msg("plugin process exited, restarting")
+	p.client.Kill()
+	err := p.start()
+	if err != nil {
+		return nil, err
+	}
+	return p.conn, nil
+}
+
+// running returns true if conn belongs to the plugin process currently running
+func (p *Plugin) running(conn *grpcClient) bool {
+	p.Lock()
+	defer p.Unlock()
+	return !p.stopped && p.conn == conn && !p.client.Exited()
+}
+
+// Stop ends the plugin process, the plugin cannot be used afterwards
+func (p *Plugin) Stop() {
+	p.Lock()
+	defer p.Unlock()
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	p.client.Kill()
+	p.logger.Info().Str("op", "external.Stop").Msg("plugin process stopped")
+}
+
+// Info returns what the plugin process reported about itself when it was last started
+func (p *Plugin) Info() Info {
+	p.Lock()
+	defer p.Unlock()
+	return p.info
+}
+
+func (p *Plugin) Name() string {
+	return p.config.Name
+}
+
+func (p *Plugin) Version() string {
+	return p.Info().Version
+}
+
+func (p *Plugin) CommitID() string {
+	return p.Info().CommitID
+}
+
+func (p *Plugin) Config() string {
+	return p.config.Path
+}
+
+func (p *Plugin) SupportedTypes() bit.Mask {
+	return p.Info().Types
+}
+
+// ResolvesSecrets is true, configs are handed to the plugin process with their secret references in place
+func (p *Plugin) ResolvesSecrets() bool {
+	return true
+}
+
+func (p *Plugin) ReceiverHash(config interface{}) (string, error) {
+	return hasher.Hash(config), nil
+}
+
+func (p *Plugin) FiltererHash(config interface{}) (string, error) {
+	return hasher.Hash(config), nil
+}
+
+func (p *Plugin) SenderHash(config interface{}) (string, error) {
+	return hasher.Hash(config), nil
+}
+
+func (p *Plugin) NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	i, err := p.newInstance(pkgplugin.TypeReceiver, tid, plugin, name, config, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{instance: i, tid: tid, plugin: plugin, name: name, config: config}, nil
+}
+
+func (p *Plugin) NewFilterer(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (filter.Filterer, error) {
+	i, err := p.newInstance(pkgplugin.TypeFilter, tid, plugin, name, config, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{instance: i, tid: tid, plugin: plugin, name: name, config: config}, nil
+}
+
+func (p *Plugin) NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	i, err := p.newInstance(pkgplugin.TypeSender, tid, plugin, name, config, secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{instance: i, tid: tid, plugin: plugin, name: name, config: config}, nil
+}
+
+func (p *Plugin) newInstance(kind bit.Mask, tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (*instance, error) {
+	req, err := newInstanceRequest(tid, plugin, name, config)
+	if err != nil {
+		return nil, err
+	}
+	i := &instance{plugin: p, kind: kind, request: req, secrets: secrets}
+	_, _, err = i.get(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// get returns the clients of the plugin process and the id of the instance in it, creating the instance if the
+// process is new. Each instance gets its own vault on the broker of the plugin process.
+func (i *instance) get(ctx context.Context) (*grpcClient, string, error) {
+	conn, err := i.plugin.process()
+	if err != nil {
+		return nil, "", err
+	}
+	i.Lock()
+	defer i.Unlock()
+	if i.conn == conn {
+		return conn, i.id, nil
+	}
+	if i.stopSecrets != nil {
+		i.stopSecrets()
+		i.stopSecrets = nil
+	}
+	brokerId, stopSecrets := conn.serveSecrets(i.secrets)
+	i.request.SecretsBrokerId = brokerId
+	var in *proto.Instance
+	switch i.kind {
+	case pkgplugin.TypeReceiver:
+		in, err = conn.receivers.NewReceiver(ctx, i.request)
+	case pkgplugin.TypeFilter:
+		in, err = conn.filterers.NewFilterer(ctx, i.request)
+	case pkgplugin.TypeSender:
+		in, err = conn.senders.NewSender(ctx, i.request)
+	}
+	if err != nil {
+		stopSecrets()
+		return nil, "", pluginError(err)
+	}
+	i.conn = conn
+	i.id = in.Id
+	i.stopSecrets = stopSecrets
+	return conn, i.id, nil
+}
+
+// release removes the instance from its plugin process and stops serving its vault
+func (i *instance) release(ctx context.Context) error {
+	i.Lock()
+	conn, id, stopSecrets := i.conn, i.id, i.stopSecrets
+	i.conn = nil
+	i.stopSecrets = nil
+	i.Unlock()
+	if conn == nil {
+		return nil
+	}
+	defer stopSecrets()
+	if !i.plugin.running(conn) {
+		return nil
+	}
+	var err error
+	switch i.kind {
+	case pkgplugin.TypeReceiver:
+		_, err = conn.receivers.StopReceiving(ctx, &proto.Instance{Id: id})
+	case pkgplugin.TypeFilter:
+		_, err = conn.filterers.Release(ctx, &proto.Instance{Id: id})
+	case pkgplugin.TypeSender:
+		_, err = conn.senders.StopSending(ctx, &proto.Instance{Id: id})
+	}
+	if err != nil {
+		return pluginError(err)
+	}
+	return nil
+}
+
+// checkHealth asks the plugin process about the health of the instance, an instance whose process is gone is unhealthy
+func (i *instance) checkHealth(ctx context.Context) error {
+	i.Lock()
+	conn, id := i.conn, i.id
+	i.Unlock()
+	if conn == nil {
+		return nil
+	}
+	if !i.plugin.running(conn) {
+		return &ProcessExitedError{Name: i.plugin.config.Name}
+	}
+	var err error
+	switch i.kind {
+	case pkgplugin.TypeReceiver:
+		_, err = conn.receivers.CheckHealth(ctx, &proto.Instance{Id: id})
+	case pkgplugin.TypeSender:
+		_, err = conn.senders.CheckHealth(ctx, &proto.Instance{Id: id})
+	}
+	if err != nil {
+		return pluginError(err)
+	}
+	return nil
+}
+
+// logWriter logs what a plugin process writes line by line
+type logWriter struct {
+	sync.Mutex
+	logger *zerolog.Logger
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		n := bytes.IndexByte(w.buf, '\n')
+		if n < 0 {
+			break
+		}
+		w.logger.Info().Str("op", "external.output").Msg(string(w.buf[:n]))
+		w.buf = w.buf[n+1:]
+	}
+	return len(p), nil
+}
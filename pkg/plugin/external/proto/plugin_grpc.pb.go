@@ -0,0 +1,776 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// PluginClient is the client API for Plugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PluginClient interface {
+	Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*InfoResponse, error)
+}
+
+type pluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginClient(cc grpc.ClientConnInterface) PluginClient {
+	return &pluginClient{cc}
+}
+
+func (c *pluginClient) Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Plugin/Info", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PluginServer is the server API for Plugin service.
+// All implementations must embed UnimplementedPluginServer
+// for forward compatibility
+type PluginServer interface {
+	Info(context.Context, *Empty) (*InfoResponse, error)
+	mustEmbedUnimplementedPluginServer()
+}
+
+// UnimplementedPluginServer must be embedded to have forward compatible implementations.
+type UnimplementedPluginServer struct {
+}
+
+func (UnimplementedPluginServer) Info(context.Context, *Empty) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedPluginServer) mustEmbedUnimplementedPluginServer() {}
+
+// UnsafePluginServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PluginServer will
+// result in compilation errors.
+type UnsafePluginServer interface {
+	mustEmbedUnimplementedPluginServer()
+}
+
+func RegisterPluginServer(s grpc.ServiceRegistrar, srv PluginServer) {
+	s.RegisterService(&Plugin_ServiceDesc, srv)
+}
+
+func _Plugin_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Plugin/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Info(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Plugin_ServiceDesc is the grpc.ServiceDesc for Plugin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Plugin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ears.plugin.v1.Plugin",
+	HandlerType: (*PluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Plugin_Info_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
+
+// ReceiverClient is the client API for Receiver service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReceiverClient interface {
+	// NewReceiver reports an invalid config as INVALID_ARGUMENT
+	NewReceiver(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error)
+	// Receive runs the receiver, it streams the received events to EARS and EARS answers each with an ack once the
+	// event is acked or nacked. The receiver is stopped when EARS closes the stream.
+	Receive(ctx context.Context, opts ...grpc.CallOption) (Receiver_ReceiveClient, error)
+	StopReceiving(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error)
+	CheckHealth(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type receiverClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReceiverClient(cc grpc.ClientConnInterface) ReceiverClient {
+	return &receiverClient{cc}
+}
+
+func (c *receiverClient) NewReceiver(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error) {
+	out := new(Instance)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Receiver/NewReceiver", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *receiverClient) Receive(ctx context.Context, opts ...grpc.CallOption) (Receiver_ReceiveClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Receiver_ServiceDesc.Streams[0], "/ears.plugin.v1.Receiver/Receive", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &receiverReceiveClient{stream}
+	return x, nil
+}
+
+type Receiver_ReceiveClient interface {
+	Send(*ReceiveRequest) error
+	Recv() (*ReceivedEvent, error)
+	grpc.ClientStream
+}
+
+type receiverReceiveClient struct {
+	grpc.ClientStream
+}
+
+func (x *receiverReceiveClient) Send(m *ReceiveRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *receiverReceiveClient) Recv() (*ReceivedEvent, error) {
+	m := new(ReceivedEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *receiverClient) StopReceiving(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Receiver/StopReceiving", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *receiverClient) CheckHealth(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Receiver/CheckHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReceiverServer is the server API for Receiver service.
+// All implementations must embed UnimplementedReceiverServer
+// for forward compatibility
+type ReceiverServer interface {
+	// NewReceiver reports an invalid config as INVALID_ARGUMENT
+	NewReceiver(context.Context, *NewInstanceRequest) (*Instance, error)
+	// Receive runs the receiver, it streams the received events to EARS and EARS answers each with an ack once the
+	// event is acked or nacked. The receiver is stopped when EARS closes the stream.
+	Receive(Receiver_ReceiveServer) error
+	StopReceiving(context.Context, *Instance) (*Empty, error)
+	CheckHealth(context.Context, *Instance) (*Empty, error)
+	mustEmbedUnimplementedReceiverServer()
+}
+
+// UnimplementedReceiverServer must be embedded to have forward compatible implementations.
+type UnimplementedReceiverServer struct {
+}
+
+func (UnimplementedReceiverServer) NewReceiver(context.Context, *NewInstanceRequest) (*Instance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewReceiver not implemented")
+}
+func (UnimplementedReceiverServer) Receive(Receiver_ReceiveServer) error {
+	return status.Errorf(codes.Unimplemented, "method Receive not implemented")
+}
+func (UnimplementedReceiverServer) StopReceiving(context.Context, *Instance) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopReceiving not implemented")
+}
+func (UnimplementedReceiverServer) CheckHealth(context.Context, *Instance) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
+}
+func (UnimplementedReceiverServer) mustEmbedUnimplementedReceiverServer() {}
+
+// UnsafeReceiverServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReceiverServer will
+// result in compilation errors.
+type UnsafeReceiverServer interface {
+	mustEmbedUnimplementedReceiverServer()
+}
+
+func RegisterReceiverServer(s grpc.ServiceRegistrar, srv ReceiverServer) {
+	s.RegisterService(&Receiver_ServiceDesc, srv)
+}
+
+func _Receiver_NewReceiver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiverServer).NewReceiver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Receiver/NewReceiver",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiverServer).NewReceiver(ctx, req.(*NewInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Receiver_Receive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReceiverServer).Receive(&receiverReceiveServer{stream})
+}
+
+type Receiver_ReceiveServer interface {
+	Send(*ReceivedEvent) error
+	Recv() (*ReceiveRequest, error)
+	grpc.ServerStream
+}
+
+type receiverReceiveServer struct {
+	grpc.ServerStream
+}
+
+func (x *receiverReceiveServer) Send(m *ReceivedEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *receiverReceiveServer) Recv() (*ReceiveRequest, error) {
+	m := new(ReceiveRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Receiver_StopReceiving_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiverServer).StopReceiving(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Receiver/StopReceiving",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiverServer).StopReceiving(ctx, req.(*Instance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Receiver_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReceiverServer).CheckHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Receiver/CheckHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReceiverServer).CheckHealth(ctx, req.(*Instance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Receiver_ServiceDesc is the grpc.ServiceDesc for Receiver service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Receiver_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ears.plugin.v1.Receiver",
+	HandlerType: (*ReceiverServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewReceiver",
+			Handler:    _Receiver_NewReceiver_Handler,
+		},
+		{
+			MethodName: "StopReceiving",
+			Handler:    _Receiver_StopReceiving_Handler,
+		},
+		{
+			MethodName: "CheckHealth",
+			Handler:    _Receiver_CheckHealth_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Receive",
+			Handler:       _Receiver_Receive_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
+
+// FiltererClient is the client API for Filterer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FiltererClient interface {
+	NewFilterer(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error)
+	// Filter returns the resulting events, an error nacks the event
+	Filter(ctx context.Context, in *FilterRequest, opts ...grpc.CallOption) (*FilterResponse, error)
+	Release(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type filtererClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFiltererClient(cc grpc.ClientConnInterface) FiltererClient {
+	return &filtererClient{cc}
+}
+
+func (c *filtererClient) NewFilterer(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error) {
+	out := new(Instance)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Filterer/NewFilterer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filtererClient) Filter(ctx context.Context, in *FilterRequest, opts ...grpc.CallOption) (*FilterResponse, error) {
+	out := new(FilterResponse)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Filterer/Filter", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *filtererClient) Release(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Filterer/Release", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FiltererServer is the server API for Filterer service.
+// All implementations must embed UnimplementedFiltererServer
+// for forward compatibility
+type FiltererServer interface {
+	NewFilterer(context.Context, *NewInstanceRequest) (*Instance, error)
+	// Filter returns the resulting events, an error nacks the event
+	Filter(context.Context, *FilterRequest) (*FilterResponse, error)
+	Release(context.Context, *Instance) (*Empty, error)
+	mustEmbedUnimplementedFiltererServer()
+}
+
+// UnimplementedFiltererServer must be embedded to have forward compatible implementations.
+type UnimplementedFiltererServer struct {
+}
+
+func (UnimplementedFiltererServer) NewFilterer(context.Context, *NewInstanceRequest) (*Instance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewFilterer not implemented")
+}
+func (UnimplementedFiltererServer) Filter(context.Context, *FilterRequest) (*FilterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Filter not implemented")
+}
+func (UnimplementedFiltererServer) Release(context.Context, *Instance) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Release not implemented")
+}
+func (UnimplementedFiltererServer) mustEmbedUnimplementedFiltererServer() {}
+
+// UnsafeFiltererServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FiltererServer will
+// result in compilation errors.
+type UnsafeFiltererServer interface {
+	mustEmbedUnimplementedFiltererServer()
+}
+
+func RegisterFiltererServer(s grpc.ServiceRegistrar, srv FiltererServer) {
+	s.RegisterService(&Filterer_ServiceDesc, srv)
+}
+
+func _Filterer_NewFilterer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FiltererServer).NewFilterer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Filterer/NewFilterer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FiltererServer).NewFilterer(ctx, req.(*NewInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filterer_Filter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FilterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FiltererServer).Filter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Filterer/Filter",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FiltererServer).Filter(ctx, req.(*FilterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Filterer_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FiltererServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Filterer/Release",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FiltererServer).Release(ctx, req.(*Instance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Filterer_ServiceDesc is the grpc.ServiceDesc for Filterer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Filterer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ears.plugin.v1.Filterer",
+	HandlerType: (*FiltererServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewFilterer",
+			Handler:    _Filterer_NewFilterer_Handler,
+		},
+		{
+			MethodName: "Filter",
+			Handler:    _Filterer_Filter_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _Filterer_Release_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
+
+// SenderClient is the client API for Sender service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SenderClient interface {
+	NewSender(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error)
+	// Send returns once the event is delivered, an error nacks the event
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*Empty, error)
+	StopSending(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error)
+	CheckHealth(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type senderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSenderClient(cc grpc.ClientConnInterface) SenderClient {
+	return &senderClient{cc}
+}
+
+func (c *senderClient) NewSender(ctx context.Context, in *NewInstanceRequest, opts ...grpc.CallOption) (*Instance, error) {
+	out := new(Instance)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Sender/NewSender", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *senderClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Sender/Send", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *senderClient) StopSending(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Sender/StopSending", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *senderClient) CheckHealth(ctx context.Context, in *Instance, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Sender/CheckHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SenderServer is the server API for Sender service.
+// All implementations must embed UnimplementedSenderServer
+// for forward compatibility
+type SenderServer interface {
+	NewSender(context.Context, *NewInstanceRequest) (*Instance, error)
+	// Send returns once the event is delivered, an error nacks the event
+	Send(context.Context, *SendRequest) (*Empty, error)
+	StopSending(context.Context, *Instance) (*Empty, error)
+	CheckHealth(context.Context, *Instance) (*Empty, error)
+	mustEmbedUnimplementedSenderServer()
+}
+
+// UnimplementedSenderServer must be embedded to have forward compatible implementations.
+type UnimplementedSenderServer struct {
+}
+
+func (UnimplementedSenderServer) NewSender(context.Context, *NewInstanceRequest) (*Instance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewSender not implemented")
+}
+func (UnimplementedSenderServer) Send(context.Context, *SendRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedSenderServer) StopSending(context.Context, *Instance) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopSending not implemented")
+}
+func (UnimplementedSenderServer) CheckHealth(context.Context, *Instance) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
+}
+func (UnimplementedSenderServer) mustEmbedUnimplementedSenderServer() {}
+
+// UnsafeSenderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SenderServer will
+// result in compilation errors.
+type UnsafeSenderServer interface {
+	mustEmbedUnimplementedSenderServer()
+}
+
+func RegisterSenderServer(s grpc.ServiceRegistrar, srv SenderServer) {
+	s.RegisterService(&Sender_ServiceDesc, srv)
+}
+
+func _Sender_NewSender_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewInstanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SenderServer).NewSender(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Sender/NewSender",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SenderServer).NewSender(ctx, req.(*NewInstanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sender_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SenderServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Sender/Send",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SenderServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sender_StopSending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SenderServer).StopSending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Sender/StopSending",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SenderServer).StopSending(ctx, req.(*Instance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sender_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Instance)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SenderServer).CheckHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Sender/CheckHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SenderServer).CheckHealth(ctx, req.(*Instance))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sender_ServiceDesc is the grpc.ServiceDesc for Sender service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sender_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ears.plugin.v1.Sender",
+	HandlerType: (*SenderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewSender",
+			Handler:    _Sender_NewSender_Handler,
+		},
+		{
+			MethodName: "Send",
+			Handler:    _Sender_Send_Handler,
+		},
+		{
+			MethodName: "StopSending",
+			Handler:    _Sender_StopSending_Handler,
+		},
+		{
+			MethodName: "CheckHealth",
+			Handler:    _Sender_CheckHealth_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
+
+// SecretsClient is the client API for Secrets service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SecretsClient interface {
+	Secret(ctx context.Context, in *SecretRequest, opts ...grpc.CallOption) (*SecretResponse, error)
+}
+
+type secretsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSecretsClient(cc grpc.ClientConnInterface) SecretsClient {
+	return &secretsClient{cc}
+}
+
+func (c *secretsClient) Secret(ctx context.Context, in *SecretRequest, opts ...grpc.CallOption) (*SecretResponse, error) {
+	out := new(SecretResponse)
+	err := c.cc.Invoke(ctx, "/ears.plugin.v1.Secrets/Secret", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SecretsServer is the server API for Secrets service.
+// All implementations must embed UnimplementedSecretsServer
+// for forward compatibility
+type SecretsServer interface {
+	Secret(context.Context, *SecretRequest) (*SecretResponse, error)
+	mustEmbedUnimplementedSecretsServer()
+}
+
+// UnimplementedSecretsServer must be embedded to have forward compatible implementations.
+type UnimplementedSecretsServer struct {
+}
+
+func (UnimplementedSecretsServer) Secret(context.Context, *SecretRequest) (*SecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Secret not implemented")
+}
+func (UnimplementedSecretsServer) mustEmbedUnimplementedSecretsServer() {}
+
+// UnsafeSecretsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SecretsServer will
+// result in compilation errors.
+type UnsafeSecretsServer interface {
+	mustEmbedUnimplementedSecretsServer()
+}
+
+func RegisterSecretsServer(s grpc.ServiceRegistrar, srv SecretsServer) {
+	s.RegisterService(&Secrets_ServiceDesc, srv)
+}
+
+func _Secrets_Secret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretsServer).Secret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ears.plugin.v1.Secrets/Secret",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretsServer).Secret(ctx, req.(*SecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Secrets_ServiceDesc is the grpc.ServiceDesc for Secrets service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Secrets_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ears.plugin.v1.Secrets",
+	HandlerType: (*SecretsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Secret",
+			Handler:    _Secrets_Secret_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/xmidt-org/ears/pkg/event"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// secretTimeout is how long a plugin process waits for EARS to look up a secret
+const secretTimeout = 10 * time.Second
+
+// pluginMap returns the plugins known to go-plugin, the server is only set in plugin processes
+func pluginMap(s *server) map[string]plugin.Plugin {
+	return map[string]plugin.Plugin{
+		PluginName: &grpcPlugin{server: s},
+	}
+}
+
+// GRPCServer registers the services of the plugin types the plugin supports
+func (p *grpcPlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
+	p.server.broker = broker
+	proto.RegisterPluginServer(s, p.server)
+	types := p.server.plugin.SupportedTypes()
+	if types.IsSet(pkgplugin.TypeReceiver) {
+		proto.RegisterReceiverServer(s, p.server)
+	}
+	if types.IsSet(pkgplugin.TypeFilter) {
+		proto.RegisterFiltererServer(s, p.server)
+	}
+	if types.IsSet(pkgplugin.TypeSender) {
+		proto.RegisterSenderServer(s, p.server)
+	}
+	return nil
+}
+
+func (p *grpcPlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return &grpcClient{
+		broker:    broker,
+		plugin:    proto.NewPluginClient(conn),
+		receivers: proto.NewReceiverClient(conn),
+		filterers: proto.NewFiltererClient(conn),
+		senders:   proto.NewSenderClient(conn),
+	}, nil
+}
+
+// serveSecrets serves the vault to the plugin process on a new broker id, the returned function stops serving
+func (c *grpcClient) serveSecrets(vault secret.Vault) (uint32, func()) {
+	id := c.broker.NextId()
+	servers := make(chan *grpc.Server, 1)
+	go c.broker.AcceptAndServe(id, func(opts []grpc.ServerOption) *grpc.Server {
+		s := grpc.NewServer(opts...)
+		proto.RegisterSecretsServer(s, &secretsServer{vault: vault})
+		servers <- s
+		return s
+	})
+	return id, func() {
+		s := <-servers
+		s.Stop()
+	}
+}
+
+// secretsServer looks up the secrets of a receiver, filterer or sender for its plugin process
+type secretsServer struct {
+	proto.UnimplementedSecretsServer
+	vault secret.Vault
+}
+
+func (s *secretsServer) Secret(ctx context.Context, in *proto.SecretRequest) (*proto.SecretResponse, error) {
+	resp := &proto.SecretResponse{}
+	if s.vault != nil {
+		resp.Value = s.vault.Secret(in.Key)
+	}
+	return resp, nil
+}
+
+// secretsClient is the vault handed to receivers, filterers and senders in a plugin process, it asks EARS for
+// every secret so that secrets never leave EARS unless a plugin needs them
+type secretsClient struct {
+	client proto.SecretsClient
+}
+
+func (c *secretsClient) Secret(key string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	resp, err := c.client.Secret(ctx, &proto.SecretRequest{Key: key})
+	if err != nil {
+		return ""
+	}
+	return resp.Value
+}
+
+// wireEvent is an event as it travels between EARS and a plugin process
+type wireEvent struct {
+	id          string
+	payload     interface{}
+	metadata    map[string]interface{}
+	contentType string
+	data        []byte
+}
+
+// encodeEvent turns an event into its message, the payload and metadata are encoded as json unless the event
+// has a binary payload
+func encodeEvent(e event.Event) (*proto.Event, error) {
+	pe := &proto.Event{Id: e.Id()}
+	if ct := e.ContentType(); ct != event.ContentTypeJSON {
+		pe.ContentType = ct
+		pe.Payload, _ = e.Payload().([]byte)
+	} else if raw, ok := e.RawPayload(); ok {
+		pe.Payload = raw
+	} else {
+		buf, err := json.Marshal(e.Payload())
+		if err != nil {
+			return nil, err
+		}
+		pe.Payload = buf
+	}
+	if md := e.Metadata(); md != nil {
+		buf, err := json.Marshal(md)
+		if err != nil {
+			return nil, err
+		}
+		pe.Metadata = buf
+	}
+	return pe, nil
+}
+
+func decodeEvent(pe *proto.Event) (*wireEvent, error) {
+	if pe == nil {
+		return nil, &event.InvalidPayloadError{}
+	}
+	w := &wireEvent{
+		id:          pe.Id,
+		contentType: pe.ContentType,
+	}
+	if w.contentType != "" {
+		w.data = pe.Payload
+	} else if len(pe.Payload) > 0 {
+		err := json.Unmarshal(pe.Payload, &w.payload)
+		if err != nil {
+			return nil, &event.InvalidPayloadError{Err: err}
+		}
+	}
+	if len(pe.Metadata) > 0 {
+		err := json.Unmarshal(pe.Metadata, &w.metadata)
+		if err != nil {
+			return nil, &event.InvalidPayloadError{Err: err}
+		}
+	}
+	return w, nil
+}
+
+// options returns the event options carrying the id, metadata and binary payload of a wire event
+func (w *wireEvent) options() []event.EventOption {
+	var options []event.EventOption
+	if w.id != "" {
+		options = append(options, event.WithId(w.id))
+	}
+	if w.metadata != nil {
+		options = append(options, event.WithMetadata(w.metadata))
+	}
+	if w.contentType != "" {
+		options = append(options, event.WithBinaryPayload(w.data, w.contentType))
+	}
+	return options
+}
+
+// newInstanceRequest is the request of NewReceiver, NewFilterer and NewSender, the config keeps its secret
+// references, the broker id of the vault is set for each plugin process
+func newInstanceRequest(tid tenant.Id, plugin string, name string, config interface{}) (*proto.NewInstanceRequest, error) {
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return nil, &pkgplugin.InvalidConfigError{Err: err}
+	}
+	return &proto.NewInstanceRequest{
+		Tenant: &proto.Tenant{OrgId: tid.OrgId, AppId: tid.AppId},
+		Plugin: plugin,
+		Name:   name,
+		Config: buf,
+	}, nil
+}
+
+func instanceRequestOf(in *proto.NewInstanceRequest) (tenant.Id, interface{}, error) {
+	tid := tenant.Id{
+		OrgId: in.GetTenant().GetOrgId(),
+		AppId: in.GetTenant().GetAppId(),
+	}
+	var config interface{}
+	if len(in.Config) > 0 {
+		err := json.Unmarshal(in.Config, &config)
+		if err != nil {
+			return tid, nil, err
+		}
+	}
+	return tid, config, nil
+}
+
+// pluginError turns the status of a failed call into the error EARS plugins return
+func pluginError(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return &pkgplugin.InvalidConfigError{Err: errors.New(status.Convert(err).Message())}
+	case codes.Unimplemented:
+		return &pkgplugin.NotSupportedError{}
+	}
+	return &pkgplugin.Error{Err: err}
+}
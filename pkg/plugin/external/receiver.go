@@ -0,0 +1,168 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var _ receiver.Receiver = (*Receiver)(nil)
+var _ receiver.HealthChecker = (*Receiver)(nil)
+
+// DefaultEventTimeout is how long events received from a plugin process have to be acked
+const DefaultEventTimeout = 5 * time.Second
+
+// Receive streams the events of the receiver in the plugin process and returns the ack or nack of each event to
+// the plugin process. Receive returns an error if the stream breaks, e.g. because the plugin process crashed.
+func (r *Receiver) Receive(next receiver.NextFn) error {
+	if next == nil {
+		return &receiver.InvalidConfigError{
+			Err: fmt.Errorf("next cannot be nil"),
+		}
+	}
+	conn, id, err := r.instance.get(context.Background())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Lock()
+	r.next = next
+	r.cancel = cancel
+	r.err = nil
+	r.Unlock()
+	stream, err := conn.receivers.Receive(ctx)
+	if err != nil {
+		return r.fail(ctx, pluginError(err))
+	}
+	err = stream.Send(&proto.ReceiveRequest{Request: &proto.ReceiveRequest_Start{Start: &proto.Instance{Id: id}}})
+	if err != nil {
+		return r.fail(ctx, pluginError(err))
+	}
+	var sendLock sync.Mutex
+	ack := func(seq uint64, err error) {
+		a := &proto.Ack{Seq: seq}
+		if err != nil {
+			a.Error = err.Error()
+		}
+		sendLock.Lock()
+		stream.Send(&proto.ReceiveRequest{Request: &proto.ReceiveRequest_Ack{Ack: a}})
+		sendLock.Unlock()
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return r.fail(ctx, pluginError(err))
+		}
+		seq := msg.Seq
+		w, err := decodeEvent(msg.Event)
+		if err != nil {
+			ack(seq, err)
+			continue
+		}
+		ectx, ecancel := context.WithTimeout(context.Background(), DefaultEventTimeout)
+		options := append(w.options(),
+			event.WithAck(
+				func(e event.Event) {
+					ack(seq, nil)
+					ecancel()
+				},
+				func(e event.Event, err error) {
+					log.Ctx(e.Context()).Error().Str("op", "external.Receive").Str("name", r.Name()).Msg("failed to process message: " + err.Error())
+					ack(seq, err)
+					ecancel()
+				}),
+			event.WithOtelTracing(r.Name()),
+			event.WithTenant(r.Tenant()))
+		e, err := event.New(ectx, w.payload, options...)
+		if err != nil {
+			ecancel()
+			ack(seq, err)
+			continue
+		}
+		r.Trigger(e)
+	}
+}
+
+// fail records the error that ended the event stream unless the receiver was stopped
+func (r *Receiver) fail(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	r.Lock()
+	r.err = err
+	r.Unlock()
+	return err
+}
+
+func (r *Receiver) StopReceiving(ctx context.Context) error {
+	r.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return r.instance.release(ctx)
+}
+
+// CheckHealth reports the error that ended the event stream, a plugin process that is gone or the health
+// of the receiver in the plugin process
+func (r *Receiver) CheckHealth(ctx context.Context) error {
+	r.Lock()
+	err := r.err
+	r.Unlock()
+	if err != nil {
+		return err
+	}
+	return r.instance.checkHealth(ctx)
+}
+
+func (r *Receiver) Trigger(e event.Event) {
+	r.Lock()
+	next := r.next
+	r.Unlock()
+	if next == nil {
+		e.Nack(&pkgplugin.Error{Err: fmt.Errorf("receiver %s is not receiving", r.name)})
+		return
+	}
+	next(e)
+}
+
+func (r *Receiver) Config() interface{} {
+	return r.config
+}
+
+func (r *Receiver) Name() string {
+	return r.name
+}
+
+func (r *Receiver) Plugin() string {
+	return r.plugin
+}
+
+func (r *Receiver) Tenant() tenant.Id {
+	return r.tid
+}
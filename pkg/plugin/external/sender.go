@@ -0,0 +1,77 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+var _ sender.Sender = (*Sender)(nil)
+var _ sender.HealthChecker = (*Sender)(nil)
+
+// Send hands the event to the sender in the plugin process and waits for it to be delivered, the event is acked
+// or nacked with the outcome
+func (s *Sender) Send(evt event.Event) {
+	conn, id, err := s.instance.get(evt.Context())
+	if err != nil {
+		evt.Nack(err)
+		return
+	}
+	in, err := encodeEvent(evt)
+	if err != nil {
+		evt.Nack(err)
+		return
+	}
+	_, err = conn.senders.Send(evt.Context(), &proto.SendRequest{Id: id, Event: in})
+	if err != nil {
+		evt.Nack(pluginError(err))
+		return
+	}
+	evt.Ack()
+}
+
+func (s *Sender) Unwrap() sender.Sender {
+	return s
+}
+
+func (s *Sender) StopSending(ctx context.Context) {
+	s.instance.release(ctx)
+}
+
+// CheckHealth reports a plugin process that is gone or the health of the sender in the plugin process
+func (s *Sender) CheckHealth(ctx context.Context) error {
+	return s.instance.checkHealth(ctx)
+}
+
+func (s *Sender) Config() interface{} {
+	return s.config
+}
+
+func (s *Sender) Name() string {
+	return s.name
+}
+
+func (s *Sender) Plugin() string {
+	return s.plugin
+}
+
+func (s *Sender) Tenant() tenant.Id {
+	return s.tid
+}
@@ -0,0 +1,445 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Serve runs the plugin p in a plugin process started by EARS using go-plugin and returns once EARS shuts the
+// plugin process down. Plugin binaries written in Go call it from main:
+//
+//	func main() {
+//	    p, _ := myplugin.NewPlugin()
+//	    if err := external.Serve(p); err != nil {
+//	        os.Exit(1)
+//	    }
+//	}
+func Serve(p pkgplugin.Pluginer) error {
+	// go-plugin exits the process if it was not started as a plugin, report it instead
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return &NotPluginProcessError{}
+	}
+	srv := newServer(p)
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         pluginMap(srv),
+		GRPCServer:      plugin.DefaultGRPCServer,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:       p.Name(),
+			Level:      hclog.Warn,
+			Output:     os.Stderr,
+			JSONFormat: true,
+		}),
+	})
+	srv.stop()
+	return nil
+}
+
+// server serves the receivers, filterers and senders of a plugin to EARS
+type server struct {
+	sync.Mutex
+	proto.UnimplementedPluginServer
+	proto.UnimplementedReceiverServer
+	proto.UnimplementedFiltererServer
+	proto.UnimplementedSenderServer
+	plugin    pkgplugin.Pluginer
+	broker    *plugin.GRPCBroker
+	receivers map[string]*receiverInstance
+	filterers map[string]filter.Filterer
+	senders   map[string]sender.Sender
+	secrets   map[string]*grpc.ClientConn
+	lastId    int
+}
+
+type receiverInstance struct {
+	receiver.Receiver
+	stopOnce sync.Once
+}
+
+func newServer(p pkgplugin.Pluginer) *server {
+	return &server{
+		plugin:    p,
+		receivers: make(map[string]*receiverInstance),
+		filterers: make(map[string]filter.Filterer),
+		senders:   make(map[string]sender.Sender),
+		secrets:   make(map[string]*grpc.ClientConn),
+	}
+}
+
+func (s *server) nextId() string {
+	s.Lock()
+	defer s.Unlock()
+	s.lastId++
+	return strconv.Itoa(s.lastId)
+}
+
+func (s *server) Info(ctx context.Context, in *proto.Empty) (*proto.InfoResponse, error) {
+	info := &proto.InfoResponse{
+		Name:     s.plugin.Name(),
+		Version:  s.plugin.Version(),
+		CommitId: s.plugin.CommitID(),
+	}
+	if s.plugin.SupportedTypes().IsSet(pkgplugin.TypeReceiver) {
+		info.Types = append(info.Types, "receiver")
+	}
+	if s.plugin.SupportedTypes().IsSet(pkgplugin.TypeFilter) {
+		info.Types = append(info.Types, "filter")
+	}
+	if s.plugin.SupportedTypes().IsSet(pkgplugin.TypeSender) {
+		info.Types = append(info.Types, "sender")
+	}
+	return info, nil
+}
+
+// newInstance connects to the vault EARS serves for the instance, resolves the secret references of the config with
+// it and calls fn with the resolved config and the vault. The vault stays connected until the instance is released.
+func (s *server) newInstance(in *proto.NewInstanceRequest, fn func(tid tenant.Id, config interface{}, secrets secret.Vault) error) (string, error) {
+	tid, config, err := instanceRequestOf(in)
+	if err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	conn, err := s.broker.Dial(in.SecretsBrokerId)
+	if err != nil {
+		return "", status.Error(codes.Unavailable, err.Error())
+	}
+	secrets := &secretsClient{client: proto.NewSecretsClient(conn)}
+	config, err = secret.Interpolate(secrets, config)
+	if err == nil {
+		err = fn(tid, config, secrets)
+	}
+	if err != nil {
+		conn.Close()
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	id := s.nextId()
+	s.Lock()
+	s.secrets[id] = conn
+	s.Unlock()
+	return id, nil
+}
+
+func (s *server) NewReceiver(ctx context.Context, in *proto.NewInstanceRequest) (*proto.Instance, error) {
+	newer, ok := s.plugin.(receiver.NewReceiverer)
+	if !ok || !s.plugin.SupportedTypes().IsSet(pkgplugin.TypeReceiver) {
+		return nil, status.Error(codes.Unimplemented, "plugin has no receiver")
+	}
+	var r receiver.Receiver
+	id, err := s.newInstance(in, func(tid tenant.Id, config interface{}, secrets secret.Vault) error {
+		var err error
+		r, err = newer.NewReceiver(tid, in.Plugin, in.Name, config, secrets)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Lock()
+	s.receivers[id] = &receiverInstance{Receiver: r}
+	s.Unlock()
+	return &proto.Instance{Id: id}, nil
+}
+
+func (s *server) NewFilterer(ctx context.Context, in *proto.NewInstanceRequest) (*proto.Instance, error) {
+	newer, ok := s.plugin.(filter.NewFilterer)
+	if !ok || !s.plugin.SupportedTypes().IsSet(pkgplugin.TypeFilter) {
+		return nil, status.Error(codes.Unimplemented, "plugin has no filterer")
+	}
+	var f filter.Filterer
+	id, err := s.newInstance(in, func(tid tenant.Id, config interface{}, secrets secret.Vault) error {
+		var err error
+		f, err = newer.NewFilterer(tid, in.Plugin, in.Name, config, secrets)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Lock()
+	s.filterers[id] = f
+	s.Unlock()
+	return &proto.Instance{Id: id}, nil
+}
+
+func (s *server) NewSender(ctx context.Context, in *proto.NewInstanceRequest) (*proto.Instance, error) {
+	newer, ok := s.plugin.(sender.NewSenderer)
+	if !ok || !s.plugin.SupportedTypes().IsSet(pkgplugin.TypeSender) {
+		return nil, status.Error(codes.Unimplemented, "plugin has no sender")
+	}
+	var snd sender.Sender
+	id, err := s.newInstance(in, func(tid tenant.Id, config interface{}, secrets secret.Vault) error {
+		var err error
+		snd, err = newer.NewSender(tid, in.Plugin, in.Name, config, secrets)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Lock()
+	s.senders[id] = snd
+	s.Unlock()
+	return &proto.Instance{Id: id}, nil
+}
+
+func (s *server) StopReceiving(ctx context.Context, in *proto.Instance) (*proto.Empty, error) {
+	s.release(ctx, in.Id)
+	return &proto.Empty{}, nil
+}
+
+func (s *server) Release(ctx context.Context, in *proto.Instance) (*proto.Empty, error) {
+	s.release(ctx, in.Id)
+	return &proto.Empty{}, nil
+}
+
+func (s *server) StopSending(ctx context.Context, in *proto.Instance) (*proto.Empty, error) {
+	s.release(ctx, in.Id)
+	return &proto.Empty{}, nil
+}
+
+// release stops the receiver or sender with the given id and disconnects its vault
+func (s *server) release(ctx context.Context, id string) {
+	s.Lock()
+	r := s.receivers[id]
+	snd := s.senders[id]
+	conn := s.secrets[id]
+	delete(s.receivers, id)
+	delete(s.senders, id)
+	delete(s.filterers, id)
+	delete(s.secrets, id)
+	s.Unlock()
+	if r != nil {
+		r.stop(ctx)
+	}
+	if snd != nil {
+		snd.StopSending(ctx)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// stop stops all receivers and senders when EARS goes away
+func (s *server) stop() {
+	s.Lock()
+	var ids []string
+	for id := range s.secrets {
+		ids = append(ids, id)
+	}
+	s.Unlock()
+	for _, id := range ids {
+		s.release(context.Background(), id)
+	}
+}
+
+func (r *receiverInstance) stop(ctx context.Context) {
+	r.stopOnce.Do(func() {
+		r.StopReceiving(ctx)
+	})
+}
+
+// handle creates an event from the event of a request and waits for the plugin to ack or nack it after
+// calling fn
+func handle(ctx context.Context, in *proto.Event, fn func(e event.Event) error) error {
+	w, err := decodeEvent(in)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	done := make(chan error, 1)
+	options := append(w.options(), event.WithAck(
+		func(e event.Event) {
+			done <- nil
+		},
+		func(e event.Event, err error) {
+			done <- err
+		}))
+	e, err := event.New(ctx, w.payload, options...)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = fn(e)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return nil
+}
+
+func (s *server) Filter(ctx context.Context, in *proto.FilterRequest) (*proto.FilterResponse, error) {
+	s.Lock()
+	f, ok := s.filterers[in.Id]
+	s.Unlock()
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown filterer")
+	}
+	out := &proto.FilterResponse{}
+	err := handle(ctx, in.Event, func(e event.Event) error {
+		events := f.Filter(e)
+		for _, fe := range events {
+			pe, err := encodeEvent(fe)
+			if err != nil {
+				return err
+			}
+			out.Events = append(out.Events, pe)
+		}
+		for _, fe := range events {
+			fe.Ack()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *server) Send(ctx context.Context, in *proto.SendRequest) (*proto.Empty, error) {
+	s.Lock()
+	snd, ok := s.senders[in.Id]
+	s.Unlock()
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown sender")
+	}
+	err := handle(ctx, in.Event, func(e event.Event) error {
+		snd.Send(e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proto.Empty{}, nil
+}
+
+// CheckHealth serves the Receiver and the Sender service, instance ids are unique across both
+func (s *server) CheckHealth(ctx context.Context, in *proto.Instance) (*proto.Empty, error) {
+	s.Lock()
+	var checker interface{}
+	if r, ok := s.receivers[in.Id]; ok {
+		checker = r.Receiver
+	} else if snd, ok := s.senders[in.Id]; ok {
+		checker = snd
+	}
+	s.Unlock()
+	if checker == nil {
+		return nil, status.Error(codes.NotFound, "unknown instance")
+	}
+	if hc, ok := checker.(interface {
+		CheckHealth(ctx context.Context) error
+	}); ok {
+		err := hc.CheckHealth(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+	return &proto.Empty{}, nil
+}
+
+// Receive runs the receiver started by the first message of the stream, sends its events to EARS and acks or
+// nacks them as EARS reports back. The receiver is stopped when EARS closes the stream.
+func (s *server) Receive(stream proto.Receiver_ReceiveServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if req.GetStart() == nil {
+		return status.Error(codes.InvalidArgument, "receive stream must start with the receiver")
+	}
+	s.Lock()
+	r, ok := s.receivers[req.GetStart().Id]
+	s.Unlock()
+	if !ok {
+		return status.Error(codes.NotFound, "unknown receiver")
+	}
+	var lock sync.Mutex
+	pending := make(map[uint64]event.Event)
+	seq := uint64(0)
+	go func() {
+		defer r.stop(context.Background())
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			ack := req.GetAck()
+			if ack == nil {
+				continue
+			}
+			lock.Lock()
+			e, ok := pending[ack.Seq]
+			delete(pending, ack.Seq)
+			lock.Unlock()
+			if !ok {
+				continue
+			}
+			if ack.Error != "" {
+				e.Nack(errors.New(ack.Error))
+			} else {
+				e.Ack()
+			}
+		}
+	}()
+	err = r.Receive(func(e event.Event) {
+		pe, err := encodeEvent(e)
+		if err != nil {
+			e.Nack(err)
+			return
+		}
+		lock.Lock()
+		seq++
+		n := seq
+		pending[n] = e
+		err = stream.Send(&proto.ReceivedEvent{Seq: n, Event: pe})
+		if err != nil {
+			delete(pending, n)
+		}
+		lock.Unlock()
+		if err != nil {
+			e.Nack(err)
+		}
+	})
+	lock.Lock()
+	for n, e := range pending {
+		delete(pending, n)
+		e.Nack(&pkgplugin.Error{Err: fmt.Errorf("receiver stopped")})
+	}
+	lock.Unlock()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package external runs receiver, filter and sender plugins as separate processes using hashicorp go-plugin. EARS
+// starts the plugin binary with plugin.NewClient and talks to it through the gRPC services generated from
+// proto/plugin.proto, so plugins can be written in any language go-plugin supports, Go plugins simply call Serve.
+// Plugin configs are handed over with their secret references in place, plugin processes resolve them through a
+// vault EARS serves on the go-plugin broker. A crashing plugin process does not take down EARS, it is restarted
+// the next time one of its receivers, filters or senders is used.
+package external
+
+//go:generate protoc --proto_path=proto --go_out=proto --go_opt=paths=source_relative --go-grpc_out=proto --go-grpc_opt=paths=source_relative plugin.proto
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/bit"
+	"github.com/xmidt-org/ears/pkg/plugin/external/proto"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	// MagicCookieKey and MagicCookieValue are set in the environment of plugin processes, Serve refuses to run
+	// without them so that plugin binaries are not started by accident
+	MagicCookieKey   = "EARS_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "c2f5d1e4-1b6e-4a0b-9a8e-6f3c0e7d2b91"
+
+	ProtocolVersion = 2
+
+	// PluginName is the name the plugin is dispensed under by go-plugin
+	PluginName = "ears"
+
+	DefaultStartTimeout = 10 * time.Second
+	// DefaultRestartDelay is the minimum time between two starts of a plugin process, so that a plugin crashing on
+	// startup is not restarted for every event
+	DefaultRestartDelay = time.Second
+)
+
+// Handshake is the go-plugin handshake of EARS and its plugin processes
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  ProtocolVersion,
+	MagicCookieKey:   MagicCookieKey,
+	MagicCookieValue: MagicCookieValue,
+}
+
+// Config describes an external plugin binary
+type Config struct {
+	Name         string        `yaml:"name"`
+	Path         string        `yaml:"path"`
+	Args         []string      `yaml:"args"`
+	StartTimeout time.Duration `yaml:"startTimeout"`
+}
+
+// Info is what a plugin process reports about itself
+type Info struct {
+	Name     string
+	Version  string
+	CommitID string
+	Types    bit.Mask
+}
+
+// Plugin implements Pluginer, NewReceiverer, NewFilterer and NewSenderer for a plugin binary by forwarding
+// to its plugin process
+type Plugin struct {
+	sync.Mutex
+	config    Config
+	logger    *zerolog.Logger
+	info      Info
+	client    *plugin.Client
+	conn      *grpcClient
+	startedAt time.Time
+	stopped   bool
+}
+
+// grpcPlugin is the go-plugin plugin of EARS, the plugin process serves the services of the plugin and EARS
+// dispenses a grpcClient for them
+type grpcPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	server *server
+}
+
+// grpcClient holds the clients of the services of a plugin process, instance ids handed out by a plugin process
+// are only valid for that process
+type grpcClient struct {
+	broker    *plugin.GRPCBroker
+	plugin    proto.PluginClient
+	receivers proto.ReceiverClient
+	filterers proto.FiltererClient
+	senders   proto.SenderClient
+}
+
+// instance is a receiver, filterer or sender living in a plugin process, it is created again in the new process
+// after the plugin process was restarted
+type instance struct {
+	sync.Mutex
+	plugin      *Plugin
+	kind        bit.Mask
+	request     *proto.NewInstanceRequest
+	secrets     secret.Vault
+	conn        *grpcClient
+	id          string
+	stopSecrets func()
+}
+
+type Receiver struct {
+	sync.Mutex
+	instance *instance
+	tid      tenant.Id
+	plugin   string
+	name     string
+	config   interface{}
+	next     receiver.NextFn
+	cancel   func()
+	err      error
+}
+
+type Filter struct {
+	instance *instance
+	tid      tenant.Id
+	plugin   string
+	name     string
+	config   interface{}
+}
+
+type Sender struct {
+	instance *instance
+	tid      tenant.Id
+	plugin   string
+	name     string
+	config   interface{}
+}
+
+// === Errors =========================================================
+
+// StartError is returned when a plugin process cannot be started or does not answer in time
+type StartError struct {
+	Name string
+	Err  error
+}
+
+// ProcessExitedError is returned when the plugin process is gone and cannot be restarted yet
+type ProcessExitedError struct {
+	Name string
+}
+
+// StoppedError is returned when a plugin is used after Stop was called
+type StoppedError struct {
+	Name string
+}
+
+// NotPluginProcessError is returned by Serve when the binary was not started by EARS
+type NotPluginProcessError struct{}
@@ -24,6 +24,14 @@ func (e *OpenPluginError) Error() string {
 	return errs.String("OpenPluginError", nil, e.Err)
 }
 
+func (e *LoadPluginError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LoadPluginError) Error() string {
+	return errs.String("LoadPluginError", map[string]interface{}{"path": e.Path}, e.Err)
+}
+
 func (e *NewPluginerError) Unwrap() error {
 	return e.Err
 }
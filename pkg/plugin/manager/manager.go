@@ -18,7 +18,10 @@ import (
 	"fmt"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	goplugin "plugin"
@@ -124,6 +127,31 @@ func (m *manager) LoadPlugin(config Config) (plugin.Pluginer, error) {
 	return plug, m.RegisterPlugin(config.Name, plug)
 }
 
+// LoadPlugins loads all Go plugin shared objects (*.so) in dir in the order of their file names, each plugin is
+// registered under its file name without the .so extension. Loading stops at the first plugin that cannot be loaded,
+// the plugins loaded before it stay registered.
+func (m *manager) LoadPlugins(dir string) ([]plugin.Pluginer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, &InvalidConfigError{
+			Err: fmt.Errorf("bad plugin directory %s: %w", dir, err),
+		}
+	}
+	sort.Strings(paths)
+	plugs := make([]plugin.Pluginer, 0, len(paths))
+	for _, path := range paths {
+		plug, err := m.LoadPlugin(Config{
+			Name: strings.TrimSuffix(filepath.Base(path), ".so"),
+			Path: path,
+		})
+		if err != nil {
+			return plugs, &LoadPluginError{Path: path, Err: err}
+		}
+		plugs = append(plugs, plug)
+	}
+	return plugs, nil
+}
+
 func (m *manager) RegisterPlugin(pluginName string, p plugin.Pluginer) error {
 	if p == nil {
 		return &NilPluginError{}
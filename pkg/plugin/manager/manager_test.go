@@ -16,6 +16,7 @@ package manager_test
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/xmidt-org/ears/pkg/secret"
@@ -376,6 +377,26 @@ func TestLoadErrors(t *testing.T) {
 
 }
 
+func TestLoadPlugins(t *testing.T) {
+	a := NewWithT(t)
+	m, _ := manager.New()
+
+	plugs, err := m.LoadPlugins(filepath.Join(testPluginDir, "complete"))
+	a.Expect(err).To(BeNil())
+	a.Expect(len(plugs)).To(Equal(1))
+	a.Expect(m.Plugin("plugin").Capabilities).To(Equal(manager.Capabilities{Receiver: true, Filterer: true, Sender: true}))
+	_, err = m.NewReceiver(tid, "plugin", "", "", nil)
+	a.Expect(err).To(BeNil())
+
+	plugs, err = m.LoadPlugins(filepath.Join(testPluginDir, "does_not_exist"))
+	a.Expect(err).To(BeNil())
+	a.Expect(len(plugs)).To(Equal(0))
+
+	_, err = m.LoadPlugins(filepath.Join(testPluginDir, "err_VariableLookupError"))
+	a.Expect(errTypeToString(err)).To(Equal(errTypeToString(&manager.LoadPluginError{})))
+	a.Expect(errTypeToString(errors.Unwrap(err))).To(Equal(errTypeToString(&manager.VariableLookupError{})))
+}
+
 func TestNewReceiver(t *testing.T) {
 
 	a := NewWithT(t)
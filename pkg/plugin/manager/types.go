@@ -44,6 +44,8 @@ type Manager interface {
 	// Probably needs some sort of asset interface to
 	// be able to load from file system, s3, and other places [Future]
 	LoadPlugin(config Config) (plugin.Pluginer, error)
+	// LoadPlugins loads all *.so files in a directory, registering each under its file name
+	LoadPlugins(dir string) ([]plugin.Pluginer, error)
 
 	RegisterPlugin(name string, p plugin.Pluginer) error
 	UnregisterPlugin(name string) error
@@ -69,6 +71,13 @@ type OpenPluginError struct {
 	Err error
 }
 
+// LoadPluginError is returned by LoadPlugins when one of the plugins
+// in the directory cannot be loaded
+type LoadPluginError struct {
+	Path string
+	Err  error
+}
+
 // NewPluginerError is returned when the call to NewPluginer
 // on a loaded plugin results in an error.
 type NewPluginerError struct {
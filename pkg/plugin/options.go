@@ -36,9 +36,11 @@ type OptionProcessor interface {
 
 	WithNewReceiver(fn NewReceiverFn) error
 	WithReceiverHasher(fn HashFn) error
+	WithReceiverValidator(fn ValidateFn) error
 
 	WithNewSender(fn NewSenderFn) error
 	WithSenderHasher(fn HashFn) error
+	WithSenderValidator(fn ValidateFn) error
 }
 
 func WithName(name string) Option {
@@ -102,6 +104,12 @@ func WithReceiverHasher(fn HashFn) Option {
 	}
 }
 
+func WithReceiverValidator(fn ValidateFn) Option {
+	return func(o OptionProcessor) error {
+		return o.WithReceiverValidator(fn)
+	}
+}
+
 func WithNewSender(fn NewSenderFn) Option {
 	return func(o OptionProcessor) error {
 		return o.WithNewSender(fn)
@@ -113,3 +121,9 @@ func WithSenderHasher(fn HashFn) Option {
 		return o.WithSenderHasher(fn)
 	}
 }
+
+func WithSenderValidator(fn ValidateFn) Option {
+	return func(o OptionProcessor) error {
+		return o.WithSenderValidator(fn)
+	}
+}
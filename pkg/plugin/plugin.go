@@ -109,6 +109,14 @@ func (p *Plugin) WithReceiverHasher(fn HashFn) error {
 	return p.setHasher(&p.hashReceiver, fn)
 }
 
+func (p *Plugin) WithReceiverValidator(fn ValidateFn) error {
+	if p == nil {
+		return &NilPluginError{}
+	}
+
+	return p.setValidator(&p.validateReceiver, fn)
+}
+
 func (p *Plugin) WithNewSender(fn NewSenderFn) error {
 
 	if p == nil {
@@ -134,6 +142,14 @@ func (p *Plugin) WithSenderHasher(fn HashFn) error {
 	return p.setHasher(&p.hashSender, fn)
 }
 
+func (p *Plugin) WithSenderValidator(fn ValidateFn) error {
+	if p == nil {
+		return &NilPluginError{}
+	}
+
+	return p.setValidator(&p.validateSender, fn)
+}
+
 func (p *Plugin) SupportedTypes() bit.Mask {
 	if p == nil {
 		return bit.Mask(0)
@@ -238,6 +254,24 @@ func (p *Plugin) NewReceiver(tid tenant.Id, plugin string, name string, config i
 	return p.newReceiver(tid, plugin, name, config, secrets)
 }
 
+// ValidateReceiverConfig checks a receiver config with the validator of the plugin, configs of plugins without
+// a validator are only checked when the receiver is created
+func (p *Plugin) ValidateReceiverConfig(config interface{}) error {
+	if p == nil {
+		return &NilPluginError{}
+	}
+
+	if p.newReceiver == nil {
+		return &NotSupportedError{}
+	}
+
+	if p.validateReceiver == nil {
+		return nil
+	}
+
+	return p.validateReceiver(config)
+}
+
 // == Senderer ===========================================================
 
 func (p *Plugin) SenderHash(config interface{}) (string, error) {
@@ -260,6 +294,24 @@ func (p *Plugin) NewSender(tid tenant.Id, plugin string, name string, config int
 	return p.newSender(tid, plugin, name, config, secrets)
 }
 
+// ValidateSenderConfig checks a sender config with the validator of the plugin, configs of plugins without
+// a validator are only checked when the sender is created
+func (p *Plugin) ValidateSenderConfig(config interface{}) error {
+	if p == nil {
+		return &NilPluginError{}
+	}
+
+	if p.newSender == nil {
+		return &NotSupportedError{}
+	}
+
+	if p.validateSender == nil {
+		return nil
+	}
+
+	return p.validateSender(config)
+}
+
 // == Filterer ===========================================================
 
 func (p *Plugin) FiltererHash(config interface{}) (string, error) {
@@ -305,3 +357,15 @@ func (p *Plugin) setHasher(field *HashFn, fn HashFn) error {
 	return nil
 
 }
+
+func (p *Plugin) setValidator(field *ValidateFn, fn ValidateFn) error {
+	if fn == nil {
+		return fmt.Errorf("nil Validate Function provided")
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	*field = fn
+
+	return nil
+}
@@ -66,6 +66,9 @@ const (
 
 type HashFn func(i interface{}) (string, error)
 
+// ValidateFn checks a config without creating an instance of the plugin
+type ValidateFn func(config interface{}) error
+
 // TODO: Question -- How do these stay in sync with the package?
 // Do we define them in the package alongside the interface definition?
 type NewPluginerFn func(config interface{}) (Pluginer, error)
@@ -89,6 +92,9 @@ type Plugin struct {
 	hashFilter   HashFn
 	hashSender   HashFn
 
+	validateReceiver ValidateFn
+	validateSender   ValidateFn
+
 	newPluginer NewPluginerFn
 	newReceiver NewReceiverFn
 	newSender   NewSenderFn
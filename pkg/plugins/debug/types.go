@@ -17,6 +17,7 @@ package debug
 import (
 	"container/ring"
 	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/metric"
 	"sync"
@@ -49,7 +50,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -161,11 +164,11 @@ type Sender struct {
 	config              SenderConfig
 	history             *history
 	destination         EventWriter
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
 }
 
 type history struct {
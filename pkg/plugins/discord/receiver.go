@@ -34,7 +34,8 @@ import (
 	"go.opentelemetry.io/otel/metric/unit"
 )
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -48,12 +49,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
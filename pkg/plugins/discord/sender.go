@@ -25,6 +25,7 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -34,7 +35,8 @@ import (
 	"go.opentelemetry.io/otel/metric/unit"
 )
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -48,11 +50,22 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -73,34 +86,44 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		attribute.String(rtsemconv.EARSOrgIdLabel, s.tid.OrgId),
 		attribute.String(rtsemconv.HostnameLabel, hostname),
 	}
-	s.eventSuccessCounter = metric.Must(meter).
-		NewInt64Counter(
+	s.eventSuccessCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventSuccess,
 			metric.WithDescription("measures the number of successful events"),
-		).Bind(commonLabels...)
-	s.eventFailureCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventFailureCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventFailure,
 			metric.WithDescription("measures the number of unsuccessful events"),
-		).Bind(commonLabels...)
-	s.eventBytesCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventBytesCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventBytes,
 			metric.WithDescription("measures the number of event bytes processed"),
 			metric.WithUnit(unit.Bytes),
-		).Bind(commonLabels...)
-	s.eventProcessingTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventProcessingTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventProcessingTime,
 			metric.WithDescription("measures the time an event spends in ears"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
-	s.eventSendOutTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventSendOutTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventSendOutTime,
 			metric.WithDescription("measures the time ears spends to send an event to a downstream data sink"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
+		),
+		commonLabels...,
+	)
 	return s, nil
 }
 
@@ -21,6 +21,7 @@ import (
 	"github.com/rs/zerolog"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
 	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/metric"
@@ -45,7 +46,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -68,11 +71,11 @@ type Sender struct {
 	name                string
 	plugin              string
 	tid                 tenant.Id
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
 }
 
 type Receiver struct {
@@ -26,6 +26,7 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -42,7 +43,8 @@ import (
 	"time"
 )
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -56,12 +58,23 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +103,9 @@ func (s *Sender) getLabelValues(e event.Event, labels []DynamicMetricLabel) []Dy
 		}
 		labelValues = append(labelValues, DynamicMetricValue{Label: label.Label, Value: value})
 	}
+	for _, kv := range route.MetricLabelsFromContext(e.Context()) {
+		labelValues = append(labelValues, DynamicMetricValue{Label: string(kv.Key), Value: kv.Value.AsString()})
+	}
 	return labelValues
 }
 
@@ -44,6 +44,7 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
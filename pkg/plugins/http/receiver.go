@@ -17,12 +17,15 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/goccy/go-yaml"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/receiver"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -35,12 +38,14 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -54,12 +59,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +120,8 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 	port := *r.config.Port
 	r.logger.Info().Int("port", port).Str("path", r.config.Path).Msg("starting http receiver")
 	r.srv = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}
-	b3Propagator := b3.New()
+	// b3 headers are still accepted for clients that do not send W3C trace context
+	propagator := propagation.NewCompositeTextMapPropagator(b3.New(), propagation.TraceContext{}, propagation.Baggage{})
 
 	mux.HandleFunc(r.config.Path, func(w http.ResponseWriter, req *http.Request) {
 		if r.config.Method != "" && !strings.EqualFold(r.config.Method, strings.ToLower(req.Method)) {
@@ -117,8 +134,7 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			r.logger.Error().Str("error", err.Error()).Msg("error reading body")
 			return
 		}
-		var body interface{}
-		err = json.Unmarshal(b, &body)
+		err = event.ValidPayload(b)
 		if err != nil {
 			r.logger.Error().Str("error", err.Error()).Msg("error unmarshalling body")
 			return
@@ -126,7 +142,7 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5)*time.Second)
 
 		//extract any trace information
-		ctx = b3Propagator.Extract(ctx, propagation.HeaderCarrier(req.Header))
+		ctx = propagator.Extract(ctx, propagation.HeaderCarrier(req.Header))
 
 		r.eventBytesCounter.Add(ctx, int64(len(b)))
 		var wg sync.WaitGroup
@@ -140,7 +156,15 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			"relativePath": req.URL.Path[len(r.config.Path):],
 			"method":       req.Method,
 		}}
-		event, err := event.New(ctx, body,
+		headers := make(map[string]string, len(req.Header))
+		for name := range req.Header {
+			headers[name] = req.Header.Get(name)
+		}
+		if ceHeaders := cloudevents.ReceivedHeaders(headers); ceHeaders != nil {
+			metadata[cloudevents.ReceivedHeadersKey] = ceHeaders
+		}
+		event, err := event.New(ctx, nil,
+			event.WithRawPayload(b),
 			event.WithAck(
 				func(e event.Event) {
 					w.Header().Set("Content-Type", "application/json")
@@ -159,12 +183,21 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 					r.eventSuccessCounter.Add(ctx, 1)
 					cancel()
 				}, func(e event.Event, err error) {
+					status := *r.config.FailureStatus
+					message := ""
+					var quotaExceeded *ratelimit.QuotaExceeded
+					if errors.As(err, &quotaExceeded) {
+						status = http.StatusTooManyRequests
+						message = fmt.Sprintf("quota of %d events per second (burst %d) exceeded", quotaExceeded.EventsPerSec, quotaExceeded.Burst)
+						w.Header().Set("Retry-After", strconv.Itoa(quotaExceeded.RetryAfterSeconds()))
+					}
 					w.Header().Set("Content-Type", "application/json")
 					w.Header().Set("User-Agent", "ears")
-					w.WriteHeader(*r.config.FailureStatus)
+					w.WriteHeader(status)
 					resp := Response{
 						Status: &Status{
-							Code: *r.config.FailureStatus,
+							Code:    status,
+							Message: message,
 						},
 						Tracing: &Tracing{
 							TraceId: trace.SpanFromContext(ctx).SpanContext().TraceID().String(),
@@ -17,11 +17,18 @@ package http
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
 	"github.com/goccy/go-yaml"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -31,6 +38,7 @@ import (
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/metric/unit"
 	"go.opentelemetry.io/otel/propagation"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -40,7 +48,8 @@ import (
 
 const DEFAULT_TIMEOUT = 10
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -54,11 +63,23 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
+	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -84,75 +105,122 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		attribute.String(rtsemconv.EARSOrgIdLabel, s.tid.OrgId),
 		attribute.String(rtsemconv.HostnameLabel, hostname),
 	}
-	s.eventSuccessCounter = metric.Must(meter).
-		NewInt64Counter(
+	s.eventSuccessCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventSuccess,
 			metric.WithDescription("measures the number of successful events"),
-		).Bind(commonLabels...)
-	s.eventFailureCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventFailureCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventFailure,
 			metric.WithDescription("measures the number of unsuccessful events"),
-		).Bind(commonLabels...)
-	s.eventBytesCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventBytesCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventBytes,
 			metric.WithDescription("measures the number of event bytes processed"),
 			metric.WithUnit(unit.Bytes),
-		).Bind(commonLabels...)
-	s.eventProcessingTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventProcessingTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventProcessingTime,
 			metric.WithDescription("measures the time an event spends in ears"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
-	s.eventSendOutTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventSendOutTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventSendOutTime,
 			metric.WithDescription("measures the time ears spends to send an event to a downstream data sink"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
+		),
+		commonLabels...,
+	)
 
-	s.b3Propagator = b3.New()
+	// b3 headers are sent along with W3C trace context for receivers that only understand b3
+	s.propagator = propagation.NewCompositeTextMapPropagator(b3.New(), propagation.TraceContext{}, propagation.Baggage{})
+	if cfg.HMACSecret != "" {
+		var key string
+		if secrets != nil {
+			key = secrets.Secret(cfg.HMACSecret)
+		}
+		if key == "" {
+			return nil, &pkgplugin.InvalidConfigError{
+				Err: fmt.Errorf("hmac secret %s not found", cfg.HMACSecret),
+			}
+		}
+		s.hmacKey = []byte(key)
+	}
 	return s, nil
 }
 
-func (s *Sender) Send(event event.Event) {
-	payload := event.Payload()
-	body, err := json.Marshal(payload)
+// sign computes the hmac signature of the request body, formatted as <algorithm>=<hex digest>
+func (s *Sender) sign(body []byte) string {
+	var h func() hash.Hash
+	switch s.config.HMACAlgorithm {
+	case HMACAlgorithmSHA1:
+		h = sha1.New
+	case HMACAlgorithmSHA512:
+		h = sha512.New
+	default:
+		h = sha256.New
+	}
+	mac := hmac.New(h, s.hmacKey)
+	mac.Write(body)
+	return s.config.HMACAlgorithm + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Sender) Send(evt event.Event) {
+	body, err := event.MarshalPayload(evt)
 	if err != nil {
-		s.eventFailureCounter.Add(event.Context(), 1)
-		event.Nack(err)
+		s.eventFailureCounter.Add(evt.Context(), 1)
+		evt.Nack(err)
 		return
 	}
-	s.eventBytesCounter.Add(event.Context(), int64(len(body)))
-	s.eventProcessingTime.Record(event.Context(), time.Since(event.Created()).Milliseconds())
+	s.eventBytesCounter.Add(evt.Context(), int64(len(body)))
+	s.eventProcessingTime.Record(evt.Context(), time.Since(evt.Created()).Milliseconds())
 	req, err := http.NewRequest(s.config.Method, s.config.Url, bytes.NewReader(body))
 	if err != nil {
-		s.eventFailureCounter.Add(event.Context(), 1)
-		event.Nack(err)
+		s.eventFailureCounter.Add(evt.Context(), 1)
+		evt.Nack(err)
 		return
 	}
-	ctx := event.Context()
-	s.b3Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if ct := evt.ContentType(); ct != event.ContentTypeJSON {
+		req.Header.Set("Content-Type", ct)
+	}
+	for name, value := range cloudevents.SendHeaders(evt) {
+		req.Header.Set(name, value)
+	}
+	if s.hmacKey != nil {
+		req.Header.Set(s.config.HMACSignatureHeader, s.sign(body))
+	}
+	ctx := evt.Context()
+	s.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	start := time.Now()
 	resp, err := s.client.Do(req)
-	s.eventSendOutTime.Record(event.Context(), time.Since(start).Milliseconds())
+	s.eventSendOutTime.Record(evt.Context(), time.Since(start).Milliseconds())
 	if err != nil {
-		s.eventFailureCounter.Add(event.Context(), 1)
-		event.Nack(err)
+		s.eventFailureCounter.Add(evt.Context(), 1)
+		evt.Nack(err)
 		return
 	}
 	io.Copy(ioutil.Discard, resp.Body)
 	defer resp.Body.Close()
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
-		s.eventFailureCounter.Add(event.Context(), 1)
-		event.Nack(&BadHttpStatusError{resp.StatusCode})
+		s.eventFailureCounter.Add(evt.Context(), 1)
+		evt.Nack(&BadHttpStatusError{resp.StatusCode})
 		return
 	}
-	s.eventSuccessCounter.Add(event.Context(), 1)
-	event.Ack()
+	s.eventSuccessCounter.Add(evt.Context(), 1)
+	evt.Ack()
 }
 
 func (s *Sender) Unwrap() sender.Sender {
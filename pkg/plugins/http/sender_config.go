@@ -19,6 +19,18 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
+// WithDefaults
+func (sc SenderConfig) WithDefaults() SenderConfig {
+	cfg := sc
+	if cfg.HMACAlgorithm == "" {
+		cfg.HMACAlgorithm = DefaultSenderConfig.HMACAlgorithm
+	}
+	if cfg.HMACSignatureHeader == "" {
+		cfg.HMACSignatureHeader = DefaultSenderConfig.HMACSignatureHeader
+	}
+	return cfg
+}
+
 // Validate
 func (sc *SenderConfig) Validate() error {
 	schema := gojsonschema.NewStringLoader(senderSchema)
@@ -46,6 +58,16 @@ const senderSchema = `
                     "type": "string"
                 },
 				"method": {
+                    "type": "string"
+				},
+				"hmacSecret": {
+                    "type": "string"
+				},
+				"hmacAlgorithm": {
+                    "type": "string",
+					"enum": ["sha1", "sha256", "sha512"]
+				},
+				"hmacSignatureHeader": {
                     "type": "string"
 				}
             },
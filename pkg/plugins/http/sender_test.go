@@ -0,0 +1,124 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	earshttp "github.com/xmidt-org/ears/pkg/plugins/http"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testVault map[string]string
+
+func (v testVault) Secret(key string) string {
+	return v[key]
+}
+
+func TestSenderHMACSignature(t *testing.T) {
+	signatures := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("mykey"))
+		mac.Write(body)
+		if r.Header.Get("X-Ears-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		signatures <- r.Header.Get("X-Ears-Signature")
+	}))
+	defer server.Close()
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+	secrets := testVault{"secret://webhook.hmacKey": "mykey"}
+	s, err := earshttp.NewSender(tid, "http", "mysender", earshttp.SenderConfig{
+		Url:        server.URL,
+		Method:     http.MethodPost,
+		HMACSecret: "secret://webhook.hmacKey",
+	}, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e, err := event.New(context.Background(), map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+	select {
+	case sig := <-signatures:
+		if sig == "" {
+			t.Fatalf("missing signature header")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("request not received")
+	}
+	_, err = earshttp.NewSender(tid, "http", "mysender", earshttp.SenderConfig{
+		Url:        server.URL,
+		Method:     http.MethodPost,
+		HMACSecret: "secret://webhook.unknown",
+	}, secrets)
+	if err == nil {
+		t.Fatalf("expected error for unknown hmac secret")
+	}
+}
+
+func TestSenderTraceContext(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer server.Close()
+	s, err := earshttp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "http", "mysender", earshttp.SenderConfig{
+		Url:    server.URL,
+		Method: http.MethodPost,
+	}, nil)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+	select {
+	case h := <-headers:
+		if !strings.HasPrefix(h.Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+			t.Fatalf("unexpected traceparent header: %s", h.Get("traceparent"))
+		}
+		if !strings.HasPrefix(h.Get("b3"), "4bf92f3577b34da6a3ce929d0e0e4736-") {
+			t.Fatalf("unexpected b3 header: %s", h.Get("b3"))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("request not received")
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/errs"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
 	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"github.com/xorcare/pointer"
@@ -47,7 +48,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -99,8 +102,22 @@ type Receiver struct {
 }
 
 type SenderConfig struct {
-	Url    string `json:"url"`
-	Method string `json:"method"`
+	Url                 string `json:"url"`
+	Method              string `json:"method"`
+	HMACSecret          string `json:"hmacSecret,omitempty"`          // secret reference, e.g. secret://webhook.hmacKey
+	HMACAlgorithm       string `json:"hmacAlgorithm,omitempty"`       // sha256, sha1 or sha512
+	HMACSignatureHeader string `json:"hmacSignatureHeader,omitempty"` // header carrying the signature
+}
+
+const (
+	HMACAlgorithmSHA1   = "sha1"
+	HMACAlgorithmSHA256 = "sha256"
+	HMACAlgorithmSHA512 = "sha512"
+)
+
+var DefaultSenderConfig = SenderConfig{
+	HMACAlgorithm:       HMACAlgorithmSHA256,
+	HMACSignatureHeader: "X-Ears-Signature",
 }
 
 type Sender struct {
@@ -109,12 +126,13 @@ type Sender struct {
 	name                string
 	plugin              string
 	tid                 tenant.Id
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
-	b3Propagator        propagation.TextMapPropagator
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
+	propagator          propagation.TextMapPropagator
+	hmacKey             []byte
 }
 
 type BadHttpStatusError struct {
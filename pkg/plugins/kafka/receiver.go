@@ -19,11 +19,10 @@ package kafka
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -41,11 +40,13 @@ import (
 
 	"github.com/goccy/go-yaml"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
 	"github.com/xmidt-org/ears/pkg/receiver"
 )
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -59,12 +60,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +95,9 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		stopped: true,
 		secrets: secrets,
 	}
+	if cfg.SchemaRegistry != nil {
+		r.schemaRegistry = newSchemaRegistry(cfg.SchemaRegistry, secrets)
+	}
 	saramaConfig, err := r.getSaramaConfig(*r.config.CommitInterval)
 	if err != nil {
 		return nil, err
@@ -148,7 +163,20 @@ func (r *Receiver) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 	// Do not move the code below to a goroutine.
 	// The `ConsumeClaim` itself is called within a goroutine, see:
 	// https://github.com/Shopify/sarama/blob/master/consumer_group.go#L27-L29
+	pc := &partitionClaim{claim: claim, offset: claim.InitialOffset() - 1}
+	r.Lock()
+	if r.claims == nil {
+		r.claims = make(map[int32]*partitionClaim)
+	}
+	r.claims[claim.Partition()] = pc
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		delete(r.claims, claim.Partition())
+		r.Unlock()
+	}()
 	for message := range claim.Messages() {
+		atomic.StoreInt64(&pc.offset, message.Offset)
 		if r.handler(message) {
 			session.MarkMessage(message, "")
 		}
@@ -156,6 +184,31 @@ func (r *Receiver) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 	return nil
 }
 
+// ParseErrors returns the messages the receiver recently failed to parse
+func (r *Receiver) ParseErrors() []receiver.ParseError {
+	return r.parseErrors.List()
+}
+
+// Lag returns the number of messages between the last consumed message and the head of each claimed partition
+func (r *Receiver) Lag() []receiver.Lag {
+	r.Lock()
+	defer r.Unlock()
+	lags := make([]receiver.Lag, 0, len(r.claims))
+	for partition, pc := range r.claims {
+		offset := atomic.LoadInt64(&pc.offset)
+		if offset < 0 {
+			// initial offset is oldest or newest, unknown until the first message is consumed
+			continue
+		}
+		lag := pc.claim.HighWaterMarkOffset() - offset - 1
+		if lag < 0 {
+			lag = 0
+		}
+		lags = append(lags, receiver.Lag{Kind: receiver.LagConsumerOffsets, Partition: strconv.Itoa(int(partition)), Value: lag})
+	}
+	return lags
+}
+
 func (r *Receiver) Start(handler func(*sarama.ConsumerMessage) bool) {
 	r.handler = handler
 	r.wg.Add(1)
@@ -218,47 +271,35 @@ func (r *Receiver) getSaramaConfig(commitIntervalSec int) (*sarama.Config, error
 	if *r.config.ChannelBufferSize > 0 {
 		config.ChannelBufferSize = *r.config.ChannelBufferSize
 	}
-	config.Net.TLS.Enable = r.config.TLSEnable
-	if "" != r.config.Username {
-		config.Net.TLS.Enable = true
-		config.Net.SASL.Enable = true
-		config.Net.SASL.User = r.config.Username
-		config.Net.SASL.Password = r.secrets.Secret(r.config.Password)
-		if config.Net.SASL.Password == "" {
-			config.Net.SASL.Password = r.config.Password
-		}
-
-	} else if "" != r.config.AccessCert {
-		accessCert := r.secrets.Secret(r.config.AccessCert)
-		if accessCert == "" {
-			accessCert = r.config.AccessCert
-		}
-		accessKey := r.secrets.Secret(r.config.AccessKey)
-		if accessKey == "" {
-			accessKey = r.config.AccessKey
-		}
-		caCert := r.secrets.Secret(r.config.CACert)
-		if caCert == "" {
-			caCert = r.config.CACert
-		}
-		keypair, err := tls.X509KeyPair([]byte(accessCert), []byte(accessKey))
-		if err != nil {
-			return nil, err
-		}
-		caAuthorityPool := x509.NewCertPool()
-		caAuthorityPool.AppendCertsFromPEM([]byte(caCert))
-		tlsConfig := &tls.Config{
-			Certificates:       []tls.Certificate{keypair},
-			RootCAs:            caAuthorityPool,
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: true,
-		}
-		config.Net.TLS.Enable = true
-		config.Net.TLS.Config = tlsConfig
+	err := configureSecurity(config, r.secrets, r.config.securityConfig())
+	if err != nil {
+		return nil, err
 	}
 	return config, nil
 }
 
+// binaryPayload returns true if messages are passed on as binary payloads of the configured content type
+func (r *Receiver) binaryPayload() bool {
+	return r.config.ContentType != "" && r.config.ContentType != event.ContentTypeJSON
+}
+
+// decodePayload parses a message value as Avro if a schema registry is configured, otherwise the value is
+// returned as raw JSON that is only decoded if a filter accesses it, or as binary payload
+func (r *Receiver) decodePayload(value []byte) (interface{}, []byte, error) {
+	if r.schemaRegistry != nil {
+		pl, err := r.schemaRegistry.decode(value)
+		return pl, nil, err
+	}
+	if r.binaryPayload() {
+		return nil, value, nil
+	}
+	err := event.ValidPayload(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, value, nil
+}
+
 func (r *Receiver) Receive(next receiver.NextFn) error {
 	if r == nil {
 		return &pkgplugin.Error{
@@ -305,10 +346,10 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			r.Lock()
 			r.count++
 			r.Unlock()
-			var pl interface{}
-			err := json.Unmarshal(msg.Value, &pl)
+			pl, raw, err := r.decodePayload(msg.Value)
 			if err != nil {
-				r.logger.Error().Str("op", "kafka.Receive").Msg("cannot parse payload: " + err.Error())
+				r.logger.Error().Str("op", "kafka.Receive").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Msg("cannot parse payload: " + err.Error())
+				r.parseErrors.Add(err, msg.Value)
 				return false
 			}
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5)*time.Second)
@@ -317,7 +358,21 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			ctx = otel.GetTextMapPropagator().Extract(ctx, otelsarama.NewConsumerMessageCarrier(msg))
 
 			r.eventBytesCounter.Add(ctx, int64(len(msg.Value)))
-			e, err := event.New(ctx, pl, event.WithAck(
+			headers := make(map[string]string, len(msg.Headers))
+			for _, h := range msg.Headers {
+				if h != nil {
+					headers[string(h.Key)] = string(h.Value)
+				}
+			}
+			var ceHeaders map[string]interface{}
+			if h := cloudevents.ReceivedHeaders(headers); h != nil {
+				ceHeaders = map[string]interface{}{cloudevents.ReceivedHeadersKey: h}
+			}
+			payload := event.WithRawPayload(raw)
+			if r.binaryPayload() {
+				payload = event.WithBinaryPayload(raw, r.config.ContentType)
+			}
+			e, err := event.New(ctx, pl, payload, event.WithAck(
 				func(e event.Event) {
 					log.Ctx(e.Context()).Debug().Str("op", "kafka.Receive").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Msg("processed message from kafka topic")
 					r.eventSuccessCounter.Add(ctx, 1)
@@ -330,6 +385,7 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 				}),
 				event.WithOtelTracing(r.Name()),
 				event.WithTenant(r.Tenant()),
+				event.WithMetadata(ceHeaders),
 				event.WithTracePayloadOnNack(*r.config.TracePayloadOnNack))
 			if err != nil {
 				r.logger.Error().Str("op", "kafka.Receive").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Msg("cannot create event: " + err.Error())
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/xeipuuv/gojsonschema"
+	"github.com/xmidt-org/ears/pkg/event"
 )
 
 // WithDefaults returns a new config object that has all
@@ -59,6 +60,9 @@ func (rc *ReceiverConfig) Validate() error {
 	if !result.Valid() {
 		return fmt.Errorf(fmt.Sprintf("%+v", result.Errors()))
 	}
+	if rc.SchemaRegistry != nil && rc.ContentType != "" && rc.ContentType != event.ContentTypeJSON {
+		return fmt.Errorf("contentType %s cannot be used with a schema registry", rc.ContentType)
+	}
 	return nil
 }
 
@@ -103,6 +107,55 @@ const receiverSchema = `
                 },
                 "channelBufferSize": {
                     "type": "integer"
+                },
+                "consumeByPartitions": {
+                    "type": "boolean"
+                },
+                "tlsEnable": {
+                    "type": "boolean"
+                },
+                "tlsInsecureSkipVerify": {
+                    "type": "boolean"
+                },
+                "saslMechanism": {
+                    "type": "string",
+                    "enum": ["", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512", "OAUTHBEARER"]
+                },
+                "oauthTokenUrl": {
+                    "type": "string"
+                },
+                "oauthClientId": {
+                    "type": "string"
+                },
+                "oauthClientSecret": {
+                    "type": "string"
+                },
+                "oauthScopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "schemaRegistry": {
+                    "type": "object",
+                    "additionalProperties": false,
+                    "properties": {
+                        "url": {
+                            "type": "string"
+                        },
+                        "username": {
+                            "type": "string"
+                        },
+                        "password": {
+                            "type": "string"
+                        }
+                    },
+                    "required": [
+                        "url"
+                    ]
+                },
+                "contentType": {
+                    "type": "string"
                 },
 				"tracePayloadOnNack" : {
 					"type": "boolean",
@@ -0,0 +1,127 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/xmidt-org/ears/pkg/secret"
+)
+
+const (
+	// confluent wire format: magic byte followed by a 4 byte big endian schema id
+	schemaRegistryMagicByte  = 0
+	schemaRegistryHeaderSize = 5
+)
+
+// schemaRegistry decodes Avro messages in the Confluent wire format using
+// schemas looked up by id from a Confluent Schema Registry
+type schemaRegistry struct {
+	sync.Mutex
+	url      string
+	username string
+	password string
+	client   *http.Client
+	codecs   map[uint32]*goavro.Codec
+}
+
+func newSchemaRegistry(config *SchemaRegistryConfig, secrets secret.Vault) *schemaRegistry {
+	return &schemaRegistry{
+		url:      strings.TrimSuffix(config.Url, "/"),
+		username: secretOrValue(secrets, config.Username),
+		password: secretOrValue(secrets, config.Password),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		codecs:   make(map[uint32]*goavro.Codec),
+	}
+}
+
+// decode converts an Avro encoded message into a JSON compatible payload
+func (sr *schemaRegistry) decode(msg []byte) (interface{}, error) {
+	if len(msg) < schemaRegistryHeaderSize || msg[0] != schemaRegistryMagicByte {
+		return nil, fmt.Errorf("message is not in schema registry wire format")
+	}
+	schemaId := binary.BigEndian.Uint32(msg[1:schemaRegistryHeaderSize])
+	codec, err := sr.codec(schemaId)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := codec.NativeFromBinary(msg[schemaRegistryHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+	buf, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, err
+	}
+	var payload interface{}
+	err = json.Unmarshal(buf, &payload)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// codec returns the cached codec for a schema id or fetches the schema from the registry
+func (sr *schemaRegistry) codec(schemaId uint32) (*goavro.Codec, error) {
+	sr.Lock()
+	codec, ok := sr.codecs[schemaId]
+	sr.Unlock()
+	if ok {
+		return codec, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", sr.url, schemaId), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if sr.username != "" {
+		req.SetBasicAuth(sr.username, sr.password)
+	}
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status %d for schema id %d", resp.StatusCode, schemaId)
+	}
+	var schemaResp struct {
+		Schema string `json:"schema"`
+	}
+	err = json.Unmarshal(body, &schemaResp)
+	if err != nil {
+		return nil, err
+	}
+	codec, err = goavro.NewCodec(schemaResp.Schema)
+	if err != nil {
+		return nil, err
+	}
+	sr.Lock()
+	sr.codecs[schemaId] = codec
+	sr.Unlock()
+	return codec, nil
+}
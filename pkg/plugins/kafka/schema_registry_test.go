@@ -0,0 +1,74 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+const testAvroSchema = `{"type":"record","name":"Device","fields":[{"name":"id","type":"string"},{"name":"count","type":"int"}]}`
+
+func TestSchemaRegistryDecode(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		user, pass, _ := r.BasicAuth()
+		if user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": testAvroSchema})
+	}))
+	defer server.Close()
+	codec, err := goavro.NewCodec(testAvroSchema)
+	if err != nil {
+		t.Fatalf("cannot create codec: %s", err.Error())
+	}
+	msg := make([]byte, schemaRegistryHeaderSize)
+	binary.BigEndian.PutUint32(msg[1:], 7)
+	msg, err = codec.BinaryFromNative(msg, map[string]interface{}{"id": "abc", "count": 3})
+	if err != nil {
+		t.Fatalf("cannot encode message: %s", err.Error())
+	}
+	sr := newSchemaRegistry(&SchemaRegistryConfig{Url: server.URL + "/", Username: "user", Password: "pass"}, nil)
+	for i := 0; i < 2; i++ {
+		payload, err := sr.decode(msg)
+		if err != nil {
+			t.Fatalf("cannot decode message: %s", err.Error())
+		}
+		expected := map[string]interface{}{"id": "abc", "count": float64(3)}
+		if !reflect.DeepEqual(payload, expected) {
+			t.Fatalf("unexpected payload %v", payload)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected schema to be fetched once but got %d requests", requests)
+	}
+	_, err = sr.decode([]byte(`{"id":"abc"}`))
+	if err == nil {
+		t.Fatalf("expected error decoding message without wire format header")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	SASLMechanismScramSHA512 = "SCRAM-SHA-512"
+	SASLMechanismOAuthBearer = "OAUTHBEARER"
+)
+
+// securityConfig holds the TLS and SASL settings shared by the kafka sender and receiver
+type securityConfig struct {
+	Username              string
+	Password              string
+	CACert                string
+	AccessCert            string
+	AccessKey             string
+	TLSEnable             bool
+	TLSInsecureSkipVerify *bool
+	SASLMechanism         string
+	OAuthTokenUrl         string
+	OAuthClientId         string
+	OAuthClientSecret     string
+	OAuthScopes           []string
+}
+
+func (rc *ReceiverConfig) securityConfig() securityConfig {
+	return securityConfig{
+		Username:              rc.Username,
+		Password:              rc.Password,
+		CACert:                rc.CACert,
+		AccessCert:            rc.AccessCert,
+		AccessKey:             rc.AccessKey,
+		TLSEnable:             rc.TLSEnable,
+		TLSInsecureSkipVerify: rc.TLSInsecureSkipVerify,
+		SASLMechanism:         rc.SASLMechanism,
+		OAuthTokenUrl:         rc.OAuthTokenUrl,
+		OAuthClientId:         rc.OAuthClientId,
+		OAuthClientSecret:     rc.OAuthClientSecret,
+		OAuthScopes:           rc.OAuthScopes,
+	}
+}
+
+func (sc *SenderConfig) securityConfig() securityConfig {
+	return securityConfig{
+		Username:              sc.Username,
+		Password:              sc.Password,
+		CACert:                sc.CACert,
+		AccessCert:            sc.AccessCert,
+		AccessKey:             sc.AccessKey,
+		TLSEnable:             sc.TLSEnable,
+		TLSInsecureSkipVerify: sc.TLSInsecureSkipVerify,
+		SASLMechanism:         sc.SASLMechanism,
+		OAuthTokenUrl:         sc.OAuthTokenUrl,
+		OAuthClientId:         sc.OAuthClientId,
+		OAuthClientSecret:     sc.OAuthClientSecret,
+		OAuthScopes:           sc.OAuthScopes,
+	}
+}
+
+// secretOrValue resolves a secret reference and falls back to the literal value
+func secretOrValue(secrets secret.Vault, val string) string {
+	if secrets == nil || val == "" {
+		return val
+	}
+	s := secrets.Secret(val)
+	if s == "" {
+		return val
+	}
+	return s
+}
+
+// configureSecurity applies TLS, mTLS and SASL settings to a sarama config
+func configureSecurity(config *sarama.Config, secrets secret.Vault, sc securityConfig) error {
+	config.Net.TLS.Enable = sc.TLSEnable
+	if sc.AccessCert != "" || sc.CACert != "" {
+		tlsConfig := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		if sc.TLSInsecureSkipVerify != nil {
+			tlsConfig.InsecureSkipVerify = *sc.TLSInsecureSkipVerify
+		} else {
+			// client certificates have historically been used without server verification
+			tlsConfig.InsecureSkipVerify = sc.AccessCert != ""
+		}
+		if sc.AccessCert != "" {
+			keypair, err := tls.X509KeyPair([]byte(secretOrValue(secrets, sc.AccessCert)), []byte(secretOrValue(secrets, sc.AccessKey)))
+			if err != nil {
+				return err
+			}
+			tlsConfig.Certificates = []tls.Certificate{keypair}
+		}
+		if sc.CACert != "" {
+			caAuthorityPool := x509.NewCertPool()
+			caAuthorityPool.AppendCertsFromPEM([]byte(secretOrValue(secrets, sc.CACert)))
+			tlsConfig.RootCAs = caAuthorityPool
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+	switch sc.SASLMechanism {
+	case "", SASLMechanismPlain:
+		if sc.Username == "" {
+			return nil
+		}
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case SASLMechanismScramSHA256:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha256.New}
+		}
+	case SASLMechanismScramSHA512:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha512.New}
+		}
+	case SASLMechanismOAuthBearer:
+		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		cc := clientcredentials.Config{
+			ClientID:     secretOrValue(secrets, sc.OAuthClientId),
+			ClientSecret: secretOrValue(secrets, sc.OAuthClientSecret),
+			TokenURL:     sc.OAuthTokenUrl,
+			Scopes:       sc.OAuthScopes,
+		}
+		config.Net.SASL.TokenProvider = &oauthTokenProvider{tokenSource: cc.TokenSource(context.Background())}
+	default:
+		return fmt.Errorf("unsupported sasl mechanism %s", sc.SASLMechanism)
+	}
+	config.Net.TLS.Enable = true
+	config.Net.SASL.Enable = true
+	if sc.SASLMechanism != SASLMechanismOAuthBearer {
+		config.Net.SASL.User = sc.Username
+		config.Net.SASL.Password = secretOrValue(secrets, sc.Password)
+	}
+	return nil
+}
+
+// scramClient implements sarama.SCRAMClient
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	c.ClientConversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.ClientConversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.ClientConversation.Done()
+}
+
+// oauthTokenProvider implements sarama.AccessTokenProvider using the oauth2 client credentials flow,
+// the underlying token source caches tokens and refreshes them when they expire
+type oauthTokenProvider struct {
+	tokenSource oauth2.TokenSource
+}
+
+func (p *oauthTokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := p.tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.AccessToken{Token: token.AccessToken}, nil
+}
@@ -16,9 +16,6 @@ package kafka
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -29,7 +26,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter/cloudevents"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -44,7 +43,8 @@ import (
 //TODO: support headers
 //
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -58,12 +58,23 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -135,6 +146,7 @@ func (s *Sender) getAttributes(e event.Event, labels []DynamicMetricLabel) []att
 		}
 		attrs = append(attrs, attribute.String(label.Label, value))
 	}
+	attrs = append(attrs, route.MetricLabelsFromContext(e.Context())...)
 	return attrs
 }
 
@@ -202,44 +214,7 @@ func (s *Sender) setConfig(config *sarama.Config) error {
 	if 0 < *s.config.ChannelBufferSize {
 		config.ChannelBufferSize = *s.config.ChannelBufferSize
 	}
-	config.Net.TLS.Enable = s.config.TLSEnable
-	if "" != s.config.Username {
-		config.Net.TLS.Enable = true
-		config.Net.SASL.Enable = true
-		config.Net.SASL.User = s.config.Username
-		config.Net.SASL.Password = s.secrets.Secret(s.config.Password)
-		if config.Net.SASL.Password == "" {
-			config.Net.SASL.Password = s.config.Password
-		}
-	} else if "" != s.config.AccessCert {
-		accessCert := s.secrets.Secret(s.config.AccessCert)
-		if accessCert == "" {
-			accessCert = s.config.AccessCert
-		}
-		accessKey := s.secrets.Secret(s.config.AccessKey)
-		if accessKey == "" {
-			accessKey = s.config.AccessKey
-		}
-		caCert := s.secrets.Secret(s.config.CACert)
-		if caCert == "" {
-			caCert = s.config.CACert
-		}
-		keypair, err := tls.X509KeyPair([]byte(accessCert), []byte(accessKey))
-		if err != nil {
-			return err
-		}
-		caAuthorityPool := x509.NewCertPool()
-		caAuthorityPool.AppendCertsFromPEM([]byte(caCert))
-		tlsConfig := &tls.Config{
-			Certificates:       []tls.Certificate{keypair},
-			RootCAs:            caAuthorityPool,
-			MinVersion:         tls.VersionTLS12,
-			InsecureSkipVerify: true,
-		}
-		config.Net.TLS.Enable = true
-		config.Net.TLS.Config = tlsConfig
-	}
-	return nil
+	return configureSecurity(config, s.secrets, s.config.securityConfig())
 }
 
 func (s *Sender) NewSyncProducers(count int) ([]sarama.SyncProducer, sarama.Client, error) {
@@ -370,7 +345,7 @@ func (s *Sender) Send(e event.Event) {
 		s.eventFailureCounter.Add(e.Context(), 1.0, s.getAttributes(e, s.config.DynamicMetricLabels)...)
 		return
 	}
-	buf, err := json.Marshal(e.Payload())
+	buf, err := event.MarshalPayload(e)
 	if err != nil {
 		log.Ctx(e.Context()).Error().Str("op", "kafka.Send").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Msg("failed to marshal message: " + err.Error())
 		s.eventFailureCounter.Add(e.Context(), 1.0, s.getAttributes(e, s.config.DynamicMetricLabels)...)
@@ -389,7 +364,7 @@ func (s *Sender) Send(e event.Event) {
 	} else {
 		partition = *s.config.Partition
 	}
-	err = s.producer.SendMessage(e.Context(), s.config.Topic, partition, nil, buf, e)
+	err = s.producer.SendMessage(e.Context(), s.config.Topic, partition, cloudevents.SendHeaders(e), buf, e)
 	if err != nil {
 		log.Ctx(e.Context()).Error().Str("op", "kafka.Send").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Msg("failed to send message: " + err.Error())
 		s.eventFailureCounter.Add(e.Context(), 1, s.getAttributes(e, s.config.DynamicMetricLabels)...)
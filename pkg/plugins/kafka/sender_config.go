@@ -107,6 +107,31 @@ const senderSchema = `
                 },
                 "channelBufferSize": {
                     "type": "integer"
+                },
+                "tlsEnable": {
+                    "type": "boolean"
+                },
+                "tlsInsecureSkipVerify": {
+                    "type": "boolean"
+                },
+                "saslMechanism": {
+                    "type": "string",
+                    "enum": ["", "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512", "OAUTHBEARER"]
+                },
+                "oauthTokenUrl": {
+                    "type": "string"
+                },
+                "oauthClientId": {
+                    "type": "string"
+                },
+                "oauthClientSecret": {
+                    "type": "string"
+                },
+                "oauthScopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
 				"senderPoolSize": {
                     "type": "integer", 
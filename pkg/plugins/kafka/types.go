@@ -51,7 +51,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -71,20 +73,36 @@ var DefaultReceiverConfig = ReceiverConfig{
 }
 
 type ReceiverConfig struct {
-	Brokers             string `json:"brokers,omitempty"`
-	Topic               string `json:"topic,omitempty"`
-	GroupId             string `json:"groupId,omitempty"`
-	Username            string `json:"username,omitempty"` // yaml
-	Password            string `json:"password,omitempty"`
-	CACert              string `json:"caCert,omitempty"`
-	AccessCert          string `json:"accessCert,omitempty"`
-	AccessKey           string `json:"accessKey,omitempty"`
-	Version             string `json:"version,omitempty"`
-	CommitInterval      *int   `json:"commitInterval,omitempty"`
-	ChannelBufferSize   *int   `json:"channelBufferSize,omitempty"`
-	ConsumeByPartitions bool   `json:"consumeByPartitions,omitempty"`
-	TLSEnable           bool   `json:"tlsEnable,omitempty"`
-	TracePayloadOnNack  *bool  `json:"tracePayloadOnNack,omitempty"`
+	Brokers               string                `json:"brokers,omitempty"`
+	Topic                 string                `json:"topic,omitempty"`
+	GroupId               string                `json:"groupId,omitempty"`
+	Username              string                `json:"username,omitempty"` // yaml
+	Password              string                `json:"password,omitempty"`
+	CACert                string                `json:"caCert,omitempty"`
+	AccessCert            string                `json:"accessCert,omitempty"`
+	AccessKey             string                `json:"accessKey,omitempty"`
+	Version               string                `json:"version,omitempty"`
+	CommitInterval        *int                  `json:"commitInterval,omitempty"`
+	ChannelBufferSize     *int                  `json:"channelBufferSize,omitempty"`
+	ConsumeByPartitions   bool                  `json:"consumeByPartitions,omitempty"`
+	TLSEnable             bool                  `json:"tlsEnable,omitempty"`
+	TLSInsecureSkipVerify *bool                 `json:"tlsInsecureSkipVerify,omitempty"`
+	SASLMechanism         string                `json:"saslMechanism,omitempty"` // PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER
+	OAuthTokenUrl         string                `json:"oauthTokenUrl,omitempty"`
+	OAuthClientId         string                `json:"oauthClientId,omitempty"`
+	OAuthClientSecret     string                `json:"oauthClientSecret,omitempty"`
+	OAuthScopes           []string              `json:"oauthScopes,omitempty"`
+	SchemaRegistry        *SchemaRegistryConfig `json:"schemaRegistry,omitempty"`
+	ContentType           string                `json:"contentType,omitempty"` // messages of a content type other than application/json are passed on as binary payloads
+	TracePayloadOnNack    *bool                 `json:"tracePayloadOnNack,omitempty"`
+}
+
+// SchemaRegistryConfig configures a Confluent Schema Registry used to decode
+// Avro encoded messages into JSON payloads
+type SchemaRegistryConfig struct {
+	Url      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
 type Receiver struct {
@@ -107,10 +125,19 @@ type Receiver struct {
 	client              sarama.ConsumerGroup
 	topics              []string
 	handler             func(message *sarama.ConsumerMessage) bool
+	schemaRegistry      *schemaRegistry
 	eventSuccessCounter metric.BoundInt64Counter
 	eventFailureCounter metric.BoundInt64Counter
 	eventBytesCounter   metric.BoundInt64Counter
 	secrets             secret.Vault
+	claims              map[int32]*partitionClaim // claimed partitions of the current session by partition
+	parseErrors         receiver.ParseErrors
+}
+
+// partitionClaim tracks the offset of the last message consumed from a claimed partition
+type partitionClaim struct {
+	claim  sarama.ConsumerGroupClaim
+	offset int64
 }
 
 var DefaultSenderConfig = SenderConfig{
@@ -132,22 +159,28 @@ var DefaultSenderConfig = SenderConfig{
 // SenderConfig can be passed into NewSender() in order to configure
 // the behavior of the sender.
 type SenderConfig struct {
-	Brokers             string               `json:"brokers,omitempty"`
-	Topic               string               `json:"topic,omitempty"`
-	Partition           *int                 `json:"partition,omitempty"`
-	PartitionPath       string               `json:"partitionPath,omitempty"` // if path is set, look up partition from event rather than using the hard coded partition id
-	Username            string               `json:"username,omitempty"`
-	Password            string               `json:"password,omitempty"`
-	CACert              string               `json:"caCert,omitempty"`
-	AccessCert          string               `json:"accessCert,omitempty"`
-	AccessKey           string               `json:"accessKey,omitempty"`
-	Version             string               `json:"version,omitempty"`
-	ChannelBufferSize   *int                 `json:"channelBufferSize,omitempty"`
-	TLSEnable           bool                 `json:"tlsEnable,omitempty"`
-	SenderPoolSize      *int                 `json:"senderPoolSize,omitempty"`
-	DynamicMetricLabels []DynamicMetricLabel `json:"dynamicMetricLabel,omitempty"`
-	CompressionMethod   string               `json:"compressionMethod,omitempty"`
-	CompressionLevel    *int                 `json:"compressionLevel,omitempty"`
+	Brokers               string               `json:"brokers,omitempty"`
+	Topic                 string               `json:"topic,omitempty"`
+	Partition             *int                 `json:"partition,omitempty"`
+	PartitionPath         string               `json:"partitionPath,omitempty"` // if path is set, look up partition from event rather than using the hard coded partition id
+	Username              string               `json:"username,omitempty"`
+	Password              string               `json:"password,omitempty"`
+	CACert                string               `json:"caCert,omitempty"`
+	AccessCert            string               `json:"accessCert,omitempty"`
+	AccessKey             string               `json:"accessKey,omitempty"`
+	Version               string               `json:"version,omitempty"`
+	ChannelBufferSize     *int                 `json:"channelBufferSize,omitempty"`
+	TLSEnable             bool                 `json:"tlsEnable,omitempty"`
+	TLSInsecureSkipVerify *bool                `json:"tlsInsecureSkipVerify,omitempty"`
+	SASLMechanism         string               `json:"saslMechanism,omitempty"` // PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER
+	OAuthTokenUrl         string               `json:"oauthTokenUrl,omitempty"`
+	OAuthClientId         string               `json:"oauthClientId,omitempty"`
+	OAuthClientSecret     string               `json:"oauthClientSecret,omitempty"`
+	OAuthScopes           []string             `json:"oauthScopes,omitempty"`
+	SenderPoolSize        *int                 `json:"senderPoolSize,omitempty"`
+	DynamicMetricLabels   []DynamicMetricLabel `json:"dynamicMetricLabel,omitempty"`
+	CompressionMethod     string               `json:"compressionMethod,omitempty"`
+	CompressionLevel      *int                 `json:"compressionLevel,omitempty"`
 }
 
 type DynamicMetricLabel struct {
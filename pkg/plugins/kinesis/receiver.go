@@ -16,7 +16,6 @@ package kinesis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -55,7 +54,8 @@ const (
 
 // https://docs.aws.amazon.com/streams/latest/dev/troubleshooting-consumers.html
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -69,12 +69,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +161,35 @@ func (r *Receiver) stopShardReceiver(shardIdx int) {
 	}
 }
 
+// setIteratorAge remembers how far the receiver of a shard is behind the tip of the stream
+func (r *Receiver) setIteratorAge(shardIdx int, millisBehindLatest *int64) {
+	if millisBehindLatest == nil {
+		return
+	}
+	r.Lock()
+	if r.iteratorAge == nil {
+		r.iteratorAge = make(map[int]int64)
+	}
+	r.iteratorAge[shardIdx] = *millisBehindLatest
+	r.Unlock()
+}
+
+// ParseErrors returns the messages the receiver recently failed to parse
+func (r *Receiver) ParseErrors() []receiver.ParseError {
+	return r.parseErrors.List()
+}
+
+// Lag returns how many milliseconds the receiver of each shard is behind the tip of the stream
+func (r *Receiver) Lag() []receiver.Lag {
+	r.Lock()
+	defer r.Unlock()
+	lags := make([]receiver.Lag, 0, len(r.iteratorAge))
+	for shardIdx, millis := range r.iteratorAge {
+		lags = append(lags, receiver.Lag{Kind: receiver.LagIteratorAgeMillis, Partition: strconv.Itoa(shardIdx), Value: millis})
+	}
+	return lags
+}
+
 func (r *Receiver) getCheckpointId(shardID int) string {
 	return r.name + "-" + r.config.ConsumerName + "-" + r.config.StreamName + "-" + strconv.Itoa(shardID)
 }
@@ -316,6 +356,7 @@ func (r *Receiver) startShardReceiverEFO(svc *kinesis.Kinesis, stream *kinesis.D
 				r.Lock()
 				close(r.stopChannelMap[shardIdx])
 				delete(r.stopChannelMap, shardIdx)
+				delete(r.iteratorAge, shardIdx)
 				r.Unlock()
 				return
 			default:
@@ -346,12 +387,14 @@ func (r *Receiver) startShardReceiverEFO(svc *kinesis.Kinesis, stream *kinesis.D
 					r.Lock()
 					close(r.stopChannelMap[shardIdx])
 					delete(r.stopChannelMap, shardIdx)
+					delete(r.iteratorAge, shardIdx)
 					r.Unlock()
 					return
 				default:
 				}
 				switch kinEvt := evt.(type) {
 				case *kinesis.SubscribeToShardEvent:
+					r.setIteratorAge(shardIdx, kinEvt.MillisBehindLatest)
 					if kinEvt.ContinuationSequenceNumber != nil {
 						params.StartingPosition.Type = aws.String(kinesis.ShardIteratorTypeAtSequenceNumber)
 						params.StartingPosition.SequenceNumber = kinEvt.ContinuationSequenceNumber
@@ -365,6 +408,7 @@ func (r *Receiver) startShardReceiverEFO(svc *kinesis.Kinesis, stream *kinesis.D
 								r.Lock()
 								close(r.stopChannelMap[shardIdx])
 								delete(r.stopChannelMap, shardIdx)
+								delete(r.iteratorAge, shardIdx)
 								r.Unlock()
 								return
 							case <-time.After(monitorTimeoutSecShort * time.Second):
@@ -379,10 +423,11 @@ func (r *Receiver) startShardReceiverEFO(svc *kinesis.Kinesis, stream *kinesis.D
 							r.Lock()
 							r.receiveCount++
 							r.Unlock()
-							var payload interface{}
-							err = json.Unmarshal(rec.Data, &payload)
+							payload := rec.Data
+							err = event.ValidPayload(payload)
 							if err != nil {
 								r.logger.Error().Str("op", "kinesis.startShardReceiverEFO").Str("stream", *r.stream.StreamDescription.StreamName).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("shardIdx", shardIdx).Msg("cannot parse message " + (*rec.SequenceNumber) + ": " + err.Error())
+								r.parseErrors.Add(err, payload)
 								continue
 							}
 							ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*r.config.AcknowledgeTimeout)*time.Second)
@@ -391,7 +436,7 @@ func (r *Receiver) startShardReceiverEFO(svc *kinesis.Kinesis, stream *kinesis.D
 							trueLagMillis := time.Since(*rec.ApproximateArrivalTimestamp).Milliseconds()
 							r.eventTrueLagMillis.Record(ctx, trueLagMillis)
 							r.logger.Debug().Str("op", "kinesis.startShardReceiverEFO").Str("stream", *r.stream.StreamDescription.StreamName).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("shardIdx", shardIdx).Str("partitionId", *rec.PartitionKey).Str("sequenceId", *rec.SequenceNumber).Msg("message received")
-							e, err := event.New(ctx, payload, event.WithMetadataKeyValue("kinesisMessage", rec), event.WithAck(
+							e, err := event.New(ctx, nil, event.WithRawPayload(payload), event.WithMetadataKeyValue("kinesisMessage", rec), event.WithAck(
 								func(e event.Event) {
 									r.eventSuccessCounter.Add(ctx, 1)
 									if kinEvt.ContinuationSequenceNumber != nil {
@@ -518,6 +563,7 @@ func (r *Receiver) startShardReceiver(svc *kinesis.Kinesis, stream *kinesis.Desc
 					r.Lock()
 					close(r.stopChannelMap[shardIdx])
 					delete(r.stopChannelMap, shardIdx)
+					delete(r.iteratorAge, shardIdx)
 					r.Unlock()
 					return
 				default:
@@ -530,6 +576,7 @@ func (r *Receiver) startShardReceiver(svc *kinesis.Kinesis, stream *kinesis.Desc
 					time.Sleep(errorTimeoutSecShort * time.Second)
 					continue
 				}
+				r.setIteratorAge(shardIdx, getRecordsOutput.MillisBehindLatest)
 				records := getRecordsOutput.Records
 				if len(records) > 0 {
 					r.Lock()
@@ -546,10 +593,11 @@ func (r *Receiver) startShardReceiver(svc *kinesis.Kinesis, stream *kinesis.Desc
 							r.Lock()
 							r.receiveCount++
 							r.Unlock()
-							var payload interface{}
-							err = json.Unmarshal(msg.Data, &payload)
+							payload := msg.Data
+							err = event.ValidPayload(payload)
 							if err != nil {
 								r.logger.Error().Str("op", "kinesis.startShardReceiver").Str("stream", *r.stream.StreamDescription.StreamName).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("shardIdx", shardIdx).Msg("cannot parse message " + (*msg.SequenceNumber) + ": " + err.Error())
+								r.parseErrors.Add(err, payload)
 								return
 							}
 							ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*r.config.AcknowledgeTimeout)*time.Second)
@@ -557,7 +605,7 @@ func (r *Receiver) startShardReceiver(svc *kinesis.Kinesis, stream *kinesis.Desc
 							trueLagMillis := time.Since(*msg.ApproximateArrivalTimestamp).Milliseconds()
 							r.eventTrueLagMillis.Record(ctx, trueLagMillis)
 							r.eventBytesCounter.Add(ctx, int64(len(msg.Data)))
-							e, err := event.New(ctx, payload, event.WithMetadataKeyValue("kinesisMessage", *msg), event.WithAck(
+							e, err := event.New(ctx, nil, event.WithRawPayload(payload), event.WithMetadataKeyValue("kinesisMessage", *msg), event.WithAck(
 								func(e event.Event) {
 									r.eventSuccessCounter.Add(ctx, 1)
 									checkpoint.SetCheckpoint(checkpointId, *msg.SequenceNumber)
@@ -590,6 +638,7 @@ func (r *Receiver) startShardReceiver(svc *kinesis.Kinesis, stream *kinesis.Desc
 							r.Lock()
 							close(r.stopChannelMap[shardIdx])
 							delete(r.stopChannelMap, shardIdx)
+							delete(r.iteratorAge, shardIdx)
 							r.Unlock()
 							return
 						case <-time.After(monitorTimeoutSecShort * time.Second):
@@ -16,7 +16,6 @@ package kinesis
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -28,6 +27,7 @@ import (
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
 	"github.com/xmidt-org/ears/pkg/event"
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -39,7 +39,8 @@ import (
 	"time"
 )
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -53,12 +54,23 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -82,34 +94,44 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		attribute.String(rtsemconv.KinesisStreamNameLabel, s.config.StreamName),
 		attribute.String(rtsemconv.HostnameLabel, hostname),
 	}
-	s.eventSuccessCounter = metric.Must(meter).
-		NewInt64Counter(
+	s.eventSuccessCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventSuccess,
 			metric.WithDescription("measures the number of successful events"),
-		).Bind(commonLabels...)
-	s.eventFailureCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventFailureCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventFailure,
 			metric.WithDescription("measures the number of unsuccessful events"),
-		).Bind(commonLabels...)
-	s.eventBytesCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventBytesCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventBytes,
 			metric.WithDescription("measures the number of event bytes processed"),
 			metric.WithUnit(unit.Bytes),
-		).Bind(commonLabels...)
-	s.eventProcessingTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventProcessingTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventProcessingTime,
 			metric.WithDescription("measures the time an event spends in ears"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
-	s.eventSendOutTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventSendOutTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventSendOutTime,
 			metric.WithDescription("measures the time ears spends to send an event to a downstream data sink"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
+		),
+		commonLabels...,
+	)
 	return s, nil
 }
 
@@ -198,7 +220,7 @@ func (s *Sender) send(events []event.Event) {
 		if idx == 0 {
 			log.Ctx(evt.Context()).Debug().Str("op", "Kinesis.sendWorker").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Int("eventIdx", idx).Int("batchSize", len(events)).Int("sendCount", s.count).Msg("send message batch")
 		}
-		buf, err := json.Marshal(evt.Payload())
+		buf, err := event.MarshalPayload(evt)
 		if err != nil {
 			continue
 		}
@@ -20,6 +20,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/pkg/errs"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/sharder"
 	"github.com/xmidt-org/ears/pkg/tenant"
@@ -53,7 +54,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -120,6 +123,8 @@ type Receiver struct {
 	eventBytesCounter              metric.BoundInt64Counter
 	eventLagMillis                 metric.BoundInt64Histogram
 	eventTrueLagMillis             metric.BoundInt64Histogram
+	iteratorAge                    map[int]int64 // milliseconds behind the tip of the stream by shard index
+	parseErrors                    receiver.ParseErrors
 }
 
 var DefaultSenderConfig = SenderConfig{
@@ -158,11 +163,11 @@ type Sender struct {
 	eventBatch          []event.Event
 	done                chan struct{}
 	secrets             secret.Vault
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
 }
 
 type KinesisError struct {
@@ -16,8 +16,8 @@ package nop
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"go.opentelemetry.io/otel/attribute"
@@ -32,7 +32,8 @@ import (
 	"github.com/xmidt-org/ears/pkg/sender"
 )
 
-func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
 	var cfg SenderConfig
 	var err error
 	switch c := config.(type) {
@@ -46,12 +47,23 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -69,39 +81,49 @@ func NewSender(tid tenant.Id, plugin string, name string, config interface{}, se
 		attribute.String(rtsemconv.EARSAppIdLabel, s.tid.AppId),
 		attribute.String(rtsemconv.EARSOrgIdLabel, s.tid.OrgId),
 	}
-	s.eventSuccessCounter = metric.Must(meter).
-		NewInt64Counter(
+	s.eventSuccessCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventSuccess,
 			metric.WithDescription("measures the number of successful events"),
-		).Bind(commonLabels...)
-	s.eventFailureCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventFailureCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventFailure,
 			metric.WithDescription("measures the number of unsuccessful events"),
-		).Bind(commonLabels...)
-	s.eventBytesCounter = metric.Must(meter).
-		NewInt64Counter(
+		),
+		commonLabels...,
+	)
+	s.eventBytesCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
 			rtsemconv.EARSMetricEventBytes,
 			metric.WithDescription("measures the number of event bytes processed"),
 			metric.WithUnit(unit.Bytes),
-		).Bind(commonLabels...)
-	s.eventProcessingTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventProcessingTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventProcessingTime,
 			metric.WithDescription("measures the time an event spends in ears"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
-	s.eventSendOutTime = metric.Must(meter).
-		NewInt64Histogram(
+		),
+		commonLabels...,
+	)
+	s.eventSendOutTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
 			rtsemconv.EARSMetricEventSendOutTime,
 			metric.WithDescription("measures the time ears spends to send an event to a downstream data sink"),
 			metric.WithUnit(unit.Milliseconds),
-		).Bind(commonLabels...)
+		),
+		commonLabels...,
+	)
 	return s, nil
 }
 
 func (s *Sender) Send(e event.Event) {
-	buf, err := json.Marshal(e.Payload())
+	buf, err := event.MarshalPayload(e)
 	if err != nil {
 		s.eventFailureCounter.Add(e.Context(), 1)
 		e.Nack(err)
@@ -16,8 +16,8 @@ package nop
 
 import (
 	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/tenant"
-	"go.opentelemetry.io/otel/metric"
 	"sync"
 
 	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
@@ -45,7 +45,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -77,9 +79,9 @@ type Sender struct {
 	plugin              string
 	tid                 tenant.Id
 	config              SenderConfig
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
 }
@@ -35,7 +35,8 @@ import (
 	"github.com/xmidt-org/ears/pkg/receiver"
 )
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -49,12 +50,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -119,11 +131,13 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			}
 			r.Unlock()
 		}()
+		r.Lock()
 		r.redisClient = redis.NewClient(&redis.Options{
 			Addr:     r.config.Endpoint,
 			Password: "",
 			DB:       0,
 		})
+		r.Unlock()
 		defer r.redisClient.Close()
 		r.pubsub = r.redisClient.Subscribe(r.config.Channel)
 		defer r.pubsub.Close()
@@ -138,6 +152,7 @@ func (r *Receiver) Receive(next receiver.NextFn) error {
 			err := json.Unmarshal([]byte(msg.Payload), &pl)
 			if err != nil {
 				r.logger.Error().Str("op", "redis.Receive").Msg("cannot parse payload: " + err.Error())
+				r.parseErrors.Add(err, []byte(msg.Payload))
 				continue
 			}
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5)*time.Second)
@@ -187,6 +202,22 @@ func (r *Receiver) Count() int {
 	return r.count
 }
 
+// ParseErrors returns the messages the receiver recently failed to parse
+func (r *Receiver) ParseErrors() []receiver.ParseError {
+	return r.parseErrors.List()
+}
+
+// CheckHealth pings the redis endpoint of the subscription
+func (r *Receiver) CheckHealth(ctx context.Context) error {
+	r.Lock()
+	client := r.redisClient
+	r.Unlock()
+	if client == nil {
+		return nil
+	}
+	return client.WithContext(ctx).Ping().Err()
+}
+
 func (r *Receiver) StopReceiving(ctx context.Context) error {
 	r.Lock()
 	if !r.stopped {
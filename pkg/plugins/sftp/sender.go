@@ -0,0 +1,430 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/sftp"
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/event"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newSenderConfig parses a sender config, fills in its defaults and validates it
+func newSenderConfig(config interface{}) (SenderConfig, error) {
+	var cfg SenderConfig
+	var err error
+	switch c := config.(type) {
+	case string:
+		err = yaml.Unmarshal([]byte(c), &cfg)
+	case []byte:
+		err = yaml.Unmarshal(c, &cfg)
+	case SenderConfig:
+		cfg = c
+	case *SenderConfig:
+		cfg = *c
+	}
+	if err != nil {
+		return cfg, &pkgplugin.InvalidConfigError{
+			Err: err,
+		}
+	}
+	cfg = cfg.WithDefaults()
+	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateSenderConfig checks a sender config without creating the sender
+func ValidateSenderConfig(config interface{}) error {
+	_, err := newSenderConfig(config)
+	return err
+}
+
+func NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (sender.Sender, error) {
+	cfg, err := newSenderConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sender{
+		name:    name,
+		plugin:  plugin,
+		tid:     tid,
+		config:  cfg,
+		logger:  event.GetEventLogger(),
+		secrets: secrets,
+	}
+	s.sshConfig, err = s.clientConfig()
+	if err != nil {
+		return nil, &pkgplugin.InvalidConfigError{
+			Err: err,
+		}
+	}
+	hostname, _ := os.Hostname()
+	// metric recorders
+	meter := global.Meter(rtsemconv.EARSMeterName)
+	commonLabels := []attribute.KeyValue{
+		attribute.String(rtsemconv.EARSPluginTypeLabel, rtsemconv.EARSPluginTypeSFTPSender),
+		attribute.String(rtsemconv.EARSPluginNameLabel, s.Name()),
+		attribute.String(rtsemconv.EARSAppIdLabel, s.tid.AppId),
+		attribute.String(rtsemconv.EARSOrgIdLabel, s.tid.OrgId),
+		attribute.String(rtsemconv.SFTPHostLabel, s.config.Host),
+		attribute.String(rtsemconv.HostnameLabel, hostname),
+	}
+	s.eventSuccessCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
+			rtsemconv.EARSMetricEventSuccess,
+			metric.WithDescription("measures the number of successful events"),
+		),
+		commonLabels...,
+	)
+	s.eventFailureCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
+			rtsemconv.EARSMetricEventFailure,
+			metric.WithDescription("measures the number of unsuccessful events"),
+		),
+		commonLabels...,
+	)
+	s.eventBytesCounter = route.NewEventCounter(
+		metric.Must(meter).NewInt64Counter(
+			rtsemconv.EARSMetricEventBytes,
+			metric.WithDescription("measures the number of event bytes processed"),
+			metric.WithUnit(unit.Bytes),
+		),
+		commonLabels...,
+	)
+	s.eventProcessingTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
+			rtsemconv.EARSMetricEventProcessingTime,
+			metric.WithDescription("measures the time an event spends in ears"),
+			metric.WithUnit(unit.Milliseconds),
+		),
+		commonLabels...,
+	)
+	s.eventSendOutTime = route.NewEventHistogram(
+		metric.Must(meter).NewInt64Histogram(
+			rtsemconv.EARSMetricEventSendOutTime,
+			metric.WithDescription("measures the time ears spends to send an event to a downstream data sink"),
+			metric.WithUnit(unit.Milliseconds),
+		),
+		commonLabels...,
+	)
+	s.done = make(chan struct{})
+	s.startTimedSender()
+	return s, nil
+}
+
+// clientConfig builds the ssh client config from the sender config resolving passwords and keys from the secret vault
+func (s *Sender) clientConfig() (*ssh.ClientConfig, error) {
+	auth := make([]ssh.AuthMethod, 0)
+	if s.config.PrivateKey != "" {
+		key := s.secret(s.config.PrivateKey)
+		if key == "" {
+			return nil, errors.New("cannot resolve sftp private key secret " + s.config.PrivateKey)
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(key))
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if s.config.Password != "" {
+		password := s.secret(s.config.Password)
+		if password == "" {
+			return nil, errors.New("cannot resolve sftp password secret " + s.config.Password)
+		}
+		auth = append(auth, ssh.Password(password))
+	}
+	hostKeyCallback, err := s.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.ClientConfig{
+		User:            s.config.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	}, nil
+}
+
+// hostKeyCallback verifies the identity of the sftp server against the configured host key or known hosts file
+func (s *Sender) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if s.config.HostKey != "" {
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s.config.HostKey))
+		if err != nil {
+			return nil, err
+		}
+		return ssh.FixedHostKey(hostKey), nil
+	}
+	if s.config.KnownHostsFile != "" {
+		return knownhosts.New(s.config.KnownHostsFile)
+	}
+	return nil, errors.New("either hostKey or knownHostsFile is required")
+}
+
+func (s *Sender) secret(key string) string {
+	if s.secrets == nil {
+		return ""
+	}
+	return s.secrets.Secret(key)
+}
+
+// client returns the current sftp client and connects to the sftp server if necessary, caller must hold the lock
+func (s *Sender) client() (*sftp.Client, error) {
+	if s.sftpClient != nil {
+		return s.sftpClient, nil
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(*s.config.Port))
+	sshClient, err := ssh.Dial("tcp", addr, s.sshConfig)
+	if err != nil {
+		return nil, &SFTPError{op: "Dial", err: err}
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, &SFTPError{op: "NewClient", err: err}
+	}
+	s.sshClient = sshClient
+	s.sftpClient = sftpClient
+	return s.sftpClient, nil
+}
+
+// disconnect closes the connection to the sftp server, caller must hold the lock
+func (s *Sender) disconnect() {
+	if s.sftpClient != nil {
+		s.sftpClient.Close()
+		s.sftpClient = nil
+	}
+	if s.sshClient != nil {
+		s.sshClient.Close()
+		s.sshClient = nil
+	}
+}
+
+func (s *Sender) startTimedSender() {
+	done := s.done
+	go func() {
+		for {
+			select {
+			case <-done:
+				s.logger.Info().Str("op", "SFTP.timedSender").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Int("sendCount", s.Count()).Msg("stopping sftp sender")
+				return
+			case <-time.After(time.Duration(*s.config.SendTimeout) * time.Second):
+			}
+			s.Lock()
+			evtBatch := s.eventBatch
+			s.eventBatch = make([]event.Event, 0)
+			s.Unlock()
+			if len(evtBatch) > 0 {
+				s.send(evtBatch)
+			}
+		}
+	}()
+}
+
+func (s *Sender) Count() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.count
+}
+
+func (s *Sender) StopSending(ctx context.Context) {
+	s.Lock()
+	if s.done == nil {
+		s.Unlock()
+		return
+	}
+	close(s.done)
+	s.done = nil
+	evtBatch := s.eventBatch
+	s.eventBatch = make([]event.Event, 0)
+	s.Unlock()
+	// flush pending events so that they are acked or nacked before the connection is closed
+	s.send(evtBatch)
+	s.Lock()
+	s.eventSuccessCounter.Unbind()
+	s.eventFailureCounter.Unbind()
+	s.eventBytesCounter.Unbind()
+	s.eventProcessingTime.Unbind()
+	s.eventSendOutTime.Unbind()
+	s.disconnect()
+	s.Unlock()
+}
+
+// send writes a batch of events as newline delimited json into a single remote file, the file name is
+// evaluated against the first event of the batch
+func (s *Sender) send(events []event.Event) {
+	if len(events) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	// only events that made it into the buffer are acked or nacked with the outcome of the write, the others are
+	// nacked right away
+	written := make([]event.Event, 0, len(events))
+	for _, evt := range events {
+		payload, err := event.MarshalPayload(evt)
+		if err != nil {
+			s.eventFailureCounter.Add(evt.Context(), 1)
+			evt.Nack(err)
+			continue
+		}
+		buf.Write(payload)
+		buf.WriteByte('\n')
+		written = append(written, evt)
+		s.eventBytesCounter.Add(evt.Context(), int64(len(payload)))
+		s.eventProcessingTime.Record(evt.Context(), time.Since(evt.Created()).Milliseconds())
+	}
+	if len(written) == 0 {
+		return
+	}
+	fn, _, _ := written[0].Evaluate(s.config.FileName)
+	fileName, ok := fn.(string)
+	if !ok || fileName == "" {
+		s.nackAll(written, errors.New("sftp file name not a string"))
+		return
+	}
+	// file names come from event content and must not escape the configured path
+	if strings.ContainsAny(fileName, "/\\") || strings.Contains(fileName, "..") {
+		log.Ctx(written[0].Context()).Error().Str("op", "SFTP.send").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Str("fileName", fileName).Int("batchSize", len(written)).Msg("invalid file name")
+		s.nackAll(written, &InvalidFileNameError{FileName: fileName})
+		return
+	}
+	filePath := path.Join(s.config.Path, fileName)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if s.config.Mode == ModeAppend {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	start := time.Now()
+	s.Lock()
+	err := s.write(filePath, flags, buf.Bytes())
+	if err != nil {
+		// force a reconnect on the next batch in case the connection went stale
+		s.disconnect()
+	}
+	s.Unlock()
+	s.eventSendOutTime.Record(written[0].Context(), time.Since(start).Milliseconds())
+	if err != nil {
+		log.Ctx(written[0].Context()).Error().Str("op", "SFTP.send").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Str("path", filePath).Int("batchSize", len(written)).Err(err).Msg("failed to write file")
+		s.nackAll(written, err)
+		return
+	}
+	for _, evt := range written {
+		s.eventSuccessCounter.Add(evt.Context(), 1)
+		evt.Ack()
+	}
+	s.Lock()
+	s.count += len(written)
+	s.Unlock()
+}
+
+// write writes the buffer into the remote file, caller must hold the lock
+func (s *Sender) write(filePath string, flags int, buf []byte) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+	f, err := client.OpenFile(filePath, flags)
+	if err != nil {
+		return &SFTPError{op: "OpenFile", err: err}
+	}
+	if flags&os.O_APPEND != 0 {
+		// the sftp client writes at explicit offsets, not every server honors the append flag on its own
+		_, err = f.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.Close()
+			return &SFTPError{op: "Seek", err: err}
+		}
+	}
+	_, err = f.Write(buf)
+	if err != nil {
+		f.Close()
+		return &SFTPError{op: "Write", err: err}
+	}
+	err = f.Close()
+	if err != nil {
+		return &SFTPError{op: "Close", err: err}
+	}
+	return nil
+}
+
+func (s *Sender) nackAll(events []event.Event, err error) {
+	for _, evt := range events {
+		s.eventFailureCounter.Add(evt.Context(), 1)
+		evt.Nack(err)
+	}
+}
+
+func (s *Sender) Send(e event.Event) {
+	s.Lock()
+	if s.done == nil {
+		s.Unlock()
+		log.Ctx(e.Context()).Error().Str("op", "SFTP.Send").Str("name", s.Name()).Str("tid", s.Tenant().ToString()).Msg("nack event due to stopped sender")
+		e.Nack(errors.New("sftp sender stopped"))
+		return
+	}
+	if s.eventBatch == nil {
+		s.eventBatch = make([]event.Event, 0)
+	}
+	s.eventBatch = append(s.eventBatch, e)
+	if len(s.eventBatch) >= *s.config.MaxNumberOfEvents {
+		eventBatch := s.eventBatch
+		s.eventBatch = make([]event.Event, 0)
+		s.Unlock()
+		s.send(eventBatch)
+	} else {
+		s.Unlock()
+	}
+}
+
+func (s *Sender) Unwrap() sender.Sender {
+	return s
+}
+
+func (s *Sender) Config() interface{} {
+	return s.config
+}
+
+func (s *Sender) Name() string {
+	return s.name
+}
+
+func (s *Sender) Plugin() string {
+	return s.plugin
+}
+
+func (s *Sender) Tenant() tenant.Id {
+	return s.tid
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// WithDefaults
+func (sc SenderConfig) WithDefaults() SenderConfig {
+	cfg := sc
+	if cfg.Port == nil {
+		cfg.Port = DefaultSenderConfig.Port
+	}
+	if cfg.FileName == "" {
+		cfg.FileName = DefaultSenderConfig.FileName
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = DefaultSenderConfig.Mode
+	}
+	if cfg.MaxNumberOfEvents == nil {
+		cfg.MaxNumberOfEvents = DefaultSenderConfig.MaxNumberOfEvents
+	}
+	if cfg.SendTimeout == nil {
+		cfg.SendTimeout = DefaultSenderConfig.SendTimeout
+	}
+	return cfg
+}
+
+// Validate
+func (sc *SenderConfig) Validate() error {
+	schema := gojsonschema.NewStringLoader(senderSchema)
+	doc := gojsonschema.NewGoLoader(*sc)
+	result, err := gojsonschema.Validate(schema, doc)
+	if err != nil {
+		return err
+	}
+	if !result.Valid() {
+		return fmt.Errorf(fmt.Sprintf("%+v", result.Errors()))
+	}
+	if sc.Password == "" && sc.PrivateKey == "" {
+		return errors.New("either password or privateKey is required")
+	}
+	if sc.HostKey == "" && sc.KnownHostsFile == "" {
+		return errors.New("either hostKey or knownHostsFile is required")
+	}
+	return nil
+}
+
+const senderSchema = `
+{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "$ref": "#/definitions/SenderConfig",
+    "definitions": {
+        "SenderConfig": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+                "host": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "maximum": 65535
+                },
+                "username": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "privateKey": {
+                    "type": "string"
+                },
+                "hostKey": {
+                    "type": "string"
+                },
+                "knownHostsFile": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": ["upload", "append"]
+                },
+                "maxNumberOfEvents": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "maximum": 10000
+                },
+                "sendTimeout": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "maximum": 3600
+                }
+            },
+            "required": [
+                "host", "username"
+            ],
+            "title": "SenderConfig"
+        }
+    }
+}
+`
@@ -0,0 +1,471 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/event"
+	earssftp "github.com/xmidt-org/ears/pkg/plugins/sftp"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestMain(m *testing.M) {
+	logger := zerolog.New(ioutil.Discard)
+	event.SetEventLogger(&logger)
+	os.Exit(m.Run())
+}
+
+type testVault map[string]string
+
+func (v testVault) Secret(key string) string {
+	return v[key]
+}
+
+// testServer is an ssh server serving an in memory sftp file system
+type testServer struct {
+	listener net.Listener
+	hostKey  ssh.PublicKey
+	handlers sftp.Handlers
+	fail     bool
+}
+
+func newTestServer(t *testing.T) *testServer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate host key: %s", err.Error())
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("cannot create signer: %s", err.Error())
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "ears" && string(pass) == "mypassword" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(signer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err.Error())
+	}
+	s := &testServer{
+		listener: listener,
+		hostKey:  signer.PublicKey(),
+		handlers: sftp.InMemHandler(),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+	})
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" || s.fail {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		go func() {
+			server := sftp.NewRequestServer(channel, s.handlers)
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func (s *testServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testServer) authorizedKey() string {
+	return string(ssh.MarshalAuthorizedKey(s.hostKey))
+}
+
+// open opens a file of the in memory file system through an sftp client connected over a pipe
+func (s *testServer) open(name string) ([]byte, error) {
+	clientConn, serverConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, s.handlers)
+	go server.Serve()
+	defer server.Close()
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	f, err := client.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func (s *testServer) file(t *testing.T, name string) string {
+	buf, err := s.open(name)
+	if err != nil {
+		t.Fatalf("cannot open %s: %s", name, err.Error())
+	}
+	return string(buf)
+}
+
+func (s *testServer) exists(name string) bool {
+	_, err := s.open(name)
+	return err == nil
+}
+
+func (s *testServer) config() earssftp.SenderConfig {
+	return earssftp.SenderConfig{
+		Host:              "127.0.0.1",
+		Port:              pointer.Int(s.port()),
+		Username:          "ears",
+		Password:          "secret://sftp.password",
+		HostKey:           s.authorizedKey(),
+		Path:              "/",
+		FileName:          "{.id}.json",
+		MaxNumberOfEvents: pointer.Int(2),
+		SendTimeout:       pointer.Int(3600),
+	}
+}
+
+var secrets = testVault{"secret://sftp.password": "mypassword"}
+
+// testEvent returns an event and a channel receiving nil once the event is acked or the error it is nacked with
+func testEvent(t *testing.T, payload interface{}) (event.Event, chan error) {
+	done := make(chan error, 2)
+	e, err := event.New(context.Background(), payload, event.WithAck(
+		func(event.Event) {
+			done <- nil
+		},
+		func(_ event.Event, err error) {
+			done <- err
+		},
+	))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	return e, done
+}
+
+// outcome waits for the ack or nack of an event and makes sure there is only one of them
+func outcome(t *testing.T, done chan error) error {
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event neither acked nor nacked")
+	}
+	select {
+	case <-done:
+		t.Fatalf("event acked or nacked more than once")
+	case <-time.After(50 * time.Millisecond):
+	}
+	return err
+}
+
+func TestSenderBatch(t *testing.T) {
+	server := newTestServer(t)
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", server.config(), secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e1, done1 := testEvent(t, map[string]interface{}{"id": "batch", "n": 1})
+	s.Send(e1)
+	select {
+	case <-done1:
+		t.Fatalf("incomplete batch sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+	e2, done2 := testEvent(t, map[string]interface{}{"id": "other", "n": 2})
+	s.Send(e2)
+	for _, done := range []chan error{done1, done2} {
+		if err := outcome(t, done); err != nil {
+			t.Fatalf("event nacked: %s", err.Error())
+		}
+	}
+	// the file name is evaluated against the first event of the batch
+	if server.exists("/other.json") {
+		t.Fatalf("file name evaluated against second event")
+	}
+	content := server.file(t, "/batch.json")
+	if content != `{"id":"batch","n":1}`+"\n"+`{"id":"other","n":2}`+"\n" {
+		t.Fatalf("unexpected file content: %q", content)
+	}
+}
+
+func TestSenderTimeout(t *testing.T) {
+	server := newTestServer(t)
+	cfg := server.config()
+	cfg.MaxNumberOfEvents = pointer.Int(10)
+	cfg.SendTimeout = pointer.Int(1)
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e, done := testEvent(t, map[string]interface{}{"id": "timeout"})
+	s.Send(e)
+	if err := outcome(t, done); err != nil {
+		t.Fatalf("event nacked: %s", err.Error())
+	}
+	if content := server.file(t, "/timeout.json"); content != `{"id":"timeout"}`+"\n" {
+		t.Fatalf("unexpected file content: %q", content)
+	}
+}
+
+func TestSenderMode(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected string
+	}{
+		{
+			mode:     earssftp.ModeUpload,
+			expected: `{"id":"mode","n":2}` + "\n",
+		},
+		{
+			mode:     earssftp.ModeAppend,
+			expected: `{"id":"mode","n":1}` + "\n" + `{"id":"mode","n":2}` + "\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			server := newTestServer(t)
+			cfg := server.config()
+			cfg.Mode = tc.mode
+			cfg.MaxNumberOfEvents = pointer.Int(1)
+			s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", cfg, secrets)
+			if err != nil {
+				t.Fatalf("cannot create sender: %s", err.Error())
+			}
+			defer s.StopSending(context.Background())
+			for n := 1; n <= 2; n++ {
+				e, done := testEvent(t, map[string]interface{}{"id": "mode", "n": n})
+				s.Send(e)
+				if err := outcome(t, done); err != nil {
+					t.Fatalf("event nacked: %s", err.Error())
+				}
+			}
+			if content := server.file(t, "/mode.json"); content != tc.expected {
+				t.Fatalf("unexpected file content: %q", content)
+			}
+		})
+	}
+}
+
+func TestSenderNack(t *testing.T) {
+	server := newTestServer(t)
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", server.config(), secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+
+	// an event that cannot be marshaled is nacked once, the rest of the batch is still written
+	e1, done1 := testEvent(t, map[string]interface{}{"id": make(chan int)})
+	s.Send(e1)
+	e2, done2 := testEvent(t, map[string]interface{}{"id": "marshal"})
+	s.Send(e2)
+	if err := outcome(t, done1); err == nil {
+		t.Fatalf("expected nack for payload that cannot be marshaled")
+	}
+	if err := outcome(t, done2); err != nil {
+		t.Fatalf("event nacked: %s", err.Error())
+	}
+	if content := server.file(t, "/marshal.json"); content != `{"id":"marshal"}`+"\n" {
+		t.Fatalf("unexpected file content: %q", content)
+	}
+
+	// a file name that does not evaluate to a string nacks the batch
+	cfg := server.config()
+	cfg.FileName = "{.id}"
+	s, err = earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e3, done3 := testEvent(t, map[string]interface{}{"id": 1})
+	s.Send(e3)
+	e4, done4 := testEvent(t, map[string]interface{}{"id": 2})
+	s.Send(e4)
+	for _, done := range []chan error{done3, done4} {
+		if err := outcome(t, done); err == nil {
+			t.Fatalf("expected nack for invalid file name")
+		}
+	}
+}
+
+func TestSenderWriteError(t *testing.T) {
+	server := newTestServer(t)
+	server.fail = true
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", server.config(), secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e1, done1 := testEvent(t, map[string]interface{}{"id": "fail"})
+	s.Send(e1)
+	e2, done2 := testEvent(t, map[string]interface{}{"id": "fail"})
+	s.Send(e2)
+	for _, done := range []chan error{done1, done2} {
+		if err := outcome(t, done); err == nil {
+			t.Fatalf("expected nack for failed write")
+		}
+	}
+}
+
+func TestSenderHostKey(t *testing.T) {
+	server := newTestServer(t)
+	tid := tenant.Id{OrgId: "myorg", AppId: "myapp"}
+
+	cfg := server.config()
+	cfg.HostKey = ""
+	_, err := earssftp.NewSender(tid, "sftp", "mysender", cfg, secrets)
+	if err == nil {
+		t.Fatalf("expected error for missing host key")
+	}
+
+	// a server presenting an unexpected host key is rejected
+	other := newTestServer(t)
+	cfg = server.config()
+	cfg.HostKey = other.authorizedKey()
+	s, err := earssftp.NewSender(tid, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	e1, done1 := testEvent(t, map[string]interface{}{"id": "hostkey"})
+	s.Send(e1)
+	e2, done2 := testEvent(t, map[string]interface{}{"id": "hostkey"})
+	s.Send(e2)
+	for _, done := range []chan error{done1, done2} {
+		if err := outcome(t, done); err == nil {
+			t.Fatalf("expected nack for unknown host key")
+		}
+	}
+	s.StopSending(context.Background())
+
+	// the host key can also come from a known hosts file
+	knownHosts, err := ioutil.TempFile(t.TempDir(), "known_hosts")
+	if err != nil {
+		t.Fatalf("cannot create known hosts file: %s", err.Error())
+	}
+	knownHosts.WriteString("[127.0.0.1]:" + strconv.Itoa(server.port()) + " " + server.authorizedKey())
+	knownHosts.Close()
+	cfg = server.config()
+	cfg.HostKey = ""
+	cfg.KnownHostsFile = knownHosts.Name()
+	s, err = earssftp.NewSender(tid, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	e3, done3 := testEvent(t, map[string]interface{}{"id": "knownhosts"})
+	s.Send(e3)
+	e4, done4 := testEvent(t, map[string]interface{}{"id": "knownhosts"})
+	s.Send(e4)
+	for _, done := range []chan error{done3, done4} {
+		if err := outcome(t, done); err != nil {
+			t.Fatalf("event nacked: %s", err.Error())
+		}
+	}
+}
+
+func TestSenderFileNameEscape(t *testing.T) {
+	server := newTestServer(t)
+	cfg := server.config()
+	cfg.Path = "/data"
+	cfg.MaxNumberOfEvents = pointer.Int(1)
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	defer s.StopSending(context.Background())
+	for _, id := range []string{"../escape", "sub/escape", `..\escape`, ".."} {
+		e, done := testEvent(t, map[string]interface{}{"id": id})
+		s.Send(e)
+		err := outcome(t, done)
+		var fileNameErr *earssftp.InvalidFileNameError
+		if !errors.As(err, &fileNameErr) {
+			t.Fatalf("expected nack with invalid file name for %s but got %v", id, err)
+		}
+	}
+	if server.exists("/escape.json") || server.exists("/data/sub/escape.json") {
+		t.Fatalf("file written outside of the configured path")
+	}
+}
+
+func TestSenderStopFlushes(t *testing.T) {
+	server := newTestServer(t)
+	cfg := server.config()
+	cfg.MaxNumberOfEvents = pointer.Int(10)
+	s, err := earssftp.NewSender(tenant.Id{OrgId: "myorg", AppId: "myapp"}, "sftp", "mysender", cfg, secrets)
+	if err != nil {
+		t.Fatalf("cannot create sender: %s", err.Error())
+	}
+	e1, done1 := testEvent(t, map[string]interface{}{"id": "stop"})
+	s.Send(e1)
+	s.StopSending(context.Background())
+	if err := outcome(t, done1); err != nil {
+		t.Fatalf("event nacked: %s", err.Error())
+	}
+	if content := server.file(t, "/stop.json"); content != `{"id":"stop"}`+"\n" {
+		t.Fatalf("unexpected file content: %q", content)
+	}
+	// events sent after stopping are nacked rather than batched
+	e2, done2 := testEvent(t, map[string]interface{}{"id": "stopped"})
+	s.Send(e2)
+	if err := outcome(t, done2); err == nil {
+		t.Fatalf("expected nack for stopped sender")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/errs"
+	"github.com/xmidt-org/ears/pkg/event"
+	pkgplugin "github.com/xmidt-org/ears/pkg/plugin"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/secret"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+	"golang.org/x/crypto/ssh"
+)
+
+var _ sender.Sender = (*Sender)(nil)
+
+var (
+	Name     = "sftp"
+	Version  = "v0.0.0"
+	CommitID = ""
+)
+
+func NewPlugin() (*pkgplugin.Plugin, error) {
+	return NewPluginVersion(Name, Version, CommitID)
+}
+
+func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.Plugin, error) {
+	return pkgplugin.NewPlugin(
+		pkgplugin.WithName(name),
+		pkgplugin.WithVersion(version),
+		pkgplugin.WithCommitID(commitID),
+		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
+	)
+}
+
+const (
+	// ModeUpload writes each batch of events into a new file
+	ModeUpload = "upload"
+	// ModeAppend appends each batch of events to the end of a file, creating the file if necessary
+	ModeAppend = "append"
+)
+
+var DefaultSenderConfig = SenderConfig{
+	Host:              "",
+	Port:              pointer.Int(22),
+	Username:          "",
+	Password:          "",
+	PrivateKey:        "",
+	HostKey:           "",
+	KnownHostsFile:    "",
+	Path:              "",
+	FileName:          "{trace.id}.json",
+	Mode:              ModeUpload,
+	MaxNumberOfEvents: pointer.Int(1),
+	SendTimeout:       pointer.Int(1),
+}
+
+// SenderConfig can be passed into NewSender() in order to configure
+// the behavior of the sender.
+type SenderConfig struct {
+	Host              string `json:"host,omitempty"`
+	Port              *int   `json:"port,omitempty"`
+	Username          string `json:"username,omitempty"`
+	Password          string `json:"password,omitempty"`
+	PrivateKey        string `json:"privateKey,omitempty"`
+	HostKey           string `json:"hostKey,omitempty"`
+	KnownHostsFile    string `json:"knownHostsFile,omitempty"`
+	Path              string `json:"path,omitempty"`
+	FileName          string `json:"fileName,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	MaxNumberOfEvents *int   `json:"maxNumberOfEvents,omitempty"`
+	SendTimeout       *int   `json:"sendTimeout,omitempty"`
+}
+
+type Sender struct {
+	sync.Mutex
+	sshConfig           *ssh.ClientConfig
+	sshClient           *ssh.Client
+	sftpClient          *sftp.Client
+	name                string
+	plugin              string
+	tid                 tenant.Id
+	config              SenderConfig
+	count               int
+	logger              *zerolog.Logger
+	done                chan struct{}
+	secrets             secret.Vault
+	eventBatch          []event.Event
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
+}
+
+type SFTPError struct {
+	op  string
+	err error
+}
+
+func (e *SFTPError) Error() string {
+	return errs.String("SFTPError", map[string]interface{}{"op": e.op}, e.err)
+}
+
+func (e *SFTPError) Unwrap() error {
+	return e.err
+}
+
+// InvalidFileNameError is returned for file names evaluated from an event that would leave the configured path
+type InvalidFileNameError struct {
+	FileName string
+}
+
+func (e *InvalidFileNameError) Error() string {
+	return errs.String("InvalidFileNameError", map[string]interface{}{"fileName": e.FileName}, nil)
+}
@@ -16,13 +16,13 @@ package sqs
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/goccy/go-yaml"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
@@ -43,7 +43,8 @@ import (
 	"time"
 )
 
-func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+// newReceiverConfig parses a receiver config, fills in its defaults and validates it
+func newReceiverConfig(config interface{}) (ReceiverConfig, error) {
 	var cfg ReceiverConfig
 	var err error
 	switch c := config.(type) {
@@ -57,12 +58,23 @@ func NewReceiver(tid tenant.Id, plugin string, name string, config interface{},
 		cfg = *c
 	}
 	if err != nil {
-		return nil, &pkgplugin.InvalidConfigError{
+		return cfg, &pkgplugin.InvalidConfigError{
 			Err: err,
 		}
 	}
 	cfg = cfg.WithDefaults()
 	err = cfg.Validate()
+	return cfg, err
+}
+
+// ValidateReceiverConfig checks a receiver config without creating the receiver
+func ValidateReceiverConfig(config interface{}) error {
+	_, err := newReceiverConfig(config)
+	return err
+}
+
+func NewReceiver(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (receiver.Receiver, error) {
+	cfg, err := newReceiverConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -115,9 +127,15 @@ const (
 	approximateReceiveCount     = "ApproximateReceiveCount"
 	approximateNumberOfMessages = "ApproximateNumberOfMessages"
 	attributeNames              = "All"
+	minReceiveBackoff           = 1 * time.Second
+	maxReceiveBackoff           = 60 * time.Second
+	maxDeleteAttempts           = 3
 )
 
 func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
+	r.Lock()
+	done := r.done
+	r.Unlock()
 	go func() {
 		defer func() {
 			p := recover()
@@ -146,17 +164,27 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 				}
 				queueAttributesResp, err := svc.GetQueueAttributes(queueAttributesParams)
 				if err != nil {
-					r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg(err.Error())
+					r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Err(err).Msg("error getting queue attributes")
 				} else {
 					if queueAttributesResp.Attributes[approximateNumberOfMessages] != nil {
 						numMsgs, err := strconv.Atoi(*queueAttributesResp.Attributes[approximateNumberOfMessages])
 						if err != nil {
-							r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("error parsing message count: " + err.Error())
+							r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Err(err).Msg("error parsing message count")
 						}
 						r.eventQueueDepth.Record(ctx, int64(numMsgs))
+						if err == nil {
+							queueDepth := int64(numMsgs)
+							r.Lock()
+							r.queueDepth = &queueDepth
+							r.Unlock()
+						}
 					}
 				}
-				time.Sleep(30 * time.Second)
+				select {
+				case <-done:
+					return
+				case <-time.After(30 * time.Second):
+				}
 			}
 		}()
 		//messageRetries := make(map[string]int)
@@ -193,9 +221,9 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 						Entries:  deleteBatch,
 						QueueUrl: aws.String(r.config.QueueUrl),
 					}
-					_, err := svc.DeleteMessageBatch(deleteParams)
+					err := r.deleteMessageBatch(svc, deleteParams, n)
 					if err != nil {
-						r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Msg("delete error: " + err.Error())
+						r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Int("batchSize", len(deleteBatch)).Err(err).Msg("delete error")
 					} else {
 						r.Lock()
 						r.deleteCount += len(deleteBatch)
@@ -217,6 +245,7 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 			}
 		}()
 		// receive messages
+		backoff := time.Duration(0)
 		for {
 			sqsParams := &sqs.ReceiveMessageInput{
 				QueueUrl:              aws.String(r.config.QueueUrl),
@@ -235,10 +264,17 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 				return
 			}
 			if err != nil {
-				r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg(err.Error())
-				time.Sleep(1 * time.Second)
+				backoff = nextBackoff(backoff)
+				r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Dur("backoff", backoff).Err(err).Msg("receive error")
+				select {
+				case <-done:
+					r.logger.Info().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("receive loop stopped")
+					return
+				case <-time.After(backoff):
+				}
 				continue
 			}
+			backoff = 0
 			if len(sqsResp.Messages) > 0 {
 				r.Lock()
 				r.logger.Debug().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("receiveCount", r.receiveCount).Int("batchSize", len(sqsResp.Messages)).Int("workerNum", n).Msg("received message batch")
@@ -265,28 +301,27 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 				if message.Attributes[approximateReceiveCount] != nil {
 					retryAttempt, err = strconv.Atoi(*message.Attributes[approximateReceiveCount])
 					if err != nil {
-						r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("error parsing receive count: " + err.Error())
+						r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Str("messageId", *message.MessageId).Err(err).Msg("error parsing receive count")
 					}
 					retryAttempt--
 				}
-				if retryAttempt > *(r.config.NumRetries) {
-					r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("max retries reached for " + (*message.MessageId))
-					entry := sqs.DeleteMessageBatchRequestEntry{Id: message.MessageId, ReceiptHandle: message.ReceiptHandle}
-					entries <- &entry
+				payload := []byte(*message.Body)
+				err = event.ValidPayload(payload)
+				if err != nil {
+					// the receive count includes the current attempt
+					r.handlePoisonMessage(svc, message, retryAttempt+1, err, entries, traceId, n)
 					cancel()
 					continue
 				}
-				var payload interface{}
-				err = json.Unmarshal([]byte(*message.Body), &payload)
-				if err != nil {
-					r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("cannot parse message " + (*message.MessageId) + ": " + err.Error())
+				if retryAttempt > *(r.config.NumRetries) {
+					r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Str("messageId", *message.MessageId).Int("retryAttempt", retryAttempt).Msg("max retries reached")
 					entry := sqs.DeleteMessageBatchRequestEntry{Id: message.MessageId, ReceiptHandle: message.ReceiptHandle}
 					entries <- &entry
 					cancel()
 					continue
 				}
 				r.eventBytesCounter.Add(ctx, int64(len(*message.Body)))
-				e, err := event.New(ctx, payload, event.WithMetadataKeyValue("sqsMessage", *message), event.WithAck(
+				e, err := event.New(ctx, nil, event.WithRawPayload(payload), event.WithMetadataKeyValue("sqsMessage", *message), event.WithAck(
 					func(e event.Event) {
 						msg, ok := e.Metadata()["sqsMessage"].(sqs.Message) // get metadata associated with this event
 						//log.Ctx(e.Context()).Debug().Str("op", "SQS.receiveWorker").Int("batchSize", len(sqsResp.Messages)).Int("workerNum", n).Msg("processed message " + (*msg.MessageId))
@@ -315,8 +350,9 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 					event.WithTracePayloadOnNack(*r.config.TracePayloadOnNack))
 				if err != nil {
 					cancel()
-					r.logger.Error().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Msg("cannot create event: " + err.Error())
-					return
+					// leave the message on the queue so it will be redelivered after the visibility timeout
+					r.logger.Error().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Str("messageId", *message.MessageId).Err(err).Msg("cannot create event")
+					continue
 				}
 				r.Trigger(e)
 			}
@@ -324,6 +360,77 @@ func (r *Receiver) startReceiveWorker(svc *sqs.SQS, n int) {
 	}()
 }
 
+// nextBackoff doubles the current backoff starting at minReceiveBackoff and capped at maxReceiveBackoff
+func nextBackoff(current time.Duration) time.Duration {
+	if current < minReceiveBackoff {
+		return minReceiveBackoff
+	}
+	next := current * 2
+	if next > maxReceiveBackoff {
+		return maxReceiveBackoff
+	}
+	return next
+}
+
+// deleteMessageBatch deletes a batch of messages and retries failed deletes with exponential backoff
+func (r *Receiver) deleteMessageBatch(svc sqsiface.SQSAPI, params *sqs.DeleteMessageBatchInput, n int) error {
+	var err error
+	backoff := time.Duration(0)
+	for attempt := 1; attempt <= maxDeleteAttempts; attempt++ {
+		var output *sqs.DeleteMessageBatchOutput
+		output, err = svc.DeleteMessageBatch(params)
+		if err == nil {
+			if len(output.Failed) == 0 {
+				return nil
+			}
+			failed := make([]*sqs.DeleteMessageBatchRequestEntry, 0)
+			for _, f := range output.Failed {
+				for _, entry := range params.Entries {
+					if *entry.Id == *f.Id {
+						failed = append(failed, entry)
+						break
+					}
+				}
+			}
+			params.Entries = failed
+			err = fmt.Errorf("%d messages failed to delete", len(failed))
+		}
+		if attempt < maxDeleteAttempts {
+			backoff = nextBackoff(backoff)
+			r.logger.Warn().Str("op", "SQS.receiveWorker").Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Int("attempt", attempt).Dur("backoff", backoff).Err(err).Msg("delete error, retrying")
+			time.Sleep(backoff)
+		}
+	}
+	return err
+}
+
+// handlePoisonMessage applies the poison message policy to a message that cannot be parsed; messages
+// remain on the queue until they have failed to parse more than maxParseFailures times
+func (r *Receiver) handlePoisonMessage(svc sqsiface.SQSAPI, message *sqs.Message, parseFailures int, parseErr error, entries chan<- *sqs.DeleteMessageBatchRequestEntry, traceId string, n int) {
+	r.parseErrors.Add(parseErr, []byte(aws.StringValue(message.Body)))
+	logger := r.logger.With().Str("op", "SQS.receiveWorker").Str(rtsemconv.EarsLogTraceIdKey, traceId).Str("name", r.Name()).Str("tid", r.Tenant().ToString()).Int("workerNum", n).Str("messageId", *message.MessageId).Int("parseFailures", parseFailures).Logger()
+	if parseFailures <= *r.config.MaxParseFailures {
+		logger.Warn().Err(parseErr).Msg("cannot parse message, awaiting redelivery")
+		return
+	}
+	if r.config.PoisonMessagePolicy == PoisonMessagePolicyDLQ {
+		_, err := svc.SendMessage(&sqs.SendMessageInput{
+			QueueUrl:          aws.String(r.config.DeadLetterQueueUrl),
+			MessageBody:       message.Body,
+			MessageAttributes: message.MessageAttributes,
+		})
+		if err != nil {
+			// keep the message on the queue and try again on redelivery
+			logger.Error().Str("deadLetterQueueUrl", r.config.DeadLetterQueueUrl).Err(err).Msg("cannot forward poison message to dead letter queue")
+			return
+		}
+		logger.Error().Str("deadLetterQueueUrl", r.config.DeadLetterQueueUrl).Err(parseErr).Msg("poison message forwarded to dead letter queue")
+	} else {
+		logger.Error().Err(parseErr).Msg("poison message skipped")
+	}
+	entries <- &sqs.DeleteMessageBatchRequestEntry{Id: message.MessageId, ReceiptHandle: message.ReceiptHandle}
+}
+
 func (r *Receiver) Receive(next receiver.NextFn) error {
 	if r == nil {
 		return &pkgplugin.Error{
@@ -399,6 +506,21 @@ func (r *Receiver) StopReceiving(ctx context.Context) error {
 	return nil
 }
 
+// ParseErrors returns the messages the receiver recently failed to parse
+func (r *Receiver) ParseErrors() []receiver.ParseError {
+	return r.parseErrors.List()
+}
+
+// Lag returns the approximate number of messages waiting in the queue
+func (r *Receiver) Lag() []receiver.Lag {
+	r.Lock()
+	defer r.Unlock()
+	if r.queueDepth == nil {
+		return nil
+	}
+	return []receiver.Lag{{Kind: receiver.LagQueueDepth, Value: *r.queueDepth}}
+}
+
 func (r *Receiver) Trigger(e event.Event) {
 	r.Lock()
 	next := r.next
@@ -54,6 +54,12 @@ func (rc *ReceiverConfig) WithDefaults() ReceiverConfig {
 	if cfg.AWSRegion == "" {
 		cfg.AWSRegion = DefaultReceiverConfig.AWSRegion
 	}
+	if cfg.MaxParseFailures == nil {
+		cfg.MaxParseFailures = DefaultReceiverConfig.MaxParseFailures
+	}
+	if cfg.PoisonMessagePolicy == "" {
+		cfg.PoisonMessagePolicy = DefaultReceiverConfig.PoisonMessagePolicy
+	}
 	return cfg
 }
 
@@ -68,6 +74,9 @@ func (rc *ReceiverConfig) Validate() error {
 	if !result.Valid() {
 		return fmt.Errorf(fmt.Sprintf("%+v", result.Errors()))
 	}
+	if rc.PoisonMessagePolicy == PoisonMessagePolicyDLQ && rc.DeadLetterQueueUrl == "" {
+		return fmt.Errorf("deadLetterQueueUrl required for poison message policy %s", PoisonMessagePolicyDLQ)
+	}
 	return nil
 }
 
@@ -135,6 +144,18 @@ const receiverSchema = `
 				"tracePayloadOnNack" : {
 					"type": "boolean",
 					"default": false
+				},
+				"maxParseFailures": {
+                    "type": "integer", 
+					"minimum": 0,
+					"maximum": 100
+				},
+				"poisonMessagePolicy": {
+                    "type": "string",
+					"enum": ["skip", "dlq"]
+				},
+				"deadLetterQueueUrl": {
+                    "type": "string"
 				}
             },
             "required": [
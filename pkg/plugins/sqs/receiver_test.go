@@ -0,0 +1,163 @@
+package sqs
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"github.com/xorcare/pointer"
+	"testing"
+	"time"
+)
+
+type mockSQS struct {
+	sqsiface.SQSAPI
+	deletedIds    [][]string
+	deleteOutputs []*sqs.DeleteMessageBatchOutput
+	sent          []*sqs.SendMessageInput
+	sendErr       error
+}
+
+func (m *mockSQS) DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	ids := make([]string, 0, len(input.Entries))
+	for _, entry := range input.Entries {
+		ids = append(ids, *entry.Id)
+	}
+	m.deletedIds = append(m.deletedIds, ids)
+	output := &sqs.DeleteMessageBatchOutput{}
+	if len(m.deleteOutputs) > 0 {
+		output = m.deleteOutputs[0]
+		m.deleteOutputs = m.deleteOutputs[1:]
+	}
+	return output, nil
+}
+
+func (m *mockSQS) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	if m.sendErr != nil {
+		return nil, m.sendErr
+	}
+	m.sent = append(m.sent, input)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func newTestReceiver(config ReceiverConfig) *Receiver {
+	logger := zerolog.Nop()
+	return &Receiver{
+		config: config.WithDefaults(),
+		name:   "mysqs",
+		plugin: "sqs",
+		tid:    tenant.Id{OrgId: "myorg", AppId: "myapp"},
+		logger: &logger,
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, 60 * time.Second, 60 * time.Second}
+	backoff := time.Duration(0)
+	for i, e := range expected {
+		backoff = nextBackoff(backoff)
+		if backoff != e {
+			t.Fatalf("backoff %d: expected %s but got %s", i, e, backoff)
+		}
+	}
+}
+
+func TestDeleteMessageBatchRetriesFailedEntries(t *testing.T) {
+	r := newTestReceiver(ReceiverConfig{})
+	svc := &mockSQS{
+		deleteOutputs: []*sqs.DeleteMessageBatchOutput{
+			{Failed: []*sqs.BatchResultErrorEntry{{Id: aws.String("m2")}}},
+		},
+	}
+	err := r.deleteMessageBatch(svc, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("myqueue"),
+		Entries: []*sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("m1"), ReceiptHandle: aws.String("h1")},
+			{Id: aws.String("m2"), ReceiptHandle: aws.String("h2")},
+		},
+	}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(svc.deletedIds) != 2 || len(svc.deletedIds[1]) != 1 || svc.deletedIds[1][0] != "m2" {
+		t.Fatalf("expected only the failed entry to be retried but got %v", svc.deletedIds)
+	}
+}
+
+func TestHandlePoisonMessage(t *testing.T) {
+	message := &sqs.Message{
+		MessageId:     aws.String("m1"),
+		ReceiptHandle: aws.String("h1"),
+		Body:          aws.String("{not json"),
+	}
+	parseErr := errors.New("cannot parse")
+	testCases := []struct {
+		name          string
+		config        ReceiverConfig
+		parseFailures int
+		sendErr       error
+		deleted       bool
+		forwarded     bool
+	}{
+		{
+			name:          "awaiting redelivery",
+			config:        ReceiverConfig{MaxParseFailures: pointer.Int(2)},
+			parseFailures: 2,
+		},
+		{
+			name:          "skip",
+			config:        ReceiverConfig{MaxParseFailures: pointer.Int(2)},
+			parseFailures: 3,
+			deleted:       true,
+		},
+		{
+			name:          "dlq",
+			config:        ReceiverConfig{PoisonMessagePolicy: PoisonMessagePolicyDLQ, DeadLetterQueueUrl: "mydlq"},
+			parseFailures: 1,
+			deleted:       true,
+			forwarded:     true,
+		},
+		{
+			name:          "dlq unavailable",
+			config:        ReceiverConfig{PoisonMessagePolicy: PoisonMessagePolicyDLQ, DeadLetterQueueUrl: "mydlq"},
+			parseFailures: 1,
+			sendErr:       errors.New("dlq unavailable"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReceiver(tc.config)
+			svc := &mockSQS{sendErr: tc.sendErr}
+			entries := make(chan *sqs.DeleteMessageBatchRequestEntry, 1)
+			r.handlePoisonMessage(svc, message, tc.parseFailures, parseErr, entries, "", 0)
+			if deleted := len(entries) == 1; deleted != tc.deleted {
+				t.Fatalf("expected deleted %t but got %t", tc.deleted, deleted)
+			}
+			if forwarded := len(svc.sent) == 1; forwarded != tc.forwarded {
+				t.Fatalf("expected forwarded %t but got %t", tc.forwarded, forwarded)
+			}
+			if tc.forwarded && (*svc.sent[0].QueueUrl != "mydlq" || *svc.sent[0].MessageBody != *message.Body) {
+				t.Fatalf("unexpected dead letter message %+v", svc.sent[0])
+			}
+		})
+	}
+}
+
+func TestReceiverConfigPoisonMessagePolicy(t *testing.T) {
+	config := ReceiverConfig{QueueUrl: "myqueue"}
+	config = config.WithDefaults()
+	if *config.MaxParseFailures != 0 || config.PoisonMessagePolicy != PoisonMessagePolicySkip {
+		t.Fatalf("unexpected defaults %+v", config)
+	}
+	config.PoisonMessagePolicy = PoisonMessagePolicyDLQ
+	if config.Validate() == nil {
+		t.Fatalf("expected error for dlq policy without dead letter queue")
+	}
+	config.DeadLetterQueueUrl = "mydlq"
+	err := config.Validate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/xmidt-org/ears/pkg/errs"
 	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/route"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"github.com/xorcare/pointer"
@@ -52,7 +53,9 @@ func NewPluginVersion(name string, version string, commitID string) (*pkgplugin.
 		pkgplugin.WithVersion(version),
 		pkgplugin.WithCommitID(commitID),
 		pkgplugin.WithNewReceiver(NewReceiver),
+		pkgplugin.WithReceiverValidator(ValidateReceiverConfig),
 		pkgplugin.WithNewSender(NewSender),
+		pkgplugin.WithSenderValidator(ValidateSenderConfig),
 	)
 }
 
@@ -71,8 +74,18 @@ var DefaultReceiverConfig = ReceiverConfig{
 	ReceiverPoolSize:    pointer.Int(1),
 	NeverDelete:         pointer.Bool(false),
 	TracePayloadOnNack:  pointer.Bool(false),
+	MaxParseFailures:    pointer.Int(0),
+	PoisonMessagePolicy: PoisonMessagePolicySkip,
+	DeadLetterQueueUrl:  "",
 }
 
+const (
+	// PoisonMessagePolicySkip deletes unparsable messages from the queue
+	PoisonMessagePolicySkip = "skip"
+	// PoisonMessagePolicyDLQ forwards unparsable messages to the dead letter queue before deleting them
+	PoisonMessagePolicyDLQ = "dlq"
+)
+
 type ReceiverConfig struct {
 	QueueUrl            string `json:"queueUrl,omitempty"`
 	AWSRoleARN          string `json:"awsRoleARN,omitempty"`
@@ -88,6 +101,9 @@ type ReceiverConfig struct {
 	ReceiverPoolSize    *int   `json:"receiverPoolSize,omitempty"`
 	NeverDelete         *bool  `json:"neverDelete,omitempty"`
 	TracePayloadOnNack  *bool  `json:"tracePayloadOnNack,omitempty"`
+	MaxParseFailures    *int   `json:"maxParseFailures,omitempty"`
+	PoisonMessagePolicy string `json:"poisonMessagePolicy,omitempty"`
+	DeadLetterQueueUrl  string `json:"deadLetterQueueUrl,omitempty"`
 }
 
 type Receiver struct {
@@ -108,6 +124,8 @@ type Receiver struct {
 	eventFailureCounter metric.BoundInt64Counter
 	eventBytesCounter   metric.BoundInt64Counter
 	eventQueueDepth     metric.BoundInt64Histogram
+	queueDepth          *int64 // last approximate number of messages in the queue, nil until known
+	parseErrors         receiver.ParseErrors
 }
 
 var DefaultSenderConfig = SenderConfig{
@@ -146,11 +164,11 @@ type Sender struct {
 	eventBatch          []event.Event
 	done                chan struct{}
 	secrets             secret.Vault
-	eventSuccessCounter metric.BoundInt64Counter
-	eventFailureCounter metric.BoundInt64Counter
-	eventBytesCounter   metric.BoundInt64Counter
-	eventProcessingTime metric.BoundInt64Histogram
-	eventSendOutTime    metric.BoundInt64Histogram
+	eventSuccessCounter *route.EventCounter
+	eventFailureCounter *route.EventCounter
+	eventBytesCounter   *route.EventCounter
+	eventProcessingTime *route.EventHistogram
+	eventSendOutTime    *route.EventHistogram
 }
 
 type SQSError struct {
@@ -28,6 +28,7 @@ type AdaptiveRateLimiter struct {
 	backend    RateLimiter
 	initialRqs int
 	totalRqs   int
+	totalBurst int
 	currentRqs int
 	limiter    *rate.Limiter
 	lock       *sync.Mutex
@@ -60,9 +61,47 @@ func (r *AdaptiveRateLimiter) SetLimit(newLimit int) error {
 
 	r.totalRqs = newLimit
 	r.backend.SetLimit(r.totalRqs)
+	if r.limiter != nil {
+		r.limiter.SetBurst(r.burstFor(r.currentRqs))
+	}
+	return nil
+}
+
+//SetBurst sets the burst capacity shared by all instances, each instance gets a share of the burst
+//in proportion to its share of the limit
+func (r *AdaptiveRateLimiter) SetBurst(newBurst int) error {
+	if newBurst < 0 {
+		return &InvalidUnitError{newBurst}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.totalBurst = newBurst
+	if backend, ok := r.backend.(BurstRateLimiter); ok {
+		backend.SetBurst(newBurst)
+	}
+	if r.limiter != nil {
+		r.limiter.SetBurst(r.burstFor(r.currentRqs))
+	}
 	return nil
 }
 
+func (r *AdaptiveRateLimiter) Burst() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.totalBurst
+}
+
+//burstFor returns the local burst for the given local rqs
+func (r *AdaptiveRateLimiter) burstFor(rqs int) int {
+	if r.totalRqs == 0 || r.totalBurst <= r.totalRqs {
+		return rqs
+	}
+	return rqs * r.totalBurst / r.totalRqs
+}
+
 func (r *AdaptiveRateLimiter) Limit() int {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -118,12 +157,23 @@ func (r *AdaptiveRateLimiter) initLimiter(ctx context.Context) error {
 		}
 		return &BackendError{err}
 	}
-	r.limiter = rate.NewLimiter(rate.Limit(r.initialRqs), r.initialRqs)
+	r.limiter = rate.NewLimiter(rate.Limit(r.initialRqs), r.burstFor(r.initialRqs))
 	r.currentRqs = r.initialRqs
 	r.lastTune = time.Now()
 	return nil
 }
 
+//pause stops taking locally until the next tune
+func (r *AdaptiveRateLimiter) pause() {
+	r.limiter.SetLimit(0)
+	r.limiter.SetBurst(0)
+	r.currentRqs = 0
+	r.lastTune = time.Now()
+	r.takeCount = 0
+	//count the take that caused the pause so that the next tune asks for more quota
+	r.limitCount = 1
+}
+
 //Tune RQS check the rate limit history and ask backend ratelimiter for a new quota if necessary
 func (r *AdaptiveRateLimiter) tuneRqs(ctx context.Context) error {
 	if r.lastTune.Add(time.Second).After(time.Now()) {
@@ -150,6 +200,10 @@ func (r *AdaptiveRateLimiter) tuneRqs(ctx context.Context) error {
 			newRqs = r.currentRqs
 		}
 	}
+	if newRqs < 1 {
+		//rqs should never be below 1 rqs (for now), this also lets a paused limiter ask for quota again
+		newRqs = 1
+	}
 
 	//try to see if we can actually take newRqs from backend
 	target := newRqs
@@ -183,6 +237,9 @@ func (r *AdaptiveRateLimiter) tuneRqs(ctx context.Context) error {
 		}
 		target = (target + floor) / 2
 		if target == 0 {
+			//other instances hold all of the quota. Pause until the next tune rather than asking the
+			//backend again on every take
+			r.pause()
 			return &BackendError{err}
 		}
 	}
@@ -199,7 +256,7 @@ func (r *AdaptiveRateLimiter) tuneRqs(ctx context.Context) error {
 		Msg("Updating new ratelimit")
 
 	r.limiter.SetLimit(rate.Limit(newRqs))
-	r.limiter.SetBurst(newRqs)
+	r.limiter.SetBurst(r.burstFor(newRqs))
 	r.currentRqs = newRqs
 	return nil
 }
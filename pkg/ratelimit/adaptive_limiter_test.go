@@ -20,6 +20,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"sync"
 	"testing"
 	"time"
 )
@@ -92,6 +93,103 @@ func TestAdaptiveRateLimiter(t *testing.T) {
 	cancel()
 }
 
+func TestAdaptiveRateLimiterSharedBackend(t *testing.T) {
+	//two instances sharing a backend must not take more than the quota between them
+	quota := 10
+	backend := ratelimit.NewInMemoryBackendLimiter(tenant.Id{OrgId: "myOrg", AppId: "mySharedApp"}, quota)
+	limiters := []*ratelimit.AdaptiveRateLimiter{
+		ratelimit.NewAdaptiveRateLimiter(backend, quota/2, quota),
+		ratelimit.NewAdaptiveRateLimiter(backend, quota/2, quota),
+	}
+	ctx := context.Background()
+	duration := 4 * time.Second
+	taken := make([]int, len(limiters))
+	var wg sync.WaitGroup
+	for i := range limiters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			end := time.Now().Add(duration)
+			for time.Now().Before(end) {
+				if limiters[i].Take(ctx, 1) == nil {
+					taken[i]++
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+	total := taken[0] + taken[1]
+	//allow for the initial bursts of the local limiters
+	maxTotal := quota*int(duration/time.Second) + 2*quota
+	if total > maxTotal {
+		t.Fatalf("expected at most %d takes but got %d (%d+%d)", maxTotal, total, taken[0], taken[1])
+	}
+	if total < quota*int(duration/time.Second)/2 {
+		t.Fatalf("expected at least %d takes but got %d (%d+%d)", quota*int(duration/time.Second)/2, total, taken[0], taken[1])
+	}
+}
+
+func TestAdaptiveRateLimiterBurst(t *testing.T) {
+	backend := ratelimit.NewInMemoryBackendLimiter(tenant.Id{OrgId: "myOrg", AppId: "myAdaptiveBurstApp"}, 10)
+	limiter := ratelimit.NewAdaptiveRateLimiter(backend, 5, 10)
+	err := limiter.SetBurst(40)
+	if err != nil {
+		t.Fatalf("Fail to set burst %s\n", err.Error())
+	}
+	if backend.Burst() != 40 {
+		t.Fatalf("Expect backend burst 40, got %d\n", backend.Burst())
+	}
+	ctx := context.Background()
+
+	//the instance holds half of the limit, so it may burst up to half of the burst
+	taken := 0
+	for limiter.Take(ctx, 1) == nil {
+		taken++
+	}
+	if taken != 20 {
+		t.Fatalf("Expect a local burst of 20 events, got %d\n", taken)
+	}
+}
+
+// exhaustedBackend grants the initial take and no quota after that, as if other instances held all of it
+type exhaustedBackend struct {
+	ratelimit.RateLimiter
+	sync.Mutex
+	takes int
+}
+
+func (b *exhaustedBackend) Take(ctx context.Context, unit int) error {
+	b.Lock()
+	defer b.Unlock()
+	b.takes++
+	if b.takes == 1 {
+		return nil
+	}
+	return &ratelimit.LimitReached{}
+}
+
+func TestAdaptiveRateLimiterExhaustedBackend(t *testing.T) {
+	backend := &exhaustedBackend{}
+	limiter := ratelimit.NewAdaptiveRateLimiter(backend, 5, 10)
+	ctx := context.Background()
+	end := time.Now().Add(3 * time.Second)
+	for time.Now().Before(end) {
+		limiter.Take(ctx, 1)
+		time.Sleep(time.Millisecond)
+	}
+	//an instance without quota left waits for the next tune instead of asking the backend on every take
+	backend.Lock()
+	takes := backend.takes
+	backend.Unlock()
+	if takes > 50 {
+		t.Fatalf("expected the backend to be asked at most 50 times but got %d", takes)
+	}
+	if limiter.AdaptiveLimit() != 0 {
+		t.Fatalf("expected adaptive limit 0 but got %d", limiter.AdaptiveLimit())
+	}
+}
+
 func validateRps(limiter *ratelimit.AdaptiveRateLimiter, rps int, ctx context.Context) error {
 	sleepTime := time.Duration(1000000/rps) * time.Microsecond
 	for i := 0; i < rps; i++ {
@@ -14,7 +14,11 @@
 
 package ratelimit
 
-import "github.com/xmidt-org/ears/pkg/errs"
+import (
+	"github.com/xmidt-org/ears/pkg/errs"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"time"
+)
 
 type InvalidUnitError struct {
 	BadUnit int
@@ -49,3 +53,29 @@ type ContextCancelled struct {
 func (e *ContextCancelled) Error() string {
 	return errs.String("ContextCancelled", nil, nil)
 }
+
+// QuotaExceeded is returned when events of a tenant are rejected because the tenant is out of quota
+type QuotaExceeded struct {
+	Tenant       tenant.Id
+	EventsPerSec int
+	Burst        int
+	RetryAfter   time.Duration
+}
+
+func (e *QuotaExceeded) Error() string {
+	return errs.String("QuotaExceeded", map[string]interface{}{
+		"tenant":       e.Tenant.ToString(),
+		"eventsPerSec": e.EventsPerSec,
+		"burst":        e.Burst,
+		"retryAfter":   e.RetryAfter.String(),
+	}, nil)
+}
+
+// RetryAfterSeconds returns the time to wait before retrying in whole seconds as used by the Retry-After header
+func (e *QuotaExceeded) RetryAfterSeconds() int {
+	secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		return 1
+	}
+	return secs
+}
@@ -28,8 +28,9 @@ var lock = &sync.Mutex{}
 type InMemoryBackendLimiter struct {
 	sync.Mutex
 
-	rqs  int       // request per second
-	last time.Time // last time we were polled/asked
+	rqs   int       // request per second
+	burst int       // bucket capacity, rqs if 0
+	last  time.Time // last time we were polled/asked
 
 	allowance float64
 }
@@ -59,13 +60,17 @@ func (r *InMemoryBackendLimiter) Take(ctx context.Context, unit int) error {
 	defer r.Unlock()
 
 	rate := float64(r.rqs)
+	capacity := rate
+	if r.burst > r.rqs {
+		capacity = float64(r.burst)
+	}
 	now := time.Now()
 	elapsed := now.Sub(r.last)
 	r.last = now
 	r.allowance += elapsed.Seconds() * rate
 
-	if r.allowance > rate {
-		r.allowance = rate
+	if r.allowance > capacity {
+		r.allowance = capacity
 	}
 
 	if r.allowance < float64(unit) {
@@ -86,3 +91,17 @@ func (r *InMemoryBackendLimiter) SetLimit(newLimit int) error {
 	r.rqs = newLimit
 	return nil
 }
+
+func (r *InMemoryBackendLimiter) Burst() int {
+	return r.burst
+}
+
+func (r *InMemoryBackendLimiter) SetBurst(newBurst int) error {
+	if newBurst < 0 {
+		return &InvalidUnitError{newBurst}
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.burst = newBurst
+	return nil
+}
@@ -15,9 +15,11 @@
 package ratelimit_test
 
 import (
+	"context"
 	"github.com/xmidt-org/ears/pkg/ratelimit"
 	"github.com/xmidt-org/ears/pkg/tenant"
 	"testing"
+	"time"
 )
 
 func TestInMemoryBackendLimiter(t *testing.T) {
@@ -25,3 +27,27 @@ func TestInMemoryBackendLimiter(t *testing.T) {
 
 	testBackendLimiter(limiter, t)
 }
+
+func TestInMemoryBackendLimiterBurst(t *testing.T) {
+	limiter := ratelimit.NewInMemoryBackendLimiter(tenant.Id{OrgId: "myOrg", AppId: "myBurstApp"}, 10)
+	err := limiter.SetBurst(30)
+	if err != nil {
+		t.Fatalf("Fail to set burst %s\n", err.Error())
+	}
+	ctx := context.Background()
+
+	//the bucket starts with one second worth of events and fills up to the burst when idle
+	time.Sleep(2 * time.Second)
+	taken := 0
+	for limiter.Take(ctx, 1) == nil {
+		taken++
+	}
+	if taken < 29 || taken > 31 {
+		t.Fatalf("Expect a burst of 30 events, got %d\n", taken)
+	}
+
+	err = limiter.SetBurst(-1)
+	if err == nil {
+		t.Fatalf("Expect an error for a negative burst\n")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/xmidt-org/ears/pkg/ratelimit"
+)
+
+// RedisKeyRateLimiter maintains a separate token bucket for each key, for example
+// per device id. Buckets of idle keys expire automatically.
+type RedisKeyRateLimiter struct {
+	client *redis.Client
+	prefix string
+	limit  int
+	period time.Duration
+}
+
+func NewRedisKeyRateLimiter(client *redis.Client, prefix string, limit int, period time.Duration) *RedisKeyRateLimiter {
+	return &RedisKeyRateLimiter{
+		client: client,
+		prefix: prefix,
+		limit:  limit,
+		period: period,
+	}
+}
+
+func (r *RedisKeyRateLimiter) Limit() int {
+	return r.limit
+}
+
+// Take takes units from the bucket of the given key
+// Returns LimitReached if limit reached
+// Returns InvalidUnitError if unit <= 0 or > the max limit
+func (r *RedisKeyRateLimiter) Take(ctx context.Context, key string, unit int) error {
+	if r.limit == 0 {
+		//Unlimited
+		return nil
+	}
+	if unit <= 0 || unit > r.limit {
+		return &ratelimit.InvalidUnitError{BadUnit: unit}
+	}
+	// a bucket left alone for a full period is full again and does not need to be kept around
+	return takeFromBucket(ctx, r.client, r.prefix+key, r.limit, r.limit, r.period, unit, 2*r.period)
+}
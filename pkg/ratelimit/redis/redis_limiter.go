@@ -29,20 +29,27 @@ import (
 type RedisRateLimiter struct {
 	client *redis.Client
 	rqs    int
+	burst  int
 	tid    tenant.Id
 }
 
 const NUM_REDIS_RETRY = 3
 
 func NewRedisRateLimiter(tid tenant.Id, addr string, rqs int) *RedisRateLimiter {
+	return NewSharedRedisRateLimiter(redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: "", // no password set
+		DB:       0,  // use default DB
+	}), tid, rqs)
+}
+
+// NewSharedRedisRateLimiter creates a rate limiter using an existing client so that the limiters of
+// all tenants can share one connection pool
+func NewSharedRedisRateLimiter(client *redis.Client, tid tenant.Id, rqs int) *RedisRateLimiter {
 	return &RedisRateLimiter{
-		client: redis.NewClient(&redis.Options{
-			Addr:     addr,
-			Password: "", // no password set
-			DB:       0,  // use default DB
-		}),
-		rqs: rqs,
-		tid: tid,
+		client: client,
+		rqs:    rqs,
+		tid:    tid,
 	}
 }
 
@@ -58,6 +65,18 @@ func (r *RedisRateLimiter) SetLimit(newLimit int) error {
 	return nil
 }
 
+func (r *RedisRateLimiter) Burst() int {
+	return r.burst
+}
+
+func (r *RedisRateLimiter) SetBurst(newBurst int) error {
+	if newBurst < 0 {
+		return &ratelimit.InvalidUnitError{BadUnit: newBurst}
+	}
+	r.burst = newBurst
+	return nil
+}
+
 func (r *RedisRateLimiter) Take(ctx context.Context, unit int) error {
 	if r.rqs == 0 {
 		//Unlimited
@@ -93,22 +112,32 @@ func (r *RedisRateLimiter) Take(ctx context.Context, unit int) error {
 }
 
 func (r *RedisRateLimiter) take(ctx context.Context, unit int) error {
-	if unit <= 0 || unit > r.rqs {
+	capacity := r.rqs
+	if r.burst > r.rqs {
+		capacity = r.burst
+	}
+	if unit <= 0 || unit > capacity {
 		return &ratelimit.InvalidUnitError{BadUnit: unit}
 	}
+	return takeFromBucket(ctx, r.client, r.tid.Key(), r.rqs, capacity, time.Second, unit, 0)
+}
 
-	bucketKey := r.tid.Key() + "_bucket"
-	tsKey := r.tid.Key() + "_refillTs"
+// takeFromBucket takes units from the token bucket stored under the given key prefix. The bucket
+// holds up to capacity tokens and is refilled at a rate of limit tokens per period. Bucket keys
+// expire after the given expiration unless it is 0.
+func takeFromBucket(ctx context.Context, client *redis.Client, keyPrefix string, limit int, capacity int, period time.Duration, unit int, expiration time.Duration) error {
+	bucketKey := keyPrefix + "_bucket"
+	tsKey := keyPrefix + "_refillTs"
 
 	allowed := false
 
-	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+	err := client.Watch(ctx, func(tx *redis.Tx) error {
 		allowance, err := tx.Get(ctx, bucketKey).Float64()
 		if err != nil {
 			if !errors.Is(err, redis.Nil) {
 				return err
 			}
-			allowance = float64(r.rqs)
+			allowance = float64(capacity)
 		}
 		refillTs, err := tx.Get(ctx, tsKey).Int64()
 		if err != nil {
@@ -122,13 +151,11 @@ func (r *RedisRateLimiter) take(ctx context.Context, unit int) error {
 		currTs := time.Now()
 		elapsed := currTs.UnixNano() - refillTs
 
-		//log.Ctx(ctx).Info().Float64("allowance", allowance).Int("elapsed", int(elapsed/1000000)).Int("rqs", r.rqs).Int("unit", unit).Msg("=========debug==========")
-
-		allowance += float64(elapsed) * float64(r.rqs) / float64(time.Second)
+		allowance += float64(elapsed) * float64(limit) / float64(period)
 
-		//allowance cannot be bigger than quota
-		if allowance > float64(r.rqs) {
-			allowance = float64(r.rqs)
+		//allowance cannot be bigger than the bucket capacity
+		if allowance > float64(capacity) {
+			allowance = float64(capacity)
 		}
 
 		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
@@ -138,8 +165,8 @@ func (r *RedisRateLimiter) take(ctx context.Context, unit int) error {
 				allowance -= float64(unit)
 				allowed = true
 
-				pipe.Set(ctx, bucketKey, strconv.FormatFloat(allowance, 'f', -1, 64), 0)
-				pipe.Set(ctx, tsKey, strconv.FormatInt(currTs.UnixNano(), 10), 0)
+				pipe.Set(ctx, bucketKey, strconv.FormatFloat(allowance, 'f', -1, 64), expiration)
+				pipe.Set(ctx, tsKey, strconv.FormatInt(currTs.UnixNano(), 10), expiration)
 			}
 			return nil
 		})
@@ -32,3 +32,16 @@ type RateLimiter interface {
 	//Returns InvalidUnitError if newLimit < 0
 	SetLimit(newLimit int) error
 }
+
+// BurstRateLimiter is a RateLimiter whose token bucket can hold more units than the limit,
+// so that bursts above the sustained rate are allowed after idle periods
+type BurstRateLimiter interface {
+	RateLimiter
+
+	//Burst gets the bucket capacity. 0 means the capacity equals the limit
+	Burst() int
+
+	//SetBurst sets the bucket capacity. 0 means the capacity equals the limit
+	//Returns InvalidUnitError if newBurst < 0
+	SetBurst(newBurst int) error
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package receiver
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	MaxParseErrors          = 20  // number of parse errors kept by ParseErrors
+	MaxParseErrorPayloadLen = 256 // number of leading bytes of a message kept with its parse error
+)
+
+// ParseErrors keeps the most recent parse errors of a receiver, receivers add their parse errors and implement
+// ParseErrorReporter by returning List
+type ParseErrors struct {
+	lock   sync.Mutex
+	errors []ParseError
+	next   int
+}
+
+// Add keeps a parse error with the leading bytes of the message, replacing the oldest one once MaxParseErrors are kept
+func (p *ParseErrors) Add(err error, payload []byte) {
+	if len(payload) > MaxParseErrorPayloadLen {
+		payload = payload[:MaxParseErrorPayloadLen]
+	}
+	parseErr := ParseError{Time: time.Now(), Err: err, Payload: append([]byte(nil), payload...)}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.errors) < MaxParseErrors {
+		p.errors = append(p.errors, parseErr)
+	} else {
+		p.errors[p.next] = parseErr
+		p.next = (p.next + 1) % MaxParseErrors
+	}
+}
+
+// List returns the kept parse errors, oldest first
+func (p *ParseErrors) List() []ParseError {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	errors := make([]ParseError, 0, len(p.errors))
+	errors = append(errors, p.errors[p.next:]...)
+	return append(errors, p.errors[:p.next]...)
+}
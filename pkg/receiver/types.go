@@ -18,6 +18,7 @@ import (
 	"context"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"time"
 
 	"github.com/xmidt-org/ears/pkg/event"
 )
@@ -39,6 +40,12 @@ type Hasher interface {
 	ReceiverHash(config interface{}) (string, error)
 }
 
+// ConfigValidator is implemented by receiverers that can check a receiver config
+// without creating the receiver
+type ConfigValidator interface {
+	ValidateReceiverConfig(config interface{}) error
+}
+
 type NewReceiverer interface {
 	Hasher
 	// NewReceiver returns an object that implements the
@@ -65,3 +72,41 @@ type Receiver interface {
 	Plugin() string
 	Tenant() tenant.Id
 }
+
+const (
+	LagQueueDepth        = "queueDepth"        // approximate number of messages waiting in a queue, e.g. sqs
+	LagConsumerOffsets   = "consumerLag"       // number of messages between the consumer offset and the head of a partition, e.g. kafka
+	LagIteratorAgeMillis = "iteratorAgeMillis" // age of the last record read from a shard in milliseconds, e.g. kinesis
+)
+
+// Lag is how far a receiver is behind its source, either as a whole or at a single partition or shard
+type Lag struct {
+	Kind      string // one of LagQueueDepth, LagConsumerOffsets or LagIteratorAgeMillis
+	Partition string // partition or shard, empty if the lag applies to the source as a whole
+	Value     int64
+}
+
+// LagReporter is implemented by receivers that know how far they are behind their source,
+// routes expose the lag of their receiver as gauges
+type LagReporter interface {
+	Lag() []Lag
+}
+
+// ParseError is a message a receiver could not turn into an event
+type ParseError struct {
+	Time    time.Time
+	Err     error
+	Payload []byte // leading bytes of the message, see ParseErrors.Add
+}
+
+// ParseErrorReporter is implemented by receivers that keep the messages they recently failed to parse,
+// routes include them in their error log
+type ParseErrorReporter interface {
+	ParseErrors() []ParseError
+}
+
+// HealthChecker is implemented by receivers that can tell whether their connection to the source is alive,
+// the route supervisor restarts routes whose receiver reports an error
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
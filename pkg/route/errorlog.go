@@ -0,0 +1,184 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boriwo/deepcopy"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+)
+
+const (
+	DefaultErrorLogSize = 100
+	MaxErrorPayloadLen  = 256 // number of leading bytes of the payload kept with an error
+	RedactedValue       = "***"
+
+	ErrorSourceReceiver = "receiver" // the receiver could not parse a message
+	ErrorSourceFilter   = "filter"   // a filter nacked an event
+	ErrorSourceSender   = "sender"   // the sender nacked an event
+)
+
+// RouteError is an error that occurred while an event was flowing through a route
+type RouteError struct {
+	Time    int64  `json:"time"`              // time of the error in unix milliseconds
+	Source  string `json:"source"`            // one of ErrorSourceReceiver, ErrorSourceFilter or ErrorSourceSender
+	Error   string `json:"error"`             // error message
+	EventId string `json:"eventId,omitempty"` // id of the event, empty for parse errors
+	Payload string `json:"payload,omitempty"` // leading bytes of the payload with the values at the redacted paths replaced
+}
+
+// ErrorLog keeps the most recent errors of a route in a ring buffer
+type ErrorLog struct {
+	sync.Mutex
+	size   int
+	redact []string // paths of values replaced by RedactedValue before payloads are kept
+	errors []RouteError
+	next   int
+}
+
+func NewErrorLog(size int, redact []string) *ErrorLog {
+	if size <= 0 {
+		size = DefaultErrorLogSize
+	}
+	return &ErrorLog{
+		size:   size,
+		redact: redact,
+		errors: make([]RouteError, 0, size),
+	}
+}
+
+// record keeps the error of an event nacked by a filter or the sender
+func (l *ErrorLog) record(source string, e event.Event, err error) {
+	routeErr := RouteError{
+		Time:    time.Now().UnixNano() / int64(time.Millisecond),
+		Source:  source,
+		EventId: e.Id(),
+		Payload: l.snippet(e),
+	}
+	if err != nil {
+		routeErr.Error = err.Error()
+	}
+	l.add(routeErr)
+}
+
+func (l *ErrorLog) add(routeErr RouteError) {
+	l.Lock()
+	defer l.Unlock()
+	if len(l.errors) < l.size {
+		l.errors = append(l.errors, routeErr)
+	} else {
+		l.errors[l.next] = routeErr
+		l.next = (l.next + 1) % l.size
+	}
+}
+
+// snippet returns the leading bytes of the json payload of an event with the values at the redacted paths replaced,
+// binary payloads are not kept
+func (l *ErrorLog) snippet(e event.Event) string {
+	if e.ContentType() != event.ContentTypeJSON {
+		return ""
+	}
+	payload := e.Payload()
+	if len(l.redact) > 0 {
+		cpy, err := event.New(context.Background(), deepcopy.DeepCopy(payload))
+		if err != nil {
+			return ""
+		}
+		for _, path := range l.redact {
+			if v, _, _ := cpy.GetPathValue(path); v != nil {
+				cpy.SetPathValue(path, RedactedValue, false)
+			}
+		}
+		payload = cpy.Payload()
+	}
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	if len(buf) > MaxErrorPayloadLen {
+		buf = buf[:MaxErrorPayloadLen]
+	}
+	return string(buf)
+}
+
+// Errors returns the kept errors, most recent first
+func (l *ErrorLog) Errors() []RouteError {
+	l.Lock()
+	defer l.Unlock()
+	errors := make([]RouteError, 0, len(l.errors))
+	for i := len(l.errors) - 1; i >= 0; i-- {
+		errors = append(errors, l.errors[(l.next+i)%len(l.errors)])
+	}
+	return errors
+}
+
+// Errors returns the most recent errors of the route and the recent parse errors of its receiver, most recent first
+func (rte *Route) Errors() []RouteError {
+	rte.Lock()
+	if rte.errors == nil {
+		rte.errors = NewErrorLog(DefaultErrorLogSize, rte.ErrorRedact)
+	}
+	errorLog := rte.errors
+	r := rte.r
+	rte.Unlock()
+	errors := errorLog.Errors()
+	reporter, ok := r.(receiver.ParseErrorReporter)
+	if !ok {
+		return errors
+	}
+	for _, parseErr := range reporter.ParseErrors() {
+		routeErr := RouteError{
+			Time:    parseErr.Time.UnixNano() / int64(time.Millisecond),
+			Source:  ErrorSourceReceiver,
+			Payload: string(parseErr.Payload),
+		}
+		if parseErr.Err != nil {
+			routeErr.Error = parseErr.Err.Error()
+		}
+		errors = append(errors, routeErr)
+	}
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Time > errors[j].Time
+	})
+	if len(errors) > errorLog.size {
+		errors = errors[:errorLog.size]
+	}
+	return errors
+}
+
+// errorEvent records nacks of the filter chain in the error log of the route, clones record their nacks as well
+type errorEvent struct {
+	event.Event
+	errors *ErrorLog
+}
+
+func (e *errorEvent) Nack(err error) {
+	e.errors.record(ErrorSourceFilter, e.Event, err)
+	e.Event.Nack(err)
+}
+
+func (e *errorEvent) Clone(ctx context.Context) (event.Event, error) {
+	child, err := e.Event.Clone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &errorEvent{Event: child, errors: e.errors}, nil
+}
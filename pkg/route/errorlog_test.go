@@ -0,0 +1,144 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+type parseErrorReceiver struct {
+	*receiver.ReceiverMock
+	parseErrors receiver.ParseErrors
+}
+
+func (r *parseErrorReceiver) ParseErrors() []receiver.ParseError {
+	return r.parseErrors.List()
+}
+
+func TestRouteErrors(t *testing.T) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	payloads := []map[string]interface{}{
+		{"kind": "ok"},
+		{"kind": "bad", "secret": "hunter2"},
+		{"kind": "fail"},
+	}
+	r := &parseErrorReceiver{}
+	r.ReceiverMock = &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			r.parseErrors.Add(errors.New("bad json"), []byte(`{"kind":`))
+			time.Sleep(time.Millisecond)
+			for _, p := range payloads {
+				e, err := event.New(ctx, p, event.WithId(p["kind"].(string)), event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			if e.Id() == "bad" {
+				e.Nack(errors.New("filter failed"))
+				return nil
+			}
+			child, err := e.Clone(e.Context())
+			if err != nil {
+				t.Errorf("cannot clone event: %s", err.Error())
+				return nil
+			}
+			e.Ack()
+			wg.Add(1)
+			return []event.Event{child}
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			defer wg.Done()
+			if e.Id() == "fail" {
+				e.Nack(errors.New("send failed"))
+				return
+			}
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1", ErrorRedact: []string{"payload.secret"}}
+	err := rte.Run(r, f, s)
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	wg.Wait()
+	routeErrors := rte.Errors()
+	if len(routeErrors) != 3 {
+		t.Fatalf("expected 3 errors, got %+v", routeErrors)
+	}
+	sent, filtered, parsed := routeErrors[0], routeErrors[1], routeErrors[2]
+	if sent.Source != route.ErrorSourceSender || sent.EventId != "fail" || sent.Error != "send failed" {
+		t.Errorf("unexpected sender error %+v", sent)
+	}
+	if filtered.Source != route.ErrorSourceFilter || filtered.EventId != "bad" || filtered.Error != "filter failed" {
+		t.Errorf("unexpected filter error %+v", filtered)
+	}
+	if strings.Contains(filtered.Payload, "hunter2") || !strings.Contains(filtered.Payload, route.RedactedValue) {
+		t.Errorf("payload not redacted: %s", filtered.Payload)
+	}
+	if parsed.Source != route.ErrorSourceReceiver || parsed.Error != "bad json" || parsed.Payload != `{"kind":` {
+		t.Errorf("unexpected parse error %+v", parsed)
+	}
+	if filtered.Time < parsed.Time || sent.Time < parsed.Time {
+		t.Errorf("errors not ordered by time: %+v", routeErrors)
+	}
+}
+
+func TestParseErrorsRing(t *testing.T) {
+	var parseErrors receiver.ParseErrors
+	for i := 0; i < receiver.MaxParseErrors+5; i++ {
+		parseErrors.Add(errors.New("bad json"), []byte(strings.Repeat("x", receiver.MaxParseErrorPayloadLen+i)))
+	}
+	list := parseErrors.List()
+	if len(list) != receiver.MaxParseErrors {
+		t.Fatalf("expected %d parse errors, got %d", receiver.MaxParseErrors, len(list))
+	}
+	for _, parseErr := range list {
+		if len(parseErr.Payload) != receiver.MaxParseErrorPayloadLen {
+			t.Fatalf("payload not truncated: %d bytes", len(parseErr.Payload))
+		}
+	}
+	if list[0].Time.After(list[len(list)-1].Time) {
+		t.Errorf("parse errors not ordered oldest first")
+	}
+}
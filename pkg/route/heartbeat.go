@@ -0,0 +1,80 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+)
+
+var hostname, _ = os.Hostname()
+
+// IsHeartbeat returns true if the event is a synthetic heartbeat event of a route
+func IsHeartbeat(e event.Event) bool {
+	if e == nil {
+		return false
+	}
+	heartbeat, _ := e.Metadata()[event.HEARTBEAT].(bool)
+	return heartbeat
+}
+
+// runHeartbeats hands a heartbeat event to next every interval until done is closed. Heartbeats take the same path
+// through the filter chain to the sender as received events, the heartbeat flag in their metadata lets filters drop
+// them or senders deliver them to a monitoring sink.
+func (rte *Route) runHeartbeats(interval time.Duration, next receiver.NextFn, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	seq := 0
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			seq++
+			n := seq
+			payload := map[string]interface{}{
+				"routeId":  rte.Id,
+				"tenant":   map[string]interface{}{"orgId": rte.TenantId.OrgId, "appId": rte.TenantId.AppId},
+				"hostname": hostname,
+				"time":     now.UnixNano() / int64(time.Millisecond),
+				"seq":      n,
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			e, err := event.New(ctx, payload,
+				event.WithMetadataKeyValue(event.HEARTBEAT, true),
+				event.WithTenant(rte.TenantId),
+				event.WithAck(
+					func(e event.Event) {
+						cancel()
+					},
+					func(e event.Event, err error) {
+						log.Ctx(e.Context()).Warn().Str("op", "route.heartbeat").Str("routeId", rte.Id).
+							Str("tenantId", rte.TenantId.ToString()).Int("seq", n).Str("error", err.Error()).Msg("heartbeat nacked")
+						cancel()
+					}))
+			if err != nil {
+				cancel()
+				log.Error().Str("op", "route.heartbeat").Str("routeId", rte.Id).Str("error", err.Error()).Msg("cannot create heartbeat event")
+				continue
+			}
+			next(e)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+func TestRouteHeartbeat(t *testing.T) {
+	stop := make(chan struct{})
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			e, err := event.New(context.Background(), map[string]interface{}{"foo": "bar"}, event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+			if err != nil {
+				return err
+			}
+			next(e)
+			<-stop
+			return nil
+		},
+	}
+	filtered := make(chan bool, 10)
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			filtered <- route.IsHeartbeat(e)
+			return []event.Event{e}
+		},
+	}
+	sent := make(chan event.Event, 10)
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			e.Ack()
+			sent <- e
+		},
+	}
+	rte := &route.Route{Id: "r1", TenantId: tenant.Id{OrgId: "myorg", AppId: "myapp"}, HeartbeatInterval: 10 * time.Millisecond}
+	go rte.Run(r, f, s)
+	defer close(stop)
+	e := <-sent
+	if route.IsHeartbeat(e) || <-filtered {
+		t.Fatalf("received event flagged as heartbeat")
+	}
+	for seq := 1; seq <= 2; seq++ {
+		select {
+		case e = <-sent:
+		case <-time.After(time.Second):
+			t.Fatalf("no heartbeat event")
+		}
+		if !route.IsHeartbeat(e) || !<-filtered {
+			t.Fatalf("heartbeat event not flagged as heartbeat")
+		}
+		payload, _ := e.Payload().(map[string]interface{})
+		if payload["routeId"] != "r1" || payload["seq"] != seq || e.Tenant().AppId != "myapp" {
+			t.Fatalf("unexpected heartbeat payload %+v", payload)
+		}
+		if v, _, _ := e.GetPathValue(event.HEARTBEAT_METADATA); v != true {
+			t.Fatalf("heartbeat flag not found at %s", event.HEARTBEAT_METADATA)
+		}
+	}
+}
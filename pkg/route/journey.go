@@ -0,0 +1,96 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+)
+
+// journey records the time an event spends in the stages of a route while slow event logging is enabled, it is
+// shared by an event and its clones
+type journey struct {
+	sync.Mutex
+	receiver string
+	received time.Time // time the event was handed to the route
+	stages   []journeyStage
+}
+
+type journeyStage struct {
+	Name string  `json:"name"`
+	Ms   float64 `json:"ms"`
+}
+
+type journeyKey struct{}
+
+// withJourney starts recording the journey of an event through a route
+func withJourney(e event.Event, receiverName string) {
+	if e == nil {
+		return
+	}
+	e.SetContext(context.WithValue(e.Context(), journeyKey{}, &journey{receiver: receiverName, received: time.Now()}))
+}
+
+// AddJourneyStage records the time an event spent in a stage of its route, e.g. a filter, the stage is only kept if
+// slow event logging is enabled for the route
+func AddJourneyStage(ctx context.Context, stage string, d time.Duration) {
+	if ctx == nil {
+		return
+	}
+	j, ok := ctx.Value(journeyKey{}).(*journey)
+	if !ok {
+		return
+	}
+	j.Lock()
+	j.stages = append(j.stages, journeyStage{Name: stage, Ms: millis(d)})
+	j.Unlock()
+}
+
+// logSlowEvent logs the journey of an event at warn level if the time from its receipt until the sender acked or
+// nacked it exceeds the threshold
+func logSlowEvent(e event.Event, senderName string, sent time.Time, threshold time.Duration, success bool) {
+	now := time.Now()
+	total := now.Sub(e.Created())
+	if total < threshold {
+		return
+	}
+	logger := log.Ctx(e.Context()).Warn().Str("op", "route.slowEvent").Str("routeId", IdFromContext(e.Context())).
+		Str("eventId", e.Id()).Str("sender", senderName).Bool("acked", success).Float64("totalMs", millis(total)).
+		Float64("senderMs", millis(now.Sub(sent)))
+	if j, ok := e.Context().Value(journeyKey{}).(*journey); ok {
+		j.Lock()
+		stages := append([]journeyStage(nil), j.stages...)
+		j.Unlock()
+		// time spent waiting for workers or the turn of the event, or in the route outside of recorded stages
+		queued := sent.Sub(j.received)
+		for _, stage := range stages {
+			queued -= time.Duration(stage.Ms * float64(time.Millisecond))
+		}
+		if queued < 0 {
+			queued = 0
+		}
+		logger = logger.Str("receiver", j.receiver).Float64("receiverMs", millis(j.received.Sub(e.Created()))).
+			Interface("filters", stages).Float64("queuedMs", millis(queued))
+	}
+	logger.Msg("slow event")
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+type syncBuffer struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func TestRouteSlowEvents(t *testing.T) {
+	var buf syncBuffer
+	logger := zerolog.New(&buf)
+	ctx := logger.WithContext(context.Background())
+	var wg sync.WaitGroup
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "myReceiver"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			for _, id := range []string{"slow", "fast"} {
+				e, err := event.New(ctx, id, event.WithId(id), event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+				if err != nil {
+					return err
+				}
+				wg.Add(1)
+				next(e)
+			}
+			return nil
+		},
+	}
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			if e.Id() == "slow" {
+				start := time.Now()
+				time.Sleep(20 * time.Millisecond)
+				route.AddJourneyStage(e.Context(), "mock/slowFilter", time.Since(start))
+			}
+			return []event.Event{e}
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mySender"
+		},
+		SendFunc: func(e event.Event) {
+			defer wg.Done()
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1", SlowEventThreshold: 10 * time.Millisecond}
+	err := rte.Run(r, f, s)
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	wg.Wait()
+	buf.Lock()
+	defer buf.Unlock()
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("expected a single slow event log, got %s", buf.String())
+	}
+	var entry struct {
+		Level    string  `json:"level"`
+		Message  string  `json:"message"`
+		RouteId  string  `json:"routeId"`
+		EventId  string  `json:"eventId"`
+		Receiver string  `json:"receiver"`
+		Sender   string  `json:"sender"`
+		TotalMs  float64 `json:"totalMs"`
+		Filters  []struct {
+			Name string  `json:"name"`
+			Ms   float64 `json:"ms"`
+		} `json:"filters"`
+	}
+	err = json.Unmarshal(lines[0], &entry)
+	if err != nil {
+		t.Fatalf("cannot unmarshal log %s: %s", lines[0], err.Error())
+	}
+	if entry.Level != "warn" || entry.Message != "slow event" || entry.RouteId != "r1" || entry.EventId != "slow" {
+		t.Errorf("unexpected slow event log %s", lines[0])
+	}
+	if entry.Receiver != "myReceiver" || entry.Sender != "mySender" || entry.TotalMs < 20 {
+		t.Errorf("unexpected slow event log %s", lines[0])
+	}
+	if len(entry.Filters) != 1 || entry.Filters[0].Name != "mock/slowFilter" || entry.Filters[0].Ms < 20 {
+		t.Errorf("unexpected filter timings %s", lines[0])
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	LABEL_KEY_REGEX        = `^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`
+	LABEL_KEY_MAX_LEN      = 63
+	LABEL_VALUE_MAX_LEN    = 256
+	LABEL_MAX_COUNT        = 64
+	labelOpEquals          = "="
+	labelOpNotEquals       = "!="
+	labelOpExists          = ""
+	labelSelectorSeparator = ","
+)
+
+var validLabelKey = regexp.MustCompile(LABEL_KEY_REGEX)
+
+type labelRequirement struct {
+	key   string
+	op    string
+	value string
+}
+
+// LabelSelector is a list of label requirements which all have to be met by a route. Requirements have the
+// form key=value, key!=value or key to only check for the presence of a label.
+type LabelSelector []labelRequirement
+
+// ValidateLabels returns an error if any of the label keys or values is invalid
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > LABEL_MAX_COUNT {
+		return errors.New("too many labels")
+	}
+	for k, v := range labels {
+		if len(k) > LABEL_KEY_MAX_LEN || !validLabelKey.MatchString(k) {
+			return errors.New("invalid label key " + k)
+		}
+		if len(v) > LABEL_VALUE_MAX_LEN {
+			return errors.New("label value too long for key " + k)
+		}
+	}
+	return nil
+}
+
+// ParseLabelSelector parses a list of selectors, each selector may hold several comma separated requirements
+func ParseLabelSelector(selectors []string) (LabelSelector, error) {
+	ls := make(LabelSelector, 0)
+	for _, selector := range selectors {
+		for _, req := range strings.Split(selector, labelSelectorSeparator) {
+			req = strings.TrimSpace(req)
+			if req == "" {
+				continue
+			}
+			var lr labelRequirement
+			if idx := strings.Index(req, labelOpNotEquals); idx >= 0 {
+				lr = labelRequirement{key: req[:idx], op: labelOpNotEquals, value: req[idx+len(labelOpNotEquals):]}
+			} else if idx := strings.Index(req, labelOpEquals); idx >= 0 {
+				lr = labelRequirement{key: req[:idx], op: labelOpEquals, value: req[idx+len(labelOpEquals):]}
+			} else {
+				lr = labelRequirement{key: req, op: labelOpExists}
+			}
+			lr.key = strings.TrimSpace(lr.key)
+			lr.value = strings.TrimSpace(lr.value)
+			if !validLabelKey.MatchString(lr.key) {
+				return nil, errors.New("invalid label selector " + req)
+			}
+			ls = append(ls, lr)
+		}
+	}
+	return ls, nil
+}
+
+// Matches returns true if the labels meet all requirements of the selector, an empty selector matches everything
+func (ls LabelSelector) Matches(labels map[string]string) bool {
+	for _, lr := range ls {
+		v, ok := labels[lr.key]
+		switch lr.op {
+		case labelOpEquals:
+			if !ok || v != lr.value {
+				return false
+			}
+		case labelOpNotEquals:
+			if ok && v == lr.value {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FilterByLabels returns the routes matching the label selector
+func FilterByLabels(routes []Config, ls LabelSelector) []Config {
+	if len(ls) == 0 {
+		return routes
+	}
+	filtered := make([]Config, 0)
+	for _, r := range routes {
+		if ls.Matches(r.Labels) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/route"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"team": "xfi", "env": "prod"}
+	testCases := []struct {
+		name      string
+		selectors []string
+		matches   bool
+	}{
+		{name: "empty", selectors: nil, matches: true},
+		{name: "equals", selectors: []string{"team=xfi"}, matches: true},
+		{name: "equalsMismatch", selectors: []string{"team=other"}, matches: false},
+		{name: "notEquals", selectors: []string{"env!=test"}, matches: true},
+		{name: "notEqualsMismatch", selectors: []string{"env!=prod"}, matches: false},
+		{name: "exists", selectors: []string{"env"}, matches: true},
+		{name: "missing", selectors: []string{"owner"}, matches: false},
+		{name: "commaSeparated", selectors: []string{"team=xfi,env=prod"}, matches: true},
+		{name: "multiple", selectors: []string{"team=xfi", "env=test"}, matches: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ls, err := route.ParseLabelSelector(tc.selectors)
+			if err != nil {
+				t.Fatalf("cannot parse selector: %s", err.Error())
+			}
+			if ls.Matches(labels) != tc.matches {
+				t.Fatalf("expected match to be %t", tc.matches)
+			}
+		})
+	}
+	_, err := route.ParseLabelSelector([]string{"=xfi"})
+	if err == nil {
+		t.Fatalf("expected error for selector without key")
+	}
+	err = route.ValidateLabels(map[string]string{"team name": "xfi"})
+	if err == nil {
+		t.Fatalf("expected error for invalid label key")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
+)
+
+// lagGauges exposes the lag of the receivers of all running routes as gauges. Batch observers cannot be
+// unregistered, so a single observer is registered on first use and routes come and go in its route map.
+type lagGauges struct {
+	sync.Mutex
+	once   sync.Once
+	routes map[*Route]*lagSource
+}
+
+type lagSource struct {
+	reporter receiver.LagReporter
+	labels   []attribute.KeyValue
+}
+
+var receiverLag = &lagGauges{routes: make(map[*Route]*lagSource)}
+
+// add starts observing the lag of the receiver of a route if the receiver reports any
+func (g *lagGauges) add(rte *Route, r receiver.Receiver) {
+	reporter, ok := r.(receiver.LagReporter)
+	if !ok {
+		return
+	}
+	g.once.Do(g.register)
+	g.Lock()
+	defer g.Unlock()
+	g.routes[rte] = &lagSource{
+		reporter: reporter,
+		labels: []attribute.KeyValue{
+			rtsemconv.EARSRouteId.String(rte.Id),
+			attribute.String(rtsemconv.EARSAppIdLabel, rte.TenantId.AppId),
+			attribute.String(rtsemconv.EARSOrgIdLabel, rte.TenantId.OrgId),
+			attribute.String(rtsemconv.EARSReceiverName, r.Name()),
+		},
+	}
+}
+
+// remove stops observing the lag of the receiver of a route
+func (g *lagGauges) remove(rte *Route) {
+	g.Lock()
+	defer g.Unlock()
+	delete(g.routes, rte)
+}
+
+func (g *lagGauges) sources() []*lagSource {
+	g.Lock()
+	defer g.Unlock()
+	sources := make([]*lagSource, 0, len(g.routes))
+	for _, source := range g.routes {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+func (g *lagGauges) register() {
+	var queueDepthGauge, consumerLagGauge, iteratorAgeGauge metric.Int64GaugeObserver
+	batch := metric.Must(global.Meter(rtsemconv.EARSMeterName)).NewBatchObserver(
+		func(ctx context.Context, result metric.BatchObserverResult) {
+			for _, source := range g.sources() {
+				for _, lag := range source.reporter.Lag() {
+					labels := source.labels
+					if lag.Partition != "" {
+						labels = append(labels[:len(labels):len(labels)], attribute.String(rtsemconv.EARSPartitionLabel, lag.Partition))
+					}
+					switch lag.Kind {
+					case receiver.LagQueueDepth:
+						result.Observe(labels, queueDepthGauge.Observation(lag.Value))
+					case receiver.LagConsumerOffsets:
+						result.Observe(labels, consumerLagGauge.Observation(lag.Value))
+					case receiver.LagIteratorAgeMillis:
+						result.Observe(labels, iteratorAgeGauge.Observation(lag.Value))
+					}
+				}
+			}
+		})
+	queueDepthGauge = batch.NewInt64GaugeObserver(
+		rtsemconv.EARSMetricReceiverQueueDepth,
+		metric.WithDescription("measures the approximate number of messages waiting in the source queue of a route"),
+	)
+	consumerLagGauge = batch.NewInt64GaugeObserver(
+		rtsemconv.EARSMetricReceiverConsumerLag,
+		metric.WithDescription("measures the number of messages a route is behind the head of a source partition"),
+	)
+	iteratorAgeGauge = batch.NewInt64GaugeObserver(
+		rtsemconv.EARSMetricReceiverIteratorAge,
+		metric.WithDescription("measures the age of the last record a route read from a source shard"),
+		metric.WithUnit(unit.Milliseconds),
+	)
+}
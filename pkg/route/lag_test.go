@@ -0,0 +1,95 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"testing"
+
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/metrictest"
+)
+
+type lagReceiver struct {
+	*receiver.ReceiverMock
+	lag []receiver.Lag
+}
+
+func (r *lagReceiver) Lag() []receiver.Lag {
+	return r.lag
+}
+
+func TestRouteReceiverLag(t *testing.T) {
+	meter, provider := metrictest.NewMeterProvider()
+	global.SetMeterProvider(provider)
+	observed := make(chan []metrictest.Measured)
+	r := &lagReceiver{
+		ReceiverMock: &receiver.ReceiverMock{
+			NameFunc: func() string {
+				return "myKinesisReceiver"
+			},
+			PluginFunc: func() string {
+				return "kinesis"
+			},
+			ReceiveFunc: func(next receiver.NextFn) error {
+				meter.RunAsyncInstruments()
+				observed <- metrictest.AsStructs(meter.MeasurementBatches)
+				return nil
+			},
+		},
+		lag: []receiver.Lag{{Kind: receiver.LagIteratorAgeMillis, Partition: "0", Value: 1500}},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+	}
+	rte := &route.Route{Id: "lagRoute", TenantId: tenant.Id{OrgId: "myorg", AppId: "lagapp"}}
+	done := make(chan error)
+	go func() {
+		done <- rte.Run(r, nil, s)
+	}()
+	found := false
+	for _, m := range <-observed {
+		if m.Name != rtsemconv.EARSMetricReceiverIteratorAge || m.Labels[rtsemconv.EARSRouteId].AsString() != "lagRoute" {
+			continue
+		}
+		found = true
+		if m.Labels[rtsemconv.EARSPartitionLabel].AsString() != "0" || m.Labels[rtsemconv.EARSAppIdLabel].AsString() != "lagapp" {
+			t.Errorf("unexpected labels: %v", m.Labels)
+		}
+		if m.Number.AsInt64() != 1500 {
+			t.Errorf("expected iterator age 1500, got %d", m.Number.AsInt64())
+		}
+	}
+	if !found {
+		t.Fatalf("no iterator age observed for route")
+	}
+	err := <-done
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	meter.MeasurementBatches = nil
+	meter.RunAsyncInstruments()
+	for _, m := range metrictest.AsStructs(meter.MeasurementBatches) {
+		if m.Labels[rtsemconv.EARSRouteId].AsString() == "lagRoute" {
+			t.Errorf("lag still observed after the route stopped: %v", m)
+		}
+	}
+}
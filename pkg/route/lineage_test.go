@@ -0,0 +1,77 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRouteLineage(t *testing.T) {
+	ctx := context.Background()
+	// metadata shared with clones of the event handed to other routes
+	metadata := map[string]interface{}{"key": "value"}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "myReceiver"
+		},
+		PluginFunc: func() string {
+			return "debug"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.WithMetadata(metadata), event.WithAck(
+				func(event.Event) { wg.Done() },
+				func(event.Event, error) { wg.Done() }))
+			if err != nil {
+				return err
+			}
+			next(e)
+			return nil
+		},
+	}
+	var lineage map[string]interface{}
+	var routeId interface{}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			lineage = event.Lineage(e)
+			routeId, _, _ = e.GetPathValue(event.LINEAGE_METADATA + "." + event.LineageRouteId)
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1"}
+	go rte.Run(r, nil, s)
+	wg.Wait()
+	if lineage[event.LineageReceiver] != "myReceiver" || lineage[event.LineageReceiverPlugin] != "debug" ||
+		lineage[event.LineageRouteId] != "r1" || lineage[event.LineageHops] != 1 {
+		t.Fatalf("unexpected lineage: %+v", lineage)
+	}
+	if routeId != "r1" {
+		t.Fatalf("lineage not accessible by path: %+v", routeId)
+	}
+	if _, ok := metadata[event.LINEAGE]; ok || len(metadata) != 1 {
+		t.Fatalf("shared metadata modified: %+v", metadata)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// modes of ears.metrics.routeLabels, each route label multiplies the number of time series of the event metrics
+const (
+	MetricLabelsNone    = "none"    // event metrics are labeled with plugin and tenant only
+	MetricLabelsRouteId = "routeId" // event metrics of senders are also labeled with the route id
+	MetricLabelsAll     = "all"     // event metrics of senders are also labeled with the route id and the route labels
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// NewMetricLabels returns the labels a route adds to the event metrics of its sender in the given mode. Route labels
+// are prefixed with ears.label and reduced to characters allowed in prometheus label names.
+func NewMetricLabels(mode string, routeId string, labels map[string]string) []attribute.KeyValue {
+	if mode != MetricLabelsRouteId && mode != MetricLabelsAll {
+		return nil
+	}
+	metricLabels := []attribute.KeyValue{rtsemconv.EARSRouteId.String(routeId)}
+	if mode == MetricLabelsAll {
+		keys := make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			metricLabels = append(metricLabels, attribute.String(rtsemconv.EARSRouteLabelPrefix+invalidLabelChars.ReplaceAllString(key, "_"), labels[key]))
+		}
+	}
+	return metricLabels
+}
+
+type metricLabelsKey struct{}
+
+// MetricLabelsFromContext returns the labels of the route an event is flowing through for the event metrics of
+// plugins, nil if the route adds no labels
+func MetricLabelsFromContext(ctx context.Context) []attribute.KeyValue {
+	if ctx == nil {
+		return nil
+	}
+	labels, _ := ctx.Value(metricLabelsKey{}).([]attribute.KeyValue)
+	return labels
+}
+
+// EventCounter is an int64 counter bound to the labels of a plugin which adds the route labels of the event context
+// to each measurement
+type EventCounter struct {
+	counter metric.Int64Counter
+	labels  []attribute.KeyValue
+	bound   metric.BoundInt64Counter
+}
+
+func NewEventCounter(counter metric.Int64Counter, labels ...attribute.KeyValue) *EventCounter {
+	return &EventCounter{counter: counter, labels: labels, bound: counter.Bind(labels...)}
+}
+
+func (c *EventCounter) Add(ctx context.Context, value int64) {
+	routeLabels := MetricLabelsFromContext(ctx)
+	if len(routeLabels) == 0 {
+		c.bound.Add(ctx, value)
+		return
+	}
+	c.counter.Add(ctx, value, withRouteLabels(c.labels, routeLabels)...)
+}
+
+func (c *EventCounter) Unbind() {
+	c.bound.Unbind()
+}
+
+// EventHistogram is an int64 histogram bound to the labels of a plugin which adds the route labels of the event
+// context to each measurement
+type EventHistogram struct {
+	histogram metric.Int64Histogram
+	labels    []attribute.KeyValue
+	bound     metric.BoundInt64Histogram
+}
+
+func NewEventHistogram(histogram metric.Int64Histogram, labels ...attribute.KeyValue) *EventHistogram {
+	return &EventHistogram{histogram: histogram, labels: labels, bound: histogram.Bind(labels...)}
+}
+
+func (h *EventHistogram) Record(ctx context.Context, value int64) {
+	routeLabels := MetricLabelsFromContext(ctx)
+	if len(routeLabels) == 0 {
+		h.bound.Record(ctx, value)
+		return
+	}
+	h.histogram.Record(ctx, value, withRouteLabels(h.labels, routeLabels)...)
+}
+
+func (h *EventHistogram) Unbind() {
+	h.bound.Unbind()
+}
+
+func withRouteLabels(labels []attribute.KeyValue, routeLabels []attribute.KeyValue) []attribute.KeyValue {
+	all := make([]attribute.KeyValue, 0, len(labels)+len(routeLabels))
+	all = append(all, labels...)
+	return append(all, routeLabels...)
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/route"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestNewMetricLabels(t *testing.T) {
+	labels := map[string]string{"team": "xfi", "app-name": "gears"}
+	testCases := []struct {
+		name     string
+		mode     string
+		expected []attribute.KeyValue
+	}{
+		{name: "empty", mode: "", expected: nil},
+		{name: "none", mode: route.MetricLabelsNone, expected: nil},
+		{name: "unknown", mode: "everything", expected: nil},
+		{
+			name:     "routeId",
+			mode:     route.MetricLabelsRouteId,
+			expected: []attribute.KeyValue{attribute.String("ears.routeId", "r1")},
+		},
+		{
+			name: "all",
+			mode: route.MetricLabelsAll,
+			expected: []attribute.KeyValue{
+				attribute.String("ears.routeId", "r1"),
+				attribute.String("ears.label.app_name", "gears"),
+				attribute.String("ears.label.team", "xfi"),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metricLabels := route.NewMetricLabels(tc.mode, "r1", labels)
+			if !reflect.DeepEqual(metricLabels, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, metricLabels)
+			}
+		})
+	}
+}
+
+func TestMetricLabelsFromContext(t *testing.T) {
+	if labels := route.MetricLabelsFromContext(context.Background()); labels != nil {
+		t.Errorf("expected no labels, got %v", labels)
+	}
+	if labels := route.MetricLabelsFromContext(nil); labels != nil {
+		t.Errorf("expected no labels, got %v", labels)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+)
+
+// orderedQueues serializes the delivery of events sharing a key. Events are queued per key and a single worker per
+// key delivers them one at a time, each event is only handed to the sender once the previous one has been acked or
+// nacked. Events without a key are delivered unordered.
+type orderedQueues struct {
+	sync.Mutex
+	keyPath string
+	queues  map[string][]event.Event // events waiting for delivery by key, a key is present while its worker runs
+}
+
+func newOrderedQueues(keyPath string) *orderedQueues {
+	return &orderedQueues{
+		keyPath: keyPath,
+		queues:  make(map[string][]event.Event),
+	}
+}
+
+// key returns the ordering key of an event or an empty string if it has none
+func (q *orderedQueues) key(e event.Event) string {
+	val, _, _ := e.GetPathValue(q.keyPath)
+	if val == nil {
+		return ""
+	}
+	if s, ok := val.(string); ok {
+		return s
+	}
+	return fmt.Sprint(val)
+}
+
+// fanOut queues events for ordered delivery, starting a worker for every key that does not have one yet
+func (q *orderedQueues) fanOut(events []event.Event, next receiver.NextFn, senderName string, pool *workerPool, stats *Stats) error {
+	if next == nil {
+		return &InvalidRouteError{
+			Err: fmt.Errorf("next cannot be nil"),
+		}
+	}
+	waited := 0
+	for _, e := range events {
+		evt := e
+		key := q.key(evt)
+		if key == "" {
+			if pool.Go(func() { deliver(evt, next, senderName) }) {
+				waited++
+			}
+			continue
+		}
+		q.Lock()
+		pending, running := q.queues[key]
+		q.queues[key] = append(pending, evt)
+		q.Unlock()
+		if !running {
+			if pool.Go(func() { q.drain(key, next, senderName) }) {
+				waited++
+			}
+		}
+	}
+	if waited > 0 {
+		stats.waited(waited)
+	}
+	return nil
+}
+
+// drain delivers the events queued for a key one at a time until the queue is empty
+func (q *orderedQueues) drain(key string, next receiver.NextFn, senderName string) {
+	for {
+		q.Lock()
+		pending := q.queues[key]
+		if len(pending) == 0 {
+			delete(q.queues, key)
+			q.Unlock()
+			return
+		}
+		evt := pending[0]
+		pending[0] = nil
+		q.queues[key] = pending[1:]
+		q.Unlock()
+		ctx := evt.Context()
+		if ctx.Err() != nil {
+			// the event timed out while waiting for its predecessors
+			evt.Nack(ctx.Err())
+			continue
+		}
+		done := &orderedEvent{Event: evt, done: make(chan struct{})}
+		deliver(done, next, senderName)
+		select {
+		case <-done.done:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// orderedEvent signals when the sender has acked or nacked an event
+type orderedEvent struct {
+	event.Event
+	done chan struct{}
+	once sync.Once
+}
+
+func (e *orderedEvent) Ack() {
+	e.Event.Ack()
+	e.once.Do(func() {
+		close(e.done)
+	})
+}
+
+func (e *orderedEvent) Nack(err error) {
+	e.Event.Nack(err)
+	e.once.Do(func() {
+		close(e.done)
+	})
+}
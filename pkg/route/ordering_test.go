@@ -0,0 +1,101 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRouteOrdering(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"a", "b", "a", "b", "a", "b", "a", "b", ""}
+	var wg sync.WaitGroup
+	wg.Add(len(keys))
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			for i, key := range keys {
+				payload := map[string]interface{}{"seq": i}
+				if key != "" {
+					payload["key"] = key
+				}
+				e, err := event.New(ctx, payload, event.WithAck(
+					func(event.Event) { wg.Done() },
+					func(event.Event, error) { wg.Done() }))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	var lock sync.Mutex
+	inFlight := make(map[string]int)
+	sent := make(map[string][]int)
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			key, _, _ := e.GetPathValue("payload.key")
+			seq, _, _ := e.GetPathValue("payload.seq")
+			k, _ := key.(string)
+			lock.Lock()
+			inFlight[k]++
+			if k != "" && inFlight[k] > 1 {
+				t.Errorf("more than one event with key %s in flight", k)
+			}
+			sent[k] = append(sent[k], seq.(int))
+			lock.Unlock()
+			// ack asynchronously with decreasing delays so that unordered delivery would reorder events
+			go func() {
+				time.Sleep(time.Duration(10-seq.(int)) * time.Millisecond)
+				lock.Lock()
+				inFlight[k]--
+				lock.Unlock()
+				e.Ack()
+			}()
+		},
+	}
+	rte := &route.Route{Id: "r1", OrderBy: "payload.key"}
+	go rte.Run(r, nil, s)
+	wg.Wait()
+	lock.Lock()
+	defer lock.Unlock()
+	for k, expected := range map[string][]int{"a": {0, 2, 4, 6}, "b": {1, 3, 5, 7}, "": {8}} {
+		if len(sent[k]) != len(expected) {
+			t.Fatalf("unexpected events sent for key %s: %v", k, sent[k])
+		}
+		for i := range expected {
+			if sent[k][i] != expected[i] {
+				t.Fatalf("events with key %s sent out of order: %v", k, sent[k])
+			}
+		}
+	}
+}
@@ -19,12 +19,15 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/filter"
 	"github.com/xmidt-org/ears/pkg/panics"
 	"github.com/xmidt-org/ears/pkg/receiver"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
 )
 
 func (rte *Route) Run(r receiver.Receiver, f filter.Filterer, s sender.Sender) error {
@@ -42,29 +45,161 @@ func (rte *Route) Run(r receiver.Receiver, f filter.Filterer, s sender.Sender) e
 	rte.r = r
 	rte.f = f
 	rte.s = s
+	if rte.stats == nil {
+		rte.stats = NewStats(DefaultStatsWindowSecs)
+	}
+	if rte.errors == nil {
+		rte.errors = NewErrorLog(DefaultErrorLogSize, rte.ErrorRedact)
+	}
+	rte.pool = newWorkerPool(rte.Workers, rte.Id, rte.TenantId, rte.Scheduler, rte.Priority)
+	id := rte.Id
+	metricLabels := rte.MetricLabels
+	sampling := rte.TraceSampling
+	stats := rte.stats
+	errorLog := rte.errors
+	pool := rte.pool
+	defer pool.stop()
+	var ordered *orderedQueues
+	if rte.OrderBy != "" {
+		ordered = newOrderedQueues(rte.OrderBy)
+	}
+	ttl := newEventTtl(rte.Ttl, rte.Id, rte.TenantId)
+	receiverName, receiverPlugin := r.Name(), r.Plugin()
+	senderName := s.Name()
+	slowThreshold := rte.SlowEventThreshold
+	heartbeatInterval := rte.HeartbeatInterval
 	rte.Unlock()
+	send := func(e event.Event) {
+		if ee, ok := e.(*errorEvent); ok {
+			// nacks of the sender are recorded as sender errors
+			e = ee.Event
+		}
+		// a raw payload that turned out not to be decodable cannot be sent
+		if err := e.PayloadError(); err != nil {
+			errorLog.record(ErrorSourceReceiver, e, err)
+			e.Nack(err)
+			return
+		}
+		// stale events are dropped at the last moment to also catch events that waited for a worker or their turn
+		if ttl != nil && ttl.expired(e) {
+			stats.expired()
+			e.Ack()
+			return
+		}
+		rte.tapEvent(e)
+		s.Send(&statsEvent{Event: e, stats: stats, errors: errorLog, name: senderName, slow: slowThreshold, start: time.Now()})
+	}
+	// events filters emit on their own, such as batches flushed once their time window expired, are sent like
+	// the events filters return
+	flush := func(events []event.Event) {
+		stats.filtered(len(events))
+		var err error
+		if ordered != nil {
+			err = ordered.fanOut(events, send, s.Name(), pool, stats)
+		} else {
+			err = fanOut(events, send, s.Name(), pool, stats)
+		}
+		if err != nil {
+			for _, e := range events {
+				e.Nack(err)
+			}
+		}
+	}
 	var next receiver.NextFn
-	if f == nil {
+	if ordered != nil {
+		next = func(e event.Event) {
+			withRouteId(e, id, metricLabels, sampling)
+			withLineage(e, id, receiverName, receiverPlugin)
+			if slowThreshold > 0 {
+				withJourney(e, receiverName)
+			}
+			stats.received()
+			events := []event.Event{e}
+			if f != nil {
+				events = filterWithFlush(f, &errorEvent{Event: e, errors: errorLog}, flush)
+				stats.filtered(len(events))
+			}
+			err := ordered.fanOut(events, send, s.Name(), pool, stats)
+			if err != nil {
+				e.Nack(err)
+			}
+		}
+	} else if f == nil {
 		next = func(e event.Event) {
-			tracer := otel.Tracer(rtsemconv.EARSTracerName)
-			_, span := tracer.Start(e.Context(), s.Name())
-			s.Send(e)
-			span.End()
+			withRouteId(e, id, metricLabels, sampling)
+			withLineage(e, id, receiverName, receiverPlugin)
+			if slowThreshold > 0 {
+				withJourney(e, receiverName)
+			}
+			stats.received()
+			waited := pool.Go(func() { deliver(e, send, s.Name()) })
+			if waited {
+				stats.waited(1)
+			}
 		}
 	} else {
 		next = func(e event.Event) {
-			events := f.Filter(e)
-			err := fanOut(events, s.Send, s.Name())
+			withRouteId(e, id, metricLabels, sampling)
+			withLineage(e, id, receiverName, receiverPlugin)
+			if slowThreshold > 0 {
+				withJourney(e, receiverName)
+			}
+			stats.received()
+			events := filterWithFlush(f, &errorEvent{Event: e, errors: errorLog}, flush)
+			stats.filtered(len(events))
+			err := fanOut(events, send, s.Name(), pool, stats)
 			if err != nil {
 				e.Nack(err)
 			}
 		}
 	}
+	receiverLag.add(rte, r)
+	defer receiverLag.remove(rte)
+	traceSampling.add(rte, r)
+	defer traceSampling.remove(rte, r)
+	if heartbeatInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go rte.runHeartbeats(heartbeatInterval, next, done)
+	}
 	//TODO: deal with errors properly
 	return rte.r.Receive(next)
 
 }
 
+// Stats returns the runtime statistics of the route over the rolling window
+func (rte *Route) Stats() StatsSnapshot {
+	rte.Lock()
+	if rte.stats == nil {
+		rte.stats = NewStats(DefaultStatsWindowSecs)
+	}
+	stats := rte.stats
+	pool := rte.pool
+	rte.Unlock()
+	return withWorkers(stats.Snapshot(), pool)
+}
+
+// StatsWindow returns the route statistics aggregated over the most recent seconds of the rolling window
+func (rte *Route) StatsWindow(windowSecs int) StatsSnapshot {
+	rte.Lock()
+	if rte.stats == nil {
+		rte.stats = NewStats(DefaultStatsWindowSecs)
+	}
+	stats := rte.stats
+	pool := rte.pool
+	rte.Unlock()
+	return withWorkers(stats.SnapshotWindow(windowSecs), pool)
+}
+
+// withWorkers adds the current state of the worker pool of a running route to its statistics
+func withWorkers(snapshot StatsSnapshot, pool *workerPool) StatsSnapshot {
+	if pool != nil {
+		snapshot.Workers = pool.Size()
+		snapshot.WorkersBusy = pool.Busy()
+	}
+	return snapshot
+}
+
 func (rte *Route) Stop(ctx context.Context) error {
 	rte.Lock()
 	defer rte.Unlock()
@@ -80,7 +215,55 @@ func (rte *Route) Stop(ctx context.Context) error {
 	return err
 }
 
-func fanOut(events []event.Event, next receiver.NextFn, senderName string) error {
+type routeIdKey struct{}
+
+// NewContext returns a copy of ctx carrying the route id
+func NewContext(ctx context.Context, routeId string) context.Context {
+	return context.WithValue(ctx, routeIdKey{}, routeId)
+}
+
+// IdFromContext returns the id of the route an event is flowing through or an empty string if unknown
+func IdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(routeIdKey{}).(string)
+	return id
+}
+
+// withRouteId stamps the route id, the metric labels and the trace sampling of the route into the event context, each
+// route receives its own clone of an event
+func withRouteId(e event.Event, id string, metricLabels []attribute.KeyValue, sampling *TraceSamplingConfig) {
+	if id == "" || e == nil {
+		return
+	}
+	ctx := NewContext(e.Context(), id)
+	if len(metricLabels) > 0 {
+		ctx = context.WithValue(ctx, metricLabelsKey{}, metricLabels)
+	}
+	if sampling != nil {
+		ctx = context.WithValue(ctx, traceSamplingKey{}, sampling)
+	}
+	e.SetContext(ctx)
+}
+
+// withLineage stamps the receiver and route an event is flowing through into the lineage of the event and counts the hop
+func withLineage(e event.Event, id string, receiverName string, receiverPlugin string) {
+	if e == nil {
+		return
+	}
+	err := event.SetLineage(e, map[string]interface{}{
+		event.LineageReceiver:       receiverName,
+		event.LineageReceiverPlugin: receiverPlugin,
+		event.LineageRouteId:        id,
+		event.LineageHops:           event.LineageHopCount(e) + 1,
+	})
+	if err != nil {
+		log.Ctx(e.Context()).Error().Str("op", "withLineage").Str("error", err.Error()).Msg("cannot stamp lineage")
+	}
+}
+
+func fanOut(events []event.Event, next receiver.NextFn, senderName string, pool *workerPool, stats *Stats) error {
 	if next == nil {
 		return &InvalidRouteError{
 			Err: fmt.Errorf("next cannot be nil"),
@@ -89,21 +272,50 @@ func fanOut(events []event.Event, next receiver.NextFn, senderName string) error
 	if len(events) == 0 {
 		return nil
 	}
+	waited := 0
 	for _, e := range events {
-		go func(evt event.Event) {
-			defer func() {
-				p := recover()
-				if p != nil {
-					panicErr := panics.ToError(p)
-					log.Ctx(evt.Context()).Error().Str("op", "fanOutToSender").Str("error", panicErr.Error()).
-						Str("stackTrace", panicErr.StackTrace()).Msg("A panic has occurred")
-				}
-			}()
-			tracer := otel.Tracer(rtsemconv.EARSTracerName)
-			_, span := tracer.Start(evt.Context(), senderName)
-			next(evt)
-			span.End()
-		}(e)
+		evt := e
+		if pool.Go(func() { deliver(evt, next, senderName) }) {
+			waited++
+		}
+	}
+	if waited > 0 {
+		stats.waited(waited)
 	}
 	return nil
 }
+
+// deliver hands an event to the sender, recovering from panics of the sender
+func deliver(evt event.Event, next receiver.NextFn, senderName string) {
+	defer func() {
+		p := recover()
+		if p != nil {
+			panicErr := panics.ToError(p)
+			log.Ctx(evt.Context()).Error().Str("op", "fanOutToSender").Str("error", panicErr.Error()).
+				Str("stackTrace", panicErr.StackTrace()).Msg("A panic has occurred")
+			sysevent.Emit(sysevent.Event{
+				Type:    sysevent.TypePluginPanic,
+				Tenant:  evt.Tenant(),
+				RouteId: IdFromContext(evt.Context()),
+				Name:    senderName,
+				EventId: evt.Id(),
+				Error:   panicErr.Error(),
+				Stack:   panicErr.StackTrace(),
+			})
+			// the sender may not have gotten to ack or nack the event
+			evt.Nack(panicErr)
+		}
+	}()
+	tracer := otel.Tracer(rtsemconv.EARSTracerName)
+	_, span := tracer.Start(evt.Context(), senderName)
+	next(evt)
+	span.End()
+}
+
+// filterWithFlush passes an event through a filter, handing flush to filters that emit events on their own
+func filterWithFlush(f filter.Filterer, e event.Event, flush filter.FlushFn) []event.Event {
+	if fl, ok := f.(filter.Flusher); ok {
+		return fl.FilterWithFlush(e, flush)
+	}
+	return f.Filter(e)
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// receiverSampling keeps the trace sampling of the running routes by tenant and receiver. Events are sampled when they
+// are received at the highest ratio of the routes they may take, route spans are then sampled at the ratio of their
+// route among the sampled events, so that sampled route spans always have a sampled parent.
+type receiverSampling struct {
+	sync.RWMutex
+	routes map[receiverKey]map[*Route]*TraceSamplingConfig
+}
+
+type receiverKey struct {
+	tid  tenant.Id
+	name string
+}
+
+var traceSampling = &receiverSampling{routes: make(map[receiverKey]map[*Route]*TraceSamplingConfig)}
+
+func (s *receiverSampling) add(rte *Route, r receiver.Receiver) {
+	key := receiverKey{tid: rte.TenantId, name: r.Name()}
+	s.Lock()
+	defer s.Unlock()
+	if s.routes[key] == nil {
+		s.routes[key] = make(map[*Route]*TraceSamplingConfig)
+	}
+	s.routes[key][rte] = rte.TraceSampling
+}
+
+func (s *receiverSampling) remove(rte *Route, r receiver.Receiver) {
+	key := receiverKey{tid: rte.TenantId, name: r.Name()}
+	s.Lock()
+	defer s.Unlock()
+	delete(s.routes[key], rte)
+	if len(s.routes[key]) == 0 {
+		delete(s.routes, key)
+	}
+}
+
+// ReceiverTraceSampling returns the highest trace sampling ratio of the running routes of a tenant fed by a receiver,
+// inherit is true if one of these routes has no ratio of its own or if the receiver feeds no route
+func ReceiverTraceSampling(tid tenant.Id, receiverName string) (ratio float64, inherit bool) {
+	traceSampling.RLock()
+	defer traceSampling.RUnlock()
+	routes := traceSampling.routes[receiverKey{tid: tid, name: receiverName}]
+	if len(routes) == 0 {
+		return 0, true
+	}
+	for _, sampling := range routes {
+		if sampling == nil {
+			inherit = true
+		} else if sampling.Ratio > ratio {
+			ratio = sampling.Ratio
+		}
+	}
+	return ratio, inherit
+}
+
+type traceSamplingKey struct{}
+
+// TraceSamplingFromContext returns the trace sampling of the route an event is flowing through, nil if the route has
+// no sampling of its own or if the route is unknown
+func TraceSamplingFromContext(ctx context.Context) *TraceSamplingConfig {
+	if ctx == nil {
+		return nil
+	}
+	sampling, _ := ctx.Value(traceSamplingKey{}).(*TraceSamplingConfig)
+	return sampling
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+type samplingResult struct {
+	ratio   float64
+	inherit bool
+}
+
+func TestRouteReceiverTraceSampling(t *testing.T) {
+	tid := tenant.Id{OrgId: "myorg", AppId: "samplingapp"}
+	observed := make(chan samplingResult)
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mySamplingReceiver"
+		},
+		PluginFunc: func() string {
+			return "debug"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			ratio, inherit := route.ReceiverTraceSampling(tid, "mySamplingReceiver")
+			observed <- samplingResult{ratio, inherit}
+			return nil
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+	}
+	rte := &route.Route{Id: "samplingRoute", TenantId: tid, TraceSampling: &route.TraceSamplingConfig{Ratio: 0.25}}
+	done := make(chan error)
+	go func() {
+		done <- rte.Run(r, nil, s)
+	}()
+	got := <-observed
+	if got.ratio != 0.25 || got.inherit {
+		t.Errorf("expected ratio 0.25 without inheriting the tenant ratio, got %+v", got)
+	}
+	err := <-done
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	ratio, inherit := route.ReceiverTraceSampling(tid, "mySamplingReceiver")
+	if ratio != 0 || !inherit {
+		t.Errorf("expected the tenant ratio after the route stopped, got ratio %g inherit %t", ratio, inherit)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"sync"
+)
+
+const (
+	PriorityHigh   = "high"   // latency critical routes
+	PriorityNormal = "normal" // default priority
+	PriorityLow    = "low"    // bulk routes like backfills
+
+	DefaultSchedulerWorkers = 10000 // max number of events all routes of an instance deliver concurrently unless configured otherwise
+)
+
+// priorities in the order waiting routes are served, with the share of freed up workers each priority gets
+var priorities = []string{PriorityHigh, PriorityNormal, PriorityLow}
+var priorityWeights = []int{8, 4, 1}
+
+// ValidPriority returns true if the priority is empty or one of high, normal and low
+func ValidPriority(priority string) bool {
+	return priority == "" || priorityIndex(priority) >= 0
+}
+
+func priorityIndex(priority string) int {
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	for i, p := range priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return -1
+}
+
+// Scheduler bounds the number of events delivered concurrently by all routes of an instance on top of the worker
+// pool of each route. When all workers are busy, freed up workers are handed to waiting routes in proportion to the
+// weight of their priority (8:4:1 for high, normal and low) so that latency critical routes are not starved by bulk
+// routes while bulk routes still make progress. A nil scheduler does not limit anything.
+type Scheduler struct {
+	sync.Mutex
+	size    int
+	busy    int
+	waiters [][]chan struct{} // routes waiting for a worker by priority
+	credits []int             // workers each priority may still take before the others get their share
+}
+
+func NewScheduler(size int) *Scheduler {
+	if size <= 0 {
+		size = DefaultSchedulerWorkers
+	}
+	s := &Scheduler{
+		size:    size,
+		waiters: make([][]chan struct{}, len(priorities)),
+		credits: make([]int, len(priorities)),
+	}
+	copy(s.credits, priorityWeights)
+	return s
+}
+
+// acquire takes a worker, waiting for one if all are busy, and returns true if it had to wait
+func (s *Scheduler) acquire(priority string) bool {
+	if s == nil {
+		return false
+	}
+	idx := priorityIndex(priority)
+	if idx < 0 {
+		idx = priorityIndex(PriorityNormal)
+	}
+	s.Lock()
+	if s.busy < s.size {
+		s.busy++
+		s.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	s.waiters[idx] = append(s.waiters[idx], ready)
+	s.Unlock()
+	<-ready
+	return true
+}
+
+// release returns a worker, handing it straight to the next waiting route if there is one
+func (s *Scheduler) release() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if ready := s.next(); ready != nil {
+		close(ready)
+		return
+	}
+	s.busy--
+}
+
+// next removes the next waiting route by weighted round robin, must be called while holding the lock
+func (s *Scheduler) next() chan struct{} {
+	for round := 0; round < 2; round++ {
+		for i, waiting := range s.waiters {
+			if len(waiting) > 0 && s.credits[i] > 0 {
+				s.credits[i]--
+				ready := waiting[0]
+				waiting[0] = nil
+				s.waiters[i] = waiting[1:]
+				return ready
+			}
+		}
+		// every priority with waiting routes used up its share, start the next round
+		copy(s.credits, priorityWeights)
+	}
+	return nil
+}
+
+// Size returns the number of workers shared by all routes
+func (s *Scheduler) Size() int {
+	if s == nil {
+		return 0
+	}
+	return s.size
+}
+
+// Busy returns the number of shared workers currently delivering events
+func (s *Scheduler) Busy() int {
+	if s == nil {
+		return 0
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.busy
+}
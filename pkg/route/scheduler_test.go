@@ -0,0 +1,139 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRoutePriority(t *testing.T) {
+	ctx := context.Background()
+	scheduler := route.NewScheduler(1)
+	var lock sync.Mutex
+	var sent []string
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	run := func(id string, priority string, events int, block bool) {
+		wg.Add(events)
+		r := &receiver.ReceiverMock{
+			NameFunc: func() string {
+				return "mock"
+			},
+			PluginFunc: func() string {
+				return "mock"
+			},
+			ReceiveFunc: func(next receiver.NextFn) error {
+				for i := 0; i < events; i++ {
+					e, err := event.New(ctx, id, event.WithAck(
+						func(event.Event) { wg.Done() },
+						func(event.Event, error) { wg.Done() }))
+					if err != nil {
+						return err
+					}
+					next(e)
+				}
+				return nil
+			},
+		}
+		s := &sender.SenderMock{
+			NameFunc: func() string {
+				return "mock"
+			},
+			SendFunc: func(e event.Event) {
+				lock.Lock()
+				sent = append(sent, e.Payload().(string))
+				lock.Unlock()
+				if block {
+					<-release
+				}
+				e.Ack()
+			},
+		}
+		rte := &route.Route{Id: id, Priority: priority, Scheduler: scheduler}
+		go rte.Run(r, nil, s)
+	}
+	// the bulk route takes the only shared worker, then a low and a high priority route wait for it
+	run("bulk", route.PriorityLow, 1, true)
+	for scheduler.Busy() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	run("low", route.PriorityLow, 2, false)
+	time.Sleep(50 * time.Millisecond)
+	run("high", route.PriorityHigh, 1, false)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	lock.Lock()
+	defer lock.Unlock()
+	expected := []string{"bulk", "high", "low", "low"}
+	for i := range expected {
+		if i >= len(sent) || sent[i] != expected[i] {
+			t.Fatalf("unexpected delivery order: %v", sent)
+		}
+	}
+	if scheduler.Busy() != 0 {
+		t.Fatalf("shared workers still busy: %d", scheduler.Busy())
+	}
+}
+
+func TestRouteSenderPanic(t *testing.T) {
+	ctx := context.Background()
+	nacked := make(chan error, 1)
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			e, err := event.New(ctx, "panic", event.WithAck(
+				func(event.Event) { nacked <- nil },
+				func(_ event.Event, err error) { nacked <- err }))
+			if err != nil {
+				return err
+			}
+			next(e)
+			return nil
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			panic("sender panic")
+		},
+	}
+	// without a filter events go straight to the worker pool, a panicking sender must not crash the process
+	rte := &route.Route{Id: "panic"}
+	go rte.Run(r, nil, s)
+	select {
+	case err := <-nacked:
+		if err == nil {
+			t.Fatalf("event acked despite panicking sender")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event neither acked nor nacked")
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
+	"github.com/xmidt-org/ears/pkg/event"
+)
+
+const (
+	DefaultStatsWindowSecs = 60
+	maxLatencySamples      = 1024
+)
+
+// StatsSnapshot holds the runtime statistics of a route aggregated over a rolling window
+type StatsSnapshot struct {
+	WindowSecs     int          `json:"windowSecs"`
+	EventsReceived int64        `json:"eventsReceived"`
+	EventsPerSec   float64      `json:"eventsPerSec"`
+	FilterPassed   int64        `json:"filterPassed"`  // events emitted by the filter chain
+	FilterDropped  int64        `json:"filterDropped"` // received events the filter chain did not emit any event for
+	SendSuccess    int64        `json:"sendSuccess"`   // events acked by the sender
+	SendFailure    int64        `json:"sendFailure"`   // events nacked by the sender
+	AckLatencyMs   LatencyStats `json:"ackLatencyMs"`  // time from handing an event to the sender until it is acked or nacked
+	LastEvent      int64        `json:"lastEvent"`     // time of the last received event in unix milliseconds, zero if none
+	Workers        int          `json:"workers"`       // max number of events delivered concurrently
+	WorkersBusy    int          `json:"workersBusy"`   // number of events currently being delivered
+	WorkerWaits    int64        `json:"workerWaits"`   // events that had to wait for a free worker, a sign of saturation
+	Expired        int64        `json:"expired"`       // events dropped because their ttl expired before delivery
+	FailureStreak  int64        `json:"failureStreak"` // events nacked by the sender in a row since the last ack
+}
+
+type LatencyStats struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+type statsBucket struct {
+	ts            int64 // unix seconds
+	received      int64
+	filterPassed  int64
+	filterDropped int64
+	sendSuccess   int64
+	sendFailure   int64
+	waited        int64
+	expired       int64
+}
+
+type latencySample struct {
+	ts int64 // unix seconds
+	ms float64
+}
+
+// Stats collects route statistics in one second buckets covering the rolling window
+type Stats struct {
+	sync.Mutex
+	windowSecs    int
+	buckets       []statsBucket
+	latencies     []latencySample
+	nextSample    int
+	lastEvent     time.Time
+	failureStreak int64
+}
+
+func NewStats(windowSecs int) *Stats {
+	if windowSecs <= 0 {
+		windowSecs = DefaultStatsWindowSecs
+	}
+	return &Stats{
+		windowSecs: windowSecs,
+		buckets:    make([]statsBucket, windowSecs),
+		latencies:  make([]latencySample, 0, maxLatencySamples),
+	}
+}
+
+// bucket returns the bucket for the current second, must be called while holding the lock
+func (s *Stats) bucket(now time.Time) *statsBucket {
+	ts := now.Unix()
+	b := &s.buckets[ts%int64(s.windowSecs)]
+	if b.ts != ts {
+		*b = statsBucket{ts: ts}
+	}
+	return b
+}
+
+func (s *Stats) received() {
+	now := time.Now()
+	s.Lock()
+	defer s.Unlock()
+	s.bucket(now).received++
+	s.lastEvent = now
+}
+
+func (s *Stats) filtered(passed int) {
+	s.Lock()
+	defer s.Unlock()
+	b := s.bucket(time.Now())
+	if passed == 0 {
+		b.filterDropped++
+	} else {
+		b.filterPassed += int64(passed)
+	}
+}
+
+func (s *Stats) waited(n int) {
+	s.Lock()
+	defer s.Unlock()
+	s.bucket(time.Now()).waited += int64(n)
+}
+
+func (s *Stats) expired() {
+	s.Lock()
+	defer s.Unlock()
+	s.bucket(time.Now()).expired++
+}
+
+func (s *Stats) sent(start time.Time, success bool) {
+	now := time.Now()
+	s.Lock()
+	defer s.Unlock()
+	b := s.bucket(now)
+	if success {
+		b.sendSuccess++
+		s.failureStreak = 0
+	} else {
+		b.sendFailure++
+		s.failureStreak++
+	}
+	sample := latencySample{ts: now.Unix(), ms: float64(now.Sub(start).Microseconds()) / 1000.0}
+	if len(s.latencies) < maxLatencySamples {
+		s.latencies = append(s.latencies, sample)
+	} else {
+		s.latencies[s.nextSample] = sample
+		s.nextSample = (s.nextSample + 1) % maxLatencySamples
+	}
+}
+
+// Snapshot aggregates all buckets and latency samples within the rolling window
+func (s *Stats) Snapshot() StatsSnapshot {
+	return s.SnapshotWindow(s.windowSecs)
+}
+
+// SnapshotWindow aggregates all buckets and latency samples within the most recent seconds of the rolling window
+func (s *Stats) SnapshotWindow(windowSecs int) StatsSnapshot {
+	if windowSecs <= 0 || windowSecs > s.windowSecs {
+		windowSecs = s.windowSecs
+	}
+	now := time.Now().Unix()
+	snapshot := StatsSnapshot{WindowSecs: windowSecs}
+	latencies := make([]float64, 0)
+	s.Lock()
+	for _, b := range s.buckets {
+		if now-b.ts >= int64(windowSecs) {
+			continue
+		}
+		snapshot.EventsReceived += b.received
+		snapshot.FilterPassed += b.filterPassed
+		snapshot.FilterDropped += b.filterDropped
+		snapshot.SendSuccess += b.sendSuccess
+		snapshot.SendFailure += b.sendFailure
+		snapshot.WorkerWaits += b.waited
+		snapshot.Expired += b.expired
+	}
+	for _, l := range s.latencies {
+		if now-l.ts < int64(windowSecs) {
+			latencies = append(latencies, l.ms)
+		}
+	}
+	if !s.lastEvent.IsZero() {
+		snapshot.LastEvent = s.lastEvent.UnixNano() / int64(time.Millisecond)
+	}
+	snapshot.FailureStreak = s.failureStreak
+	s.Unlock()
+	snapshot.EventsPerSec = float64(snapshot.EventsReceived) / float64(windowSecs)
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		snapshot.AckLatencyMs = LatencyStats{
+			P50: percentile(latencies, 0.5),
+			P90: percentile(latencies, 0.9),
+			P99: percentile(latencies, 0.99),
+			Max: latencies[len(latencies)-1],
+		}
+	}
+	return snapshot
+}
+
+// percentile uses the nearest rank method on sorted values
+func percentile(sorted []float64, p float64) float64 {
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// statsEvent records the ack outcome and latency of an event handed to the sender and the errors of nacked events,
+// which are also reported as delivery failures
+type statsEvent struct {
+	event.Event
+	stats  *Stats
+	errors *ErrorLog     // records the errors of nacked events if set
+	name   string        // name of the sender
+	slow   time.Duration // threshold of slow event logging, disabled if zero
+	start  time.Time
+	once   sync.Once
+}
+
+func (e *statsEvent) Ack() {
+	e.once.Do(func() {
+		e.stats.sent(e.start, true)
+		if e.slow > 0 {
+			logSlowEvent(e.Event, e.name, e.start, e.slow, true)
+		}
+	})
+	e.Event.Ack()
+}
+
+func (e *statsEvent) Nack(err error) {
+	e.once.Do(func() {
+		e.stats.sent(e.start, false)
+		if e.slow > 0 {
+			logSlowEvent(e.Event, e.name, e.start, e.slow, false)
+		}
+		if e.errors != nil {
+			e.errors.record(ErrorSourceSender, e.Event, err)
+		}
+		sysEvent := sysevent.Event{
+			Type:    sysevent.TypeDeliveryFailure,
+			Tenant:  e.Tenant(),
+			RouteId: IdFromContext(e.Context()),
+			Name:    e.name,
+			EventId: e.Id(),
+		}
+		if err != nil {
+			sysEvent.Error = err.Error()
+		}
+		sysevent.Emit(sysEvent)
+	})
+	e.Event.Nack(err)
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/internal/pkg/sysevent"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/filter"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRouteStats(t *testing.T) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	payloads := []string{"ok", "ok", "fail", "drop"}
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			for _, p := range payloads {
+				e, err := event.New(ctx, p, event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			if e.Payload() == "drop" {
+				e.Ack()
+				return []event.Event{}
+			}
+			wg.Add(1)
+			return []event.Event{e}
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			defer wg.Done()
+			if e.Payload() == "fail" {
+				e.Nack(errors.New("send failed"))
+				return
+			}
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1"}
+	err := rte.Run(r, f, s)
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	wg.Wait()
+	stats := rte.Stats()
+	if stats.EventsReceived != 4 || stats.FilterPassed != 3 || stats.FilterDropped != 1 {
+		t.Fatalf("unexpected filter stats: %+v", stats)
+	}
+	if stats.SendSuccess != 2 || stats.SendFailure != 1 {
+		t.Fatalf("unexpected sender stats: %+v", stats)
+	}
+	if stats.LastEvent == 0 || stats.WindowSecs != route.DefaultStatsWindowSecs {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	stats = rte.StatsWindow(10)
+	if stats.WindowSecs != 10 || stats.EventsReceived != 4 || stats.EventsPerSec != 0.4 {
+		t.Fatalf("unexpected windowed stats: %+v", stats)
+	}
+}
+
+func TestRouteWorkers(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	done := make(chan struct{})
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			defer close(done)
+			for i := 0; i < 3; i++ {
+				e, err := event.New(ctx, i, event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			return []event.Event{e}
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			started <- struct{}{}
+			<-release
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1", Workers: 2}
+	go rte.Run(r, f, s)
+	// the third event has to wait until one of the two workers is done
+	<-started
+	<-started
+	stats := rte.Stats()
+	if stats.Workers != 2 || stats.WorkersBusy != 2 {
+		t.Fatalf("unexpected worker stats: %+v", stats)
+	}
+	select {
+	case <-started:
+		t.Fatalf("third event delivered despite all workers being busy")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-started
+	<-done
+	stats = rte.Stats()
+	if stats.WorkerWaits != 1 {
+		t.Fatalf("unexpected worker stats: %+v", stats)
+	}
+}
+
+func TestRouteDeliveryFailureEvents(t *testing.T) {
+	ctx := context.Background()
+	failures := make(chan sysevent.Event, 1)
+	sysevent.SetHandler(func(e sysevent.Event) {
+		failures <- e
+	})
+	defer sysevent.SetHandler(nil)
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			e, err := event.New(ctx, "fail", event.WithId("e1"), event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+			if err != nil {
+				return err
+			}
+			next(e)
+			return nil
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mySender"
+		},
+		SendFunc: func(e event.Event) {
+			e.Nack(errors.New("send failed"))
+		},
+	}
+	rte := &route.Route{Id: "r1"}
+	err := rte.Run(r, nil, s)
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	select {
+	case e := <-failures:
+		if e.Type != sysevent.TypeDeliveryFailure || e.RouteId != "r1" || e.Name != "mySender" || e.EventId != "e1" || e.Error != "send failed" {
+			t.Fatalf("unexpected delivery failure %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no delivery failure reported")
+	}
+}
+
+func TestRouteFailureStreak(t *testing.T) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	payloads := []string{"fail", "ok", "fail", "fail"}
+	wg.Add(len(payloads))
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			for _, p := range payloads {
+				e, err := event.New(ctx, p, event.WithAck(func(event.Event) {}, func(event.Event, error) {}))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	f := &filter.FiltererMock{
+		FilterFunc: func(e event.Event) []event.Event {
+			return []event.Event{e}
+		},
+	}
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			defer wg.Done()
+			if e.Payload() == "fail" {
+				e.Nack(errors.New("send failed"))
+				return
+			}
+			e.Ack()
+		},
+	}
+	// a single worker delivers the events in order
+	rte := &route.Route{Id: "r1", Workers: 1}
+	err := rte.Run(r, f, s)
+	if err != nil {
+		t.Fatalf("route run failed: %s", err.Error())
+	}
+	wg.Wait()
+	stats := rte.Stats()
+	if stats.FailureStreak != 2 || stats.SendFailure != 3 {
+		t.Fatalf("unexpected failure streak: %+v", stats)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/boriwo/deepcopy"
+	"github.com/xmidt-org/ears/pkg/event"
+)
+
+// TapFn receives a copy of an event handed to the sender of a route, it must not block
+type TapFn func(e event.Event)
+
+type tap struct {
+	sampleRate float64
+	fn         TapFn
+}
+
+// AddTap registers a function receiving copies of a random sample of the events handed to the sender of the route,
+// a sample rate of 1 taps every event; the returned function removes the tap again
+func (rte *Route) AddTap(sampleRate float64, fn TapFn) func() {
+	t := &tap{sampleRate: sampleRate, fn: fn}
+	rte.Lock()
+	if rte.taps == nil {
+		rte.taps = make(map[*tap]struct{})
+	}
+	rte.taps[t] = struct{}{}
+	rte.Unlock()
+	return func() {
+		rte.Lock()
+		delete(rte.taps, t)
+		rte.Unlock()
+	}
+}
+
+// tapEvent hands copies of an event to all taps sampling it
+func (rte *Route) tapEvent(e event.Event) {
+	rte.Lock()
+	if len(rte.taps) == 0 {
+		rte.Unlock()
+		return
+	}
+	fns := make([]TapFn, 0, len(rte.taps))
+	for t := range rte.taps {
+		if t.sampleRate >= 1 || rand.Float64() < t.sampleRate {
+			fns = append(fns, t.fn)
+		}
+	}
+	rte.Unlock()
+	for _, fn := range fns {
+		var metadata map[string]interface{}
+		if e.Metadata() != nil {
+			metadata, _ = deepcopy.DeepCopy(e.Metadata()).(map[string]interface{})
+		}
+		cpy, err := event.New(context.Background(), deepcopy.DeepCopy(e.Payload()), event.WithId(e.Id()), event.WithTenant(e.Tenant()), event.WithMetadata(metadata))
+		if err != nil {
+			continue
+		}
+		fn(cpy)
+	}
+}
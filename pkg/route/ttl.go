@@ -0,0 +1,103 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+)
+
+const DefaultTtlNanoFactor = 1000 * 1000 // numeric timestamps are in milliseconds unless configured otherwise
+
+// eventTtl decides whether an event has become too old to be delivered, for example after working off a backlog
+// accumulated during an outage
+type eventTtl struct {
+	ttl            time.Duration
+	path           string
+	nanoFactor     int64
+	expiredCounter metric.BoundInt64Counter
+}
+
+func newEventTtl(config *EventTtlConfig, routeId string, tid tenant.Id) *eventTtl {
+	if config == nil || config.TtlMs <= 0 {
+		return nil
+	}
+	nanoFactor := int64(config.NanoFactor)
+	if nanoFactor <= 0 {
+		nanoFactor = DefaultTtlNanoFactor
+	}
+	meter := global.Meter(rtsemconv.EARSMeterName)
+	return &eventTtl{
+		ttl:        time.Duration(config.TtlMs) * time.Millisecond,
+		path:       config.Path,
+		nanoFactor: nanoFactor,
+		expiredCounter: metric.Must(meter).
+			NewInt64Counter(
+				rtsemconv.EARSMetricRouteEventsExpired,
+				metric.WithDescription("measures the number of events of a route dropped because their ttl expired"),
+			).Bind(
+			rtsemconv.EARSRouteId.String(routeId),
+			attribute.String(rtsemconv.EARSAppIdLabel, tid.AppId),
+			attribute.String(rtsemconv.EARSOrgIdLabel, tid.OrgId),
+		),
+	}
+}
+
+// expired returns true if the event is older than the ttl. The age is measured from the timestamp at the configured
+// path or, if there is none or it cannot be parsed, from the time the event was received.
+func (t *eventTtl) expired(e event.Event) bool {
+	since := e.Created()
+	if t.path != "" {
+		if ts, ok := t.timestamp(e); ok {
+			since = ts
+		}
+	}
+	if time.Since(since) < t.ttl {
+		return false
+	}
+	t.expiredCounter.Add(context.Background(), 1)
+	log.Ctx(e.Context()).Info().Str("op", "route.ttl").Str("routeId", IdFromContext(e.Context())).Str("eventId", e.Id()).Msg("event ttl expired")
+	return true
+}
+
+// timestamp reads a numeric timestamp, converted with the nano factor, or an RFC 3339 timestamp
+func (t *eventTtl) timestamp(e event.Event) (time.Time, bool) {
+	obj, _, _ := e.GetPathValue(t.path)
+	switch ts := obj.(type) {
+	case float64:
+		return time.Unix(0, int64(ts*float64(t.nanoFactor))), true
+	case int:
+		return time.Unix(0, int64(ts)*t.nanoFactor), true
+	case int64:
+		return time.Unix(0, ts*t.nanoFactor), true
+	case string:
+		if n, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			return time.Unix(0, n*t.nanoFactor), true
+		}
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/receiver"
+	"github.com/xmidt-org/ears/pkg/route"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRouteTtl(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	payloads := []map[string]interface{}{
+		{"id": "fresh", "ts": float64(now.UnixNano() / 1e6)},
+		{"id": "stale", "ts": float64(old.UnixNano() / 1e6)},
+		{"id": "staleRfc3339", "ts": old.Format(time.RFC3339)},
+		{"id": "noTimestamp"},
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(payloads))
+	r := &receiver.ReceiverMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		PluginFunc: func() string {
+			return "mock"
+		},
+		ReceiveFunc: func(next receiver.NextFn) error {
+			for _, payload := range payloads {
+				e, err := event.New(ctx, payload, event.WithAck(
+					func(event.Event) { wg.Done() },
+					func(event.Event, error) { wg.Done() }))
+				if err != nil {
+					return err
+				}
+				next(e)
+			}
+			return nil
+		},
+	}
+	var lock sync.Mutex
+	sent := make(map[string]bool)
+	s := &sender.SenderMock{
+		NameFunc: func() string {
+			return "mock"
+		},
+		SendFunc: func(e event.Event) {
+			id, _, _ := e.GetPathValue("payload.id")
+			lock.Lock()
+			sent[id.(string)] = true
+			lock.Unlock()
+			e.Ack()
+		},
+	}
+	rte := &route.Route{Id: "r1", Ttl: &route.EventTtlConfig{TtlMs: 60000, Path: "payload.ts"}}
+	go rte.Run(r, nil, s)
+	wg.Wait()
+	lock.Lock()
+	defer lock.Unlock()
+	if len(sent) != 2 || !sent["fresh"] || !sent["noTimestamp"] {
+		t.Fatalf("unexpected events sent: %v", sent)
+	}
+	stats := rte.Stats()
+	if stats.Expired != 2 || stats.SendSuccess != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -18,19 +18,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/xmidt-org/ears/pkg/filter"
 	"github.com/xmidt-org/ears/pkg/hasher"
 	"github.com/xmidt-org/ears/pkg/receiver"
 	"github.com/xmidt-org/ears/pkg/sender"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const ROUTE_ID_REGEX = `^[a-zA-Z0-9][a-zA-Z0-9_\-\.]*[a-zA-Z0-9]$`
 const ROUTE_STATUS_RUNNING = "running"
 const ROUTE_STATUS_STOPPED = "stopped"
+const MAX_DELIVERY_ATTEMPTS = 10
 
 type Router interface {
 	Run(r receiver.Receiver, f filter.Filterer, s sender.Sender) error
@@ -40,9 +45,33 @@ type Router interface {
 type Route struct {
 	sync.Mutex
 
-	r receiver.Receiver
-	f filter.Filterer
-	s sender.Sender
+	Id       string          // route id, made available to filters and senders through the event context
+	TenantId tenant.Id       // tenant of the route, used to label route metrics
+	Workers  int             // max number of events delivered to the sender concurrently, DefaultWorkers if zero
+	OrderBy  string          // path of the key of events that are delivered strictly in order, unordered if empty
+	Ttl      *EventTtlConfig // stale events are dropped instead of delivered if set
+	Priority string          // priority of the route when competing for workers of the scheduler, PriorityNormal if empty
+	// labels added to the event metrics of the sender, see NewMetricLabels
+	MetricLabels []attribute.KeyValue
+	// ratio of events whose route spans are sampled, the ratio of the tenant applies if nil
+	TraceSampling *TraceSamplingConfig
+	// paths of payload values replaced before payloads are kept in the error log of the route
+	ErrorRedact []string
+	// events taking longer from receipt until the sender acks or nacks them are logged with the timings of their
+	// journey through the route, disabled if zero
+	SlowEventThreshold time.Duration
+	// interval synthetic heartbeat events are routed at, disabled if zero
+	HeartbeatInterval time.Duration
+	// workers shared by all routes of the instance, only the worker pool of the route limits deliveries if nil
+	Scheduler *Scheduler
+
+	r      receiver.Receiver
+	f      filter.Filterer
+	s      sender.Sender
+	stats  *Stats
+	errors *ErrorLog
+	taps   map[*tap]struct{}
+	pool   *workerPool
 }
 
 type InvalidRouteError struct {
@@ -56,23 +85,72 @@ type PluginConfig struct {
 	FragmentName string      `json:"fragmentName,omitempty"` // plugin reference id to load config as a fragment (optional)
 }
 
+// CloudEventsConfig configures the cloud events mode of a route
+type CloudEventsConfig struct {
+	Format string `json:"format,omitempty"` // format events are sent in, structured (default) or binary
+	Source string `json:"source,omitempty"` // source attribute template for events not received as cloud events, default ears/<orgId>/<appId>/<routeId>
+	Type   string `json:"type,omitempty"`   // type attribute template for events not received as cloud events, default ears.event
+}
+
+// DeliveryPolicyConfig configures the ack timeout and retries of events delivered to the sender of a route
+type DeliveryPolicyConfig struct {
+	AckTimeoutSeconds int    `json:"ackTimeoutSeconds,omitempty"` // seconds a delivery attempt may take before it counts as failed, default no timeout
+	MaxAttempts       int    `json:"maxAttempts,omitempty"`       // max delivery attempts including the first one, default 1
+	Backoff           string `json:"backoff,omitempty"`           // delay between attempts, constant (default) or exponential
+	BackoffMs         int    `json:"backoffMs,omitempty"`         // delay before the first retry in milliseconds, default 100
+	MaxBackoffMs      int    `json:"maxBackoffMs,omitempty"`      // max delay between retries in milliseconds, default 10000
+}
+
+// EventTtlConfig configures the max age of events delivered to the sender of a route
+type EventTtlConfig struct {
+	TtlMs      int    `json:"ttlMs,omitempty"`      // max age in milliseconds an event may have when it is handed to the sender
+	Path       string `json:"path,omitempty"`       // optional path of a timestamp the age is measured from, default time the event was received
+	NanoFactor int    `json:"nanoFactor,omitempty"` // factor converting a numeric timestamp to nanoseconds, default 1000000 (milliseconds)
+}
+
+// OrderingConfig configures ordered delivery of events sharing a key
+type OrderingConfig struct {
+	KeyPath string `json:"keyPath,omitempty"` // path of the key, e.g. payload.deviceId
+}
+
+// TraceSamplingConfig sets the ratio of events whose traces are sampled, overriding the ratio of the tenant
+type TraceSamplingConfig struct {
+	Ratio float64 `json:"ratio"` // between 0 (no traces) and 1 (all traces)
+}
+
+// HeartbeatConfig makes a route emit synthetic heartbeat events to prove it is alive end to end
+type HeartbeatConfig struct {
+	IntervalSecs int `json:"intervalSecs"` // interval heartbeat events are routed at, zero disables heartbeats
+}
+
 type Config struct {
-	Id           string         `json:"id,omitempty"`           // route ID
-	TenantId     tenant.Id      `json:"tenant,omitempty"`       // TenantId. Derived from URL path. Should not be marshaled
-	UserId       string         `json:"userId,omitempty"`       // user ID / author of route
-	Region       string         `json:"region,omitempty"`       // optional region of route for active-active scenarios - if present, route will only be active in a single region
-	Inactive     bool           `json:"inactive"`               // if true, route will not execute
-	Status       string         `json:"status,omitempty"`       // a route running on this instance will have status running, otherwise status will be stopped
-	Name         string         `json:"name,omitempty"`         // optional unique name for route
-	Desc         string         `json:"desc,omitempty"`         // optional description for route
-	Origin       string         `json:"origin,omitempty"`       // optional reference to route owner, e.g. Flow ID in case of Gears
-	Receiver     PluginConfig   `json:"receiver,omitempty"`     // source plugin configuration
-	Sender       PluginConfig   `json:"sender,omitempty"`       // destination plugin configuration
-	FilterChain  []PluginConfig `json:"filterChain,omitempty"`  // filter chain configuration
-	DeliveryMode string         `json:"deliveryMode,omitempty"` // possible values: fire_and_forget, at_least_once, exactly_once
-	Debug        bool           `json:"debug,omitempty"`        // if true generate debug logs and metrics for events taking this route
-	Created      int64          `json:"created,omitempty"`      // time on when route was created, in unix timestamp seconds
-	Modified     int64          `json:"modified,omitempty"`     // last time when route was modified, in unix timestamp seconds
+	Id             string                  `json:"id,omitempty"`             // route ID
+	TenantId       tenant.Id               `json:"tenant,omitempty"`         // TenantId. Derived from URL path. Should not be marshaled
+	UserId         string                  `json:"userId,omitempty"`         // user ID / author of route
+	Region         string                  `json:"region,omitempty"`         // optional region of route for active-active scenarios - if present, route will only be active in a single region
+	Inactive       bool                    `json:"inactive"`                 // if true, route will not execute
+	Status         string                  `json:"status,omitempty"`         // a route running on this instance will have status running, otherwise status will be stopped
+	Name           string                  `json:"name,omitempty"`           // optional unique name for route
+	Desc           string                  `json:"desc,omitempty"`           // optional description for route
+	Origin         string                  `json:"origin,omitempty"`         // optional reference to route owner, e.g. Flow ID in case of Gears
+	Labels         map[string]string       `json:"labels,omitempty"`         // optional labels for ownership and grouping of routes, e.g. team=xfi
+	Receiver       PluginConfig            `json:"receiver,omitempty"`       // source plugin configuration
+	Sender         PluginConfig            `json:"sender,omitempty"`         // destination plugin configuration
+	FilterChain    []PluginConfig          `json:"filterChain,omitempty"`    // filter chain configuration
+	DeadLetter     *PluginConfig           `json:"deadLetter,omitempty"`     // optional sender plugin configuration for events nacked by the sender
+	Branches       map[string]PluginConfig `json:"branches,omitempty"`       // optional sender plugin configurations for events tagged with a branch name
+	DeliveryMode   string                  `json:"deliveryMode,omitempty"`   // possible values: fire_and_forget, at_least_once, exactly_once
+	DeliveryPolicy *DeliveryPolicyConfig   `json:"deliveryPolicy,omitempty"` // optional ack timeout and retry policy for events delivered to the sender
+	Workers        int                     `json:"workers,omitempty"`        // optional max number of events delivered to the sender concurrently, default 1000
+	Priority       string                  `json:"priority,omitempty"`       // optional priority when competing with other routes for workers: high, normal (default) or low
+	Ordering       *OrderingConfig         `json:"ordering,omitempty"`       // optional, if present events sharing a key are sent strictly in order
+	Ttl            *EventTtlConfig         `json:"ttl,omitempty"`            // optional, if present events older than the ttl are dropped instead of delivered
+	CloudEvents    *CloudEventsConfig      `json:"cloudEvents,omitempty"`    // optional, if present all events taking this route are treated as cloud events
+	Debug          bool                    `json:"debug,omitempty"`          // if true generate debug logs and metrics for events taking this route
+	TraceSampling  *TraceSamplingConfig    `json:"traceSampling,omitempty"`  // optional, if present the ratio of events taking this route whose route spans are sampled
+	Heartbeat      *HeartbeatConfig        `json:"heartbeat,omitempty"`      // optional, if present overrides the interval of heartbeat events set by ears.heartbeat.intervalSecs
+	Created        int64                   `json:"created,omitempty"`        // time on when route was created, in unix timestamp seconds
+	Modified       int64                   `json:"modified,omitempty"`       // last time when route was modified, in unix timestamp seconds
 }
 
 //Validate returns an error if the plugin config is invalid and nil otherwise
@@ -108,6 +186,18 @@ func (rc *Config) Validate(ctx context.Context) error {
 			}
 		}
 	}
+	if rc.DeadLetter != nil {
+		err = rc.DeadLetter.Validate(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	for _, b := range rc.Branches {
+		err = b.Validate(ctx)
+		if err != nil {
+			return err
+		}
+	}
 	if rc.Id == "" {
 		return errors.New("missing ID for plugin configuration")
 	}
@@ -129,6 +219,42 @@ func (rc *Config) Validate(ctx context.Context) error {
 	if rc.UserId == "" {
 		return errors.New("missing user ID for plugin configuration")
 	}
+	err = ValidateLabels(rc.Labels)
+	if err != nil {
+		return err
+	}
+	if rc.Workers < 0 || rc.Workers > MaxWorkers {
+		return fmt.Errorf("workers must be between 0 and %d", MaxWorkers)
+	}
+	if dp := rc.DeliveryPolicy; dp != nil {
+		if dp.AckTimeoutSeconds < 0 || dp.MaxAttempts < 0 || dp.BackoffMs < 0 || dp.MaxBackoffMs < 0 {
+			return errors.New("delivery policy values must not be negative")
+		}
+		if dp.MaxAttempts > MAX_DELIVERY_ATTEMPTS {
+			return fmt.Errorf("delivery policy allows at most %d attempts", MAX_DELIVERY_ATTEMPTS)
+		}
+		if dp.Backoff != "" && dp.Backoff != sender.BackoffConstant && dp.Backoff != sender.BackoffExponential {
+			return errors.New("invalid delivery policy backoff " + dp.Backoff)
+		}
+	}
+	if !ValidPriority(rc.Priority) {
+		return errors.New("invalid priority " + rc.Priority)
+	}
+	if rc.Ordering != nil && rc.Ordering.KeyPath == "" {
+		return errors.New("missing ordering key path")
+	}
+	if rc.Ttl != nil && (rc.Ttl.TtlMs <= 0 || rc.Ttl.NanoFactor < 0) {
+		return errors.New("ttl must be positive")
+	}
+	if rc.CloudEvents != nil && rc.CloudEvents.Format != "" && rc.CloudEvents.Format != "structured" && rc.CloudEvents.Format != "binary" {
+		return errors.New("invalid cloud events format " + rc.CloudEvents.Format)
+	}
+	if rc.TraceSampling != nil && (rc.TraceSampling.Ratio < 0 || rc.TraceSampling.Ratio > 1) {
+		return errors.New("trace sampling ratio must be between 0 and 1")
+	}
+	if rc.Heartbeat != nil && rc.Heartbeat.IntervalSecs < 0 {
+		return errors.New("heartbeat interval must not be negative")
+	}
 	return nil
 }
 
@@ -160,6 +286,44 @@ func (pc *Config) Hash(ctx context.Context) string {
 			str += f.Hash(ctx)
 		}
 	}
+	if pc.DeadLetter != nil {
+		str += pc.DeadLetter.Hash(ctx)
+	}
+	if pc.Branches != nil {
+		names := make([]string, 0, len(pc.Branches))
+		for name := range pc.Branches {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b := pc.Branches[name]
+			str += name + b.Hash(ctx)
+		}
+	}
+	if pc.Workers > 0 {
+		str += fmt.Sprintf("w%d", pc.Workers)
+	}
+	if dp := pc.DeliveryPolicy; dp != nil {
+		str += fmt.Sprintf("dp%d/%d/%s/%d/%d", dp.AckTimeoutSeconds, dp.MaxAttempts, dp.Backoff, dp.BackoffMs, dp.MaxBackoffMs)
+	}
+	if pc.Priority != "" {
+		str += "p" + pc.Priority
+	}
+	if pc.Ordering != nil {
+		str += "ord" + pc.Ordering.KeyPath
+	}
+	if pc.Ttl != nil {
+		str += fmt.Sprintf("ttl%d/%s/%d", pc.Ttl.TtlMs, pc.Ttl.Path, pc.Ttl.NanoFactor)
+	}
+	if pc.CloudEvents != nil {
+		str += "ce" + pc.CloudEvents.Format + pc.CloudEvents.Source + pc.CloudEvents.Type
+	}
+	if pc.TraceSampling != nil {
+		str += fmt.Sprintf("ts%g", pc.TraceSampling.Ratio)
+	}
+	if pc.Heartbeat != nil {
+		str += fmt.Sprintf("hb%d", pc.Heartbeat.IntervalSecs)
+	}
 	hash := hasher.String(str)
 	return hash
 }
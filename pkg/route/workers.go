@@ -0,0 +1,122 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xmidt-org/ears/internal/pkg/rtsemconv"
+	"github.com/xmidt-org/ears/pkg/tenant"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+)
+
+const (
+	DefaultWorkers = 1000  // max number of events a route delivers concurrently unless configured otherwise
+	MaxWorkers     = 10000 // upper limit of the configurable number of workers of a route
+)
+
+// workerPool bounds the number of goroutines delivering events of a route to its sender. When all workers are busy
+// the receiver blocks until a worker frees up, which pushes back on the source instead of piling up goroutines.
+type workerPool struct {
+	slots        chan struct{}
+	scheduler    *Scheduler // workers shared with the other routes of the instance, nil if unlimited
+	priority     string
+	busy         int64
+	inFlight     sync.WaitGroup
+	busyCounter  metric.BoundInt64UpDownCounter
+	waitsCounter metric.BoundInt64Counter
+}
+
+func newWorkerPool(size int, routeId string, tid tenant.Id, scheduler *Scheduler, priority string) *workerPool {
+	if size <= 0 {
+		size = DefaultWorkers
+	}
+	meter := global.Meter(rtsemconv.EARSMeterName)
+	commonLabels := []attribute.KeyValue{
+		rtsemconv.EARSRouteId.String(routeId),
+		attribute.String(rtsemconv.EARSAppIdLabel, tid.AppId),
+		attribute.String(rtsemconv.EARSOrgIdLabel, tid.OrgId),
+	}
+	return &workerPool{
+		slots:     make(chan struct{}, size),
+		scheduler: scheduler,
+		priority:  priority,
+		busyCounter: metric.Must(meter).
+			NewInt64UpDownCounter(
+				rtsemconv.EARSMetricRouteWorkersBusy,
+				metric.WithDescription("measures the number of busy workers of a route"),
+			).Bind(commonLabels...),
+		waitsCounter: metric.Must(meter).
+			NewInt64Counter(
+				rtsemconv.EARSMetricRouteWorkersWaits,
+				metric.WithDescription("measures the number of events that had to wait for a free worker of a route"),
+			).Bind(commonLabels...),
+	}
+}
+
+// Go runs fn on a free worker, waiting for one if all are busy, and returns true if it had to wait
+func (p *workerPool) Go(fn func()) bool {
+	waited := false
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		waited = true
+		p.slots <- struct{}{}
+	}
+	if p.scheduler.acquire(p.priority) {
+		waited = true
+	}
+	if waited {
+		p.waitsCounter.Add(context.Background(), 1)
+	}
+	atomic.AddInt64(&p.busy, 1)
+	p.busyCounter.Add(context.Background(), 1)
+	p.inFlight.Add(1)
+	go func() {
+		defer func() {
+			atomic.AddInt64(&p.busy, -1)
+			p.busyCounter.Add(context.Background(), -1)
+			p.scheduler.release()
+			<-p.slots
+			p.inFlight.Done()
+		}()
+		fn()
+	}()
+	return waited
+}
+
+// stop unbinds the metrics of the pool once the events still in flight are delivered, the pool must not be used
+// afterwards
+func (p *workerPool) stop() {
+	go func() {
+		p.inFlight.Wait()
+		p.busyCounter.Unbind()
+		p.waitsCounter.Unbind()
+	}()
+}
+
+// Size returns the number of workers of the pool
+func (p *workerPool) Size() int {
+	return cap(p.slots)
+}
+
+// Busy returns the number of workers currently delivering events
+func (p *workerPool) Busy() int {
+	return int(atomic.LoadInt64(&p.busy))
+}
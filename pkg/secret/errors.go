@@ -0,0 +1,7 @@
+package secret
+
+import "github.com/xmidt-org/ears/pkg/errs"
+
+func (e *UnresolvedSecretError) Error() string {
+	return errs.String(e, map[string]interface{}{"key": e.Key}, nil)
+}
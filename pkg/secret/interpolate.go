@@ -0,0 +1,45 @@
+package secret
+
+import (
+	"github.com/xmidt-org/ears/pkg/config"
+	"regexp"
+)
+
+// referencePrefix starts a secret reference embedded in a string value
+const referencePrefix = "${" + Protocol
+
+var referenceRegex = regexp.MustCompile(`\$\{(secret://[^}]+)\}`)
+
+// Interpolate resolves all ${secret://...} references in the string values of a plugin config, a reference may
+// be the whole value or part of it, e.g. "amqp://ears:${secret://rabbitmq.password}@localhost". Configs without
+// references are returned unchanged, otherwise the resolved config is returned as a json string, which all plugins
+// accept in place of their config struct. The config is not modified.
+func Interpolate(vault Vault, cfg interface{}) (interface{}, error) {
+	return config.MapStringValues(cfg, referencePrefix, func(s string) (string, error) {
+		return interpolateString(vault, s)
+	})
+}
+
+// HasReferences returns true if a plugin config contains ${secret://...} references
+func HasReferences(cfg interface{}) bool {
+	return config.HasMarker(cfg, referencePrefix)
+}
+
+func interpolateString(vault Vault, s string) (string, error) {
+	var err error
+	res := referenceRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		key := referenceRegex.FindStringSubmatch(ref)[1]
+		val := ""
+		if vault != nil {
+			val = vault.Secret(key)
+		}
+		if val == "" && err == nil {
+			err = &UnresolvedSecretError{Key: key}
+		}
+		return val
+	})
+	if err != nil {
+		return "", err
+	}
+	return res, nil
+}
@@ -6,3 +6,14 @@ type Vault interface {
 	//Secret returns a secret given a key. Return an empty string if a secret is not found
 	Secret(key string) string
 }
+
+// UnresolvedSecretError is returned when a ${secret://...} reference in a plugin config cannot be resolved
+type UnresolvedSecretError struct {
+	Key string
+}
+
+// Resolver is implemented by plugins that resolve the secret references in their configs themselves, their configs
+// are handed to them with the references in place together with the vault to resolve them with
+type Resolver interface {
+	ResolvesSecrets() bool
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// DefaultBranchPath is the path the branch filter writes branch names to unless configured otherwise
+const DefaultBranchPath = "metadata.branch"
+
+// BranchSender wraps a sender and delivers events tagged with a branch name at the given path to
+// the sender registered for that branch. Events without a branch name or with an unknown branch
+// name are delivered to the wrapped sender.
+type BranchSender struct {
+	primary  Sender
+	branches map[string]Sender
+	path     string
+}
+
+func NewBranchSender(primary Sender, branches map[string]Sender, path string) *BranchSender {
+	return &BranchSender{
+		primary:  primary,
+		branches: branches,
+		path:     path,
+	}
+}
+
+func (s *BranchSender) Send(e event.Event) {
+	obj, _, _ := e.GetPathValue(s.path)
+	if name, ok := obj.(string); ok {
+		if branch, ok := s.branches[name]; ok {
+			branch.Send(e)
+			return
+		}
+	}
+	s.primary.Send(e)
+}
+
+func (s *BranchSender) Unwrap() Sender {
+	return s.primary
+}
+
+func (s *BranchSender) StopSending(ctx context.Context) {
+	s.primary.StopSending(ctx)
+	for _, branch := range s.branches {
+		branch.StopSending(ctx)
+	}
+}
+
+func (s *BranchSender) Branches() map[string]Sender {
+	return s.branches
+}
+
+func (s *BranchSender) Config() interface{} {
+	return s.primary.Config()
+}
+
+func (s *BranchSender) Name() string {
+	return s.primary.Name()
+}
+
+func (s *BranchSender) Plugin() string {
+	return s.primary.Plugin()
+}
+
+func (s *BranchSender) Tenant() tenant.Id {
+	return s.primary.Tenant()
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestBranchSender(t *testing.T) {
+	received := map[string]int{}
+	newSender := func(name string) *sender.SenderMock {
+		return &sender.SenderMock{
+			SendFunc: func(e event.Event) {
+				received[name]++
+				e.Ack()
+			},
+			NameFunc: func() string { return name },
+		}
+	}
+	primary := newSender("primary")
+	s := sender.NewBranchSender(primary, map[string]sender.Sender{
+		"alerts":  newSender("alerts"),
+		"metrics": newSender("metrics"),
+	}, "metadata.target")
+	if s.Unwrap() != primary {
+		t.Fatalf("branch sender should unwrap to primary sender")
+	}
+	ctx := context.Background()
+	for _, branch := range []string{"alerts", "metrics", "metrics", "unknown", ""} {
+		opts := []event.EventOption{event.FailOnNack(t)}
+		if branch != "" {
+			opts = append(opts, event.WithMetadataKeyValue("target", branch))
+		}
+		e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, opts...)
+		if err != nil {
+			t.Fatalf("cannot create event: %s", err.Error())
+		}
+		s.Send(e)
+	}
+	// branch names at other paths are ignored
+	e, err := event.New(ctx, map[string]interface{}{"foo": "bar"}, event.FailOnNack(t), event.WithMetadataKeyValue("branch", "alerts"))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+	expected := map[string]int{"alerts": 1, "metrics": 2, "primary": 3}
+	for name, cnt := range expected {
+		if received[name] != cnt {
+			t.Fatalf("expected %d events for %s but got %d", cnt, name, received[name])
+		}
+	}
+}
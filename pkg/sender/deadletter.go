@@ -0,0 +1,121 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+// DeadLetterSender wraps a sender and forwards every event the wrapped sender nacks to a
+// dead letter sender. The forwarded payload is an envelope holding the original payload
+// and the error that caused the nack:
+//
+//	{"payload": <original payload>, "error": {"message": "...", "sender": "...", "plugin": "...", "eventId": "...", "attempts": 1, "timestamp": 1620000000000}}
+//
+// The original event is acked once the dead letter sender acks the envelope and nacked if
+// the dead letter sender nacks it as well.
+type DeadLetterSender struct {
+	primary    Sender
+	deadLetter Sender
+}
+
+func NewDeadLetterSender(primary Sender, deadLetter Sender) *DeadLetterSender {
+	return &DeadLetterSender{
+		primary:    primary,
+		deadLetter: deadLetter,
+	}
+}
+
+func (s *DeadLetterSender) Send(e event.Event) {
+	s.primary.Send(&deadLetterEvent{Event: e, sender: s})
+}
+
+func (s *DeadLetterSender) forward(e event.Event, err error) {
+	log.Ctx(e.Context()).Warn().Str("op", "DeadLetterSender.forward").Str("sender", s.primary.Name()).
+		Str("deadLetterSender", s.deadLetter.Name()).Str("error", err.Error()).Msg("forwarding nacked event to dead letter sender")
+	attempts := 1
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		attempts = deliveryErr.Attempts
+		err = deliveryErr.Err
+	}
+	envelope := map[string]interface{}{
+		"payload": e.Payload(),
+		"error": map[string]interface{}{
+			"message":   err.Error(),
+			"sender":    s.primary.Name(),
+			"plugin":    s.primary.Plugin(),
+			"eventId":   e.Id(),
+			"attempts":  attempts,
+			"timestamp": time.Now().UnixNano() / int64(time.Millisecond),
+		},
+	}
+	setErr := e.SetPayload(envelope)
+	if setErr != nil {
+		e.Nack(err)
+		return
+	}
+	s.deadLetter.Send(e)
+}
+
+func (s *DeadLetterSender) Unwrap() Sender {
+	return s.primary
+}
+
+func (s *DeadLetterSender) StopSending(ctx context.Context) {
+	s.primary.StopSending(ctx)
+	s.deadLetter.StopSending(ctx)
+}
+
+func (s *DeadLetterSender) DeadLetter() Sender {
+	return s.deadLetter
+}
+
+func (s *DeadLetterSender) Config() interface{} {
+	return s.primary.Config()
+}
+
+func (s *DeadLetterSender) Name() string {
+	return s.primary.Name()
+}
+
+func (s *DeadLetterSender) Plugin() string {
+	return s.primary.Plugin()
+}
+
+func (s *DeadLetterSender) Tenant() tenant.Id {
+	return s.primary.Tenant()
+}
+
+// deadLetterEvent intercepts the nack of the primary sender and hands the event over to the
+// dead letter sender instead of failing it
+type deadLetterEvent struct {
+	event.Event
+	sender *DeadLetterSender
+	once   sync.Once
+}
+
+func (e *deadLetterEvent) Nack(err error) {
+	e.once.Do(func() {
+		e.sender.forward(e.Event, err)
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestDeadLetterSender(t *testing.T) {
+	primary := &sender.SenderMock{
+		SendFunc: func(e event.Event) {
+			if e.Payload().(map[string]interface{})["fail"] == true {
+				e.Nack(errors.New("boom"))
+				return
+			}
+			e.Ack()
+		},
+		NameFunc:   func() string { return "primary" },
+		PluginFunc: func() string { return "http" },
+	}
+	var deadLettered []interface{}
+	deadLetter := &sender.SenderMock{
+		SendFunc: func(e event.Event) {
+			deadLettered = append(deadLettered, e.Payload())
+			e.Ack()
+		},
+		NameFunc: func() string { return "dlq" },
+	}
+	s := sender.NewDeadLetterSender(primary, deadLetter)
+	if s.Unwrap() != primary {
+		t.Fatalf("dead letter sender should unwrap to primary sender")
+	}
+	ctx := context.Background()
+	// delivered events never reach the dead letter sender
+	e, err := event.New(ctx, map[string]interface{}{"fail": false}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+	if len(deadLettered) != 0 {
+		t.Fatalf("unexpected dead letter events %v", deadLettered)
+	}
+	// nacked events are acked once the dead letter sender accepts them
+	e, err = event.New(ctx, map[string]interface{}{"fail": true}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+	if len(deadLettered) != 1 {
+		t.Fatalf("expected one dead letter event but got %d", len(deadLettered))
+	}
+	envelope := deadLettered[0].(map[string]interface{})
+	if !reflect.DeepEqual(envelope["payload"], map[string]interface{}{"fail": true}) {
+		t.Fatalf("unexpected dead letter payload %v", envelope["payload"])
+	}
+	errInfo := envelope["error"].(map[string]interface{})
+	if errInfo["message"] != "boom" || errInfo["sender"] != "primary" || errInfo["plugin"] != "http" || errInfo["attempts"] != 1 || errInfo["eventId"] != e.Id() {
+		t.Fatalf("unexpected dead letter error %v", errInfo)
+	}
+	// events are only dead lettered after all delivery attempts failed
+	retried := make(chan interface{}, 1)
+	retrying := sender.NewDeadLetterSender(sender.NewRetrySender(primary, sender.RetryPolicy{MaxAttempts: 3}), &sender.SenderMock{
+		SendFunc: func(e event.Event) {
+			retried <- e.Payload()
+			e.Ack()
+		},
+		NameFunc: func() string { return "dlq" },
+	})
+	e, err = event.New(ctx, map[string]interface{}{"fail": true}, event.FailOnNack(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	retrying.Send(e)
+	select {
+	case payload := <-retried:
+		errInfo = payload.(map[string]interface{})["error"].(map[string]interface{})
+		if errInfo["message"] != "boom" || errInfo["attempts"] != 3 {
+			t.Fatalf("unexpected dead letter error %v", errInfo)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event was not dead lettered")
+	}
+	// events are nacked if the dead letter sender fails as well
+	deadLetter.SendFunc = func(e event.Event) {
+		e.Nack(errors.New("dlq unavailable"))
+	}
+	e, err = event.New(ctx, map[string]interface{}{"fail": true}, event.FailOnAck(t))
+	if err != nil {
+		t.Fatalf("cannot create event: %s", err.Error())
+	}
+	s.Send(e)
+}
@@ -23,3 +23,15 @@ func (e *InvalidConfigError) Unwrap() error {
 func (e *InvalidConfigError) Error() string {
 	return errs.String("InvalidConfigError", nil, e.Err)
 }
+
+func (e *AckTimeoutError) Error() string {
+	return errs.String("AckTimeoutError", map[string]interface{}{"timeout": e.Timeout.String()}, nil)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DeliveryError) Error() string {
+	return errs.String("DeliveryError", map[string]interface{}{"attempts": e.Attempts}, e.Err)
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/tenant"
+)
+
+const (
+	BackoffConstant    = "constant"
+	BackoffExponential = "exponential"
+)
+
+// RetryPolicy defines how often and how patiently a RetrySender tries to deliver an event
+type RetryPolicy struct {
+	AckTimeout     time.Duration // time a delivery attempt may take before it counts as failed, no timeout if zero
+	MaxAttempts    int           // max number of delivery attempts including the first one
+	Backoff        string        // constant or exponential
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound of the delay between retries
+}
+
+// Delay returns the delay before the retry following the given failed attempt
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	if p.Backoff == BackoffExponential {
+		for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+			delay *= 2
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// RetrySender wraps a sender and redelivers events the wrapped sender nacks or does not ack within the ack
+// timeout of the policy. The original event is acked as soon as any attempt is acked and nacked with the error
+// of the last attempt once all attempts failed or the event expired.
+type RetrySender struct {
+	primary Sender
+	policy  RetryPolicy
+}
+
+func NewRetrySender(primary Sender, policy RetryPolicy) *RetrySender {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return &RetrySender{
+		primary: primary,
+		policy:  policy,
+	}
+}
+
+func (s *RetrySender) Send(e event.Event) {
+	r := &retryEvent{Event: e, sender: s}
+	r.send()
+}
+
+func (s *RetrySender) Policy() RetryPolicy {
+	return s.policy
+}
+
+func (s *RetrySender) Unwrap() Sender {
+	return s.primary
+}
+
+func (s *RetrySender) StopSending(ctx context.Context) {
+	s.primary.StopSending(ctx)
+}
+
+func (s *RetrySender) Config() interface{} {
+	return s.primary.Config()
+}
+
+func (s *RetrySender) Name() string {
+	return s.primary.Name()
+}
+
+func (s *RetrySender) Plugin() string {
+	return s.primary.Plugin()
+}
+
+func (s *RetrySender) Tenant() tenant.Id {
+	return s.primary.Tenant()
+}
+
+// retryEvent tracks the delivery attempts of an event
+type retryEvent struct {
+	event.Event
+	sender  *RetrySender
+	lock    sync.Mutex
+	attempt int
+	done    bool
+	timer   *time.Timer
+}
+
+// retryAttempt is the event handed to the wrapped sender for a single delivery attempt
+type retryAttempt struct {
+	event.Event
+	retry   *retryEvent
+	attempt int
+}
+
+func (a *retryAttempt) Ack() {
+	a.retry.ack()
+}
+
+func (a *retryAttempt) Nack(err error) {
+	a.retry.nack(a.attempt, err)
+}
+
+func (r *retryEvent) send() {
+	r.lock.Lock()
+	if r.done {
+		r.lock.Unlock()
+		return
+	}
+	r.attempt++
+	attempt := r.attempt
+	if timeout := r.sender.policy.AckTimeout; timeout > 0 {
+		r.timer = time.AfterFunc(timeout, func() {
+			r.nack(attempt, &AckTimeoutError{Timeout: timeout})
+		})
+	}
+	r.lock.Unlock()
+	r.sender.primary.Send(&retryAttempt{Event: r.Event, retry: r, attempt: attempt})
+}
+
+func (r *retryEvent) ack() {
+	// a late ack of an earlier attempt still means the event got delivered
+	r.lock.Lock()
+	if r.done {
+		r.lock.Unlock()
+		return
+	}
+	r.done = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.lock.Unlock()
+	r.Event.Ack()
+}
+
+func (r *retryEvent) nack(attempt int, err error) {
+	r.lock.Lock()
+	if r.done || attempt != r.attempt {
+		r.lock.Unlock()
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if attempt >= r.sender.policy.MaxAttempts || r.Event.Context().Err() != nil {
+		r.done = true
+		r.lock.Unlock()
+		r.Event.Nack(&DeliveryError{Attempts: attempt, Err: err})
+		return
+	}
+	delay := r.sender.policy.Delay(attempt)
+	r.lock.Unlock()
+	log.Ctx(r.Event.Context()).Warn().Str("op", "RetrySender.nack").Str("sender", r.sender.Name()).Int("attempt", attempt).
+		Int64("delayMs", delay.Milliseconds()).Str("error", err.Error()).Msg("retrying nacked event")
+	time.AfterFunc(delay, r.send)
+}
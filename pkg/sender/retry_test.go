@@ -0,0 +1,128 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xmidt-org/ears/pkg/event"
+	"github.com/xmidt-org/ears/pkg/sender"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	constant := sender.RetryPolicy{Backoff: sender.BackoffConstant, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	exponential := sender.RetryPolicy{Backoff: sender.BackoffExponential, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	testCases := []struct {
+		policy   sender.RetryPolicy
+		attempt  int
+		expected time.Duration
+	}{
+		{constant, 1, 100 * time.Millisecond},
+		{constant, 5, 100 * time.Millisecond},
+		{exponential, 1, 100 * time.Millisecond},
+		{exponential, 3, 400 * time.Millisecond},
+		{exponential, 10, time.Second},
+	}
+	for _, tc := range testCases {
+		if d := tc.policy.Delay(tc.attempt); d != tc.expected {
+			t.Errorf("%s attempt %d: expected delay %s but got %s", tc.policy.Backoff, tc.attempt, tc.expected, d)
+		}
+	}
+}
+
+func TestRetrySender(t *testing.T) {
+	var attempts int32
+	var failures int32
+	primary := &sender.SenderMock{
+		SendFunc: func(e event.Event) {
+			n := atomic.AddInt32(&attempts, 1)
+			switch {
+			case n <= atomic.LoadInt32(&failures):
+				e.Nack(errors.New("boom"))
+			case e.Payload().(map[string]interface{})["hang"] == true:
+				// never ack to trigger the ack timeout
+			default:
+				e.Ack()
+			}
+		},
+		NameFunc: func() string { return "primary" },
+	}
+	policy := sender.RetryPolicy{
+		AckTimeout:     100 * time.Millisecond,
+		MaxAttempts:    3,
+		Backoff:        sender.BackoffExponential,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+	s := sender.NewRetrySender(primary, policy)
+	if s.Unwrap() != primary {
+		t.Fatalf("retry sender should unwrap to primary sender")
+	}
+	send := func(payload map[string]interface{}) error {
+		done := make(chan error, 1)
+		e, err := event.New(context.Background(), payload, event.WithAck(
+			func(e event.Event) {
+				done <- nil
+			},
+			func(e event.Event, err error) {
+				done <- err
+			}))
+		if err != nil {
+			t.Fatalf("cannot create event: %s", err.Error())
+		}
+		s.Send(e)
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event neither acked nor nacked")
+		}
+		return nil
+	}
+	// events are acked once a retry succeeds
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&failures, 2)
+	if err := send(map[string]interface{}{"foo": "bar"}); err != nil {
+		t.Fatalf("expected ack but got %s", err.Error())
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("expected 3 attempts but got %d", n)
+	}
+	// events are nacked with the last error once all attempts failed
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&failures, 5)
+	var deliveryErr *sender.DeliveryError
+	if err := send(map[string]interface{}{"foo": "bar"}); !errors.As(err, &deliveryErr) || deliveryErr.Attempts != 3 || deliveryErr.Err.Error() != "boom" {
+		t.Fatalf("expected delivery error after 3 attempts with boom but got %v", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("expected 3 attempts but got %d", n)
+	}
+	// attempts that are not acked in time count as failed
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&failures, 0)
+	err := send(map[string]interface{}{"hang": true})
+	var timeoutErr *sender.AckTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected ack timeout error but got %v", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("expected 3 attempts but got %d", n)
+	}
+}
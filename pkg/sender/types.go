@@ -19,6 +19,7 @@ import (
 	"github.com/xmidt-org/ears/pkg/event"
 	"github.com/xmidt-org/ears/pkg/secret"
 	"github.com/xmidt-org/ears/pkg/tenant"
+	"time"
 )
 
 //go:generate rm -f testing_mock.go
@@ -28,17 +29,40 @@ type InvalidConfigError struct {
 	Err error
 }
 
+// DeliveryError is the error of an event that could not be delivered in any of its delivery attempts
+type DeliveryError struct {
+	Attempts int
+	Err      error
+}
+
+// AckTimeoutError is the error of a delivery attempt that was neither acked nor nacked in time
+type AckTimeoutError struct {
+	Timeout time.Duration
+}
+
 type Hasher interface {
 	// SenderHash calculates the hash of a sender based on the given configuration
 	SenderHash(config interface{}) (string, error)
 }
 
+// ConfigValidator is implemented by senderers that can check a sender config
+// without creating the sender
+type ConfigValidator interface {
+	ValidateSenderConfig(config interface{}) error
+}
+
 type NewSenderer interface {
 	Hasher
 	// Returns an objec that implements the Sender interface
 	NewSender(tid tenant.Id, plugin string, name string, config interface{}, secrets secret.Vault) (Sender, error)
 }
 
+// HealthChecker is implemented by senders that can tell whether their connection to the target is alive,
+// the route supervisor restarts routes whose sender reports an error
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
 // or Outputter[√] or Producer[x] or Publisher[√]
 type Sender interface {
 	// Send consumes and event and sends it to the target
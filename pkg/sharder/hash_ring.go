@@ -0,0 +1,94 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharder
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultVirtualNodes is the number of points each node gets on the hash ring, more points spread keys more
+	// evenly across nodes
+	DefaultVirtualNodes = 128
+)
+
+// HashRing assigns keys to nodes by consistent hashing, when a node joins or leaves only the keys of that node
+// move, keys owned by the remaining nodes stay where they are
+type HashRing struct {
+	nodes  []string
+	points []uint64
+	owners map[uint64]string
+}
+
+// NewHashRing returns a hash ring of the given nodes with virtualNodes points per node
+func NewHashRing(nodes []string, virtualNodes int) *HashRing {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	ring := &HashRing{
+		owners: make(map[uint64]string),
+	}
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		if node == "" || seen[node] {
+			continue
+		}
+		seen[node] = true
+		ring.nodes = append(ring.nodes, node)
+		for i := 0; i < virtualNodes; i++ {
+			point := hashKey(node + "#" + strconv.Itoa(i))
+			// on the rare collision the node that sorts first wins so that all nodes build the same ring
+			if owner, ok := ring.owners[point]; ok && owner < node {
+				continue
+			} else if !ok {
+				ring.points = append(ring.points, point)
+			}
+			ring.owners[point] = node
+		}
+	}
+	sort.Strings(ring.nodes)
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+// Owner returns the node a key is assigned to, or a blank string if the ring has no nodes
+func (h *HashRing) Owner(key string) string {
+	if len(h.points) == 0 {
+		return ""
+	}
+	point := hashKey(key)
+	idx := sort.Search(len(h.points), func(i int) bool { return h.points[i] >= point })
+	if idx == len(h.points) {
+		idx = 0
+	}
+	return h.owners[h.points[idx]]
+}
+
+// Nodes returns the sorted nodes of the ring
+func (h *HashRing) Nodes() []string {
+	return h.nodes
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// fnv alone barely mixes the last bytes of similar keys into the high bits, finish with the splitmix64 mixer
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Comcast Cable Communications Management, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharder_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/xmidt-org/ears/pkg/sharder"
+)
+
+func TestHashRingDistribution(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c", "node-d"}
+	ring := sharder.NewHashRing(nodes, 0)
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[ring.Owner("org.app.route"+strconv.Itoa(i))]++
+	}
+	if len(counts) != len(nodes) {
+		t.Fatalf("expected keys on %d nodes, got %v", len(nodes), counts)
+	}
+	for node, cnt := range counts {
+		if cnt < 1500 || cnt > 3500 {
+			t.Errorf("uneven distribution, node %s owns %d of 10000 keys", node, cnt)
+		}
+	}
+}
+
+func TestHashRingMembershipChange(t *testing.T) {
+	before := sharder.NewHashRing([]string{"node-a", "node-b", "node-c"}, 0)
+	after := sharder.NewHashRing([]string{"node-c", "node-a", "node-b", "node-d"}, 0)
+	moved := 0
+	for i := 0; i < 10000; i++ {
+		key := "org.app.route" + strconv.Itoa(i)
+		if before.Owner(key) != after.Owner(key) {
+			if after.Owner(key) != "node-d" {
+				t.Fatalf("key %s moved from %s to %s instead of the new node", key, before.Owner(key), after.Owner(key))
+			}
+			moved++
+		}
+	}
+	if moved == 0 || moved > 4000 {
+		t.Errorf("unexpected number of moved keys %d", moved)
+	}
+}
+
+func TestHashRingEmpty(t *testing.T) {
+	ring := sharder.NewHashRing(nil, 0)
+	if ring.Owner("key") != "" {
+		t.Errorf("expected no owner")
+	}
+	ring = sharder.NewHashRing([]string{"node-a", "node-a", ""}, 4)
+	if len(ring.Nodes()) != 1 || ring.Owner("key") != "node-a" {
+		t.Errorf("unexpected ring nodes %v", ring.Nodes())
+	}
+}
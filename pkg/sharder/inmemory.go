@@ -27,7 +27,7 @@ func newInMemoryNodeManager(identity string, config StorageConfig) (*inmemoryNod
 }
 
 func (d *inmemoryNodeManager) GetActiveNodes() ([]string, error) {
-	return []string{"localhost"}, nil
+	return []string{d.identity}, nil
 }
 
 func (d *inmemoryNodeManager) RemoveNode() {
@@ -38,3 +38,58 @@ func TestTenantId(t *testing.T) {
 	g.Assert(t, "string", []byte(id1.ToString()))
 	g.Assert(t, "keyRoute", []byte(id1.KeyWithRoute("routeId")))
 }
+
+func TestQuotaValid(t *testing.T) {
+	testCases := []struct {
+		quota tenant.Quota
+		valid bool
+	}{
+		{tenant.Quota{EventsPerSec: 10}, true},
+		{tenant.Quota{EventsPerSec: 10, Burst: 50}, true},
+		{tenant.Quota{EventsPerSec: 10, Burst: 10}, true},
+		{tenant.Quota{EventsPerSec: 10, Burst: 5}, false},
+		{tenant.Quota{EventsPerSec: -1}, false},
+		{tenant.Quota{EventsPerSec: 10, Burst: -1}, false},
+	}
+	for _, tc := range testCases {
+		if tc.quota.Valid() != tc.valid {
+			t.Errorf("Expect quota %+v valid=%t", tc.quota, tc.valid)
+		}
+	}
+}
+
+func TestQuotaAlertValid(t *testing.T) {
+	testCases := []struct {
+		alert tenant.QuotaAlert
+		valid bool
+	}{
+		{tenant.QuotaAlert{Thresholds: []int{80, 95}, Url: "http://localhost/alerts"}, true},
+		{tenant.QuotaAlert{Thresholds: []int{80}, RouteId: "alerts"}, true},
+		{tenant.QuotaAlert{Thresholds: []int{80}}, false},
+		{tenant.QuotaAlert{RouteId: "alerts"}, false},
+		{tenant.QuotaAlert{Thresholds: []int{0}, RouteId: "alerts"}, false},
+	}
+	for _, tc := range testCases {
+		if tc.alert.Valid() != tc.valid {
+			t.Errorf("Expect quota alert %+v valid=%t", tc.alert, tc.valid)
+		}
+	}
+}
+
+func TestTraceSamplingValid(t *testing.T) {
+	testCases := []struct {
+		sampling tenant.TraceSampling
+		valid    bool
+	}{
+		{tenant.TraceSampling{Ratio: 0}, true},
+		{tenant.TraceSampling{Ratio: 0.5}, true},
+		{tenant.TraceSampling{Ratio: 1}, true},
+		{tenant.TraceSampling{Ratio: -0.1}, false},
+		{tenant.TraceSampling{Ratio: 1.5}, false},
+	}
+	for _, tc := range testCases {
+		if tc.sampling.Valid() != tc.valid {
+			t.Errorf("Expect trace sampling %+v valid=%t", tc.sampling, tc.valid)
+		}
+	}
+}
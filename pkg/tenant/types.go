@@ -25,6 +25,13 @@ const (
 	ORG_ID_REGEX = `^[a-zA-Z0-9][a-zA-Z0-9_\-]*[a-zA-Z0-9]$`
 )
 
+// roles granted to callers by a tenant's role mappings, each role includes the permissions of the previous one
+const (
+	ROLE_READER = "reader" // read access to routes, fragments and tenant config
+	ROLE_WRITER = "writer" // may also add, update and remove routes and fragments and send events
+	ROLE_ADMIN  = "admin"  // may also update and delete the tenant config
+)
+
 type Id struct {
 	OrgId string `json:"orgId,omitempty"`
 	AppId string `json:"appId,omitempty"`
@@ -56,15 +63,70 @@ func (id Id) ToString() string {
 }
 
 type Config struct {
-	Tenant       Id       `json:"tenant"`                 // tenant id
-	Quota        Quota    `json:"quota"`                  // tenant quota
-	ClientIds    []string `json:"clientIds,omitempty"`    // jwt subjects or client IDs
-	OpenEventApi bool     `json:"openEventApi,omitempty"` // if true, allow unauthenticated calls to the event API for routes under that tenant
-	Modified     int64    `json:"modified,omitempty"`     // last time when the tenant config is modified
+	Tenant        Id             `json:"tenant"`                  // tenant id
+	Quota         Quota          `json:"quota"`                   // tenant quota
+	ClientIds     []string       `json:"clientIds,omitempty"`     // jwt subjects or client IDs
+	OpenEventApi  bool           `json:"openEventApi,omitempty"`  // if true, allow unauthenticated calls to the event API for routes under that tenant
+	RoleMappings  []RoleMapping  `json:"roleMappings,omitempty"`  // optional mapping of jwt claims to roles, if present callers need a role sufficient for the API
+	QuotaAlert    *QuotaAlert    `json:"quotaAlert,omitempty"`    // optional alert fired when the quota utilization crosses a threshold
+	TraceSampling *TraceSampling `json:"traceSampling,omitempty"` // optional ratio of events of the tenant whose traces are sampled
+	Modified      int64          `json:"modified,omitempty"`      // last time when the tenant config is modified
+}
+
+// RoleMapping grants a role to callers whose jwt claim contains the given value, string claims are split into
+// space separated values (as in the scope claim) and string array claims are matched element by element
+type RoleMapping struct {
+	Claim string `json:"claim"` // jwt claim, e.g. sub, groups or scope
+	Value string `json:"value"` // claim value granting the role
+	Role  string `json:"role"`  // reader, writer or admin
 }
 
+// ValidRole returns true if the role is one of reader, writer or admin
+func ValidRole(role string) bool {
+	return role == ROLE_READER || role == ROLE_WRITER || role == ROLE_ADMIN
+}
+
+// Quota is a token bucket refilled at the sustained rate of EventsPerSec, holding up to Burst events so that a
+// tenant can exceed its sustained rate for a short time after being idle
 type Quota struct {
-	EventsPerSec int `json:"eventsPerSec"`
+	EventsPerSec int `json:"eventsPerSec"`    // sustained rate
+	Burst        int `json:"burst,omitempty"` // bucket capacity, same as EventsPerSec if 0
+}
+
+// Valid returns true if the quota has no negative values and the burst is not below the sustained rate
+func (q Quota) Valid() bool {
+	return q.EventsPerSec >= 0 && q.Burst >= 0 && (q.Burst == 0 || q.Burst >= q.EventsPerSec)
+}
+
+// QuotaAlert warns a tenant before its events get throttled. Whenever the utilization of the quota rises above one of
+// the thresholds, an alert is posted to the url and sent as an event to the route of the tenant, whichever is set.
+type QuotaAlert struct {
+	Thresholds []int  `json:"thresholds"`        // utilization in percent of eventsPerSec, e.g. 80 and 95
+	Url        string `json:"url,omitempty"`     // webhook the alert is posted to
+	RouteId    string `json:"routeId,omitempty"` // route of the tenant the alert is sent to
+}
+
+// Valid returns true if the alert has at least one positive threshold and somewhere to send the alert to
+func (a QuotaAlert) Valid() bool {
+	if len(a.Thresholds) == 0 || (a.Url == "" && a.RouteId == "") {
+		return false
+	}
+	for _, threshold := range a.Thresholds {
+		if threshold <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceSampling sets the ratio of events whose traces are sampled, overriding ears.opentelemetry.sampling.ratio
+type TraceSampling struct {
+	Ratio float64 `json:"ratio"` // between 0 (no traces) and 1 (all traces)
+}
+
+// Valid returns true if the ratio is between 0 and 1
+func (s TraceSampling) Valid() bool {
+	return s.Ratio >= 0 && s.Ratio <= 1
 }
 
 type TenantStorer interface {